@@ -59,6 +59,12 @@ type HealthTestDI struct {
 	MockedIndicator *testdata.MockedHealthIndicator
 }
 
+type HealthGroupTestDI struct {
+	fx.In
+	HealthTestDI
+	Availability *health.ApplicationAvailability
+}
+
 /*************************
 	Tests
  *************************/
@@ -114,6 +120,29 @@ func TestHealthWithCustomDisclosure(t *testing.T) {
 	)
 }
 
+func TestHealthGroups(t *testing.T) {
+	di := &HealthGroupTestDI{}
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		webtest.WithMockedServer(webtest.AddDefaultRequestOptions(v3RequestOptions())),
+		sectest.WithMockedMiddleware(),
+		actuatortest.WithEndpoints(actuatortest.DisableAllEndpoints()),
+		apptest.WithModules(health.Module, healthep.Module),
+		apptest.WithConfigFS(testdata.TestConfigFS),
+		apptest.WithProperties(
+			"management.endpoint.health.groups.custom.include: test, readinessState",
+		),
+		apptest.WithFxOptions(
+			fx.Provide(testdata.NewMockedHealthIndicator),
+			fx.Invoke(ConfigureHealth),
+		),
+		apptest.WithDI(di),
+		test.GomegaSubTest(SubTestAvailabilityGroups(di), "TestAvailabilityGroups"),
+		test.GomegaSubTest(SubTestCustomHealthGroup(di, mockedSecurityAdmin()), "TestCustomHealthGroup"),
+		test.GomegaSubTest(SubTestUnknownHealthGroup(), "TestUnknownHealthGroup"),
+	)
+}
+
 /*************************
 	Sub Tests
  *************************/
@@ -278,3 +307,72 @@ func assertHealth(t *testing.T, g *gomega.WithT, h health.Health, expected healt
 		}
 	}
 }
+
+func SubTestAvailabilityGroups(di *HealthGroupTestDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		defer func() {
+			di.Availability.SetLiveness(health.LivenessCorrect)
+			di.Availability.SetReadiness(health.ReadinessAcceptingTraffic)
+		}()
+		// application started
+		g.Expect(di.Availability.Readiness()).To(Equal(health.ReadinessAcceptingTraffic), "readiness should be accepting traffic after started")
+		for _, group := range []string{"liveness", "readiness"} {
+			req := webtest.NewRequest(ctx, http.MethodGet, "/admin/health/"+group, nil)
+			resp := webtest.MustExec(ctx, req)
+			assertResponse(t, g, resp.Response, http.StatusOK, "Content-Type", actuator.ContentTypeSpringBootV3)
+			AssertHealthResponse(t, resp.Response)
+		}
+
+		// refusing traffic
+		di.Availability.SetReadiness(health.ReadinessRefusingTraffic)
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/health/readiness", nil)
+		resp := webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusServiceUnavailable, "Content-Type", actuator.ContentTypeSpringBootV3)
+		AssertHealthResponse(t, resp.Response, ExpectHealth(health.StatusOutOfService))
+
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/health/liveness", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusOK, "Content-Type", actuator.ContentTypeSpringBootV3)
+		AssertHealthResponse(t, resp.Response)
+
+		// broken
+		di.Availability.SetLiveness(health.LivenessBroken)
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/health/liveness", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusServiceUnavailable, "Content-Type", actuator.ContentTypeSpringBootV3)
+		AssertHealthResponse(t, resp.Response, ExpectHealth(health.StatusDown))
+
+		// system health is not affected
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/health", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusOK, "Content-Type", actuator.ContentTypeSpringBootV3)
+		AssertHealthResponse(t, resp.Response)
+	}
+}
+
+func SubTestCustomHealthGroup(di *HealthGroupTestDI, secOpts sectest.SecurityContextOptions) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		defer func() {
+			di.MockedIndicator.Status = health.StatusUp
+		}()
+		ctx = sectest.ContextWithSecurity(ctx, secOpts)
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/health/custom", nil)
+		resp := webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusOK, "Content-Type", actuator.ContentTypeSpringBootV3)
+		AssertHealthResponse(t, resp.Response, ExpectHealthDetails(), ExpectHealthComponents("test", health.IndicatorNameReadiness))
+
+		di.MockedIndicator.Status = health.StatusDown
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/health/custom", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusServiceUnavailable, "Content-Type", actuator.ContentTypeSpringBootV3)
+		AssertHealthResponse(t, resp.Response, ExpectHealth(health.StatusDown), ExpectHealthDetails(), ExpectHealthComponents("test"))
+	}
+}
+
+func SubTestUnknownHealthGroup() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/health/unknown", nil)
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusNotFound), "unknown health group should return 404")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package actuator_tests
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/actuator/actuator_tests/testdata"
+	metricsep "github.com/cisco-open/go-lanai/pkg/actuator/metrics"
+	prometheusep "github.com/cisco-open/go-lanai/pkg/actuator/prometheus"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/actuatortest"
+	"github.com/cisco-open/go-lanai/test/apptest"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	. "github.com/cisco-open/go-lanai/test/utils/gomega"
+	"github.com/cisco-open/go-lanai/test/webtest"
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"io"
+	"net/http"
+	"testing"
+)
+
+/*************************
+	Tests
+ *************************/
+
+func TestMetricsEndpoints(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		webtest.WithMockedServer(webtest.AddDefaultRequestOptions(v3RequestOptions())),
+		sectest.WithMockedMiddleware(),
+		actuatortest.WithEndpoints(actuatortest.DisableAllEndpoints()),
+		apptest.WithModules(metrics.Module, metricsep.Module, prometheusep.Module),
+		apptest.WithConfigFS(testdata.TestConfigFS),
+		apptest.WithFxOptions(fx.Invoke(recordTestMetrics)),
+		test.GomegaSubTest(SubTestMetricsWithAccess(mockedSecurityAdmin()), "TestMetricsWithAccess"),
+		test.GomegaSubTest(SubTestMetricsByName(mockedSecurityAdmin()), "TestMetricsByName"),
+		test.GomegaSubTest(SubTestPrometheusWithAccess(mockedSecurityAdmin()), "TestPrometheusWithAccess"),
+		test.GomegaSubTest(SubTestMetricsWithoutAccess(mockedSecurityNonAdmin()), "TestMetricsWithoutAccess"),
+		test.GomegaSubTest(SubTestMetricsWithoutAuth(), "TestMetricsWithoutAuth"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestMetricsWithAccess(secOpts sectest.SecurityContextOptions) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		ctx = sectest.ContextWithSecurity(ctx, secOpts)
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/metrics", nil)
+		resp := webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusOK)
+		body, e := io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed(), "response body should be readable")
+		g.Expect(body).To(HaveJsonPathWithValue("$.names[*]", ContainElements("test.requests", "test.active")),
+			"metrics response should contain registered meters")
+	}
+}
+
+func SubTestMetricsByName(secOpts sectest.SecurityContextOptions) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		ctx = sectest.ContextWithSecurity(ctx, secOpts)
+		// without tag filter
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/metrics/test.requests", nil)
+		resp := webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusOK)
+		body, e := io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed(), "response body should be readable")
+		g.Expect(body).To(HaveJsonPathWithValue("$.name", "test.requests"), "metric should have correct name")
+		g.Expect(body).To(HaveJsonPathWithValue("$.measurements[0].value", ContainElement(BeNumerically("==", 3))), "metric should have correct count")
+		g.Expect(body).To(HaveJsonPathWithValue("$.availableTags[0].values[*]", ConsistOf("a", "b")), "metric should have correct tags")
+
+		// with tag filter
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/metrics/test.requests?tag=kind:a", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusOK)
+		body, e = io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed(), "response body should be readable")
+		g.Expect(body).To(HaveJsonPathWithValue("$.measurements[0].value", ContainElement(BeNumerically("==", 2))), "filtered metric should have correct count")
+
+		// not found
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/metrics/test.non-existing", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusNotFound)
+	}
+}
+
+func SubTestPrometheusWithAccess(secOpts sectest.SecurityContextOptions) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		ctx = sectest.ContextWithSecurity(ctx, secOpts)
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/prometheus", nil, func(req *http.Request) {
+			req.Header.Set("Accept", "text/plain")
+		})
+		resp := webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusOK)
+		g.Expect(resp.Response.Header.Get("Content-Type")).To(HavePrefix("text/plain"), "response should be text")
+		body, e := io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed(), "response body should be readable")
+		g.Expect(string(body)).To(ContainSubstring(`test_requests_total{`), "response should contain counter")
+		g.Expect(string(body)).To(ContainSubstring(`test_active 5`), "response should contain gauge")
+	}
+}
+
+func SubTestMetricsWithoutAccess(secOpts sectest.SecurityContextOptions) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		ctx = sectest.ContextWithSecurity(ctx, secOpts)
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/metrics", nil)
+		resp := webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusForbidden)
+
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/prometheus", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusForbidden)
+	}
+}
+
+func SubTestMetricsWithoutAuth() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		req := webtest.NewRequest(ctx, http.MethodGet, "/admin/metrics", nil)
+		resp := webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusUnauthorized)
+
+		req = webtest.NewRequest(ctx, http.MethodGet, "/admin/prometheus", nil)
+		resp = webtest.MustExec(ctx, req)
+		assertResponse(t, g, resp.Response, http.StatusUnauthorized)
+	}
+}
+
+/*************************
+	Common Helpers
+ *************************/
+
+func recordTestMetrics(reg metrics.Registry) {
+	counter := reg.Counter("test.requests", metrics.WithTags("kind"))
+	counter.Inc(metrics.Tags{"kind": "a"})
+	counter.Inc(metrics.Tags{"kind": "a"})
+	counter.Inc(metrics.Tags{"kind": "b"})
+	reg.Gauge("test.active").Set(5, nil)
+}
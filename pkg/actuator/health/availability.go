@@ -0,0 +1,168 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"go.uber.org/fx"
+	"sync/atomic"
+)
+
+const (
+	IndicatorNameLiveness  = "livenessState"
+	IndicatorNameReadiness = "readinessState"
+)
+
+const (
+	// LivenessCorrect the application is running and its internal state is correct.
+	LivenessCorrect LivenessState = iota
+	// LivenessBroken the application is running but its internal state is broken. Restarting is required
+	LivenessBroken
+)
+
+// LivenessState tells whether the application is in a correct internal state
+type LivenessState int32
+
+// fmt.Stringer
+func (s LivenessState) String() string {
+	switch s {
+	case LivenessBroken:
+		return "BROKEN"
+	default:
+		return "CORRECT"
+	}
+}
+
+const (
+	// ReadinessRefusingTraffic the application is not willing to receive traffic. e.g. starting up or shutting down
+	ReadinessRefusingTraffic ReadinessState = iota
+	// ReadinessAcceptingTraffic the application is ready to receive traffic
+	ReadinessAcceptingTraffic
+)
+
+// ReadinessState tells whether the application is ready to accept traffic
+type ReadinessState int32
+
+// fmt.Stringer
+func (s ReadinessState) String() string {
+	switch s {
+	case ReadinessAcceptingTraffic:
+		return "ACCEPTING_TRAFFIC"
+	default:
+		return "REFUSING_TRAFFIC"
+	}
+}
+
+// ApplicationAvailability holds the liveness and readiness state of the application.
+// Readiness is ReadinessRefusingTraffic until the application is fully started,
+// and it's switched back to ReadinessRefusingTraffic at the beginning of graceful shutdown
+// (see bootstrap.ShutdownPhaseRefuseTraffic).
+// The states are reported by "livenessState" and "readinessState" indicators, which are included in default
+// "liveness" and "readiness" health groups.
+type ApplicationAvailability struct {
+	liveness  atomic.Int32
+	readiness atomic.Int32
+}
+
+func NewApplicationAvailability() *ApplicationAvailability {
+	return &ApplicationAvailability{}
+}
+
+func (a *ApplicationAvailability) Liveness() LivenessState {
+	return LivenessState(a.liveness.Load())
+}
+
+func (a *ApplicationAvailability) SetLiveness(state LivenessState) {
+	a.liveness.Store(int32(state))
+}
+
+func (a *ApplicationAvailability) Readiness() ReadinessState {
+	return ReadinessState(a.readiness.Load())
+}
+
+func (a *ApplicationAvailability) SetReadiness(state ReadinessState) {
+	a.readiness.Store(int32(state))
+}
+
+// Indicators returns Indicator of liveness and readiness state
+func (a *ApplicationAvailability) Indicators() []Indicator {
+	return []Indicator{LivenessIndicator{availability: a}, ReadinessIndicator{availability: a}}
+}
+
+/*******************************
+	Indicators
+********************************/
+
+// LivenessIndicator implements Indicator. It reports StatusUp when LivenessCorrect, StatusDown otherwise
+type LivenessIndicator struct {
+	availability *ApplicationAvailability
+}
+
+func (i LivenessIndicator) Name() string {
+	return IndicatorNameLiveness
+}
+
+func (i LivenessIndicator) Health(_ context.Context, _ Options) Health {
+	state := i.availability.Liveness()
+	status := StatusUp
+	if state != LivenessCorrect {
+		status = StatusDown
+	}
+	return NewDetailedHealth(status, state.String(), nil)
+}
+
+// ReadinessIndicator implements Indicator. It reports StatusUp when ReadinessAcceptingTraffic, StatusOutOfService otherwise
+type ReadinessIndicator struct {
+	availability *ApplicationAvailability
+}
+
+func (i ReadinessIndicator) Name() string {
+	return IndicatorNameReadiness
+}
+
+func (i ReadinessIndicator) Health(_ context.Context, _ Options) Health {
+	state := i.availability.Readiness()
+	status := StatusUp
+	if state != ReadinessAcceptingTraffic {
+		status = StatusOutOfService
+	}
+	return NewDetailedHealth(status, state.String(), nil)
+}
+
+/*******************************
+	Lifecycle
+********************************/
+
+// acceptTraffic switches readiness to ReadinessAcceptingTraffic when application is started.
+// This function should be invoked after all other modules
+func acceptTraffic(lc fx.Lifecycle, availability *ApplicationAvailability) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			availability.SetReadiness(ReadinessAcceptingTraffic)
+			return nil
+		},
+	})
+}
+
+// provideRefuseTrafficShutdownHook switches readiness to ReadinessRefusingTraffic at the beginning of graceful shutdown
+func provideRefuseTrafficShutdownHook(availability *ApplicationAvailability) bootstrap.ShutdownHook {
+	return bootstrap.NewShutdownHook("readiness", bootstrap.ShutdownPhaseRefuseTraffic, func(ctx context.Context) error {
+		availability.SetReadiness(ReadinessRefusingTraffic)
+		return nil
+	})
+}
@@ -19,8 +19,11 @@ package healthep
 import (
     "context"
     "encoding/json"
+    "fmt"
     "github.com/cisco-open/go-lanai/pkg/actuator"
     "github.com/cisco-open/go-lanai/pkg/actuator/health"
+    "github.com/cisco-open/go-lanai/pkg/web"
+    "net/http"
 )
 
 const (
@@ -30,6 +33,10 @@ const (
 
 type Input struct{}
 
+type GroupInput struct {
+	Group string `uri:"group" binding:"required"`
+}
+
 type Output struct {
 	health.Health
 	sc int
@@ -58,6 +65,7 @@ func (o Output) MarshalJSON() ([]byte, error) {
 type EndpointOptions func(opt *EndpointOption)
 type EndpointOption struct {
 	Contributor       health.Indicator
+	Groups            map[string]*health.GroupIndicator
 	StatusCodeMapper  health.StatusCodeMapper
 	MgtProperties     actuator.ManagementProperties
 	Properties        health.HealthProperties
@@ -69,6 +77,8 @@ type EndpointOption struct {
 type HealthEndpoint struct {
 	actuator.WebEndpointBase
 	contributor       health.Indicator
+	groups            map[string]*health.GroupIndicator
+	pathSuffix        map[actuator.Operation]string
 	scMapper          health.StatusCodeMapper
 	detailsControl    health.DetailsDisclosureControl
 	componentsControl health.ComponentsDisclosureControl
@@ -95,17 +105,23 @@ func newEndpoint(opts ...EndpointOptions) (*HealthEndpoint, error) {
 
 	ep := HealthEndpoint{
 		contributor:       opt.Contributor,
+		groups:            opt.Groups,
 		scMapper:          opt.StatusCodeMapper,
 		detailsControl:    disclosureCtrl,
 		componentsControl: disclosureCtrl,
 	}
 
+	readOp := actuator.NewReadOperation(ep.Read)
+	readGroupOp := actuator.NewReadOperation(ep.ReadGroup)
+	ops := []actuator.Operation{readOp, readGroupOp}
+	ep.pathSuffix = map[actuator.Operation]string{
+		readOp:      "",
+		readGroupOp: "/:group",
+	}
 	properties := opt.MgtProperties
 	ep.WebEndpointBase = actuator.MakeWebEndpointBase(func(opt *actuator.EndpointOption) {
 		opt.Id = ID
-		opt.Ops = []actuator.Operation{
-			actuator.NewReadOperation(ep.Read),
-		}
+		opt.Ops = ops
 		opt.Properties = &properties.Endpoints
 		opt.EnabledByDefault = EnableByDefault
 	})
@@ -113,13 +129,41 @@ func newEndpoint(opts ...EndpointOptions) (*HealthEndpoint, error) {
 	return &ep, nil
 }
 
+// Mappings implements WebEndpoint
+func (ep *HealthEndpoint) Mappings(op actuator.Operation, group string) ([]web.Mapping, error) {
+	builder, e := ep.RestMappingBuilder(op, group, ep.MappingPath, ep.MappingName)
+	if e != nil {
+		return nil, e
+	}
+	return []web.Mapping{builder.Build()}, nil
+}
+
+func (ep *HealthEndpoint) MappingPath(op actuator.Operation, props *actuator.WebEndpointsProperties) string {
+	path := ep.WebEndpointBase.MappingPath(op, props)
+	suffix := ep.pathSuffix[op]
+	return path + suffix
+}
+
 // Read never returns error
 func (ep *HealthEndpoint) Read(ctx context.Context, _ *Input) (*Output, error) {
+	return ep.health(ctx, ep.contributor), nil
+}
+
+// ReadGroup returns health of given health group, e.g. "liveness" or "readiness"
+func (ep *HealthEndpoint) ReadGroup(ctx context.Context, in *GroupInput) (*Output, error) {
+	indicator, ok := ep.groups[in.Group]
+	if !ok {
+		return nil, web.NewHttpError(http.StatusNotFound, fmt.Errorf("health group with name %s not found", in.Group))
+	}
+	return ep.health(ctx, indicator), nil
+}
+
+func (ep *HealthEndpoint) health(ctx context.Context, indicator health.Indicator) *Output {
 	opts := health.Options{
 		ShowDetails:    ep.detailsControl.ShouldShowDetails(ctx),
 		ShowComponents: ep.componentsControl.ShouldShowComponents(ctx),
 	}
-	h := ep.contributor.Health(ctx, opts)
+	h := indicator.Health(ctx, opts)
 	switch f := ep.WebEndpointBase.NegotiateFormat(ctx); f {
 	case actuator.ContentTypeSpringBootV2:
 		h = ep.toSpringBootV2(h)
@@ -130,7 +174,7 @@ func (ep *HealthEndpoint) Read(ctx context.Context, _ *Input) (*Output, error) {
 	return &Output{
 		Health: h,
 		sc:     ep.scMapper.StatusCode(ctx, h.Status()),
-	}, nil
+	}
 }
 
 func (ep *HealthEndpoint) toSpringBootV2(h health.Health) health.Health {
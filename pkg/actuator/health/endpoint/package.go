@@ -61,6 +61,7 @@ func register(di regDI) {
 	endpoint, e := newEndpoint(func(opt *EndpointOption) {
 		opt.MgtProperties = di.MgtProperties
 		opt.Contributor = healthReg.Indicator
+		opt.Groups = healthReg.Groups
 		opt.Properties = di.Properties
 		opt.DetailsControl = healthReg.DetailsDisclosure
 		opt.ComponentsControl = healthReg.ComponentsDisclosure
@@ -0,0 +1,66 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+)
+
+/*******************************
+	GroupIndicator
+********************************/
+
+// GroupIndicator implements Indicator. It aggregates a subset of indicators selected by name.
+// The candidates are indicators registered to the system CompositeIndicator and the given additional indicators
+// (e.g. ApplicationAvailability.Indicators). Candidates are resolved at the time of health check,
+// therefore the indicators registered after the group is created are also included.
+type GroupIndicator struct {
+	name       string
+	include    utils.StringSet
+	system     *CompositeIndicator
+	additional []Indicator
+}
+
+func NewGroupIndicator(name string, include []string, system *CompositeIndicator, additional ...Indicator) *GroupIndicator {
+	return &GroupIndicator{
+		name:       name,
+		include:    utils.NewStringSet(include...),
+		system:     system,
+		additional: additional,
+	}
+}
+
+func (g *GroupIndicator) Name() string {
+	return g.name
+}
+
+func (g *GroupIndicator) Health(ctx context.Context, options Options) Health {
+	composite := CompositeIndicator{
+		name:       g.name,
+		delegates:  make([]Indicator, 0, len(g.include)),
+		aggregator: g.system.aggregator,
+	}
+	for _, candidates := range [][]Indicator{g.additional, g.system.delegates} {
+		for _, indicator := range candidates {
+			if g.include.Has(indicator.Name()) {
+				composite.delegates = append(composite.delegates, indicator)
+			}
+		}
+	}
+	return composite.Health(ctx, options)
+}
@@ -27,9 +27,21 @@ var Module = &bootstrap.Module{
 	Options: []fx.Option{
 		fx.Provide(
 			BindHealthProperties,
+			NewApplicationAvailability,
 			NewSystemHealthRegistrar,
 			provideInterfaces,
 		),
+		fx.Provide(fx.Annotated{Group: bootstrap.FxShutdownHookGroup, Target: provideRefuseTrafficShutdownHook}),
+	},
+	Modules: []*bootstrap.Module{availabilityModule},
+}
+
+// availabilityModule switches readiness state after all other modules are started
+var availabilityModule = &bootstrap.Module{
+	Name:       "actuator-health-availability",
+	Precedence: bootstrap.StartupSummaryPrecedence,
+	Options: []fx.Option{
+		fx.Invoke(acceptTraffic),
 	},
 }
 
@@ -39,6 +39,15 @@ type HealthProperties struct {
 	// Permisions used to determine whether or not a user is authorized to be shown details.
 	// When empty, all authenticated users are authorized.
 	Permissions utils.CommaSeparatedSlice `json:"permissions"`
+
+	// Health groups, keyed by group name. Each group is available at "<health-endpoint-path>/<group-name>".
+	// "liveness" and "readiness" groups are defined by default.
+	Groups map[string]GroupProperties `json:"groups"`
+}
+
+type GroupProperties struct {
+	// Comma-separated list of indicator names included in the group.
+	Include utils.CommaSeparatedSlice `json:"include"`
 }
 
 type StatusOrders []Status
@@ -76,6 +85,10 @@ func NewHealthProperties() *HealthProperties {
 			ScMapping: map[Status]int{},
 		},
 		Permissions: []string{},
+		Groups: map[string]GroupProperties{
+			"liveness":  {Include: []string{IndicatorNameLiveness}},
+			"readiness": {Include: []string{IndicatorNameReadiness}},
+		},
 	}
 }
 
@@ -24,6 +24,7 @@ import (
 // SystemHealthRegistrar implements Registrar
 type SystemHealthRegistrar struct {
 	Indicator            *CompositeIndicator
+	Groups               map[string]*GroupIndicator
 	DetailsDisclosure    DetailsDisclosureControl
 	ComponentsDisclosure ComponentsDisclosureControl
 }
@@ -31,10 +32,12 @@ type SystemHealthRegistrar struct {
 type regDI struct {
 	fx.In
 	Properties    HealthProperties
+	Availability  *ApplicationAvailability `optional:"true"`
 }
 
 func NewSystemHealthRegistrar(di regDI) *SystemHealthRegistrar {
-	return &SystemHealthRegistrar{
+	reg := &SystemHealthRegistrar{
+		Groups: map[string]*GroupIndicator{},
 		Indicator: &CompositeIndicator{
 			name: "system",
 			delegates: []Indicator{
@@ -47,6 +50,15 @@ func NewSystemHealthRegistrar(di regDI) *SystemHealthRegistrar {
 			}),
 		},
 	}
+
+	var additional []Indicator
+	if di.Availability != nil {
+		additional = di.Availability.Indicators()
+	}
+	for name, props := range di.Properties.Groups {
+		reg.Groups[name] = NewGroupIndicator(name, props.Include, reg.Indicator, additional...)
+	}
+	return reg
 }
 
 // Register configure SystemHealthRegistrar
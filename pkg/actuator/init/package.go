@@ -25,6 +25,8 @@ import (
     health "github.com/cisco-open/go-lanai/pkg/actuator/health/endpoint"
     "github.com/cisco-open/go-lanai/pkg/actuator/info"
     "github.com/cisco-open/go-lanai/pkg/actuator/loggers"
+    metricsep "github.com/cisco-open/go-lanai/pkg/actuator/metrics"
+    prometheusep "github.com/cisco-open/go-lanai/pkg/actuator/prometheus"
     appconfig "github.com/cisco-open/go-lanai/pkg/appconfig/init"
     "github.com/cisco-open/go-lanai/pkg/bootstrap"
     "go.uber.org/fx"
@@ -50,6 +52,8 @@ func Use() {
 	alive.Register()
 	apilist.Register()
 	loggers.Register()
+	metricsep.Register()
+	prometheusep.Register()
 }
 
 /**************************
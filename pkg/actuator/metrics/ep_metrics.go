@@ -0,0 +1,272 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metricsep
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/actuator"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	dto "github.com/prometheus/client_model/go"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	ID              = "metrics"
+	EnableByDefault = false
+)
+
+const (
+	StatisticCount     = "COUNT"
+	StatisticTotal     = "TOTAL"
+	StatisticTotalTime = "TOTAL_TIME"
+	StatisticValue     = "VALUE"
+)
+
+type ReadInput struct {
+	Name string `uri:"name"`
+	// Tags are in format of "key:value"
+	Tags []string `form:"tag"`
+}
+
+type NamesOutput struct {
+	Names []string `json:"names"`
+}
+
+type MetricOutput struct {
+	Name          string         `json:"name"`
+	Description   string         `json:"description,omitempty"`
+	BaseUnit      string         `json:"baseUnit,omitempty"`
+	Measurements  []Measurement  `json:"measurements"`
+	AvailableTags []AvailableTag `json:"availableTags"`
+}
+
+type Measurement struct {
+	Statistic string  `json:"statistic"`
+	Value     float64 `json:"value"`
+}
+
+type AvailableTag struct {
+	Tag    string   `json:"tag"`
+	Values []string `json:"values"`
+}
+
+// MetricsEndpoint implements actuator.Endpoint, actuator.WebEndpoint
+//
+//goland:noinspection GoNameStartsWithPackageName
+type MetricsEndpoint struct {
+	actuator.WebEndpointBase
+	registry   metrics.Registry
+	pathSuffix map[actuator.Operation]string
+}
+
+func newEndpoint(di regDI) *MetricsEndpoint {
+	ep := MetricsEndpoint{
+		registry: di.MetricsRegistry,
+	}
+	ep.pathSuffix = map[actuator.Operation]string{
+		actuator.NewReadOperation(ep.ReadAll):    "",
+		actuator.NewReadOperation(ep.ReadByName): "/:name",
+	}
+	ops := make([]actuator.Operation, 0, len(ep.pathSuffix))
+	for k := range ep.pathSuffix {
+		ops = append(ops, k)
+	}
+	ep.WebEndpointBase = actuator.MakeWebEndpointBase(func(opt *actuator.EndpointOption) {
+		opt.Id = ID
+		opt.Ops = ops
+		opt.Properties = &di.MgtProperties.Endpoints
+		opt.EnabledByDefault = EnableByDefault
+	})
+	return &ep
+}
+
+// Mappings implements WebEndpoint
+func (ep *MetricsEndpoint) Mappings(op actuator.Operation, group string) ([]web.Mapping, error) {
+	builder, e := ep.RestMappingBuilder(op, group, ep.MappingPath, ep.MappingName)
+	if e != nil {
+		return nil, e
+	}
+	return []web.Mapping{builder.Build()}, nil
+}
+
+func (ep *MetricsEndpoint) MappingPath(op actuator.Operation, props *actuator.WebEndpointsProperties) string {
+	path := ep.WebEndpointBase.MappingPath(op, props)
+	suffix, _ := ep.pathSuffix[op]
+	return path + suffix
+}
+
+// ReadAll returns names of all available metrics
+func (ep *MetricsEndpoint) ReadAll(_ context.Context, _ *struct{}) (*NamesOutput, error) {
+	families, e := ep.registry.Gather()
+	if e != nil {
+		return nil, e
+	}
+
+	names := utils.NewStringSet()
+	exported := utils.NewStringSet()
+	for _, desc := range ep.registry.Meters() {
+		names.Add(desc.Name)
+		exported.Add(desc.ExportedName)
+	}
+	for _, f := range families {
+		if !exported.Has(f.GetName()) {
+			names.Add(f.GetName())
+		}
+	}
+	ret := names.Values()
+	sort.Strings(ret)
+	return &NamesOutput{Names: ret}, nil
+}
+
+// ReadByName returns measurements of given metric, optionally filtered by tags
+func (ep *MetricsEndpoint) ReadByName(_ context.Context, input *ReadInput) (*MetricOutput, error) {
+	filters, e := parseTagFilters(input.Tags)
+	if e != nil {
+		return nil, web.NewHttpError(http.StatusBadRequest, e)
+	}
+
+	out := MetricOutput{Name: input.Name}
+	exportedName := input.Name
+	for _, desc := range ep.registry.Meters() {
+		if desc.Name == input.Name || desc.ExportedName == input.Name {
+			out.Name = desc.Name
+			out.Description = desc.Description
+			out.BaseUnit = desc.Unit
+			exportedName = desc.ExportedName
+			break
+		}
+	}
+
+	families, e := ep.registry.Gather()
+	if e != nil {
+		return nil, e
+	}
+	for _, f := range families {
+		if f.GetName() != exportedName {
+			continue
+		}
+		if out.Description == "" {
+			out.Description = f.GetHelp()
+		}
+		out.Measurements, out.AvailableTags = aggregate(f, filters, out.BaseUnit)
+		if len(out.Measurements) == 0 {
+			break
+		}
+		return &out, nil
+	}
+	return nil, web.NewHttpError(http.StatusNotFound, fmt.Errorf("metric with name %s not found", input.Name))
+}
+
+/*******************************
+	helpers
+********************************/
+
+func parseTagFilters(tags []string) (map[string]string, error) {
+	filters := map[string]string{}
+	for _, tag := range tags {
+		kv := strings.SplitN(tag, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf(`tag filter [%s] is not in format of "key:value"`, tag)
+		}
+		filters[kv[0]] = kv[1]
+	}
+	return filters, nil
+}
+
+func matchesFilters(m *dto.Metric, filters map[string]string) bool {
+	matched := 0
+	for _, lp := range m.GetLabel() {
+		if v, ok := filters[lp.GetName()]; ok {
+			if v != lp.GetValue() {
+				return false
+			}
+			matched++
+		}
+	}
+	return matched == len(filters)
+}
+
+func aggregate(f *dto.MetricFamily, filters map[string]string, unit string) ([]Measurement, []AvailableTag) {
+	var count, total, value float64
+	var matched int
+	tags := map[string]utils.StringSet{}
+	for _, m := range f.GetMetric() {
+		if !matchesFilters(m, filters) {
+			continue
+		}
+		matched++
+		for _, lp := range m.GetLabel() {
+			if _, ok := filters[lp.GetName()]; ok {
+				continue
+			}
+			if _, ok := tags[lp.GetName()]; !ok {
+				tags[lp.GetName()] = utils.NewStringSet()
+			}
+			tags[lp.GetName()].Add(lp.GetValue())
+		}
+		switch f.GetType() {
+		case dto.MetricType_COUNTER:
+			count += m.GetCounter().GetValue()
+		case dto.MetricType_GAUGE:
+			value += m.GetGauge().GetValue()
+		case dto.MetricType_HISTOGRAM:
+			count += float64(m.GetHistogram().GetSampleCount())
+			total += m.GetHistogram().GetSampleSum()
+		case dto.MetricType_SUMMARY:
+			count += float64(m.GetSummary().GetSampleCount())
+			total += m.GetSummary().GetSampleSum()
+		default:
+			value += m.GetUntyped().GetValue()
+		}
+	}
+	if matched == 0 {
+		return nil, nil
+	}
+
+	var measurements []Measurement
+	switch f.GetType() {
+	case dto.MetricType_COUNTER:
+		measurements = []Measurement{{Statistic: StatisticCount, Value: count}}
+	case dto.MetricType_HISTOGRAM, dto.MetricType_SUMMARY:
+		totalStat := StatisticTotal
+		if unit == "seconds" {
+			totalStat = StatisticTotalTime
+		}
+		measurements = []Measurement{
+			{Statistic: StatisticCount, Value: count},
+			{Statistic: totalStat, Value: total},
+		}
+	default:
+		measurements = []Measurement{{Statistic: StatisticValue, Value: value}}
+	}
+
+	available := make([]AvailableTag, 0, len(tags))
+	for k, v := range tags {
+		values := v.Values()
+		sort.Strings(values)
+		available = append(available, AvailableTag{Tag: k, Values: values})
+	}
+	sort.SliceStable(available, func(i, j int) bool {
+		return available[i].Tag < available[j].Tag
+	})
+	return measurements, available
+}
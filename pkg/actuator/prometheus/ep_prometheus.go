@@ -0,0 +1,104 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prometheusep
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/actuator"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"net/http"
+)
+
+const (
+	ID              = "prometheus"
+	EnableByDefault = false
+)
+
+// Scrape is the output of read operation
+type Scrape struct {
+	Families []*dto.MetricFamily
+}
+
+// PrometheusEndpoint implements actuator.Endpoint, actuator.WebEndpoint
+//
+//goland:noinspection GoNameStartsWithPackageName
+type PrometheusEndpoint struct {
+	actuator.WebEndpointBase
+	registry metrics.Registry
+}
+
+func newEndpoint(di regDI) *PrometheusEndpoint {
+	ep := PrometheusEndpoint{
+		registry: di.MetricsRegistry,
+	}
+	ep.WebEndpointBase = actuator.MakeWebEndpointBase(func(opt *actuator.EndpointOption) {
+		opt.Id = ID
+		opt.Ops = []actuator.Operation{
+			actuator.NewReadOperation(ep.Read),
+		}
+		opt.Properties = &di.MgtProperties.Endpoints
+		opt.EnabledByDefault = EnableByDefault
+	})
+	return &ep
+}
+
+// Mappings implements WebEndpoint
+func (ep *PrometheusEndpoint) Mappings(op actuator.Operation, group string) ([]web.Mapping, error) {
+	builder, e := ep.RestMappingBuilder(op, group, ep.MappingPath, ep.MappingName)
+	if e != nil {
+		return nil, e
+	}
+	builder.EncodeResponseFunc(ep.EncodeResponse)
+	return []web.Mapping{builder.Build()}, nil
+}
+
+// Read gathers all metrics from registry
+func (ep *PrometheusEndpoint) Read(_ context.Context, _ *struct{}) (*Scrape, error) {
+	families, e := ep.registry.Gather()
+	if e != nil {
+		return nil, e
+	}
+	return &Scrape{Families: families}, nil
+}
+
+// EncodeResponse write gathered metrics in Prometheus exposition format, negotiated by "Accept" header
+func (ep *PrometheusEndpoint) EncodeResponse(ctx context.Context, rw http.ResponseWriter, v interface{}) error {
+	scrape, ok := v.(*Scrape)
+	if !ok {
+		return web.NewHttpError(http.StatusInternalServerError, fmt.Errorf("unexpected scrape result type %T", v))
+	}
+	format := expfmt.NewFormat(expfmt.TypeTextPlain)
+	if gc := web.GinContext(ctx); gc != nil {
+		format = expfmt.Negotiate(gc.Request.Header)
+	}
+	rw.Header().Set("Content-Type", string(format))
+	rw.WriteHeader(http.StatusOK)
+	enc := expfmt.NewEncoder(rw, format)
+	for _, f := range scrape.Families {
+		if e := enc.Encode(f); e != nil {
+			return e
+		}
+	}
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -182,9 +182,10 @@ func (b *Bootstrapper) NewApp(cliCtx *CliExecContext, priorityOptions []fx.Optio
 
 type App struct {
 	*fx.App
-	ctx          *ApplicationContext
-	startCtxOpts []ContextOption
-	stopCtxOpts  []ContextOption
+	ctx             *ApplicationContext
+	startCtxOpts    []ContextOption
+	stopCtxOpts     []ContextOption
+	shutdownTimeout time.Duration
 }
 
 // EagerGetApplicationContext returns the global ApplicationContext before it becomes available for dependency injection
@@ -197,6 +198,12 @@ func (app *App) EagerGetApplicationContext() *ApplicationContext {
 	return app.ctx
 }
 
+// StopTimeout returns fx.App's stop timeout extended by the sum of all ShutdownHook's Timeout.
+// fx.App's stop timeout (fx.StopTimeout) is the headroom for ShutdownHook without Timeout and other fx.Hook's OnStop.
+func (app *App) StopTimeout() time.Duration {
+	return app.App.StopTimeout() + app.shutdownTimeout
+}
+
 func (app *App) Run() {
 	// to be revised:
 	//  1. (Solved)	Support Timeout in bootstrap.Context
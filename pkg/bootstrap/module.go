@@ -34,6 +34,7 @@ const (
 	_ = FrameworkModulePrecedence + iota*(FrameworkModulePrecedenceBandwidth+1)
 	AppConfigPrecedence
 	TracingPrecedence
+	MetricsPrecedence
 	ActuatorPrecedence
 	ConsulPrecedence
 	VaultPrecedence
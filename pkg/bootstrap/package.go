@@ -49,6 +49,13 @@ func MiscModules() []*Module {
 				fx.Invoke(startupTiming), // startup need to be run at last
 			},
 		},
+		{
+			Precedence: LowestPrecedence,
+			Options: []fx.Option{
+				// graceful shutdown need to be invoked at last, so it runs before any other fx.Hook.OnStop
+				fx.Invoke(gracefulShutdown),
+			},
+		},
 		{
 			Precedence: HighestPrecedence,
 			PriorityOptions: []fx.Option{
@@ -0,0 +1,147 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"go.uber.org/fx"
+	"sync"
+	"time"
+)
+
+const (
+	FxShutdownHookGroup = "bootstrap_shutdown_hook"
+)
+
+// Phases of the graceful shutdown sequence. ShutdownHook with lower phase is executed first.
+// Hooks that need to run before or after other hooks of the same phase may use offset, e.g. ShutdownPhaseMessaging - 10
+const (
+	// ShutdownPhaseRefuseTraffic the application reports itself as not ready, e.g. readiness health group
+	ShutdownPhaseRefuseTraffic = (iota + 1) * 1000
+	// ShutdownPhaseDeregister the application is removed from service discovery
+	ShutdownPhaseDeregister
+	// ShutdownPhasePreStop wait for load-balancers and clients to stop routing new traffic to this instance
+	ShutdownPhasePreStop
+	// ShutdownPhaseWebServer the web server stops accepting new connections and drains in-flight requests
+	ShutdownPhaseWebServer
+	// ShutdownPhaseMessaging message consumers and producers are stopped
+	ShutdownPhaseMessaging
+	// ShutdownPhaseScheduler scheduled tasks are cancelled
+	ShutdownPhaseScheduler
+)
+
+type ShutdownHookFunc func(ctx context.Context) error
+
+// ShutdownHook is a step of the graceful shutdown sequence.
+// Any module can contribute ShutdownHook using fx group FxShutdownHookGroup:
+//
+//	fx.Provide(fx.Annotated{
+//		Group:  bootstrap.FxShutdownHookGroup,
+//		Target: func(dep SomeDependency) bootstrap.ShutdownHook {
+//			return bootstrap.NewShutdownHook("my-hook", bootstrap.ShutdownPhaseMessaging, dep.Stop)
+//		},
+//	})
+//
+// All hooks are executed sequentially in order of Phase, before any fx.Hook's OnStop.
+// Hooks are always executed, even if some previous hook returned error.
+//
+// Note: the graceful shutdown sequence is executed only if the application started successfully. Components that hold
+// resources (servers, connections, etc.) should also register the same ShutdownHook.Func as fx.Hook's OnStop,
+// so the resources are released when any other component fails to start. ShutdownHook created via NewShutdownHook
+// executes its Func at most once, so such fallback is a no-op after graceful shutdown.
+//
+// Hooks that are expected to take a while (e.g. waiting or draining) should declare their max duration via Timeout.
+// The application's stop timeout is extended by the sum of all hooks' Timeout, see App.StopTimeout.
+type ShutdownHook struct {
+	Name    string
+	Phase   int
+	Func    ShutdownHookFunc
+	Timeout time.Duration
+}
+
+// NewShutdownHook create a ShutdownHook, whose Func is executed at most once.
+// Subsequent invocations return the result of the first one.
+func NewShutdownHook(name string, phase int, fn ShutdownHookFunc) ShutdownHook {
+	return ShutdownHook{
+		Name:  name,
+		Phase: phase,
+		Func:  onceShutdownHookFunc(fn),
+	}
+}
+
+// WithTimeout returns a copy of the ShutdownHook with given Timeout
+func (h ShutdownHook) WithTimeout(timeout time.Duration) ShutdownHook {
+	h.Timeout = timeout
+	return h
+}
+
+// Order implements order.Ordered
+func (h ShutdownHook) Order() int {
+	return h.Phase
+}
+
+type shutdownDI struct {
+	fx.In
+	App   *App           `optional:"true"`
+	Hooks []ShutdownHook `group:"bootstrap_shutdown_hook"`
+}
+
+// gracefulShutdown register a single fx.Hook that executes all ShutdownHook in order.
+// This invocation should happen after everything else, so its OnStop is executed before any other OnStop
+func gracefulShutdown(lc fx.Lifecycle, di shutdownDI) {
+	hooks := make([]ShutdownHook, 0, len(di.Hooks))
+	var timeout time.Duration
+	for _, h := range di.Hooks {
+		if h.Func != nil {
+			hooks = append(hooks, h)
+			timeout += h.Timeout
+		}
+	}
+	order.SortStable(hooks, order.OrderedFirstCompare)
+	if di.App != nil {
+		di.App.shutdownTimeout = timeout
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			var errs []error
+			for _, h := range hooks {
+				logger.WithContext(ctx).Debugf("Shutdown [%d] %s", h.Phase, h.Name)
+				if e := h.Func(ctx); e != nil {
+					logger.WithContext(ctx).Warnf("Shutdown [%d] %s failed: %v", h.Phase, h.Name, e)
+					errs = append(errs, e)
+				}
+			}
+			return errors.Join(errs...)
+		},
+	})
+}
+
+func onceShutdownHookFunc(fn ShutdownHookFunc) ShutdownHookFunc {
+	if fn == nil {
+		return nil
+	}
+	var once sync.Once
+	var err error
+	return func(ctx context.Context) error {
+		once.Do(func() {
+			err = fn(ctx)
+		})
+		return err
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"testing"
+	"time"
+)
+
+/*************************
+	Tests
+ *************************/
+
+func TestGracefulShutdown(t *testing.T) {
+	t.Run("NormalShutdown", SubTestNormalShutdown)
+	t.Run("StartFailure", SubTestShutdownOnStartFailure)
+	t.Run("ShutdownHookOnce", SubTestShutdownHookOnce)
+	t.Run("StopTimeout", SubTestShutdownStopTimeout)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestNormalShutdown(t *testing.T) {
+	g := gomega.NewWithT(t)
+	comp := &testComponent{}
+	app := fx.New(fx.NopLogger,
+		fx.Supply(comp),
+		fx.Provide(fx.Annotated{Group: FxShutdownHookGroup, Target: provideTestShutdownHook("first", ShutdownPhaseMessaging)}),
+		fx.Provide(provideTestComponentShutdownHook),
+		fx.Invoke(startTestComponent),
+		fx.Invoke(gracefulShutdown),
+	)
+	g.Expect(app.Start(context.Background())).To(gomega.Succeed(), "app should start")
+	g.Expect(app.Stop(context.Background())).To(gomega.Succeed(), "app should stop")
+	g.Expect(comp.started).To(gomega.BeTrue(), "component should be started")
+	g.Expect(comp.stopCount).To(gomega.Equal(1), "component should be stopped exactly once")
+	g.Expect(comp.events).To(gomega.Equal([]string{"component", "first"}), "shutdown hooks should be executed in order of phase")
+}
+
+func SubTestShutdownOnStartFailure(t *testing.T) {
+	g := gomega.NewWithT(t)
+	comp := &testComponent{}
+	app := fx.New(fx.NopLogger,
+		fx.Supply(comp),
+		fx.Provide(fx.Annotated{Group: FxShutdownHookGroup, Target: provideTestShutdownHook("first", ShutdownPhaseMessaging)}),
+		fx.Provide(provideTestComponentShutdownHook),
+		fx.Invoke(startTestComponent),
+		fx.Invoke(func(lc fx.Lifecycle) {
+			lc.Append(fx.StartHook(func(ctx context.Context) error {
+				return errors.New("oops")
+			}))
+		}),
+		fx.Invoke(gracefulShutdown),
+	)
+	g.Expect(app.Start(context.Background())).To(gomega.HaveOccurred(), "app should fail to start")
+	g.Expect(comp.started).To(gomega.BeTrue(), "component should be started")
+	g.Expect(comp.stopCount).To(gomega.Equal(1), "started component should be stopped during rollback")
+	g.Expect(comp.events).To(gomega.Equal([]string{"component"}), "graceful shutdown sequence should not be executed")
+}
+
+func SubTestShutdownHookOnce(t *testing.T) {
+	g := gomega.NewWithT(t)
+	count := 0
+	hook := NewShutdownHook("test", ShutdownPhaseMessaging, func(ctx context.Context) error {
+		count++
+		return errors.New("oops")
+	})
+	g.Expect(hook.Func(context.Background())).To(gomega.HaveOccurred(), "first invocation should return error")
+	g.Expect(hook.Func(context.Background())).To(gomega.HaveOccurred(), "subsequent invocation should return same error")
+	g.Expect(count).To(gomega.Equal(1), "hook should be executed once")
+	g.Expect(NewShutdownHook("nil", ShutdownPhaseMessaging, nil).Func).To(gomega.BeNil(), "nil func should remain nil")
+}
+
+func SubTestShutdownStopTimeout(t *testing.T) {
+	g := gomega.NewWithT(t)
+	app := &App{}
+	app.App = fx.New(fx.NopLogger,
+		fx.Supply(&testComponent{}, app),
+		fx.Provide(fx.Annotated{Group: FxShutdownHookGroup, Target: func(comp *testComponent) ShutdownHook {
+			return provideTestShutdownHook("first", ShutdownPhasePreStop)(comp).WithTimeout(10 * time.Second)
+		}}),
+		fx.Provide(fx.Annotated{Group: FxShutdownHookGroup, Target: func(comp *testComponent) ShutdownHook {
+			return provideTestShutdownHook("second", ShutdownPhaseWebServer)(comp).WithTimeout(20 * time.Second)
+		}}),
+		fx.Provide(fx.Annotated{Group: FxShutdownHookGroup, Target: provideTestShutdownHook("third", ShutdownPhaseMessaging)}),
+		fx.Invoke(gracefulShutdown),
+	)
+	g.Expect(app.Err()).To(gomega.Succeed(), "app should be created")
+	g.Expect(app.StopTimeout()).To(gomega.Equal(app.App.StopTimeout()+30*time.Second),
+		"stop timeout should be extended by timeout of shutdown hooks")
+}
+
+/*************************
+	Helpers
+ *************************/
+
+type testComponent struct {
+	started   bool
+	stopCount int
+	events    []string
+}
+
+type testShutdownOut struct {
+	fx.Out
+	Hook ShutdownHook     `group:"bootstrap_shutdown_hook"`
+	Stop ShutdownHookFunc `name:"test/stop"`
+}
+
+type testStartDI struct {
+	fx.In
+	Comp *testComponent
+	Stop ShutdownHookFunc `name:"test/stop"`
+}
+
+func provideTestShutdownHook(name string, phase int) func(comp *testComponent) ShutdownHook {
+	return func(comp *testComponent) ShutdownHook {
+		return NewShutdownHook(name, phase, func(ctx context.Context) error {
+			comp.events = append(comp.events, name)
+			return nil
+		})
+	}
+}
+
+func provideTestComponentShutdownHook(comp *testComponent) testShutdownOut {
+	hook := NewShutdownHook("component", ShutdownPhaseWebServer, func(ctx context.Context) error {
+		comp.stopCount++
+		comp.events = append(comp.events, "component")
+		return nil
+	})
+	return testShutdownOut{Hook: hook, Stop: hook.Func}
+}
+
+func startTestComponent(lc fx.Lifecycle, di testStartDI) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			di.Comp.started = true
+			return nil
+		},
+		OnStop: di.Stop,
+	})
+}
@@ -41,7 +41,9 @@ type TLSOption struct {
 }
 
 type Source interface {
-	// TLSConfig get certificates as tls.Config. For native drivers that support standard tls.Config
+	// TLSConfig get certificates as tls.Config. For native drivers that support standard tls.Config.
+	// The returned tls.Config can be used by clients (GetClientCertificate and RootCAs)
+	// as well as servers (GetCertificate). Rotated certificates are picked up by these functions.
 	TLSConfig(ctx context.Context, opts ...TLSOptions) (*tls.Config, error)
 	// Files get certificates as local files. For drivers that support filesystem based certificates config e.g. postgres DSN
 	Files(ctx context.Context) (*CertificateFiles, error)
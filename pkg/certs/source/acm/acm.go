@@ -84,6 +84,7 @@ func (a *AcmProvider) TLSConfig(ctx context.Context, _ ...certs.TLSOptions) (*tl
 	//nolint:gosec // false positive -  G402: TLS MinVersion too low
 	return &tls.Config{
 		GetClientCertificate: a.toGetClientCertificateFunc(),
+		GetCertificate:       a.toGetCertificateFunc(),
 		RootCAs:              rootCAs,
 		MinVersion:           minVer,
 	}, nil
@@ -160,6 +161,17 @@ func (a *AcmProvider) LazyInit(ctx context.Context) error {
 	return err
 }
 
+func (a *AcmProvider) toGetCertificateFunc() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		a.mutex.RLock()
+		defer a.mutex.RUnlock()
+		if a.cachedCertificate == nil {
+			return nil, fmt.Errorf("certificate from [%s] is not available", sourceType)
+		}
+		return a.cachedCertificate, nil
+	}
+}
+
 func (a *AcmProvider) toGetClientCertificateFunc() func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
 	return func(certificateReq *tls.CertificateRequestInfo) (*tls.Certificate, error) {
 		a.mutex.RLock()
@@ -26,10 +26,16 @@ import (
     "io"
     "os"
     "path/filepath"
+    "sync"
+    "time"
 )
 
 type FileProvider struct {
 	p SourceProperties
+
+	mutex             sync.RWMutex
+	cachedCertificate *tls.Certificate
+	cachedModTime     time.Time
 }
 
 func NewFileProvider(p SourceProperties) certs.Source {
@@ -50,8 +56,9 @@ func (f *FileProvider) TLSConfig(ctx context.Context, _ ...certs.TLSOptions) (*t
 	//nolint:gosec // false positive -  G402: TLS MinVersion too low
 	return &tls.Config{
 		GetClientCertificate: f.toGetClientCertificateFunc(),
-		RootCAs: rootCAs,
-		MinVersion: minVer,
+		GetCertificate:       f.toGetCertificateFunc(),
+		RootCAs:              rootCAs,
+		MinVersion:           minVer,
 	}, nil
 }
 
@@ -76,48 +83,106 @@ func (f *FileProvider) RootCAs(_ context.Context) (*x509.CertPool, error) {
 
 func (f *FileProvider) toGetClientCertificateFunc() func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
 	return func(certificateReq *tls.CertificateRequestInfo) (*tls.Certificate, error) {
-		keyFile, err := os.Open(f.p.KeyFile)
+		clientCert, err := f.loadCertificate()
 		if err != nil {
 			return nil, err
 		}
 
-		keyBytes, err := io.ReadAll(keyFile)
-		if err != nil {
-			return nil, err
-		}
-		if f.p.KeyPass != "" {
-			keyBlock, _ := pem.Decode(keyBytes)
-			//nolint:staticcheck
-			unEncryptedKey, e := x509.DecryptPEMBlock(keyBlock, []byte(f.p.KeyPass))
-			if e != nil {
-				return nil, e
-			}
-			keyBlock.Bytes = unEncryptedKey
-			keyBlock.Headers = nil
-			keyBytes = pem.EncodeToMemory(keyBlock)
-		}
-		certfile, err := os.Open(f.p.CertFile)
-		if err != nil {
-			return nil, err
+		e := certificateReq.SupportsCertificate(clientCert)
+		if e != nil {
+			// No acceptable certificate found. Don't send a certificate. Don't need to treat as error.
+			// see tls package's tls.Conn.getClientCertificate(cri *CertificateRequestInfo) (*Certificate, error)
+			return new(tls.Certificate), nil //nolint:nilerr
+		} else {
+			return clientCert, nil
 		}
-		certBytes, err := io.ReadAll(certfile)
+	}
+}
+
+func (f *FileProvider) toGetCertificateFunc() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return f.loadCertificate()
+	}
+}
+
+// loadCertificate returns cached certificate, the certificate is reloaded when any of certificate and key files is modified.
+// This allows certificate rotation without restarting the application
+func (f *FileProvider) loadCertificate() (*tls.Certificate, error) {
+	modTime, err := f.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	f.mutex.RLock()
+	cached, cachedModTime := f.cachedCertificate, f.cachedModTime
+	f.mutex.RUnlock()
+	if cached != nil && modTime.Equal(cachedModTime) {
+		return cached, nil
+	}
+
+	cert, err := f.readCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.cachedCertificate = cert
+	f.cachedModTime = modTime
+	return cert, nil
+}
+
+func (f *FileProvider) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{f.p.CertFile, f.p.KeyFile} {
+		info, err := os.Stat(path)
 		if err != nil {
-			return nil, err
+			return latest, err
 		}
-		clientCert, err := tls.X509KeyPair(certBytes, keyBytes)
-		if err != nil {
-			return nil, err
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
 		}
+	}
+	return latest, nil
+}
 
-		e := certificateReq.SupportsCertificate(&clientCert)
+func (f *FileProvider) readCertificate() (*tls.Certificate, error) {
+	keyFile, err := os.Open(f.p.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = keyFile.Close() }()
+
+	keyBytes, err := io.ReadAll(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if f.p.KeyPass != "" {
+		keyBlock, _ := pem.Decode(keyBytes)
+		//nolint:staticcheck
+		unEncryptedKey, e := x509.DecryptPEMBlock(keyBlock, []byte(f.p.KeyPass))
 		if e != nil {
-			// No acceptable certificate found. Don't send a certificate. Don't need to treat as error.
-			// see tls package's tls.Conn.getClientCertificate(cri *CertificateRequestInfo) (*Certificate, error)
-			return new(tls.Certificate), nil //nolint:nilerr
-		} else {
-			return &clientCert, nil
+			return nil, e
 		}
+		keyBlock.Bytes = unEncryptedKey
+		keyBlock.Headers = nil
+		keyBytes = pem.EncodeToMemory(keyBlock)
+	}
+	certfile, err := os.Open(f.p.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = certfile.Close() }()
+
+	certBytes, err := io.ReadAll(certfile)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return nil, err
 	}
+	return &cert, nil
 }
 
 func (f *FileProvider) toAbsPath(path string) string {
@@ -28,7 +28,9 @@ import (
     "github.com/cisco-open/go-lanai/test/apptest"
     "go.uber.org/fx"
     "os"
+    "path/filepath"
     "testing"
+    "time"
 )
 import . "github.com/onsi/gomega"
 
@@ -75,6 +77,7 @@ func TestFileCertificateSource(t *testing.T) {
 		),
 		test.GomegaSubTest(SubTestTLSConfig(di), "SubTestTLSConfig"),
 		test.GomegaSubTest(SubTestFiles(di), "TestFiles"),
+		test.GomegaSubTest(SubTestServerCertificate(di), "TestServerCertificate"),
 	)
 }
 
@@ -160,10 +163,56 @@ func SubTestFiles(di *FileTestDi) test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestServerCertificate(di *FileTestDi) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		dir := t.TempDir()
+		p := filecerts.SourceProperties{
+			CACertFile: "testdata/ca-cert-test.pem",
+			CertFile:   CopyFile(g, "testdata/client-cert-signed-test.pem", dir),
+			KeyFile:    CopyFile(g, "testdata/client-key-test.pem", dir),
+			KeyPass:    "foobar",
+		}
+
+		tlsSrc, err := di.CertsManager.Source(ctx, certs.WithType(certs.SourceFile, p))
+		g.Expect(err).NotTo(HaveOccurred())
+		tlsCfg, err := tlsSrc.TLSConfig(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(tlsCfg.GetCertificate).ToNot(BeNil())
+
+		cert, err := tlsCfg.GetCertificate(&tls.ClientHelloInfo{})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cert.Certificate).To(HaveLen(1))
+		cached, err := tlsCfg.GetCertificate(&tls.ClientHelloInfo{})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cached).To(BeIdenticalTo(cert), "certificate should be cached")
+
+		// rotate
+		modTime := time.Now().Add(time.Minute)
+		g.Expect(os.Chtimes(p.CertFile, modTime, modTime)).To(Succeed())
+		reloaded, err := tlsCfg.GetCertificate(&tls.ClientHelloInfo{})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(reloaded).ToNot(BeIdenticalTo(cert), "certificate should be reloaded after file is modified")
+		g.Expect(reloaded.Certificate).To(Equal(cert.Certificate))
+
+		// missing files
+		g.Expect(os.Remove(p.KeyFile)).To(Succeed())
+		_, err = tlsCfg.GetCertificate(&tls.ClientHelloInfo{})
+		g.Expect(err).To(HaveOccurred(), "missing key file should fail")
+	}
+}
+
 /*************************
 	Helpers
  *************************/
 
+func CopyFile(g *WithT, src, dir string) string {
+	data, e := os.ReadFile(src)
+	g.Expect(e).To(Succeed(), "reading file '%s' should not fail", src)
+	dst := filepath.Join(dir, filepath.Base(src))
+	g.Expect(os.WriteFile(dst, data, 0600)).To(Succeed(), "writing file '%s' should not fail", dst)
+	return dst
+}
+
 func AssertFilesExist(g *WithT, paths []string) {
 	for _, path := range paths {
 		AssertFileExists(g, path)
@@ -81,6 +81,7 @@ func (v *VaultProvider) TLSConfig(ctx context.Context, _ ...certs.TLSOptions) (*
 	//nolint:gosec // false positive -  G402: TLS MinVersion too low
 	return &tls.Config{
 		GetClientCertificate: v.toGetClientCertificateFunc(),
+		GetCertificate:       v.toGetCertificateFunc(),
 		RootCAs:              rootCAs,
 		MinVersion:           minVer,
 	}, nil
@@ -166,6 +167,17 @@ func (v *VaultProvider) Close() error {
 	return nil
 }
 
+func (v *VaultProvider) toGetCertificateFunc() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		v.mutex.RLock()
+		defer v.mutex.RUnlock()
+		if v.cachedCertificate == nil {
+			return nil, fmt.Errorf("certificate from [%s] is not available", sourceType)
+		}
+		return v.cachedCertificate, nil
+	}
+}
+
 func (v *VaultProvider) toGetClientCertificateFunc() func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
 	return func(certificateReq *tls.CertificateRequestInfo) (*tls.Certificate, error) {
 		v.mutex.RLock()
@@ -0,0 +1,142 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package data
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	gormPluginMetrics       = gormCallbackPrefix + "metrics"
+	gormKeyMetricsStartTime = gormCallbackPrefix + "metrics_start"
+	MeterNameDBOperations   = "db.client.operations"
+)
+
+type gormMetricsConfigurer struct {
+	registry metrics.Registry
+}
+
+func NewGormMetricsConfigurer(registry metrics.Registry) GormConfigurer {
+	return &gormMetricsConfigurer{
+		registry: registry,
+	}
+}
+
+func (c gormMetricsConfigurer) Order() int {
+	return order.Highest + 2
+}
+
+func (c gormMetricsConfigurer) Configure(config *gorm.Config) {
+	if config.Plugins == nil {
+		config.Plugins = map[string]gorm.Plugin{}
+	}
+	config.Plugins[gormPluginMetrics] = &gormMetricsPlugin{
+		histogram: c.registry.Histogram(MeterNameDBOperations,
+			metrics.WithDescription("Duration of database operations"),
+			metrics.WithUnit("seconds"),
+			metrics.WithTags("operation", "table", "outcome"),
+		),
+	}
+}
+
+type gormMetricsPlugin struct {
+	histogram metrics.Histogram
+}
+
+// Name implements gorm.Plugin
+func (p gormMetricsPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin. This function register metrics related callbacks
+// Default callbacks can be found at github.com/go-gorm/gorm/callbacks/callbacks.go
+func (p gormMetricsPlugin) Initialize(db *gorm.DB) error {
+	_ = db.Callback().Create().Before(GormCallbackBeforeCreate).
+		Register(p.cbBeforeName("create"), p.makeBeforeCallback())
+	_ = db.Callback().Create().After(GormCallbackAfterCreate).
+		Register(p.cbAfterName("create"), p.makeAfterCallback("create"))
+
+	_ = db.Callback().Query().Before(GormCallbackBeforeQuery).
+		Register(p.cbBeforeName("query"), p.makeBeforeCallback())
+	_ = db.Callback().Query().After(GormCallbackAfterQuery).
+		Register(p.cbAfterName("query"), p.makeAfterCallback("select"))
+
+	_ = db.Callback().Update().Before(GormCallbackBeforeUpdate).
+		Register(p.cbBeforeName("update"), p.makeBeforeCallback())
+	_ = db.Callback().Update().After(GormCallbackAfterUpdate).
+		Register(p.cbAfterName("update"), p.makeAfterCallback("update"))
+
+	_ = db.Callback().Delete().Before(GormCallbackBeforeDelete).
+		Register(p.cbBeforeName("delete"), p.makeBeforeCallback())
+	_ = db.Callback().Delete().After(GormCallbackAfterDelete).
+		Register(p.cbAfterName("delete"), p.makeAfterCallback("delete"))
+
+	_ = db.Callback().Row().Before(GormCallbackBeforeRow).
+		Register(p.cbBeforeName("row"), p.makeBeforeCallback())
+	_ = db.Callback().Row().After(GormCallbackAfterRow).
+		Register(p.cbAfterName("row"), p.makeAfterCallback("row"))
+
+	_ = db.Callback().Raw().Before(GormCallbackBeforeRaw).
+		Register(p.cbBeforeName("raw"), p.makeBeforeCallback())
+	_ = db.Callback().Raw().After(GormCallbackAfterRaw).
+		Register(p.cbAfterName("raw"), p.makeAfterCallback("sql"))
+
+	return nil
+}
+
+func (p gormMetricsPlugin) makeBeforeCallback() gormCallbackFunc {
+	return func(db *gorm.DB) {
+		db.InstanceSet(gormKeyMetricsStartTime, time.Now())
+	}
+}
+
+func (p gormMetricsPlugin) makeAfterCallback(opName string) gormCallbackFunc {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormKeyMetricsStartTime)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if db.Statement.TableExpr != nil {
+			table = db.Statement.TableExpr.SQL
+		}
+		outcome := "SUCCESS"
+		if db.Error != nil {
+			outcome = "ERROR"
+		}
+		p.histogram.Observe(time.Since(start).Seconds(), metrics.Tags{
+			"operation": opName,
+			"table":     table,
+			"outcome":   outcome,
+		})
+	}
+}
+
+func (p gormMetricsPlugin) cbBeforeName(name string) string {
+	return gormCallbackPrefix + "metrics_before_" + name
+}
+
+func (p gormMetricsPlugin) cbAfterName(name string) string {
+	return gormCallbackPrefix + "metrics_after_" + name
+}
@@ -88,6 +88,7 @@ func (v *Version) UnmarshalJSON(data []byte) (err error) {
 const (
 	AlgPlain   Algorithm = "p"
 	AlgVault   Algorithm = "e" // this value is compatible with Java counterpart
+	AlgLocal   Algorithm = "l" // AES-256-GCM with keys from local keyring
 	defaultAlg           = AlgPlain
 )
 
@@ -101,6 +102,8 @@ func (a *Algorithm) UnmarshalText(text []byte) error {
 		*a = AlgPlain
 	case string(AlgVault):
 		*a = AlgVault
+	case string(AlgLocal):
+		*a = AlgLocal
 	case "":
 		*a = defaultAlg
 	default:
@@ -0,0 +1,176 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pqcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+const localKeyInfoPrefix = "go-lanai/pqcrypt/"
+
+// localEncryptor implements Encryptor and KeyOperations with keys from local Keyring.
+// Data is encrypted with AES-256-GCM. Per-KeyID data keys are derived from keyring key using HKDF-SHA256,
+// so no key need to be created or stored for each KeyID. The KeyID is also used as additional authenticated data.
+// The ID of keyring key is embedded in the encrypted data, so data encrypted with older keys can still be decrypted
+// after the active key is rotated.
+type localEncryptor struct {
+	keyring *Keyring
+}
+
+// localCipherText is the JSON format of EncryptedRaw.Raw for AlgLocal
+type localCipherText struct {
+	// KeyringKeyID is the ID of the keyring key
+	KeyringKeyID string `json:"k"`
+	// Data is nonce followed by ciphertext and tag
+	Data []byte `json:"d"`
+}
+
+func newLocalEncryptor(keyring *Keyring) Encryptor {
+	return &localEncryptor{
+		keyring: keyring,
+	}
+}
+
+func (enc *localEncryptor) Encrypt(_ context.Context, kid string, v interface{}) (raw *EncryptedRaw, err error) {
+	raw = &EncryptedRaw{
+		Ver:   V2,
+		KeyID: normalizeKeyID(kid),
+		Alg:   AlgLocal,
+	}
+	switch {
+	case raw.KeyID == "":
+		return nil, newEncryptionError("KeyID is required for algorithm %v", raw.Alg)
+	}
+
+	if v == nil {
+		// special rule encrypted []byte(nil) <-> nil
+		return raw, nil
+	}
+
+	jsonVal, e := json.Marshal(v)
+	if e != nil {
+		return nil, newEncryptionError("failed to marshal data - %v", e)
+	}
+
+	keyringKeyID, key := enc.keyring.Active()
+	aead, e := newLocalAEAD(key, raw.KeyID)
+	if e != nil {
+		return nil, newEncryptionError("%v", e)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, e := io.ReadFull(rand.Reader, nonce); e != nil {
+		return nil, newEncryptionError("unable to generate nonce - %v", e)
+	}
+	ct := localCipherText{
+		KeyringKeyID: keyringKeyID,
+		Data:         aead.Seal(nonce, nonce, jsonVal, []byte(raw.KeyID)),
+	}
+	if raw.Raw, e = json.Marshal(ct); e != nil {
+		return nil, newEncryptionError("failed to marshal ciphertext - %v", e)
+	}
+	return
+}
+
+func (enc *localEncryptor) Decrypt(ctx context.Context, raw *EncryptedRaw, dest interface{}) error {
+	switch {
+	case raw == nil:
+		return newDecryptionError("raw data is nil")
+	case raw.Alg != AlgLocal:
+		return ErrUnsupportedAlgorithm
+	case raw.KeyID == "":
+		return newDecryptionError("KeyID is required for algorithm %v", raw.Alg)
+	}
+
+	switch raw.Ver {
+	case V2:
+		return enc.decrypt(ctx, raw, dest)
+	default:
+		return ErrUnsupportedVersion
+	}
+}
+
+func (enc *localEncryptor) KeyOperations() KeyOperations {
+	return enc
+}
+
+/* KeyOperations */
+
+// Create validates the key ID. Data keys are derived from keyring, so there is nothing to create
+func (enc *localEncryptor) Create(_ context.Context, kid string, _ ...KeyOptions) error {
+	if normalizeKeyID(kid) == "" {
+		return fmt.Errorf("invalid key ID")
+	}
+	return nil
+}
+
+/* Helpers */
+
+func (enc *localEncryptor) decrypt(_ context.Context, raw *EncryptedRaw, dest interface{}) error {
+	if len(raw.Raw) == 0 {
+		// special rule encrypted []byte(nil) <-> nil
+		return tryAssign(nil, dest)
+	}
+
+	var ct localCipherText
+	if e := json.Unmarshal(raw.Raw, &ct); e != nil {
+		return newDecryptionError("invalid ciphertext - %v", e)
+	}
+	key, ok := enc.keyring.Key(ct.KeyringKeyID)
+	if !ok {
+		return newDecryptionError("keyring key [%s] is not available", ct.KeyringKeyID)
+	}
+
+	kid := normalizeKeyID(raw.KeyID)
+	aead, e := newLocalAEAD(key, kid)
+	if e != nil {
+		return newDecryptionError("%v", e)
+	}
+	if len(ct.Data) < aead.NonceSize() {
+		return newDecryptionError("invalid ciphertext - too short")
+	}
+	nonce, sealed := ct.Data[:aead.NonceSize()], ct.Data[aead.NonceSize():]
+	plain, e := aead.Open(nil, nonce, sealed, []byte(kid))
+	if e != nil {
+		return newDecryptionError("unable to decrypt ciphertext - %v", e)
+	}
+
+	if e := json.Unmarshal(plain, dest); e != nil {
+		return newDecryptionError("failed to unmarshal decrypted data - %v", e)
+	}
+	return nil
+}
+
+// newLocalAEAD derive data key of given KeyID from keyring key and create AES-GCM AEAD
+func newLocalAEAD(key []byte, kid string) (cipher.AEAD, error) {
+	dataKey := make([]byte, keyringKeySize)
+	if _, e := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(localKeyInfoPrefix+kid)), dataKey); e != nil {
+		return nil, fmt.Errorf("unable to derive data key - %v", e)
+	}
+	block, e := aes.NewCipher(dataKey)
+	if e != nil {
+		return nil, e
+	}
+	return cipher.NewGCM(block)
+}
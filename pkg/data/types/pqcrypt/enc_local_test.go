@@ -0,0 +1,366 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pqcrypt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/cisco-open/go-lanai/pkg/vault"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/google/uuid"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const (
+	TestKeyringKey1 = "key-1"
+	TestKeyringKey2 = "key-2"
+)
+
+/*************************
+	Test Cases
+ *************************/
+
+func TestLocalEncryptor(t *testing.T) {
+	enc := newLocalEncryptor(newTestKeyring(t, TestKeyringKey1))
+	mapValue := map[string]interface{}{
+		"key1": "value1",
+		"key2": 2.0,
+	}
+	strValue := "this is a string"
+	arrValue := []interface{}{"value1", 2.0}
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestLocalEncryptor(enc, mapValue), "LocalMap"),
+		test.GomegaSubTest(SubTestLocalEncryptor(enc, strValue), "LocalString"),
+		test.GomegaSubTest(SubTestLocalEncryptor(enc, arrValue), "LocalSlice"),
+		test.GomegaSubTest(SubTestLocalEncryptor(enc, nil), "LocalNil"),
+		test.GomegaSubTest(SubTestLocalKeyOperations(enc), "KeyOperations"),
+	)
+}
+
+func TestLocalEncryptorKeyRotation(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestLocalKeyRotation(), "DecryptWithRotatedKey"),
+		test.GomegaSubTest(SubTestLocalRemovedKey(), "DecryptWithRemovedKey"),
+	)
+}
+
+func TestLocalFailedDecrypt(t *testing.T) {
+	enc := newLocalEncryptor(newTestKeyring(t, TestKeyringKey1))
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestLocalFailedEncryption(enc), "InvalidKeyID"),
+		test.GomegaSubTest(SubTestPlainTextFailedDecryption(enc, Version(-1), AlgLocal, ErrUnsupportedVersion), "InvalidVersion"),
+		test.GomegaSubTest(SubTestPlainTextFailedDecryption(enc, V1, AlgLocal, ErrUnsupportedVersion), "V1Unsupported"),
+		test.GomegaSubTest(SubTestPlainTextFailedDecryption(enc, V2, AlgVault, ErrUnsupportedAlgorithm), "UnsupportedAlg"),
+		test.GomegaSubTest(SubTestLocalTamperedData(enc), "TamperedData"),
+		test.GomegaSubTest(SubTestLocalWrongKeyID(enc), "WrongKeyID"),
+	)
+}
+
+func TestKeyring(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestKeyringFileSource(), "FileSource"),
+		test.GomegaSubTest(SubTestKeyringVaultSource(), "VaultSource"),
+		test.GomegaSubTest(SubTestKeyringDefaultActive(), "DefaultActive"),
+		test.GomegaSubTest(SubTestKeyringInvalidConfig(), "InvalidConfig"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestLocalEncryptor(enc Encryptor, v interface{}) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		kid := uuid.New().String()
+
+		// encrypt
+		raw, e := enc.Encrypt(ctx, kid, v)
+		g.Expect(e).To(Succeed(), "Encrypt shouldn't return error")
+		g.Expect(raw.Ver).To(BeIdenticalTo(V2), "encrypted data should be V2")
+		g.Expect(raw.Alg).To(BeIdenticalTo(AlgLocal), "encrypted data should have correct alg")
+		g.Expect(raw.KeyID).To(BeIdenticalTo(kid), "encrypted data should have correct KeyID")
+		if v != nil {
+			plain, _ := json.Marshal(v)
+			g.Expect(string(raw.Raw)).ToNot(ContainSubstring(string(plain)), "encrypted raw should not contain plain text")
+		} else {
+			g.Expect(raw.Raw).To(BeEmpty(), "encrypted raw of nil should be empty")
+		}
+
+		// serialize
+		bytes, e := json.Marshal(raw)
+		g.Expect(e).To(Succeed(), "JSON marshal of raw data shouldn't return error")
+		testLocalDecryption(g, enc, bytes, kid, v)
+	}
+}
+
+func SubTestLocalKeyOperations(enc Encryptor) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		e := enc.KeyOperations().Create(ctx, uuid.New().String())
+		g.Expect(e).To(Succeed(), "Create key shouldn't return error")
+		e = enc.KeyOperations().Create(ctx, "")
+		g.Expect(e).To(HaveOccurred(), "Create key with empty KeyID should return error")
+	}
+}
+
+func SubTestLocalKeyRotation() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		kid := uuid.New().String()
+		v := map[string]interface{}{"key1": "value1"}
+		oldEnc := newLocalEncryptor(newTestKeyring(t, TestKeyringKey1))
+		raw, e := oldEnc.Encrypt(ctx, kid, v)
+		g.Expect(e).To(Succeed(), "Encrypt shouldn't return error")
+		bytes, _ := json.Marshal(raw)
+
+		// rotate
+		newEnc := newLocalEncryptor(newTestKeyring(t, TestKeyringKey2))
+		testLocalDecryption(g, newEnc, bytes, kid, v)
+
+		// new data should use new key
+		raw, e = newEnc.Encrypt(ctx, kid, v)
+		g.Expect(e).To(Succeed(), "Encrypt shouldn't return error")
+		var ct localCipherText
+		g.Expect(json.Unmarshal(raw.Raw, &ct)).To(Succeed(), "encrypted raw should be valid")
+		g.Expect(ct.KeyringKeyID).To(Equal(TestKeyringKey2), "encrypted raw should use active key")
+	}
+}
+
+func SubTestLocalRemovedKey() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		kid := uuid.New().String()
+		oldEnc := newLocalEncryptor(newTestKeyring(t, TestKeyringKey1))
+		raw, e := oldEnc.Encrypt(ctx, kid, "value")
+		g.Expect(e).To(Succeed(), "Encrypt shouldn't return error")
+
+		keyring, e := NewKeyring(&KeyringProperties{
+			Keys: []KeyringKeyProperties{newTestKeyProperties(TestKeyringKey2)},
+		})
+		g.Expect(e).To(Succeed(), "NewKeyring shouldn't return error")
+		var decrypted string
+		e = newLocalEncryptor(keyring).Decrypt(ctx, raw, &decrypted)
+		g.Expect(e).To(HaveOccurred(), "Decrypt with removed key should return error")
+	}
+}
+
+func SubTestLocalFailedEncryption(enc Encryptor) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		_, e := enc.Encrypt(ctx, "", "value")
+		g.Expect(e).To(Not(Succeed()), "Encrypt should return error")
+	}
+}
+
+func SubTestLocalTamperedData(enc Encryptor) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		kid := uuid.New().String()
+		raw, e := enc.Encrypt(ctx, kid, "value")
+		g.Expect(e).To(Succeed(), "Encrypt shouldn't return error")
+
+		var ct localCipherText
+		g.Expect(json.Unmarshal(raw.Raw, &ct)).To(Succeed(), "encrypted raw should be valid")
+		ct.Data[len(ct.Data)-1] ^= 0xff
+		raw.Raw, _ = json.Marshal(ct)
+
+		var decrypted string
+		e = enc.Decrypt(ctx, raw, &decrypted)
+		g.Expect(e).To(HaveOccurred(), "Decrypt of tampered data should return error")
+		g.Expect(decrypted).To(BeEmpty(), "decrypted value should be empty")
+	}
+}
+
+func SubTestLocalWrongKeyID(enc Encryptor) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		raw, e := enc.Encrypt(ctx, uuid.New().String(), "value")
+		g.Expect(e).To(Succeed(), "Encrypt shouldn't return error")
+
+		raw.KeyID = uuid.New().String()
+		var decrypted string
+		e = enc.Decrypt(ctx, raw, &decrypted)
+		g.Expect(e).To(HaveOccurred(), "Decrypt with different KeyID should return error")
+	}
+}
+
+func SubTestKeyringFileSource() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		dir := t.TempDir()
+		rawFile := filepath.Join(dir, "raw.key")
+		b64File := filepath.Join(dir, "b64.key")
+		g.Expect(os.WriteFile(rawFile, testKey(TestKeyringKey1), 0600)).To(Succeed())
+		g.Expect(os.WriteFile(b64File, []byte(base64.StdEncoding.EncodeToString(testKey(TestKeyringKey2))+"\n"), 0600)).To(Succeed())
+
+		keyring, e := NewKeyring(&KeyringProperties{
+			Active: TestKeyringKey2,
+			Keys: []KeyringKeyProperties{
+				{ID: TestKeyringKey1, Type: KeySourceFile, File: rawFile},
+				{ID: TestKeyringKey2, Type: KeySourceFile, File: b64File},
+			},
+		})
+		g.Expect(e).To(Succeed(), "NewKeyring shouldn't return error")
+		id, key := keyring.Active()
+		g.Expect(id).To(Equal(TestKeyringKey2), "active key ID should be correct")
+		g.Expect(key).To(Equal(testKey(TestKeyringKey2)), "active key should be correct")
+		key, ok := keyring.Key(TestKeyringKey1)
+		g.Expect(ok).To(BeTrue(), "key should be available")
+		g.Expect(key).To(Equal(testKey(TestKeyringKey1)), "raw key should be correct")
+	}
+}
+
+func SubTestKeyringVaultSource() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		// mocked KV v1 and v2 secrets
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			var data map[string]interface{}
+			switch r.URL.Path {
+			case "/v1/secret/keys":
+				data = map[string]interface{}{"key": base64.StdEncoding.EncodeToString(testKey(TestKeyringKey1))}
+			case "/v1/secret/data/keys":
+				data = map[string]interface{}{
+					"data":     map[string]interface{}{"my-key": base64.StdEncoding.EncodeToString(testKey(TestKeyringKey2))},
+					"metadata": map[string]interface{}{"version": 1},
+				}
+			default:
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(map[string]interface{}{"data": data})
+		}))
+		defer srv.Close()
+		srvUrl, e := url.Parse(srv.URL)
+		g.Expect(e).To(Succeed(), "parsing server URL shouldn't fail")
+		port, _ := strconv.Atoi(srvUrl.Port())
+		client, e := vault.New(vault.WithProperties(vault.ConnectionProperties{
+			Host:           srvUrl.Hostname(),
+			Port:           port,
+			Scheme:         "http",
+			Authentication: vault.Token,
+			Token:          "test-token",
+		}))
+		g.Expect(e).To(Succeed(), "creating vault client shouldn't fail")
+
+		keyring, e := NewKeyring(&KeyringProperties{
+			Keys: []KeyringKeyProperties{
+				{ID: TestKeyringKey1, Type: KeySourceVault, Path: "secret/keys"},
+				{ID: TestKeyringKey2, Type: KeySourceVault, Path: "secret/data/keys", Field: "my-key"},
+			},
+		}, WithVaultClient(ctx, client))
+		g.Expect(e).To(Succeed(), "NewKeyring shouldn't return error")
+		key, ok := keyring.Key(TestKeyringKey1)
+		g.Expect(ok).To(BeTrue(), "key should be available")
+		g.Expect(key).To(Equal(testKey(TestKeyringKey1)), "KV v1 key should be correct")
+		key, ok = keyring.Key(TestKeyringKey2)
+		g.Expect(ok).To(BeTrue(), "key should be available")
+		g.Expect(key).To(Equal(testKey(TestKeyringKey2)), "KV v2 key should be correct")
+
+		invalid := []KeyringKeyProperties{
+			{ID: TestKeyringKey1, Type: KeySourceVault, Path: "secret/non-exist"},
+			{ID: TestKeyringKey1, Type: KeySourceVault, Path: "secret/keys", Field: "unknown"},
+			{ID: TestKeyringKey1, Type: KeySourceVault},
+		}
+		for i := range invalid {
+			_, e := NewKeyring(&KeyringProperties{Keys: invalid[i : i+1]}, WithVaultClient(ctx, client))
+			g.Expect(e).To(HaveOccurred(), "NewKeyring should return error for invalid vault source at index %d", i)
+		}
+		_, e = NewKeyring(&KeyringProperties{Keys: []KeyringKeyProperties{{ID: TestKeyringKey1, Type: KeySourceVault, Path: "secret/keys"}}})
+		g.Expect(e).To(HaveOccurred(), "NewKeyring should return error without vault client")
+	}
+}
+
+func SubTestKeyringDefaultActive() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		keyring, e := NewKeyring(&KeyringProperties{
+			Keys: []KeyringKeyProperties{newTestKeyProperties(TestKeyringKey1), newTestKeyProperties(TestKeyringKey2)},
+		})
+		g.Expect(e).To(Succeed(), "NewKeyring shouldn't return error")
+		id, _ := keyring.Active()
+		g.Expect(id).To(Equal(TestKeyringKey1), "first key should be active by default")
+	}
+}
+
+func SubTestKeyringInvalidConfig() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		invalid := []KeyringProperties{
+			{},
+			{Active: "unknown", Keys: []KeyringKeyProperties{newTestKeyProperties(TestKeyringKey1)}},
+			{Keys: []KeyringKeyProperties{newTestKeyProperties(TestKeyringKey1), newTestKeyProperties(TestKeyringKey1)}},
+			{Keys: []KeyringKeyProperties{{ID: TestKeyringKey1, Value: base64.StdEncoding.EncodeToString([]byte("too short"))}}},
+			{Keys: []KeyringKeyProperties{{ID: TestKeyringKey1, Value: "not base64!"}}},
+			{Keys: []KeyringKeyProperties{{ID: TestKeyringKey1, Type: "unknown"}}},
+			{Keys: []KeyringKeyProperties{{ID: TestKeyringKey1, Type: KeySourceFile, File: "testdata/non-exist.key"}}},
+			{Keys: []KeyringKeyProperties{{Value: base64.StdEncoding.EncodeToString(testKey(TestKeyringKey1))}}},
+		}
+		for i := range invalid {
+			_, e := NewKeyring(&invalid[i])
+			g.Expect(e).To(HaveOccurred(), "NewKeyring should return error for invalid config at index %d", i)
+		}
+	}
+}
+
+/* Helpers */
+
+// testKey generates deterministic 32 bytes key for given ID
+func testKey(id string) []byte {
+	key := make([]byte, keyringKeySize)
+	copy(key, id)
+	return key
+}
+
+func newTestKeyProperties(id string) KeyringKeyProperties {
+	return KeyringKeyProperties{
+		ID:    id,
+		Value: base64.StdEncoding.EncodeToString(testKey(id)),
+	}
+}
+
+// newTestKeyring creates keyring with both test keys and given active key
+func newTestKeyring(t *testing.T, active string) *Keyring {
+	keyring, e := NewKeyring(&KeyringProperties{
+		Active: active,
+		Keys:   []KeyringKeyProperties{newTestKeyProperties(TestKeyringKey1), newTestKeyProperties(TestKeyringKey2)},
+	})
+	if e != nil {
+		t.Fatalf("unable to create keyring: %v", e)
+	}
+	return keyring
+}
+
+func testLocalDecryption(g *gomega.WithT, enc Encryptor, bytes []byte, expectedKid string, expectedVal interface{}) {
+	// deserialize
+	parsed := EncryptedRaw{}
+	e := json.Unmarshal(bytes, &parsed)
+	g.Expect(e).To(Succeed(), "JSON unmarshal of raw data shouldn't return error")
+	g.Expect(parsed.Ver).To(BeIdenticalTo(V2), "unmarshalled data should be V2")
+	g.Expect(parsed.KeyID).To(Equal(expectedKid), "unmarshalled KeyID should be correct")
+	g.Expect(parsed.Alg).To(BeIdenticalTo(AlgLocal), "unmarshalled Alg should be correct")
+
+	// decrypt
+	decrypted := interface{}(nil)
+	e = enc.Decrypt(context.Background(), &parsed, &decrypted)
+	g.Expect(e).To(Succeed(), "decrypted of raw data shouldn't return error")
+	if expectedVal != nil {
+		g.Expect(decrypted).To(BeEquivalentTo(expectedVal), "decrypted value should be correct")
+	} else {
+		g.Expect(decrypted).To(BeNil(), "decrypted value should be correct")
+	}
+}
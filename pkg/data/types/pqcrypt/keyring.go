@@ -0,0 +1,192 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pqcrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/vault"
+	"os"
+	"strings"
+)
+
+const (
+	KeySourceInline KeySourceType = "inline"
+	KeySourceFile   KeySourceType = "file"
+	KeySourceVault  KeySourceType = "vault"
+)
+
+const defaultVaultKeyField = "key"
+
+// KeySourceType defines where a keyring key is loaded from
+type KeySourceType string
+
+const keyringKeySize = 32
+
+type keyLoader func(opt *KeyringOption, props *KeyringKeyProperties) ([]byte, error)
+
+var keyLoaders = map[KeySourceType]keyLoader{
+	KeySourceInline: loadInlineKey,
+	KeySourceFile:   loadFileKey,
+	KeySourceVault:  loadVaultKey,
+}
+
+type KeyringOptions func(opt *KeyringOption)
+type KeyringOption struct {
+	// Context is used when loading keys from remote sources
+	Context context.Context
+	// VaultClient is required by "vault" key source
+	VaultClient *vault.Client
+}
+
+func WithVaultClient(ctx context.Context, client *vault.Client) KeyringOptions {
+	return func(opt *KeyringOption) {
+		opt.Context = ctx
+		opt.VaultClient = client
+	}
+}
+
+// Keyring holds a set of AES-256 keys identified by ID. The active key is used for encryption,
+// and all keys are available for decryption, which allows key rotation without re-encrypting existing data.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyring load keys according to given properties.
+func NewKeyring(props *KeyringProperties, opts ...KeyringOptions) (*Keyring, error) {
+	if len(props.Keys) == 0 {
+		return nil, fmt.Errorf("keyring has no keys")
+	}
+	opt := KeyringOption{
+		Context: context.Background(),
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	keyring := Keyring{
+		active: props.Active,
+		keys:   make(map[string][]byte),
+	}
+	for i := range props.Keys {
+		kp := &props.Keys[i]
+		if kp.ID == "" {
+			return nil, fmt.Errorf("keyring key at index %d doesn't have ID", i)
+		}
+		if _, ok := keyring.keys[kp.ID]; ok {
+			return nil, fmt.Errorf("duplicated keyring key ID [%s]", kp.ID)
+		}
+		typ := KeySourceType(strings.ToLower(string(kp.Type)))
+		if typ == "" {
+			typ = KeySourceInline
+		}
+		loader, ok := keyLoaders[typ]
+		if !ok {
+			return nil, fmt.Errorf("unsupported key source type [%s] of key [%s]", kp.Type, kp.ID)
+		}
+		key, e := loader(&opt, kp)
+		if e != nil {
+			return nil, fmt.Errorf("unable to load key [%s]: %v", kp.ID, e)
+		}
+		if len(key) != keyringKeySize {
+			return nil, fmt.Errorf("key [%s] should be %d bytes, but got %d bytes", kp.ID, keyringKeySize, len(key))
+		}
+		keyring.keys[kp.ID] = key
+	}
+
+	if keyring.active == "" {
+		keyring.active = props.Keys[0].ID
+	}
+	if _, ok := keyring.keys[keyring.active]; !ok {
+		return nil, fmt.Errorf("active key [%s] is not found in keyring", keyring.active)
+	}
+	return &keyring, nil
+}
+
+// Active returns the ID and value of the key used for encryption
+func (k *Keyring) Active() (id string, key []byte) {
+	return k.active, k.keys[k.active]
+}
+
+// Key returns the key with given ID
+func (k *Keyring) Key(id string) ([]byte, bool) {
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+/* Loaders */
+
+func loadInlineKey(_ *KeyringOption, props *KeyringKeyProperties) ([]byte, error) {
+	return decodeKey([]byte(props.Value))
+}
+
+func loadFileKey(_ *KeyringOption, props *KeyringKeyProperties) ([]byte, error) {
+	data, e := os.ReadFile(props.File)
+	if e != nil {
+		return nil, e
+	}
+	if len(data) == keyringKeySize {
+		return data, nil
+	}
+	return decodeKey(data)
+}
+
+// loadVaultKey reads base64 encoded key from a secret of Vault KV secret engine. Both version 1 and 2 are supported
+func loadVaultKey(opt *KeyringOption, props *KeyringKeyProperties) ([]byte, error) {
+	if opt.VaultClient == nil {
+		return nil, fmt.Errorf("vault client is not available")
+	}
+	if props.Path == "" {
+		return nil, fmt.Errorf("vault secret path is required")
+	}
+	secret, e := opt.VaultClient.Logical(opt.Context).Read(props.Path)
+	switch {
+	case e != nil:
+		return nil, e
+	case secret == nil || secret.Data == nil:
+		return nil, fmt.Errorf("vault secret [%s] is not found", props.Path)
+	}
+	data := secret.Data
+	// KV version 2 nests the secret in "data"
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	field := props.Field
+	if field == "" {
+		field = defaultVaultKeyField
+	}
+	encoded, ok := data[field].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault secret [%s] doesn't have field [%s]", props.Path, field)
+	}
+	return decodeKey([]byte(encoded))
+}
+
+func decodeKey(encoded []byte) ([]byte, error) {
+	encoded = bytes.TrimSpace(encoded)
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("key value is empty")
+	}
+	key := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, e := base64.StdEncoding.Decode(key, encoded)
+	if e != nil {
+		return nil, fmt.Errorf("key value is not valid base64: %v", e)
+	}
+	return key[:n], nil
+}
@@ -57,6 +57,15 @@ func TestNoopCreateKey(t *testing.T) {
 	)
 }
 
+func TestProvideEncryptor(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestProvideEncryptor(false, "unknown", true), "Disabled"),
+		test.GomegaSubTest(SubTestProvideEncryptor(true, ProviderVault, false), "VaultWithoutClient"),
+		test.GomegaSubTest(SubTestProvideEncryptor(true, "", false), "DefaultWithoutClient"),
+		test.GomegaSubTest(SubTestProvideEncryptor(true, "unknown", false), "UnknownProvider"),
+	)
+}
+
 /*************************
 	Sub-Test Cases
  *************************/
@@ -71,3 +80,20 @@ func SubTestCreateKey(uuid uuid.UUID, expectSuccess bool) test.GomegaSubTestFunc
 		}
 	}
 }
+
+func SubTestProvideEncryptor(enabled bool, provider string, expectSuccess bool) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := NewDataEncryptionProperties()
+		props.Enabled = enabled
+		props.Provider = provider
+		fn := func() {
+			out := provideEncryptor(encDI{Properties: *props})
+			g.Expect(out.Enc).To(BeAssignableToTypeOf(plainTextEncryptor{}), "encryptor should be plain text")
+		}
+		if expectSuccess {
+			g.Expect(fn).ToNot(Panic(), "provideEncryptor should not fail")
+		} else {
+			g.Expect(fn).To(Panic(), "provideEncryptor should fail")
+		}
+	}
+}
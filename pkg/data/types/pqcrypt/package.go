@@ -21,11 +21,13 @@ import (
     "fmt"
     appconfig "github.com/cisco-open/go-lanai/pkg/appconfig/init"
     "github.com/cisco-open/go-lanai/pkg/bootstrap"
+    "github.com/cisco-open/go-lanai/pkg/log"
     "github.com/cisco-open/go-lanai/pkg/vault"
     "go.uber.org/fx"
+    "strings"
 )
 
-//var logger = log.New("Data.Enc")
+var logger = log.New("Data.Enc")
 
 //go:embed defaults-data-enc.yml
 var defaultConfigFS embed.FS
@@ -50,6 +52,7 @@ func Use() {
 
 type encDI struct {
 	fx.In
+	AppCtx     *bootstrap.ApplicationContext
 	Properties DataEncryptionProperties `optional:"true"`
 	Client     *vault.Client            `optional:"true"`
 	UnnamedEnc Encryptor                `optional:"true"`
@@ -68,15 +71,24 @@ func provideEncryptor(di encDI) encOut {
 	}
 
 	var enc Encryptor
-	switch {
-	case di.Properties.Enabled:
+	switch provider := strings.ToLower(di.Properties.Provider); {
+	case !di.Properties.Enabled:
+		enc = plainTextEncryptor{}
+	case provider == ProviderLocal:
+		keyring, e := NewKeyring(&di.Properties.Keyring, WithVaultClient(di.AppCtx, di.Client))
+		if e != nil {
+			panic(fmt.Errorf("data encryption enabled but keyring is not properly configured: %v", e))
+		}
+		enc = compositeEncryptor{newLocalEncryptor(keyring), plainTextEncryptor{}}
+	case provider == ProviderVault || provider == "":
+		// empty provider is treated as vault for backward compatibility
 		if di.Client == nil {
 			panic(fmt.Errorf("data encryption enabled but vault client is not initialized"))
 		}
 		venc := newVaultEncryptor(di.Client, &di.Properties.Key)
 		enc = compositeEncryptor{venc, plainTextEncryptor{}}
 	default:
-		enc = plainTextEncryptor{}
+		panic(fmt.Errorf("data encryption enabled but provider [%s] is not supported", di.Properties.Provider))
 	}
 	return encOut{
 		Enc: enc,
@@ -29,8 +29,11 @@ const (
 )
 
 type DataEncryptionProperties struct {
-	Enabled bool          `json:"enabled"`
-	Key     KeyProperties `json:"key"`
+	Enabled bool `json:"enabled"`
+	// Provider of encryption when enabled. Supported values are "vault" (default) and "local".
+	Provider string            `json:"provider"`
+	Key      KeyProperties     `json:"key"`
+	Keyring  KeyringProperties `json:"keyring"`
 }
 
 type KeyProperties struct {
@@ -39,6 +42,34 @@ type KeyProperties struct {
 	AllowPlaintextBackup bool   `json:"allow-plaintext-backup"`
 }
 
+// KeyringProperties configures keys of "local" provider.
+type KeyringProperties struct {
+	// Active is the ID of the key used for encryption. Other keys are only used for decryption.
+	// When not set, the first key is used.
+	Active string `json:"active"`
+	// Keys are 256-bit AES keys identified by ID. ID is embedded in encrypted data and should never be reused for a different key.
+	Keys []KeyringKeyProperties `json:"keys"`
+}
+
+type KeyringKeyProperties struct {
+	ID string `json:"id"`
+	// Type of the key source. Supported values are "inline" (default), "file" and "vault"
+	Type KeySourceType `json:"type"`
+	// Value is the base64 encoded key, used by "inline" source
+	Value string `json:"value"`
+	// File is the path of a file containing raw or base64 encoded key, used by "file" source
+	File string `json:"file"`
+	// Path is the path of Vault KV secret containing base64 encoded key, used by "vault" source. e.g. "secret/data/my-keys"
+	Path string `json:"path"`
+	// Field is the field of the Vault secret containing the key, used by "vault" source. Default is "key"
+	Field string `json:"field"`
+}
+
+const (
+	ProviderVault = "vault"
+	ProviderLocal = "local"
+)
+
 // https://www.vaultproject.io/api/secret/transit#create-key
 const (
 	KeyTypeAES128   = "aes128-gcm96"
@@ -80,7 +111,8 @@ func (t *KeyType) UnmarshalText(text []byte) error {
 //NewDataEncryptionProperties create a CockroachProperties with default values
 func NewDataEncryptionProperties() *DataEncryptionProperties {
 	return &DataEncryptionProperties{
-		Enabled: false,
+		Enabled:  false,
+		Provider: ProviderVault,
 		Key: KeyProperties{
 			Type:                 defaultKeyType,
 			Exportable:           false,
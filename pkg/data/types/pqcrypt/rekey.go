@@ -0,0 +1,388 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pqcrypt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/data/tx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+const defaultRekeyBatchSize = 100
+
+var (
+	typeEncryptedRaw = reflect.TypeOf(EncryptedRaw{})
+	typeEncryptedMap = reflect.TypeOf(EncryptedMap{})
+)
+
+// RekeyOptions customizes Rekey
+type RekeyOptions func(opt *RekeyOption)
+
+type RekeyOption struct {
+	// Columns are names of encrypted fields or columns to re-encrypt.
+	// When empty, all fields of type EncryptedMap or EncryptedRaw are re-encrypted
+	Columns []string
+	// BatchSize is the number of rows processed in each transaction
+	BatchSize int
+	// Filter decides whether given encrypted value need to be re-encrypted. When nil, all non-null values are re-encrypted
+	Filter func(raw *EncryptedRaw) bool
+	// KeyIDMapper returns the KeyID used to re-encrypt given value. When nil, the original KeyID is kept.
+	// When KeyID is changed, the new key is created via KeyOperations.Create before use.
+	KeyIDMapper func(ctx context.Context, raw *EncryptedRaw) (string, error)
+	// ProgressFunc is invoked after each batch is committed. When nil, progress is logged
+	ProgressFunc func(ctx context.Context, progress RekeyProgress)
+}
+
+// RekeyProgress reports accumulated progress of Rekey
+type RekeyProgress struct {
+	Table string
+	// Batches is the number of committed batches
+	Batches int
+	// Scanned is the number of scanned rows
+	Scanned int
+	// Rekeyed is the number of rows with at least one value re-encrypted
+	Rekeyed int
+	// LastKey is the primary key of the last scanned row
+	LastKey interface{}
+}
+
+// RekeyWithColumns specifies which encrypted fields or columns to re-encrypt
+func RekeyWithColumns(columns ...string) RekeyOptions {
+	return func(opt *RekeyOption) {
+		opt.Columns = append(opt.Columns, columns...)
+	}
+}
+
+// RekeyWithBatchSize specifies the number of rows processed in each transaction
+func RekeyWithBatchSize(size int) RekeyOptions {
+	return func(opt *RekeyOption) {
+		opt.BatchSize = size
+	}
+}
+
+// RekeyWithFilter specifies which encrypted values need to be re-encrypted
+func RekeyWithFilter(filter func(raw *EncryptedRaw) bool) RekeyOptions {
+	return func(opt *RekeyOption) {
+		opt.Filter = filter
+	}
+}
+
+// RekeyToKeyID specifies how to choose the KeyID of re-encrypted values
+func RekeyToKeyID(mapper func(ctx context.Context, raw *EncryptedRaw) (string, error)) RekeyOptions {
+	return func(opt *RekeyOption) {
+		opt.KeyIDMapper = mapper
+	}
+}
+
+// RekeyWithProgress specifies a function to receive progress
+func RekeyWithProgress(fn func(ctx context.Context, progress RekeyProgress)) RekeyOptions {
+	return func(opt *RekeyOption) {
+		opt.ProgressFunc = fn
+	}
+}
+
+// RekeyIfNot returns a filter that re-encrypts values not yet encrypted with given version and algorithm,
+// e.g. RekeyIfNot(V2, AlgVault) migrates plain text and V1 data to Vault
+func RekeyIfNot(ver Version, alg Algorithm) func(raw *EncryptedRaw) bool {
+	return func(raw *EncryptedRaw) bool {
+		return raw.Ver != ver || raw.Alg != alg
+	}
+}
+
+// Rekey walks through all rows of given gorm model in batches of primary key order, decrypts encrypted fields
+// and re-encrypts them with currently configured Encryptor. Each batch is processed within a transaction and
+// selected rows are locked for update, so it's safe to run while the application is serving traffic.
+// Rekey cannot run within an outer transaction, because rows locked by each batch would stay locked until the outer
+// transaction is committed. Error is returned if the context carries a transaction (e.g. a transactional migration step).
+// The model should have a single primary key.
+func Rekey(ctx context.Context, db *gorm.DB, model interface{}, opts ...RekeyOptions) (*RekeyProgress, error) {
+	if tx.GormTxWithContext(ctx) != nil {
+		return nil, fmt.Errorf("rekey cannot run within a transaction")
+	}
+	opt := RekeyOption{
+		BatchSize: defaultRekeyBatchSize,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	r, e := newRekeyer(db, model, &opt)
+	if e != nil {
+		return nil, e
+	}
+	return r.run(ctx)
+}
+
+// RekeyMigrationFunc wraps Rekey as a function that can be used as migration step.
+// The step should be non-transactional, e.g.
+// <code>
+// r.AddMigrations(migration.WithVersion("1.0.0.1").WithFunc(pqcrypt.RekeyMigrationFunc(db, &MyModel{})).WithoutTransaction())
+// </code>
+func RekeyMigrationFunc(db *gorm.DB, model interface{}, opts ...RekeyOptions) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, e := Rekey(ctx, db, model, opts...)
+		return e
+	}
+}
+
+/**************************
+	Helpers
+***************************/
+
+type rekeyer struct {
+	db       *gorm.DB
+	opt      *RekeyOption
+	table    string
+	pk       string
+	columns  []string
+	progress RekeyProgress
+	created  map[string]struct{}
+}
+
+func newRekeyer(db *gorm.DB, model interface{}, opt *RekeyOption) (*rekeyer, error) {
+	if opt.BatchSize <= 0 {
+		return nil, fmt.Errorf("invalid rekey batch size %d", opt.BatchSize)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if e := stmt.Parse(model); e != nil {
+		return nil, fmt.Errorf("unable to parse model %T: %v", model, e)
+	}
+	s := stmt.Schema
+	if len(s.PrimaryFields) != 1 {
+		return nil, fmt.Errorf("rekey requires model with single primary key, but %T has %d", model, len(s.PrimaryFields))
+	}
+
+	var columns []string
+	if len(opt.Columns) == 0 {
+		for _, f := range s.Fields {
+			if f.DBName != "" && isEncryptedType(f.FieldType) {
+				columns = append(columns, f.DBName)
+			}
+		}
+	}
+	for _, name := range opt.Columns {
+		f := s.LookUpField(name)
+		if f == nil || f.DBName == "" {
+			return nil, fmt.Errorf("unknown column [%s] of model %T", name, model)
+		}
+		columns = append(columns, f.DBName)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("model %T doesn't have any encrypted columns", model)
+	}
+
+	return &rekeyer{
+		db:       db,
+		opt:      opt,
+		table:    stmt.Table,
+		pk:       s.PrimaryFields[0].DBName,
+		columns:  columns,
+		progress: RekeyProgress{Table: stmt.Table},
+		created:  map[string]struct{}{},
+	}, nil
+}
+
+func (r *rekeyer) run(ctx context.Context) (*RekeyProgress, error) {
+	for {
+		var batch rekeyBatchResult
+		e := r.transaction(ctx, func(ctx context.Context) (err error) {
+			batch, err = r.rekeyBatch(ctx)
+			return
+		})
+		if e != nil {
+			return &r.progress, fmt.Errorf("failed to rekey table [%s] after key [%v]: %v", r.table, r.progress.LastKey, e)
+		}
+		if batch.scanned == 0 {
+			break
+		}
+		// progress is only updated after the batch is committed
+		r.progress.Scanned += batch.scanned
+		r.progress.Rekeyed += batch.rekeyed
+		r.progress.LastKey = batch.lastKey
+		r.progress.Batches++
+		r.report(ctx)
+		if batch.scanned < r.opt.BatchSize {
+			break
+		}
+	}
+	return &r.progress, nil
+}
+
+func (r *rekeyer) transaction(ctx context.Context, fn tx.TxFunc) error {
+	return r.db.WithContext(ctx).Transaction(func(txDB *gorm.DB) error {
+		return fn(tx.NewGormTxContext(ctx, txDB))
+	})
+}
+
+type rekeyRow struct {
+	key    interface{}
+	values [][]byte
+}
+
+type rekeyBatchResult struct {
+	scanned int
+	rekeyed int
+	lastKey interface{}
+}
+
+// rekeyBatch process next batch and returns number of scanned and rekeyed rows, and the key of last scanned row.
+// It doesn't update progress, because the transaction might not be committed
+func (r *rekeyer) rekeyBatch(ctx context.Context) (rekeyBatchResult, error) {
+	db := tx.GormTxWithContext(ctx)
+	rows, e := r.selectBatch(db)
+	if e != nil {
+		return rekeyBatchResult{}, e
+	}
+
+	var rekeyed int
+	for _, row := range rows {
+		updates := map[string]interface{}{}
+		for i, v := range row.values {
+			newRaw, e := r.rekeyValue(ctx, v)
+			if e != nil {
+				return rekeyBatchResult{}, fmt.Errorf("column [%s] of row [%v]: %v", r.columns[i], row.key, e)
+			}
+			if newRaw != nil {
+				updates[r.columns[i]] = newRaw
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		rs := db.Table(r.table).
+			Where(clause.Eq{Column: clause.Column{Name: r.pk}, Value: row.key}).
+			UpdateColumns(updates)
+		if rs.Error != nil {
+			return rekeyBatchResult{}, rs.Error
+		}
+		rekeyed++
+	}
+
+	if len(rows) == 0 {
+		return rekeyBatchResult{}, nil
+	}
+	return rekeyBatchResult{
+		scanned: len(rows),
+		rekeyed: rekeyed,
+		lastKey: rows[len(rows)-1].key,
+	}, nil
+}
+
+func (r *rekeyer) selectBatch(db *gorm.DB) ([]*rekeyRow, error) {
+	pkCol := clause.Column{Name: r.pk}
+	selected := []clause.Column{pkCol}
+	for _, col := range r.columns {
+		selected = append(selected, clause.Column{Name: col})
+	}
+	q := db.Table(r.table).
+		Clauses(clause.Select{Columns: selected}).
+		Order(clause.OrderByColumn{Column: pkCol}).
+		Limit(r.opt.BatchSize).
+		Clauses(clause.Locking{Strength: "UPDATE"})
+	if r.progress.LastKey != nil {
+		q = q.Where(clause.Gt{Column: pkCol, Value: r.progress.LastKey})
+	}
+	rs, e := q.Rows()
+	if e != nil {
+		return nil, e
+	}
+	defer func() { _ = rs.Close() }()
+
+	// Note: all rows need to be read before any update is executed within the same transaction
+	results := make([]*rekeyRow, 0, r.opt.BatchSize)
+	for rs.Next() {
+		row := rekeyRow{values: make([][]byte, len(r.columns))}
+		dest := make([]interface{}, len(r.columns)+1)
+		dest[0] = &row.key
+		for i := range row.values {
+			dest[i+1] = &row.values[i]
+		}
+		if e := rs.Scan(dest...); e != nil {
+			return nil, e
+		}
+		results = append(results, &row)
+	}
+	return results, rs.Err()
+}
+
+// rekeyValue decrypts and re-encrypts given JSON encoded EncryptedRaw.
+// It returns nil without error if the value doesn't need to be re-encrypted
+func (r *rekeyer) rekeyValue(ctx context.Context, data []byte) (*EncryptedRaw, error) {
+	if data == nil {
+		return nil, nil
+	}
+	raw := EncryptedRaw{}
+	if e := raw.Scan(data); e != nil {
+		return nil, newInvalidFormatError("%v", e)
+	}
+	if r.opt.Filter != nil && !r.opt.Filter(&raw) {
+		return nil, nil
+	}
+
+	// Note: decrypted JSON is re-encrypted as-is. Unmarshalling into interface{} would convert all numbers to float64
+	// and corrupt large integers
+	var v json.RawMessage
+	if e := Decrypt(ctx, &raw, &v); e != nil {
+		return nil, e
+	}
+
+	kid := raw.KeyID
+	if r.opt.KeyIDMapper != nil {
+		var e error
+		if kid, e = r.opt.KeyIDMapper(ctx, &raw); e != nil {
+			return nil, e
+		}
+		if e := r.tryCreateKey(ctx, kid, raw.KeyID); e != nil {
+			return nil, e
+		}
+	}
+	if len(v) == 0 || string(v) == jsonNull {
+		// special rule encrypted []byte(nil) <-> nil
+		return Encrypt(ctx, kid, nil)
+	}
+	return Encrypt(ctx, kid, v)
+}
+
+func (r *rekeyer) tryCreateKey(ctx context.Context, kid, oldKid string) error {
+	kid = normalizeKeyID(kid)
+	if _, ok := r.created[kid]; ok || kid == normalizeKeyID(oldKid) {
+		return nil
+	}
+	if e := CreateKey(ctx, kid); e != nil {
+		return fmt.Errorf("unable to create key [%s]: %v", kid, e)
+	}
+	r.created[kid] = struct{}{}
+	return nil
+}
+
+func (r *rekeyer) report(ctx context.Context) {
+	if r.opt.ProgressFunc != nil {
+		r.opt.ProgressFunc(ctx, r.progress)
+		return
+	}
+	logger.WithContext(ctx).Infof("Rekey [%s]: %d rows scanned, %d rows re-encrypted", r.table, r.progress.Scanned, r.progress.Rekeyed)
+}
+
+func isEncryptedType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == typeEncryptedRaw || t == typeEncryptedMap
+}
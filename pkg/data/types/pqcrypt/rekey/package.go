@@ -0,0 +1,109 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rekey provides a CLI runner that re-encrypts encrypted columns of registered models,
+// typically after key rotation or changing data encryption provider. See pqcrypt.Rekey for details.
+package rekey
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/data/types/pqcrypt"
+	"github.com/cisco-open/go-lanai/pkg/log"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+const (
+	FxRekeyJobGroup = "pqcrypt_rekey_job"
+)
+
+var logger = log.New("Data.Rekey")
+
+var Module = &bootstrap.Module{
+	Name:       "data-encryption-rekey",
+	Precedence: bootstrap.CommandLineRunnerPrecedence,
+	Options: []fx.Option{
+		fx.Provide(provideRekeyRunner()),
+	},
+}
+
+func Use() {
+	pqcrypt.Use()
+	bootstrap.Register(Module)
+	// Note: rekey CliRunner is provided in Module
+	bootstrap.EnableCliRunnerMode()
+}
+
+// Job defines a model to re-encrypt and options for pqcrypt.Rekey
+type Job struct {
+	Model   interface{}
+	Options []pqcrypt.RekeyOptions
+}
+
+// FxJobProviders annotates given constructors that returns *Job to be used by the rekey runner, e.g.
+// <code>
+//
+//	fx.Options(rekey.FxJobProviders(func() *rekey.Job {
+//		return &rekey.Job{Model: &MyModel{}}
+//	}))
+//
+// </code>
+func FxJobProviders(targets ...interface{}) fx.Option {
+	providers := make([]interface{}, len(targets))
+	for i := range targets {
+		providers[i] = fx.Annotated{
+			Group:  FxRekeyJobGroup,
+			Target: targets[i],
+		}
+	}
+	return fx.Provide(providers...)
+}
+
+func provideRekeyRunner() fx.Annotated {
+	return fx.Annotated{
+		Group:  bootstrap.FxCliRunnerGroup,
+		Target: newRekeyRunner,
+	}
+}
+
+type runnerDI struct {
+	fx.In
+	DB   *gorm.DB
+	Jobs []*Job `group:"pqcrypt_rekey_job"`
+}
+
+func newRekeyRunner(di runnerDI) bootstrap.CliRunner {
+	return func(ctx context.Context) error {
+		if len(di.Jobs) == 0 {
+			logger.WithContext(ctx).Warnf("No rekey jobs are registered")
+			return nil
+		}
+		for _, job := range di.Jobs {
+			if job == nil || job.Model == nil {
+				continue
+			}
+			progress, e := pqcrypt.Rekey(ctx, di.DB, job.Model, job.Options...)
+			if e != nil {
+				return fmt.Errorf("rekey of %T failed: %v", job.Model, e)
+			}
+			logger.WithContext(ctx).Infof("Rekey [%s] finished: %d rows scanned, %d rows re-encrypted",
+				progress.Table, progress.Scanned, progress.Rekeyed)
+		}
+		return nil
+	}
+}
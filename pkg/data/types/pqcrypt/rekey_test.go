@@ -0,0 +1,260 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pqcrypt
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cisco-open/go-lanai/pkg/data/tx"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/apptest"
+	"github.com/cisco-open/go-lanai/test/dbtest"
+	"github.com/google/uuid"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"testing"
+)
+
+const (
+	testRekeyKid    = `d034a284-172f-46c3-aead-e7cfb2f78ddc`
+	testRekeyNewKid = `aa74a96c-c0f4-4a29-9c76-e643ff29dee8`
+)
+
+/*************************
+	Models
+ *************************/
+
+type PlainModel struct {
+	ID   int    `gorm:"primaryKey;type:serial;"`
+	Name string `gorm:"uniqueIndex;not null;"`
+}
+
+func (PlainModel) TableName() string {
+	return "data_encryption_test"
+}
+
+/*************************
+	Test Cases
+ *************************/
+
+func TestRekey(t *testing.T) {
+	di := dbDI{}
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		dbtest.WithDBPlayback("testdb"),
+		apptest.WithModules(Module),
+		apptest.WithFxOptions(
+			fx.Provide(newMockedEncryptor(true)),
+		),
+		apptest.WithDI(&di),
+		test.GomegaSubTest(SubTestRekeyInBatches(&di), "RekeyInBatches"),
+		test.GomegaSubTest(SubTestRekeyInvalidModel(&di), "InvalidModel"),
+	)
+}
+
+func TestRekeyValue(t *testing.T) {
+	v := map[string]interface{}{
+		"key1": "value1",
+		"key2": 2.0,
+	}
+	test.RunTest(context.Background(), t,
+		test.Setup(SetupRekeyWithMockedEncryptor()),
+		test.GomegaSubTest(SubTestRekeyValue(V1, AlgPlain, v), "V1PlainText"),
+		test.GomegaSubTest(SubTestRekeyValue(V2, AlgPlain, v), "V2PlainText"),
+		test.GomegaSubTest(SubTestRekeyValue(V2, AlgVault, v), "V2Vault"),
+		test.GomegaSubTest(SubTestRekeyValueWithNewKeyID(v), "NewKeyID"),
+		test.GomegaSubTest(SubTestRekeyValueLargeInteger(), "LargeInteger"),
+		test.GomegaSubTest(SubTestRekeyValueSkipped(), "Skipped"),
+		test.GomegaSubTest(SubTestRekeyInvalidValue(), "InvalidValue"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SetupRekeyWithMockedEncryptor() test.SetupFunc {
+	return func(ctx context.Context, t *testing.T) (context.Context, error) {
+		original := encryptor
+		encryptor = newMockedEncryptor(true)()
+		t.Cleanup(func() {
+			encryptor = original
+		})
+		return ctx, nil
+	}
+}
+
+func SubTestRekeyInBatches(di *dbDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// capture updated values
+		const callbackName = "test:rekey_capture"
+		updated := make([]map[string]interface{}, 0)
+		e := di.DB.Callback().Update().Before("gorm:update").Register(callbackName, func(db *gorm.DB) {
+			if m, ok := db.Statement.Dest.(map[string]interface{}); ok {
+				updated = append(updated, m)
+			}
+		})
+		g.Expect(e).To(Succeed(), "registering callback shouldn't fail")
+		defer func() { _ = di.DB.Callback().Update().Remove(callbackName) }()
+
+		progresses := make([]RekeyProgress, 0)
+		progress, e := Rekey(ctx, di.DB, &EncryptedModel{},
+			RekeyWithBatchSize(2),
+			RekeyWithFilter(RekeyIfNot(V2, AlgVault)),
+			RekeyWithProgress(func(ctx context.Context, p RekeyProgress) {
+				progresses = append(progresses, p)
+			}),
+		)
+		g.Expect(e).To(Succeed(), "Rekey shouldn't fail")
+		g.Expect(progress.Table).To(Equal("data_encryption_test"), "progress should have correct table")
+		g.Expect(progress.Batches).To(Equal(2), "progress should have correct batches")
+		g.Expect(progress.Scanned).To(Equal(3), "progress should have correct scanned rows")
+		g.Expect(progress.Rekeyed).To(Equal(2), "progress should have correct re-encrypted rows")
+		g.Expect(progress.LastKey).To(BeEquivalentTo(3), "progress should have correct last key")
+		g.Expect(progresses).To(HaveLen(2), "progress should be reported for each batch")
+		g.Expect(progresses[0].Scanned).To(Equal(2), "first progress should have correct scanned rows")
+
+		g.Expect(updated).To(HaveLen(2), "correct number of rows should be updated")
+		for _, m := range updated {
+			g.Expect(m).To(HaveKey("value"), "updated row should have encrypted column")
+			raw := m["value"].(*EncryptedRaw)
+			g.Expect(raw.Ver).To(Equal(V2), "re-encrypted value should have correct Ver")
+			g.Expect(raw.Alg).To(Equal(AlgVault), "re-encrypted value should have correct Alg")
+			g.Expect(raw.KeyID).To(Equal(testRekeyKid), "re-encrypted value should have correct KeyID")
+		}
+	}
+}
+
+func SubTestRekeyInvalidModel(di *dbDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		_, e := Rekey(ctx, di.DB, &PlainModel{})
+		g.Expect(e).To(HaveOccurred(), "Rekey without encrypted columns should fail")
+		_, e = Rekey(ctx, di.DB, &EncryptedModel{}, RekeyWithColumns("unknown"))
+		g.Expect(e).To(HaveOccurred(), "Rekey with unknown columns should fail")
+		_, e = Rekey(ctx, di.DB, &EncryptedModel{}, RekeyWithBatchSize(0))
+		g.Expect(e).To(HaveOccurred(), "Rekey with invalid batch size should fail")
+		_, e = Rekey(tx.NewGormTxContext(ctx, di.DB), di.DB, &EncryptedModel{})
+		g.Expect(e).To(HaveOccurred(), "Rekey within transaction should fail")
+	}
+}
+
+func SubTestRekeyValue(ver Version, alg Algorithm, v map[string]interface{}) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		data := encryptForRekeyTest(ctx, g, ver, alg, testRekeyKid, v)
+		r := rekeyer{opt: &RekeyOption{}, created: map[string]struct{}{}}
+		raw, e := r.rekeyValue(ctx, data)
+		g.Expect(e).To(Succeed(), "rekey value shouldn't fail")
+		assertRekeyedValue(ctx, g, raw, testRekeyKid, v)
+	}
+}
+
+func SubTestRekeyValueWithNewKeyID(v map[string]interface{}) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		data := encryptForRekeyTest(ctx, g, V2, AlgVault, testRekeyKid, v)
+		r := rekeyer{
+			opt: &RekeyOption{
+				KeyIDMapper: func(_ context.Context, raw *EncryptedRaw) (string, error) {
+					return testRekeyNewKid, nil
+				},
+			},
+			created: map[string]struct{}{},
+		}
+		raw, e := r.rekeyValue(ctx, data)
+		g.Expect(e).To(Succeed(), "rekey value shouldn't fail")
+		assertRekeyedValue(ctx, g, raw, testRekeyNewKid, v)
+		g.Expect(r.created).To(HaveKey(testRekeyNewKid), "new key should be created")
+	}
+}
+
+func SubTestRekeyValueLargeInteger() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const large = int64(1<<53 + 1)
+		data := encryptForRekeyTest(ctx, g, V2, AlgPlain, testRekeyKid, map[string]interface{}{"id": large})
+		r := rekeyer{opt: &RekeyOption{}, created: map[string]struct{}{}}
+		raw, e := r.rekeyValue(ctx, data)
+		g.Expect(e).To(Succeed(), "rekey value shouldn't fail")
+		g.Expect(raw).ToNot(BeNil(), "re-encrypted value shouldn't be nil")
+
+		decrypted := map[string]int64{}
+		e = Decrypt(ctx, raw, &decrypted)
+		g.Expect(e).To(Succeed(), "re-encrypted value should be decryptable")
+		g.Expect(decrypted).To(HaveKeyWithValue("id", large), "large integer should not lose precision")
+	}
+}
+
+func SubTestRekeyValueSkipped() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		r := rekeyer{opt: &RekeyOption{Filter: RekeyIfNot(V2, AlgVault)}, created: map[string]struct{}{}}
+		data := encryptForRekeyTest(ctx, g, V2, AlgVault, testRekeyKid, map[string]interface{}{"key": "value"})
+		raw, e := r.rekeyValue(ctx, data)
+		g.Expect(e).To(Succeed(), "rekey value shouldn't fail")
+		g.Expect(raw).To(BeNil(), "value already in target format should be skipped")
+
+		raw, e = r.rekeyValue(ctx, nil)
+		g.Expect(e).To(Succeed(), "rekey null value shouldn't fail")
+		g.Expect(raw).To(BeNil(), "null value should be skipped")
+	}
+}
+
+func SubTestRekeyInvalidValue() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		r := rekeyer{opt: &RekeyOption{}, created: map[string]struct{}{}}
+		_, e := r.rekeyValue(ctx, []byte(`not json`))
+		g.Expect(e).To(HaveOccurred(), "rekey invalid JSON should fail")
+		_, e = r.rekeyValue(ctx, []byte(`{"v":2,"kid":"`+testRekeyKid+`","alg":"e","d":"invalid"}`))
+		g.Expect(e).To(HaveOccurred(), "rekey undecryptable value should fail")
+	}
+}
+
+/*************************
+	Helper
+ *************************/
+
+func encryptForRekeyTest(ctx context.Context, g *gomega.WithT, ver Version, alg Algorithm, kid string, v map[string]interface{}) []byte {
+	var raw *EncryptedRaw
+	var e error
+	switch alg {
+	case AlgVault:
+		raw, e = newMockedVaultEncryptor().Encrypt(ctx, kid, v)
+	default:
+		raw, e = plainTextEncryptor{}.Encrypt(ctx, kid, v)
+	}
+	g.Expect(e).To(Succeed(), "preparing encrypted data shouldn't fail")
+	raw.Ver = ver
+	if ver == V1 && alg == AlgPlain {
+		raw.Raw = json.RawMessage(`["java.util.HashMap",` + string(raw.Raw) + `]`)
+	}
+	data, e := json.Marshal(raw)
+	g.Expect(e).To(Succeed(), "preparing encrypted data shouldn't fail")
+	return data
+}
+
+func assertRekeyedValue(ctx context.Context, g *gomega.WithT, raw *EncryptedRaw, expectedKid string, expected map[string]interface{}) {
+	g.Expect(raw).ToNot(BeNil(), "re-encrypted value shouldn't be nil")
+	g.Expect(raw.Ver).To(Equal(V2), "re-encrypted value should have correct Ver")
+	g.Expect(raw.Alg).To(Equal(AlgVault), "re-encrypted value should have correct Alg")
+	g.Expect(raw.KeyID).To(Equal(expectedKid), "re-encrypted value should have correct KeyID")
+	_, e := uuid.Parse(raw.KeyID)
+	g.Expect(e).To(Succeed(), "re-encrypted value should have valid KeyID")
+
+	decrypted := map[string]interface{}{}
+	e = Decrypt(ctx, raw, &decrypted)
+	g.Expect(e).To(Succeed(), "re-encrypted value should be decryptable")
+	g.Expect(decrypted).To(Equal(expected), "re-encrypted value should have correct data")
+}
@@ -46,6 +46,7 @@ var Module = &bootstrap.Module{
 			NewServiceRegistrar,
 			provideRegistration,
 			provideDiscoveryClient),
+		fx.Provide(provideDeregisterShutdownHook),
 		fx.Invoke(registerService, closeDiscoveryClient),
 	},
 }
@@ -83,16 +84,41 @@ func provideDiscoveryClient(ctx *bootstrap.ApplicationContext, conn *consul.Conn
 	})
 }
 
-func registerService(lc fx.Lifecycle, registrar discovery.ServiceRegistrar, registration discovery.ServiceRegistration) {
+type registerDI struct {
+	fx.In
+	Registrar    discovery.ServiceRegistrar
+	Registration discovery.ServiceRegistration
+	Deregister   bootstrap.ShutdownHookFunc `name:"consulsd/deregister"`
+}
+
+func registerService(lc fx.Lifecycle, di registerDI) {
 	// because we are the lowest precedence, this is executed when every thing is ready
+	// deregistration is performed during graceful shutdown, see provideDeregisterShutdownHook.
+	// OnStop is a fallback in case graceful shutdown is not executed
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			return registrar.Register(ctx, registration)
-		},
-		OnStop: func(ctx context.Context) error {
-			return registrar.Deregister(ctx, registration)
+			return di.Registrar.Register(ctx, di.Registration)
 		},
+		OnStop: di.Deregister,
+	})
+}
+
+type deregisterOut struct {
+	fx.Out
+	Hook       bootstrap.ShutdownHook     `group:"bootstrap_shutdown_hook"`
+	Deregister bootstrap.ShutdownHookFunc `name:"consulsd/deregister"`
+}
+
+// provideDeregisterShutdownHook deregister the service before web server stops accepting new connections,
+// so other services stop discovering this instance while in-flight requests are drained
+func provideDeregisterShutdownHook(registrar discovery.ServiceRegistrar, registration discovery.ServiceRegistration) deregisterOut {
+	hook := bootstrap.NewShutdownHook("service deregistration", bootstrap.ShutdownPhaseDeregister, func(ctx context.Context) error {
+		return registrar.Deregister(ctx, registration)
 	})
+	return deregisterOut{
+		Hook:       hook,
+		Deregister: hook.Func,
+	}
 }
 
 func closeDiscoveryClient(lc fx.Lifecycle, client discovery.Client) {
@@ -18,10 +18,15 @@ package httpclient_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"github.com/cisco-open/go-lanai/pkg/discovery"
 	"github.com/cisco-open/go-lanai/pkg/integrate/httpclient"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
 	"github.com/cisco-open/go-lanai/pkg/utils"
 	"github.com/cisco-open/go-lanai/pkg/web"
 	"github.com/cisco-open/go-lanai/test"
@@ -112,6 +117,7 @@ func TestWithMockedServer(t *testing.T) {
 		test.GomegaSubTest(SubTestWithRetry(&di), "TestWithRetry"),
 		test.GomegaSubTest(SubTestWithTimeout(&di), "TestWithTimeout"),
 		test.GomegaSubTest(SubTestWithURLEncoded(&di), "TestWithURLEncoded"),
+		test.GomegaSubTest(SubTestWithDPoPProof(&di), "TestWithDPoPProof"),
 	)
 }
 
@@ -390,6 +396,46 @@ func SubTestWithURLEncoded(di *TestDI) test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestWithDPoPProof(di *TestDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		g.Expect(e).To(Succeed(), "generating key should not fail")
+		signer, e := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", key))
+		g.Expect(e).To(Succeed(), "creating DPoP proof signer should not fail")
+		hook := httpclient.HookDPoPProof(signer)
+
+		baseUrl := fmt.Sprintf(`http://localhost:%d%s`, webtest.CurrentPort(ctx), webtest.CurrentContextPath(ctx))
+		client, e := di.HttpClient.WithBaseUrl(baseUrl)
+		g.Expect(e).To(Succeed(), "client with base URL should be available")
+		client = client.WithConfig(&httpclient.ClientConfig{
+			BeforeHooks: []httpclient.BeforeHook{hook},
+			AfterHooks:  []httpclient.AfterHook{hook},
+		})
+
+		const token = "dummy-access-token"
+		req := httpclient.NewRequest(TestPath, http.MethodPost,
+			httpclient.WithHeader(httpclient.HeaderAuthorization, "Bearer "+token),
+			httpclient.WithBody(makeEchoRequestBody()),
+		)
+		resp, e := client.Execute(ctx, req, httpclient.JsonBody(&EchoResponse{}))
+		g.Expect(e).To(Succeed(), "execute request shouldn't fail")
+		echo := resp.Body.(*EchoResponse)
+		g.Expect(echo.Headers).To(HaveKeyWithValue(httpclient.HeaderAuthorization, "DPoP "+token), "token should be sent with DPoP scheme")
+		proofHeader := http.CanonicalHeaderKey(dpop.HeaderDPoP)
+		g.Expect(echo.Headers).To(HaveKey(proofHeader), "DPoP proof should be sent")
+
+		verifier := dpop.NewProofVerifier()
+		proof, e := verifier.Verify(ctx, echo.Headers[proofHeader], dpop.ProofExpectation{
+			Method:      http.MethodPost,
+			URL:         baseUrl + TestPath + "?query=ignored",
+			AccessToken: token,
+			Thumbprint:  signer.Thumbprint(),
+		})
+		g.Expect(e).To(Succeed(), "DPoP proof should be valid")
+		g.Expect(proof.Thumbprint).To(Equal(signer.Thumbprint()), "DPoP proof should have correct key")
+	}
+}
+
 /*************************
 	Request/Response
  *************************/
@@ -21,7 +21,10 @@ import (
 	"fmt"
 	"github.com/cisco-open/go-lanai/pkg/security"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -29,6 +32,7 @@ const (
 	HighestReservedHookOrder  = -10000
 	LowestReservedHookOrder   = 10000
 	HookOrderTokenPassthrough = HighestReservedHookOrder + 10
+	HookOrderDPoPProof        = HighestReservedHookOrder + 20
 	HookOrderRequestLogger    = LowestReservedHookOrder
 	HookOrderResponseLogger   = HighestReservedHookOrder
 )
@@ -124,6 +128,69 @@ func HookTokenPassthrough() BeforeHook {
 	return BeforeHookWithOrder(HookOrderTokenPassthrough, hook)
 }
 
+/****************************
+	DPoP Proof Hook
+ ****************************/
+
+// DPoPProofHook implements BeforeHook, AfterHook and order.Ordered.
+// As BeforeHook, it attaches a DPoP proof to outgoing requests. If the request carries an access token, the token is
+// presented with "DPoP" scheme and the proof is bound to it via "ath".
+// As AfterHook, it remembers server provided nonce, which is used for subsequent requests to the same host.
+// Note: the hook doesn't retry requests rejected with "use_dpop_nonce". Use it as both BeforeHook and AfterHook, and
+// retry on such error when the server requires nonce.
+// See https://datatracker.ietf.org/doc/html/rfc9449
+type DPoPProofHook struct {
+	signer *dpop.ProofSigner
+	// nonces keeps latest nonce by host
+	nonces sync.Map
+}
+
+// HookDPoPProof create a DPoPProofHook that sign DPoP proofs with given signer.
+// Access tokens used with this hook should be issued for the same key, e.g. requested with proof signed by the same signer
+func HookDPoPProof(signer *dpop.ProofSigner) *DPoPProofHook {
+	return &DPoPProofHook{
+		signer: signer,
+	}
+}
+
+func (h *DPoPProofHook) Order() int {
+	return HookOrderDPoPProof
+}
+
+func (h *DPoPProofHook) Before(ctx context.Context, req *http.Request) context.Context {
+	var token string
+	authHeader := req.Header.Get(HeaderAuthorization)
+	for _, scheme := range []string{"Bearer ", dpop.AuthScheme + " "} {
+		if strings.HasPrefix(strings.ToUpper(authHeader), strings.ToUpper(scheme)) {
+			token = authHeader[len(scheme):]
+			req.Header.Set(HeaderAuthorization, fmt.Sprintf("%s %s", dpop.AuthScheme, token))
+			break
+		}
+	}
+
+	var nonce string
+	if v, ok := h.nonces.Load(req.URL.Host); ok {
+		nonce = v.(string)
+	}
+	proof, e := h.signer.Sign(req.Method, req.URL.String(), dpop.WithAccessToken(token), dpop.WithNonce(nonce))
+	if e != nil {
+		logger.WithContext(ctx).Warnf("unable to create DPoP proof: %v", e)
+		return ctx
+	}
+	req.Header.Set(dpop.HeaderDPoP, proof)
+	return ctx
+}
+
+func (h *DPoPProofHook) After(ctx context.Context, resp *http.Response) context.Context {
+	if resp == nil || resp.Request == nil {
+		return ctx
+	}
+	if nonce := resp.Header.Get(dpop.HeaderDPoPNonce); len(nonce) != 0 {
+		h.nonces.Store(resp.Request.URL.Host, nonce)
+	}
+	return ctx
+}
+
 /*************************
 	Logger Hook
  *************************/
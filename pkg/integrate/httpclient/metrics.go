@@ -0,0 +1,91 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpclient
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"go.uber.org/fx"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	MeterNameClientRequests = "http.client.requests"
+)
+
+type ctxKeyMetricsStartTime struct{}
+
+type metricsCustomizer struct {
+	registry metrics.Registry
+}
+
+func metricsProvider() fx.Annotated {
+	return FxClientCustomizers(newMetricsCustomizer)[0]
+}
+
+type metricsDI struct {
+	fx.In
+	Registry metrics.Registry `optional:"true"`
+}
+
+func newMetricsCustomizer(di metricsDI) ClientCustomizer {
+	return &metricsCustomizer{
+		registry: di.Registry,
+	}
+}
+
+func (c *metricsCustomizer) Customize(opt *ClientOption) {
+	if c.registry == nil {
+		return
+	}
+	histogram := c.registry.Histogram(MeterNameClientRequests,
+		metrics.WithDescription("Duration of HTTP client requests"),
+		metrics.WithUnit("seconds"),
+		metrics.WithTags("method", "host", "status"),
+	)
+	opt.DefaultBeforeHooks = append(opt.DefaultBeforeHooks, startTimerHook())
+	opt.DefaultAfterHooks = append(opt.DefaultAfterHooks, recordTimerHook(histogram))
+}
+
+func startTimerHook() BeforeHook {
+	fn := func(ctx context.Context, _ *http.Request) context.Context {
+		return context.WithValue(ctx, ctxKeyMetricsStartTime{}, time.Now())
+	}
+	return BeforeHookWithOrder(order.Highest, BeforeHookFunc(fn))
+}
+
+func recordTimerHook(histogram metrics.Histogram) AfterHook {
+	fn := func(ctx context.Context, resp *http.Response) context.Context {
+		start, ok := ctx.Value(ctxKeyMetricsStartTime{}).(time.Time)
+		if !ok {
+			return ctx
+		}
+		tags := metrics.Tags{
+			"status": strconv.Itoa(resp.StatusCode),
+		}
+		if resp.Request != nil {
+			tags["method"] = resp.Request.Method
+			tags["host"] = resp.Request.URL.Host
+		}
+		histogram.Observe(time.Since(start).Seconds(), tags)
+		return ctx
+	}
+	return AfterHookWithOrder(order.Lowest, AfterHookFunc(fn))
+}
@@ -36,6 +36,7 @@ var Module = &bootstrap.Module{
 		fx.Provide(bindHttpClientProperties),
 		fx.Provide(provideHttpClient),
 		fx.Provide(tracingProvider()),
+		fx.Provide(metricsProvider()),
 	},
 }
 
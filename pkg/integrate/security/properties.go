@@ -19,6 +19,7 @@ package security
 import (
 	"embed"
 	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
 	"github.com/cisco-open/go-lanai/pkg/utils"
 	"github.com/pkg/errors"
 	"time"
@@ -50,6 +51,23 @@ type SecurityIntegrationProperties struct {
 type ClientCredentialsProperties struct {
 	ClientId     string `json:"client-id"`
 	ClientSecret string `json:"secret"`
+	// AuthMethod is how the client authenticates with auth server.
+	// Supported values are "client_secret_basic" (default), "client_secret_jwt" and "private_key_jwt"
+	AuthMethod string `json:"auth-method"`
+	// Assertion configures JWT assertion when AuthMethod is "client_secret_jwt" or "private_key_jwt"
+	Assertion ClientAssertionProperties `json:"assertion"`
+}
+
+type ClientAssertionProperties struct {
+	// Audience is the "aud" of assertions, typically the token endpoint URL or issuer identifier of the auth server
+	Audience string `json:"audience"`
+	// Validity is the lifetime of each assertion
+	Validity utils.Duration `json:"validity"`
+	// SigningMethod is the JWS algorithm, e.g. "RS256". Optional
+	SigningMethod string `json:"signing-method"`
+	// Key is the private key used by "private_key_jwt", in same format as "security.keys.<key-name>".
+	// "id" of the key should match the "kid" registered with auth server if the client has more than one key
+	Key jwt.CryptoKeyProperties `json:"key"`
 }
 
 type AuthEndpointsProperties struct {
@@ -93,6 +111,9 @@ func NewSecurityIntegrationProperties() *SecurityIntegrationProperties {
 		Client: ClientCredentialsProperties{
 			ClientId:     "nfv-service",
 			ClientSecret: "nfv-service-secret",
+			Assertion: ClientAssertionProperties{
+				Validity: utils.Duration(time.Minute),
+			},
 		},
 		Accounts: AccountsProperties{
 			Default: AccountCredentialsProperties{
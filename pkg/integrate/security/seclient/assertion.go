@@ -0,0 +1,106 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package seclient
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	defaultAssertionValidity = time.Minute
+)
+
+// ClientAssertionOption configures JWT assertion based client authentication ("private_key_jwt" and "client_secret_jwt").
+// See https://datatracker.ietf.org/doc/html/rfc7523#section-2.2
+type ClientAssertionOption struct {
+	// Audience is the "aud" of assertions, typically the token endpoint URL or issuer identifier of the auth server.
+	Audience string
+	// Validity is the lifetime of each assertion. Default: 1 minute
+	Validity time.Duration
+	// JwkStore and JwkName provides private key used by "private_key_jwt". Ignored by "client_secret_jwt".
+	JwkStore jwt.JwkStore
+	JwkName  string
+	// SigningMethod is the JWS algorithm. When not set, "private_key_jwt" resolves it from private key type,
+	// and "client_secret_jwt" uses HS256
+	SigningMethod gojwt.SigningMethod
+}
+
+// clientAssertionSigner creates signed JWT assertions for client authentication
+type clientAssertionSigner struct {
+	clientId string
+	audience string
+	validity time.Duration
+	encoder  jwt.JwtEncoder
+}
+
+func newClientAssertionSigner(method, clientId, secret string, opt ClientAssertionOption) (*clientAssertionSigner, error) {
+	if len(opt.Audience) == 0 {
+		return nil, fmt.Errorf(`audience of client assertion is required by "%s"`, method)
+	}
+
+	var encoder jwt.JwtEncoder
+	switch method {
+	case oauth2.ClientAuthMethodPrivateKeyJwt:
+		if opt.JwkStore == nil {
+			return nil, fmt.Errorf(`JWK store is required by "%s"`, method)
+		}
+		encoder = jwt.NewSignedJwtEncoder(jwt.SignWithJwkStore(opt.JwkStore, opt.JwkName), jwt.SignWithMethod(opt.SigningMethod))
+	case oauth2.ClientAuthMethodSecretJwt:
+		if len(secret) == 0 {
+			return nil, fmt.Errorf(`client secret is required by "%s"`, method)
+		}
+		signingMethod := opt.SigningMethod
+		if signingMethod == nil {
+			signingMethod = gojwt.SigningMethodHS256
+		}
+		store := jwt.NewJwkSetStore(jwt.NewPrivateJwk(clientId, clientId, []byte(secret)))
+		encoder = jwt.NewSignedJwtEncoder(jwt.SignWithJwkStore(store, clientId), jwt.SignWithMethod(signingMethod))
+	default:
+		return nil, fmt.Errorf(`unsupported client authentication method "%s"`, method)
+	}
+
+	validity := opt.Validity
+	if validity <= 0 {
+		validity = defaultAssertionValidity
+	}
+	return &clientAssertionSigner{
+		clientId: clientId,
+		audience: opt.Audience,
+		validity: validity,
+		encoder:  encoder,
+	}, nil
+}
+
+func (s *clientAssertionSigner) Sign(ctx context.Context) (string, error) {
+	now := time.Now()
+	claims := oauth2.BasicClaims{
+		Id:        uuid.New().String(),
+		Issuer:    s.clientId,
+		Subject:   s.clientId,
+		Audience:  oauth2.StringSetClaim(utils.NewStringSet(s.audience)),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.validity),
+	}
+	return s.encoder.Encode(ctx, &claims)
+}
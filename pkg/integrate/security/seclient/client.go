@@ -18,6 +18,7 @@ package seclient
 
 import (
 	"context"
+	"fmt"
 	"github.com/cisco-open/go-lanai/pkg/integrate/httpclient"
 	"github.com/cisco-open/go-lanai/pkg/log"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
@@ -44,12 +45,18 @@ type AuthClientOption struct {
 	SwitchContextPath string
 	ClientId          string
 	ClientSecret      string
+	// ClientAuthMethod is how the client authenticates with auth server. Supported values are
+	// oauth2.ClientAuthMethodSecretBasic (default), oauth2.ClientAuthMethodSecretJwt and oauth2.ClientAuthMethodPrivateKeyJwt
+	ClientAuthMethod string
+	// ClientAssertion is used when ClientAuthMethod is JWT assertion based
+	ClientAssertion ClientAssertionOption
 }
 
 type remoteAuthClient struct {
 	client        httpclient.Client
 	clientId      string
 	clientSecret  string
+	assertion     *clientAssertionSigner
 	pwdLoginPath  string
 	switchCtxPath string
 }
@@ -78,6 +85,16 @@ func NewRemoteAuthClient(opts ...AuthClientOptions) (AuthenticationClient, error
 		return nil, err
 	}
 
+	// prepare client assertion
+	var assertion *clientAssertionSigner
+	switch opt.ClientAuthMethod {
+	case "", oauth2.ClientAuthMethodSecretBasic:
+	default:
+		if assertion, err = newClientAssertionSigner(opt.ClientAuthMethod, opt.ClientId, opt.ClientSecret, opt.ClientAssertion); err != nil {
+			return nil, err
+		}
+	}
+
 	return &remoteAuthClient{
 		client: client.WithConfig(&httpclient.ClientConfig{
 			// Note: we don't want access token passthrough
@@ -93,6 +110,7 @@ func NewRemoteAuthClient(opts ...AuthClientOptions) (AuthenticationClient, error
 		}),
 		clientId:      opt.ClientId,
 		clientSecret:  opt.ClientSecret,
+		assertion:     assertion,
 		pwdLoginPath:  opt.PwdLoginPath,
 		switchCtxPath: opt.SwitchContextPath,
 	}, nil
@@ -100,13 +118,17 @@ func NewRemoteAuthClient(opts ...AuthClientOptions) (AuthenticationClient, error
 
 func (c *remoteAuthClient) ClientCredentials(ctx context.Context, opts ...AuthOptions) (*Result, error) {
 	opt := c.option(opts)
+	clientAuth, e := c.clientAuthOption(ctx, opt)
+	if e != nil {
+		return nil, e
+	}
 	reqOpts := []httpclient.RequestOptions{
-		c.withClientAuth(opt),
 		httpclient.WithHeader(httpclient.HeaderContentType, httpclient.MediaTypeFormUrlEncoded),
 		httpclient.WithUrlEncodedBody(WithNonEmptyURLValues(url.Values{
 			oauth2.ParameterGrantType: {oauth2.GrantTypeClientCredentials},
 			oauth2.ClaimScope:         {strings.Join(opt.Scopes, " ")},
 		})),
+		clientAuth,
 	}
 
 	reqOpts = append(reqOpts, c.reqOptionsForTenancy(opt)...)
@@ -121,17 +143,21 @@ func (c *remoteAuthClient) ClientCredentials(ctx context.Context, opts ...AuthOp
 
 func (c *remoteAuthClient) PasswordLogin(ctx context.Context, opts ...AuthOptions) (*Result, error) {
 	opt := c.option(opts)
+	clientAuth, e := c.clientAuthOption(ctx, opt)
+	if e != nil {
+		return nil, e
+	}
 
 	nonce := c.generateNonce(10)
 	reqOpts := []httpclient.RequestOptions{
 		httpclient.WithParam(oauth2.ParameterGrantType, oauth2.GrantTypePassword),
 		httpclient.WithParam(oauth2.ParameterUsername, opt.Username),
-		c.withClientAuth(opt),
 		httpclient.WithUrlEncodedBody(WithNonEmptyURLValues(url.Values{
 			oauth2.ParameterPassword: {opt.Password},
 			oauth2.ParameterNonce:    {nonce},
 			oauth2.ClaimScope:        {strings.Join(opt.Scopes, " ")},
 		})),
+		clientAuth,
 	}
 	reqOpts = append(reqOpts, c.reqOptionsForTenancy(opt)...)
 
@@ -145,16 +171,20 @@ func (c *remoteAuthClient) PasswordLogin(ctx context.Context, opts ...AuthOption
 
 func (c *remoteAuthClient) SwitchUser(ctx context.Context, opts ...AuthOptions) (*Result, error) {
 	opt := c.option(opts)
+	clientAuth, e := c.clientAuthOption(ctx, opt)
+	if e != nil {
+		return nil, e
+	}
 
 	nonce := c.generateNonce(10)
 	reqOpts := []httpclient.RequestOptions{
 		httpclient.WithParam(oauth2.ParameterGrantType, oauth2.GrantTypeSwitchUser),
-		c.withClientAuth(opt),
 		httpclient.WithUrlEncodedBody(WithNonEmptyURLValues(url.Values{
 			oauth2.ParameterAccessToken: {opt.AccessToken},
 			oauth2.ParameterNonce:       {nonce},
 			oauth2.ClaimScope:           {strings.Join(opt.Scopes, " ")},
 		})),
+		clientAuth,
 	}
 	reqOpts = append(reqOpts, c.reqOptionsForSwitchUser(opt)...)
 	reqOpts = append(reqOpts, c.reqOptionsForTenancy(opt)...)
@@ -169,16 +199,20 @@ func (c *remoteAuthClient) SwitchUser(ctx context.Context, opts ...AuthOptions)
 
 func (c *remoteAuthClient) SwitchTenant(ctx context.Context, opts ...AuthOptions) (*Result, error) {
 	opt := c.option(opts)
+	clientAuth, e := c.clientAuthOption(ctx, opt)
+	if e != nil {
+		return nil, e
+	}
 
 	nonce := c.generateNonce(10)
 	reqOpts := []httpclient.RequestOptions{
 		httpclient.WithParam(oauth2.ParameterGrantType, oauth2.GrantTypeSwitchTenant),
-		c.withClientAuth(opt),
 		httpclient.WithUrlEncodedBody(WithNonEmptyURLValues(url.Values{
 			oauth2.ParameterAccessToken: {opt.AccessToken},
 			oauth2.ParameterNonce:       {nonce},
 			oauth2.ClaimScope:           {strings.Join(opt.Scopes, " ")},
 		})),
+		clientAuth,
 	}
 	reqOpts = append(reqOpts, c.reqOptionsForTenancy(opt)...)
 
@@ -190,6 +224,38 @@ func (c *remoteAuthClient) SwitchTenant(ctx context.Context, opts ...AuthOptions
 	return c.handleResponse(resp, e)
 }
 
+func (c *remoteAuthClient) TokenExchange(ctx context.Context, opts ...AuthOptions) (*Result, error) {
+	opt := c.option(opts)
+	clientAuth, e := c.clientAuthOption(ctx, opt)
+	if e != nil {
+		return nil, e
+	}
+
+	values := WithNonEmptyURLValues(url.Values{
+		oauth2.ParameterSubjectToken:       {opt.AccessToken},
+		oauth2.ParameterSubjectTokenType:   {oauth2.TokenTypeIdAccessToken},
+		oauth2.ParameterRequestedTokenType: {oauth2.TokenTypeIdAccessToken},
+		oauth2.ParameterAudience:           opt.Audience,
+		oauth2.ClaimScope:                  {strings.Join(opt.Scopes, " ")},
+	})
+	if opt.ActorToken != "" {
+		values.Set(oauth2.ParameterActorToken, opt.ActorToken)
+		values.Set(oauth2.ParameterActorTokenType, oauth2.TokenTypeIdAccessToken)
+	}
+	reqOpts := []httpclient.RequestOptions{
+		httpclient.WithParam(oauth2.ParameterGrantType, oauth2.GrantTypeTokenExchange),
+		httpclient.WithUrlEncodedBody(values),
+		clientAuth,
+	}
+
+	// prepare request
+	req := httpclient.NewRequest(c.switchCtxPath, http.MethodPost, reqOpts...)
+	// send request and parse response
+	body := oauth2.NewDefaultAccessToken("")
+	resp, e := c.client.Execute(ctx, req, httpclient.JsonBody(body))
+	return c.handleResponse(resp, e)
+}
+
 func (c *remoteAuthClient) option(opts []AuthOptions) *AuthOption {
 	opt := AuthOption{}
 	for _, fn := range opts {
@@ -236,6 +302,30 @@ func (c *remoteAuthClient) generateNonce(length int) string {
 	return utils.RandomString(length)
 }
 
+// clientAuthOption returns a requestOption for client authentication.
+// When client assertion is configured and AuthOption doesn't override client ID, a signed JWT assertion is added to
+// the URL encoded request body. Otherwise, basic auth is used. See withClientAuth.
+// Note: the returned requestOption should be applied after the request body is set
+func (c *remoteAuthClient) clientAuthOption(ctx context.Context, opt *AuthOption) (httpclient.RequestOptions, error) {
+	if c.assertion == nil || opt.ClientID != "" {
+		return c.withClientAuth(opt), nil
+	}
+	assertion, e := c.assertion.Sign(ctx)
+	if e != nil {
+		return nil, fmt.Errorf("unable to sign client assertion: %v", e)
+	}
+	return func(r *httpclient.Request) {
+		values, ok := r.Body.(url.Values)
+		if !ok || values == nil {
+			values = url.Values{}
+		}
+		values.Set(oauth2.ParameterClientId, c.clientId)
+		values.Set(oauth2.ParameterClientAssertionType, oauth2.ClientAssertionTypeJwtBearer)
+		values.Set(oauth2.ParameterClientAssertion, assertion)
+		httpclient.WithUrlEncodedBody(values)(r)
+	}, nil
+}
+
 // withClientAuth will return a requestOption based off of WithBasicAuth, but
 // use the clientID from the AuthOptions. If the AuthOption.ClientID is empty, then
 // it will return WithBasicAuth using the fallback remoteAuthClient.clientId and secret instead
@@ -26,7 +26,9 @@ type AuthOptions func(opt *AuthOption)
 
 type AuthOption struct {
 	Password         string   // Password is used by password login
-	AccessToken      string   // AccessToken is used by switch user/tenant
+	AccessToken      string   // AccessToken is used by switch user/tenant and as subject token of token exchange
+	ActorToken       string   // ActorToken is used by token exchange
+	Audience         []string // Audience is used by token exchange
 	Username         string   // Username is used by password login and switch user
 	UserId           string   // UserId is used by switch user
 	TenantId         string   // TenantId is used by password login and switch user/tenant
@@ -41,6 +43,10 @@ type AuthenticationClient interface {
 	ClientCredentials(ctx context.Context, opts ...AuthOptions) (*Result, error)
 	SwitchUser(ctx context.Context, opts ...AuthOptions) (*Result, error)
 	SwitchTenant(ctx context.Context, opts ...AuthOptions) (*Result, error)
+	// TokenExchange exchanges the access token (specified via WithAccessToken or WithCurrentSecurity) for a new token
+	// with narrowed scopes and audience, using OAuth2 token exchange grant (RFC 8693).
+	// It's typically used by a service to obtain a token for downstream calls on behalf of current user.
+	TokenExchange(ctx context.Context, opts ...AuthOptions) (*Result, error)
 }
 
 type Result struct {
@@ -130,6 +136,20 @@ func WithScopes(scopes ...string) AuthOptions {
 	}
 }
 
+// WithActorToken specify the token representing the acting party in token exchange
+func WithActorToken(actorToken string) AuthOptions {
+	return func(opt *AuthOption) {
+		opt.ActorToken = actorToken
+	}
+}
+
+// WithAudience specify the intended audience (resource IDs) of the exchanged token
+func WithAudience(audience ...string) AuthOptions {
+	return func(opt *AuthOption) {
+		opt.Audience = audience
+	}
+}
+
 func WithClientAuth(clientID, secret string) AuthOptions {
 	return func(opt *AuthOption) {
 		opt.ClientID = clientID
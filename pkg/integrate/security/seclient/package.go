@@ -22,7 +22,11 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/integrate/httpclient"
 	securityint "github.com/cisco-open/go-lanai/pkg/integrate/security"
 	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	gojwt "github.com/golang-jwt/jwt/v4"
 	"go.uber.org/fx"
+	"time"
 )
 
 var logger = log.New("SEC.Client")
@@ -59,5 +63,24 @@ func provideAuthClient(di clientDI) (AuthenticationClient, error) {
 		opt.ContextPath = di.Properties.Endpoints.ContextPath
 		opt.PwdLoginPath = di.Properties.Endpoints.PasswordLogin
 		opt.SwitchContextPath = di.Properties.Endpoints.SwitchContext
+		opt.ClientAuthMethod = di.Properties.Client.AuthMethod
+		opt.ClientAssertion = clientAssertionOption(&di.Properties.Client)
 	})
 }
+
+func clientAssertionOption(props *securityint.ClientCredentialsProperties) ClientAssertionOption {
+	opt := ClientAssertionOption{
+		Audience: props.Assertion.Audience,
+		Validity: time.Duration(props.Assertion.Validity),
+	}
+	if len(props.Assertion.SigningMethod) != 0 {
+		opt.SigningMethod = gojwt.GetSigningMethod(props.Assertion.SigningMethod)
+	}
+	if props.AuthMethod == oauth2.ClientAuthMethodPrivateKeyJwt {
+		opt.JwkName = props.ClientId
+		opt.JwkStore = jwt.NewFileJwkStore(jwt.CryptoProperties{
+			Keys: map[string]jwt.CryptoKeyProperties{props.ClientId: props.Assertion.Key},
+		})
+	}
+	return opt
+}
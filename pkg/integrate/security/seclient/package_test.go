@@ -2,11 +2,14 @@ package seclient_test
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"github.com/cisco-open/go-lanai/pkg/discovery"
 	"github.com/cisco-open/go-lanai/pkg/integrate/httpclient"
 	"github.com/cisco-open/go-lanai/pkg/integrate/security/seclient"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
 	"github.com/cisco-open/go-lanai/pkg/web"
 	"github.com/cisco-open/go-lanai/test"
 	"github.com/cisco-open/go-lanai/test/apptest"
@@ -17,6 +20,7 @@ import (
 	. "github.com/onsi/gomega"
 	"go.uber.org/fx"
 	"testing"
+	"time"
 )
 
 const (
@@ -32,6 +36,10 @@ const (
 	TestAltClientID        = `test-client-alt`
 	TestAltClientSecret    = `test-secret-alt`
 	TestCurrentAccessToken = `test-token`
+	TestActorAccessToken   = `test-actor-token`
+	TestAudience           = `test-audience`
+	TestAssertionAudience  = `http://localhost/test/v2/token`
+	TestAssertionKid       = `test-kid`
 )
 
 /*************************
@@ -42,6 +50,7 @@ type TestDI struct {
 	fx.In
 	sdtest.DI
 	AuthClient       seclient.AuthenticationClient
+	HttpClient       httpclient.Client
 	MockedController *MockedController
 }
 
@@ -62,6 +71,8 @@ func TestWithMockedServer(t *testing.T) {
 		test.GomegaSubTest(SubTestPasswordLogin(&di), "PasswordLogin"),
 		test.GomegaSubTest(SubTestSwitchUser(&di), "SwitchUser"),
 		test.GomegaSubTest(SubTestSwitchTenant(&di), "SwitchTenant"),
+		test.GomegaSubTest(SubTestTokenExchange(&di), "TokenExchange"),
+		test.GomegaSubTest(SubTestClientAssertion(&di), "ClientAssertion"),
 	)
 }
 
@@ -279,10 +290,147 @@ func SubTestSwitchTenant(di *TestDI) test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestTokenExchange(di *TestDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		test.RunTest(ctx, t,
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				// minimum options
+				rs, e := di.AuthClient.TokenExchange(ctx, seclient.WithAccessToken(TestCurrentAccessToken))
+				g.Expect(e).To(Succeed(), "token exchange should not fail")
+				req := AssertResult(g, rs, oauth2.GrantTypeTokenExchange, TestClientID, TestClientSecret)
+				AssertTokenRequestParams(g, req,
+					oauth2.ParameterSubjectToken, TestCurrentAccessToken,
+					oauth2.ParameterSubjectTokenType, oauth2.TokenTypeIdAccessToken,
+					oauth2.ParameterRequestedTokenType, oauth2.TokenTypeIdAccessToken,
+					oauth2.ParameterActorToken, nil, oauth2.ParameterActorTokenType, nil, oauth2.ParameterAudience, nil)
+			}, "MinimumOptions"),
+
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				// with more options
+				rs, e := di.AuthClient.TokenExchange(ctx, seclient.WithAccessToken(TestCurrentAccessToken),
+					seclient.WithActorToken(TestActorAccessToken),
+					seclient.WithAudience(TestAudience),
+					seclient.WithClientAuth(TestAltClientID, TestAltClientSecret),
+					seclient.WithScopes(TestScope),
+				)
+				g.Expect(e).To(Succeed(), "token exchange should not fail")
+				req := AssertResult(g, rs, oauth2.GrantTypeTokenExchange, TestAltClientID, TestAltClientSecret)
+				g.Expect(req.Scopes).To(HaveKey(TestScope), "request's [%s] should be correct", "Scopes")
+				AssertTokenRequestParams(g, req,
+					oauth2.ParameterSubjectToken, TestCurrentAccessToken,
+					oauth2.ParameterActorToken, TestActorAccessToken,
+					oauth2.ParameterActorTokenType, oauth2.TokenTypeIdAccessToken,
+					oauth2.ParameterAudience, TestAudience)
+			}, "WithMoreOptions"),
+
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				// current security
+				ctx = ContextWithSecurity(ctx)
+				rs, e := di.AuthClient.TokenExchange(ctx, seclient.WithCurrentSecurity(ctx), seclient.WithScopes(TestScope))
+				g.Expect(e).To(Succeed(), "token exchange should not fail")
+				req := AssertResult(g, rs, oauth2.GrantTypeTokenExchange, TestClientID, TestClientSecret)
+				AssertTokenRequestParams(g, req, oauth2.ParameterSubjectToken, TestCurrentAccessToken)
+			}, "WithCurrentSecurity"),
+
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				// failure
+				_, e := di.AuthClient.TokenExchange(ctx, seclient.WithAccessToken(TestCurrentAccessToken),
+					seclient.WithClientAuth(InvalidClientID, "whatever"))
+				g.Expect(e).To(HaveOccurred(), "token exchange should fail with invalid credentials")
+			}, "Failure"),
+		)
+	}
+}
+
+func SubTestClientAssertion(di *TestDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		privKey, e := rsa.GenerateKey(rand.Reader, 2048)
+		g.Expect(e).To(Succeed(), "generating private key should not fail")
+		privStore := jwt.NewJwkSetStore(jwt.NewPrivateJwk(TestAssertionKid, TestAssertionKid, privKey))
+		pubStore := jwt.NewJwkSetStore(jwt.NewJwk(TestAssertionKid, TestAssertionKid, &privKey.PublicKey))
+		secretStore := jwt.NewJwkSetStore(jwt.NewJwk(TestClientID, TestClientID, []byte(TestClientSecret)))
+
+		test.RunTest(ctx, t,
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				client := NewAssertionAuthClient(g, di, oauth2.ClientAuthMethodPrivateKeyJwt, func(opt *seclient.ClientAssertionOption) {
+					opt.JwkStore = privStore
+					opt.JwkName = TestAssertionKid
+				})
+				rs, e := client.ClientCredentials(ctx, seclient.WithScopes(TestScope))
+				g.Expect(e).To(Succeed(), "authentication should not fail")
+				req := AssertResult(g, rs, oauth2.GrantTypeClientCredentials, "", "")
+				AssertClientAssertion(ctx, g, req, pubStore, TestAssertionKid)
+			}, "PrivateKeyJwt"),
+
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				client := NewAssertionAuthClient(g, di, oauth2.ClientAuthMethodSecretJwt, nil)
+				rs, e := client.PasswordLogin(ctx, seclient.WithCredentials(TestAccount, TestPassword))
+				g.Expect(e).To(Succeed(), "authentication should not fail")
+				req := AssertResult(g, rs, oauth2.GrantTypePassword, "", "")
+				AssertClientAssertion(ctx, g, req, secretStore, TestClientID)
+			}, "ClientSecretJwt"),
+
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				client := NewAssertionAuthClient(g, di, oauth2.ClientAuthMethodSecretJwt, nil)
+				rs, e := client.ClientCredentials(ctx, seclient.WithClientAuth(TestAltClientID, TestAltClientSecret))
+				g.Expect(e).To(Succeed(), "authentication should not fail")
+				req := AssertResult(g, rs, oauth2.GrantTypeClientCredentials, TestAltClientID, TestAltClientSecret)
+				AssertTokenRequestParams(g, req, oauth2.ParameterClientAssertion, nil, oauth2.ParameterClientAssertionType, nil)
+			}, "OverrideClientAuth"),
+
+			test.GomegaSubTest(func(ctx context.Context, t *testing.T, g *WithT) {
+				_, e := seclient.NewRemoteAuthClient(func(opt *seclient.AuthClientOption) {
+					opt.Client = di.HttpClient
+					opt.ServiceName = SDAuthServiceName
+					opt.ClientId = TestClientID
+					opt.ClientAuthMethod = oauth2.ClientAuthMethodPrivateKeyJwt
+					opt.ClientAssertion.Audience = TestAssertionAudience
+				})
+				g.Expect(e).To(HaveOccurred(), "creating client without JWK store should fail")
+			}, "InvalidConfig"),
+		)
+	}
+}
+
 /*************************
 	Helpers
  *************************/
 
+func NewAssertionAuthClient(g *gomega.WithT, di *TestDI, method string, fn func(opt *seclient.ClientAssertionOption)) seclient.AuthenticationClient {
+	client, e := seclient.NewRemoteAuthClient(func(opt *seclient.AuthClientOption) {
+		opt.Client = di.HttpClient
+		opt.ServiceName = SDAuthServiceName
+		opt.Scheme = "http"
+		opt.ContextPath = "/test"
+		opt.ClientId = TestClientID
+		opt.ClientSecret = TestClientSecret
+		opt.ClientAuthMethod = method
+		opt.ClientAssertion.Audience = TestAssertionAudience
+		if fn != nil {
+			fn(&opt.ClientAssertion)
+		}
+	})
+	g.Expect(e).To(Succeed(), "creating auth client should not fail")
+	return client
+}
+
+func AssertClientAssertion(ctx context.Context, g *gomega.WithT, req *auth.TokenRequest, verifyStore jwt.JwkStore, jwkName string) {
+	g.Expect(req.ClientId).To(Equal(TestClientID), "request's [%s] should be correct", "ClientId")
+	g.Expect(req.Extensions).ToNot(HaveKey(ExtClientSecret), "request should not use basic auth")
+	AssertTokenRequestParams(g, req, oauth2.ParameterClientAssertionType, oauth2.ClientAssertionTypeJwtBearer)
+	g.Expect(req.Parameters).To(HaveKey(oauth2.ParameterClientAssertion), "request should have client assertion")
+
+	dec := jwt.NewSignedJwtDecoder(jwt.VerifyWithJwkStore(verifyStore, jwkName), jwt.VerifyWithMethods(jwt.SupportedSigningMethods...))
+	claims := oauth2.BasicClaims{}
+	e := dec.DecodeWithClaims(ctx, req.Parameters[oauth2.ParameterClientAssertion], &claims)
+	g.Expect(e).To(Succeed(), "client assertion should be verifiable")
+	g.Expect(claims.Issuer).To(Equal(TestClientID), "client assertion's [%s] should be correct", "iss")
+	g.Expect(claims.Subject).To(Equal(TestClientID), "client assertion's [%s] should be correct", "sub")
+	g.Expect(claims.Audience).To(HaveKey(TestAssertionAudience), "client assertion's [%s] should be correct", "aud")
+	g.Expect(claims.Id).ToNot(BeEmpty(), "client assertion's [%s] should be correct", "jti")
+	g.Expect(claims.ExpiresAt).To(BeTemporally(">", time.Now()), "client assertion's [%s] should be correct", "exp")
+}
+
 func AssertResult(g *gomega.WithT, rs *seclient.Result, expectedGrant, expectedClientId, expectedSecret string) *auth.TokenRequest {
 	g.Expect(rs).ToNot(BeNil(), "result should be available")
 	g.Expect(rs.Token).ToNot(BeNil(), "result token should be available")
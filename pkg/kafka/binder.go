@@ -51,6 +51,7 @@ type SaramaKafkaBinder struct {
 	handlerInterceptors  []ConsumerHandlerInterceptor
 	monitor              *loop.Loop
 	tlsCertsManager      certs.Manager
+	schemaRegistry       SchemaRegistry
 
 	// TODO consider mutex lock for following fields
 	producers      map[string]BindingLifecycle
@@ -76,6 +77,7 @@ type BinderOption struct {
 	ConsumerInterceptors []ConsumerDispatchInterceptor
 	HandlerInterceptors  []ConsumerHandlerInterceptor
 	TLSCertsManager      certs.Manager
+	SchemaRegistry       SchemaRegistry
 }
 
 func NewBinder(ctx context.Context, opts ...BinderOptions) *SaramaKafkaBinder {
@@ -98,6 +100,7 @@ func NewBinder(ctx context.Context, opts ...BinderOptions) *SaramaKafkaBinder {
 		subscribers:          make(map[string]BindingLifecycle),
 		consumerGroups:       make(map[string]BindingLifecycle),
 		tlsCertsManager:      opt.TLSCertsManager,
+		schemaRegistry:       opt.SchemaRegistry,
 	}
 
 	if e := s.Initialize(ctx); e != nil {
@@ -127,6 +130,7 @@ func (b *SaramaKafkaBinder) prepareDefaults(ctx context.Context, saramaDefaults
 			dispatchInterceptors: b.consumerInterceptors,
 			handlerInterceptors:  b.handlerInterceptors,
 			msgLogger:            newSaramaMessageLogger(),
+			schemaRegistry:       b.schemaRegistry,
 		},
 	}
 
@@ -219,15 +223,75 @@ func (b *SaramaKafkaBinder) Consume(topic string, group string, options ...Consu
 	props := b.loadProperties(cfg.name)
 	WithConsumerProperties(&props.Consumer)(&cfg)
 
+	// retry and dead-letter topics
+	var retryFinalizer *retryDispatchFinalizer
+	if cfg.consumer.retry.enabled() {
+		cfg.consumer.retry.maxWait = retryMaxWait(&cfg.sarama)
+		var e error
+		if retryFinalizer, e = b.prepareRetryFinalizer(topic, group, &cfg.consumer.retry); e != nil {
+			return nil, e
+		}
+		interceptors := make([]ConsumerDispatchInterceptor, len(cfg.consumer.dispatchInterceptors), len(cfg.consumer.dispatchInterceptors)+1)
+		copy(interceptors, cfg.consumer.dispatchInterceptors)
+		cfg.consumer.dispatchInterceptors = append(interceptors, retryFinalizer)
+	}
+
 	cg, err := newSaramaGroupConsumer(topic, group, b.brokers, &cfg, b.provisioner)
 	if err != nil {
 		return nil, err
 	}
 
 	b.consumerGroups[topic] = cg
+	if retryFinalizer != nil {
+		if e := b.bindRetryConsumers(retryFinalizer, group, &cfg, cg); e != nil {
+			return nil, e
+		}
+	}
 	return cg, b.tryScheduleStart(cg)
 }
 
+// prepareRetryFinalizer provision retry and dead-letter topics by creating producers of them
+func (b *SaramaKafkaBinder) prepareRetryFinalizer(topic, group string, cfg *retryConfig) (*retryDispatchFinalizer, error) {
+	f := newRetryDispatchFinalizer(topic, group, cfg, map[string]Producer{})
+	targets := f.retryTopics
+	if f.deadLetterTopic != "" {
+		targets = append(targets[:len(targets):len(targets)], f.deadLetterTopic)
+	}
+	for _, t := range targets {
+		if existing, ok := b.producers[t].(Producer); ok && !b.producers[t].Closed() {
+			f.producers[t] = existing
+			continue
+		}
+		p, e := b.Produce(t)
+		if e != nil {
+			return nil, e
+		}
+		f.producers[t] = p
+	}
+	return f, nil
+}
+
+// bindRetryConsumers create group consumers of retry topics, sharing the dispatcher of given main consumer
+func (b *SaramaKafkaBinder) bindRetryConsumers(f *retryDispatchFinalizer, group string, cfg *bindingConfig, main *saramaGroupConsumer) error {
+	for _, t := range f.retryTopics {
+		if c, ok := b.consumerGroups[t]; ok && !c.Closed() {
+			return NewKafkaError(ErrorCodeConsumerExists, fmt.Sprintf(errTmplConsumerGroupExists, t))
+		}
+		retryCfg := *cfg // make a copy
+		rc, e := newSaramaGroupConsumer(t, group, b.brokers, &retryCfg, b.provisioner)
+		if e != nil {
+			return e
+		}
+		rc.dispatcher = main.dispatcher
+		rc.retry = f
+		b.consumerGroups[t] = rc
+		if e := b.tryScheduleStart(rc); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
 func (b *SaramaKafkaBinder) ListTopics() (topics []string) {
 	topics = make([]string, 0, len(b.producers)+len(b.subscribers)+len(b.consumerGroups))
 	for t := range b.producers {
@@ -33,10 +33,12 @@ type saramaGroupConsumer struct {
 	config      *bindingConfig
 	dispatcher  *saramaDispatcher
 	provisioner *saramaTopicProvisioner
-	started     bool
-	consumer    sarama.ConsumerGroup
-	cancelFunc  context.CancelFunc
-	closed      bool
+	// retry is set when the consumer consumes retry topics. Messages are held by the claim loop until they are due
+	retry      *retryDispatchFinalizer
+	started    bool
+	consumer   sarama.ConsumerGroup
+	cancelFunc context.CancelFunc
+	closed     bool
 }
 
 func newSaramaGroupConsumer(topic string, group string, addrs []string, config *bindingConfig, provisioner *saramaTopicProvisioner) (*saramaGroupConsumer, error) {
@@ -200,6 +202,12 @@ func (h saramaGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, cl
 			if !ok {
 				return nil
 			}
+			if h.owner.retry != nil {
+				if e := h.owner.retry.hold(session.Context(), msg); e != nil {
+					// session ended while the message was held. It's not marked, so it will be consumed again
+					return nil
+				}
+			}
 			go h.handleMessage(session.Context(), session, msg)
 		case <-session.Context().Done():
 			return nil
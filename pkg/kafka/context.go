@@ -30,6 +30,10 @@ const (
 	MIMETypeJson   = "application/json;charset=utf-8"
 	MIMETypeBinary = "application/octet-stream"
 	MIMETypeText   = "text/plain"
+	// MIMETypeAvro payload is Avro binary in Confluent wire format (magic byte and schema ID followed by data)
+	MIMETypeAvro = "application/avro"
+	// MIMETypeProtobuf payload is Protobuf binary in Confluent wire format (magic byte, schema ID and message indexes followed by data)
+	MIMETypeProtobuf = "application/x-protobuf"
 )
 
 /************************
@@ -144,6 +148,35 @@ type Producer interface {
 	ReadyCh() <-chan struct{}
 }
 
+// NewMessageContext create a MessageContext of given message the same way as Producer.Send would do before applying
+// ProducerMessageInterceptor. Supported message types are same as Producer.Send.
+// This is useful for Producer implementations that defer the actual sending, e.g. transactional outbox.
+func NewMessageContext(ctx context.Context, topic string, message interface{}, options ...MessageOptions) *MessageContext {
+	msgCtx := MessageContext{
+		Context:       ctx,
+		Topic:         topic,
+		messageConfig: defaultMessageConfig(),
+	}
+	switch m := message.(type) {
+	case *Message:
+		msgCtx.Message = *m
+	case Message:
+		msgCtx.Message = m
+	default:
+		msgCtx.Message = Message{
+			Headers: Headers{},
+			Payload: message,
+		}
+	}
+	if msgCtx.Message.Headers == nil {
+		msgCtx.Message.Headers = Headers{}
+	}
+	for _, optionFunc := range options {
+		optionFunc(&msgCtx.messageConfig)
+	}
+	return &msgCtx
+}
+
 type ProducerMessageInterceptor interface {
 	// Intercept is called before raw message is prepared and send.
 	// Implementations can modify fields of MessageContext to manipulate sending behaviour.
@@ -254,6 +287,8 @@ type consumerConfig struct {
 	dispatchInterceptors []ConsumerDispatchInterceptor
 	handlerInterceptors  []ConsumerHandlerInterceptor
 	msgLogger            MessageLogger
+	retry                retryConfig
+	schemaRegistry       SchemaRegistry
 }
 
 type topicConfig struct {
@@ -23,6 +23,7 @@ import (
 	"github.com/IBM/sarama"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 // MessageHandlerFunc is message handling function that conform with following signature:
@@ -97,6 +98,9 @@ type Dispatcher struct {
 	handlers     []*handler
 	Interceptors []ConsumerDispatchInterceptor
 	Logger       MessageLogger
+	// SchemaRegistry is required to decode payloads encoded with schema ID, such as Avro. Optional
+	SchemaRegistry SchemaRegistry
+	avroSchemas    sync.Map
 }
 
 func (d *Dispatcher) AddHandler(fn MessageHandlerFunc, opts ...DispatchOptions) error {
@@ -150,6 +154,10 @@ func (d *Dispatcher) AddHandler(fn MessageHandlerFunc, opts ...DispatchOptions)
 	return nil
 }
 
+// errSkipDispatch can be returned by ConsumerDispatchInterceptor to stop dispatching without error,
+// e.g. the message is re-queued to be consumed later
+var errSkipDispatch = errors.New("message dispatch skipped")
+
 //nolint:contextcheck // context is passed inside msgCtx
 func (d *Dispatcher) Dispatch(msgCtx *MessageContext) (err error) {
 	defer func() {
@@ -161,16 +169,26 @@ func (d *Dispatcher) Dispatch(msgCtx *MessageContext) (err error) {
 		}
 	}()
 
-	// invoke Interceptors
-	for _, interceptor := range d.Interceptors {
-		msgCtx, err = interceptor.Intercept(msgCtx)
-		if err != nil {
-			return ErrorSubTypeConsumerGeneral.WithMessage("consumer dispatch interceptor error: %v", err)
+	// invoke Interceptors.
+	// note: when dispatching is stopped by an interceptor, finalizers of interceptors that are already invoked are still
+	// 		 invoked, so resources opened by them (e.g. tracing spans) are released
+	for i, interceptor := range d.Interceptors {
+		var intercepted *MessageContext
+		intercepted, err = interceptor.Intercept(msgCtx)
+		if intercepted != nil {
+			msgCtx = intercepted
+		}
+		switch {
+		case errors.Is(err, errSkipDispatch):
+			return d.finalizeDispatch(msgCtx, nil, d.Interceptors[:i+1])
+		case err != nil:
+			err = ErrorSubTypeConsumerGeneral.WithMessage("consumer dispatch interceptor error: %v", err)
+			return d.finalizeDispatch(msgCtx, err, d.Interceptors[:i])
 		}
 	}
 
 	defer func() {
-		err = d.finalizeDispatch(msgCtx, err)
+		err = d.finalizeDispatch(msgCtx, err, d.Interceptors)
 	}()
 
 	// log message
@@ -219,8 +237,8 @@ func (d *Dispatcher) dispatch(msgCtx *MessageContext, h *handler) (err error) {
 	return
 }
 
-func (d *Dispatcher) finalizeDispatch(msgCtx *MessageContext, err error) error {
-	for _, interceptor := range d.Interceptors {
+func (d *Dispatcher) finalizeDispatch(msgCtx *MessageContext, err error, interceptors []ConsumerDispatchInterceptor) error {
+	for _, interceptor := range interceptors {
 		switch finalizer := interceptor.(type) {
 		case ConsumerDispatchFinalizer:
 			msgCtx, err = finalizer.Finalize(msgCtx, err)
@@ -242,7 +260,7 @@ func (d *Dispatcher) finalizeDispatch(msgCtx *MessageContext, err error) error {
 	Helpers
  ********************/
 
-func (d *Dispatcher) decodePayload(_ context.Context, typ reflect.Type, msg *Message) error {
+func (d *Dispatcher) decodePayload(ctx context.Context, typ reflect.Type, msg *Message) error {
 	if _, ok := msg.Payload.([]byte); !ok || typ == nil {
 		return nil
 	}
@@ -258,6 +276,10 @@ func (d *Dispatcher) decodePayload(_ context.Context, typ reflect.Type, msg *Mes
 		msg.Payload = string(msg.Payload.([]byte))
 	case contentType == MIMETypeBinary:
 		//  do nothing
+	case contentType == MIMETypeAvro:
+		return d.decodeAvro(ctx, typ, msg)
+	case contentType == MIMETypeProtobuf:
+		return d.decodeProtobuf(ctx, typ, msg)
 	default:
 		return ErrorSubTypeDecoding.WithMessage("unsupported MIME type %s", contentType)
 	}
@@ -347,9 +369,10 @@ type saramaDispatcher struct {
 func newSaramaDispatcher(cfg *bindingConfig) *saramaDispatcher {
 	return &saramaDispatcher{
 		Dispatcher{
-			handlers:     []*handler{},
-			Interceptors: cfg.consumer.dispatchInterceptors,
-			Logger:       cfg.msgLogger,
+			handlers:       []*handler{},
+			Interceptors:   cfg.consumer.dispatchInterceptors,
+			Logger:         cfg.msgLogger,
+			SchemaRegistry: cfg.consumer.schemaRegistry,
 		},
 	}
 }
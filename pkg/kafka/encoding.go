@@ -57,6 +57,25 @@ func (enc binaryEncoder) Encode(v interface{}) (bytes []byte, err error) {
 	}
 }
 
+// rawEncoder encode raw bytes with given MIME type. It's used to re-publish messages that are already encoded
+type rawEncoder struct {
+	mimeType string
+}
+
+// NewRawEncoder returns an Encoder that encodes values the same way as binary encoder, but reports given MIME type.
+// This is useful to publish payloads that are already encoded, e.g. forwarding consumed messages as-is.
+func NewRawEncoder(mimeType string) Encoder {
+	return rawEncoder{mimeType: mimeType}
+}
+
+func (enc rawEncoder) MIMEType() string {
+	return enc.mimeType
+}
+
+func (enc rawEncoder) Encode(v interface{}) ([]byte, error) {
+	return binaryEncoder{}.Encode(v)
+}
+
 type saramaEncoderWrapper struct {
 	v     interface{}
 	enc   Encoder
@@ -0,0 +1,283 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"reflect"
+	"sync"
+)
+
+// Confluent wire format: magic byte (0x0), followed by 4-bytes big-endian schema ID.
+// Protobuf payload also includes message indexes after schema ID.
+// See https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format
+const (
+	wireFormatMagicByte  byte = 0x0
+	wireFormatHeaderSize      = 5
+)
+
+/*************************
+	Avro
+ *************************/
+
+type avroEncoder struct {
+	registry SchemaRegistry
+	subject  string
+	schema   avro.Schema
+	mtx      sync.Mutex
+	id       int
+}
+
+// NewAvroEncoder create an Encoder that encode values as Avro binary with given schema, in Confluent wire format.
+// The schema is registered to SchemaRegistry under given subject when the first message is encoded.
+// Typically, the subject should follow Confluent's "TopicNameStrategy", e.g. "<topic>-value"
+func NewAvroEncoder(registry SchemaRegistry, subject string, schema string) (Encoder, error) {
+	if registry == nil {
+		return nil, ErrorSubTypeIllegalProducerUsage.WithMessage("schema registry is required for Avro encoder")
+	}
+	parsed, e := avro.Parse(schema)
+	if e != nil {
+		return nil, ErrorSubTypeIllegalProducerUsage.WithCause(e, "invalid Avro schema: %v", e)
+	}
+	return &avroEncoder{
+		registry: registry,
+		subject:  subject,
+		schema:   parsed,
+	}, nil
+}
+
+func (enc *avroEncoder) MIMEType() string {
+	return MIMETypeAvro
+}
+
+func (enc *avroEncoder) Encode(v interface{}) ([]byte, error) {
+	id, e := enc.schemaID()
+	if e != nil {
+		return nil, e
+	}
+	data, e := avro.Marshal(enc.schema, v)
+	if e != nil {
+		return nil, ErrorSubTypeEncoding.WithCause(e, "unable to encode as Avro: %v", e)
+	}
+	return append(wireFormatHeader(id), data...), nil
+}
+
+func (enc *avroEncoder) schemaID() (int, error) {
+	enc.mtx.Lock()
+	defer enc.mtx.Unlock()
+	if enc.id != 0 {
+		return enc.id, nil
+	}
+	id, e := enc.registry.Register(context.Background(), enc.subject, SchemaTypeAvro, enc.schema.String())
+	if e != nil {
+		return 0, ErrorSubTypeEncoding.WithCause(e, "unable to register Avro schema with subject [%s]: %v", enc.subject, e)
+	}
+	enc.id = id
+	return id, nil
+}
+
+func (d *Dispatcher) decodeAvro(ctx context.Context, typ reflect.Type, msg *Message) error {
+	id, data, e := parseWireFormatHeader(msg.Payload.([]byte))
+	if e != nil {
+		return e
+	}
+	schema, e := d.avroSchema(ctx, id)
+	if e != nil {
+		return e
+	}
+	ptr, v := d.instantiateByType(typ)
+	if e := avro.Unmarshal(schema, data, ptr.Interface()); e != nil {
+		return ErrorSubTypeDecoding.WithCause(e, "unable to decode as Avro: %v", e)
+	}
+	msg.Payload = v.Interface()
+	return nil
+}
+
+func (d *Dispatcher) avroSchema(ctx context.Context, id int) (avro.Schema, error) {
+	if cached, ok := d.avroSchemas.Load(id); ok {
+		return cached.(avro.Schema), nil
+	}
+	if d.SchemaRegistry == nil {
+		return nil, ErrorSubTypeDecoding.WithMessage("schema registry is required to decode Avro message")
+	}
+	s, e := d.SchemaRegistry.SchemaByID(ctx, id)
+	if e != nil {
+		// registry may be temporarily unavailable, so this is not considered as decoding error
+		return nil, ErrorSubTypeConsumerGeneral.WithCause(e, "unable to resolve schema [%d]: %v", id, e)
+	}
+	if s.Type != SchemaTypeAvro {
+		return nil, ErrorSubTypeDecoding.WithMessage("schema [%d] is not Avro schema: %s", id, s.Type)
+	}
+	// each schema is parsed with its own cache, so named types of different schema versions don't collide
+	schema, e := avro.ParseWithCache(s.Schema, "", &avro.SchemaCache{})
+	if e != nil {
+		return nil, ErrorSubTypeDecoding.WithCause(e, "invalid Avro schema [%d]: %v", id, e)
+	}
+	d.avroSchemas.Store(id, schema)
+	return schema, nil
+}
+
+/*************************
+	Protobuf
+ *************************/
+
+type protobufEncoder struct {
+	registry SchemaRegistry
+	subject  string
+	mtx      sync.Mutex
+	ids      map[protoreflect.FullName]int
+}
+
+// NewProtobufEncoder create an Encoder that encode proto.Message in Confluent wire format.
+// The schema of each message type (serialized FileDescriptorProto of the file that defines the message)
+// is registered to SchemaRegistry under given subject when the first message of that type is encoded.
+// Note: imported .proto files are not registered as schema references.
+func NewProtobufEncoder(registry SchemaRegistry, subject string) (Encoder, error) {
+	if registry == nil {
+		return nil, ErrorSubTypeIllegalProducerUsage.WithMessage("schema registry is required for Protobuf encoder")
+	}
+	return &protobufEncoder{
+		registry: registry,
+		subject:  subject,
+		ids:      map[protoreflect.FullName]int{},
+	}, nil
+}
+
+func (enc *protobufEncoder) MIMEType() string {
+	return MIMETypeProtobuf
+}
+
+func (enc *protobufEncoder) Encode(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrorSubTypeEncoding.WithMessage("unsupported value for Protobuf encoding: %T", v)
+	}
+	desc := m.ProtoReflect().Descriptor()
+	id, e := enc.schemaID(desc)
+	if e != nil {
+		return nil, e
+	}
+	data, e := proto.Marshal(m)
+	if e != nil {
+		return nil, ErrorSubTypeEncoding.WithCause(e, "unable to encode as Protobuf: %v", e)
+	}
+	ret := appendMessageIndexes(wireFormatHeader(id), desc)
+	return append(ret, data...), nil
+}
+
+func (enc *protobufEncoder) schemaID(desc protoreflect.MessageDescriptor) (int, error) {
+	enc.mtx.Lock()
+	defer enc.mtx.Unlock()
+	if id, ok := enc.ids[desc.FullName()]; ok {
+		return id, nil
+	}
+	fd, e := proto.Marshal(protodesc.ToFileDescriptorProto(desc.ParentFile()))
+	if e != nil {
+		return 0, ErrorSubTypeEncoding.WithCause(e, "unable to serialize descriptor of %s: %v", desc.FullName(), e)
+	}
+	id, e := enc.registry.Register(context.Background(), enc.subject, SchemaTypeProtobuf, base64.StdEncoding.EncodeToString(fd))
+	if e != nil {
+		return 0, ErrorSubTypeEncoding.WithCause(e, "unable to register Protobuf schema with subject [%s]: %v", enc.subject, e)
+	}
+	enc.ids[desc.FullName()] = id
+	return id, nil
+}
+
+// decodeProtobuf decode Protobuf payload into the handler's payload type. Since the type is known by the handler,
+// schema registry is not consulted.
+func (d *Dispatcher) decodeProtobuf(_ context.Context, typ reflect.Type, msg *Message) error {
+	_, data, e := parseWireFormatHeader(msg.Payload.([]byte))
+	if e != nil {
+		return e
+	}
+	if data, e = skipMessageIndexes(data); e != nil {
+		return e
+	}
+	ptr, v := d.instantiateByType(typ)
+	var m proto.Message
+	switch {
+	case v.Type().Implements(reflect.TypeOf((*proto.Message)(nil)).Elem()):
+		m = v.Interface().(proto.Message)
+	case ptr.Type().Implements(reflect.TypeOf((*proto.Message)(nil)).Elem()):
+		m = ptr.Interface().(proto.Message)
+	default:
+		return ErrorSubTypeDecoding.WithMessage("unable to decode as Protobuf: %v is not proto.Message", typ)
+	}
+	if e := proto.Unmarshal(data, m); e != nil {
+		return ErrorSubTypeDecoding.WithCause(e, "unable to decode as Protobuf: %v", e)
+	}
+	msg.Payload = v.Interface()
+	return nil
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func wireFormatHeader(id int) []byte {
+	header := make([]byte, wireFormatHeaderSize, 16)
+	header[0] = wireFormatMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	return header
+}
+
+func parseWireFormatHeader(data []byte) (id int, payload []byte, err error) {
+	if len(data) < wireFormatHeaderSize || data[0] != wireFormatMagicByte {
+		return 0, nil, ErrorSubTypeDecoding.WithMessage("payload is not in schema registry wire format")
+	}
+	return int(binary.BigEndian.Uint32(data[1:wireFormatHeaderSize])), data[wireFormatHeaderSize:], nil
+}
+
+// appendMessageIndexes append the path of message type within its .proto file, as zig-zag varints.
+// The most common case [0] (first message in the file) is encoded as single 0 byte.
+func appendMessageIndexes(buf []byte, desc protoreflect.MessageDescriptor) []byte {
+	var indexes []int
+	for d := protoreflect.Descriptor(desc); d != nil; d = d.Parent() {
+		if md, ok := d.(protoreflect.MessageDescriptor); ok {
+			indexes = append([]int{md.Index()}, indexes...)
+		}
+	}
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(buf, 0)
+	}
+	buf = binary.AppendVarint(buf, int64(len(indexes)))
+	for _, i := range indexes {
+		buf = binary.AppendVarint(buf, int64(i))
+	}
+	return buf
+}
+
+func skipMessageIndexes(data []byte) ([]byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return nil, ErrorSubTypeDecoding.WithMessage("invalid Protobuf message indexes")
+	}
+	data = data[n:]
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(data); n <= 0 {
+			return nil, ErrorSubTypeDecoding.WithMessage("invalid Protobuf message indexes")
+		}
+		data = data[n:]
+	}
+	return data, nil
+}
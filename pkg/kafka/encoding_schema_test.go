@@ -0,0 +1,267 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	schemaTestSubject = `test-schema-value`
+	schemaTestAvro    = `{
+  "type": "record",
+  "name": "TestAvroPayload",
+  "namespace": "com.example",
+  "fields": [
+    {"name": "name", "type": "string"},
+    {"name": "count", "type": "int"}
+  ]
+}`
+)
+
+type TestAvroPayload struct {
+	Name  string `avro:"name"`
+	Count int    `avro:"count"`
+}
+
+/*************************
+	Test Cases
+ *************************/
+
+func TestSchemaEncoding(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestAvroRoundTrip(), "AvroRoundTrip"),
+		test.GomegaSubTest(SubTestAvroWithoutRegistry(), "AvroWithoutRegistry"),
+		test.GomegaSubTest(SubTestAvroRegistryUnavailable(), "AvroRegistryUnavailable"),
+		test.GomegaSubTest(SubTestProtobufRoundTrip(), "ProtobufRoundTrip"),
+		test.GomegaSubTest(SubTestProtobufInvalidPayload(), "ProtobufInvalidPayload"),
+	)
+}
+
+func TestSchemaRegistryClient(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestSchemaRegistryClientRegister(), "Register"),
+		test.GomegaSubTest(SubTestSchemaRegistryClientError(), "Error"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestAvroRoundTrip() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		registry := NewInMemorySchemaRegistry()
+		enc, e := NewAvroEncoder(registry, schemaTestSubject, schemaTestAvro)
+		g.Expect(e).To(Succeed(), "creating Avro encoder should not fail")
+		g.Expect(enc.MIMEType()).To(Equal(MIMETypeAvro))
+
+		data, e := enc.Encode(&TestAvroPayload{Name: "avro", Count: 10})
+		g.Expect(e).To(Succeed(), "encoding should not fail")
+		g.Expect(data[:wireFormatHeaderSize]).To(Equal([]byte{0, 0, 0, 0, 1}), "payload should start with wire format header")
+		g.Expect(registry.Subjects(schemaTestSubject)).To(Equal([]int{1}), "schema should be registered")
+
+		_, e = enc.Encode(&TestAvroPayload{Name: "again"})
+		g.Expect(e).To(Succeed(), "encoding should not fail")
+		g.Expect(registry.Subjects(schemaTestSubject)).To(HaveLen(1), "schema should be registered once")
+
+		var received *TestAvroPayload
+		d := &Dispatcher{SchemaRegistry: registry}
+		e = d.AddHandler(func(_ context.Context, payload *TestAvroPayload) error {
+			received = payload
+			return nil
+		})
+		g.Expect(e).To(Succeed(), "adding handler should not fail")
+		e = d.Dispatch(newTestSchemaMessageContext(ctx, MIMETypeAvro, data))
+		g.Expect(e).To(Succeed(), "dispatching should not fail")
+		g.Expect(received).To(Equal(&TestAvroPayload{Name: "avro", Count: 10}), "payload should be decoded")
+	}
+}
+
+func SubTestAvroWithoutRegistry() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		_, e := NewAvroEncoder(nil, schemaTestSubject, schemaTestAvro)
+		g.Expect(e).To(HaveOccurred(), "creating Avro encoder without registry should fail")
+		_, e = NewAvroEncoder(NewInMemorySchemaRegistry(), schemaTestSubject, `{"type":"unknown"}`)
+		g.Expect(e).To(HaveOccurred(), "creating Avro encoder with invalid schema should fail")
+
+		enc, _ := NewAvroEncoder(NewInMemorySchemaRegistry(), schemaTestSubject, schemaTestAvro)
+		data, e := enc.Encode(&TestAvroPayload{Name: "avro", Count: 10})
+		g.Expect(e).To(Succeed(), "encoding should not fail")
+
+		d := &Dispatcher{}
+		e = d.AddHandler(func(_ context.Context, payload *TestAvroPayload) error { return nil })
+		g.Expect(e).To(Succeed(), "adding handler should not fail")
+		e = d.Dispatch(newTestSchemaMessageContext(ctx, MIMETypeAvro, data))
+		g.Expect(e).To(HaveOccurred(), "decoding without registry should fail")
+		g.Expect(e).To(BeAssignableToTypeOf(ErrorSubTypeDecoding.WithMessage("")), "error should be decoding error")
+	}
+}
+
+func SubTestAvroRegistryUnavailable() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		registry := NewInMemorySchemaRegistry()
+		enc, _ := NewAvroEncoder(registry, schemaTestSubject, schemaTestAvro)
+		data, e := enc.Encode(&TestAvroPayload{Name: "avro", Count: 10})
+		g.Expect(e).To(Succeed(), "encoding should not fail")
+
+		d := &Dispatcher{SchemaRegistry: unavailableSchemaRegistry{registry}}
+		e = d.AddHandler(func(_ context.Context, payload *TestAvroPayload) error { return nil })
+		g.Expect(e).To(Succeed(), "adding handler should not fail")
+		e = d.Dispatch(newTestSchemaMessageContext(ctx, MIMETypeAvro, data))
+		g.Expect(e).To(HaveOccurred(), "decoding with unavailable registry should fail")
+		g.Expect(errors.Is(e, ErrorSubTypeDecoding)).To(BeFalse(), "error should not be decoding error")
+		g.Expect(isRetryableError(e)).To(BeTrue(), "error should be retryable")
+	}
+}
+
+func SubTestProtobufRoundTrip() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		registry := NewInMemorySchemaRegistry()
+		enc, e := NewProtobufEncoder(registry, schemaTestSubject)
+		g.Expect(e).To(Succeed(), "creating Protobuf encoder should not fail")
+		g.Expect(enc.MIMEType()).To(Equal(MIMETypeProtobuf))
+
+		// DoubleValue is the first message in its file, StringValue is not
+		first, e := enc.Encode(wrapperspb.Double(1.5))
+		g.Expect(e).To(Succeed(), "encoding should not fail")
+		g.Expect(first[wireFormatHeaderSize]).To(Equal(byte(0)), "first message type should have single 0 as indexes")
+		other, e := enc.Encode(wrapperspb.String("protobuf"))
+		g.Expect(e).To(Succeed(), "encoding should not fail")
+		g.Expect(other[wireFormatHeaderSize:wireFormatHeaderSize+2]).To(Equal([]byte{2, 14}), "message indexes should be encoded as zig-zag varints")
+		g.Expect(registry.Subjects(schemaTestSubject)).To(HaveLen(1), "same file should be registered once")
+		s, e := registry.SchemaByID(ctx, 1)
+		g.Expect(e).To(Succeed(), "schema should be registered")
+		g.Expect(s.Type).To(Equal(SchemaTypeProtobuf))
+
+		var received *wrapperspb.StringValue
+		d := &Dispatcher{}
+		e = d.AddHandler(func(_ context.Context, payload *wrapperspb.StringValue) error {
+			received = payload
+			return nil
+		})
+		g.Expect(e).To(Succeed(), "adding handler should not fail")
+		e = d.Dispatch(newTestSchemaMessageContext(ctx, MIMETypeProtobuf, other))
+		g.Expect(e).To(Succeed(), "dispatching should not fail")
+		g.Expect(received.GetValue()).To(Equal("protobuf"), "payload should be decoded")
+	}
+}
+
+func SubTestProtobufInvalidPayload() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		enc, _ := NewProtobufEncoder(NewInMemorySchemaRegistry(), schemaTestSubject)
+		_, e := enc.Encode(&TestAvroPayload{})
+		g.Expect(e).To(HaveOccurred(), "encoding non-proto value should fail")
+
+		d := &Dispatcher{}
+		e = d.AddHandler(func(_ context.Context, payload *wrapperspb.StringValue) error { return nil })
+		g.Expect(e).To(Succeed(), "adding handler should not fail")
+		e = d.Dispatch(newTestSchemaMessageContext(ctx, MIMETypeProtobuf, []byte(`{"value":"json"}`)))
+		g.Expect(e).To(HaveOccurred(), "decoding payload not in wire format should fail")
+	}
+}
+
+func SubTestSchemaRegistryClientRegister() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		var registered srSchemaRequest
+		var calls int
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			calls++
+			user, pass, _ := r.BasicAuth()
+			g.Expect(user+":"+pass).To(Equal("user:pass"), "request should be authenticated")
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/subjects/"+schemaTestSubject+"/versions":
+				g.Expect(r.Header.Get("Content-Type")).To(Equal(schemaRegistryMIMEType))
+				_ = json.NewDecoder(r.Body).Decode(&registered)
+				_, _ = rw.Write([]byte(`{"id":42}`))
+			case r.Method == http.MethodGet && r.URL.Path == "/schemas/ids/7":
+				_, _ = rw.Write([]byte(`{"schema":"\"string\""}`))
+			default:
+				rw.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer srv.Close()
+
+		client := NewSchemaRegistryClient(WithSchemaRegistryProperties(&SchemaRegistryProperties{
+			URL: srv.URL + "/", Username: "user", Password: "pass",
+		}))
+		id, e := client.Register(ctx, schemaTestSubject, SchemaTypeAvro, `"string"`)
+		g.Expect(e).To(Succeed(), "register should not fail")
+		g.Expect(id).To(Equal(42))
+		g.Expect(registered).To(Equal(srSchemaRequest{Schema: `"string"`}), "schema type should be omitted for Avro")
+		_, _ = client.Register(ctx, schemaTestSubject, SchemaTypeAvro, `"string"`)
+		s, e := client.SchemaByID(ctx, 42)
+		g.Expect(e).To(Succeed(), "registered schema should be cached")
+		g.Expect(s.Schema).To(Equal(`"string"`))
+		g.Expect(calls).To(Equal(1), "registration should be cached")
+
+		s, e = client.SchemaByID(ctx, 7)
+		g.Expect(e).To(Succeed(), "get schema should not fail")
+		g.Expect(s).To(Equal(&Schema{ID: 7, Type: SchemaTypeAvro, Schema: `"string"`}), "schema type should default to Avro")
+		_, _ = client.SchemaByID(ctx, 7)
+		g.Expect(calls).To(Equal(2), "schema should be cached")
+	}
+}
+
+func SubTestSchemaRegistryClientError() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = rw.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+		}))
+		defer srv.Close()
+
+		client := NewSchemaRegistryClient(WithSchemaRegistryProperties(&SchemaRegistryProperties{URL: srv.URL}))
+		_, e := client.SchemaByID(ctx, 1)
+		g.Expect(e).To(HaveOccurred(), "get schema should fail")
+		g.Expect(e).To(MatchError(ContainSubstring("40403")), "error should contain registry error code")
+		g.Expect(e).To(MatchError(ErrorSubTypeSchemaRegistry), "error should be schema registry error")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+// unavailableSchemaRegistry fails schema lookup as if the registry is not reachable
+type unavailableSchemaRegistry struct {
+	SchemaRegistry
+}
+
+func (r unavailableSchemaRegistry) SchemaByID(_ context.Context, _ int) (*Schema, error) {
+	return nil, ErrorSubTypeSchemaRegistry.WithMessage("connection refused")
+}
+
+func newTestSchemaMessageContext(ctx context.Context, contentType string, data []byte) *MessageContext {
+	return &MessageContext{
+		Context: ctx,
+		Message: Message{
+			Headers: Headers{HeaderContentType: contentType},
+			Payload: data,
+		},
+		Topic: "test-schema",
+	}
+}
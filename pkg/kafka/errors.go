@@ -44,6 +44,7 @@ const (
 	ErrorSubTypeCodeBindingInternal = ErrorTypeCodeBinding + iota<<errorutils.ErrorSubTypeOffset
 	ErrorSubTypeCodeConnectivity
 	ErrorSubTypeCodeProvisioning
+	ErrorSubTypeCodeSchemaRegistry
 )
 
 // ErrorSubTypeCodeBindingInternal
@@ -99,6 +100,7 @@ var (
 	ErrorSubTypeBindingInternal = NewErrorSubType(ErrorSubTypeCodeBindingInternal, errors.New("error sub-type: internal"))
 	ErrorSubTypeConnectivity    = NewErrorSubType(ErrorSubTypeCodeConnectivity, errors.New("error sub-type: connectivity"))
 	ErrorSubTypeProvisioning    = NewErrorSubType(ErrorSubTypeCodeProvisioning, errors.New("error sub-type: provisioning"))
+	ErrorSubTypeSchemaRegistry  = NewErrorSubType(ErrorSubTypeCodeSchemaRegistry, errors.New("error sub-type: schema registry"))
 
 	ErrorSubTypeProducerGeneral      = NewErrorSubType(ErrorSubTypeCodeProducerGeneral, errors.New("error sub-type: producer"))
 	ErrorSubTypeIllegalProducerUsage = NewErrorSubType(ErrorSubTypeCodeIllegalProducerUsage, errors.New("error sub-type: producer api usage"))
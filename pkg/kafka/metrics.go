@@ -0,0 +1,116 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"go.uber.org/fx"
+	"time"
+)
+
+const (
+	MeterNameProducerMessages = "kafka.producer.messages"
+	MeterNameConsumerMessages = "kafka.consumer.messages"
+)
+
+type ctxKeyMetricsStartTime struct{}
+
+type metricsDI struct {
+	fx.In
+	Registry metrics.Registry `optional:"true"`
+}
+
+func metricsProvider() fx.Annotated {
+	return fx.Annotated{
+		Group: FxGroup,
+		Target: func(di metricsDI) (ProducerMessageInterceptor, ConsumerDispatchInterceptor) {
+			if di.Registry != nil {
+				return newKafkaMetricsInterceptors(di.Registry)
+			}
+			return nil, nil
+		},
+	}
+}
+
+func newKafkaMetricsInterceptors(registry metrics.Registry) (ProducerMessageInterceptor, ConsumerDispatchInterceptor) {
+	return &kafkaProducerMetricsInterceptor{
+		histogram: registry.Histogram(MeterNameProducerMessages,
+			metrics.WithDescription("Duration of sending Kafka messages, until delivery is confirmed"),
+			metrics.WithUnit("seconds"),
+			metrics.WithTags("topic", "outcome"),
+		),
+	}, &kafkaConsumerMetricsInterceptor{
+		histogram: registry.Histogram(MeterNameConsumerMessages,
+			metrics.WithDescription("Duration of processing received Kafka messages"),
+			metrics.WithUnit("seconds"),
+			metrics.WithTags("topic", "group", "outcome"),
+		),
+	}
+}
+
+// kafkaProducerMetricsInterceptor implements kafka.ProducerMessageInterceptor and kafka.ProducerMessageFinalizer
+type kafkaProducerMetricsInterceptor struct {
+	histogram metrics.Histogram
+}
+
+func (i kafkaProducerMetricsInterceptor) Intercept(msgCtx *MessageContext) (*MessageContext, error) {
+	msgCtx.Context = context.WithValue(msgCtx.Context, ctxKeyMetricsStartTime{}, time.Now())
+	return msgCtx, nil
+}
+
+func (i kafkaProducerMetricsInterceptor) Finalize(msgCtx *MessageContext, _ int32, _ int64, err error) (*MessageContext, error) {
+	if start, ok := msgCtx.Value(ctxKeyMetricsStartTime{}).(time.Time); ok {
+		i.histogram.Observe(time.Since(start).Seconds(), metrics.Tags{
+			"topic":   msgCtx.Topic,
+			"outcome": metricsOutcome(err),
+		})
+	}
+	return msgCtx, err
+}
+
+// kafkaConsumerMetricsInterceptor implements kafka.ConsumerDispatchInterceptor and kafka.ConsumerDispatchFinalizer
+type kafkaConsumerMetricsInterceptor struct {
+	histogram metrics.Histogram
+}
+
+func (i kafkaConsumerMetricsInterceptor) Intercept(msgCtx *MessageContext) (*MessageContext, error) {
+	msgCtx.Context = context.WithValue(msgCtx.Context, ctxKeyMetricsStartTime{}, time.Now())
+	return msgCtx, nil
+}
+
+func (i kafkaConsumerMetricsInterceptor) Finalize(msgCtx *MessageContext, err error) (*MessageContext, error) {
+	if start, ok := msgCtx.Value(ctxKeyMetricsStartTime{}).(time.Time); ok {
+		var group string
+		if gc, ok := msgCtx.Source.(GroupConsumer); ok {
+			group = gc.Group()
+		}
+		i.histogram.Observe(time.Since(start).Seconds(), metrics.Tags{
+			"topic":   msgCtx.Topic,
+			"group":   group,
+			"outcome": metricsOutcome(err),
+		})
+	}
+	return msgCtx, err
+}
+
+func metricsOutcome(err error) string {
+	if err != nil {
+		return "ERROR"
+	}
+	return "SUCCESS"
+}
@@ -157,6 +157,15 @@ func AckTimeout(timeout time.Duration) ProducerOptions {
 	}
 }
 
+// SkipInterceptors configures Producer to not apply any ProducerMessageInterceptor, except the built-in one that
+// encodes message value and sets Content-Type header.
+// This is useful for relaying messages that were already intercepted, e.g. by transactional outbox.
+func SkipInterceptors() ProducerOptions {
+	return func(config *bindingConfig) {
+		config.producer.interceptors = []ProducerMessageInterceptor{mimeTypeProducerInterceptor{}}
+	}
+}
+
 /***********************
   Options for consumer
 ************************/
@@ -171,6 +180,40 @@ func WithConsumerProperties(p *ConsumerProperties) ConsumerOptions {
 		utils.MustSetIfNotNil(&cfg.sarama.Consumer.Group.Rebalance.Timeout, p.Group.JoinTimeout)
 		utils.MustSetIfNotNil(&cfg.sarama.Consumer.Group.Rebalance.Retry.Max, p.Group.MaxRetry)
 		utils.MustSetIfNotNil(&cfg.sarama.Consumer.Group.Rebalance.Retry.Backoff, p.Group.Backoff)
+
+		switch {
+		case p.Retry.Enabled != nil && !*p.Retry.Enabled:
+			RetryTopics()(cfg)
+		case len(p.Retry.Delays) != 0:
+			delays := make([]time.Duration, len(p.Retry.Delays))
+			for i := range p.Retry.Delays {
+				delays[i] = time.Duration(p.Retry.Delays[i])
+			}
+			RetryTopics(delays...)(cfg)
+		case p.Retry.Enabled != nil:
+			RetryTopics(defaultRetryDelays...)(cfg)
+		}
+		utils.MustSetIfNotNil(&cfg.consumer.retry.deadLetter, p.DeadLetter.Enabled)
+		utils.MustSetIfNotNil(&cfg.consumer.retry.deadLetterTopic, p.DeadLetter.Topic)
+	}
+}
+
+// RetryTopics configures GroupConsumer to forward failed messages to retry topics, one per given delay.
+// Messages in each retry topic are processed again by the same handlers after corresponding delay.
+// Calling RetryTopics without any delays disables retry topics. This option has no effect on Subscriber.
+func RetryTopics(delays ...time.Duration) ConsumerOptions {
+	return func(cfg *bindingConfig) {
+		cfg.consumer.retry.delays = delays
+	}
+}
+
+// DeadLetterTopic configures GroupConsumer to forward failed messages to given dead-letter topic, when retries are exhausted
+// or the error is not retryable (e.g. decoding error). If topic is empty, DeadLetterTopicName is used.
+// This option has no effect on Subscriber.
+func DeadLetterTopic(topic string) ConsumerOptions {
+	return func(cfg *bindingConfig) {
+		cfg.consumer.retry.deadLetter = true
+		cfg.consumer.retry.deadLetterTopic = topic
 	}
 }
 
@@ -0,0 +1,60 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outbox
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/kafka"
+	"time"
+)
+
+const TableName = "kafka_outbox"
+
+// Message is a Kafka message stored in outbox table, pending to be published by the relay.
+// Application is responsible for creating the table, e.g. via AutoMigrate or a migration step:
+//
+//	CREATE TABLE IF NOT EXISTS "kafka_outbox" (
+//		"id" BIGSERIAL PRIMARY KEY,
+//		"topic" STRING NOT NULL,
+//		"key" BYTES,
+//		"headers" JSONB,
+//		"payload" BYTES,
+//		"attempts" INT NOT NULL DEFAULT 0,
+//		"last_error" STRING,
+//		"created_at" TIMESTAMPTZ,
+//		"sent_at" TIMESTAMPTZ,
+//		"failed_at" TIMESTAMPTZ,
+//		INDEX "idx_kafka_outbox_sent_at" ("sent_at", "id")
+//	);
+//
+// FailedAt is set when the message is parked after RelayProperties.MaxAttempts failed attempts.
+// Parked messages are neither published nor cleaned up, set "failed_at" back to NULL to retry.
+type Message struct {
+	ID        uint64        `gorm:"primaryKey;type:bigserial;"`
+	Topic     string        `gorm:"not null;"`
+	Key       []byte        `gorm:""`
+	Headers   kafka.Headers `gorm:"type:jsonb;serializer:json;"`
+	Payload   []byte        `gorm:""`
+	Attempts  int           `gorm:"not null;default:0;"`
+	LastError string        `gorm:""`
+	CreatedAt time.Time     `gorm:""`
+	SentAt    *time.Time    `gorm:"index:idx_kafka_outbox_sent_at,priority:1;"`
+	FailedAt  *time.Time    `gorm:""`
+}
+
+func (Message) TableName() string {
+	return TableName
+}
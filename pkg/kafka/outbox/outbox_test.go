@@ -0,0 +1,299 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/data/tx"
+	"github.com/cisco-open/go-lanai/pkg/kafka"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/apptest"
+	"github.com/cisco-open/go-lanai/test/dbtest"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"testing"
+)
+
+const (
+	testTopic       = `test-outbox`
+	testFailedTopic = `test-outbox-failed`
+	testOtherTopic  = `test-outbox-other`
+)
+
+/*************************
+	Test Cases
+ *************************/
+
+type testDI struct {
+	fx.In
+	dbtest.DI
+}
+
+func TestOutbox(t *testing.T) {
+	di := testDI{}
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		dbtest.WithDBPlayback("testdb"),
+		apptest.WithDI(&di),
+		test.GomegaSubTest(SubTestProducerSend(&di), "ProducerSend"),
+		test.GomegaSubTest(SubTestRelayInOrder(&di), "RelayInOrder"),
+		test.GomegaSubTest(SubTestRelayPoisonMessage(&di), "RelayPoisonMessage"),
+		test.GomegaSubTest(SubTestProducerSendInvalid(&di), "ProducerSendInvalid"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestProducerSend(di *testDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// capture inserted rows
+		const callbackName = "test:outbox_capture"
+		var created *Message
+		e := di.DB.Callback().Create().Before("gorm:create").Register(callbackName, func(db *gorm.DB) {
+			if m, ok := db.Statement.Dest.(*Message); ok {
+				created = m
+			}
+		})
+		g.Expect(e).To(Succeed(), "registering callback should not fail")
+		defer func() { _ = di.DB.Callback().Create().Remove(callbackName) }()
+
+		kafkaBinder := newMockedBinder()
+		interceptor := &mockedInterceptor{headers: kafka.Headers{"X-Intercepted": "true"}}
+		p, e := newOutboxBinder(di.DB, kafkaBinder, interceptor).Produce(testTopic)
+		g.Expect(e).To(Succeed(), "creating outbox producer should not fail")
+		g.Expect(p.Topic()).To(Equal(testTopic))
+		g.Expect(kafkaBinder.producers).To(HaveKey(testTopic), "kafka producer should be created")
+		g.Expect(kafkaBinder.options[testTopic]).To(HaveLen(1), "kafka producer should be created with SkipInterceptors")
+		g.Expect(p.ReadyCh()).To(BeClosed(), "outbox producer should be ready")
+
+		e = tx.Transaction(ctx, func(ctx context.Context) error {
+			return p.Send(ctx, map[string]string{"hello": "world"}, kafka.WithKey("my-key"))
+		})
+		g.Expect(e).To(Succeed(), "sending within transaction should not fail")
+		g.Expect(kafkaBinder.producers[testTopic].sent).To(BeEmpty(), "message should not be sent to kafka directly")
+		g.Expect(created).ToNot(BeNil(), "message should be written to outbox")
+		g.Expect(created.ID).To(BeEquivalentTo(1))
+		g.Expect(created.Topic).To(Equal(testTopic))
+		g.Expect(created.Key).To(Equal([]byte("my-key")))
+		g.Expect(created.Payload).To(MatchJSON(`{"hello":"world"}`))
+		g.Expect(created.Headers).To(HaveKeyWithValue(kafka.HeaderContentType, kafka.MIMETypeJson))
+		g.Expect(created.Headers).To(HaveKeyWithValue("X-Intercepted", "true"), "headers added by interceptor should be stored")
+		g.Expect(interceptor.finalized).To(Equal(1), "finalizer should be invoked after message is written")
+	}
+}
+
+func SubTestProducerSendInvalid(di *testDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		interceptor := &mockedInterceptor{}
+		p, e := newOutboxBinder(di.DB, newMockedBinder(), interceptor).Produce(testTopic)
+		g.Expect(e).To(Succeed(), "creating outbox producer should not fail")
+
+		// nil payload
+		e = p.Send(ctx, nil)
+		g.Expect(e).To(HaveOccurred(), "sending nil payload should fail")
+		g.Expect(errors.Is(e, kafka.ErrorSubTypeIllegalProducerUsage)).To(BeTrue(), "error should be correct")
+		e = p.Send(ctx, &kafka.Message{})
+		g.Expect(e).To(HaveOccurred(), "sending message without payload should fail")
+
+		// interceptor error
+		interceptor.err = errors.New("oops")
+		e = p.Send(ctx, map[string]string{"hello": "world"})
+		g.Expect(e).To(HaveOccurred(), "interceptor error should fail sending")
+		g.Expect(errors.Is(e, kafka.ErrorSubTypeProducerGeneral)).To(BeTrue(), "error should be correct")
+		g.Expect(interceptor.finalized).To(BeZero(), "finalizer should not be invoked")
+	}
+}
+
+func SubTestRelayInOrder(di *testDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// capture updates
+		const callbackName = "test:outbox_capture"
+		updated := make([]map[string]interface{}, 0)
+		e := di.DB.Callback().Update().Before("gorm:update").Register(callbackName, func(db *gorm.DB) {
+			switch v := db.Statement.Dest.(type) {
+			case map[string]interface{}:
+				updated = append(updated, v)
+			}
+		})
+		g.Expect(e).To(Succeed(), "registering callback should not fail")
+		defer func() { _ = di.DB.Callback().Update().Remove(callbackName) }()
+
+		kafkaBinder := newMockedBinder()
+		kafkaBinder.failingTopics[testFailedTopic] = errors.New("oops")
+		r := newRelay(di.DB, newOutboxBinder(di.DB, kafkaBinder), nil, NewOutboxProperties().Relay)
+		count, e := r.relay(ctx)
+		g.Expect(e).To(HaveOccurred(), "relay should report failed message")
+		g.Expect(count).To(Equal(2), "messages of other topics should be published")
+
+		sent := kafkaBinder.producers[testTopic].sent
+		g.Expect(sent).To(HaveLen(1), "first message should be sent")
+		g.Expect(sent[0].msg.Payload).To(Equal([]byte(`{"hello":"world"}`)))
+		g.Expect(sent[0].msg.Headers).To(HaveKeyWithValue(kafka.HeaderContentType, kafka.MIMETypeJson))
+		g.Expect(sent[0].msgCtx.Key).To(Equal([]byte("my-key")), "key should be preserved")
+		g.Expect(sent[0].msgCtx.ValueEncoder.MIMEType()).To(Equal(kafka.MIMETypeJson), "content type should be preserved")
+		g.Expect(kafkaBinder.producers[testOtherTopic].sent).To(HaveLen(1), "message of other topic should be sent")
+
+		g.Expect(updated).To(HaveLen(3), "failed message and sent messages should be updated")
+		g.Expect(updated[0]).To(HaveKey("sent_at"), "sent message should be marked")
+		g.Expect(updated[1]).To(HaveKeyWithValue("last_error", "oops"), "failed message should record error")
+		g.Expect(updated[1]).To(HaveKey("attempts"), "failed message should record attempts")
+		g.Expect(updated[1]).ToNot(HaveKey("failed_at"), "failed message should not be parked before max attempts")
+		g.Expect(updated[2]).To(HaveKey("sent_at"), "message of other topic should be marked, "+
+			"later message of the failed topic should wait for next run")
+	}
+}
+
+func SubTestRelayPoisonMessage(di *testDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// capture updates
+		const callbackName = "test:outbox_capture"
+		updated := make([]map[string]interface{}, 0)
+		e := di.DB.Callback().Update().Before("gorm:update").Register(callbackName, func(db *gorm.DB) {
+			switch v := db.Statement.Dest.(type) {
+			case map[string]interface{}:
+				updated = append(updated, v)
+			}
+		})
+		g.Expect(e).To(Succeed(), "registering callback should not fail")
+		defer func() { _ = di.DB.Callback().Update().Remove(callbackName) }()
+
+		// the poison message always fails and already failed twice
+		kafkaBinder := newMockedBinder()
+		kafkaBinder.failingPayloads[`{"poison":true}`] = errors.New("message too large")
+		props := NewOutboxProperties().Relay
+		props.MaxAttempts = 3
+		r := newRelay(di.DB, newOutboxBinder(di.DB, kafkaBinder), nil, props)
+		count, e := r.relay(ctx)
+		g.Expect(e).To(Succeed(), "parked message should not fail the relay")
+		g.Expect(count).To(Equal(1), "message after the parked one should be published")
+
+		sent := kafkaBinder.producers[testTopic].sent
+		g.Expect(sent).To(HaveLen(1), "message after the parked one should be sent")
+		g.Expect(sent[0].msg.Payload).To(Equal([]byte(`{"hello":"world"}`)))
+
+		g.Expect(updated).To(HaveLen(2), "both messages should be updated")
+		g.Expect(updated[0]).To(HaveKeyWithValue("last_error", "message too large"), "parked message should record error")
+		g.Expect(updated[0]).To(HaveKey("failed_at"), "message should be parked after max attempts")
+		g.Expect(updated[1]).To(HaveKey("sent_at"), "sent message should be marked")
+	}
+}
+
+/*************************
+	Mocks
+ *************************/
+
+type sentMessage struct {
+	msg    *kafka.Message
+	msgCtx *kafka.MessageContext
+}
+
+type mockedProducer struct {
+	topic          string
+	err            error
+	failingPayload map[string]error
+	sent           []sentMessage
+	readyCh        chan struct{}
+}
+
+func (p *mockedProducer) Topic() string {
+	return p.topic
+}
+
+func (p *mockedProducer) Send(ctx context.Context, message interface{}, options ...kafka.MessageOptions) error {
+	if p.err != nil {
+		return p.err
+	}
+	payload, _ := message.(*kafka.Message).Payload.([]byte)
+	if e := p.failingPayload[string(payload)]; e != nil {
+		return e
+	}
+	msgCtx := kafka.NewMessageContext(ctx, p.topic, message, options...)
+	p.sent = append(p.sent, sentMessage{msg: message.(*kafka.Message), msgCtx: msgCtx})
+	return nil
+}
+
+func (p *mockedProducer) ReadyCh() <-chan struct{} {
+	return p.readyCh
+}
+
+// mockedInterceptor implements kafka.ProducerMessageInterceptor and kafka.ProducerMessageFinalizer
+type mockedInterceptor struct {
+	headers   kafka.Headers
+	err       error
+	finalized int
+}
+
+func (i *mockedInterceptor) Intercept(msgCtx *kafka.MessageContext) (*kafka.MessageContext, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	for k, v := range i.headers {
+		msgCtx.Message.Headers[k] = v
+	}
+	return msgCtx, nil
+}
+
+func (i *mockedInterceptor) Finalize(msgCtx *kafka.MessageContext, _ int32, _ int64, err error) (*kafka.MessageContext, error) {
+	i.finalized++
+	return msgCtx, err
+}
+
+type mockedBinder struct {
+	producers       map[string]*mockedProducer
+	options         map[string][]kafka.ProducerOptions
+	failingTopics   map[string]error
+	failingPayloads map[string]error
+}
+
+func newMockedBinder() *mockedBinder {
+	return &mockedBinder{
+		producers:       map[string]*mockedProducer{},
+		options:         map[string][]kafka.ProducerOptions{},
+		failingTopics:   map[string]error{},
+		failingPayloads: map[string]error{},
+	}
+}
+
+func (b *mockedBinder) Produce(topic string, options ...kafka.ProducerOptions) (kafka.Producer, error) {
+	b.options[topic] = options
+	readyCh := make(chan struct{})
+	close(readyCh)
+	p := &mockedProducer{topic: topic, err: b.failingTopics[topic], failingPayload: b.failingPayloads, readyCh: readyCh}
+	b.producers[topic] = p
+	return p, nil
+}
+
+func (b *mockedBinder) Subscribe(_ string, _ ...kafka.ConsumerOptions) (kafka.Subscriber, error) {
+	return nil, errors.New("not supported")
+}
+
+func (b *mockedBinder) Consume(_ string, _ string, _ ...kafka.ConsumerOptions) (kafka.GroupConsumer, error) {
+	return nil, errors.New("not supported")
+}
+
+func (b *mockedBinder) ListTopics() []string {
+	topics := make([]string, 0, len(b.producers))
+	for k := range b.producers {
+		topics = append(topics, k)
+	}
+	return topics
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package outbox provides transactional outbox for Kafka producers.
+// Messages sent via outbox producers are written into outbox table within current gorm transaction,
+// and published to Kafka by a relay after the transaction is committed.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/dsync"
+	"github.com/cisco-open/go-lanai/pkg/kafka"
+	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/scheduler"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+const (
+	lockKeyFormat = "service/%s/kafka-outbox"
+)
+
+var logger = log.New("Kafka.Outbox")
+
+var Module = &bootstrap.Module{
+	Name:       "kafka-outbox",
+	Precedence: bootstrap.KafkaPrecedence,
+	Options: []fx.Option{
+		fx.Provide(BindOutboxProperties, provideBinder, provideRelay),
+		fx.Provide(provideShutdownHook),
+		fx.Invoke(initialize),
+	},
+}
+
+// Use Allow service to include this module in main()
+func Use() {
+	kafka.Use()
+	scheduler.Use()
+	bootstrap.Register(Module)
+}
+
+type binderDI struct {
+	fx.In
+	DB           *gorm.DB
+	KafkaBinder  kafka.Binder
+	Interceptors []kafka.ProducerMessageInterceptor `group:"kafka"`
+}
+
+type binderOut struct {
+	fx.Out
+	Binder   Binder
+	Internal *outboxBinder
+}
+
+func provideBinder(di binderDI) binderOut {
+	b := newOutboxBinder(di.DB, di.KafkaBinder, di.Interceptors...)
+	return binderOut{
+		Binder:   b,
+		Internal: b,
+	}
+}
+
+type relayDI struct {
+	fx.In
+	AppCtx      *bootstrap.ApplicationContext
+	Properties  OutboxProperties
+	DB          *gorm.DB
+	Binder      *outboxBinder
+	SyncManager dsync.SyncManager `optional:"true"`
+}
+
+// provideRelay returns nil if relay is disabled
+func provideRelay(di relayDI) (*relay, error) {
+	if !di.Properties.Relay.Enabled {
+		return nil, nil
+	}
+	var lock dsync.Lock
+	if di.SyncManager != nil {
+		var e error
+		key := fmt.Sprintf(lockKeyFormat, di.AppCtx.Name())
+		if lock, e = di.SyncManager.Lock(key); e != nil {
+			return nil, e
+		}
+	} else {
+		logger.WithContext(di.AppCtx).Warnf("Outbox relay lock is not available, messages may be published out of order " +
+			"when multiple instances are running. Provide dsync.SyncManager (e.g. consuldsync.Use()) to guard the relay")
+	}
+	return newRelay(di.DB, di.Binder, lock, di.Properties.Relay), nil
+}
+
+type shutdownHookOut struct {
+	fx.Out
+	Hook bootstrap.ShutdownHook     `group:"bootstrap_shutdown_hook"`
+	Stop bootstrap.ShutdownHookFunc `name:"kafka-outbox/stop"`
+}
+
+// provideShutdownHook stops the relay before kafka binder is shutdown
+func provideShutdownHook(r *relay) shutdownHookOut {
+	if r == nil {
+		return shutdownHookOut{}
+	}
+	hook := bootstrap.NewShutdownHook("kafka outbox relay", bootstrap.ShutdownPhaseMessaging-10, r.Stop)
+	return shutdownHookOut{
+		Hook: hook,
+		Stop: hook.Func,
+	}
+}
+
+type initDI struct {
+	fx.In
+	Relay *relay
+	Stop  bootstrap.ShutdownHookFunc `name:"kafka-outbox/stop"`
+}
+
+// initialize starts the relay. It's stopped during graceful shutdown, see provideShutdownHook.
+// OnStop is a fallback in case graceful shutdown is not executed (e.g. other components failed to start)
+func initialize(lc fx.Lifecycle, di initDI) {
+	if di.Relay == nil {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return di.Relay.Start(ctx)
+		},
+		OnStop: di.Stop,
+	})
+}
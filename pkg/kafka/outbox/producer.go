@@ -0,0 +1,181 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outbox
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/data/tx"
+	"github.com/cisco-open/go-lanai/pkg/kafka"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"gorm.io/gorm"
+	"sync"
+)
+
+// Binder creates kafka.Producer that writes messages into outbox table instead of publishing them to Kafka directly.
+// Messages are written within the current gorm transaction (see tx.GormTxWithContext), and published by the relay
+// after the transaction is committed.
+//
+// Note: the relay publishes messages in the order of their IDs, which are assigned when the messages are written.
+// Messages written by concurrent transactions may be published out of commit order: a message written earlier by a
+// transaction that commits later may be skipped by a relay run and published after messages with larger IDs.
+// Use single writer per topic/key if strict ordering is required.
+type Binder interface {
+	// Produce create an outbox kafka.Producer of given topic. The actual Kafka producer used by the relay is created
+	// using kafka.Binder with given options, if not created already.
+	// Note: message keys are stored as binary. Custom kafka.KeyEncoder must accept []byte.
+	// kafka.ProducerMessageInterceptor are applied when messages are written to outbox, not when they are relayed.
+	Produce(topic string, options ...kafka.ProducerOptions) (kafka.Producer, error)
+}
+
+type outboxBinder struct {
+	db           *gorm.DB
+	binder       kafka.Binder
+	interceptors []kafka.ProducerMessageInterceptor
+	mtx          sync.Mutex
+	producers    map[string]kafka.Producer
+}
+
+func newOutboxBinder(db *gorm.DB, binder kafka.Binder, interceptors ...kafka.ProducerMessageInterceptor) *outboxBinder {
+	interceptors = append([]kafka.ProducerMessageInterceptor{}, interceptors...)
+	order.SortStable(interceptors, order.OrderedFirstCompare)
+	return &outboxBinder{
+		db:           db,
+		binder:       binder,
+		interceptors: interceptors,
+		producers:    map[string]kafka.Producer{},
+	}
+}
+
+func (b *outboxBinder) Produce(topic string, options ...kafka.ProducerOptions) (kafka.Producer, error) {
+	if _, e := b.kafkaProducer(topic, options...); e != nil {
+		return nil, e
+	}
+	return newProducer(b.db, topic, b.interceptors), nil
+}
+
+// kafkaProducer returns the actual Kafka producer of given topic, create one if not exists
+func (b *outboxBinder) kafkaProducer(topic string, options ...kafka.ProducerOptions) (kafka.Producer, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if p, ok := b.producers[topic]; ok {
+		return p, nil
+	}
+	// interceptors are already applied when the message is written to outbox
+	options = append(options[:len(options):len(options)], kafka.SkipInterceptors())
+	p, e := b.binder.Produce(topic, options...)
+	if e != nil {
+		return nil, e
+	}
+	b.producers[topic] = p
+	return p, nil
+}
+
+// producer implements kafka.Producer and writes messages into outbox table.
+// kafka.ProducerMessageInterceptor are applied before the message is written, so headers they add (e.g. tracing)
+// are stored with the message. kafka.ProducerMessageFinalizer are invoked after the message is written,
+// with partition and offset of -1.
+type producer struct {
+	db           *gorm.DB
+	topic        string
+	interceptors []kafka.ProducerMessageInterceptor
+	readyCh      chan struct{}
+}
+
+func newProducer(db *gorm.DB, topic string, interceptors []kafka.ProducerMessageInterceptor) *producer {
+	readyCh := make(chan struct{})
+	close(readyCh)
+	return &producer{
+		db:           db,
+		topic:        topic,
+		interceptors: interceptors,
+		readyCh:      readyCh,
+	}
+}
+
+func (p *producer) Topic() string {
+	return p.topic
+}
+
+// Send encodes the message and writes it into outbox table, using the transaction in given context if available.
+// Supported message types and options are same as kafka.Producer
+func (p *producer) Send(ctx context.Context, message interface{}, options ...kafka.MessageOptions) (err error) {
+	msgCtx := kafka.NewMessageContext(ctx, p.topic, message, options...)
+	msgCtx.Source = p
+	if msgCtx.Message.Payload == nil {
+		return kafka.ErrorSubTypeIllegalProducerUsage.WithMessage("payload is required for outbox messages")
+	}
+
+	// apply Interceptors
+	for _, interceptor := range p.interceptors {
+		if msgCtx, err = interceptor.Intercept(msgCtx); err != nil {
+			return kafka.ErrorSubTypeProducerGeneral.WithMessage("producer interceptor error: %v", err)
+		}
+	}
+	return p.finalizeSend(msgCtx, p.write(msgCtx))
+}
+
+func (p *producer) ReadyCh() <-chan struct{} {
+	return p.readyCh
+}
+
+func (p *producer) write(msgCtx *kafka.MessageContext) error {
+	if msgCtx.ValueEncoder == nil {
+		return kafka.ErrorSubTypeIllegalProducerUsage.WithMessage("value encoder is required for outbox messages")
+	}
+
+	payload, e := msgCtx.ValueEncoder.Encode(msgCtx.Message.Payload)
+	if e != nil {
+		return e
+	}
+	headers := kafka.Headers{}
+	for k, v := range msgCtx.Message.Headers {
+		headers[k] = v
+	}
+	headers[kafka.HeaderContentType] = msgCtx.ValueEncoder.MIMEType()
+
+	var key []byte
+	if msgCtx.Key != nil {
+		if key, e = kafka.NewRawEncoder(kafka.MIMETypeBinary).Encode(msgCtx.Key); e != nil {
+			return e
+		}
+	}
+
+	db := tx.GormTxWithContext(msgCtx.Context)
+	if db == nil {
+		db = p.db.WithContext(msgCtx.Context)
+	}
+	row := &Message{
+		Topic:   p.topic,
+		Key:     key,
+		Headers: headers,
+		Payload: payload,
+	}
+	if e := db.Create(row).Error; e != nil {
+		return kafka.ErrorSubTypeProducerGeneral.WithCause(e, "unable to write message to outbox: %v", e)
+	}
+	return nil
+}
+
+func (p *producer) finalizeSend(msgCtx *kafka.MessageContext, err error) error {
+	for _, interceptor := range p.interceptors {
+		switch finalizer := interceptor.(type) {
+		case kafka.ProducerMessageFinalizer:
+			msgCtx, err = finalizer.Finalize(msgCtx, -1, -1, err)
+		}
+	}
+	return err
+}
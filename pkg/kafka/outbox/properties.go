@@ -0,0 +1,66 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outbox
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const PropertiesPrefix = "kafka.outbox"
+
+type OutboxProperties struct {
+	Relay RelayProperties `json:"relay"`
+}
+
+// RelayProperties configures the relay that publishes pending outbox messages to Kafka.
+// The relay is guarded by a distributed lock if dsync.SyncManager is available (e.g. consuldsync.Use() or redisdsync.Use()),
+// so only one instance publishes at a time and order of messages is preserved.
+type RelayProperties struct {
+	Enabled bool `json:"enabled"`
+	// Interval is the delay between two relay runs
+	Interval utils.Duration `json:"interval"`
+	// BatchSize is the max number of messages published in each run
+	BatchSize int `json:"batch-size"`
+	// Retention is how long sent messages are kept in the outbox table. Sent messages are kept forever if not positive
+	Retention utils.Duration `json:"retention"`
+	// MaxAttempts is the max number of failed attempts to publish a message, before the message is parked.
+	// Messages are never parked if not positive
+	MaxAttempts int `json:"max-attempts"`
+}
+
+func NewOutboxProperties() *OutboxProperties {
+	return &OutboxProperties{
+		Relay: RelayProperties{
+			Enabled:     true,
+			Interval:    utils.Duration(time.Second),
+			BatchSize:   100,
+			Retention:   utils.Duration(7 * 24 * time.Hour),
+			MaxAttempts: 10,
+		},
+	}
+}
+
+func BindOutboxProperties(ctx *bootstrap.ApplicationContext) OutboxProperties {
+	props := NewOutboxProperties()
+	if err := ctx.Config().Bind(props, PropertiesPrefix); err != nil {
+		panic(errors.Wrap(err, "failed to bind OutboxProperties"))
+	}
+	return *props
+}
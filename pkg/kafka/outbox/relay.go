@@ -0,0 +1,178 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outbox
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/dsync"
+	"github.com/cisco-open/go-lanai/pkg/kafka"
+	"github.com/cisco-open/go-lanai/pkg/scheduler"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	relayTaskName = "kafka-outbox-relay"
+)
+
+// relay periodically publishes pending outbox messages in order of their IDs, and marks them sent.
+// When a message fails to publish, later messages of the same topic wait for the next run, so that messages of
+// a topic are not published out of order. Messages failed RelayProperties.MaxAttempts times are parked.
+type relay struct {
+	db        *gorm.DB
+	binder    *outboxBinder
+	lock      dsync.Lock
+	props     RelayProperties
+	canceller scheduler.TaskCanceller
+}
+
+func newRelay(db *gorm.DB, binder *outboxBinder, lock dsync.Lock, props RelayProperties) *relay {
+	return &relay{
+		db:     db,
+		binder: binder,
+		lock:   lock,
+		props:  props,
+	}
+}
+
+func (r *relay) Start(_ context.Context) (err error) {
+	r.canceller, err = scheduler.Repeat(r.run, scheduler.Name(relayTaskName), scheduler.WithDelay(time.Duration(r.props.Interval)))
+	return
+}
+
+func (r *relay) Stop(_ context.Context) error {
+	if r.canceller != nil {
+		r.canceller.Cancel()
+	}
+	if r.lock != nil {
+		return r.lock.Release()
+	}
+	return nil
+}
+
+func (r *relay) run(ctx context.Context) error {
+	// only lock holder relays messages. Note: once TryLock is invoked, the lock keeps trying in the background
+	if r.lock != nil {
+		if e := r.lock.TryLock(ctx); e != nil {
+			return nil
+		}
+	}
+	count, e := r.relay(ctx)
+	if e != nil {
+		logger.WithContext(ctx).Warnf("Outbox relay published %d messages, some topics are delayed to next run: %v", count, e)
+	} else if count != 0 {
+		logger.WithContext(ctx).Debugf("Outbox relay published %d messages", count)
+	}
+	if e := r.cleanup(ctx); e != nil {
+		logger.WithContext(ctx).Warnf("Unable to cleanup sent outbox messages: %v", e)
+	}
+	return nil
+}
+
+// relay publishes one batch of pending messages and returns the number of published messages.
+// When a message fails to publish, later messages of the same topic are skipped in this run to preserve their order,
+// while messages of other topics are still published. The first failure is returned.
+func (r *relay) relay(ctx context.Context) (count int, err error) {
+	var msgs []*Message
+	if e := r.db.WithContext(ctx).
+		Where("sent_at IS NULL AND failed_at IS NULL").
+		Order("id").Limit(r.props.BatchSize).
+		Find(&msgs).Error; e != nil {
+		return 0, e
+	}
+	blocked := map[string]struct{}{}
+	for _, msg := range msgs {
+		if _, ok := blocked[msg.Topic]; ok {
+			continue
+		}
+		published, e := r.publish(ctx, msg)
+		switch {
+		case e != nil:
+			blocked[msg.Topic] = struct{}{}
+			if err == nil {
+				err = e
+			}
+		case published:
+			count++
+		}
+	}
+	return
+}
+
+// publish sends given message and marks it sent. It returns false without error if the message is parked.
+func (r *relay) publish(ctx context.Context, msg *Message) (bool, error) {
+	p, e := r.binder.kafkaProducer(msg.Topic)
+	if e != nil {
+		return false, r.recordFailure(ctx, msg, e)
+	}
+	select {
+	case <-p.ReadyCh():
+	default:
+		// try again in next run
+		return false, kafka.ErrorSubTypeIllegalProducerUsage.WithMessage(`producer for topic "%s" is not ready`, msg.Topic)
+	}
+
+	if e := p.Send(ctx, &kafka.Message{Headers: msg.Headers, Payload: msg.Payload}, r.messageOptions(msg)...); e != nil {
+		return false, r.recordFailure(ctx, msg, e)
+	}
+	if e := r.db.WithContext(ctx).Model(msg).UpdateColumn("sent_at", time.Now().UTC()).Error; e != nil {
+		// the message might be published again in next run
+		return false, e
+	}
+	return true, nil
+}
+
+// recordFailure increments attempts of the failed message and returns the given error.
+// Once the attempts reach RelayProperties.MaxAttempts, the message is parked by setting "failed_at" and nil is returned,
+// so it no longer blocks later messages of the same topic.
+func (r *relay) recordFailure(ctx context.Context, msg *Message, err error) error {
+	parked := r.props.MaxAttempts > 0 && msg.Attempts+1 >= r.props.MaxAttempts
+	columns := map[string]interface{}{
+		"attempts":   gorm.Expr(`"attempts" + 1`),
+		"last_error": err.Error(),
+	}
+	if parked {
+		columns["failed_at"] = time.Now().UTC()
+	}
+	if e := r.db.WithContext(ctx).Model(msg).UpdateColumns(columns).Error; e != nil {
+		logger.WithContext(ctx).Warnf("Unable to update outbox message [%d]: %v", msg.ID, e)
+		return err
+	}
+	if !parked {
+		return err
+	}
+	logger.WithContext(ctx).Errorf("Outbox message [%d] to topic [%s] is parked after %d failed attempts: %v",
+		msg.ID, msg.Topic, msg.Attempts+1, err)
+	return nil
+}
+
+func (r *relay) messageOptions(msg *Message) []kafka.MessageOptions {
+	opts := []kafka.MessageOptions{kafka.WithEncoder(kafka.NewRawEncoder(msg.Headers[kafka.HeaderContentType]))}
+	if len(msg.Key) != 0 {
+		opts = append(opts, kafka.WithKey(msg.Key))
+	}
+	return opts
+}
+
+// cleanup removes sent messages that are older than retention
+func (r *relay) cleanup(ctx context.Context) error {
+	if r.props.Retention <= 0 {
+		return nil
+	}
+	before := time.Now().UTC().Add(-time.Duration(r.props.Retention))
+	return r.db.WithContext(ctx).Where("sent_at < ?", before).Delete(&Message{}).Error
+}
@@ -31,8 +31,10 @@ var logger = log.New("Kafka")
 var Module = &bootstrap.Module{
 	Precedence: bootstrap.KafkaPrecedence,
 	Options: []fx.Option{
-		fx.Provide(BindKafkaProperties, ProvideKafkaBinder),
+		fx.Provide(BindKafkaProperties, ProvideSchemaRegistry, ProvideKafkaBinder),
 		fx.Provide(tracingProvider()),
+		fx.Provide(metricsProvider()),
+		fx.Provide(provideShutdownHook),
 		fx.Invoke(initialize),
 	},
 }
@@ -54,6 +56,7 @@ type binderDI struct {
 	ConsumerInterceptors []ConsumerDispatchInterceptor `group:"kafka"`
 	HandlerInterceptors  []ConsumerHandlerInterceptor  `group:"kafka"`
 	TLSCertsManager      certs.Manager                 `optional:"true"`
+	SchemaRegistry       SchemaRegistry                `optional:"true"`
 }
 
 func ProvideKafkaBinder(di binderDI) Binder {
@@ -65,29 +68,39 @@ func ProvideKafkaBinder(di binderDI) Binder {
 			ConsumerInterceptors: append(opt.ConsumerInterceptors, filterZeroValues(di.ConsumerInterceptors)...),
 			HandlerInterceptors:  append(opt.HandlerInterceptors, filterZeroValues(di.HandlerInterceptors)...),
 			TLSCertsManager:      di.TLSCertsManager,
+			SchemaRegistry:       di.SchemaRegistry,
 		}
 	})
 }
 
+// ProvideSchemaRegistry provides SchemaRegistry client when "kafka.schema-registry.url" is set, otherwise nil.
+// Applications or tests may replace it using fx.Decorate (e.g. with NewInMemorySchemaRegistry)
+func ProvideSchemaRegistry(props KafkaProperties) SchemaRegistry {
+	if len(props.SchemaRegistry.URL) == 0 {
+		return nil
+	}
+	return NewSchemaRegistryClient(WithSchemaRegistryProperties(&props.SchemaRegistry))
+}
+
 type initDI struct {
 	fx.In
 	AppCtx          *bootstrap.ApplicationContext
 	Lifecycle       fx.Lifecycle
 	Properties      KafkaProperties
 	Binder          Binder
-	HealthRegistrar health.Registrar `optional:"true"`
+	HealthRegistrar health.Registrar           `optional:"true"`
+	Shutdown        bootstrap.ShutdownHookFunc `name:"kafka/shutdown"`
 }
 
 func initialize(di initDI) {
-	// register lifecycle functions
+	// register lifecycle functions. Binder is shutdown during graceful shutdown, see provideShutdownHook.
+	// OnStop is a fallback in case graceful shutdown is not executed (e.g. other components failed to start)
 	di.Lifecycle.Append(fx.Hook{
 		OnStart: func(_ context.Context) error {
 			//nolint:contextcheck // intentional, given context is cancelled after bootstrap, AppCtx is cancelled when app close
 			return di.Binder.(BinderLifecycle).Start(di.AppCtx)
 		},
-		OnStop: func(ctx context.Context) error {
-			return di.Binder.(BinderLifecycle).Shutdown(ctx)
-		},
+		OnStop: di.Shutdown,
 	})
 
 	// register health endpoints if applicable
@@ -98,6 +111,22 @@ func initialize(di initDI) {
 	di.HealthRegistrar.MustRegister(NewHealthIndicator(di.Binder))
 }
 
+// provideShutdownHook stops consumers and producers after web server is drained,
+// so messages produced by in-flight requests are still delivered
+func provideShutdownHook(binder Binder) shutdownHookOut {
+	hook := bootstrap.NewShutdownHook("kafka binder", bootstrap.ShutdownPhaseMessaging, binder.(BinderLifecycle).Shutdown)
+	return shutdownHookOut{
+		Hook:     hook,
+		Shutdown: hook.Func,
+	}
+}
+
+type shutdownHookOut struct {
+	fx.Out
+	Hook     bootstrap.ShutdownHook     `group:"bootstrap_shutdown_hook"`
+	Shutdown bootstrap.ShutdownHookFunc `name:"kafka/shutdown"`
+}
+
 func filterZeroValues[T any](values []T) []T {
 	filtered := make([]T, 0, len(values))
 	for i := range values {
@@ -155,27 +155,9 @@ func (p *saramaProducer) Closed() bool {
 }
 
 func (p *saramaProducer) prepare(ctx context.Context, v interface{}) *MessageContext {
-	msgCtx := MessageContext{
-		Context:       ctx,
-		Topic:         p.topic,
-		messageConfig: defaultMessageConfig(),
-		Source:        p,
-	}
-	switch m := v.(type) {
-	case *Message:
-		msgCtx.Message = *m
-	case Message:
-		msgCtx.Message = m
-	default:
-		msgCtx.Message = Message{
-			Headers: Headers{},
-			Payload: v,
-		}
-	}
-	if msgCtx.Message.Headers == nil {
-		msgCtx.Message.Headers = Headers{}
-	}
-	return &msgCtx
+	msgCtx := NewMessageContext(ctx, p.topic, v)
+	msgCtx.Source = p
+	return msgCtx
 }
 
 func (p *saramaProducer) finalizeSend(msgCtx *MessageContext, partition int32, offset int64, err error) error {
@@ -42,6 +42,21 @@ import (
 type TestProducerDI struct {
 	fx.In
 	TestBinderDI
+	Interceptor *CountingInterceptor
+}
+
+type countingInterceptorOut struct {
+	fx.Out
+	Concrete  *CountingInterceptor
+	Interface kafka.ProducerMessageInterceptor `group:"kafka"`
+}
+
+func ProvideCountingInterceptor() countingInterceptorOut {
+	interceptor := &CountingInterceptor{}
+	return countingInterceptorOut{
+		Concrete:  interceptor,
+		Interface: interceptor,
+	}
 }
 
 func TestProducer(t *testing.T) {
@@ -51,9 +66,11 @@ func TestProducer(t *testing.T) {
 		testdata.WithMockedBroker(),
 		apptest.WithModules(kafka.Module),
 		apptest.WithDI(&di),
+		apptest.WithFxOptions(fx.Provide(ProvideCountingInterceptor)),
 		test.GomegaSubTest(SubTestSendWithLocalAck(&di), "TestSendWithLocalAck"),
 		test.GomegaSubTest(SubTestSendWithoutAck(&di), "TestSendWithoutAck"),
 		test.GomegaSubTest(SubTestSendWithAllAck(&di), "TestSendWithAllAck"),
+		test.GomegaSubTest(SubTestSendSkipInterceptors(&di), "TestSendSkipInterceptors"),
 	)
 }
 
@@ -134,6 +151,24 @@ func SubTestSendWithAllAck(di *TestProducerDI) test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestSendSkipInterceptors(di *TestProducerDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const topic = `test.producer-skip-interceptors`
+		var e error
+		producer := TryBindTestProducer(ctx, t, g, &di.TestBinderDI, topic, kafka.SkipInterceptors())
+		g.Expect(producer.Topic()).To(Equal(topic), "producer's topic should be correct")
+
+		// send some messages
+		testdata.MockProduce(ctx, topic, false)
+		encoder := &TestEncoder{}
+		di.Interceptor.Reset()
+		e = producer.Send(ctx, map[string]interface{}{"test-body-int-field": 1}, kafka.WithEncoder(encoder))
+		g.Expect(e).To(Succeed(), "producer Send(msg) should not fail")
+		g.Expect(di.Interceptor.Count).To(Equal(0), "interceptor should not be applied")
+		g.Expect(encoder.EncodeCount).To(Equal(1), "encoder.Encode should still be called")
+	}
+}
+
 /*************************
 	Helpers
  *************************/
@@ -176,3 +211,16 @@ func (enc *TestEncoder) Encode(v interface{}) ([]byte, error) {
 	return json.Marshal(v)
 }
 
+
+type CountingInterceptor struct {
+	Count int
+}
+
+func (i *CountingInterceptor) Reset() {
+	i.Count = 0
+}
+
+func (i *CountingInterceptor) Intercept(msgCtx *kafka.MessageContext) (*kafka.MessageContext, error) {
+	i.Count++
+	return msgCtx, nil
+}
@@ -39,6 +39,8 @@ type KafkaProperties struct {
 	Metadata Metadata                  `json:"metadata"`
 	Binder   BinderProperties          `json:"binder"`
 	ClientId string                    `json:"client-id"`
+	// SchemaRegistry is the Confluent compatible schema registry used by Avro and Protobuf encoders
+	SchemaRegistry SchemaRegistryProperties `json:"schema-registry"`
 }
 
 type Net struct {
@@ -68,6 +70,14 @@ type TLS struct {
 	Certs  certs.SourceProperties `json:"certs"`
 }
 
+type SchemaRegistryProperties struct {
+	// URL of the schema registry. Schema registry client is not created when URL is empty
+	URL      string         `json:"url"`
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	Timeout  utils.Duration `json:"timeout"`
+}
+
 type BinderProperties struct {
 	InitialHeartbeat       utils.Duration `json:"init-heartbeat"`
 	HeartbeatCurveFactor   float64        `json:"heartbeat-curve-factor"`
@@ -112,9 +122,11 @@ type ProducerProperties struct {
 }
 
 type ConsumerProperties struct {
-	LogLevel *log.LoggingLevel       `json:"log-level"`
-	Backoff  *utils.Duration         `json:"backoff-interval"`
-	Group    ConsumerGroupProperties `json:"group"`
+	LogLevel   *log.LoggingLevel       `json:"log-level"`
+	Backoff    *utils.Duration         `json:"backoff-interval"`
+	Group      ConsumerGroupProperties `json:"group"`
+	Retry      RetryProperties         `json:"retry"`
+	DeadLetter DeadLetterProperties    `json:"dead-letter"`
 }
 
 type ProvisioningProperties struct {
@@ -141,6 +153,27 @@ type ConsumerGroupProperties struct {
 	Backoff     *utils.Duration `json:"backoff-interval"`
 }
 
+// RetryProperties configures retry topics of group consumers.
+// When enabled, failed messages are forwarded to a retry topic of each tier in turn, and processed again after the tier's delay.
+// Retry topics are named as "<topic>.<group>.retry-<tier>", where tier starts from 1
+type RetryProperties struct {
+	// Enabled whether to use retry topics. Default retry delays are used if Delays is not set
+	Enabled *bool `json:"enabled"`
+
+	// Delays of each retry tier, e.g. [1s, 10s, 1m]
+	Delays []utils.Duration `json:"delays"`
+}
+
+// DeadLetterProperties configures dead-letter topic of group consumers.
+// When enabled, messages are forwarded to the dead-letter topic when all retries are exhausted or the error is not retryable
+type DeadLetterProperties struct {
+	// Enabled whether to use dead-letter topic
+	Enabled *bool `json:"enabled"`
+
+	// Topic name of dead-letter topic. Default is "<topic>.<group>.dlq"
+	Topic *string `json:"topic"`
+}
+
 func BindKafkaProperties(ctx *bootstrap.ApplicationContext) KafkaProperties {
 	props := KafkaProperties{
 		Net: Net{
@@ -0,0 +1,324 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"strconv"
+	"time"
+)
+
+// Headers added to messages forwarded to retry topics or dead-letter topic.
+// Original headers of the failed message are preserved.
+const (
+	// HeaderRetryAttempt number of failed attempts of the message so far
+	HeaderRetryAttempt = "x-retry-attempt"
+	// HeaderRetryNotBefore unix time in milliseconds, before which the retried message should not be processed
+	HeaderRetryNotBefore = "x-retry-not-before"
+	// HeaderOriginalTopic topic where the message was originally consumed
+	HeaderOriginalTopic = "x-original-topic"
+	// HeaderOriginalPartition partition where the message was originally consumed
+	HeaderOriginalPartition = "x-original-partition"
+	// HeaderOriginalOffset offset of the message when it was originally consumed
+	HeaderOriginalOffset = "x-original-offset"
+	// HeaderErrorMessage error message of the last failed attempt
+	HeaderErrorMessage = "x-error-message"
+)
+
+var defaultRetryDelays = []time.Duration{time.Second, 10 * time.Second, time.Minute}
+
+// defaultRetryMaxWait is used when neither consumer group's rebalance timeout nor session timeout is set
+const defaultRetryMaxWait = 10 * time.Second
+
+// RetryTopicName returns the name of retry topic of given tier (starting from 1) for given topic and consumer group
+func RetryTopicName(topic, group string, tier int) string {
+	return fmt.Sprintf("%s.%s.retry-%d", topic, group, tier)
+}
+
+// DeadLetterTopicName returns the default name of dead-letter topic for given topic and consumer group
+func DeadLetterTopicName(topic, group string) string {
+	return fmt.Sprintf("%s.%s.dlq", topic, group)
+}
+
+type retryConfig struct {
+	// delays of each retry tier. Retry topics are disabled if empty
+	delays []time.Duration
+	// deadLetter whether to forward messages to dead-letter topic when retries are exhausted
+	deadLetter bool
+	// deadLetterTopic name of the dead-letter topic. DeadLetterTopicName is used if empty
+	deadLetterTopic string
+	// maxWait max duration a message from retry topic is held before it's re-queued. It should be well below
+	// consumer group's rebalance and session timeout. defaultRetryMaxWait is used if not set
+	maxWait time.Duration
+}
+
+func (c retryConfig) enabled() bool {
+	return len(c.delays) != 0 || c.deadLetter
+}
+
+/*************************
+	Retry Finalizer
+ *************************/
+
+// retryDispatchFinalizer implements ConsumerDispatchInterceptor and ConsumerDispatchFinalizer.
+// When message handling failed, the message is forwarded to the retry topic of next tier, or to the dead-letter topic
+// if all retry tiers are exhausted or the error is not retryable.
+// The same dispatcher consumes retry topics, and messages from retry topics are held by the consumer's claim loop
+// until HeaderRetryNotBefore. To avoid holding a message across consumer group rebalance, messages are held for
+// at most maxWait, and those still not due are re-queued to the same retry topic.
+type retryDispatchFinalizer struct {
+	topic           string
+	delays          []time.Duration
+	maxWait         time.Duration
+	retryTopics     []string
+	deadLetterTopic string
+	producers       map[string]Producer
+}
+
+func newRetryDispatchFinalizer(topic, group string, cfg *retryConfig, producers map[string]Producer) *retryDispatchFinalizer {
+	f := &retryDispatchFinalizer{
+		topic:       topic,
+		delays:      cfg.delays,
+		maxWait:     cfg.maxWait,
+		retryTopics: retryTopics(topic, group, cfg),
+		producers:   producers,
+	}
+	if f.maxWait <= 0 {
+		f.maxWait = defaultRetryMaxWait
+	}
+	if cfg.deadLetter {
+		f.deadLetterTopic = deadLetterTopic(topic, group, cfg)
+	}
+	return f
+}
+
+func (f *retryDispatchFinalizer) Order() int {
+	// we want the retry finalizer to be the last one, so other finalizers (e.g. tracing) can observe the original error
+	return order.Lowest
+}
+
+// Intercept implements ConsumerDispatchInterceptor. Messages from retry topics that are still not due are re-queued
+// to the same retry topic and not dispatched. See hold
+func (f *retryDispatchFinalizer) Intercept(msgCtx *MessageContext) (*MessageContext, error) {
+	tier := f.tierOf(msgCtx.Topic)
+	if tier < 0 {
+		return msgCtx, nil
+	}
+	if wait, ok := retryWait(msgCtx.Message.Headers[HeaderRetryNotBefore]); !ok || wait <= 0 {
+		return msgCtx, nil
+	}
+	if e := f.requeue(msgCtx, f.retryTopics[tier]); e != nil {
+		return msgCtx, e
+	}
+	return msgCtx, errSkipDispatch
+}
+
+// hold blocks until given message from retry topic is due, or for at most maxWait.
+// It's invoked by the consumer's claim loop before the message is dispatched, so following messages of the same
+// partition are not consumed while the message is held. Error is returned if given context is done before then,
+// in which case the message should not be dispatched nor marked.
+func (f *retryDispatchFinalizer) hold(ctx context.Context, raw *sarama.ConsumerMessage) error {
+	if f.tierOf(raw.Topic) < 0 {
+		return nil
+	}
+	var notBefore string
+	for _, rh := range raw.Headers {
+		if rh != nil && string(rh.Key) == HeaderRetryNotBefore {
+			notBefore = string(rh.Value)
+		}
+	}
+	wait, ok := retryWait(notBefore)
+	if !ok || wait <= 0 {
+		return nil
+	}
+	if wait > f.maxWait {
+		wait = f.maxWait
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Finalize implements ConsumerDispatchFinalizer. Failed messages are forwarded to retry or dead-letter topic
+func (f *retryDispatchFinalizer) Finalize(msgCtx *MessageContext, err error) (*MessageContext, error) {
+	if err == nil {
+		return msgCtx, nil
+	}
+	raw, ok := msgCtx.RawMessage.(*sarama.ConsumerMessage)
+	if !ok {
+		return msgCtx, err
+	}
+
+	attempt := f.attemptOf(msgCtx)
+	var target string
+	var delay time.Duration
+	switch {
+	case attempt <= len(f.retryTopics) && isRetryableError(err):
+		target = f.retryTopics[attempt-1]
+		delay = f.delays[attempt-1]
+	case f.deadLetterTopic != "":
+		target = f.deadLetterTopic
+	default:
+		return msgCtx, err
+	}
+
+	producer, ok := f.producers[target]
+	if !ok {
+		return msgCtx, ErrorSubTypeConsumerGeneral.WithMessage("producer of topic [%s] is not available: %v", target, err)
+	}
+	msg, opts := f.failedMessage(raw, attempt, delay, err)
+	if e := producer.Send(msgCtx.Context, msg, opts...); e != nil {
+		return msgCtx, ErrorSubTypeConsumerGeneral.WithCause(e, "unable to forward failed message to topic [%s]: %v. Original error: %v", target, e, err)
+	}
+	logger.WithContext(msgCtx.Context).Infof("Failed message [%s:%d:%d] is forwarded to [%s] after %d attempts: %v",
+		raw.Topic, raw.Partition, raw.Offset, target, attempt, err)
+	return msgCtx, nil
+}
+
+// requeue sends the message to given retry topic as-is, so it's consumed again with same attempt and HeaderRetryNotBefore
+func (f *retryDispatchFinalizer) requeue(msgCtx *MessageContext, target string) error {
+	raw, ok := msgCtx.RawMessage.(*sarama.ConsumerMessage)
+	if !ok {
+		return ErrorSubTypeConsumerGeneral.WithMessage("unable to re-queue message of type [%T]", msgCtx.RawMessage)
+	}
+	producer, ok := f.producers[target]
+	if !ok {
+		return ErrorSubTypeConsumerGeneral.WithMessage("producer of topic [%s] is not available", target)
+	}
+	msg, opts := rawMessage(raw)
+	if e := producer.Send(msgCtx.Context, msg, opts...); e != nil {
+		return ErrorSubTypeConsumerGeneral.WithCause(e, "unable to re-queue message to topic [%s]: %v", target, e)
+	}
+	logger.WithContext(msgCtx.Context).Debugf("Retried message [%s:%d:%d] is not due yet and re-queued",
+		raw.Topic, raw.Partition, raw.Offset)
+	return nil
+}
+
+// tierOf returns index of retry topic, or -1 if given topic is not a retry topic
+func (f *retryDispatchFinalizer) tierOf(topic string) int {
+	for i := range f.retryTopics {
+		if f.retryTopics[i] == topic {
+			return i
+		}
+	}
+	return -1
+}
+
+// attemptOf returns number of failed attempts of the message, including current one
+func (f *retryDispatchFinalizer) attemptOf(msgCtx *MessageContext) int {
+	if msgCtx.Topic == f.topic {
+		return 1
+	}
+	if tier := f.tierOf(msgCtx.Topic); tier >= 0 {
+		return tier + 2
+	}
+	attempt, _ := strconv.Atoi(msgCtx.Message.Headers[HeaderRetryAttempt])
+	return attempt + 1
+}
+
+func (f *retryDispatchFinalizer) failedMessage(raw *sarama.ConsumerMessage, attempt int, delay time.Duration, err error) (*Message, []MessageOptions) {
+	msg, opts := rawMessage(raw)
+	headers := msg.Headers
+	if _, ok := headers[HeaderOriginalTopic]; !ok {
+		headers[HeaderOriginalTopic] = raw.Topic
+		headers[HeaderOriginalPartition] = strconv.Itoa(int(raw.Partition))
+		headers[HeaderOriginalOffset] = strconv.FormatInt(raw.Offset, 10)
+	}
+	headers[HeaderRetryAttempt] = strconv.Itoa(attempt)
+	headers[HeaderErrorMessage] = err.Error()
+	if delay > 0 {
+		headers[HeaderRetryNotBefore] = strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)
+	} else {
+		delete(headers, HeaderRetryNotBefore)
+	}
+	return msg, opts
+}
+
+// isRetryableError returns false if retrying given error would never succeed
+func isRetryableError(err error) bool {
+	return !errors.Is(err, ErrorSubTypeDecoding) && !errors.Is(err, ErrorSubTypeIllegalConsumerUsage)
+}
+
+/*************************
+	Helpers
+ *************************/
+
+// rawMessage converts consumed message to a Message with same headers, key and payload, to be sent to another topic
+func rawMessage(raw *sarama.ConsumerMessage) (*Message, []MessageOptions) {
+	headers := Headers{}
+	for _, rh := range raw.Headers {
+		if rh == nil {
+			continue
+		}
+		headers[string(rh.Key)] = string(rh.Value)
+	}
+	payload := raw.Value
+	if payload == nil {
+		payload = []byte{}
+	}
+	opts := []MessageOptions{WithEncoder(NewRawEncoder(headers[HeaderContentType]))}
+	if len(raw.Key) != 0 {
+		opts = append(opts, WithKey(raw.Key))
+	}
+	return &Message{Headers: headers, Payload: payload}, opts
+}
+
+// retryWait parses value of HeaderRetryNotBefore and returns the remaining duration until the message is due
+func retryWait(notBefore string) (time.Duration, bool) {
+	millis, e := strconv.ParseInt(notBefore, 10, 64)
+	if e != nil {
+		return 0, false
+	}
+	return time.Until(time.UnixMilli(millis)), true
+}
+
+// retryMaxWait returns the max duration a message from retry topic can be held, based on consumer group settings.
+// Holding a message blocks its partition, so the duration is kept well below both rebalance timeout and session timeout.
+// Zero is returned if neither is set
+func retryMaxWait(cfg *sarama.Config) (wait time.Duration) {
+	if rebalance := cfg.Consumer.Group.Rebalance.Timeout / 4; rebalance > 0 {
+		wait = rebalance
+	}
+	if session := cfg.Consumer.Group.Session.Timeout / 2; session > 0 && (wait <= 0 || session < wait) {
+		wait = session
+	}
+	return
+}
+
+func retryTopics(topic, group string, cfg *retryConfig) []string {
+	topics := make([]string, len(cfg.delays))
+	for i := range cfg.delays {
+		topics[i] = RetryTopicName(topic, group, i+1)
+	}
+	return topics
+}
+
+func deadLetterTopic(topic, group string, cfg *retryConfig) string {
+	if cfg.deadLetterTopic != "" {
+		return cfg.deadLetterTopic
+	}
+	return DeadLetterTopicName(topic, group)
+}
@@ -0,0 +1,429 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	retryTestTopic = `test-retry`
+	retryTestGroup = `test.group`
+)
+
+/*************************
+	Test Cases
+ *************************/
+
+func TestRetryDispatchFinalizer(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestRetryTiers(), "RetryTiers"),
+		test.GomegaSubTest(SubTestRetryNonRetryableError(), "NonRetryableError"),
+		test.GomegaSubTest(SubTestRetryWithoutDeadLetter(), "WithoutDeadLetter"),
+		test.GomegaSubTest(SubTestRetrySuccess(), "Success"),
+		test.GomegaSubTest(SubTestRetryDelay(), "Delay"),
+		test.GomegaSubTest(SubTestRetryRequeue(), "Requeue"),
+		test.GomegaSubTest(SubTestRetrySendFailure(), "SendFailure"),
+		test.GomegaSubTest(SubTestRetryMaxWait(), "MaxWait"),
+	)
+}
+
+func TestRetryProperties(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestRetryPropertiesDefaults(), "Defaults"),
+		test.GomegaSubTest(SubTestRetryPropertiesCustomized(), "Customized"),
+		test.GomegaSubTest(SubTestRetryPropertiesDisabled(), "Disabled"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestRetryTiers() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		f, producers := newTestRetryFinalizer(true, time.Millisecond, 2*time.Millisecond)
+		d := newTestRetryDispatcher(f, fmt.Errorf("oops"))
+
+		// main topic -> retry-1
+		raw := newTestConsumerMessage(retryTestTopic, Headers{HeaderContentType: MIMETypeJson, "X-Custom": "value", "X-Empty": ""})
+		e := d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(Succeed(), "failed message should be handled by finalizer")
+		retry1 := RetryTopicName(retryTestTopic, retryTestGroup, 1)
+		sent := producers[retry1].AssertSent(g, 1)
+		AssertForwardedMessage(g, sent, raw, 1, true)
+		g.Expect(sent.Message.Headers).To(HaveKeyWithValue("X-Empty", ""), "forwarded message should keep headers with empty value")
+		g.Expect(sent.Config.ValueEncoder.MIMEType()).To(Equal(MIMETypeJson), "forwarded message should keep content type")
+		g.Expect(sent.Config.Key).To(Equal(raw.Key), "forwarded message should keep key")
+
+		// retry-1 -> retry-2
+		raw = newTestConsumerMessage(retry1, sent.Message.Headers)
+		g.Expect(f.hold(ctx, raw)).To(Succeed(), "hold should succeed")
+		e = d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(Succeed(), "failed message should be handled by finalizer")
+		retry2 := RetryTopicName(retryTestTopic, retryTestGroup, 2)
+		sent = producers[retry2].AssertSent(g, 1)
+		AssertForwardedMessage(g, sent, raw, 2, true)
+		g.Expect(sent.Message.Headers).To(HaveKeyWithValue(HeaderOriginalTopic, retryTestTopic), "original topic should be preserved")
+
+		// retry-2 -> DLQ
+		raw = newTestConsumerMessage(retry2, sent.Message.Headers)
+		g.Expect(f.hold(ctx, raw)).To(Succeed(), "hold should succeed")
+		e = d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(Succeed(), "failed message should be handled by finalizer")
+		sent = producers[DeadLetterTopicName(retryTestTopic, retryTestGroup)].AssertSent(g, 1)
+		AssertForwardedMessage(g, sent, raw, 3, false)
+	}
+}
+
+func SubTestRetryNonRetryableError() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		f, producers := newTestRetryFinalizer(true, time.Millisecond)
+		d := newTestRetryDispatcher(f, nil)
+
+		raw := newTestConsumerMessage(retryTestTopic, Headers{HeaderContentType: "application/unknown"})
+		e := d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(Succeed(), "failed message should be handled by finalizer")
+		producers[RetryTopicName(retryTestTopic, retryTestGroup, 1)].AssertSent(g, 0)
+		sent := producers[DeadLetterTopicName(retryTestTopic, retryTestGroup)].AssertSent(g, 1)
+		AssertForwardedMessage(g, sent, raw, 1, false)
+	}
+}
+
+func SubTestRetryWithoutDeadLetter() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		f, producers := newTestRetryFinalizer(false, time.Millisecond)
+		d := newTestRetryDispatcher(f, fmt.Errorf("oops"))
+
+		retry1 := RetryTopicName(retryTestTopic, retryTestGroup, 1)
+		raw := newTestConsumerMessage(retry1, Headers{HeaderContentType: MIMETypeJson})
+		e := d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(HaveOccurred(), "error should be returned when retries are exhausted without dead-letter topic")
+		producers[retry1].AssertSent(g, 0)
+	}
+}
+
+func SubTestRetrySuccess() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		f, producers := newTestRetryFinalizer(true, time.Millisecond)
+		d := newTestRetryDispatcher(f, nil)
+
+		raw := newTestConsumerMessage(retryTestTopic, Headers{HeaderContentType: MIMETypeJson})
+		e := d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(Succeed(), "dispatch should succeed")
+		for _, p := range producers {
+			p.AssertSent(g, 0)
+		}
+	}
+}
+
+func SubTestRetryDelay() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const delay = 200 * time.Millisecond
+		f, producers := newTestRetryFinalizer(true, delay)
+		d := newTestRetryDispatcher(f, nil)
+
+		retry1 := RetryTopicName(retryTestTopic, retryTestGroup, 1)
+		notBefore := time.Now().Add(delay)
+		raw := newTestConsumerMessage(retry1, Headers{
+			HeaderContentType:    MIMETypeJson,
+			HeaderRetryNotBefore: strconv.FormatInt(notBefore.UnixMilli(), 10),
+		})
+		e := f.hold(ctx, raw)
+		g.Expect(e).To(Succeed(), "hold should succeed")
+		g.Expect(time.Now()).To(BeTemporally(">=", notBefore.Truncate(time.Millisecond)), "retried message should be delayed")
+		e = d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(Succeed(), "dispatch should succeed")
+		producers[retry1].AssertSent(g, 0)
+
+		// messages from main topic are not held
+		raw = newTestConsumerMessage(retryTestTopic, Headers{
+			HeaderContentType:    MIMETypeJson,
+			HeaderRetryNotBefore: strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10),
+		})
+		start := time.Now()
+		g.Expect(f.hold(ctx, raw)).To(Succeed(), "hold should succeed")
+		g.Expect(time.Since(start)).To(BeNumerically("<", delay), "message from main topic should not be held")
+
+		// cancelled context
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		raw = newTestConsumerMessage(retry1, Headers{
+			HeaderContentType:    MIMETypeJson,
+			HeaderRetryNotBefore: strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10),
+		})
+		e = f.hold(cancelCtx, raw)
+		g.Expect(e).To(HaveOccurred(), "hold should fail when context is cancelled during delay")
+	}
+}
+
+func SubTestRetryRequeue() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const maxWait = 50 * time.Millisecond
+		f, producers := newTestRetryFinalizer(true, time.Hour)
+		f.maxWait = maxWait
+		finalizer := &testDispatchFinalizer{}
+		d := newTestRetryDispatcher(f, fmt.Errorf("oops"))
+		d.Interceptors = []ConsumerDispatchInterceptor{finalizer, f}
+
+		retry1 := RetryTopicName(retryTestTopic, retryTestGroup, 1)
+		notBefore := strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10)
+		raw := newTestConsumerMessage(retry1, Headers{
+			HeaderContentType:    MIMETypeJson,
+			HeaderRetryAttempt:   "1",
+			HeaderRetryNotBefore: notBefore,
+		})
+		start := time.Now()
+		e := f.hold(ctx, raw)
+		g.Expect(e).To(Succeed(), "hold should succeed")
+		g.Expect(time.Since(start)).To(BeNumerically("<", time.Second), "message should not be held longer than max wait")
+		e = d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(Succeed(), "re-queued message should not fail dispatch")
+		sent := producers[retry1].AssertSent(g, 1)
+		g.Expect(sent.Message.Payload).To(Equal(raw.Value), "re-queued message should have original payload")
+		g.Expect(sent.Message.Headers).To(HaveKeyWithValue(HeaderRetryAttempt, "1"), "re-queued message should keep attempt")
+		g.Expect(sent.Message.Headers).To(HaveKeyWithValue(HeaderRetryNotBefore, notBefore), "re-queued message should keep due time")
+		g.Expect(sent.Config.Key).To(Equal(raw.Key), "re-queued message should keep key")
+		producers[DeadLetterTopicName(retryTestTopic, retryTestGroup)].AssertSent(g, 0)
+		g.Expect(finalizer.Intercepted).To(Equal(1), "preceding interceptor should be invoked")
+		g.Expect(finalizer.Finalized).To(Equal(1), "preceding finalizer should be invoked when message is re-queued")
+		g.Expect(finalizer.Err).To(Succeed(), "preceding finalizer should not receive error when message is re-queued")
+
+		// re-queue failure
+		producers[retry1].Error = fmt.Errorf("broker not available")
+		e = d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(HaveOccurred(), "dispatch should fail when message cannot be re-queued")
+		g.Expect(finalizer.Finalized).To(Equal(2), "preceding finalizer should be invoked when message cannot be re-queued")
+		g.Expect(finalizer.Err).To(HaveOccurred(), "preceding finalizer should receive error when message cannot be re-queued")
+	}
+}
+
+func SubTestRetryMaxWait() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		cfg := sarama.NewConfig()
+		cfg.Consumer.Group.Rebalance.Timeout = 60 * time.Second
+		cfg.Consumer.Group.Session.Timeout = 10 * time.Second
+		g.Expect(retryMaxWait(cfg)).To(Equal(5*time.Second), "max wait should be below session timeout")
+
+		cfg.Consumer.Group.Rebalance.Timeout = 8 * time.Second
+		g.Expect(retryMaxWait(cfg)).To(Equal(2*time.Second), "max wait should be below rebalance timeout")
+
+		cfg.Consumer.Group.Session.Timeout = 0
+		g.Expect(retryMaxWait(cfg)).To(Equal(2*time.Second), "max wait should be based on rebalance timeout")
+
+		cfg.Consumer.Group.Rebalance.Timeout = 0
+		g.Expect(retryMaxWait(cfg)).To(BeZero(), "max wait should be zero when timeouts are not set")
+	}
+}
+
+func SubTestRetrySendFailure() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		f, producers := newTestRetryFinalizer(true, time.Millisecond)
+		retry1 := RetryTopicName(retryTestTopic, retryTestGroup, 1)
+		producers[retry1].Error = fmt.Errorf("broker not available")
+		d := newTestRetryDispatcher(f, fmt.Errorf("oops"))
+
+		raw := newTestConsumerMessage(retryTestTopic, Headers{HeaderContentType: MIMETypeJson})
+		e := d.Dispatch(newTestMessageContext(ctx, raw))
+		g.Expect(e).To(HaveOccurred(), "error should be returned when failed message cannot be forwarded")
+	}
+}
+
+func SubTestRetryPropertiesDefaults() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		cfg := bindingConfig{}
+		WithConsumerProperties(&ConsumerProperties{
+			Retry:      RetryProperties{Enabled: utils.BoolPtr(true)},
+			DeadLetter: DeadLetterProperties{Enabled: utils.BoolPtr(true)},
+		})(&cfg)
+		g.Expect(cfg.consumer.retry.enabled()).To(BeTrue(), "retry should be enabled")
+		g.Expect(cfg.consumer.retry.delays).To(Equal(defaultRetryDelays), "default delays should be used")
+		g.Expect(cfg.consumer.retry.deadLetter).To(BeTrue(), "dead-letter should be enabled")
+		g.Expect(deadLetterTopic(retryTestTopic, retryTestGroup, &cfg.consumer.retry)).
+			To(Equal(DeadLetterTopicName(retryTestTopic, retryTestGroup)), "default dead-letter topic should be used")
+	}
+}
+
+func SubTestRetryPropertiesCustomized() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		cfg := bindingConfig{}
+		dlq := "my-dlq"
+		WithConsumerProperties(&ConsumerProperties{
+			Retry:      RetryProperties{Delays: []utils.Duration{utils.Duration(time.Second), utils.Duration(time.Minute)}},
+			DeadLetter: DeadLetterProperties{Enabled: utils.BoolPtr(true), Topic: &dlq},
+		})(&cfg)
+		g.Expect(cfg.consumer.retry.delays).To(Equal([]time.Duration{time.Second, time.Minute}), "delays should be correct")
+		g.Expect(retryTopics(retryTestTopic, retryTestGroup, &cfg.consumer.retry)).To(Equal([]string{
+			"test-retry.test.group.retry-1", "test-retry.test.group.retry-2",
+		}), "retry topics should be correct")
+		g.Expect(deadLetterTopic(retryTestTopic, retryTestGroup, &cfg.consumer.retry)).To(Equal(dlq), "dead-letter topic should be correct")
+	}
+}
+
+func SubTestRetryPropertiesDisabled() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		cfg := bindingConfig{}
+		RetryTopics(time.Second)(&cfg)
+		WithConsumerProperties(&ConsumerProperties{
+			Retry: RetryProperties{Enabled: utils.BoolPtr(false), Delays: []utils.Duration{utils.Duration(time.Second)}},
+		})(&cfg)
+		g.Expect(cfg.consumer.retry.enabled()).To(BeFalse(), "retry should be disabled")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+type sentMessage struct {
+	Message *Message
+	Config  messageConfig
+}
+
+type testProducer struct {
+	topic string
+	Sent  []*sentMessage
+	Error error
+}
+
+func (p *testProducer) Topic() string {
+	return p.topic
+}
+
+func (p *testProducer) Send(_ context.Context, message interface{}, options ...MessageOptions) error {
+	if p.Error != nil {
+		return p.Error
+	}
+	sent := sentMessage{Message: message.(*Message), Config: defaultMessageConfig()}
+	for _, fn := range options {
+		fn(&sent.Config)
+	}
+	p.Sent = append(p.Sent, &sent)
+	return nil
+}
+
+func (p *testProducer) ReadyCh() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+func (p *testProducer) AssertSent(g *gomega.WithT, count int) *sentMessage {
+	g.Expect(p.Sent).To(HaveLen(count), "producer of [%s] should send %d messages", p.topic, count)
+	if count == 0 {
+		return nil
+	}
+	sent := p.Sent[len(p.Sent)-1]
+	p.Sent = nil
+	return sent
+}
+
+type testDispatchFinalizer struct {
+	Intercepted int
+	Finalized   int
+	Err         error
+}
+
+func (i *testDispatchFinalizer) Intercept(msgCtx *MessageContext) (*MessageContext, error) {
+	i.Intercepted++
+	return msgCtx, nil
+}
+
+func (i *testDispatchFinalizer) Finalize(msgCtx *MessageContext, err error) (*MessageContext, error) {
+	i.Finalized++
+	i.Err = err
+	return msgCtx, err
+}
+
+func newTestRetryFinalizer(deadLetter bool, delays ...time.Duration) (*retryDispatchFinalizer, map[string]*testProducer) {
+	cfg := retryConfig{delays: delays, deadLetter: deadLetter}
+	topics := retryTopics(retryTestTopic, retryTestGroup, &cfg)
+	if deadLetter {
+		topics = append(topics, deadLetterTopic(retryTestTopic, retryTestGroup, &cfg))
+	}
+	testProducers := map[string]*testProducer{}
+	producers := map[string]Producer{}
+	for _, t := range topics {
+		testProducers[t] = &testProducer{topic: t}
+		producers[t] = testProducers[t]
+	}
+	return newRetryDispatchFinalizer(retryTestTopic, retryTestGroup, &cfg, producers), testProducers
+}
+
+func newTestRetryDispatcher(f *retryDispatchFinalizer, handlerErr error) *Dispatcher {
+	d := &Dispatcher{Interceptors: []ConsumerDispatchInterceptor{f}}
+	_ = d.AddHandler(func(_ context.Context, _ map[string]interface{}) error {
+		return handlerErr
+	})
+	return d
+}
+
+func newTestConsumerMessage(topic string, headers Headers) *sarama.ConsumerMessage {
+	raw := &sarama.ConsumerMessage{
+		Topic:     topic,
+		Partition: 1,
+		Offset:    10,
+		Key:       []byte("test-key"),
+		Value:     []byte(`{"key":"value"}`),
+	}
+	for k, v := range headers {
+		raw.Headers = append(raw.Headers, &sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return raw
+}
+
+func newTestMessageContext(ctx context.Context, raw *sarama.ConsumerMessage) *MessageContext {
+	headers := Headers{}
+	for _, rh := range raw.Headers {
+		headers[string(rh.Key)] = string(rh.Value)
+	}
+	return &MessageContext{
+		Context:    ctx,
+		Message:    Message{Headers: headers, Payload: raw.Value},
+		Topic:      raw.Topic,
+		RawMessage: raw,
+	}
+}
+
+func AssertForwardedMessage(g *gomega.WithT, sent *sentMessage, raw *sarama.ConsumerMessage, expectedAttempt int, expectDelay bool) {
+	g.Expect(sent.Message.Payload).To(Equal(raw.Value), "forwarded message should have original payload")
+	for _, rh := range raw.Headers {
+		if string(rh.Key) == HeaderRetryAttempt || string(rh.Key) == HeaderRetryNotBefore || string(rh.Key) == HeaderErrorMessage {
+			continue
+		}
+		g.Expect(sent.Message.Headers).To(HaveKeyWithValue(string(rh.Key), string(rh.Value)), "forwarded message should have original headers")
+	}
+	g.Expect(sent.Message.Headers).To(HaveKey(HeaderOriginalTopic), "forwarded message should have original topic")
+	g.Expect(sent.Message.Headers).To(HaveKeyWithValue(HeaderOriginalPartition, "1"), "forwarded message should have original partition")
+	g.Expect(sent.Message.Headers).To(HaveKeyWithValue(HeaderOriginalOffset, "10"), "forwarded message should have original offset")
+	g.Expect(sent.Message.Headers).To(HaveKeyWithValue(HeaderRetryAttempt, strconv.Itoa(expectedAttempt)), "forwarded message should have correct attempt")
+	g.Expect(sent.Message.Headers).To(HaveKey(HeaderErrorMessage), "forwarded message should have error message")
+	if expectDelay {
+		g.Expect(sent.Message.Headers).To(HaveKey(HeaderRetryNotBefore), "forwarded message should have retry time")
+	} else {
+		g.Expect(sent.Message.Headers).ToNot(HaveKey(HeaderRetryNotBefore), "forwarded message should not have retry time")
+	}
+}
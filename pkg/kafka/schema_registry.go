@@ -0,0 +1,273 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SchemaTypeAvro     SchemaType = "AVRO"
+	SchemaTypeProtobuf SchemaType = "PROTOBUF"
+)
+
+const schemaRegistryMIMEType = "application/vnd.schemaregistry.v1+json"
+
+type SchemaType string
+
+// Schema is a schema registered in SchemaRegistry
+type Schema struct {
+	ID     int
+	Type   SchemaType
+	Schema string
+}
+
+// SchemaRegistry is the client of a schema registry compatible with Confluent Schema Registry.
+// Implementations should cache results, because they are invoked for every message.
+type SchemaRegistry interface {
+	// Register registers given schema under given subject, and returns the global ID of the schema.
+	// Registering same schema multiple times should return same ID.
+	Register(ctx context.Context, subject string, schemaType SchemaType, schema string) (int, error)
+	// SchemaByID returns registered schema by its global ID
+	SchemaByID(ctx context.Context, id int) (*Schema, error)
+}
+
+/*************************
+	In-Memory
+ *************************/
+
+// InMemorySchemaRegistry implements SchemaRegistry in memory. It's useful for testing
+type InMemorySchemaRegistry struct {
+	mtx      sync.RWMutex
+	schemas  []*Schema
+	ids      map[string]int
+	subjects map[string][]int
+}
+
+func NewInMemorySchemaRegistry() *InMemorySchemaRegistry {
+	return &InMemorySchemaRegistry{
+		ids:      map[string]int{},
+		subjects: map[string][]int{},
+	}
+}
+
+func (r *InMemorySchemaRegistry) Register(_ context.Context, subject string, schemaType SchemaType, schema string) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	key := string(schemaType) + ":" + schema
+	id, ok := r.ids[key]
+	if !ok {
+		id = len(r.schemas) + 1
+		r.schemas = append(r.schemas, &Schema{ID: id, Type: schemaType, Schema: schema})
+		r.ids[key] = id
+	}
+	for _, v := range r.subjects[subject] {
+		if v == id {
+			return id, nil
+		}
+	}
+	r.subjects[subject] = append(r.subjects[subject], id)
+	return id, nil
+}
+
+func (r *InMemorySchemaRegistry) SchemaByID(_ context.Context, id int) (*Schema, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	if id <= 0 || id > len(r.schemas) {
+		return nil, ErrorSubTypeSchemaRegistry.WithMessage("schema [%d] not found", id)
+	}
+	s := *r.schemas[id-1]
+	return &s, nil
+}
+
+// Subjects returns registered schema IDs of given subject, in order of registration
+func (r *InMemorySchemaRegistry) Subjects(subject string) []int {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return append([]int(nil), r.subjects[subject]...)
+}
+
+/*************************
+	HTTP Client
+ *************************/
+
+type SchemaRegistryClientOptions func(opt *SchemaRegistryClientOption)
+
+type SchemaRegistryClientOption struct {
+	URL        string
+	Username   string
+	Password   string
+	HttpClient *http.Client
+}
+
+// httpSchemaRegistry implements SchemaRegistry using REST API of Confluent Schema Registry
+type httpSchemaRegistry struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+	mtx      sync.RWMutex
+	byID     map[int]*Schema
+	ids      map[string]int
+}
+
+// NewSchemaRegistryClient create a SchemaRegistry client of Confluent Schema Registry REST API
+func NewSchemaRegistryClient(opts ...SchemaRegistryClientOptions) SchemaRegistry {
+	opt := SchemaRegistryClientOption{
+		HttpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &httpSchemaRegistry{
+		baseURL:  strings.TrimRight(opt.URL, "/"),
+		username: opt.Username,
+		password: opt.Password,
+		client:   opt.HttpClient,
+		byID:     map[int]*Schema{},
+		ids:      map[string]int{},
+	}
+}
+
+// WithSchemaRegistryProperties is a SchemaRegistryClientOptions that applies SchemaRegistryProperties
+func WithSchemaRegistryProperties(p *SchemaRegistryProperties) SchemaRegistryClientOptions {
+	return func(opt *SchemaRegistryClientOption) {
+		opt.URL = p.URL
+		opt.Username = p.Username
+		opt.Password = p.Password
+		if p.Timeout > 0 {
+			opt.HttpClient = &http.Client{Timeout: time.Duration(p.Timeout)}
+		}
+	}
+}
+
+type srSchemaRequest struct {
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType,omitempty"`
+}
+
+type srSchemaResponse struct {
+	ID         int        `json:"id"`
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType"`
+}
+
+type srErrorResponse struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (r *httpSchemaRegistry) Register(ctx context.Context, subject string, schemaType SchemaType, schema string) (int, error) {
+	key := subject + ":" + string(schemaType) + ":" + schema
+	r.mtx.RLock()
+	id, ok := r.ids[key]
+	r.mtx.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	req := srSchemaRequest{Schema: schema}
+	if schemaType != SchemaTypeAvro {
+		req.SchemaType = schemaType
+	}
+	var resp srSchemaResponse
+	if e := r.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", &req, &resp); e != nil {
+		return 0, e
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.ids[key] = resp.ID
+	r.byID[resp.ID] = &Schema{ID: resp.ID, Type: schemaType, Schema: schema}
+	return resp.ID, nil
+}
+
+func (r *httpSchemaRegistry) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	r.mtx.RLock()
+	s, ok := r.byID[id]
+	r.mtx.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	var resp srSchemaResponse
+	if e := r.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &resp); e != nil {
+		return nil, e
+	}
+	s = &Schema{ID: id, Type: resp.SchemaType, Schema: resp.Schema}
+	if s.Type == "" {
+		s.Type = SchemaTypeAvro
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.byID[id] = s
+	return s, nil
+}
+
+func (r *httpSchemaRegistry) do(ctx context.Context, method, path string, body interface{}, dest interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, e := json.Marshal(body)
+		if e != nil {
+			return ErrorSubTypeSchemaRegistry.WithCause(e, "unable to encode request: %v", e)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, e := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if e != nil {
+		return ErrorSubTypeSchemaRegistry.WithCause(e, "unable to create request: %v", e)
+	}
+	req.Header.Set("Accept", schemaRegistryMIMEType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryMIMEType)
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, e := r.client.Do(req)
+	if e != nil {
+		return ErrorSubTypeSchemaRegistry.WithCause(e, "schema registry is not reachable: %v", e)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errResp srErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return ErrorSubTypeSchemaRegistry.WithMessage("schema registry responded %d on %s %s: [%d] %s",
+			resp.StatusCode, method, path, errResp.ErrorCode, errResp.Message)
+	}
+	if e := json.NewDecoder(resp.Body).Decode(dest); e != nil {
+		return ErrorSubTypeSchemaRegistry.WithCause(e, "unable to decode schema registry response: %v", e)
+	}
+	return nil
+}
+
+func (r *httpSchemaRegistry) String() string {
+	return fmt.Sprintf("SchemaRegistry[%s]", r.baseURL)
+}
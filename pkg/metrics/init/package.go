@@ -0,0 +1,44 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"embed"
+	appconfig "github.com/cisco-open/go-lanai/pkg/appconfig/init"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"go.uber.org/fx"
+)
+
+//go:embed defaults-metrics.yml
+var defaultConfigFS embed.FS
+
+var Module = &bootstrap.Module{
+	Name:       "metrics-config",
+	Precedence: bootstrap.MetricsPrecedence,
+	Options: []fx.Option{
+		appconfig.FxEmbeddedDefaults(defaultConfigFS),
+	},
+}
+
+// Use Allow service to include this module in main().
+// Instrumentation of web, httpclient, data, redis and kafka are enabled automatically when those modules are in use.
+// To expose metrics, include "metrics" and/or "prometheus" actuator endpoints.
+func Use() {
+	bootstrap.Register(metrics.Module)
+	bootstrap.Register(Module)
+}
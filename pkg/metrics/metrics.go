@@ -0,0 +1,118 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Tags are key-value pairs attached to each measurement. Keys must be declared via WithTags when the meter is created.
+// Any declared key that is missing from Tags is recorded with empty value
+type Tags map[string]string
+
+type MeterType string
+
+const (
+	MeterTypeCounter   MeterType = "COUNTER"
+	MeterTypeGauge     MeterType = "GAUGE"
+	MeterTypeHistogram MeterType = "HISTOGRAM"
+)
+
+// Registry is the entry point to create and lookup meters. Meters with same name are shared.
+// Registry is also a prometheus.Gatherer, which is used for Prometheus exposition.
+//
+// Note: creating meters with same name but different MeterType or tag keys is a programming error and would panic
+type Registry interface {
+	prometheus.Gatherer
+	// Counter returns a Counter with given name, create one if not exists
+	Counter(name string, opts ...MeterOptions) Counter
+	// Gauge returns a Gauge with given name, create one if not exists
+	Gauge(name string, opts ...MeterOptions) Gauge
+	// Histogram returns a Histogram with given name, create one if not exists
+	Histogram(name string, opts ...MeterOptions) Histogram
+	// Meters returns descriptions of all created meters
+	Meters() []MeterDescriptor
+	// Register registers additional prometheus.Collector to the underlying prometheus registry
+	Register(collectors ...prometheus.Collector) error
+}
+
+// Counter is a monotonically increasing meter
+type Counter interface {
+	Inc(tags Tags)
+	Add(v float64, tags Tags)
+}
+
+// Gauge is a meter that can arbitrarily go up and down
+type Gauge interface {
+	Set(v float64, tags Tags)
+	Add(v float64, tags Tags)
+}
+
+// Histogram samples observations and counts them in configurable buckets
+type Histogram interface {
+	Observe(v float64, tags Tags)
+}
+
+// MeterDescriptor describes a created meter
+type MeterDescriptor struct {
+	// Name is the meter name given by application, e.g. "http.server.requests"
+	Name string
+	// ExportedName is the sanitized name exported to Prometheus, e.g. "http_server_requests_seconds"
+	ExportedName string
+	Type         MeterType
+	Description  string
+	Unit         string
+	TagKeys      []string
+}
+
+type MeterOptions func(opt *MeterOption)
+type MeterOption struct {
+	Description string
+	// Unit is appended to exported name as suffix. e.g. "seconds", "bytes"
+	Unit    string
+	TagKeys []string
+	// Buckets is only applicable to Histogram. Registry's default is used when not specified.
+	Buckets []float64
+}
+
+// WithDescription is a MeterOptions that set meter description
+func WithDescription(desc string) MeterOptions {
+	return func(opt *MeterOption) {
+		opt.Description = desc
+	}
+}
+
+// WithUnit is a MeterOptions that set meter's base unit
+func WithUnit(unit string) MeterOptions {
+	return func(opt *MeterOption) {
+		opt.Unit = unit
+	}
+}
+
+// WithTags is a MeterOptions that declare tag keys of the meter
+func WithTags(keys ...string) MeterOptions {
+	return func(opt *MeterOption) {
+		opt.TagKeys = append(opt.TagKeys, keys...)
+	}
+}
+
+// WithBuckets is a MeterOptions that set histogram buckets
+func WithBuckets(buckets ...float64) MeterOptions {
+	return func(opt *MeterOption) {
+		opt.Buckets = buckets
+	}
+}
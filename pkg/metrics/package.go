@@ -0,0 +1,61 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/log"
+	"go.uber.org/fx"
+)
+
+var logger = log.New("Metrics")
+
+var Module = &bootstrap.Module{
+	Name:       "metrics",
+	Precedence: bootstrap.MetricsPrecedence,
+	PriorityOptions: []fx.Option{
+		fx.Provide(BindMetricsProperties),
+		fx.Provide(provideRegistry),
+	},
+}
+
+/**************************
+	Provide dependencies
+***************************/
+
+type registryDI struct {
+	fx.In
+	AppCtx     *bootstrap.ApplicationContext
+	Properties MetricsProperties
+}
+
+// provideRegistry returns nil Registry if metrics is disabled. All instrumentation should treat Registry as optional
+func provideRegistry(di registryDI) Registry {
+	if !di.Properties.Enabled {
+		logger.WithContext(di.AppCtx).Infof("metrics is disabled")
+		return nil
+	}
+	return NewRegistry(func(opt *RegistryOption) {
+		opt.RuntimeMetrics = di.Properties.Runtime.Enabled
+		if len(di.Properties.Histogram.Buckets) != 0 {
+			opt.DefaultBuckets = di.Properties.Histogram.Buckets
+		}
+		for k, v := range di.Properties.CommonTags {
+			opt.CommonTags[k] = v
+		}
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/pkg/errors"
+)
+
+const (
+	MetricsPropertiesPrefix = "metrics"
+)
+
+type MetricsProperties struct {
+	Enabled    bool                `json:"enabled"`
+	CommonTags map[string]string   `json:"common-tags"`
+	Runtime    RuntimeProperties   `json:"runtime"`
+	Histogram  HistogramProperties `json:"histogram"`
+}
+
+type RuntimeProperties struct {
+	// Enabled whether to collect Go runtime and process metrics
+	Enabled bool `json:"enabled"`
+}
+
+type HistogramProperties struct {
+	// Buckets default histogram buckets in base unit (e.g. seconds)
+	Buckets []float64 `json:"buckets"`
+}
+
+// NewMetricsProperties create a MetricsProperties with default values
+func NewMetricsProperties() *MetricsProperties {
+	return &MetricsProperties{
+		Enabled:    true,
+		CommonTags: map[string]string{},
+		Runtime: RuntimeProperties{
+			Enabled: true,
+		},
+		Histogram: HistogramProperties{
+			Buckets: DefaultBuckets,
+		},
+	}
+}
+
+// BindMetricsProperties create and bind MetricsProperties, with a optional prefix
+func BindMetricsProperties(ctx *bootstrap.ApplicationContext) MetricsProperties {
+	props := NewMetricsProperties()
+	if err := ctx.Config().Bind(props, MetricsPropertiesPrefix); err != nil {
+		panic(errors.Wrap(err, "failed to bind MetricsProperties"))
+	}
+	return *props
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	dto "github.com/prometheus/client_model/go"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	DefaultBuckets   = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+)
+
+type RegistryOptions func(opt *RegistryOption)
+type RegistryOption struct {
+	// CommonTags are constant tags applied to all meters
+	CommonTags map[string]string
+	// DefaultBuckets are used by histograms without explicit buckets
+	DefaultBuckets []float64
+	// RuntimeMetrics registers Go runtime and process collectors when enabled
+	RuntimeMetrics bool
+}
+
+// promRegistry implements Registry backed by prometheus.Registry
+type promRegistry struct {
+	mtx     sync.RWMutex
+	reg     *prometheus.Registry
+	options RegistryOption
+	meters  map[string]*meter
+}
+
+func NewRegistry(opts ...RegistryOptions) Registry {
+	opt := RegistryOption{
+		CommonTags:     map[string]string{},
+		DefaultBuckets: DefaultBuckets,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	reg := prometheus.NewRegistry()
+	if opt.RuntimeMetrics {
+		reg.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+	return &promRegistry{
+		reg:     reg,
+		options: opt,
+		meters:  map[string]*meter{},
+	}
+}
+
+func (r *promRegistry) Gather() ([]*dto.MetricFamily, error) {
+	return r.reg.Gather()
+}
+
+func (r *promRegistry) Register(collectors ...prometheus.Collector) error {
+	for _, c := range collectors {
+		if e := r.reg.Register(c); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (r *promRegistry) Counter(name string, opts ...MeterOptions) Counter {
+	m := r.meter(name, MeterTypeCounter, opts, func(desc *MeterDescriptor, _ *MeterOption) prometheus.Collector {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        desc.ExportedName,
+			Help:        desc.Description,
+			ConstLabels: r.options.CommonTags,
+		}, desc.TagKeys)
+	})
+	return counter{meter: m}
+}
+
+func (r *promRegistry) Gauge(name string, opts ...MeterOptions) Gauge {
+	m := r.meter(name, MeterTypeGauge, opts, func(desc *MeterDescriptor, _ *MeterOption) prometheus.Collector {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        desc.ExportedName,
+			Help:        desc.Description,
+			ConstLabels: r.options.CommonTags,
+		}, desc.TagKeys)
+	})
+	return gauge{meter: m}
+}
+
+func (r *promRegistry) Histogram(name string, opts ...MeterOptions) Histogram {
+	m := r.meter(name, MeterTypeHistogram, opts, func(desc *MeterDescriptor, opt *MeterOption) prometheus.Collector {
+		buckets := opt.Buckets
+		if len(buckets) == 0 {
+			buckets = r.options.DefaultBuckets
+		}
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        desc.ExportedName,
+			Help:        desc.Description,
+			ConstLabels: r.options.CommonTags,
+			Buckets:     buckets,
+		}, desc.TagKeys)
+	})
+	return histogram{meter: m}
+}
+
+func (r *promRegistry) Meters() []MeterDescriptor {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	ret := make([]MeterDescriptor, 0, len(r.meters))
+	for _, m := range r.meters {
+		ret = append(ret, m.desc)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+type collectorFactory func(desc *MeterDescriptor, opt *MeterOption) prometheus.Collector
+
+func (r *promRegistry) meter(name string, typ MeterType, opts []MeterOptions, factory collectorFactory) *meter {
+	opt := MeterOption{}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	desc := MeterDescriptor{
+		Name:         name,
+		ExportedName: ExportedName(name, opt.Unit, typ),
+		Type:         typ,
+		Description:  opt.Description,
+		Unit:         opt.Unit,
+		TagKeys:      sanitizeTagKeys(opt.TagKeys),
+	}
+	if desc.Description == "" {
+		desc.Description = name
+	}
+
+	r.mtx.RLock()
+	existing, ok := r.meters[name]
+	r.mtx.RUnlock()
+	if ok {
+		r.mustCompatible(existing, &desc)
+		return existing
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if existing, ok := r.meters[name]; ok {
+		r.mustCompatible(existing, &desc)
+		return existing
+	}
+	m := &meter{
+		desc:      desc,
+		collector: factory(&desc, &opt),
+	}
+	if e := r.reg.Register(m.collector); e != nil {
+		panic(fmt.Errorf("unable to register meter [%s]: %v", name, e))
+	}
+	r.meters[name] = m
+	return m
+}
+
+func (r *promRegistry) mustCompatible(existing *meter, desc *MeterDescriptor) {
+	if existing.desc.Type != desc.Type {
+		panic(fmt.Errorf("meter [%s] already exists as %s", desc.Name, existing.desc.Type))
+	}
+	if strings.Join(existing.desc.TagKeys, ",") != strings.Join(desc.TagKeys, ",") {
+		panic(fmt.Errorf("meter [%s] already exists with different tags %v", desc.Name, existing.desc.TagKeys))
+	}
+}
+
+// ExportedName convert meter name to prometheus compatible name.
+// e.g. "http.server.requests" with unit "seconds" would be converted to "http_server_requests_seconds"
+func ExportedName(name string, unit string, typ MeterType) string {
+	exported := invalidNameChars.ReplaceAllString(name, "_")
+	if unit != "" && !strings.HasSuffix(exported, "_"+unit) {
+		exported = exported + "_" + invalidNameChars.ReplaceAllString(unit, "_")
+	}
+	if typ == MeterTypeCounter && !strings.HasSuffix(exported, "_total") {
+		exported = exported + "_total"
+	}
+	return exported
+}
+
+func sanitizeTagKeys(keys []string) []string {
+	ret := make([]string, len(keys))
+	for i, k := range keys {
+		ret[i] = invalidNameChars.ReplaceAllString(k, "_")
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+/*********************
+	Meters
+ *********************/
+
+type meter struct {
+	desc      MeterDescriptor
+	collector prometheus.Collector
+}
+
+func (m *meter) labels(tags Tags) prometheus.Labels {
+	labels := make(prometheus.Labels, len(m.desc.TagKeys))
+	for _, k := range m.desc.TagKeys {
+		labels[k] = ""
+	}
+	for k, v := range tags {
+		k = invalidNameChars.ReplaceAllString(k, "_")
+		if _, ok := labels[k]; ok {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// counter implements Counter
+type counter struct {
+	*meter
+}
+
+func (c counter) Inc(tags Tags) {
+	c.collector.(*prometheus.CounterVec).With(c.labels(tags)).Inc()
+}
+
+func (c counter) Add(v float64, tags Tags) {
+	c.collector.(*prometheus.CounterVec).With(c.labels(tags)).Add(v)
+}
+
+// gauge implements Gauge
+type gauge struct {
+	*meter
+}
+
+func (g gauge) Set(v float64, tags Tags) {
+	g.collector.(*prometheus.GaugeVec).With(g.labels(tags)).Set(v)
+}
+
+func (g gauge) Add(v float64, tags Tags) {
+	g.collector.(*prometheus.GaugeVec).With(g.labels(tags)).Add(v)
+}
+
+// histogram implements Histogram
+type histogram struct {
+	*meter
+}
+
+func (h histogram) Observe(v float64, tags Tags) {
+	h.collector.(*prometheus.HistogramVec).With(h.labels(tags)).Observe(v)
+}
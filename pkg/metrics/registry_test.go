@@ -0,0 +1,100 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/test"
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"testing"
+)
+
+/*************************
+	Tests
+ *************************/
+
+func TestRegistry(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestExportedName(), "TestExportedName"),
+		test.GomegaSubTest(SubTestMeters(), "TestMeters"),
+		test.GomegaSubTest(SubTestCommonTags(), "TestCommonTags"),
+		test.GomegaSubTest(SubTestIncompatibleMeters(), "TestIncompatibleMeters"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestExportedName() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		g.Expect(ExportedName("http.server.requests", "seconds", MeterTypeHistogram)).To(Equal("http_server_requests_seconds"))
+		g.Expect(ExportedName("jobs-done", "", MeterTypeCounter)).To(Equal("jobs_done_total"))
+		g.Expect(ExportedName("jobs_total", "", MeterTypeCounter)).To(Equal("jobs_total"))
+		g.Expect(ExportedName("memory.used", "bytes", MeterTypeGauge)).To(Equal("memory_used_bytes"))
+	}
+}
+
+func SubTestMeters() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		reg := NewRegistry()
+		reg.Counter("test.counter", WithTags("k")).Inc(Tags{"k": "v"})
+		reg.Counter("test.counter", WithTags("k")).Add(2, Tags{"k": "v", "unknown": "ignored"})
+		reg.Gauge("test.gauge").Set(10, nil)
+		reg.Histogram("test.histogram", WithUnit("seconds"), WithBuckets(1, 2)).Observe(1.5, nil)
+
+		g.Expect(reg.Meters()).To(HaveLen(3), "registry should have correct meters")
+		families, e := reg.Gather()
+		g.Expect(e).To(Succeed(), "gather should not fail")
+		found := map[string]*dto.MetricFamily{}
+		for _, f := range families {
+			found[f.GetName()] = f
+		}
+		g.Expect(found).To(HaveKey("test_counter_total"))
+		g.Expect(found["test_counter_total"].GetMetric()[0].GetCounter().GetValue()).To(BeNumerically("==", 3))
+		g.Expect(found).To(HaveKey("test_gauge"))
+		g.Expect(found["test_gauge"].GetMetric()[0].GetGauge().GetValue()).To(BeNumerically("==", 10))
+		g.Expect(found).To(HaveKey("test_histogram_seconds"))
+		g.Expect(found["test_histogram_seconds"].GetMetric()[0].GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+	}
+}
+
+func SubTestCommonTags() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		reg := NewRegistry(func(opt *RegistryOption) {
+			opt.CommonTags["application"] = "test"
+		})
+		reg.Counter("test.counter").Inc(nil)
+		families, e := reg.Gather()
+		g.Expect(e).To(Succeed(), "gather should not fail")
+		g.Expect(families).To(HaveLen(1))
+		labels := families[0].GetMetric()[0].GetLabel()
+		g.Expect(labels).To(HaveLen(1))
+		g.Expect(labels[0].GetName()).To(Equal("application"))
+		g.Expect(labels[0].GetValue()).To(Equal("test"))
+	}
+}
+
+func SubTestIncompatibleMeters() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		reg := NewRegistry()
+		reg.Counter("test.meter", WithTags("k"))
+		g.Expect(func() { reg.Gauge("test.meter", WithTags("k")) }).To(Panic(), "different meter type should panic")
+		g.Expect(func() { reg.Counter("test.meter", WithTags("other")) }).To(Panic(), "different tags should panic")
+	}
+}
@@ -18,6 +18,7 @@ package migration
 
 import (
 	"context"
+	"github.com/cisco-open/go-lanai/pkg/data/tx"
 	"gorm.io/gorm"
 	"time"
 )
@@ -25,6 +26,8 @@ import (
 type MigrationVersion struct {
 	Version       Version `gorm:"primaryKey"`
 	Description   string
+	Checksum      string
+	DownChecksum  string
 	ExecutionTime time.Duration
 	InstalledOn   time.Time
 	Success       bool
@@ -38,6 +41,14 @@ func (v MigrationVersion) GetDescription() string {
 	return v.Description
 }
 
+func (v MigrationVersion) GetChecksum() string {
+	return v.Checksum
+}
+
+func (v MigrationVersion) GetDownChecksum() string {
+	return v.DownChecksum
+}
+
 func (v MigrationVersion) IsSuccess() bool {
 	return v.Success
 }
@@ -47,13 +58,26 @@ func (v MigrationVersion) GetInstalledOn() time.Time {
 }
 
 
+type GormVersionerOptions func(opt *GormVersionerOption)
+type GormVersionerOption struct {
+	// Transactional overrides transactional execution of migration steps. See TransactionalVersioner.
+	// When nil, it's determined by the dialect of gorm.DB.
+	Transactional *bool
+}
+
 type GormVersioner struct {
-	db          *gorm.DB
+	db            *gorm.DB
+	transactional *bool
 }
 
-func NewGormVersioner(db *gorm.DB) Versioner {
+func NewGormVersioner(db *gorm.DB, opts ...GormVersionerOptions) Versioner {
+	opt := GormVersionerOption{}
+	for _, fn := range opts {
+		fn(&opt)
+	}
 	return &GormVersioner{
-		db: db,
+		db:            db,
+		transactional: opt.Transactional,
 	}
 }
 
@@ -61,6 +85,10 @@ func (v *GormVersioner) CreateVersionTableIfNotExist(ctx context.Context) error
 	return v.db.WithContext(ctx).AutoMigrate(&MigrationVersion{})
 }
 
+func (v *GormVersioner) VersionTableExists(ctx context.Context) (bool, error) {
+	return v.db.WithContext(ctx).Migrator().HasTable(&MigrationVersion{}), nil
+}
+
 func (v *GormVersioner) GetAppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
 	versions := []MigrationVersion{}
 	result := v.db.WithContext(ctx).Find(&versions)
@@ -76,13 +104,56 @@ func (v *GormVersioner) GetAppliedMigrations(ctx context.Context) ([]AppliedMigr
 }
 
 func (v *GormVersioner) RecordAppliedMigration(ctx context.Context, version Version, description string, success bool, installedOn time.Time, executionTime time.Duration) error {
+	return v.RecordAppliedMigrationWithChecksum(ctx, version, description, "", success, installedOn, executionTime)
+}
+
+func (v *GormVersioner) RecordAppliedMigrationWithChecksum(ctx context.Context, version Version, description string, checksum string, success bool, installedOn time.Time, executionTime time.Duration) error {
 	applied := &MigrationVersion{
 		Version:       version,
 		Description:   description,
+		Checksum:      checksum,
 		Success:       success,
 		InstalledOn:   installedOn,
 		ExecutionTime: executionTime,
 	}
-	result := v.db.WithContext(ctx).Save(applied)
+	result := dbWithContext(ctx, v.db).Save(applied)
+	return result.Error
+}
+
+func (v *GormVersioner) DeleteAppliedMigration(ctx context.Context, version Version) error {
+	result := dbWithContext(ctx, v.db).Delete(&MigrationVersion{Version: version})
 	return result.Error
-}
\ No newline at end of file
+}
+
+func (v *GormVersioner) UpdateChecksum(ctx context.Context, version Version, checksum string) error {
+	result := dbWithContext(ctx, v.db).Model(&MigrationVersion{Version: version}).Update("checksum", checksum)
+	return result.Error
+}
+
+func (v *GormVersioner) UpdateDownChecksum(ctx context.Context, version Version, checksum string) error {
+	result := dbWithContext(ctx, v.db).Model(&MigrationVersion{Version: version}).Update("down_checksum", checksum)
+	return result.Error
+}
+
+// SupportsTransaction returns GormVersionerOption.Transactional if set,
+// otherwise true for dialects that support DDL within transactions (postgres and cockroach)
+func (v *GormVersioner) SupportsTransaction() bool {
+	if v.transactional != nil {
+		return *v.transactional
+	}
+	if v.db == nil || v.db.Dialector == nil {
+		return false
+	}
+	switch v.db.Dialector.Name() {
+	case "postgres", "cockroach":
+		return true
+	default:
+		return false
+	}
+}
+
+func (v *GormVersioner) Transaction(ctx context.Context, fn MigrationFunc) error {
+	return v.db.WithContext(ctx).Transaction(func(txDB *gorm.DB) error {
+		return fn(tx.NewGormTxContext(ctx, txDB))
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/dsync"
+	"gorm.io/gorm"
+	"hash/fnv"
+)
+
+const (
+	lockKeyFormat = "service/%s/migration"
+	// sqlStateUndefinedFunction is returned by postgres-compatible databases without advisory lock support
+	sqlStateUndefinedFunction = "42883"
+)
+
+// runLock prevents multiple instances from running migration at the same time.
+// dsync.Lock satisfies this interface
+type runLock interface {
+	Lock(ctx context.Context) error
+	Release() error
+}
+
+// newRunLock returns a runLock based on available infrastructure:
+//   - dsync.Lock if dsync.SyncManager is available
+//   - session-level advisory lock if the database is postgres
+//   - noop lock otherwise
+func newRunLock(appCtx *bootstrap.ApplicationContext, props LockProperties, syncManager dsync.SyncManager, db *gorm.DB) (runLock, error) {
+	key := fmt.Sprintf(lockKeyFormat, appCtx.Name())
+	switch {
+	case !props.Enabled:
+		return noopLock{}, nil
+	case syncManager != nil:
+		return syncManager.Lock(key, func(opt *dsync.LockOption) {
+			opt.Valuer = dsync.NewJsonLockValuer(map[string]interface{}{
+				"service": appCtx.Name(),
+				"mode":    modeFlag,
+				"build":   bootstrap.BuildInfoMap,
+			})
+		})
+	case db != nil && db.Dialector != nil && db.Dialector.Name() == "postgres":
+		return newAdvisoryLock(db, key), nil
+	default:
+		logger.WithContext(appCtx).Warnf("Migration lock is not available. Provide dsync.SyncManager (e.g. consuldsync.Use()) to prevent concurrent migrations")
+		return noopLock{}, nil
+	}
+}
+
+type noopLock struct{}
+
+func (noopLock) Lock(_ context.Context) error {
+	return nil
+}
+
+func (noopLock) Release() error {
+	return nil
+}
+
+// advisoryLock is a postgres session-level advisory lock. The lock is held by a dedicated connection until released.
+type advisoryLock struct {
+	db   *gorm.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func newAdvisoryLock(db *gorm.DB, key string) *advisoryLock {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return &advisoryLock{
+		db:  db,
+		key: int64(h.Sum64()),
+	}
+}
+
+// Lock blocks until the advisory lock is acquired or context is done.
+// Some postgres-compatible databases (e.g. cockroach) don't support advisory locks, in such case (undefined function),
+// a warning is logged and the migration proceeds without lock. Any other error is returned.
+func (l *advisoryLock) Lock(ctx context.Context) error {
+	if l.conn != nil {
+		return nil
+	}
+	sqlDB, e := l.db.DB()
+	if e != nil {
+		return e
+	}
+	conn, e := sqlDB.Conn(ctx)
+	if e != nil {
+		return e
+	}
+	if _, e = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, l.key); e != nil {
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var sqlErr errWithSQLState
+		if !errors.As(e, &sqlErr) || sqlErr.SQLState() != sqlStateUndefinedFunction {
+			return fmt.Errorf("unable to acquire migration advisory lock: %v", e)
+		}
+		logger.WithContext(ctx).Warnf("Migration advisory lock is not supported by the database: %v. "+
+			"Provide dsync.SyncManager (e.g. consuldsync.Use()) to prevent concurrent migrations", e)
+		return nil
+	}
+	l.conn = conn
+	return nil
+}
+
+// errWithSQLState is implemented by pgx (pgconn.PgError) and lib/pq
+type errWithSQLState interface {
+	SQLState() string
+}
+
+func (l *advisoryLock) Release() error {
+	if l.conn == nil {
+		return nil
+	}
+	defer func() {
+		_ = l.conn.Close()
+		l.conn = nil
+	}()
+	_, e := l.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, l.key)
+	return e
+}
@@ -0,0 +1,324 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/mocks/dsyncmock"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*************************
+	Setup Test
+ *************************/
+
+const (
+	testLockDriverName     = "migration-lock-test"
+	testLockDSN            = "advisory-lock"
+	testLockDSNUnsupported = "unsupported"
+	testLockDSNBroken      = "broken"
+)
+
+var testAdvisoryLocks = &mockAdvisoryLocks{}
+
+func init() {
+	sql.Register(testLockDriverName, mockLockDriver{locks: testAdvisoryLocks})
+}
+
+/*************************
+	Tests
+ *************************/
+
+func TestRunLock(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestDisabledLock(), "TestDisabledLock"),
+		test.GomegaSubTest(SubTestDsyncLock(), "TestDsyncLock"),
+		test.GomegaSubTest(SubTestNonPostgresLock(), "TestNonPostgresLock"),
+		test.GomegaSubTest(SubTestAdvisoryLockAcquireAndRelease(), "TestAdvisoryLockAcquireAndRelease"),
+		test.GomegaSubTest(SubTestAdvisoryLockConcurrentRunners(), "TestAdvisoryLockConcurrentRunners"),
+		test.GomegaSubTest(SubTestAdvisoryLockUnsupported(), "TestAdvisoryLockUnsupported"),
+		test.GomegaSubTest(SubTestAdvisoryLockError(), "TestAdvisoryLockError"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestDisabledLock() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		db := NewTestLockDB(g, testLockDSN)
+		lock, e := newRunLock(bootstrap.NewApplicationContext(), LockProperties{Enabled: false}, dsyncmock.SimpleSyncManagerMock{}, db)
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		g.Expect(lock).To(BeAssignableToTypeOf(noopLock{}), "lock should be noop when disabled")
+	}
+}
+
+func SubTestDsyncLock() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		db := NewTestLockDB(g, testLockDSN)
+		lock, e := newRunLock(bootstrap.NewApplicationContext(), LockProperties{Enabled: true}, dsyncmock.SimpleSyncManagerMock{}, db)
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		g.Expect(lock).To(BeAssignableToTypeOf(&dsyncmock.AlwaysLockMock{}), "lock should be dsync lock when SyncManager is available")
+		g.Expect(lock.(*dsyncmock.AlwaysLockMock).Key()).To(Equal("service/lanai/migration"), "lock key should be correct")
+	}
+}
+
+func SubTestNonPostgresLock() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		appCtx := bootstrap.NewApplicationContext()
+		props := LockProperties{Enabled: true}
+		lock, e := newRunLock(appCtx, props, nil, nil)
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		g.Expect(lock).To(BeAssignableToTypeOf(noopLock{}), "lock should be noop without database")
+
+		db, e := gorm.Open(nonPostgresDialector{Dialector: newTestLockDialector(testLockDSN)}, &gorm.Config{})
+		g.Expect(e).To(Succeed(), "opening database should not fail")
+		lock, e = newRunLock(appCtx, props, nil, db)
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		g.Expect(lock).To(BeAssignableToTypeOf(noopLock{}), "lock should be noop for non-postgres database")
+		g.Expect(lock.Lock(ctx)).To(Succeed(), "noop lock should not fail")
+		g.Expect(lock.Release()).To(Succeed(), "noop lock should not fail")
+	}
+}
+
+func SubTestAdvisoryLockAcquireAndRelease() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		db := NewTestLockDB(g, testLockDSN)
+		lock, e := newRunLock(bootstrap.NewApplicationContext(), LockProperties{Enabled: true}, nil, db)
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		g.Expect(lock).To(BeAssignableToTypeOf(&advisoryLock{}), "lock should be advisory lock for postgres")
+		key := lock.(*advisoryLock).key
+
+		g.Expect(lock.Lock(ctx)).To(Succeed(), "acquiring lock should not fail")
+		g.Expect(testAdvisoryLocks.IsHeld(key)).To(BeTrue(), "advisory lock should be held")
+		g.Expect(lock.Lock(ctx)).To(Succeed(), "acquiring lock again should not fail")
+		g.Expect(lock.Release()).To(Succeed(), "releasing lock should not fail")
+		g.Expect(testAdvisoryLocks.IsHeld(key)).To(BeFalse(), "advisory lock should be released")
+		g.Expect(lock.Release()).To(Succeed(), "releasing lock again should not fail")
+	}
+}
+
+func SubTestAdvisoryLockConcurrentRunners() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		appCtx := bootstrap.NewApplicationContext()
+		props := LockProperties{Enabled: true}
+		lock1, e := newRunLock(appCtx, props, nil, NewTestLockDB(g, testLockDSN))
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		lock2, e := newRunLock(appCtx, props, nil, NewTestLockDB(g, testLockDSN))
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+
+		g.Expect(lock1.Lock(ctx)).To(Succeed(), "acquiring lock by first runner should not fail")
+		timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		e = lock2.Lock(timeoutCtx)
+		g.Expect(errors.Is(e, context.DeadlineExceeded)).To(BeTrue(), "second runner should time out while lock is held, but got %v", e)
+
+		acquired := make(chan error, 1)
+		go func() {
+			acquired <- lock2.Lock(ctx)
+		}()
+		g.Consistently(acquired, 100*time.Millisecond).ShouldNot(Receive(), "second runner should wait while lock is held")
+		g.Expect(lock1.Release()).To(Succeed(), "releasing lock by first runner should not fail")
+		g.Eventually(acquired, time.Second).Should(Receive(BeNil()), "second runner should acquire lock after released")
+		g.Expect(lock2.Release()).To(Succeed(), "releasing lock by second runner should not fail")
+	}
+}
+
+func SubTestAdvisoryLockUnsupported() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		db := NewTestLockDB(g, testLockDSNUnsupported)
+		lock, e := newRunLock(bootstrap.NewApplicationContext(), LockProperties{Enabled: true}, nil, db)
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		g.Expect(lock.Lock(ctx)).To(Succeed(), "migration should proceed without lock when advisory lock is not supported")
+		g.Expect(lock.Release()).To(Succeed(), "releasing lock should not fail")
+	}
+}
+
+func SubTestAdvisoryLockError() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		db := NewTestLockDB(g, testLockDSNBroken)
+		lock, e := newRunLock(bootstrap.NewApplicationContext(), LockProperties{Enabled: true}, nil, db)
+		g.Expect(e).To(Succeed(), "creating lock should not fail")
+		g.Expect(lock.Lock(ctx)).To(HaveOccurred(), "migration should not proceed when advisory lock fails for other reasons")
+		g.Expect(lock.Release()).To(Succeed(), "releasing lock should not fail")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func NewTestLockDB(g *gomega.WithT, dsn string) *gorm.DB {
+	db, e := gorm.Open(newTestLockDialector(dsn), &gorm.Config{})
+	g.Expect(e).To(Succeed(), "opening database should not fail")
+	return db
+}
+
+func newTestLockDialector(dsn string) postgres.Dialector {
+	return postgres.Dialector{Config: &postgres.Config{DriverName: testLockDriverName, DSN: dsn}}
+}
+
+type nonPostgresDialector struct {
+	postgres.Dialector
+}
+
+func (nonPostgresDialector) Name() string {
+	return "sqlite"
+}
+
+// mockAdvisoryLocks simulates postgres session-level advisory locks shared by all connections
+type mockAdvisoryLocks struct {
+	mtx     sync.Mutex
+	holders map[int64]*mockLockConn
+	waits   map[int64]chan struct{}
+}
+
+func (l *mockAdvisoryLocks) Acquire(ctx context.Context, key int64, conn *mockLockConn) error {
+	for {
+		l.mtx.Lock()
+		if l.holders == nil {
+			l.holders = map[int64]*mockLockConn{}
+			l.waits = map[int64]chan struct{}{}
+		}
+		if holder, ok := l.holders[key]; !ok || holder == conn {
+			l.holders[key] = conn
+			l.mtx.Unlock()
+			return nil
+		}
+		wait, ok := l.waits[key]
+		if !ok {
+			wait = make(chan struct{})
+			l.waits[key] = wait
+		}
+		l.mtx.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *mockAdvisoryLocks) Release(key int64, conn *mockLockConn) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if holder, ok := l.holders[key]; !ok || holder != conn {
+		return false
+	}
+	delete(l.holders, key)
+	if wait, ok := l.waits[key]; ok {
+		close(wait)
+		delete(l.waits, key)
+	}
+	return true
+}
+
+func (l *mockAdvisoryLocks) ReleaseAll(conn *mockLockConn) {
+	l.mtx.Lock()
+	var keys []int64
+	for k, holder := range l.holders {
+		if holder == conn {
+			keys = append(keys, k)
+		}
+	}
+	l.mtx.Unlock()
+	for _, k := range keys {
+		l.Release(k, conn)
+	}
+}
+
+func (l *mockAdvisoryLocks) IsHeld(key int64) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	_, ok := l.holders[key]
+	return ok
+}
+
+type mockLockDriver struct {
+	locks *mockAdvisoryLocks
+}
+
+func (d mockLockDriver) Open(dsn string) (driver.Conn, error) {
+	return &mockLockConn{locks: d.locks, unsupported: dsn == testLockDSNUnsupported, broken: dsn == testLockDSNBroken}, nil
+}
+
+// mockSQLError mimics errors with SQLSTATE returned by postgres drivers
+type mockSQLError struct {
+	code string
+	msg  string
+}
+
+func (e mockSQLError) Error() string {
+	return e.msg
+}
+
+func (e mockSQLError) SQLState() string {
+	return e.code
+}
+
+// mockLockConn supports advisory lock statements only
+type mockLockConn struct {
+	locks       *mockAdvisoryLocks
+	unsupported bool
+	broken      bool
+}
+
+func (c *mockLockConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case c.unsupported || len(args) != 1:
+		return nil, mockSQLError{code: "42883", msg: "unknown function"}
+	case c.broken:
+		return nil, mockSQLError{code: "57014", msg: "canceling statement due to statement timeout"}
+	}
+	key, _ := args[0].Value.(int64)
+	switch {
+	case strings.Contains(query, "pg_advisory_lock("):
+		if e := c.locks.Acquire(ctx, key, c); e != nil {
+			return nil, e
+		}
+	case strings.Contains(query, "pg_advisory_unlock("):
+		c.locks.Release(key, c)
+	default:
+		return nil, errors.New("unsupported statement")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *mockLockConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statement is not supported")
+}
+
+func (c *mockLockConn) Close() error {
+	c.locks.ReleaseAll(c)
+	return nil
+}
+
+func (c *mockLockConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transaction is not supported")
+}
@@ -43,6 +43,20 @@ type Migration struct {
 	Description string
 	Func		MigrationFunc
 	Tags        utils.StringSet
+	// Checksum is recorded along with the applied migration step and used to detect modification of applied steps.
+	// It's calculated automatically when the step is loaded via WithFile. Empty checksum disables such detection.
+	Checksum    string
+	// Source describes where the step is defined, e.g. SQL file path or Go function name. Used for displaying plan.
+	Source      string
+	// DownFunc reverts changes made by Func. Optional, but required for rolling back the step.
+	DownFunc    MigrationFunc
+	// DownSource describes where the down migration is defined. Similar to Source
+	DownSource  string
+	// DownChecksum is similar to Checksum, but for DownFunc. It's calculated automatically when loaded via WithDownFile.
+	DownChecksum string
+	// NonTransactional disables transactional execution of this step, even if the Versioner supports it.
+	// Useful for statements that cannot run within a transaction, e.g. "CREATE INDEX CONCURRENTLY"
+	NonTransactional bool
 }
 
 func WithVersion(version string) *Migration {
@@ -70,16 +84,45 @@ func (m *Migration) WithTag(tags...string) *Migration {
 }
 
 func (m *Migration) WithFile(fs fs.FS, filePath string, db *gorm.DB) *Migration {
-	m.Func = migrationFuncFromTextFile(fs, filePath, db)
+	m.Func, m.Checksum = migrationFuncFromTextFile(fs, filePath, db)
+	m.Source = filePath
 	return m
 }
 
 func (m *Migration) WithFunc(f MigrationFunc) *Migration {
 	m.Func = f
+	m.Source = funcName(f)
 	return m
 }
 
 func (m *Migration) WithDesc(d string) *Migration {
 	m.Description = d
 	return m
-}
\ No newline at end of file
+}
+
+// WithDownFile set the down migration of the step from a SQL file. Statements are executed in the same way as WithFile
+func (m *Migration) WithDownFile(fs fs.FS, filePath string, db *gorm.DB) *Migration {
+	m.DownFunc, m.DownChecksum = migrationFuncFromTextFile(fs, filePath, db)
+	m.DownSource = filePath
+	return m
+}
+
+// WithDownFunc set the down migration of the step
+func (m *Migration) WithDownFunc(f MigrationFunc) *Migration {
+	m.DownFunc = f
+	m.DownSource = funcName(f)
+	return m
+}
+
+// WithoutTransaction disables transactional execution of the step. See Migration.NonTransactional
+func (m *Migration) WithoutTransaction() *Migration {
+	m.NonTransactional = true
+	return m
+}
+
+// WithChecksum explicitly set the checksum of the migration step.
+// This is useful for steps defined via WithFunc, where content modification cannot be detected automatically.
+func (m *Migration) WithChecksum(checksum string) *Migration {
+	m.Checksum = checksum
+	return m
+}
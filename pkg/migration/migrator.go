@@ -26,28 +26,57 @@ import (
 )
 
 func Migrate(ctx context.Context, r *Registrar, v Versioner) error {
-	err := v.CreateVersionTableIfNotExist(ctx)
+	if err := v.CreateVersionTableIfNotExist(ctx); err != nil {
+		return err
+	}
+	appliedMigrations, err := loadAppliedMigrations(ctx, v)
 	if err != nil {
 		return err
 	}
-
-	//sort registered migration steps
-	sort.SliceStable(r.migrationSteps, func(i, j int) bool {return r.migrationSteps[i].Version.Lt(r.migrationSteps[j].Version)})
-
-	appliedMigrations, err := v.GetAppliedMigrations(ctx)
+	steps, err := plan(ctx, r, appliedMigrations)
 	if err != nil {
 		return err
 	}
+	for _, s := range steps {
+		if err = executeMigrationStep(ctx, s, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	//sort applied migration steps
-	sort.SliceStable(appliedMigrations, func (i, j int) bool {return appliedMigrations[i].GetVersion().Lt(appliedMigrations[j].GetVersion())})
+// Plan returns ordered migration steps that would be executed by Migrate, without executing any of them.
+// Checksums of applied migration steps are validated in the same way as Migrate.
+// Plan doesn't create the version table. See InspectableVersioner.
+func Plan(ctx context.Context, r *Registrar, v Versioner) ([]*Migration, error) {
+	appliedMigrations, err := loadAppliedMigrationsReadOnly(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return plan(ctx, r, appliedMigrations)
+}
 
+// plan returns ordered pending migration steps based on given applied migration steps, sorted by version.
+func plan(ctx context.Context, r *Registrar, appliedMigrations []AppliedMigration) ([]*Migration, error) {
+	sortMigrationSteps(r)
 	for _, a := range appliedMigrations {
 		if !a.IsSuccess() {
-			return errors.New(fmt.Sprintf("stopping migration because there is a failed migration step: %s", a.GetVersion().String()))
+			return nil, errors.New(fmt.Sprintf("stopping migration because there is a failed migration step: %s", a.GetVersion().String()))
 		}
 	}
 
+	if errs := validateChecksums(r, appliedMigrations); len(errs) != 0 {
+		return nil, newValidationError(errs)
+	}
+	// Note: steps skipped due to out-of-order are not considered as failure here, to keep Migrate's original behavior.
+	// They are reported by Validate.
+	for _, msg := range validateOrder(r, appliedMigrations) {
+		logger.WithContext(ctx).Warnf("%s, it will be skipped unless running with --allow_out_of_order", msg)
+	}
+	for _, msg := range validateRegistered(r, appliedMigrations) {
+		logger.WithContext(ctx).Warnf("%s, it might have been removed or renamed", msg)
+	}
+
 	var shouldExecuteMigration func(*Migration) bool
 
 	if allowOutOfOrderFlag {
@@ -68,32 +97,108 @@ func Migrate(ctx context.Context, r *Registrar, v Versioner) error {
 		}
 	}
 
+	var steps []*Migration
 	for _, s := range r.migrationSteps {
 		if filterFlag != "" && !s.Tags.Has(filterFlag) {
 			continue
 		}
-		//TODO: should the migration func and recording the version be put in one transaction?
 		if shouldExecuteMigration(s) {
-			logger.Infof("Executing migration step %s: %s", s.Version.String(), s.Description)
-			startTime := time.Now()
-			migrationErr := s.Func(ctx) //TODO: manual rollback function?
-			finishTime := time.Now()
-			duration := finishTime.Sub(startTime)
-			if migrationErr != nil {
-				err = v.RecordAppliedMigration(ctx, s.Version, s.Description, false, finishTime, duration)
-				if err != nil {
-					logger.Errorf("error recording failed migration version due to %v", err)
-				}
-				err = errors.New(fmt.Sprintf("migration stopped at step %v because of error: %v", s.Version, migrationErr))
-				logger.Errorf("%v", err)
-				return err
-			} else {
-				err = v.RecordAppliedMigration(ctx, s.Version, s.Description, true, finishTime, duration)
-				if err != nil {
-					return err
-				}
-			}
+			steps = append(steps, s)
 		}
 	}
-	return nil
+	return steps, nil
+}
+
+// executeMigrationStep executes the migration step and records its result.
+// When supported by the Versioner, the step and the success record are committed atomically.
+// Failed step is recorded outside the transaction, so it would remain in the history until repaired.
+func executeMigrationStep(ctx context.Context, s *Migration, v Versioner) error {
+	logger.Infof("Executing migration step %s: %s", s.Version.String(), s.Description)
+	var migrationErr error
+	startTime := time.Now()
+	err := doInTransaction(ctx, s, v, func(ctx context.Context) error {
+		if migrationErr = s.Func(ctx); migrationErr != nil {
+			return migrationErr
+		}
+		finishTime := time.Now()
+		return recordAppliedMigration(ctx, v, s, true, finishTime, finishTime.Sub(startTime))
+	})
+	if migrationErr == nil {
+		return err
+	}
+
+	finishTime := time.Now()
+	err = recordAppliedMigration(ctx, v, s, false, finishTime, finishTime.Sub(startTime))
+	if err != nil {
+		logger.Errorf("error recording failed migration version due to %v", err)
+	}
+	err = errors.New(fmt.Sprintf("migration stopped at step %v because of error: %v", s.Version, migrationErr))
+	logger.Errorf("%v", err)
+	return err
+}
+
+// recordAppliedMigration records the result of migration step, with its checksums if the Versioner supports it.
+func recordAppliedMigration(ctx context.Context, v Versioner, s *Migration, success bool, installedOn time.Time, executionTime time.Duration) error {
+	var err error
+	if cv, ok := v.(ChecksumVersioner); ok {
+		err = cv.RecordAppliedMigrationWithChecksum(ctx, s.Version, s.Description, s.Checksum, success, installedOn, executionTime)
+	} else {
+		err = v.RecordAppliedMigration(ctx, s.Version, s.Description, success, installedOn, executionTime)
+	}
+	if dv, ok := v.(DownChecksumVersioner); err == nil && ok && success && s.DownChecksum != "" {
+		err = dv.UpdateDownChecksum(ctx, s.Version, s.DownChecksum)
+	}
+	return err
+}
+
+// appliedChecksum returns the recorded checksum of applied migration step, or empty string if not supported.
+func appliedChecksum(a AppliedMigration) string {
+	if ca, ok := a.(ChecksumAppliedMigration); ok {
+		return ca.GetChecksum()
+	}
+	return ""
+}
+
+// appliedDownChecksum returns the recorded down migration checksum of applied migration step, or empty string if not supported.
+func appliedDownChecksum(a AppliedMigration) string {
+	if ca, ok := a.(DownChecksumAppliedMigration); ok {
+		return ca.GetDownChecksum()
+	}
+	return ""
+}
+
+// doInTransaction executes given function within a transaction if the Versioner supports it and the step allows it.
+func doInTransaction(ctx context.Context, s *Migration, v Versioner, fn MigrationFunc) error {
+	if txv, ok := v.(TransactionalVersioner); ok && txv.SupportsTransaction() && !s.NonTransactional {
+		return txv.Transaction(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+func sortMigrationSteps(r *Registrar) {
+	sort.SliceStable(r.migrationSteps, func(i, j int) bool { return r.migrationSteps[i].Version.Lt(r.migrationSteps[j].Version) })
+}
+
+// loadAppliedMigrations returns applied migration steps sorted by version
+func loadAppliedMigrations(ctx context.Context, v Versioner) ([]AppliedMigration, error) {
+	appliedMigrations, err := v.GetAppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(appliedMigrations, func(i, j int) bool { return appliedMigrations[i].GetVersion().Lt(appliedMigrations[j].GetVersion()) })
+	return appliedMigrations, nil
+}
+
+// loadAppliedMigrationsReadOnly is same as loadAppliedMigrations, without creating the version table.
+// If the Versioner supports InspectableVersioner, missing version table is treated as no migration step is applied.
+func loadAppliedMigrationsReadOnly(ctx context.Context, v Versioner) ([]AppliedMigration, error) {
+	if iv, ok := v.(InspectableVersioner); ok {
+		switch exists, e := iv.VersionTableExists(ctx); {
+		case e != nil:
+			return nil, e
+		case !exists:
+			return nil, nil
+		}
+	}
+	return loadAppliedMigrations(ctx, v)
 }
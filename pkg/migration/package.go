@@ -21,10 +21,13 @@ import (
 	"fmt"
 	"github.com/cisco-open/go-lanai/pkg/bootstrap"
 	"github.com/cisco-open/go-lanai/pkg/data"
+	"github.com/cisco-open/go-lanai/pkg/dsync"
 	"github.com/cisco-open/go-lanai/pkg/log"
 	"github.com/cisco-open/go-lanai/pkg/utils/order"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
+	"os"
+	"time"
 )
 
 const (
@@ -32,17 +35,32 @@ const (
 	TagPostUpgrade = "post_upgrade"
 )
 
+const (
+	// ModeMigrate validates applied migration steps and then executes pending ones. This is the default mode
+	ModeMigrate = "migrate"
+	// ModeValidate only validates applied migration steps against registered ones without executing anything
+	ModeValidate = "validate"
+	// ModeRepair removes failed migration records and realigns checksums of applied migration steps
+	ModeRepair = "repair"
+	// ModeDown rolls back applied migration steps down to the version specified by "--to" flag
+	ModeDown = "down"
+)
+
 var logger = log.New("Migration")
 
 var filterFlag string
 var allowOutOfOrderFlag bool
+var modeFlag string
+var targetVersionFlag string
+var dryRunFlag bool
 
 var Module = &bootstrap.Module{
 	Name:       "migration",
 	Precedence: bootstrap.CommandLineRunnerPrecedence,
 	Options: []fx.Option{
+		fx.Provide(BindMigrationProperties),
 		fx.Provide(NewRegistrar),
-		fx.Provide(NewGormVersioner),
+		fx.Provide(provideGormVersioner),
 		fx.Provide(provideMigrationRunner()),
 	},
 }
@@ -50,11 +68,20 @@ var Module = &bootstrap.Module{
 func Use() {
 	bootstrap.AddStringFlag(&filterFlag, "filter", "", fmt.Sprintf("filter the migration steps by tag value. supports %s or %s", TagPreUpgrade, TagPostUpgrade))
 	bootstrap.AddBoolFlag(&allowOutOfOrderFlag, "allow_out_of_order", false, fmt.Sprintf("allow migration steps to execute out of order"))
+	bootstrap.AddStringFlag(&modeFlag, "mode", ModeMigrate, fmt.Sprintf("migration mode. supports %s, %s, %s or %s", ModeMigrate, ModeValidate, ModeRepair, ModeDown))
+	bootstrap.AddStringFlag(&targetVersionFlag, "to", "", fmt.Sprintf("target version when mode is %s. applied migration steps with higher versions are rolled back", ModeDown))
+	bootstrap.AddBoolFlag(&dryRunFlag, "dry_run", false, fmt.Sprintf("print the ordered plan of migration steps without executing them. supports mode %s and %s", ModeMigrate, ModeDown))
 	bootstrap.Register(Module)
 	// Note: migration CliRunner is provided in Module
 	bootstrap.EnableCliRunnerMode()
 }
 
+func provideGormVersioner(db *gorm.DB, props MigrationProperties) Versioner {
+	return NewGormVersioner(db, func(opt *GormVersionerOption) {
+		opt.Transactional = props.Transactional
+	})
+}
+
 func provideMigrationRunner() fx.Annotated {
 	return fx.Annotated{
 		Group:  bootstrap.FxCliRunnerGroup,
@@ -64,10 +91,14 @@ func provideMigrationRunner() fx.Annotated {
 
 type migrationRunnerIn struct {
 	fx.In
-	R          *Registrar
-	V          Versioner
-	DB         *gorm.DB
-	DbCreators []data.DbCreator `group:"gorm_config"`
+	AppCtx               *bootstrap.ApplicationContext
+	Props                MigrationProperties
+	R                    *Registrar
+	V                    Versioner
+	DB                   *gorm.DB
+	DbCreators           []data.DbCreator    `group:"gorm_config"`
+	SyncManager          dsync.SyncManager   `optional:"true"`
+	SyncManagerOverrides []dsync.SyncManager `group:"dsync"`
 }
 
 func newMigrationRunner(di migrationRunnerIn) bootstrap.CliRunner {
@@ -79,6 +110,90 @@ func newMigrationRunner(di migrationRunnerIn) bootstrap.CliRunner {
 				return err
 			}
 		}
-		return Migrate(ctx, di.R, di.V)
+
+		// read-only modes, nothing is created or modified, including the version table
+		switch {
+		case modeFlag == ModeValidate:
+			return Validate(ctx, di.R, di.V)
+		case dryRunFlag:
+			return dryRun(ctx, di)
+		}
+
+		// modes that modify the database, the version table is created while holding the lock
+		lock, e := newRunLock(di.AppCtx, di.Props.Lock, syncManager(di), di.DB)
+		if e != nil {
+			return e
+		}
+		defer func() {
+			if e := lock.Release(); e != nil {
+				logger.WithContext(ctx).Warnf("failed to release migration lock: %v", e)
+			}
+		}()
+		lockCtx := ctx
+		if di.Props.Lock.Timeout > 0 {
+			var cancel context.CancelFunc
+			lockCtx, cancel = context.WithTimeout(ctx, time.Duration(di.Props.Lock.Timeout))
+			defer cancel()
+		}
+		if e := lock.Lock(lockCtx); e != nil {
+			return fmt.Errorf("unable to acquire migration lock: %v", e)
+		}
+
+		switch modeFlag {
+		case ModeRepair:
+			return Repair(ctx, di.R, di.V)
+		case ModeDown:
+			target, e := parseTargetVersion()
+			if e != nil {
+				return e
+			}
+			return MigrateDown(ctx, di.R, di.V, target)
+		case ModeMigrate, "":
+			return Migrate(ctx, di.R, di.V)
+		default:
+			return fmt.Errorf("unsupported migration mode [%s]", modeFlag)
+		}
+	}
+}
+
+// dryRun prints the migration plan to stdout without executing any migration steps
+func dryRun(ctx context.Context, di migrationRunnerIn) error {
+	switch modeFlag {
+	case ModeDown:
+		target, e := parseTargetVersion()
+		if e != nil {
+			return e
+		}
+		steps, e := PlanDown(ctx, di.R, di.V, target)
+		if e != nil {
+			return e
+		}
+		return PrintDownPlan(os.Stdout, target, steps)
+	case ModeMigrate, "":
+		steps, e := Plan(ctx, di.R, di.V)
+		if e != nil {
+			return e
+		}
+		return PrintPlan(os.Stdout, steps)
+	default:
+		return fmt.Errorf("dry run is not supported for migration mode [%s]", modeFlag)
+	}
+}
+
+func parseTargetVersion() (Version, error) {
+	if targetVersionFlag == "" {
+		return nil, fmt.Errorf("target version is required for mode %s, use --to flag to specify it", ModeDown)
+	}
+	target, e := fromString(targetVersionFlag)
+	if e != nil {
+		return nil, fmt.Errorf("invalid target version [%s]: %v", targetVersionFlag, e)
+	}
+	return target, nil
+}
+
+func syncManager(di migrationRunnerIn) dsync.SyncManager {
+	if len(di.SyncManagerOverrides) != 0 {
+		return di.SyncManagerOverrides[0]
 	}
+	return di.SyncManager
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/cisco-open/go-lanai/test"
 	"github.com/cisco-open/go-lanai/test/apptest"
 	"github.com/cisco-open/go-lanai/test/dbtest"
+	"github.com/cisco-open/go-lanai/test/mocks/dsyncmock"
 	"github.com/onsi/gomega"
 	. "github.com/onsi/gomega"
 	"go.uber.org/fx"
@@ -95,6 +96,7 @@ func TestModuleInit(t *testing.T) {
 			fx.Provide(cockroach.NewGormDbCreator),
 			fx.Provide(migration.DefaultGormConfigurerProvider()),
 			fx.Provide(ProvideMigrationTableDropper()),
+			fx.Provide(dsyncmock.ProvideNoopSyncManager),
 			fx.Invoke(RegisterSimpleMigrationStep),
 		),
 		apptest.WithDI(&di),
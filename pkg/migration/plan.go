@@ -0,0 +1,54 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// PrintPlan writes human-readable plan of given migration steps, as returned by Plan, to the writer.
+func PrintPlan(w io.Writer, steps []*Migration) error {
+	header := fmt.Sprintf("Migration plan: %d pending step(s)", len(steps))
+	return printPlan(w, header, steps, func(s *Migration) string { return s.Source })
+}
+
+// PrintDownPlan writes human-readable plan of given migration steps, as returned by PlanDown, to the writer.
+func PrintDownPlan(w io.Writer, target Version, steps []*Migration) error {
+	header := fmt.Sprintf("Rollback plan to version %s: %d step(s) to roll back", target, len(steps))
+	return printPlan(w, header, steps, func(s *Migration) string { return s.DownSource })
+}
+
+func printPlan(w io.Writer, header string, steps []*Migration, sourceFn func(s *Migration) string) error {
+	if _, e := fmt.Fprintln(w, header); e != nil || len(steps) == 0 {
+		return e
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "#\tVERSION\tTAGS\tDESCRIPTION\tSOURCE\tTRANSACTIONAL")
+	for i, s := range steps {
+		var tags []string
+		if s.Tags != nil {
+			tags = s.Tags.Values()
+			sort.Strings(tags)
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%t\n", i+1, s.Version, strings.Join(tags, ","), s.Description, sourceFn(s), !s.NonTransactional)
+	}
+	return tw.Flush()
+}
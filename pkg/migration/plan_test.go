@@ -0,0 +1,208 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/migration"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"testing"
+	"testing/fstest"
+)
+
+/*************************
+	Tests
+ *************************/
+
+func TestPlan(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestPlan(), "TestPlan"),
+		test.GomegaSubTest(SubTestPlanWithValidationError(), "TestPlanWithValidationError"),
+		test.GomegaSubTest(SubTestMigrateSkipsOutOfOrderStep(), "TestMigrateSkipsOutOfOrderStep"),
+		test.GomegaSubTest(SubTestPlanDown(), "TestPlanDown"),
+		test.GomegaSubTest(SubTestReadOnlyWithoutVersionTable(), "TestReadOnlyWithoutVersionTable"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestPlan() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		fs := fstest.MapFS{
+			"create_table.sql": &fstest.MapFile{Data: []byte("CREATE TABLE t (id text);")},
+		}
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.3").WithTag(migration.TagPostUpgrade).WithDesc("seed data").WithFunc(FailingMigrationFunc),
+			migration.WithVersion("1.0.0.1").WithTag(migration.TagPreUpgrade).WithDesc("applied").WithFunc(FailingMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithTag(migration.TagPreUpgrade).WithDesc("create table").
+				WithFile(fs, "create_table.sql", nil).WithoutTransaction(),
+		)
+		steps, e := migration.Plan(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "plan should not fail")
+		g.Expect(steps).To(HaveLen(2), "plan should contain pending steps only")
+		g.Expect(steps[0].Version.String()).To(Equal("1.0.0.2"), "plan should be ordered")
+		g.Expect(steps[1].Version.String()).To(Equal("1.0.0.3"), "plan should be ordered")
+		g.Expect(ver.records).To(HaveLen(1), "plan should not execute any step")
+
+		var buf bytes.Buffer
+		e = migration.PrintPlan(&buf, steps)
+		g.Expect(e).To(Succeed(), "printing plan should not fail")
+		out := buf.String()
+		g.Expect(out).To(ContainSubstring("2 pending step(s)"), "plan output should have summary")
+		g.Expect(out).To(MatchRegexp(`1\s+1\.0\.0\.2\s+pre_upgrade\s+create table\s+create_table\.sql\s+false`), "plan output should have file step")
+		g.Expect(out).To(MatchRegexp(`2\s+1\.0\.0\.3\s+post_upgrade\s+seed data\s+\S+FailingMigrationFunc\(\)\s+true`), "plan output should have function step")
+		g.Expect(out).ToNot(ContainSubstring("applied"), "plan output should not have applied step")
+	}
+}
+
+func SubTestPlanWithValidationError() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "c1", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithChecksum("modified").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithFunc(NoopMigrationFunc),
+		)
+		_, e := migration.Plan(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "plan should fail when validation fails")
+	}
+}
+
+func SubTestMigrateSkipsOutOfOrderStep() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.3", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("out of order").WithFunc(FailingMigrationFunc),
+			migration.WithVersion("1.0.0.3").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.4").WithFunc(NoopMigrationFunc),
+		)
+		steps, e := migration.Plan(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "plan should not fail because of out-of-order step")
+		g.Expect(steps).To(HaveLen(1), "plan should skip out-of-order step")
+		g.Expect(steps[0].Version.String()).To(Equal("1.0.0.4"), "plan should contain higher version")
+
+		e = migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "migration should not fail because of out-of-order step")
+		g.Expect(ver.records).To(HaveKey("1.0.0.4"), "migration should execute pending step")
+		g.Expect(ver.records).ToNot(HaveKey("1.0.0.2"), "migration should skip out-of-order step")
+
+		e = migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "validation should report out-of-order step")
+	}
+}
+
+func SubTestPlanDown() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		fs := fstest.MapFS{
+			"drop_table.sql": &fstest.MapFile{Data: []byte("DROP TABLE t;")},
+		}
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.2", "", true),
+			AppliedVersion("1.0.0.3", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithFunc(NoopMigrationFunc).WithDownFunc(FailingMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("step 2").WithFunc(NoopMigrationFunc).WithDownFile(fs, "drop_table.sql", nil),
+			migration.WithVersion("1.0.0.3").WithDesc("step 3").WithFunc(NoopMigrationFunc).WithDownFunc(FailingMigrationFunc),
+		)
+		target := migration.WithVersion("1.0.0.1").Version
+		steps, e := migration.PlanDown(ctx, reg, ver, target)
+		g.Expect(e).To(Succeed(), "plan should not fail")
+		g.Expect(steps).To(HaveLen(2), "plan should contain steps to roll back")
+		g.Expect(steps[0].Version.String()).To(Equal("1.0.0.3"), "plan should be in descending order")
+		g.Expect(steps[1].Version.String()).To(Equal("1.0.0.2"), "plan should be in descending order")
+		g.Expect(ver.records).To(HaveLen(3), "plan should not execute any step")
+
+		var buf bytes.Buffer
+		e = migration.PrintDownPlan(&buf, target, steps)
+		g.Expect(e).To(Succeed(), "printing plan should not fail")
+		out := buf.String()
+		g.Expect(out).To(ContainSubstring("to version 1.0.0.1: 2 step(s)"), "plan output should have summary")
+		g.Expect(out).To(MatchRegexp(`1\s+1\.0\.0\.3\s+step 3\s+\S+FailingMigrationFunc\(\)`), "plan output should have function step")
+		g.Expect(out).To(MatchRegexp(`2\s+1\.0\.0\.2\s+step 2\s+drop_table\.sql`), "plan output should have file step")
+	}
+}
+
+func SubTestReadOnlyWithoutVersionTable() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := &InspectableInMemoryVersioner{InMemoryVersioner: NewInMemoryVersioner()}
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithFunc(NoopMigrationFunc).WithDownFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithFunc(NoopMigrationFunc).WithDownFunc(NoopMigrationFunc),
+		)
+		steps, e := migration.Plan(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "plan should not fail without version table")
+		g.Expect(steps).To(HaveLen(2), "plan should contain all steps without version table")
+		steps, e = migration.PlanDown(ctx, reg, ver, migration.WithVersion("1.0.0.1").Version)
+		g.Expect(e).To(Succeed(), "down plan should not fail without version table")
+		g.Expect(steps).To(BeEmpty(), "down plan should be empty without version table")
+		e = migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "validation should not fail without version table")
+		g.Expect(ver.tableCreated).To(BeFalse(), "read-only operations should not create version table")
+
+		e = migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "migration should not fail")
+		g.Expect(ver.tableCreated).To(BeTrue(), "migration should create version table")
+		g.Expect(ver.records).To(HaveLen(2), "migration should execute all steps")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+// InspectableInMemoryVersioner tracks existence of version table
+type InspectableInMemoryVersioner struct {
+	*InMemoryVersioner
+	tableCreated bool
+}
+
+func (v *InspectableInMemoryVersioner) CreateVersionTableIfNotExist(_ context.Context) error {
+	v.tableCreated = true
+	return nil
+}
+
+func (v *InspectableInMemoryVersioner) VersionTableExists(_ context.Context) (bool, error) {
+	return v.tableCreated, nil
+}
+
+func (v *InspectableInMemoryVersioner) GetAppliedMigrations(ctx context.Context) ([]migration.AppliedMigration, error) {
+	if !v.tableCreated {
+		return nil, errors.New("version table doesn't exist")
+	}
+	return v.InMemoryVersioner.GetAppliedMigrations(ctx)
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const PropertiesPrefix = "migration"
+
+type MigrationProperties struct {
+	// Transactional overrides whether migration steps are executed within their own transaction together with recording
+	// their result. When not set, it's enabled for databases that support DDL within transactions (postgres and cockroach).
+	// Steps that cannot run within a transaction should opt out via Migration.WithoutTransaction.
+	Transactional *bool          `json:"transactional"`
+	Lock          LockProperties `json:"lock"`
+}
+
+// LockProperties configures the lock that prevents multiple instances from running migration at the same time.
+// dsync.SyncManager is used if available (e.g. consuldsync.Use() or redisdsync.Use()),
+// otherwise a session-level advisory lock is used on postgres.
+type LockProperties struct {
+	Enabled bool `json:"enabled"`
+	// Timeout is the max duration to wait for the lock
+	Timeout utils.Duration `json:"timeout"`
+}
+
+func NewMigrationProperties() *MigrationProperties {
+	return &MigrationProperties{
+		Lock: LockProperties{
+			Enabled: true,
+			Timeout: utils.Duration(10 * time.Minute),
+		},
+	}
+}
+
+func BindMigrationProperties(ctx *bootstrap.ApplicationContext) MigrationProperties {
+	props := NewMigrationProperties()
+	if err := ctx.Config().Bind(props, PropertiesPrefix); err != nil {
+		panic(errors.Wrap(err, "failed to bind MigrationProperties"))
+	}
+	return *props
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MigrateDown rolls back applied migration steps with versions higher than the target version, in descending order.
+// The target version itself is not rolled back. Each step is reverted via its Migration.DownFunc and its record is
+// removed from the history. When supported by the Versioner, both are committed atomically.
+//
+// Before anything is executed, all steps to roll back are verified to be registered with a down migration.
+// The Versioner is required to support RepairableVersioner.
+// Note: tag filter doesn't apply to rollback.
+func MigrateDown(ctx context.Context, r *Registrar, v Versioner, target Version) error {
+	rv, ok := v.(RepairableVersioner)
+	if !ok {
+		return fmt.Errorf("unable to roll back to version %s: %T doesn't support down migration", target, v)
+	}
+	if err := v.CreateVersionTableIfNotExist(ctx); err != nil {
+		return err
+	}
+	appliedMigrations, err := loadAppliedMigrations(ctx, v)
+	if err != nil {
+		return err
+	}
+	steps, err := planDown(r, appliedMigrations, target)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		logger.WithContext(ctx).Infof("No migration step to roll back to version %s", target)
+		return nil
+	}
+
+	for _, s := range steps {
+		logger.WithContext(ctx).Infof("Rolling back migration step %s: %s", s.Version.String(), s.Description)
+		err = doInTransaction(ctx, s, v, func(ctx context.Context) error {
+			if e := s.DownFunc(ctx); e != nil {
+				return e
+			}
+			return rv.DeleteAppliedMigration(ctx, s.Version)
+		})
+		if err != nil {
+			err = fmt.Errorf("rollback stopped at step %v because of error: %v", s.Version, err)
+			logger.WithContext(ctx).Errorf("%v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanDown returns migration steps that would be rolled back by MigrateDown in execution order, without executing any of them.
+// PlanDown doesn't create the version table. See InspectableVersioner.
+func PlanDown(ctx context.Context, r *Registrar, v Versioner, target Version) ([]*Migration, error) {
+	appliedMigrations, err := loadAppliedMigrationsReadOnly(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return planDown(r, appliedMigrations, target)
+}
+
+// planDown returns migration steps to roll back based on given applied migration steps, sorted by version.
+func planDown(r *Registrar, appliedMigrations []AppliedMigration, target Version) ([]*Migration, error) {
+	sortMigrationSteps(r)
+	registered := registeredMigrationSteps(r)
+	var steps []*Migration
+	var errs []string
+	for i := len(appliedMigrations) - 1; i >= 0 && target.Lt(appliedMigrations[i].GetVersion()); i-- {
+		a := appliedMigrations[i]
+		s, ok := registered[a.GetVersion().String()]
+		switch {
+		case !a.IsSuccess():
+			errs = append(errs, fmt.Sprintf("migration step %s failed previously, run with --mode=%s to clear it", a.GetVersion(), ModeRepair))
+		case !ok:
+			errs = append(errs, fmt.Sprintf("migration step %s is applied but not registered", a.GetVersion()))
+		case s.DownFunc == nil:
+			errs = append(errs, fmt.Sprintf("migration step %s doesn't have down migration", a.GetVersion()))
+		case validateDownChecksum(s, a) != "":
+			errs = append(errs, validateDownChecksum(s, a))
+		default:
+			steps = append(steps, s)
+		}
+	}
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("unable to roll back to version %s:\n\t%s", target, strings.Join(errs, "\n\t"))
+	}
+	return steps, nil
+}
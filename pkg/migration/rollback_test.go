@@ -0,0 +1,239 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/migration"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"testing"
+)
+
+/*************************
+	Tests
+ *************************/
+
+func TestRollbackAndTransaction(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestMigrateDown(), "TestMigrateDown"),
+		test.GomegaSubTest(SubTestMigrateDownWithoutDownFunc(), "TestMigrateDownWithoutDownFunc"),
+		test.GomegaSubTest(SubTestMigrateDownFailure(), "TestMigrateDownFailure"),
+		test.GomegaSubTest(SubTestTransactionalMigrate(), "TestTransactionalMigrate"),
+		test.GomegaSubTest(SubTestNonTransactionalStep(), "TestNonTransactionalStep"),
+		test.GomegaSubTest(SubTestGormVersionerTransactional(), "TestGormVersionerTransactional"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestMigrateDown() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		var executed []string
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.2", "", true),
+			AppliedVersion("1.0.0.3", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithFunc(NoopMigrationFunc).WithDownFunc(RecordingMigrationFunc(&executed, "down 1")),
+			migration.WithVersion("1.0.0.3").WithFunc(NoopMigrationFunc).WithDownFunc(RecordingMigrationFunc(&executed, "down 3")),
+			migration.WithVersion("1.0.0.2").WithFunc(NoopMigrationFunc).WithDownFunc(RecordingMigrationFunc(&executed, "down 2")),
+		)
+		e := migration.MigrateDown(ctx, reg, ver, migration.WithVersion("1.0.0.1").Version)
+		g.Expect(e).To(Succeed(), "rollback should not fail")
+		g.Expect(executed).To(Equal([]string{"down 3", "down 2"}), "down migrations should be executed in descending order")
+		g.Expect(ver.records).To(HaveLen(1), "rolled back steps should be removed from history")
+		g.Expect(ver.records).To(HaveKey("1.0.0.1"), "target version should remain in history")
+
+		e = migration.MigrateDown(ctx, reg, ver, migration.WithVersion("1.0.0.1").Version)
+		g.Expect(e).To(Succeed(), "rollback should not fail when nothing to roll back")
+		g.Expect(executed).To(HaveLen(2), "nothing should be executed when nothing to roll back")
+	}
+}
+
+func SubTestMigrateDownWithoutDownFunc() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		var executed []string
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.2", "", true),
+			AppliedVersion("1.0.0.3", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithFunc(NoopMigrationFunc).WithDownFunc(RecordingMigrationFunc(&executed, "down 1")),
+			migration.WithVersion("1.0.0.2").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.3").WithFunc(NoopMigrationFunc).WithDownFunc(RecordingMigrationFunc(&executed, "down 3")),
+		)
+		e := migration.MigrateDown(ctx, reg, ver, migration.WithVersion("1.0.0.1").Version)
+		g.Expect(e).To(HaveOccurred(), "rollback should fail")
+		g.Expect(e.Error()).To(ContainSubstring("1.0.0.2 doesn't have down migration"), "error should be correct")
+		g.Expect(executed).To(BeEmpty(), "nothing should be executed")
+		g.Expect(ver.records).To(HaveLen(3), "history should not change")
+	}
+}
+
+func SubTestMigrateDownFailure() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		var executed []string
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.2", "", true),
+			AppliedVersion("1.0.0.3", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithFunc(NoopMigrationFunc).WithDownFunc(RecordingMigrationFunc(&executed, "down 1")),
+			migration.WithVersion("1.0.0.2").WithFunc(NoopMigrationFunc).WithDownFunc(FailingMigrationFunc),
+			migration.WithVersion("1.0.0.3").WithFunc(NoopMigrationFunc).WithDownFunc(RecordingMigrationFunc(&executed, "down 3")),
+		)
+		e := migration.MigrateDown(ctx, reg, ver, migration.Version{0})
+		g.Expect(e).To(HaveOccurred(), "rollback should fail")
+		g.Expect(executed).To(Equal([]string{"down 3"}), "rollback should stop at failed step")
+		g.Expect(ver.records).To(HaveLen(2), "only successfully rolled back steps should be removed")
+		g.Expect(ver.records).To(HaveKey("1.0.0.2"), "failed step should remain in history")
+	}
+}
+
+func SubTestTransactionalMigrate() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := &TxInMemoryVersioner{InMemoryVersioner: NewInMemoryVersioner()}
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithFunc(TxAwareMigrationFunc(g, true)).WithDownFunc(TxAwareMigrationFunc(g, true)),
+			migration.WithVersion("1.0.0.2").WithFunc(FailingMigrationFunc),
+		)
+		e := migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "migration should fail")
+		g.Expect(ver.txCount).To(Equal(2), "each step should be executed in its own transaction")
+		g.Expect(ver.records).To(HaveKeyWithValue("1.0.0.1", HaveField("Success", true)), "successful step should be committed")
+		g.Expect(ver.records).To(HaveKeyWithValue("1.0.0.2", HaveField("Success", false)), "failed step should be recorded outside of transaction")
+
+		e = migration.MigrateDown(ctx, reg, ver, migration.Version{0})
+		g.Expect(e).To(HaveOccurred(), "rollback should fail with failed step in history")
+		delete(ver.records, "1.0.0.2")
+		e = migration.MigrateDown(ctx, reg, ver, migration.Version{0})
+		g.Expect(e).To(Succeed(), "rollback should not fail")
+		g.Expect(ver.txCount).To(Equal(3), "rollback step should be executed in transaction")
+		g.Expect(ver.records).To(BeEmpty(), "rolled back step should be removed from history")
+	}
+}
+
+func SubTestNonTransactionalStep() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := &TxInMemoryVersioner{InMemoryVersioner: NewInMemoryVersioner()}
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithFunc(TxAwareMigrationFunc(g, false)).WithoutTransaction(),
+		)
+		e := migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "migration should not fail")
+		g.Expect(ver.txCount).To(BeZero(), "non-transactional step should not be executed in transaction")
+		g.Expect(ver.records).To(HaveKeyWithValue("1.0.0.1", HaveField("Success", true)), "step should be recorded")
+	}
+}
+
+func SubTestGormVersionerTransactional() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := migration.NewGormVersioner(nil)
+		g.Expect(ver).To(BeAssignableToTypeOf(&migration.GormVersioner{}), "versioner should be correct type")
+		g.Expect(ver.(migration.TransactionalVersioner).SupportsTransaction()).To(BeFalse(), "transaction should be disabled without database")
+
+		for _, name := range []string{"postgres", "cockroach"} {
+			ver = migration.NewGormVersioner(NewDialectDB(name))
+			g.Expect(ver.(migration.TransactionalVersioner).SupportsTransaction()).To(BeTrue(), "transaction should be enabled by default for %s", name)
+		}
+		ver = migration.NewGormVersioner(NewDialectDB("mysql"))
+		g.Expect(ver.(migration.TransactionalVersioner).SupportsTransaction()).To(BeFalse(), "transaction should be disabled by default for mysql")
+
+		ver = migration.NewGormVersioner(NewDialectDB("postgres"), func(opt *migration.GormVersionerOption) {
+			opt.Transactional = utils.BoolPtr(false)
+		})
+		g.Expect(ver.(migration.TransactionalVersioner).SupportsTransaction()).To(BeFalse(), "transaction should be disabled by option")
+
+		ver = migration.NewGormVersioner(NewDialectDB("mysql"), func(opt *migration.GormVersionerOption) {
+			opt.Transactional = utils.BoolPtr(true)
+		})
+		g.Expect(ver.(migration.TransactionalVersioner).SupportsTransaction()).To(BeTrue(), "transaction should be enabled by option")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+type txCtxKey struct{}
+
+func RecordingMigrationFunc(executed *[]string, name string) migration.MigrationFunc {
+	return func(_ context.Context) error {
+		*executed = append(*executed, name)
+		return nil
+	}
+}
+
+func TxAwareMigrationFunc(g *gomega.WithT, expectTx bool) migration.MigrationFunc {
+	return func(ctx context.Context) error {
+		g.Expect(ctx.Value(txCtxKey{}) != nil).To(Equal(expectTx), "transaction in context should be correct")
+		return nil
+	}
+}
+
+func NewDialectDB(name string) *gorm.DB {
+	return &gorm.DB{Config: &gorm.Config{Dialector: namedDialector{name: name}}}
+}
+
+// namedDialector overrides dialect name of postgres.Dialector
+type namedDialector struct {
+	postgres.Dialector
+	name string
+}
+
+func (d namedDialector) Name() string {
+	return d.name
+}
+
+// TxInMemoryVersioner simulate transaction by restoring records snapshot when transaction function fails
+type TxInMemoryVersioner struct {
+	*InMemoryVersioner
+	txCount int
+}
+
+func (v *TxInMemoryVersioner) SupportsTransaction() bool {
+	return true
+}
+
+func (v *TxInMemoryVersioner) Transaction(ctx context.Context, fn migration.MigrationFunc) error {
+	v.txCount++
+	snapshot := make(map[string]migration.MigrationVersion)
+	for k, r := range v.records {
+		snapshot[k] = r
+	}
+	if e := fn(context.WithValue(ctx, txCtxKey{}, true)); e != nil {
+		v.records = snapshot
+		return errors.Join(errors.New("rolled back"), e)
+	}
+	return nil
+}
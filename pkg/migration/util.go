@@ -17,16 +17,22 @@
 package migration
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/data/tx"
 	"gorm.io/gorm"
 	"io"
 	"io/fs"
+	"reflect"
+	"runtime"
 	"strings"
 )
 
-func migrationFuncFromTextFile(fs fs.FS, filePath string, db *gorm.DB) (MigrationFunc){
+func migrationFuncFromTextFile(fs fs.FS, filePath string, db *gorm.DB) (MigrationFunc, string){
 	file, err := fs.Open(filePath)
 	if err != nil {
 		panic(errors.New(fmt.Sprintf("%s does not exist or is not a file", filePath)))
@@ -45,11 +51,39 @@ func migrationFuncFromTextFile(fs fs.FS, filePath string, db *gorm.DB) (Migratio
 				continue
 			}
 			logger.Debugf("executing query %s", query)
-			result := db.WithContext(ctx).Exec(query)
+			result := dbWithContext(ctx, db).Exec(query)
 			if result.Error != nil {
 				return result.Error
 			}
 		}
 		return nil
+	}, checksum(sql)
+}
+
+// dbWithContext returns the *gorm.DB bound to current transaction if available.
+// Otherwise, given *gorm.DB is returned with context
+func dbWithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if txDB := tx.GormTxWithContext(ctx); txDB != nil {
+		return txDB
+	}
+	return db.WithContext(ctx)
+}
+
+// checksum calculate SHA-256 of given content. Line endings are normalized so that
+// checking out files on different OS (e.g. git's autocrlf) doesn't cause checksum mismatch
+func checksum(content []byte) string {
+	normalized := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:])
+}
+
+// funcName returns fully qualified name of given function, e.g. "github.com/example/migrations/v4_0.MoveTenantData.func1"
+func funcName(f MigrationFunc) string {
+	if f == nil {
+		return ""
+	}
+	if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+		return fn.Name() + "()"
 	}
-}
\ No newline at end of file
+	return "func()"
+}
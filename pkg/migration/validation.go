@@ -0,0 +1,201 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Validate verifies applied migration steps against registered ones without executing any pending steps.
+// Validation fails if
+//   - any applied migration step is recorded as failed
+//   - checksum of any applied migration step doesn't match the registered one (i.e. the step was modified after applied)
+//   - any registered migration step is missing from applied history while steps with higher versions are already applied,
+//     unless out-of-order execution is allowed
+//   - any applied migration step is no longer registered (e.g. the step was deleted or its version was changed)
+//
+// Validate doesn't create the version table. See InspectableVersioner.
+func Validate(ctx context.Context, r *Registrar, v Versioner) error {
+	sortMigrationSteps(r)
+	appliedMigrations, err := loadAppliedMigrationsReadOnly(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, a := range appliedMigrations {
+		if !a.IsSuccess() {
+			failed = append(failed, fmt.Sprintf("migration step %s failed previously, run with --mode=%s to clear it", a.GetVersion(), ModeRepair))
+		}
+	}
+	if len(failed) != 0 {
+		return newValidationError(failed)
+	}
+
+	if err := validateAppliedMigrations(r, appliedMigrations); err != nil {
+		return err
+	}
+	logger.WithContext(ctx).Infof("Validated %d applied migration steps", len(appliedMigrations))
+	return nil
+}
+
+// Repair fixes the migration history so that it's consistent with registered migration steps:
+//   - records of failed migration steps are removed, so they would be re-executed by next migration.
+//     This requires the Versioner to support RepairableVersioner
+//   - checksums of applied migration steps are realigned with registered ones, if the Versioner supports ChecksumVersioner.
+//     Registered migration steps without checksum don't affect recorded checksums.
+//   - checksums of down migrations are realigned in the same way, if the Versioner supports DownChecksumVersioner.
+//
+// Note: Repair doesn't revert any database changes made by failed migration steps.
+func Repair(ctx context.Context, r *Registrar, v Versioner) error {
+	if err := v.CreateVersionTableIfNotExist(ctx); err != nil {
+		return err
+	}
+
+	appliedMigrations, err := loadAppliedMigrations(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	rv, repairable := v.(RepairableVersioner)
+	cv, realignable := v.(ChecksumVersioner)
+	dv, downRealignable := v.(DownChecksumVersioner)
+	registered := registeredMigrationSteps(r)
+	for _, a := range appliedMigrations {
+		if !a.IsSuccess() {
+			if !repairable {
+				return fmt.Errorf("unable to remove failed migration step %s: %T doesn't support repair", a.GetVersion(), v)
+			}
+			logger.WithContext(ctx).Infof("Removing failed migration step %s: %s", a.GetVersion(), a.GetDescription())
+			if err := rv.DeleteAppliedMigration(ctx, a.GetVersion()); err != nil {
+				return err
+			}
+			continue
+		}
+		s, ok := registered[a.GetVersion().String()]
+		if !ok {
+			continue
+		}
+		if realignable && s.Checksum != "" && s.Checksum != appliedChecksum(a) {
+			logger.WithContext(ctx).Infof("Realigning checksum of migration step %s: %s", a.GetVersion(), a.GetDescription())
+			if err := cv.UpdateChecksum(ctx, a.GetVersion(), s.Checksum); err != nil {
+				return err
+			}
+		}
+		if downRealignable && s.DownChecksum != "" && s.DownChecksum != appliedDownChecksum(a) {
+			logger.WithContext(ctx).Infof("Realigning down migration checksum of migration step %s: %s", a.GetVersion(), a.GetDescription())
+			if err := dv.UpdateDownChecksum(ctx, a.GetVersion(), s.DownChecksum); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateAppliedMigrations checks successfully applied migration steps against registered migration steps.
+// Both registered and applied migration steps are expected to be sorted by version.
+func validateAppliedMigrations(r *Registrar, appliedMigrations []AppliedMigration) error {
+	errs := validateChecksums(r, appliedMigrations)
+	errs = append(errs, validateOrder(r, appliedMigrations)...)
+	errs = append(errs, validateRegistered(r, appliedMigrations)...)
+	if len(errs) != 0 {
+		return newValidationError(errs)
+	}
+	return nil
+}
+
+// validateChecksums returns mismatches between checksums of successfully applied migration steps and registered ones.
+func validateChecksums(r *Registrar, appliedMigrations []AppliedMigration) []string {
+	registered := registeredMigrationSteps(r)
+	var errs []string
+	for _, a := range appliedMigrations {
+		s, ok := registered[a.GetVersion().String()]
+		// Note: empty checksum means either the step was applied before checksum was introduced,
+		// or the step doesn't support checksum (e.g. Go function). We don't consider those as mismatch.
+		checksum := appliedChecksum(a)
+		if !ok || !a.IsSuccess() {
+			continue
+		}
+		if s.Checksum != "" && checksum != "" && s.Checksum != checksum {
+			errs = append(errs, fmt.Sprintf("checksum mismatch for migration step %s: applied %s, registered %s", a.GetVersion(), checksum, s.Checksum))
+		}
+		if e := validateDownChecksum(s, a); e != "" {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// validateDownChecksum returns mismatch between checksums of the recorded down migration and the registered one, if any.
+// Same as up migration, empty checksum is not considered as mismatch.
+func validateDownChecksum(s *Migration, a AppliedMigration) string {
+	checksum := appliedDownChecksum(a)
+	if s.DownChecksum == "" || checksum == "" || s.DownChecksum == checksum {
+		return ""
+	}
+	return fmt.Sprintf("down migration checksum mismatch for migration step %s: applied %s, registered %s", a.GetVersion(), checksum, s.DownChecksum)
+}
+
+// validateOrder returns registered migration steps that are not applied while higher versions are already applied,
+// unless out-of-order execution is allowed. Such steps are never executed by Migrate.
+// Both registered and applied migration steps are expected to be sorted by version.
+func validateOrder(r *Registrar, appliedMigrations []AppliedMigration) []string {
+	if allowOutOfOrderFlag || len(appliedMigrations) == 0 {
+		return nil
+	}
+	applied := make(map[string]AppliedMigration)
+	for _, a := range appliedMigrations {
+		applied[a.GetVersion().String()] = a
+	}
+	var errs []string
+	lastApplied := appliedMigrations[len(appliedMigrations)-1]
+	for _, s := range r.migrationSteps {
+		if filterFlag != "" && !s.Tags.Has(filterFlag) {
+			continue
+		}
+		if _, ok := applied[s.Version.String()]; !ok && s.Version.Lt(lastApplied.GetVersion()) {
+			errs = append(errs, fmt.Sprintf("migration step %s is registered but not applied, while %s is already applied", s.Version, lastApplied.GetVersion()))
+		}
+	}
+	return errs
+}
+
+// validateRegistered returns successfully applied migration steps that are not registered.
+func validateRegistered(r *Registrar, appliedMigrations []AppliedMigration) []string {
+	registered := registeredMigrationSteps(r)
+	var errs []string
+	for _, a := range appliedMigrations {
+		if _, ok := registered[a.GetVersion().String()]; !ok && a.IsSuccess() {
+			errs = append(errs, fmt.Sprintf("migration step %s is applied but not registered", a.GetVersion()))
+		}
+	}
+	return errs
+}
+
+func registeredMigrationSteps(r *Registrar) map[string]*Migration {
+	registered := make(map[string]*Migration)
+	for _, s := range r.migrationSteps {
+		registered[s.Version.String()] = s
+	}
+	return registered
+}
+
+func newValidationError(errs []string) error {
+	return fmt.Errorf("migration validation failed:\n\t%s", strings.Join(errs, "\n\t"))
+}
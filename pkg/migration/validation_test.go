@@ -0,0 +1,396 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package migration_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/cisco-open/go-lanai/pkg/migration"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+/*************************
+	Tests
+ *************************/
+
+func TestValidateAndRepair(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestChecksumFromFile(), "TestChecksumFromFile"),
+		test.GomegaSubTest(SubTestChecksumRecorded(), "TestChecksumRecorded"),
+		test.GomegaSubTest(SubTestValidateSuccess(), "TestValidateSuccess"),
+		test.GomegaSubTest(SubTestValidateChecksumMismatch(), "TestValidateChecksumMismatch"),
+		test.GomegaSubTest(SubTestValidateMissingVersion(), "TestValidateMissingVersion"),
+		test.GomegaSubTest(SubTestValidateUnregisteredVersion(), "TestValidateUnregisteredVersion"),
+		test.GomegaSubTest(SubTestValidateFailedStep(), "TestValidateFailedStep"),
+		test.GomegaSubTest(SubTestRepair(), "TestRepair"),
+		test.GomegaSubTest(SubTestRepairWithoutRegisteredChecksum(), "TestRepairWithoutRegisteredChecksum"),
+		test.GomegaSubTest(SubTestDownChecksum(), "TestDownChecksum"),
+		test.GomegaSubTest(SubTestLegacyVersioner(), "TestLegacyVersioner"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestChecksumFromFile() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		fs := fstest.MapFS{
+			"lf.sql":   &fstest.MapFile{Data: []byte("SELECT 1;\nSELECT 2;\n")},
+			"crlf.sql": &fstest.MapFile{Data: []byte("SELECT 1;\r\nSELECT 2;\r\n")},
+			"new.sql":  &fstest.MapFile{Data: []byte("SELECT 1;\nSELECT 3;\n")},
+		}
+		lf := migration.WithVersion("1.0.0").WithFile(fs, "lf.sql", nil)
+		crlf := migration.WithVersion("1.0.0").WithFile(fs, "crlf.sql", nil)
+		modified := migration.WithVersion("1.0.0").WithFile(fs, "new.sql", nil)
+		expected := sha256.Sum256([]byte("SELECT 1;\nSELECT 2;\n"))
+		g.Expect(lf.Checksum).To(Equal(hex.EncodeToString(expected[:])), "checksum should be SHA-256 of file content")
+		g.Expect(crlf.Checksum).To(Equal(lf.Checksum), "checksum should not be affected by line endings")
+		g.Expect(modified.Checksum).ToNot(Equal(lf.Checksum), "checksum should be different when content changed")
+
+		fn := migration.WithVersion("1.0.0").WithFunc(NoopMigrationFunc)
+		g.Expect(fn.Checksum).To(BeEmpty(), "checksum of function step should be empty by default")
+		fn.WithChecksum("v1")
+		g.Expect(fn.Checksum).To(Equal("v1"), "checksum of function step should be correct")
+	}
+}
+
+func SubTestChecksumRecorded() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner()
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithChecksum("c1").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("step 2").WithFunc(NoopMigrationFunc),
+		)
+		e := migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "migration should not fail")
+		AssertAppliedChecksum(g, ver, "1.0.0.1", "c1")
+		AssertAppliedChecksum(g, ver, "1.0.0.2", "")
+	}
+}
+
+func SubTestValidateSuccess() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "c1", true),
+			AppliedVersion("1.0.0.2", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithChecksum("c1").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("step 2 - legacy record").WithChecksum("c2").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.3").WithDesc("step 3 - pending").WithChecksum("c3").WithFunc(FailingMigrationFunc),
+		)
+		e := migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "validation should not fail")
+		g.Expect(ver.records).To(HaveLen(2), "validation should not execute pending steps")
+	}
+}
+
+func SubTestValidateChecksumMismatch() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "c1", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithChecksum("modified").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("step 2").WithFunc(FailingMigrationFunc),
+		)
+		e := migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "validation should fail")
+		g.Expect(e.Error()).To(ContainSubstring("checksum mismatch"), "validation error should be correct")
+
+		e = migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "migration should fail")
+		g.Expect(ver.records).To(HaveLen(1), "migration should not execute pending steps when validation failed")
+	}
+}
+
+func SubTestValidateMissingVersion() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.3", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("step 2 - missing").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.3").WithDesc("step 3").WithFunc(NoopMigrationFunc),
+		)
+		e := migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "validation should fail")
+		g.Expect(e.Error()).To(ContainSubstring("1.0.0.2 is registered but not applied"), "validation error should be correct")
+	}
+}
+
+func SubTestValidateUnregisteredVersion() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.2", "", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.3").WithDesc("step 2 - renamed").WithFunc(NoopMigrationFunc),
+		)
+		e := migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "validation should fail")
+		g.Expect(e.Error()).To(ContainSubstring("1.0.0.2 is applied but not registered"), "validation error should be correct")
+	}
+}
+
+func SubTestValidateFailedStep() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", false),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithFunc(NoopMigrationFunc),
+		)
+		e := migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "validation should fail")
+		g.Expect(e.Error()).To(ContainSubstring("failed previously"), "validation error should be correct")
+	}
+}
+
+func SubTestRepair() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "", true),
+			AppliedVersion("1.0.0.2", "c2", true),
+			AppliedVersion("1.0.0.3", "c3", false),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithChecksum("c1").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("step 2").WithChecksum("c2-modified").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.3").WithDesc("step 3").WithChecksum("c3").WithFunc(NoopMigrationFunc),
+		)
+		e := migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "migration should fail before repair")
+
+		e = migration.Repair(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "repair should not fail")
+		g.Expect(ver.records).ToNot(HaveKey("1.0.0.3"), "failed step should be removed")
+		AssertAppliedChecksum(g, ver, "1.0.0.1", "c1")
+		AssertAppliedChecksum(g, ver, "1.0.0.2", "c2-modified")
+
+		e = migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "validation should not fail after repair")
+		e = migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "migration should not fail after repair")
+		g.Expect(ver.records).To(HaveKeyWithValue("1.0.0.3", HaveField("Success", true)), "failed step should be re-executed")
+		AssertAppliedChecksum(g, ver, "1.0.0.3", "c3")
+	}
+}
+
+func SubTestRepairWithoutRegisteredChecksum() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ver := NewInMemoryVersioner(
+			AppliedVersion("1.0.0.1", "c1", true),
+		)
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithFunc(NoopMigrationFunc),
+		)
+		e := migration.Repair(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "repair should not fail")
+		AssertAppliedChecksum(g, ver, "1.0.0.1", "c1")
+	}
+}
+
+func SubTestDownChecksum() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		fs := fstest.MapFS{
+			"drop_table.sql":     &fstest.MapFile{Data: []byte("DROP TABLE t;")},
+			"drop_table_new.sql": &fstest.MapFile{Data: []byte("DROP TABLE IF EXISTS t;")},
+		}
+		ver := NewInMemoryVersioner()
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithFunc(NoopMigrationFunc).WithDownFile(fs, "drop_table.sql", nil),
+		)
+		e := migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "migration should not fail")
+		expected := sha256.Sum256([]byte("DROP TABLE t;"))
+		g.Expect(ver.records["1.0.0.1"].GetDownChecksum()).To(Equal(hex.EncodeToString(expected[:])), "down checksum should be recorded")
+
+		// modified down migration
+		target := migration.WithVersion("1.0.0.0").Version
+		reg = migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithFunc(NoopMigrationFunc).WithDownFile(fs, "drop_table_new.sql", nil),
+		)
+		e = migration.Validate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "validation should fail")
+		g.Expect(e.Error()).To(ContainSubstring("down migration checksum mismatch"), "validation error should be correct")
+		_, e = migration.PlanDown(ctx, reg, ver, target)
+		g.Expect(e).To(HaveOccurred(), "down plan should fail")
+		g.Expect(e.Error()).To(ContainSubstring("down migration checksum mismatch"), "down plan error should be correct")
+		e = migration.MigrateDown(ctx, reg, ver, target)
+		g.Expect(e).To(HaveOccurred(), "down migration should fail")
+		g.Expect(ver.records).To(HaveKey("1.0.0.1"), "step should not be rolled back")
+
+		// repair
+		e = migration.Repair(ctx, reg, ver)
+		g.Expect(e).To(Succeed(), "repair should not fail")
+		expected = sha256.Sum256([]byte("DROP TABLE IF EXISTS t;"))
+		g.Expect(ver.records["1.0.0.1"].GetDownChecksum()).To(Equal(hex.EncodeToString(expected[:])), "down checksum should be realigned")
+		steps, e := migration.PlanDown(ctx, reg, ver, target)
+		g.Expect(e).To(Succeed(), "down plan should not fail after repair")
+		g.Expect(steps).To(HaveLen(1), "down plan should contain the step")
+	}
+}
+
+func SubTestLegacyVersioner() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		mem := NewInMemoryVersioner()
+		ver := &LegacyVersioner{delegate: mem}
+		reg := migration.NewRegistrar()
+		reg.AddMigrations(
+			migration.WithVersion("1.0.0.1").WithDesc("step 1").WithChecksum("c1").WithFunc(NoopMigrationFunc),
+			migration.WithVersion("1.0.0.2").WithDesc("step 2").WithChecksum("c2").WithFunc(FailingMigrationFunc),
+		)
+		e := migration.Migrate(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "migration should fail")
+		g.Expect(mem.records).To(HaveKeyWithValue("1.0.0.1", HaveField("Success", true)), "step should be recorded")
+		AssertAppliedChecksum(g, mem, "1.0.0.1", "")
+
+		e = migration.Repair(ctx, reg, ver)
+		g.Expect(e).To(HaveOccurred(), "repair should fail when versioner doesn't support it")
+		g.Expect(mem.records).To(HaveKey("1.0.0.2"), "failed step should not be removed")
+
+		e = migration.MigrateDown(ctx, reg, ver, migration.WithVersion("1.0.0.0").Version)
+		g.Expect(e).To(HaveOccurred(), "down migration should fail when versioner doesn't support it")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func NoopMigrationFunc(_ context.Context) error {
+	return nil
+}
+
+func FailingMigrationFunc(_ context.Context) error {
+	return context.Canceled
+}
+
+func AppliedVersion(version string, checksum string, success bool) migration.MigrationVersion {
+	return migration.MigrationVersion{
+		Version:     migration.WithVersion(version).Version,
+		Description: "applied " + version,
+		Checksum:    checksum,
+		InstalledOn: time.Now(),
+		Success:     success,
+	}
+}
+
+func AssertAppliedChecksum(g *gomega.WithT, ver *InMemoryVersioner, version string, checksum string) {
+	g.Expect(ver.records).To(HaveKey(version), "migration version [%s] should exists", version)
+	g.Expect(ver.records[version].GetChecksum()).To(Equal(checksum), "checksum of migration version [%s] should be correct", version)
+}
+
+type InMemoryVersioner struct {
+	records map[string]migration.MigrationVersion
+}
+
+func NewInMemoryVersioner(applied ...migration.MigrationVersion) *InMemoryVersioner {
+	v := &InMemoryVersioner{records: map[string]migration.MigrationVersion{}}
+	for _, a := range applied {
+		v.records[a.Version.String()] = a
+	}
+	return v
+}
+
+func (v *InMemoryVersioner) CreateVersionTableIfNotExist(_ context.Context) error {
+	return nil
+}
+
+func (v *InMemoryVersioner) GetAppliedMigrations(_ context.Context) ([]migration.AppliedMigration, error) {
+	applied := make([]migration.AppliedMigration, 0, len(v.records))
+	for _, r := range v.records {
+		applied = append(applied, r)
+	}
+	return applied, nil
+}
+
+func (v *InMemoryVersioner) RecordAppliedMigration(ctx context.Context, version migration.Version, description string, success bool, installedOn time.Time, executionTime time.Duration) error {
+	return v.RecordAppliedMigrationWithChecksum(ctx, version, description, "", success, installedOn, executionTime)
+}
+
+func (v *InMemoryVersioner) RecordAppliedMigrationWithChecksum(_ context.Context, version migration.Version, description string, checksum string, success bool, installedOn time.Time, executionTime time.Duration) error {
+	v.records[version.String()] = migration.MigrationVersion{
+		Version:       version,
+		Description:   description,
+		Checksum:      checksum,
+		ExecutionTime: executionTime,
+		InstalledOn:   installedOn,
+		Success:       success,
+	}
+	return nil
+}
+
+func (v *InMemoryVersioner) DeleteAppliedMigration(_ context.Context, version migration.Version) error {
+	delete(v.records, version.String())
+	return nil
+}
+
+func (v *InMemoryVersioner) UpdateDownChecksum(_ context.Context, version migration.Version, checksum string) error {
+	if r, ok := v.records[version.String()]; ok {
+		r.DownChecksum = checksum
+		v.records[version.String()] = r
+	}
+	return nil
+}
+
+func (v *InMemoryVersioner) UpdateChecksum(_ context.Context, version migration.Version, checksum string) error {
+	if r, ok := v.records[version.String()]; ok {
+		r.Checksum = checksum
+		v.records[version.String()] = r
+	}
+	return nil
+}
+
+// LegacyVersioner implements only migration.Versioner, without any optional interfaces
+type LegacyVersioner struct {
+	delegate *InMemoryVersioner
+}
+
+func (v *LegacyVersioner) CreateVersionTableIfNotExist(ctx context.Context) error {
+	return v.delegate.CreateVersionTableIfNotExist(ctx)
+}
+
+func (v *LegacyVersioner) GetAppliedMigrations(ctx context.Context) ([]migration.AppliedMigration, error) {
+	return v.delegate.GetAppliedMigrations(ctx)
+}
+
+func (v *LegacyVersioner) RecordAppliedMigration(ctx context.Context, version migration.Version, description string, success bool, installedOn time.Time, executionTime time.Duration) error {
+	return v.delegate.RecordAppliedMigration(ctx, version, description, success, installedOn, executionTime)
+}
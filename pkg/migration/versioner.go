@@ -28,13 +28,71 @@ type AppliedMigration interface {
 	GetInstalledOn() time.Time //TODO: other information
 }
 
+// ChecksumAppliedMigration is an optional interface of AppliedMigration that carries the recorded checksum
+type ChecksumAppliedMigration interface {
+	AppliedMigration
+	GetChecksum() string
+}
+
+// DownChecksumAppliedMigration is an optional interface of AppliedMigration that carries the recorded checksum
+// of down migration
+type DownChecksumAppliedMigration interface {
+	AppliedMigration
+	GetDownChecksum() string
+}
+
 type Versioner interface {
 	CreateVersionTableIfNotExist(ctx context.Context) error
 	GetAppliedMigrations(ctx context.Context) ([]AppliedMigration, error)
 	RecordAppliedMigration(ctx context.Context, version Version, description string, success bool, installedOn time.Time, executionTime time.Duration) error
 }
 
+// ChecksumVersioner is an optional interface of Versioner.
+// When supported, checksums of migration steps are recorded, so modified steps can be detected by validation
+// and realigned by repair. Applied migrations returned by such Versioner should implement ChecksumAppliedMigration.
+type ChecksumVersioner interface {
+	Versioner
+	// RecordAppliedMigrationWithChecksum same as RecordAppliedMigration, with the checksum of the migration step
+	RecordAppliedMigrationWithChecksum(ctx context.Context, version Version, description string, checksum string, success bool, installedOn time.Time, executionTime time.Duration) error
+	// UpdateChecksum replaces the recorded checksum of given version. Used for realigning checksums of applied steps
+	UpdateChecksum(ctx context.Context, version Version, checksum string) error
+}
 
+// DownChecksumVersioner is an optional interface of Versioner.
+// When supported, checksums of down migrations are recorded along with applied migration steps, so modified down
+// migrations can be detected before rolling back. Applied migrations returned by such Versioner should implement
+// DownChecksumAppliedMigration.
+type DownChecksumVersioner interface {
+	Versioner
+	// UpdateDownChecksum replaces the recorded down migration checksum of given version
+	UpdateDownChecksum(ctx context.Context, version Version, checksum string) error
+}
 
+// RepairableVersioner is an optional interface of Versioner, required for repairing failed migration steps
+// and for down migration.
+type RepairableVersioner interface {
+	Versioner
+	// DeleteAppliedMigration removes the record of given version
+	DeleteAppliedMigration(ctx context.Context, version Version) error
+}
 
+// TransactionalVersioner is an optional interface of Versioner.
+// When supported, each migration step is executed within a transaction together with recording its result,
+// so both are committed or rolled back atomically.
+type TransactionalVersioner interface {
+	Versioner
+	// SupportsTransaction returns true if DDL statements can be executed within a transaction
+	SupportsTransaction() bool
+	// Transaction executes given function within a transaction. The context passed into the function carries the
+	// transaction, which can be retrieved via tx.GormTxWithContext
+	Transaction(ctx context.Context, fn MigrationFunc) error
+}
 
+// InspectableVersioner is an optional interface of Versioner, used by read-only operations (Plan, PlanDown and Validate),
+// which never create the version table. When supported, missing version table is treated as no migration step is applied.
+// Otherwise, the version table is expected to exist for read-only operations.
+type InspectableVersioner interface {
+	Versioner
+	// VersionTableExists returns true if the version table exists
+	VersionTableExists(ctx context.Context) (bool, error)
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	goredis "github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+const (
+	MeterNameRedisCommands = "redis.commands"
+)
+
+type ctxKeyMetricsStartTime struct{}
+
+// redisMetricsHook implements redis.Hook and redis.OptionsAwareHook
+type redisMetricsHook struct {
+	histogram metrics.Histogram
+	db        int
+}
+
+func NewRedisMetricsHook(registry metrics.Registry) *redisMetricsHook {
+	histogram := registry.Histogram(MeterNameRedisCommands,
+		metrics.WithDescription("Duration of redis commands"),
+		metrics.WithUnit("seconds"),
+		metrics.WithTags("cmd", "db", "outcome"),
+	)
+	return newRedisMetricsHook(histogram, -1)
+}
+
+func newRedisMetricsHook(histogram metrics.Histogram, db int) *redisMetricsHook {
+	return &redisMetricsHook{
+		histogram: histogram,
+		db:        db,
+	}
+}
+
+// WithClientOption implements redis.OptionsAwareHook
+func (h redisMetricsHook) WithClientOption(opts *goredis.UniversalOptions) goredis.Hook {
+	return newRedisMetricsHook(h.histogram, opts.DB)
+}
+
+// BeforeProcess implements redis.Hook
+func (h redisMetricsHook) BeforeProcess(ctx context.Context, _ goredis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, ctxKeyMetricsStartTime{}, time.Now()), nil
+}
+
+// AfterProcess implements redis.Hook
+func (h redisMetricsHook) AfterProcess(ctx context.Context, cmd goredis.Cmder) error {
+	h.observe(ctx, cmd.Name(), cmd.Err())
+	return nil
+}
+
+// BeforeProcessPipeline implements redis.Hook
+func (h redisMetricsHook) BeforeProcessPipeline(ctx context.Context, _ []goredis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, ctxKeyMetricsStartTime{}, time.Now()), nil
+}
+
+// AfterProcessPipeline implements redis.Hook
+func (h redisMetricsHook) AfterProcessPipeline(ctx context.Context, cmds []goredis.Cmder) error {
+	var err error
+	for _, v := range cmds {
+		if v.Err() != nil && v.Err() != goredis.Nil {
+			err = v.Err()
+			break
+		}
+	}
+	h.observe(ctx, "pipeline", err)
+	return nil
+}
+
+func (h redisMetricsHook) observe(ctx context.Context, cmd string, err error) {
+	start, ok := ctx.Value(ctxKeyMetricsStartTime{}).(time.Time)
+	if !ok {
+		return
+	}
+	outcome := "SUCCESS"
+	if err != nil && err != goredis.Nil {
+		outcome = "ERROR"
+	}
+	db := ""
+	if h.db >= 0 {
+		db = strconv.Itoa(h.db)
+	}
+	h.histogram.Observe(time.Since(start).Seconds(), metrics.Tags{
+		"cmd":     cmd,
+		"db":      db,
+		"outcome": outcome,
+	})
+}
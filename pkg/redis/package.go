@@ -20,6 +20,7 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/bootstrap"
 	"github.com/cisco-open/go-lanai/pkg/certs"
 	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/fx"
 )
@@ -47,6 +48,7 @@ type factoryDI struct {
 	Props       RedisProperties
 	CertManager certs.Manager      `optional:"true"`
 	Tracer      opentracing.Tracer `optional:"true"`
+	Metrics     metrics.Registry   `optional:"true"`
 }
 
 func provideClientFactory(di factoryDI) ClientFactory {
@@ -57,6 +59,9 @@ func provideClientFactory(di factoryDI) ClientFactory {
 	if di.Tracer != nil {
 		factory.AddHooks(di.AppCtx, NewRedisTrackingHook(di.Tracer))
 	}
+	if di.Metrics != nil {
+		factory.AddHooks(di.AppCtx, NewRedisMetricsHook(di.Metrics))
+	}
 	return factory
 }
 
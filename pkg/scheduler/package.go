@@ -0,0 +1,41 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"go.uber.org/fx"
+)
+
+// Module cancels all scheduled tasks during graceful shutdown.
+// Since this module doesn't invoke anything, the precedence has no real effect
+var Module = &bootstrap.Module{
+	Name:       "scheduler",
+	Precedence: bootstrap.FrameworkModulePrecedence,
+	Options: []fx.Option{
+		fx.Provide(fx.Annotated{Group: bootstrap.FxShutdownHookGroup, Target: provideShutdownHook}),
+	},
+}
+
+// Use Allow service to include this module in main()
+func Use() {
+	bootstrap.Register(Module)
+}
+
+func provideShutdownHook() bootstrap.ShutdownHook {
+	return bootstrap.NewShutdownHook("scheduler", bootstrap.ShutdownPhaseScheduler, Shutdown)
+}
@@ -72,6 +72,7 @@ func TestTaskCancellation(t *testing.T) {
 		test.GomegaSubTest(SubTestCancelOnError(), "TestCancelOnError"),
 		test.GomegaSubTest(SubTestCancelOnPanic(), "TestCancelOnPanic"),
 		test.GomegaSubTest(SubTestManualCancel(), "TestManual"),
+		test.GomegaSubTest(SubTestShutdown(), "TestShutdown"),
 	)
 }
 
@@ -383,6 +384,27 @@ func SubTestManualCancel() test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestShutdown() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		noop := func(ctx context.Context) error { return nil }
+		cancellers := make([]TaskCanceller, 3)
+		var e error
+		cancellers[0], e = Repeat(noop, AtRate(TestTimeUnit), Name("test-shutdown-rate"))
+		g.Expect(e).To(Succeed(), "new task shouldn't return error")
+		cancellers[1], e = Repeat(noop, WithDelay(TestTimeUnit), Name("test-shutdown-delay"))
+		g.Expect(e).To(Succeed(), "new task shouldn't return error")
+		cancellers[2], e = RunOnce(noop, StartAfter(100*TestTimeUnit), Name("test-shutdown-once"))
+		g.Expect(e).To(Succeed(), "new task shouldn't return error")
+
+		e = Shutdown(ctx)
+		g.Expect(e).To(Succeed(), "shutdown should not fail")
+		for _, canceller := range cancellers {
+			g.Expect(<-canceller.Cancelled()).To(Equal(context.Canceled), "task should be cancelled")
+		}
+		g.Expect(activeTasks.all()).To(BeEmpty(), "cancelled tasks should not be tracked")
+	}
+}
+
 func SubTestSchedulingErrors() test.GomegaSubTestFunc {
 	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
 		var e error
@@ -0,0 +1,75 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+var activeTasks = &taskRegistry{
+	tasks: map[*task]struct{}{},
+}
+
+// taskRegistry keeps track of scheduled tasks that are not cancelled or finished yet
+type taskRegistry struct {
+	mtx   sync.Mutex
+	tasks map[*task]struct{}
+}
+
+func (r *taskRegistry) add(t *task) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.tasks[t] = struct{}{}
+}
+
+func (r *taskRegistry) remove(t *task) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.tasks, t)
+}
+
+func (r *taskRegistry) all() []*task {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	tasks := make([]*task, 0, len(r.tasks))
+	for t := range r.tasks {
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// Shutdown cancels all scheduled tasks and waits for their scheduling loop to exit, until given context is done.
+// Note: executions that are not waited by the scheduling loop (e.g. AtRate) might still be running when this function returns
+func Shutdown(ctx context.Context) error {
+	tasks := activeTasks.all()
+	if len(tasks) == 0 {
+		return nil
+	}
+	logger.WithContext(ctx).Infof("Cancelling %d scheduled tasks...", len(tasks))
+	for _, t := range tasks {
+		t.Cancel()
+	}
+	for _, t := range tasks {
+		select {
+		case <-t.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
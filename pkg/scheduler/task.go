@@ -32,7 +32,9 @@ type task struct {
 	option TaskOption
 	cancel context.CancelFunc
 	done chan error
-	err  error
+	// stopped is closed when main loop exits. Unlike done, it can be waited by multiple parties
+	stopped chan struct{}
+	err     error
 }
 
 func newTask(taskFunc TaskFunc, opts ...TaskOptions) (TaskCanceller, error) {
@@ -44,7 +46,8 @@ func newTask(taskFunc TaskFunc, opts ...TaskOptions) (TaskCanceller, error) {
 	t := task{
 		id:   id,
 		task: taskFunc,
-		done: make(chan error, 1),
+		done:    make(chan error, 1),
+		stopped: make(chan struct{}),
 	}
 	for _, fn := range opts {
 		if e := fn(&t.option); e != nil {
@@ -62,6 +65,7 @@ func newTask(taskFunc TaskFunc, opts ...TaskOptions) (TaskCanceller, error) {
 	}
 
 	// start and return
+	activeTasks.add(&t)
 	t.start(context.Background())
 	return &t, nil
 }
@@ -93,6 +97,8 @@ func (t *task) loop(ctx context.Context) {
 		defer t.mtx.Unlock()
 		t.done <- t.err
 		close(t.done)
+		close(t.stopped)
+		activeTasks.remove(t)
 	}()
 
 	// first, figure out first fire time if set
@@ -167,6 +167,12 @@ type AccountUpdater interface {
 	ResetFailedAttempts()
 }
 
+// CredentialsUpdater is an optional interface of Account that allows replacing the stored credentials
+// without affecting password aging status, e.g. re-encoding the same password with a different algorithm
+type CredentialsUpdater interface {
+	UpdateCredentials(credentials interface{})
+}
+
 /*********************************
 	Abstraction - Locking Rules
  *********************************/
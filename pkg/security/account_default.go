@@ -205,6 +205,14 @@ func (a *DefaultAccount) ResetGracefulAuthCount() {
 	a.AcctDetails.GracefulAuthCount = 0
 }
 
+/***********************************
+	security.CredentialsUpdater
+ ***********************************/
+
+func (a *DefaultAccount) UpdateCredentials(credentials interface{}) {
+	a.AcctDetails.Credentials = credentials
+}
+
 /***********************************
 	security.AccountLockingRule
  ***********************************/
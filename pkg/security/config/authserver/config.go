@@ -31,6 +31,7 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/openid"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/revoke"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/common"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/tokenauth"
 	"github.com/cisco-open/go-lanai/pkg/security/passwd"
@@ -40,6 +41,7 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/web/matcher"
 	"go.uber.org/fx"
 	"net/url"
+	"time"
 )
 
 const (
@@ -60,6 +62,7 @@ type configDI struct {
 	ServerProperties   web.ServerProperties
 	SessionProperties  security.SessionProperties
 	CryptoProperties   jwt.CryptoProperties
+	DPoPProperties     dpop.DPoPProperties
 	SessionStore       session.Store
 	TimeoutSupport     oauth2.TimeoutApplier `optional:"true"`
 	ApprovalStore      auth.ApprovalStore    `optional:"true"`
@@ -81,6 +84,7 @@ func ProvideAuthServerDI(di configDI) authServerOut {
 		serverProperties:   di.ServerProperties,
 		sessionProperties:  di.SessionProperties,
 		cryptoProperties:   di.CryptoProperties,
+		dpopProperties:     di.DPoPProperties,
 		Issuer:             newIssuer(&di.Properties.Issuer, &di.ServerProperties),
 		timeoutSupport:     di.TimeoutSupport,
 		ApprovalStore:      di.ApprovalStore,
@@ -101,8 +105,11 @@ func ProvideAuthServerDI(di configDI) authServerOut {
 				Location:  &url.URL{Path: di.Properties.Endpoints.Authorize, RawQuery: fmt.Sprintf("%s=%s", oauth2.ParameterGrantType, samlctx.GrantTypeSamlSSO)},
 				Condition: matcher.RequestWithForm(oauth2.ParameterGrantType, samlctx.GrantTypeSamlSSO),
 			},
-			SamlMetadata:    di.Properties.Endpoints.SamlMetadata,
-			TenantHierarchy: di.Properties.Endpoints.TenantHierarchy,
+			SamlMetadata:        di.Properties.Endpoints.SamlMetadata,
+			TenantHierarchy:     di.Properties.Endpoints.TenantHierarchy,
+			DeviceAuthorization: di.Properties.Endpoints.DeviceAuthorization,
+			DeviceVerification:  di.Properties.Endpoints.DeviceVerification,
+			PushedAuthorization: di.Properties.Endpoints.PushedAuthorization,
 		},
 		OpenIDSSOEnabled: true,
 	}
@@ -136,6 +143,7 @@ func ConfigureAuthorizationServer(di initDI) {
 		di.Config.Endpoints.SamlSso.Location.Path,
 		di.Config.Endpoints.Approval,
 		di.Config.Endpoints.Logout,
+		di.Config.Endpoints.DeviceVerification,
 	)
 	registerEndpoints(di.WebRegistrar, di.Config)
 }
@@ -150,18 +158,21 @@ type ConditionalEndpoint struct {
 }
 
 type Endpoints struct {
-	Authorize       ConditionalEndpoint
-	Approval        string
-	Token           string
-	CheckToken      string
-	UserInfo        string
-	JwkSet          string
-	Logout          string
-	LoggedOut       string
-	Error           string
-	SamlSso         ConditionalEndpoint
-	SamlMetadata    string
-	TenantHierarchy string
+	Authorize           ConditionalEndpoint
+	Approval            string
+	Token               string
+	CheckToken          string
+	UserInfo            string
+	JwkSet              string
+	Logout              string
+	LoggedOut           string
+	Error               string
+	SamlSso             ConditionalEndpoint
+	SamlMetadata        string
+	TenantHierarchy     string
+	DeviceAuthorization string
+	DeviceVerification  string
+	PushedAuthorization string
 }
 
 type Configuration struct {
@@ -190,6 +201,7 @@ type Configuration struct {
 	serverProperties          web.ServerProperties
 	sessionProperties         security.SessionProperties
 	cryptoProperties          jwt.CryptoProperties
+	dpopProperties            dpop.DPoPProperties
 	idpConfigurers            []IdpSecurityConfigurer
 	sharedContextDetailsStore security.ContextDetailsStore
 	sharedAuthRegistry        auth.AuthorizationRegistry
@@ -204,6 +216,11 @@ type Configuration struct {
 	sharedARProcessor         auth.AuthorizeRequestProcessor
 	sharedAuthHandler         auth.AuthorizeHandler
 	sharedAuthCodeStore       auth.AuthorizationCodeStore
+	sharedDeviceCodeStore     auth.DeviceCodeStore
+	sharedAssertionStore      auth.ClientAssertionReplayStore
+	sharedParStore            auth.PushedAuthorizationRequestStore
+	sharedDPoPVerifier        *dpop.ProofVerifier
+	sharedResponseEncryptor   *openid.ResponseEncryptor
 	sharedTokenAuthenticator  security.Authenticator
 	timeoutSupport            oauth2.TimeoutApplier
 }
@@ -257,6 +274,8 @@ func (c *Configuration) tokenGranter() auth.TokenGranter {
 			grants.NewRefreshGranter(c.authorizationService(), c.tokenStore()),
 			grants.NewSwitchUserGranter(c.authorizationService(), c.tokenAuthenticator(), c.UserAccountStore),
 			grants.NewSwitchTenantGranter(c.authorizationService(), c.tokenAuthenticator(), c.UserAccountStore),
+			grants.NewDeviceCodeGranter(c.authorizationService(), c.deviceCodeStore()),
+			grants.NewTokenExchangeGranter(c.authorizationService(), c.tokenAuthenticator()),
 		}
 
 		// password granter is optional
@@ -326,6 +345,7 @@ func (c *Configuration) authorizationService() auth.AuthorizationService {
 				openidEnhancer := openid.NewOpenIDTokenEnhancer(func(opt *openid.EnhancerOption) {
 					opt.Issuer = c.Issuer
 					opt.JwtEncoder = c.jwtEncoder()
+					opt.Encryptor = c.responseEncryptor()
 				})
 				conf.PostTokenEnhancers = append(conf.PostTokenEnhancers, openidEnhancer)
 			}
@@ -349,6 +369,13 @@ func (c *Configuration) jwtEncoder() jwt.JwtEncoder {
 	return c.sharedJwtEncoder
 }
 
+func (c *Configuration) responseEncryptor() *openid.ResponseEncryptor {
+	if c.sharedResponseEncryptor == nil {
+		c.sharedResponseEncryptor = openid.NewResponseEncryptor(c.ClientStore)
+	}
+	return c.sharedResponseEncryptor
+}
+
 func (c *Configuration) jwtDecoder() jwt.JwtDecoder {
 	if c.sharedJwtDecoder == nil {
 		c.sharedJwtDecoder = jwt.NewSignedJwtDecoder(jwt.VerifyWithJwkStore(c.jwkStore(), c.cryptoProperties.Jwt.KeyName))
@@ -404,6 +431,72 @@ func (c *Configuration) authorizeCodeStore() auth.AuthorizationCodeStore {
 	return c.sharedAuthCodeStore
 }
 
+func (c *Configuration) deviceCodeStore() auth.DeviceCodeStore {
+	if c.sharedDeviceCodeStore == nil {
+		c.sharedDeviceCodeStore = auth.NewRedisDeviceCodeStore(c.appContext, c.redisClientFactory, c.sessionProperties.DbIndex,
+			func(opt *auth.DeviceCodeStoreOption) {
+				opt.Validity = time.Duration(c.properties.Device.Validity)
+				opt.Interval = time.Duration(c.properties.Device.Interval)
+				opt.MaxAttempts = c.properties.Device.MaxAttempts
+				opt.AttemptWindow = time.Duration(c.properties.Device.AttemptWindow)
+			})
+	}
+	return c.sharedDeviceCodeStore
+}
+
+func (c *Configuration) pushedAuthorizationRequestStore() auth.PushedAuthorizationRequestStore {
+	if c.sharedParStore == nil {
+		c.sharedParStore = auth.NewRedisPushedAuthorizationRequestStore(c.appContext, c.redisClientFactory, c.sessionProperties.DbIndex,
+			func(opt *auth.ParStoreOption) {
+				opt.Validity = time.Duration(c.properties.Par.Validity)
+			})
+	}
+	return c.sharedParStore
+}
+
+func (c *Configuration) clientAssertionReplayStore() auth.ClientAssertionReplayStore {
+	if c.sharedAssertionStore == nil {
+		c.sharedAssertionStore = auth.NewRedisClientAssertionReplayStore(c.appContext, c.redisClientFactory, c.sessionProperties.DbIndex)
+	}
+	return c.sharedAssertionStore
+}
+
+// clientAssertionAudiences returns acceptable "aud" of client assertions: issuer's identifier, token endpoint URL
+// and pushed authorization request endpoint URL
+func (c *Configuration) clientAssertionAudiences() []string {
+	audiences := []string{c.Issuer.Identifier()}
+	for _, path := range []string{c.Endpoints.Token, c.Endpoints.PushedAuthorization} {
+		epUrl, e := c.Issuer.BuildUrl(func(opt *security.UrlBuilderOption) {
+			opt.Path = path
+		})
+		if e == nil {
+			audiences = append(audiences, epUrl.String())
+		}
+	}
+	return audiences
+}
+
+// dpopUrl returns the expected "htu" of DPoP proofs at token endpoint. It's built from the issuer instead of the request,
+// so it cannot be altered by forwarded headers
+func (c *Configuration) dpopUrl() string {
+	epUrl, e := c.Issuer.BuildUrl(func(opt *security.UrlBuilderOption) {
+		opt.Path = c.Endpoints.Token
+	})
+	if e != nil {
+		return ""
+	}
+	return epUrl.String()
+}
+
+func (c *Configuration) dpopVerifier() *dpop.ProofVerifier {
+	if c.sharedDPoPVerifier == nil {
+		c.sharedDPoPVerifier = dpop.NewProofVerifier(dpop.WithProperties(&c.dpopProperties), func(opt *dpop.VerifierOption) {
+			opt.ReplayStore = dpop.NewRedisReplayStore(c.appContext, c.redisClientFactory, c.dpopProperties.DbIndex)
+		})
+	}
+	return c.sharedDPoPVerifier
+}
+
 func (c *Configuration) tokenAuthenticator() security.Authenticator {
 	if c.sharedTokenAuthenticator == nil {
 		c.sharedTokenAuthenticator = tokenauth.NewAuthenticator(func(opt *tokenauth.AuthenticatorOption) {
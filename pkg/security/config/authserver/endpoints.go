@@ -31,8 +31,13 @@ import (
 func registerEndpoints(registrar *web.Registrar, config *Configuration) {
 	jwks := misc.NewJwkSetEndpoint(config.jwkStore())
 	ct := misc.NewCheckTokenEndpoint(config.Issuer, config.tokenStore())
-	ui := misc.NewUserInfoEndpoint(config.Issuer, config.UserAccountStore, config.jwtEncoder())
+	ui := misc.NewUserInfoEndpoint(config.Issuer, config.UserAccountStore, config.jwtEncoder(), func(opt *misc.UserInfoOption) {
+		opt.Encryptor = config.responseEncryptor()
+	})
 	th := misc.NewTenantHierarchyEndpoint()
+	da := misc.NewDeviceAuthorizationEndpoint(config.Issuer, config.deviceCodeStore(), config.Endpoints.DeviceVerification)
+	dv := misc.NewDeviceVerificationEndpoint(config.deviceCodeStore(), config.Endpoints.DeviceVerification, "device.tmpl")
+	par := misc.NewPushedAuthorizationEndpoint(config.authorizeRequestProcessor(), config.pushedAuthorizationRequestStore())
 
 	mappings := []interface{}{
 		template.New().Get(config.Endpoints.Error).HandlerFunc(errorhandling.ErrorWithStatus).Build(),
@@ -45,14 +50,17 @@ func registerEndpoints(registrar *web.Registrar, config *Configuration) {
 			EncodeResponseFunc(misc.JwtResponseEncoder()).
 			EndpointFunc(ui.JwtUserInfo).Build(),
 		rest.New("userinfo GET").Get(config.Endpoints.UserInfo).
-			Condition(notAcceptJwtMatcher()).EndpointFunc(ui.PlainUserInfo).Build(),
+			Condition(notAcceptJwtMatcher()).
+			EncodeResponseFunc(misc.UserInfoResponseEncoder()).
+			EndpointFunc(ui.UserInfo).Build(),
 		rest.New("userinfo POST").Post(config.Endpoints.UserInfo).
 			Condition(acceptJwtMatcher()).
 			EncodeResponseFunc(misc.JwtResponseEncoder()).
 			EndpointFunc(ui.JwtUserInfo).Build(),
 		rest.New("userinfo POST").Post(config.Endpoints.UserInfo).
 			Condition(notAcceptJwtMatcher()).
-			EndpointFunc(ui.PlainUserInfo).Build(),
+			EncodeResponseFunc(misc.UserInfoResponseEncoder()).
+			EndpointFunc(ui.UserInfo).Build(),
 
 		rest.New("tenant hierarchy parent").Get(fmt.Sprintf("%s/%s", config.Endpoints.TenantHierarchy, "parent")).
 			EndpointFunc(th.GetParent).EncodeResponseFunc(misc.StringResponseEncoder()).Build(),
@@ -64,6 +72,14 @@ func registerEndpoints(registrar *web.Registrar, config *Configuration) {
 			EndpointFunc(th.GetDescendants).Build(),
 		rest.New("tenant hierarchy root").Get(fmt.Sprintf("%s/%s", config.Endpoints.TenantHierarchy, "root")).
 			EndpointFunc(th.GetRoot).EncodeResponseFunc(misc.StringResponseEncoder()).Build(),
+
+		rest.New("device authorization").Post(config.Endpoints.DeviceAuthorization).
+			EndpointFunc(da.DeviceAuthorization).Build(),
+		template.New().Get(config.Endpoints.DeviceVerification).HandlerFunc(dv.VerificationForm).Build(),
+		template.New().Post(config.Endpoints.DeviceVerification).HandlerFunc(dv.Verify).Build(),
+
+		rest.New("pushed authorization").Post(config.Endpoints.PushedAuthorization).
+			EndpointFunc(par.PushedAuthorization).Build(),
 	}
 
 	// openid additional
@@ -92,6 +108,8 @@ func prepareWellKnownEndpoint(config *Configuration) *misc.WellKnownEndpoint {
 		openid.OPMetadataUserInfoEndpoint:   config.Endpoints.UserInfo,
 		openid.OPMetadataJwkSetURI:          config.Endpoints.JwkSet,
 		openid.OPMetadataEndSessionEndpoint: config.Endpoints.Logout,
+		openid.OPMetadataDeviceAuthEndpoint: config.Endpoints.DeviceAuthorization,
+		openid.OPMetadataParEndpoint:        config.Endpoints.PushedAuthorization,
 	}
 	return misc.NewWellKnownEndpoint(config.Issuer, config.IdpManager, extra)
 }
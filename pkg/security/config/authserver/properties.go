@@ -18,7 +18,9 @@ package authserver
 
 import (
 	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/utils"
 	"github.com/pkg/errors"
+	"time"
 )
 
 const (
@@ -30,6 +32,8 @@ type AuthServerProperties struct {
 	Issuer            IssuerProperties    `json:"issuer"`
 	RedirectWhitelist []string            `json:"redirect-whitelist"`
 	Endpoints         EndpointsProperties `json:"endpoints"`
+	Device            DeviceProperties    `json:"device"`
+	Par               ParProperties       `json:"par"`
 }
 
 type IssuerProperties struct {
@@ -57,6 +61,31 @@ type EndpointsProperties struct {
 	UserInfo        string `json:"user-info"`
 	JwkSet          string `json:"jwk-set"`
 	SamlMetadata    string `json:"saml-metadata"`
+	// DeviceAuthorization is the device authorization endpoint of OAuth2 Device Authorization Grant (RFC 8628)
+	DeviceAuthorization string `json:"device-authorization"`
+	// DeviceVerification is the page where end user enter the user code and approve/deny the device
+	DeviceVerification string `json:"device-verification"`
+	// PushedAuthorization is the pushed authorization request endpoint (RFC 9126)
+	PushedAuthorization string `json:"pushed-authorization"`
+}
+
+// DeviceProperties configures OAuth2 Device Authorization Grant (RFC 8628)
+type DeviceProperties struct {
+	// Validity is the lifetime of device code and user code
+	Validity utils.Duration `json:"validity"`
+	// Interval is the minimum amount of time that the client should wait between polling requests
+	Interval utils.Duration `json:"interval"`
+	// MaxAttempts is the max number of failed user code attempts per user within AttemptWindow.
+	// Zero or negative value disables the limit. See https://datatracker.ietf.org/doc/html/rfc8628#section-5.1
+	MaxAttempts int `json:"max-attempts"`
+	// AttemptWindow is how long failed user code attempts are tracked, starting from the first failure
+	AttemptWindow utils.Duration `json:"attempt-window"`
+}
+
+// ParProperties configures OAuth2 Pushed Authorization Requests (RFC 9126)
+type ParProperties struct {
+	// Validity is the lifetime of "request_uri" issued by pushed authorization request endpoint
+	Validity utils.Duration `json:"validity"`
 }
 
 // NewAuthServerProperties create a SessionProperties with default values
@@ -71,17 +100,29 @@ func NewAuthServerProperties() *AuthServerProperties {
 		},
 		RedirectWhitelist: []string{},
 		Endpoints: EndpointsProperties{
-			Authorize:       "/v2/authorize",
-			Token:           "/v2/token",
-			Approval:        "/v2/approve",
-			CheckToken:      "/v2/check_token",
-			TenantHierarchy: "/v2/tenant_hierarchy",
-			Error:           "/error",
-			Logout:          "/v2/logout",
-			UserInfo:        "/v2/userinfo",
-			JwkSet:          "/v2/jwks",
-			SamlMetadata:    "/metadata",
-			LoggedOut:       "/",
+			Authorize:           "/v2/authorize",
+			Token:               "/v2/token",
+			Approval:            "/v2/approve",
+			CheckToken:          "/v2/check_token",
+			TenantHierarchy:     "/v2/tenant_hierarchy",
+			Error:               "/error",
+			Logout:              "/v2/logout",
+			UserInfo:            "/v2/userinfo",
+			JwkSet:              "/v2/jwks",
+			SamlMetadata:        "/metadata",
+			LoggedOut:           "/",
+			DeviceAuthorization: "/v2/device_authorization",
+			DeviceVerification:  "/v2/device",
+			PushedAuthorization: "/v2/par",
+		},
+		Device: DeviceProperties{
+			Validity:      utils.Duration(10 * time.Minute),
+			Interval:      utils.Duration(5 * time.Second),
+			MaxAttempts:   5,
+			AttemptWindow: utils.Duration(15 * time.Minute),
+		},
+		Par: ParProperties{
+			Validity: utils.Duration(90 * time.Second),
 		},
 	}
 }
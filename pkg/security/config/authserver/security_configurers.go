@@ -73,12 +73,17 @@ func (c *ClientAuthEndpointsConfigurer) Configure(ws security.WebSecurity) {
 	// For Token endpoint
 	ws.Route(matcher.RouteWithPattern(c.config.Endpoints.Token)).
 		Route(matcher.RouteWithPattern(c.config.Endpoints.CheckToken)).
+		Route(matcher.RouteWithPattern(c.config.Endpoints.DeviceAuthorization)).
+		Route(matcher.RouteWithPattern(c.config.Endpoints.PushedAuthorization)).
 		Route(matcher.RouteWithPattern(fmt.Sprintf("%s/*", c.config.Endpoints.TenantHierarchy))).
 		With(clientauth.New().
 			ClientStore(c.config.ClientStore).
 			ClientSecretEncoder(c.config.clientSecretEncoder()).
 			ErrorHandler(c.config.errorHandler()).
-			AllowForm(true), // AllowForm also implicitly enables Public Client
+			AllowForm(true). // AllowForm also implicitly enables Public Client
+			AllowAssertion(true).
+			AssertionAudiences(c.config.clientAssertionAudiences()...).
+			AssertionReplayStore(c.config.clientAssertionReplayStore()),
 		).
 		// uncomment following if we want CheckToken to not allow public client
 		//With(access.Configure(ws).
@@ -87,7 +92,9 @@ func (c *ClientAuthEndpointsConfigurer) Configure(ws security.WebSecurity) {
 		//).
 		With(token.NewEndpoint().
 			Path(c.config.Endpoints.Token).
-			AddGranter(c.config.tokenGranter()),
+			AddGranter(c.config.tokenGranter()).
+			DPoPVerifier(c.config.dpopVerifier()).
+			DPoPUrl(c.config.dpopUrl()),
 		)
 }
 
@@ -135,7 +142,8 @@ func (c *AuthorizeEndpointConfigurer) Configure(ws security.WebSecurity) {
 			RequestProcessor(c.config.authorizeRequestProcessor()).
 			ErrorHandler(c.config.errorHandler()).
 			AuthorizeHanlder(c.config.authorizeHandler()).
-			ApprovalStore(c.config.approvalStore()),
+			ApprovalStore(c.config.approvalStore()).
+			PushedRequestStore(c.config.pushedAuthorizationRequestStore()),
 		).
 		Route(matcher.RouteWithPattern(c.config.Endpoints.SamlSso.Location.Path)).
 		With(samlidp.New().
@@ -144,7 +152,9 @@ func (c *AuthorizeEndpointConfigurer) Configure(ws security.WebSecurity) {
 			SsoLocation(c.config.Endpoints.SamlSso.Location).
 			MetadataPath(c.config.Endpoints.SamlMetadata).
 			EnableSLO(c.config.Endpoints.Logout).
-			SigningMethod(c.config.SamlIdpSigningMethod))
+			SigningMethod(c.config.SamlIdpSigningMethod)).
+		// device verification page is protected the same way as authorize endpoint (e.g. form login) by IDP delegate
+		Route(matcher.RouteWithPattern(c.config.Endpoints.DeviceVerification))
 
 	c.delegate.Configure(ws, c.config)
 }
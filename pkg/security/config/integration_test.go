@@ -17,7 +17,11 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -40,6 +44,8 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/authorize"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/clientauth"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/token"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
 	"github.com/cisco-open/go-lanai/pkg/security/passwd"
 	"github.com/cisco-open/go-lanai/pkg/security/request_cache"
 	samlctx "github.com/cisco-open/go-lanai/pkg/security/saml"
@@ -59,14 +65,17 @@ import (
 	. "github.com/cisco-open/go-lanai/test/utils/gomega"
 	"github.com/cisco-open/go-lanai/test/webtest"
 	"github.com/crewjam/saml"
+	gojwt "github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/onsi/gomega"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 	"go.uber.org/fx"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -86,6 +95,13 @@ const (
 	TestTenantedClientID3     = "tenant-client-3"
 	TestApprovalClientID      = "test-approval-client"
 	TestApprovalClientID2     = "test-approval-client-2"
+	TestDeviceClientID        = "test-device-client"
+	TestExchangeClientID      = "test-exchange-client"
+	TestParClientID           = "test-par-client"
+	TestPrivateKeyJwtClientID = "test-private-key-jwt-client"
+	TestEncryptedClientID     = "test-encrypted-client"
+	TestSecretJwtClientID     = "test-secret-jwt-client"
+	TestAssertionKeyName      = "test-assertion-key"
 	TestClientSecret          = "test-secret"
 	TestOAuth2CallbackURL     = "http://localhost/oauth/callback"
 )
@@ -157,6 +173,7 @@ type intDI struct {
 	Mocking         sectest.MockingProperties
 	TokenReader     oauth2.TokenStoreReader
 	SessionStore    session.Store
+	CryptoProps     jwt.CryptoProperties
 }
 
 func TestWithMockedServer(t *testing.T) {
@@ -203,6 +220,16 @@ func TestWithMockedServer(t *testing.T) {
 		test.GomegaSubTest(SubTestOAuth2AuthCodeWithTenantClient(di), "TestOAuth2AuthCodeWithTenantClient"),
 		test.GomegaSubTest(SubTestOAuth2PasswordGrant(di), "TestOAuth2PasswordGrant"),
 		test.GomegaSubTest(SubTestTenantClientCredential(di), "TestTenantClientCredential"),
+		test.GomegaSubTest(SubTestOAuth2DeviceCode(di), "TestOAuth2DeviceCode"),
+		test.GomegaSubTest(SubTestOAuth2DeviceCodeFailedAttempts(di), "TestOAuth2DeviceCodeFailedAttempts"),
+		test.GomegaSubTest(SubTestOAuth2TokenExchange(di), "TestOAuth2TokenExchange"),
+		test.GomegaSubTest(SubTestOAuth2ClientAssertion(di), "TestOAuth2ClientAssertion"),
+		test.GomegaSubTest(SubTestOAuth2PushedAuthorization(di), "TestOAuth2PushedAuthorization"),
+		test.GomegaSubTest(SubTestOAuth2DPoP(di), "TestOAuth2DPoP"),
+		test.GomegaSubTest(SubTestOAuth2DPoPRefresh(di), "TestOAuth2DPoPRefresh"),
+		test.GomegaSubTest(SubTestOAuth2DPoPTokenExchange(di), "TestOAuth2DPoPTokenExchange"),
+		test.GomegaSubTest(SubTestOAuth2DPoPSwitchTenant(di), "TestOAuth2DPoPSwitchTenant"),
+		test.GomegaSubTest(SubTestOAuth2EncryptedResponses(di), "TestOAuth2EncryptedResponses"),
 
 		//switch tenants
 		test.GomegaSubTest(SubTestOauth2SwitchTenantWithPerTenantPermission(di), "TestOauth2SwitchTenantWithPerTenantPermission"),
@@ -405,6 +432,699 @@ func SubTestOAuth2AuthCode(di *intDI) test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestOAuth2DeviceCode(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		s, token, err := newSessionWithCsrfToken(di.SessionStore)
+		g.Expect(err).ToNot(HaveOccurred(), "session should be created")
+		// mock authentication
+		fedAccount := di.Mocking.FederatedUsers.MapValues()["fed1"]
+		ctx, e := contextWithSamlAuth(ctx, di.FedAccountStore, fedAccount)
+		g.Expect(e).To(Succeed(), "SAML auth should be stored correctly")
+
+		// device authorization
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/device_authorization", deviceAuthReqBody("read"),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret), deviceReqOptions())
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "device authorization should have correct status code")
+		deviceCode, userCode := assertDeviceAuthResponse(t, g, resp.Response)
+
+		// poll before user approval
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", deviceCodeReqBody(deviceCode),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationAuthorizationPending)
+
+		// poll again too fast
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", deviceCodeReqBody(deviceCode),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationSlowDown)
+
+		// poll with another client
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", deviceCodeReqBody(deviceCode),
+			tokenReqOptions(), withDefaultClientAuth())
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusBadRequest), "token response should have correct status code")
+
+		// user enters the code, expect confirmation page
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/device", nil,
+			deviceReqOptions(), webtest.Queries(oauth2.ParameterUserCode, strings.ToLower(userCode)),
+			cookieOptions(s.Name(), s.GetID()))
+		resp = webtest.MustExec(ctx, req)
+		assertAuthorizeRequireApprovalResponse(t, g, resp.Response)
+
+		// user approves
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/device", deviceVerificationReqBody(userCode, "true", token),
+			deviceReqOptions(), approvalReqOptions(), cookieOptions(s.Name(), s.GetID()))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "device verification should have correct status code")
+		body, e := io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed())
+		g.Expect(string(body)).To(ContainSubstring("Device Approved"), "device verification should show result")
+
+		// user code cannot be used again
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/device", deviceVerificationReqBody(userCode, "true", token),
+			deviceReqOptions(), approvalReqOptions(), cookieOptions(s.Name(), s.GetID()))
+		resp = webtest.MustExec(ctx, req)
+		body, e = io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed())
+		g.Expect(string(body)).ToNot(ContainSubstring("Device Approved"), "used user code should not be approved again")
+
+		// concurrent polls after approval, only one of them should get the token
+		const polls = 5
+		var wg sync.WaitGroup
+		responses := make([]*http.Response, polls)
+		for i := 0; i < polls; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				req := webtest.NewRequest(ctx, http.MethodPost, "/v2/token", deviceCodeReqBody(deviceCode),
+					tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret))
+				responses[i] = webtest.MustExec(ctx, req).Response
+			}(i)
+		}
+		wg.Wait()
+		var granted int
+		for _, r := range responses {
+			if r.StatusCode != http.StatusOK {
+				assertOAuth2ErrorResponse(t, g, r, oauth2.ErrorTranslationInvalidGrant)
+				continue
+			}
+			granted++
+			assertTokenResponse(t, g, r, fedAccount.Username, false)
+		}
+		g.Expect(granted).To(Equal(1), "approved device code should be consumed exactly once")
+
+		// device code is consumed
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", deviceCodeReqBody(deviceCode),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// user denies
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/device_authorization", deviceAuthReqBody(""),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret), deviceReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		deviceCode, userCode = assertDeviceAuthResponse(t, g, resp.Response)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/device", deviceVerificationReqBody(userCode, "false", token),
+			deviceReqOptions(), approvalReqOptions(), cookieOptions(s.Name(), s.GetID()))
+		resp = webtest.MustExec(ctx, req)
+		body, e = io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed())
+		g.Expect(string(body)).To(ContainSubstring("Device Denied"), "device verification should show result")
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", deviceCodeReqBody(deviceCode),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationAccessDenied)
+
+		// client without device grant
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/device_authorization", deviceAuthReqBody(""),
+			tokenReqOptions(), withDefaultClientAuth(), deviceReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationUnauthorizedClient)
+	}
+}
+
+func SubTestOAuth2DeviceCodeFailedAttempts(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		s, token, err := newSessionWithCsrfToken(di.SessionStore)
+		g.Expect(err).ToNot(HaveOccurred(), "session should be created")
+		fedAccount := di.Mocking.FederatedUsers.MapValues()["fed2"]
+		ctx, e := contextWithSamlAuth(ctx, di.FedAccountStore, fedAccount)
+		g.Expect(e).To(Succeed(), "SAML auth should be stored correctly")
+
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/device_authorization", deviceAuthReqBody("read"),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret), deviceReqOptions())
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "device authorization should have correct status code")
+		_, userCode := assertDeviceAuthResponse(t, g, resp.Response)
+
+		// user enters wrong codes until max attempts (5 by default)
+		for i := 0; i < 5; i++ {
+			req = webtest.NewRequest(ctx, http.MethodGet, "/v2/device", nil,
+				deviceReqOptions(), webtest.Queries(oauth2.ParameterUserCode, "BCDF-GHJK"),
+				cookieOptions(s.Name(), s.GetID()))
+			resp = webtest.MustExec(ctx, req)
+			body, e := io.ReadAll(resp.Response.Body)
+			g.Expect(e).To(Succeed())
+			g.Expect(string(body)).To(ContainSubstring("is not valid"), "wrong user code should be rejected")
+		}
+
+		// correct code is rejected as well
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/device", nil,
+			deviceReqOptions(), webtest.Queries(oauth2.ParameterUserCode, userCode),
+			cookieOptions(s.Name(), s.GetID()))
+		resp = webtest.MustExec(ctx, req)
+		body, e := io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed())
+		g.Expect(string(body)).To(ContainSubstring("too many failed attempts"), "user should be locked out")
+
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/device", deviceVerificationReqBody(userCode, "true", token),
+			deviceReqOptions(), approvalReqOptions(), cookieOptions(s.Name(), s.GetID()))
+		resp = webtest.MustExec(ctx, req)
+		body, e = io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed())
+		g.Expect(string(body)).To(ContainSubstring("too many failed attempts"), "user should be locked out")
+		g.Expect(string(body)).ToNot(ContainSubstring("Device Approved"), "device should not be approved during lockout")
+	}
+}
+
+func SubTestOAuth2TokenExchange(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// subject token obtained by another client
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/token", passwordGrantReqBody("", "regular", "regular"),
+			tokenReqOptions(), withDefaultClientAuth())
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "password grant should have correct status code")
+		subject := assertTokenResponse(t, g, resp.Response, "regular", false)
+		subjectAuth, e := di.TokenReader.ReadAuthentication(ctx, subject.Value(), oauth2.TokenHintAccessToken)
+		g.Expect(e).To(Succeed(), "subject token should be valid")
+
+		// actor token
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientCredentialReqBody(""),
+			tokenReqOptions(), withDefaultClientAuth())
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "client credentials grant should have correct status code")
+		actor := oauth2.NewDefaultAccessToken("")
+		g.Expect(json.NewDecoder(resp.Response.Body).Decode(actor)).To(Succeed(), "actor token should be parsable")
+
+		// minimum params, scopes are narrowed to what the client allows
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), "", "", ""),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token exchange should have correct status code")
+		exchanged := assertTokenExchangeResponse(t, g, resp.Response, "regular")
+		auth, e := di.TokenReader.ReadAuthentication(ctx, exchanged.Value(), oauth2.TokenHintAccessToken)
+		g.Expect(e).To(Succeed(), "exchanged token should be valid")
+		g.Expect(auth.OAuth2Request().ClientId()).To(Equal(TestExchangeClientID), "exchanged token should have correct client")
+		g.Expect(auth.OAuth2Request().Scopes().Values()).To(ConsistOf("scope_a"), "exchanged token should have narrowed scopes")
+		assertUserAuth(t, g, auth, "id-regular",
+			subjectAuth.Details().(security.TenantDetails).TenantId(),
+			subjectAuth.Details().(TenantAccessDetails).EffectiveAssignedTenantIds(), "test-provider")
+		assertTokenClaim(t, g, exchanged, "$.aud[*]", ConsistOf("api-a", "api-b"))
+		assertTokenClaim(t, g, exchanged, "$.act.client_id", ContainElement(TestExchangeClientID))
+		assertTokenClaim(t, g, exchanged, "$.act.sub", BeEmpty())
+
+		// with audience and actor token
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), actor.Value(), "api-a", "scope_a"),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token exchange should have correct status code")
+		exchanged = assertTokenExchangeResponse(t, g, resp.Response, "regular")
+		assertTokenClaim(t, g, exchanged, "$.aud", ContainElement("api-a"))
+		assertTokenClaim(t, g, exchanged, "$.act.client_id", ContainElement(TestClientID))
+
+		// exchange the exchanged token again, "act" claim should be chained
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(exchanged.Value(), "", "", ""),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token exchange should have correct status code")
+		exchanged = assertTokenExchangeResponse(t, g, resp.Response, "regular")
+		_, e = di.TokenReader.ReadAuthentication(ctx, exchanged.Value(), oauth2.TokenHintAccessToken)
+		g.Expect(e).To(Succeed(), "exchanged token should be valid")
+		assertTokenClaim(t, g, exchanged, "$.act.client_id", ContainElement(TestExchangeClientID))
+		assertTokenClaim(t, g, exchanged, "$.act.act.client_id", ContainElement(TestClientID))
+
+		// audience not allowed
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), "", "api-c", ""),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidTarget)
+
+		// scope not granted to subject token
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), "", "", oauth2.ScopeRead),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusBadRequest), "token exchange with wider scope should fail")
+
+		// subject token without user
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(actor.Value(), "", "", ""),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// client without token exchange grant
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), "", "", ""),
+			tokenReqOptions(), withClientAuth(TestDeviceClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusBadRequest), "token exchange should fail without grant")
+	}
+}
+
+func SubTestOAuth2ClientAssertion(_ *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		keyStore := jwt.NewFileJwkStore(jwt.CryptoProperties{
+			Keys: map[string]jwt.CryptoKeyProperties{
+				TestAssertionKeyName: {KeyFormat: "pem", Location: "testdata/client_assertion_key.pem"},
+			},
+		})
+		secretStore := jwt.NewJwkSetStore(jwt.NewPrivateJwk(TestSecretJwtClientID, TestSecretJwtClientID, []byte(TestClientSecret)))
+		audience := fmt.Sprintf("http://msx.com:8900%s/v2/token", webtest.CurrentContextPath(ctx))
+		privateKeyJwt := jwt.NewSignedJwtEncoder(jwt.SignWithJwkStore(keyStore, TestAssertionKeyName), jwt.SignWithMethod(nil))
+		secretJwt := jwt.NewSignedJwtEncoder(jwt.SignWithJwkStore(secretStore, TestSecretJwtClientID), jwt.SignWithMethod(gojwt.SigningMethodHS256))
+
+		// private_key_jwt
+		assertion := mustClientAssertion(ctx, g, privateKeyJwt, TestPrivateKeyJwtClientID, audience, time.Minute)
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestPrivateKeyJwtClientID, assertion),
+			tokenReqOptions())
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "private_key_jwt should have correct status code")
+
+		// same assertion cannot be used again
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestPrivateKeyJwtClientID, assertion),
+			tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+
+		// client_secret_jwt, "client_id" is optional
+		assertion = mustClientAssertion(ctx, g, secretJwt, TestSecretJwtClientID, audience, time.Minute)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody("", assertion),
+			tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "client_secret_jwt should have correct status code")
+
+		// issuer identifier is also accepted as audience
+		assertion = mustClientAssertion(ctx, g, secretJwt, TestSecretJwtClientID, strings.TrimSuffix(audience, "/v2/token"), time.Minute)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestSecretJwtClientID, assertion),
+			tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "assertion with issuer as audience should have correct status code")
+
+		// wrong audience
+		assertion = mustClientAssertion(ctx, g, privateKeyJwt, TestPrivateKeyJwtClientID, "http://other.com/v2/token", time.Minute)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestPrivateKeyJwtClientID, assertion),
+			tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+
+		// expired assertion
+		assertion = mustClientAssertion(ctx, g, privateKeyJwt, TestPrivateKeyJwtClientID, audience, -time.Minute)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestPrivateKeyJwtClientID, assertion),
+			tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+
+		// mismatched client_id
+		assertion = mustClientAssertion(ctx, g, privateKeyJwt, TestPrivateKeyJwtClientID, audience, time.Minute)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestSecretJwtClientID, assertion),
+			tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+
+		// assertion signed by wrong key
+		assertion = mustClientAssertion(ctx, g, secretJwt, TestPrivateKeyJwtClientID, audience, time.Minute)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestPrivateKeyJwtClientID, assertion),
+			tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+
+		// JWT clients cannot authenticate with secret
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientCredentialReqBody(""),
+			tokenReqOptions(), withClientAuth(TestSecretJwtClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+
+		// multiple client authentication methods
+		assertion = mustClientAssertion(ctx, g, secretJwt, TestSecretJwtClientID, audience, time.Minute)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", clientAssertionReqBody(TestClientID, assertion),
+			tokenReqOptions(), withDefaultClientAuth())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+	}
+}
+
+func SubTestOAuth2PushedAuthorization(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// push authorize request
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/par", parReqBody("read", ""),
+			tokenReqOptions(), withClientAuth(TestParClientID, TestClientSecret))
+		resp := webtest.MustExec(ctx, req)
+		requestUri := assertParResponse(t, g, resp.Response)
+
+		// mock authentication
+		fedAccount := di.Mocking.FederatedUsers.MapValues()["fed1"]
+		ctx, e := contextWithSamlAuth(ctx, di.FedAccountStore, fedAccount)
+		g.Expect(e).To(Succeed(), "SAML auth should be stored correctly")
+
+		// authorize with request_uri
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/authorize", nil, parAuthorizeReqOptions(TestParClientID, requestUri))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusFound), "authorize response should have correct status code")
+		assertAuthorizeResponse(t, g, resp.Response, false)
+		loc, e := url.Parse(resp.Response.Header.Get("Location"))
+		g.Expect(e).To(Succeed(), "authorize redirect location should be a valid URL")
+		g.Expect(loc.Query().Get(oauth2.ParameterState)).To(Equal("test-state"), "authorize redirect should have pushed state")
+		code := extractAuthCode(resp.Response)
+
+		// token
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", authCodeReqBody(code, TestParClientID, ""), tokenReqOptions())
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token response should have correct status code")
+		assertTokenResponse(t, g, resp.Response, fedAccount.Username, true)
+
+		// request_uri cannot be used again
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/authorize", nil, parAuthorizeReqOptions(TestParClientID, requestUri))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(extractAuthCode(resp.Response)).To(BeEmpty(), "used request_uri should not be accepted")
+
+		// request_uri cannot be used by other client
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/par", parReqBody("read", ""),
+			tokenReqOptions(), withClientAuth(TestParClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		requestUri = assertParResponse(t, g, resp.Response)
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/authorize", nil, parAuthorizeReqOptions(TestClientID, requestUri))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(extractAuthCode(resp.Response)).To(BeEmpty(), "request_uri of other client should not be accepted")
+
+		// client requiring PAR cannot use inline parameters
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/authorize", nil, authorizeReqOptions(TestParClientID))
+		resp = webtest.MustExec(ctx, req)
+		assertAuthorizeResponse(t, g, resp.Response, true)
+
+		// client not requiring PAR can still use inline parameters
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/authorize", nil, authorizeReqOptions(TestClientID))
+		resp = webtest.MustExec(ctx, req)
+		assertAuthorizeResponse(t, g, resp.Response, false)
+
+		// invalid pushed requests
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/par", parReqBody("read", requestUri),
+			tokenReqOptions(), withClientAuth(TestParClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidRequest)
+
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/par", parReqBody("admin", ""),
+			tokenReqOptions(), withClientAuth(TestParClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidScope)
+
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/par", parReqBody("read", ""),
+			tokenReqOptions(), withClientAuth(TestParClientID, "wrong-secret"))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidClient)
+	}
+}
+
+func SubTestOAuth2EncryptedResponses(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		keyStore := jwt.NewFileJwkStore(jwt.CryptoProperties{
+			Keys: map[string]jwt.CryptoKeyProperties{
+				TestAssertionKeyName: {KeyFormat: "pem", Location: "testdata/client_assertion_key.pem"},
+			},
+		})
+		verifier := jwt.NewSignedJwtDecoder(jwt.VerifyWithJwkStore(jwt.NewFileJwkStore(di.CryptoProps), "jwt"))
+		decoder := jwt.NewEncryptedJwtDecoder(
+			jwt.DecryptWithJwkStore(keyStore, TestAssertionKeyName),
+			jwt.DecryptAndVerifyWith(verifier),
+		)
+
+		// ID token should be encrypted with client's key
+		body := url.Values{}
+		body.Set(oauth2.ParameterGrantType, oauth2.GrantTypePassword)
+		body.Set(oauth2.ParameterUsername, "regular")
+		body.Set(oauth2.ParameterPassword, "regular")
+		body.Set(oauth2.ParameterScope, "read openid")
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/token", strings.NewReader(body.Encode()),
+			tokenReqOptions(), withClientAuth(TestEncryptedClientID, TestClientSecret))
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token response should have correct status code")
+		var tokenResp struct {
+			AccessToken string `json:"access_token"`
+			IdToken     string `json:"id_token"`
+		}
+		g.Expect(json.NewDecoder(resp.Response.Body).Decode(&tokenResp)).To(Succeed(), "token response should be JSON")
+		g.Expect(jwt.IsJwe(tokenResp.IdToken)).To(BeTrue(), "id_token should be encrypted")
+		idToken, e := decoder.Decode(ctx, tokenResp.IdToken)
+		g.Expect(e).To(Succeed(), "id_token should be decrypted and verified")
+		g.Expect(idToken.Get(oauth2.ClaimAudience)).To(Equal(TestEncryptedClientID), "id_token should have correct audience")
+
+		// JWT userinfo should be encrypted
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withBearerAuth(tokenResp.AccessToken),
+			func(req *http.Request) {
+				req.Header.Set("Accept", "application/jwt")
+			})
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "userinfo response should have correct status code")
+		userinfo, e := io.ReadAll(resp.Response.Body)
+		g.Expect(e).To(Succeed(), "userinfo response should be readable")
+		g.Expect(jwt.IsJwe(string(userinfo))).To(BeTrue(), "userinfo should be encrypted")
+		claims, e := decoder.Decode(ctx, string(userinfo))
+		g.Expect(e).To(Succeed(), "userinfo should be decrypted and verified")
+		g.Expect(claims.Get(oauth2.ClaimPreferredUsername)).To(Equal("regular"), "userinfo should have correct username")
+	}
+}
+
+func SubTestOAuth2DPoP(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		g.Expect(e).To(Succeed(), "generating DPoP key should not fail")
+		signer, e := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", key))
+		g.Expect(e).To(Succeed(), "creating DPoP signer should not fail")
+
+		// token request with DPoP proof
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/token", passwordGrantReqBody("", "regular", "regular"),
+			tokenReqOptions(), withClientAuth(TestClientID, TestClientSecret))
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req))
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token response should have correct status code")
+		accessToken := assertDPoPTokenResponse(t, g, resp.Response)
+		assertTokenClaim(t, g, accessToken, "$.cnf.jkt", ContainElement(signer.Thumbprint()))
+
+		// access resource with DPoP proof
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withDPoPAuth(accessToken.Value()))
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req, dpop.WithAccessToken(accessToken.Value())))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "resource with valid DPoP proof should be accessible")
+
+		// DPoP bound token cannot be used as bearer token
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withBearerAuth(accessToken.Value()))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusUnauthorized), "DPoP bound token should not be accepted as bearer token")
+
+		// DPoP bound token cannot be used without proof
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withDPoPAuth(accessToken.Value()))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusUnauthorized), "DPoP bound token should not be accepted without proof")
+		g.Expect(resp.Response.Header.Get("WWW-Authenticate")).To(HavePrefix(dpop.AuthScheme), "challenge should use DPoP scheme")
+
+		// DPoP bound token cannot be used with proof of other key
+		another, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		anotherSigner, _ := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", another))
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withDPoPAuth(accessToken.Value()))
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, anotherSigner, req, dpop.WithAccessToken(accessToken.Value())))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusUnauthorized), "DPoP bound token should not be accepted with proof of other key")
+
+		// proof cannot be replayed
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withDPoPAuth(accessToken.Value()))
+		proof := mustDPoPProof(g, signer, req, dpop.WithAccessToken(accessToken.Value()))
+		req.Header.Set(dpop.HeaderDPoP, proof)
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "resource with valid DPoP proof should be accessible")
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withDPoPAuth(accessToken.Value()))
+		req.Header.Set(dpop.HeaderDPoP, proof)
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusUnauthorized), "replayed DPoP proof should not be accepted")
+
+		// forwarded headers cannot alter expected "htu"
+		req = webtest.NewRequest(ctx, http.MethodGet, "/v2/userinfo", nil, withDPoPAuth(accessToken.Value()))
+		otherHostProof, e := signer.Sign(http.MethodGet, "https://other.example.com"+req.URL.Path, dpop.WithAccessToken(accessToken.Value()))
+		g.Expect(e).To(Succeed(), "signing DPoP proof should not fail")
+		req.Header.Set(dpop.HeaderDPoP, otherHostProof)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Forwarded-Host", "other.example.com")
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusUnauthorized), "DPoP proof of other host should not be accepted")
+
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", passwordGrantReqBody("", "regular", "regular"),
+			tokenReqOptions(), withClientAuth(TestClientID, TestClientSecret))
+		otherHostProof, e = signer.Sign(http.MethodPost, dpop.RequestURL(req))
+		g.Expect(e).To(Succeed(), "signing DPoP proof should not fail")
+		req.Header.Set(dpop.HeaderDPoP, otherHostProof)
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidDPoPProof)
+
+		// token request with invalid DPoP proof
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", passwordGrantReqBody("", "regular", "regular"),
+			tokenReqOptions(), withClientAuth(TestClientID, TestClientSecret))
+		req.Header.Set(dpop.HeaderDPoP, "invalid-proof")
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidDPoPProof)
+
+		// token request with client supplied thumbprint but without DPoP proof
+		values := url.Values{}
+		values.Set(oauth2.ParameterGrantType, oauth2.GrantTypePassword)
+		values.Set(oauth2.ParameterUsername, "regular")
+		values.Set(oauth2.ParameterPassword, "regular")
+		values.Set(oauth2.ExtDPoPJkt, signer.Thumbprint())
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", strings.NewReader(values.Encode()),
+			tokenReqOptions(), withClientAuth(TestClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token response should have correct status code")
+		bearer := assertTokenResponse(t, g, resp.Response, "regular", false)
+		bearerAuth, e := di.TokenReader.ReadAuthentication(ctx, bearer.Value(), oauth2.TokenHintAccessToken)
+		g.Expect(e).To(Succeed(), "token should be valid")
+		g.Expect(bearerAuth.OAuth2Request().Extensions()).ToNot(HaveKey(oauth2.ExtDPoPJkt), "token should not be bound to client supplied thumbprint")
+	}
+}
+
+func SubTestOAuth2DPoPRefresh(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		g.Expect(e).To(Succeed(), "generating DPoP key should not fail")
+		signer, e := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", key))
+		g.Expect(e).To(Succeed(), "creating DPoP signer should not fail")
+		another, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		anotherSigner, _ := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", another))
+
+		// obtain DPoP-bound grant via authorization code
+		fedAccount := di.Mocking.FederatedUsers.MapValues()["fed1"]
+		ctx, e = contextWithSamlAuth(ctx, di.FedAccountStore, fedAccount)
+		g.Expect(e).To(Succeed(), "SAML auth should be stored correctly")
+		req := webtest.NewRequest(ctx, http.MethodGet, "/v2/authorize", nil, authorizeReqOptions("test-client"))
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusFound), "authorize response should have correct status code")
+		code := extractAuthCode(resp.Response)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", authCodeReqBody(code, TestClientID, ""), tokenReqOptions())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token response should have correct status code")
+		accessToken := assertDPoPTokenResponse(t, g, resp.Response)
+		g.Expect(accessToken.RefreshToken()).ToNot(BeNil(), "token response should have refresh token")
+		refreshToken := accessToken.RefreshToken().Value()
+
+		// refresh without proof
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", requestNewAccessToken(refreshToken),
+			tokenReqOptions(), withDefaultClientAuth())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// refresh with client supplied thumbprint but without proof
+		values := url.Values{}
+		values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeRefresh)
+		values.Set(oauth2.ParameterRefreshToken, refreshToken)
+		values.Set(oauth2.ExtDPoPJkt, signer.Thumbprint())
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", strings.NewReader(values.Encode()),
+			tokenReqOptions(), withDefaultClientAuth())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// refresh with proof of other key
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", requestNewAccessToken(refreshToken),
+			tokenReqOptions(), withDefaultClientAuth())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, anotherSigner, req))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// refresh with proof of other key and reduced scope
+		values = url.Values{}
+		values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeRefresh)
+		values.Set(oauth2.ParameterRefreshToken, refreshToken)
+		values.Set(oauth2.ParameterScope, accessToken.Scopes().Values()[0])
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", strings.NewReader(values.Encode()),
+			tokenReqOptions(), withDefaultClientAuth())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, anotherSigner, req))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// refresh with proof of same key
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", requestNewAccessToken(refreshToken),
+			tokenReqOptions(), withDefaultClientAuth())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "refresh with proof of same key should succeed")
+		refreshed := assertDPoPTokenResponse(t, g, resp.Response)
+		assertTokenClaim(t, g, refreshed, "$.cnf.jkt", ContainElement(signer.Thumbprint()))
+	}
+}
+
+func SubTestOAuth2DPoPTokenExchange(_ *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		g.Expect(e).To(Succeed(), "generating DPoP key should not fail")
+		signer, e := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", key))
+		g.Expect(e).To(Succeed(), "creating DPoP signer should not fail")
+		another, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		anotherSigner, _ := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", another))
+
+		// DPoP-bound subject token
+		req := webtest.NewRequest(ctx, http.MethodPost, "/v2/token", passwordGrantReqBody("", "regular", "regular"),
+			tokenReqOptions(), withDefaultClientAuth())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req))
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token response should have correct status code")
+		subject := assertDPoPTokenResponse(t, g, resp.Response)
+
+		// exchange without proof
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), "", "", ""),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// exchange with proof of other key
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), "", "", ""),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, anotherSigner, req))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// exchange with proof of same key, exchanged token is bound to the same key
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", tokenExchangeReqBody(subject.Value(), "", "", ""),
+			tokenReqOptions(), withClientAuth(TestExchangeClientID, TestClientSecret))
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token exchange with proof of same key should succeed")
+		exchanged := assertDPoPTokenResponse(t, g, resp.Response)
+		assertTokenClaim(t, g, exchanged, "$.cnf.jkt", ContainElement(signer.Thumbprint()))
+	}
+}
+
+func SubTestOAuth2DPoPSwitchTenant(di *intDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		g.Expect(e).To(Succeed(), "generating DPoP key should not fail")
+		signer, e := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", key))
+		g.Expect(e).To(Succeed(), "creating DPoP signer should not fail")
+		another, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		anotherSigner, _ := dpop.NewProofSigner(jwt.NewPrivateJwk("", "", another))
+
+		// obtain DPoP-bound token via authorization code
+		fedAccount := di.Mocking.FederatedUsers.MapValues()["fed3"]
+		ctx, e = contextWithSamlAuth(ctx, di.FedAccountStore, fedAccount)
+		g.Expect(e).To(Succeed(), "SAML auth should be stored correctly")
+		req := webtest.NewRequest(ctx, http.MethodGet, "/v2/authorize", nil, authorizeReqOptions("test-client"))
+		resp := webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusFound), "authorize response should have correct status code")
+		code := extractAuthCode(resp.Response)
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", authCodeReqBody(code, TestClientID, ""), tokenReqOptions())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "token response should have correct status code")
+		accessToken := assertDPoPTokenResponse(t, g, resp.Response)
+
+		// switch tenant without proof
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", switchTenantBody(accessToken.Value(), "id-tenant-1"),
+			tokenReqOptions(), withDefaultClientAuth())
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// switch tenant with proof of other key
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", switchTenantBody(accessToken.Value(), "id-tenant-1"),
+			tokenReqOptions(), withDefaultClientAuth())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, anotherSigner, req))
+		resp = webtest.MustExec(ctx, req)
+		assertOAuth2ErrorResponse(t, g, resp.Response, oauth2.ErrorTranslationInvalidGrant)
+
+		// switch tenant with proof of same key, new token is bound to the same key
+		req = webtest.NewRequest(ctx, http.MethodPost, "/v2/token", switchTenantBody(accessToken.Value(), "id-tenant-1"),
+			tokenReqOptions(), withDefaultClientAuth())
+		req.Header.Set(dpop.HeaderDPoP, mustDPoPProof(g, signer, req))
+		resp = webtest.MustExec(ctx, req)
+		g.Expect(resp.Response.StatusCode).To(Equal(http.StatusOK), "switch tenant with proof of same key should succeed")
+		switched := assertDPoPTokenResponse(t, g, resp.Response)
+		assertTokenClaim(t, g, switched, "$.cnf.jkt", ContainElement(signer.Thumbprint()))
+	}
+}
+
 func SubTestOAuth2AuthCodeWithoutTenant(di *intDI) test.GomegaSubTestFunc {
 	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
 		// mock authentication
@@ -923,6 +1643,25 @@ func withClientAuth(clientId, secret string) webtest.RequestOptions {
 	return webtest.Headers("Authorization", fmt.Sprintf("Basic %s", v))
 }
 
+func withBearerAuth(accessToken string) webtest.RequestOptions {
+	return webtest.Headers("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+}
+
+func withDPoPAuth(accessToken string) webtest.RequestOptions {
+	return webtest.Headers("Authorization", fmt.Sprintf("%s %s", dpop.AuthScheme, accessToken))
+}
+
+func mustDPoPProof(g *gomega.WithT, signer *dpop.ProofSigner, req *http.Request, opts ...dpop.ProofClaimsOptions) string {
+	// token endpoint expects "htu" built from issuer, resources expect the request URL
+	htu := dpop.RequestURL(req)
+	if strings.HasSuffix(req.URL.Path, "/v2/token") {
+		htu = fmt.Sprintf("http://msx.com:8900%s", req.URL.Path)
+	}
+	proof, e := signer.Sign(req.Method, htu, opts...)
+	g.Expect(e).To(Succeed(), "signing DPoP proof should not fail")
+	return proof
+}
+
 func withDefaultClientAuth() webtest.RequestOptions {
 	return withClientAuth(TestClientID, TestClientSecret)
 }
@@ -1051,6 +1790,17 @@ func authorizeReqOptions(clientId string) webtest.RequestOptions {
 	}
 }
 
+func parAuthorizeReqOptions(clientId, requestUri string) webtest.RequestOptions {
+	return func(req *http.Request) {
+		req.Host = testdata.IdpDomainExtSAML
+		req.URL.Host = testdata.IdpDomainExtSAML
+		values := url.Values{}
+		values.Set(oauth2.ParameterClientId, clientId)
+		values.Set(oauth2.ParameterRequestUri, requestUri)
+		req.URL.RawQuery = values.Encode()
+	}
+}
+
 func approvalReqOptions() webtest.RequestOptions {
 	return func(req *http.Request) {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -1129,6 +1879,93 @@ func approveRequestBody(approval string, approvedScopes []string, csrfToken *csr
 	return strings.NewReader(values.Encode())
 }
 
+func deviceReqOptions() webtest.RequestOptions {
+	return func(req *http.Request) {
+		req.Host = testdata.IdpDomainExtSAML
+		req.URL.Host = testdata.IdpDomainExtSAML
+	}
+}
+
+func deviceAuthReqBody(scope string) io.Reader {
+	values := url.Values{}
+	if scope != "" {
+		values.Set(oauth2.ParameterScope, scope)
+	}
+	return strings.NewReader(values.Encode())
+}
+
+func deviceCodeReqBody(deviceCode string) io.Reader {
+	values := url.Values{}
+	values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeDeviceCode)
+	values.Set(oauth2.ParameterDeviceCode, deviceCode)
+	return strings.NewReader(values.Encode())
+}
+
+func deviceVerificationReqBody(userCode string, approval string, csrfToken *csrf.Token) io.Reader {
+	values := url.Values{}
+	values.Set(oauth2.ParameterUserCode, userCode)
+	values.Set(oauth2.ParameterUserApproval, approval)
+	values.Set(csrfToken.ParameterName, csrfToken.Value)
+	return strings.NewReader(values.Encode())
+}
+
+func tokenExchangeReqBody(subjectToken, actorToken, audience, scope string) io.Reader {
+	values := url.Values{}
+	values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeTokenExchange)
+	values.Set(oauth2.ParameterSubjectToken, subjectToken)
+	values.Set(oauth2.ParameterSubjectTokenType, oauth2.TokenTypeIdAccessToken)
+	if actorToken != "" {
+		values.Set(oauth2.ParameterActorToken, actorToken)
+		values.Set(oauth2.ParameterActorTokenType, oauth2.TokenTypeIdJwt)
+	}
+	if audience != "" {
+		values.Set(oauth2.ParameterAudience, audience)
+	}
+	if scope != "" {
+		values.Set(oauth2.ParameterScope, scope)
+	}
+	return strings.NewReader(values.Encode())
+}
+
+func clientAssertionReqBody(clientId string, assertion string) io.Reader {
+	values := url.Values{}
+	values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeClientCredentials)
+	values.Set(oauth2.ParameterClientAssertionType, oauth2.ClientAssertionTypeJwtBearer)
+	values.Set(oauth2.ParameterClientAssertion, assertion)
+	if clientId != "" {
+		values.Set(oauth2.ParameterClientId, clientId)
+	}
+	return strings.NewReader(values.Encode())
+}
+
+func mustClientAssertion(ctx context.Context, g *gomega.WithT, enc jwt.JwtEncoder, clientId, audience string, validity time.Duration) string {
+	now := time.Now()
+	claims := oauth2.BasicClaims{
+		Id:        uuid.New().String(),
+		Issuer:    clientId,
+		Subject:   clientId,
+		Audience:  oauth2.StringSetClaim(utils.NewStringSet(audience)),
+		IssuedAt:  now.Add(-2 * time.Minute),
+		ExpiresAt: now.Add(validity),
+	}
+	assertion, e := enc.Encode(ctx, &claims)
+	g.Expect(e).To(Succeed(), "client assertion should be signed")
+	return assertion
+}
+
+func parReqBody(scope string, requestUri string) io.Reader {
+	values := url.Values{}
+	values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeAuthCode)
+	values.Set(oauth2.ParameterResponseType, "code")
+	values.Set(oauth2.ParameterRedirectUri, "http://localhost/test/callback")
+	values.Set(oauth2.ParameterScope, scope)
+	values.Set(oauth2.ParameterState, "test-state")
+	if requestUri != "" {
+		values.Set(oauth2.ParameterRequestUri, requestUri)
+	}
+	return strings.NewReader(values.Encode())
+}
+
 func requestNewAccessToken(refreshToken string) io.Reader {
 	values := url.Values{}
 	values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeRefresh)
@@ -1157,6 +1994,74 @@ func assertTokenResponse(_ *testing.T, g *gomega.WithT, resp *http.Response, exp
 	return accessToken
 }
 
+func assertTokenExchangeResponse(t *testing.T, g *gomega.WithT, resp *http.Response, expectedUsername string) oauth2.AccessToken {
+	body, e := io.ReadAll(resp.Body)
+	g.Expect(e).To(Succeed(), `token response body should be readable`)
+	g.Expect(body).To(HaveJsonPathWithValue("$.issued_token_type", oauth2.TokenTypeIdAccessToken), "token response should have issued_token_type")
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return assertTokenResponse(t, g, resp, expectedUsername, false)
+}
+
+func assertDPoPTokenResponse(_ *testing.T, g *gomega.WithT, resp *http.Response) oauth2.AccessToken {
+	body, e := io.ReadAll(resp.Body)
+	g.Expect(e).To(Succeed(), `token response body should be readable`)
+	g.Expect(body).To(HaveJsonPath("$.access_token"), "token response should have access_token")
+	g.Expect(body).To(HaveJsonPathWithValue("$.token_type", ContainElement(string(oauth2.TokenTypeDPoP))), "token response should have DPoP token_type")
+
+	accessToken := oauth2.NewDefaultAccessToken("")
+	e = json.Unmarshal(body, accessToken)
+	g.Expect(e).ToNot(HaveOccurred())
+	return accessToken
+}
+
+func assertTokenClaim(_ *testing.T, g *gomega.WithT, token oauth2.AccessToken, jsonPath string, matcher types.GomegaMatcher) {
+	segments := strings.Split(token.Value(), ".")
+	g.Expect(segments).To(HaveLen(3), "access token should be a JWT")
+	payload, e := base64.RawURLEncoding.DecodeString(segments[1])
+	g.Expect(e).To(Succeed(), "access token's payload should be decodable")
+	g.Expect(payload).To(HaveJsonPathWithValue(jsonPath, matcher), "access token should have correct [%s]", jsonPath)
+}
+
+func assertDeviceAuthResponse(_ *testing.T, g *gomega.WithT, resp *http.Response) (deviceCode string, userCode string) {
+	body, e := io.ReadAll(resp.Body)
+	g.Expect(e).To(Succeed(), `device authorization response body should be readable`)
+	g.Expect(body).To(HaveJsonPath("$.device_code"), "device authorization response should have device_code")
+	g.Expect(body).To(HaveJsonPath("$.user_code"), "device authorization response should have user_code")
+	g.Expect(body).To(HaveJsonPathWithValue("$.verification_uri", ContainElement(HaveSuffix("/test/v2/device"))), "device authorization response should have verification_uri")
+	g.Expect(body).To(HaveJsonPath("$.verification_uri_complete"), "device authorization response should have verification_uri_complete")
+	g.Expect(body).To(HaveJsonPath("$.expires_in"), "device authorization response should have expires_in")
+	g.Expect(body).To(HaveJsonPath("$.interval"), "device authorization response should have interval")
+
+	var parsed struct {
+		DeviceCode string `json:"device_code"`
+		UserCode   string `json:"user_code"`
+	}
+	e = json.Unmarshal(body, &parsed)
+	g.Expect(e).ToNot(HaveOccurred())
+	return parsed.DeviceCode, parsed.UserCode
+}
+
+func assertParResponse(_ *testing.T, g *gomega.WithT, resp *http.Response) (requestUri string) {
+	g.Expect(resp.StatusCode).To(Equal(http.StatusCreated), "pushed authorization response should have correct status code")
+	body, e := io.ReadAll(resp.Body)
+	g.Expect(e).To(Succeed(), "pushed authorization response body should be readable")
+	var parsed struct {
+		RequestUri string `json:"request_uri"`
+		ExpiresIn  int    `json:"expires_in"`
+	}
+	g.Expect(json.Unmarshal(body, &parsed)).To(Succeed(), "pushed authorization response should be JSON")
+	g.Expect(parsed.RequestUri).To(HavePrefix(oauth2.RequestUriPrefixPushedAuthorization), "pushed authorization response should have request_uri")
+	g.Expect(parsed.ExpiresIn).To(BeNumerically(">", 0), "pushed authorization response should have expires_in")
+	return parsed.RequestUri
+}
+
+func assertOAuth2ErrorResponse(_ *testing.T, g *gomega.WithT, resp *http.Response, expectedError string) {
+	g.Expect(resp.StatusCode).To(BeNumerically(">=", 400), "response should be error")
+	body, e := io.ReadAll(resp.Body)
+	g.Expect(e).To(Succeed(), `error response body should be readable`)
+	g.Expect(body).To(HaveJsonPathWithValue("$.error", expectedError), "error response should have correct error")
+}
+
 func assertAuthorizeResponse(t *testing.T, g *gomega.WithT, resp *http.Response, expectErr bool) {
 	g.Expect(resp.Header.Get("Set-Cookie")).To(Not(BeEmpty()), "authorize response should set cookie")
 	expected, _ := url.Parse(ExpectedAuthorizeCallback)
@@ -24,6 +24,7 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/security/config/compatibility"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/common"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/tokenauth"
 	"go.uber.org/fx"
@@ -36,6 +37,7 @@ type resServerConfigDI struct {
 	AppContext         *bootstrap.ApplicationContext
 	RedisClientFactory redis.ClientFactory
 	CryptoProperties   jwt.CryptoProperties
+	DPoPProperties     dpop.DPoPProperties
 	TimeoutSupport     oauth2.TimeoutApplier `optional:"true"`
 	Configurer         ResourceServerConfigurer
 }
@@ -52,6 +54,7 @@ func ProvideResServerDI(di resServerConfigDI) resServerOut {
 	config := Configuration{
 		appContext:         di.AppContext,
 		cryptoProperties:   di.CryptoProperties,
+		dpopProperties:     di.DPoPProperties,
 		redisClientFactory: di.RedisClientFactory,
 		timeoutSupport:     di.TimeoutSupport,
 		RemoteEndpoints: RemoteEndpoints{
@@ -80,6 +83,7 @@ func ConfigureResourceServer(di resServerDI) {
 	// register token auth feature
 	configurer := tokenauth.NewTokenAuthConfigurer(func(opt *tokenauth.TokenAuthOption) {
 		opt.TokenStoreReader = di.Config.tokenStoreReader()
+		opt.DPoPVerifier = di.Config.dpopVerifier()
 	})
 	di.SecurityRegistrar.(security.FeatureRegistrar).RegisterFeature(tokenauth.FeatureId, configurer)
 }
@@ -100,11 +104,13 @@ type Configuration struct {
 	RemoteEndpoints  RemoteEndpoints
 	TokenStoreReader oauth2.TokenStoreReader
 	JwkStore         jwt.JwkStore
+	DPoPVerifier     *dpop.ProofVerifier
 
 	// not directly configurable items
 	appContext                *bootstrap.ApplicationContext
 	redisClientFactory        redis.ClientFactory
 	cryptoProperties          jwt.CryptoProperties
+	dpopProperties            dpop.DPoPProperties
 	sharedTokenAuthenticator  security.Authenticator
 	sharedErrorHandler        *tokenauth.OAuth2ErrorHandler
 	sharedContextDetailsStore security.ContextDetailsStore
@@ -162,3 +168,12 @@ func (c *Configuration) tokenAuthenticator() security.Authenticator {
 	}
 	return c.sharedTokenAuthenticator
 }
+
+func (c *Configuration) dpopVerifier() *dpop.ProofVerifier {
+	if c.DPoPVerifier == nil {
+		c.DPoPVerifier = dpop.NewProofVerifier(dpop.WithProperties(&c.dpopProperties), func(opt *dpop.VerifierOption) {
+			opt.ReplayStore = dpop.NewRedisReplayStore(c.appContext, c.redisClientFactory, c.dpopProperties.DbIndex)
+		})
+	}
+	return c.DPoPVerifier
+}
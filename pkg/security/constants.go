@@ -70,6 +70,8 @@ const (
 	AuthMethodPassword       = "Password"
 	AuthMethodExternalSaml   = "ExtSAML"
 	AuthMethodExternalOpenID = "ExtOpenID"
+	AuthMethodWebAuthn       = "WebAuthn"
+	AuthMethodX509           = "X509"
 )
 
 const (
@@ -90,6 +92,7 @@ const (
 	MWOrderOAuth2AuthValidation
 	MWOrderSAMLMetadataRefresh
 	MWOrderPreAuth
+	MWOrderX509Auth
 	MWOrderBasicAuth
 	MWOrderFormLogout
 	MWOrderFormAuth
@@ -104,11 +107,16 @@ const (
 const (
 	_ = iota * 100
 	FeatureOrderOAuth2ClientAuth
+	FeatureOrderWebAuthn
+	FeatureOrderMFAEnrollment
 	FeatureOrderAuthenticator
+	FeatureOrderX509Auth
 	FeatureOrderBasicAuth
 	FeatureOrderFormLogin
 	FeatureOrderSamlLogin
 	FeatureOrderSamlLogout
+	FeatureOrderOidcLogin
+	FeatureOrderOidcLogout
 	FeatureOrderLogout
 	FeatureOrderOAuth2TokenEndpoint
 	FeatureOrderOAuth2AuthorizeEndpoint
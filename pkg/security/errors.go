@@ -46,6 +46,7 @@ const (
 	ErrorSubTypeCodeUsernamePasswordAuth
 	ErrorSubTypeCodeExternalSamlAuth
 	ErrorSubTypeCodeAuthWarning
+	ErrorSubTypeCodeExternalOidcAuth
 )
 
 // ErrorSubTypeCodeInternal
@@ -100,6 +101,7 @@ var (
 	ErrorSubTypeUsernamePasswordAuth = NewErrorSubType(ErrorSubTypeCodeUsernamePasswordAuth, errors.New("error sub-type: internal"))
 	ErrorSubTypeExternalSamlAuth     = NewErrorSubType(ErrorSubTypeCodeExternalSamlAuth, errors.New("error sub-type: external saml"))
 	ErrorSubTypeAuthWarning          = NewErrorSubType(ErrorSubTypeCodeAuthWarning, errors.New("error sub-type: auth warning"))
+	ErrorSubTypeExternalOidcAuth     = NewErrorSubType(ErrorSubTypeCodeExternalOidcAuth, errors.New("error sub-type: external oidc"))
 
 	ErrorSubTypeAccessDenied     = NewErrorSubType(ErrorSubTypeCodeAccessDenied, errors.New("error sub-type: access denied"))
 	ErrorSubTypeInsufficientAuth = NewErrorSubType(ErrorSubTypeCodeInsufficientAuth, errors.New("error sub-type: insufficient auth"))
@@ -175,6 +177,10 @@ func NewExternalSamlAuthenticationError(value interface{}, causes ...interface{}
 	return NewCodedError(ErrorSubTypeCodeExternalSamlAuth, value, causes...)
 }
 
+func NewExternalOidcAuthenticationError(value interface{}, causes ...interface{}) error {
+	return NewCodedError(ErrorSubTypeCodeExternalOidcAuth, value, causes...)
+}
+
 func NewUsernameNotFoundError(value interface{}, causes ...interface{}) error {
 	return NewCodedError(ErrorCodeUsernameNotFound, value, causes...)
 }
@@ -177,9 +177,10 @@ func (c *FormLoginConfigurer) configureMfaPage(f *FormLoginFeature, ws security.
 	ws.Route(routeMatcher)
 
 	// configure access
+	// Note: MFA page is shared by all second factors, OTP is not required
 	access.Configure(ws).
 		Request(requestMatcher).WithOrder(order.Highest).
-		HasPermissions(passwd.SpecialPermissionMFAPending, passwd.SpecialPermissionOtpId)
+		HasPermissions(passwd.SpecialPermissionMFAPending)
 
 	return nil
 }
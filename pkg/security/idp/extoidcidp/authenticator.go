@@ -0,0 +1,147 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+)
+
+func init() {
+	gob.Register((*oidcAuthentication)(nil))
+}
+
+// IdTokenCandidate implements security.Candidate
+type IdTokenCandidate struct {
+	Provider   OpenIDIdentityProvider
+	IdToken    *IdToken
+	DetailsMap map[string]interface{}
+}
+
+func (c *IdTokenCandidate) Principal() interface{} {
+	v, _ := c.IdToken.Claims.Get(c.Provider.ExternalIdName()).(string)
+	return v
+}
+
+func (c *IdTokenCandidate) Credentials() interface{} {
+	return c.IdToken
+}
+
+func (c *IdTokenCandidate) Details() interface{} {
+	return c.DetailsMap
+}
+
+// OidcAuthentication is the security.Authentication of users logged in with external OpenID provider
+type OidcAuthentication interface {
+	security.Authentication
+	// IdToken returns the raw ID token issued by the external OpenID provider
+	IdToken() string
+	// Issuer returns the "iss" of the external OpenID provider
+	Issuer() string
+}
+
+type oidcAuthentication struct {
+	Account      security.Account
+	Perms        map[string]interface{}
+	DetailsMap   map[string]interface{}
+	IdTokenValue string
+	IssuerValue  string
+	Domain       string
+}
+
+func (a *oidcAuthentication) Principal() interface{} {
+	return a.Account
+}
+
+func (a *oidcAuthentication) Permissions() security.Permissions {
+	return a.Perms
+}
+
+func (a *oidcAuthentication) State() security.AuthenticationState {
+	return security.StateAuthenticated
+}
+
+func (a *oidcAuthentication) Details() interface{} {
+	return a.DetailsMap
+}
+
+func (a *oidcAuthentication) IdToken() string {
+	return a.IdTokenValue
+}
+
+func (a *oidcAuthentication) Issuer() string {
+	return a.IssuerValue
+}
+
+// Authenticator implements security.Authenticator.
+// It maps validated ID token to user account via security.FederatedAccountStore
+type Authenticator struct {
+	accountStore security.FederatedAccountStore
+}
+
+func NewAuthenticator(accountStore security.FederatedAccountStore) *Authenticator {
+	return &Authenticator{
+		accountStore: accountStore,
+	}
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, candidate security.Candidate) (security.Authentication, error) {
+	can, ok := candidate.(*IdTokenCandidate)
+	if !ok {
+		return nil, nil
+	}
+
+	extId, _ := can.Principal().(string)
+	if len(extId) == 0 {
+		return nil, security.NewExternalOidcAuthenticationError(fmt.Sprintf("ID token doesn't have claim [%s]", can.Provider.ExternalIdName()))
+	}
+
+	user, e := a.accountStore.LoadAccountByExternalId(ctx, can.Provider.ExternalIdName(), extId, can.Provider.ExternalIdpName(),
+		can.Provider.GetAutoCreateUserDetails(), can.IdToken.Claims.Values())
+	if e != nil {
+		return nil, security.NewInternalAuthenticationError(e)
+	}
+
+	if user.Disabled() {
+		return nil, security.NewAccountStatusError("Account Disabled")
+	}
+
+	permissions := map[string]interface{}{}
+	for _, p := range user.Permissions() {
+		permissions[p] = true
+	}
+
+	details := can.DetailsMap
+	if details == nil {
+		details = make(map[string]interface{})
+	}
+	details[security.DetailsKeyAuthTime] = claimTime(can.IdToken.Claims.Get(oauth2.ClaimIssueAt))
+	details[security.DetailsKeyAuthMethod] = security.AuthMethodExternalOpenID
+
+	issuer, _ := can.IdToken.Claims.Get(oauth2.ClaimIssuer).(string)
+	return &oidcAuthentication{
+		Account:      user,
+		Perms:        permissions,
+		DetailsMap:   details,
+		IdTokenValue: can.IdToken.Value,
+		IssuerValue:  issuer,
+		Domain:       can.Provider.Domain(),
+	}, nil
+}
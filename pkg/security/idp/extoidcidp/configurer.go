@@ -0,0 +1,154 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/access"
+	"github.com/cisco-open/go-lanai/pkg/security/csrf"
+	"github.com/cisco-open/go-lanai/pkg/security/errorhandling"
+	"github.com/cisco-open/go-lanai/pkg/security/logout"
+	"github.com/cisco-open/go-lanai/pkg/security/redirect"
+	"github.com/cisco-open/go-lanai/pkg/security/request_cache"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"github.com/cisco-open/go-lanai/pkg/web/mapping"
+	"github.com/cisco-open/go-lanai/pkg/web/matcher"
+)
+
+// oidcConfigurer is a base implementation for both login and logout configurer.
+type oidcConfigurer struct {
+	registry *ProviderRegistry
+	rp       *RelyingParty
+}
+
+func newOidcConfigurer(registry *ProviderRegistry) *oidcConfigurer {
+	return &oidcConfigurer{
+		registry: registry,
+		rp:       NewRelyingParty(registry),
+	}
+}
+
+func (c *oidcConfigurer) effectiveSuccessHandler(f *Feature, ws security.WebSecurity) security.AuthenticationSuccessHandler {
+	if globalHandler, ok := ws.Shared(security.WSSharedKeyCompositeAuthSuccessHandler).(security.AuthenticationSuccessHandler); ok {
+		return security.NewAuthenticationSuccessHandler(globalHandler, f.successHandler)
+	} else {
+		return security.NewAuthenticationSuccessHandler(f.successHandler)
+	}
+}
+
+func (c *oidcConfigurer) savedRequestSuccessHandler() security.AuthenticationSuccessHandler {
+	return request_cache.NewSavedRequestAuthenticationSuccessHandler(
+		redirect.NewRedirectWithURL("/"),
+		func(from, to security.Authentication) bool {
+			return true
+		},
+	)
+}
+
+// OidcLoginConfigurer implements security.FeatureConfigurer for FeatureId
+type OidcLoginConfigurer struct {
+	*oidcConfigurer
+	accountStore security.FederatedAccountStore
+}
+
+func newOidcLoginConfigurer(shared *oidcConfigurer, accountStore security.FederatedAccountStore) *OidcLoginConfigurer {
+	return &OidcLoginConfigurer{
+		oidcConfigurer: shared,
+		accountStore:   accountStore,
+	}
+}
+
+func (c *OidcLoginConfigurer) Apply(feature security.Feature, ws security.WebSecurity) error {
+	f := feature.(*Feature)
+
+	m := c.makeMiddleware(f, ws)
+	ws.Route(matcher.RouteWithPattern(f.callbackPath)).
+		Add(mapping.Get(f.callbackPath).
+			HandlerFunc(m.CallbackHandlerFunc()).
+			Name("oidc login callback"))
+
+	access.Configure(ws).
+		Request(matcher.RequestWithPattern(f.callbackPath)).WithOrder(order.Highest).PermitAll()
+
+	//authentication entry point
+	errorhandling.Configure(ws).
+		AuthenticationEntryPoint(request_cache.NewSaveRequestEntryPoint(m))
+	return nil
+}
+
+func (c *OidcLoginConfigurer) makeMiddleware(f *Feature, ws security.WebSecurity) *LoginMiddleware {
+	if f.successHandler == nil {
+		f.successHandler = c.savedRequestSuccessHandler()
+	}
+	return NewLoginMiddleware(func(opt *LoginMiddlewareOption) {
+		opt.Registry = c.registry
+		opt.RelyingParty = c.rp
+		opt.Issuer = f.issuer
+		opt.CallbackPath = f.callbackPath
+		opt.Authenticator = NewAuthenticator(c.accountStore)
+		opt.SuccessHandler = c.effectiveSuccessHandler(f, ws)
+		opt.ErrorPath = f.errorPath
+	})
+}
+
+// OidcLogoutConfigurer implements security.FeatureConfigurer for LogoutFeatureId
+type OidcLogoutConfigurer struct {
+	*oidcConfigurer
+}
+
+func newOidcLogoutConfigurer(shared *oidcConfigurer) *OidcLogoutConfigurer {
+	return &OidcLogoutConfigurer{
+		oidcConfigurer: shared,
+	}
+}
+
+func (c *OidcLogoutConfigurer) Apply(feature security.Feature, ws security.WebSecurity) error {
+	f := feature.(*Feature)
+
+	m := c.makeMiddleware(f, ws)
+	lh := NewLogoutHandler(c.registry)
+	ep := request_cache.NewSaveRequestEntryPoint(m)
+
+	// configure on top of existing logout feature
+	logout.Configure(ws).
+		AddLogoutHandler(lh).
+		AddEntryPoint(ep)
+
+	// Note: callback endpoint is available regardless what auth method is used, so no condition is applied
+	ws.Route(matcher.RouteWithPattern(f.logoutCallbackPath)).
+		Add(mapping.Get(f.logoutCallbackPath).
+			HandlerFunc(m.CallbackHandlerFunc()).
+			Name("oidc logout callback"),
+		)
+
+	csrf.Configure(ws).
+		IgnoreCsrfProtectionMatcher(matcher.RequestWithPattern(f.logoutCallbackPath))
+	return nil
+}
+
+func (c *OidcLogoutConfigurer) makeMiddleware(f *Feature, ws security.WebSecurity) *LogoutMiddleware {
+	if f.successHandler == nil {
+		f.successHandler = c.savedRequestSuccessHandler()
+	}
+	return NewLogoutMiddleware(func(opt *LogoutMiddlewareOption) {
+		opt.Registry = c.registry
+		opt.RelyingParty = c.rp
+		opt.Issuer = f.issuer
+		opt.CallbackPath = f.logoutCallbackPath
+		opt.SuccessHandler = c.effectiveSuccessHandler(f, ws)
+	})
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/idp"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+)
+
+// OpenIDIdentityProvider is an external OpenID Provider (OP) that we log in users with, as Relying Party (RP).
+// idp.IdentityProvider returned by idp.IdentityProviderManager with flow idp.ExternalIdpOIDC should implement this interface.
+type OpenIDIdentityProvider interface {
+	idp.IdentityProvider
+	IssuerURI() string
+	ClientId() string
+	ClientSecret() string
+	Scopes() []string
+	ExternalIdName() string
+	ExternalIdpName() string
+	GetAutoCreateUserDetails() security.AutoCreateUserDetails
+}
+
+type OidcIdpAutoCreateUserDetails struct {
+	Enabled               bool
+	EmailWhiteList        []string
+	AttributeMapping      map[string]string
+	ElevatedUserRoleNames []string
+	RegularUserRoleNames  []string
+}
+
+func (a OidcIdpAutoCreateUserDetails) IsEnabled() bool {
+	return a.Enabled
+}
+
+func (a OidcIdpAutoCreateUserDetails) GetEmailWhiteList() []string {
+	return a.EmailWhiteList
+}
+
+func (a OidcIdpAutoCreateUserDetails) GetAttributeMapping() map[string]string {
+	return a.AttributeMapping
+}
+
+func (a OidcIdpAutoCreateUserDetails) GetElevatedUserRoleNames() []string {
+	return a.ElevatedUserRoleNames
+}
+
+func (a OidcIdpAutoCreateUserDetails) GetRegularUserRoleNames() []string {
+	return a.RegularUserRoleNames
+}
+
+type OidcIdpDetails struct {
+	Domain                string
+	IssuerURI             string
+	ClientId              string
+	ClientSecret          string
+	Scopes                []string
+	ExternalIdName        string
+	ExternalIdpName       string
+	AutoCreateUserDetails OidcIdpAutoCreateUserDetails
+}
+
+type OidcIdpOptions func(opt *OidcIdpDetails)
+
+// OidcIdentityProvider implements idp.IdentityProvider, idp.AuthenticationFlowAware and OpenIDIdentityProvider
+type OidcIdentityProvider struct {
+	OidcIdpDetails
+}
+
+func NewIdentityProvider(opts ...OidcIdpOptions) *OidcIdentityProvider {
+	opt := OidcIdpDetails{
+		Scopes:         []string{oauth2.ScopeOidc},
+		ExternalIdName: oauth2.ClaimSubject,
+	}
+	for _, f := range opts {
+		f(&opt)
+	}
+	return &OidcIdentityProvider{
+		OidcIdpDetails: opt,
+	}
+}
+
+// NewIdentityProviderWithProperties create OidcIdentityProvider from properties with given name
+func NewIdentityProviderWithProperties(name string, props OidcProviderProperties) *OidcIdentityProvider {
+	return NewIdentityProvider(func(opt *OidcIdpDetails) {
+		opt.Domain = props.Domain
+		opt.IssuerURI = props.IssuerURI
+		opt.ClientId = props.ClientId
+		opt.ClientSecret = props.ClientSecret
+		opt.ExternalIdpName = name
+		if len(props.Scopes) != 0 {
+			opt.Scopes = props.Scopes
+		}
+		if len(props.ExternalIdClaim) != 0 {
+			opt.ExternalIdName = props.ExternalIdClaim
+		}
+		if len(props.ExternalIdpName) != 0 {
+			opt.ExternalIdpName = props.ExternalIdpName
+		}
+		opt.AutoCreateUserDetails = OidcIdpAutoCreateUserDetails{
+			Enabled:               props.AutoCreateUser.Enabled,
+			EmailWhiteList:        props.AutoCreateUser.EmailWhiteList,
+			AttributeMapping:      props.AutoCreateUser.AttributeMapping,
+			ElevatedUserRoleNames: props.AutoCreateUser.ElevatedUserRoleNames,
+			RegularUserRoleNames:  props.AutoCreateUser.RegularUserRoleNames,
+		}
+	})
+}
+
+func (s OidcIdentityProvider) AuthenticationFlow() idp.AuthenticationFlow {
+	return idp.ExternalIdpOIDC
+}
+
+func (s OidcIdentityProvider) Domain() string {
+	return s.OidcIdpDetails.Domain
+}
+
+func (s OidcIdentityProvider) IssuerURI() string {
+	return s.OidcIdpDetails.IssuerURI
+}
+
+func (s OidcIdentityProvider) ClientId() string {
+	return s.OidcIdpDetails.ClientId
+}
+
+func (s OidcIdentityProvider) ClientSecret() string {
+	return s.OidcIdpDetails.ClientSecret
+}
+
+func (s OidcIdentityProvider) Scopes() []string {
+	return s.OidcIdpDetails.Scopes
+}
+
+func (s OidcIdentityProvider) ExternalIdName() string {
+	return s.OidcIdpDetails.ExternalIdName
+}
+
+func (s OidcIdentityProvider) ExternalIdpName() string {
+	return s.OidcIdpDetails.ExternalIdpName
+}
+
+func (s OidcIdentityProvider) GetAutoCreateUserDetails() security.AutoCreateUserDetails {
+	return s.OidcIdpDetails.AutoCreateUserDetails
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	DiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// ProviderMetadata is the subset of OpenID Provider Metadata we need as RP.
+// See https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type ProviderMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	UserInfoEndpoint              string   `json:"userinfo_endpoint,omitempty"`
+	JwksURI                       string   `json:"jwks_uri"`
+	EndSessionEndpoint            string   `json:"end_session_endpoint,omitempty"`
+	ScopesSupported               []string `json:"scopes_supported,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+	IdTokenSigningAlgs            []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// FetchProviderMetadata load discovery document of given issuer.
+// See https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig
+func FetchProviderMetadata(ctx context.Context, client *http.Client, issuer string) (*ProviderMetadata, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	req, e := http.NewRequestWithContext(ctx, http.MethodGet, issuer+DiscoveryPath, nil)
+	if e != nil {
+		return nil, e
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, e := client.Do(req)
+	if e != nil {
+		return nil, fmt.Errorf("failed to fetch OpenID discovery document of [%s]: %v", issuer, e)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OpenID discovery document of [%s]: status %d", issuer, resp.StatusCode)
+	}
+
+	var metadata ProviderMetadata
+	if e := json.NewDecoder(resp.Body).Decode(&metadata); e != nil {
+		return nil, fmt.Errorf("invalid OpenID discovery document of [%s]: %v", issuer, e)
+	}
+	switch {
+	case strings.TrimSuffix(metadata.Issuer, "/") != issuer:
+		return nil, fmt.Errorf("OpenID discovery document has mismatched issuer [%s], expected [%s]", metadata.Issuer, issuer)
+	case len(metadata.AuthorizationEndpoint) == 0 || len(metadata.TokenEndpoint) == 0 || len(metadata.JwksURI) == 0:
+		return nil, fmt.Errorf("OpenID discovery document of [%s] is missing required endpoints", issuer)
+	}
+	return &metadata, nil
+}
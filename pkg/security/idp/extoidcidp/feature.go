@@ -0,0 +1,79 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/security"
+)
+
+var (
+	FeatureId       = security.FeatureId("oidc_login", security.FeatureOrderOidcLogin)
+	LogoutFeatureId = security.FeatureId("oidc_logout", security.FeatureOrderOidcLogout)
+)
+
+// Feature configures login and RP-initiated logout with external OpenID providers
+type Feature struct {
+	id                 security.FeatureIdentifier
+	callbackPath       string
+	logoutCallbackPath string
+	errorPath          string //The path to send the user to when authentication error is encountered
+	successHandler     security.AuthenticationSuccessHandler
+	issuer             security.Issuer
+}
+
+func newFeature(id security.FeatureIdentifier) *Feature {
+	return &Feature{
+		id:                 id,
+		callbackPath:       "/oidc/callback",
+		logoutCallbackPath: "/oidc/logout",
+		errorPath:          "/error",
+	}
+}
+
+func New() *Feature {
+	return newFeature(FeatureId)
+}
+
+func NewLogout() *Feature {
+	return newFeature(LogoutFeatureId)
+}
+
+func (f *Feature) Identifier() security.FeatureIdentifier {
+	return f.id
+}
+
+func (f *Feature) Issuer(issuer security.Issuer) *Feature {
+	f.issuer = issuer
+	return f
+}
+
+func (f *Feature) ErrorPath(path string) *Feature {
+	f.errorPath = path
+	return f
+}
+
+// CallbackPath set the path of "redirect_uri" of authorization code flow
+func (f *Feature) CallbackPath(path string) *Feature {
+	f.callbackPath = path
+	return f
+}
+
+// LogoutCallbackPath set the path of "post_logout_redirect_uri" of RP-initiated logout
+func (f *Feature) LogoutCallbackPath(path string) *Feature {
+	f.logoutCallbackPath = path
+	return f
+}
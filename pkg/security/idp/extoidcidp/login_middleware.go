@@ -0,0 +1,174 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/session"
+	netutil "github.com/cisco-open/go-lanai/pkg/utils/net"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const (
+	sessionKeyAuthRequest = "OIDC.AuthRequest"
+)
+
+type LoginMiddlewareOptions func(opt *LoginMiddlewareOption)
+type LoginMiddlewareOption struct {
+	Registry       *ProviderRegistry
+	RelyingParty   *RelyingParty
+	Authenticator  security.Authenticator
+	SuccessHandler security.AuthenticationSuccessHandler
+	Issuer         security.Issuer
+	CallbackPath   string
+	ErrorPath      string
+}
+
+// LoginMiddleware implements the relying party side of OpenID Connect authorization code flow:
+// - Commence redirects user to the provider's authorization endpoint
+// - CallbackHandlerFunc exchanges the authorization code and authenticate user with the validated ID token
+type LoginMiddleware struct {
+	LoginMiddlewareOption
+}
+
+func NewLoginMiddleware(opts ...LoginMiddlewareOptions) *LoginMiddleware {
+	m := LoginMiddleware{}
+	for _, fn := range opts {
+		fn(&m.LoginMiddlewareOption)
+	}
+	return &m
+}
+
+// Commence implements security.AuthenticationEntryPoint
+func (m *LoginMiddleware) Commence(ctx context.Context, r *http.Request, w http.ResponseWriter, _ error) {
+	provider, e := m.Registry.FindByDomain(ctx, netutil.GetForwardedHostName(r))
+	if e != nil {
+		m.writeError(ctx, r, w, security.NewExternalOidcAuthenticationError(e.Error(), e))
+		return
+	}
+
+	redirectUri, e := m.Issuer.BuildUrl(func(opt *security.UrlBuilderOption) {
+		opt.FQDN = provider.Domain()
+		opt.Path = m.CallbackPath
+	})
+	if e != nil {
+		m.writeError(ctx, r, w, security.NewExternalOidcAuthenticationError("unable to build redirect URI", e))
+		return
+	}
+
+	authReq, authUrl, e := m.RelyingParty.AuthenticationRequest(ctx, provider, redirectUri.String())
+	if e != nil {
+		m.writeError(ctx, r, w, security.NewExternalOidcAuthenticationError(e.Error(), e))
+		return
+	}
+
+	s := session.Get(ctx)
+	if s == nil {
+		m.writeError(ctx, r, w, security.NewExternalOidcAuthenticationError("session is required for OpenID Connect login"))
+		return
+	}
+	s.Set(sessionKeyAuthRequest, authReq)
+	http.Redirect(w, r, authUrl.String(), http.StatusFound)
+}
+
+// CallbackHandlerFunc returns the handler function of "redirect_uri"
+func (m *LoginMiddleware) CallbackHandlerFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// take pending request, it's one time use regardless of result
+		var authReq *AuthRequest
+		if s := session.Get(c); s != nil {
+			authReq, _ = s.Get(sessionKeyAuthRequest).(*AuthRequest)
+			s.Delete(sessionKeyAuthRequest)
+		}
+		if authReq == nil {
+			m.handleError(c, security.NewExternalOidcAuthenticationError("no pending OpenID Connect authentication request"))
+			return
+		}
+
+		if state := c.Query(oauth2.ParameterState); state != authReq.State {
+			m.handleError(c, security.NewExternalOidcAuthenticationError("invalid state"))
+			return
+		}
+
+		if errCode := c.Query(oauth2.ParameterError); errCode != "" {
+			desc := c.Query(oauth2.ParameterErrorDescription)
+			m.handleError(c, security.NewExternalOidcAuthenticationError(fmt.Sprintf("OpenID provider returned error [%s]: %s", errCode, desc)))
+			return
+		}
+
+		code := c.Query(oauth2.ParameterAuthCode)
+		if code == "" {
+			m.handleError(c, security.NewExternalOidcAuthenticationError("missing authorization code"))
+			return
+		}
+
+		provider, e := m.Registry.FindByDomain(c, authReq.Domain)
+		if e != nil {
+			m.handleError(c, security.NewExternalOidcAuthenticationError("cannot find OpenID provider of the authentication request"))
+			return
+		}
+
+		idToken, e := m.RelyingParty.Exchange(c, provider, authReq, code)
+		if e != nil {
+			m.handleError(c, security.NewExternalOidcAuthenticationError(e.Error(), e))
+			return
+		}
+
+		candidate := &IdTokenCandidate{
+			Provider:   provider,
+			IdToken:    idToken,
+			DetailsMap: map[string]interface{}{},
+		}
+		before := security.Get(c)
+		auth, e := m.Authenticator.Authenticate(c, candidate)
+		if e != nil {
+			m.handleError(c, e)
+			return
+		}
+		m.handleSuccess(c, before, auth)
+	}
+}
+
+func (m *LoginMiddleware) handleSuccess(c *gin.Context, before, new security.Authentication) {
+	if new != nil {
+		security.MustSet(c, new)
+	}
+	m.SuccessHandler.HandleAuthenticationSuccess(c, c.Request, c.Writer, before, new)
+	if c.Writer.Written() {
+		c.Abort()
+	}
+}
+
+func (m *LoginMiddleware) handleError(c *gin.Context, err error) {
+	security.MustClear(c)
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// writeError is used by entry point, where we cannot rely on error handling middleware
+func (m *LoginMiddleware) writeError(ctx context.Context, r *http.Request, w http.ResponseWriter, err error) {
+	logger.WithContext(ctx).Warnf("unable to start OpenID Connect login: %v", err)
+	if m.ErrorPath == "" {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	http.Redirect(w, r, m.ErrorPath, http.StatusFound)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"net/http"
+)
+
+var ErrOidcLogoutRequired = security.NewAuthenticationError("OpenID Connect RP-initiated logout required")
+
+// RPLogoutHandler is a logout.ConditionalLogoutHandler that triggers RP-initiated logout
+// when current user is authenticated via external OpenID provider that supports "end_session_endpoint"
+type RPLogoutHandler struct {
+	registry *ProviderRegistry
+}
+
+func NewLogoutHandler(registry *ProviderRegistry) *RPLogoutHandler {
+	return &RPLogoutHandler{
+		registry: registry,
+	}
+}
+
+// ShouldLogout is a logout.ConditionalLogoutHandler method that interrupt logout process by returning authentication error,
+// which would trigger authentication entry point and initiate RP-initiated logout
+func (h *RPLogoutHandler) ShouldLogout(ctx context.Context, _ *http.Request, _ http.ResponseWriter, auth security.Authentication) error {
+	if !h.requiresRPLogout(ctx, auth) {
+		return nil
+	}
+	return ErrOidcLogoutRequired
+}
+
+func (h *RPLogoutHandler) HandleLogout(_ context.Context, _ *http.Request, _ http.ResponseWriter, auth security.Authentication) error {
+	oidcAuth, ok := auth.(*oidcAuthentication)
+	if !ok {
+		return nil
+	}
+	if state, ok := oidcAuth.DetailsMap[kDetailsLogoutState].(LogoutState); !ok || !state.Is(LogoutFailed) {
+		return nil
+	}
+	return security.NewAuthenticationWarningError("cisco.oidc.logout.failed")
+}
+
+func (h *RPLogoutHandler) requiresRPLogout(ctx context.Context, auth security.Authentication) bool {
+	oidcAuth, ok := auth.(*oidcAuthentication)
+	if !ok {
+		return false
+	}
+
+	// check if logout already completed
+	if state, ok := oidcAuth.DetailsMap[kDetailsLogoutState].(LogoutState); ok && state.Is(LogoutCompleted) {
+		return false
+	}
+
+	// check if provider supports RP-initiated logout
+	provider, e := h.registry.FindByDomain(ctx, oidcAuth.Domain)
+	if e != nil {
+		return false
+	}
+	metadata, e := h.registry.Metadata(ctx, provider)
+	return e == nil && len(metadata.EndSessionEndpoint) != 0
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/session"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const (
+	LogoutInitiated LogoutState = 1 << iota
+	LogoutSucceeded
+	LogoutFailed
+	LogoutCompleted = LogoutSucceeded | LogoutFailed
+)
+
+type LogoutState int
+
+func (s LogoutState) Is(mask LogoutState) bool {
+	return s&mask != 0 || mask == 0 && s == 0
+}
+
+const (
+	kDetailsLogoutState     = "OIDC.LogoutState"
+	sessionKeyLogoutRequest = "OIDC.LogoutRequest"
+)
+
+func init() {
+	gob.Register(LogoutState(0))
+}
+
+type LogoutMiddlewareOptions func(opt *LogoutMiddlewareOption)
+type LogoutMiddlewareOption struct {
+	Registry       *ProviderRegistry
+	RelyingParty   *RelyingParty
+	SuccessHandler security.AuthenticationSuccessHandler
+	Issuer         security.Issuer
+	CallbackPath   string
+}
+
+// LogoutMiddleware implements OpenID Connect RP-Initiated Logout.
+// See https://openid.net/specs/openid-connect-rpinitiated-1_0.html
+type LogoutMiddleware struct {
+	LogoutMiddlewareOption
+}
+
+func NewLogoutMiddleware(opts ...LogoutMiddlewareOptions) *LogoutMiddleware {
+	m := LogoutMiddleware{}
+	for _, fn := range opts {
+		fn(&m.LogoutMiddlewareOption)
+	}
+	return &m
+}
+
+// Commence implements security.AuthenticationEntryPoint. It's used when RP-initiated logout is required
+func (m *LogoutMiddleware) Commence(ctx context.Context, r *http.Request, w http.ResponseWriter, err error) {
+	if !errors.Is(err, ErrOidcLogoutRequired) {
+		return
+	}
+
+	logger.WithContext(ctx).Infof("trying to start OpenID Connect RP-Initiated Logout")
+	if e := m.makeLogoutRequest(ctx, r, w); e != nil {
+		m.handleError(ctx, e)
+		return
+	}
+
+	updateLogoutState(ctx, func(current LogoutState) LogoutState {
+		return current | LogoutInitiated
+	})
+}
+
+// CallbackHandlerFunc returns the handler function of "post_logout_redirect_uri"
+func (m *LogoutMiddleware) CallbackHandlerFunc() gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		var expected string
+		if s := session.Get(gc); s != nil {
+			expected, _ = s.Get(sessionKeyLogoutRequest).(string)
+			s.Delete(sessionKeyLogoutRequest)
+		}
+		if expected == "" || gc.Query(oauth2.ParameterState) != expected {
+			m.handleError(gc, security.NewExternalOidcAuthenticationError("invalid logout state"))
+			return
+		}
+		m.handleSuccess(gc)
+	}
+}
+
+func (m *LogoutMiddleware) makeLogoutRequest(ctx context.Context, r *http.Request, w http.ResponseWriter) error {
+	oidcAuth, ok := security.Get(ctx).(*oidcAuthentication)
+	if !ok {
+		return security.NewExternalOidcAuthenticationError("Unable to initiate logout: not authenticated via OpenID Connect")
+	}
+	provider, e := m.Registry.FindByDomain(ctx, oidcAuth.Domain)
+	if e != nil {
+		return security.NewExternalOidcAuthenticationError("Unable to initiate logout: unknown OpenID provider", e)
+	}
+	redirectUri, e := m.Issuer.BuildUrl(func(opt *security.UrlBuilderOption) {
+		opt.FQDN = provider.Domain()
+		opt.Path = m.CallbackPath
+	})
+	if e != nil {
+		return security.NewExternalOidcAuthenticationError("Unable to initiate logout: cannot build redirect URI", e)
+	}
+
+	state := randomString()
+	logoutUrl, e := m.RelyingParty.EndSessionURL(ctx, provider, oidcAuth.IdTokenValue, redirectUri.String(), state)
+	switch {
+	case e != nil:
+		return security.NewExternalOidcAuthenticationError(e.Error(), e)
+	case logoutUrl == nil:
+		return security.NewExternalOidcAuthenticationError("Unable to initiate logout: OpenID provider doesn't support RP-initiated logout")
+	}
+
+	s := session.Get(ctx)
+	if s == nil {
+		return security.NewExternalOidcAuthenticationError("Unable to initiate logout: session is required")
+	}
+	s.Set(sessionKeyLogoutRequest, state)
+	http.Redirect(w, r, logoutUrl.String(), http.StatusFound)
+	return nil
+}
+
+func (m *LogoutMiddleware) handleSuccess(ctx context.Context) {
+	updateLogoutState(ctx, func(current LogoutState) LogoutState {
+		return current | LogoutSucceeded
+	})
+	m.continueLogout(ctx)
+}
+
+func (m *LogoutMiddleware) handleError(ctx context.Context, e error) {
+	logger.WithContext(ctx).Infof("OpenID Connect RP-Initiated Logout failed with error: %v", e)
+	updateLogoutState(ctx, func(current LogoutState) LogoutState {
+		return current | LogoutFailed
+	})
+	// We always let logout continues
+	m.continueLogout(ctx)
+}
+
+func (m *LogoutMiddleware) continueLogout(ctx context.Context) {
+	gc := web.GinContext(ctx)
+	auth := security.Get(ctx)
+	m.SuccessHandler.HandleAuthenticationSuccess(ctx, gc.Request, gc.Writer, auth, auth)
+	if gc.Writer.Written() {
+		gc.Abort()
+	}
+}
+
+/***********************
+	Helper Funcs
+ ***********************/
+
+func updateLogoutState(ctx context.Context, updater func(current LogoutState) LogoutState) {
+	details, ok := security.Get(ctx).Details().(map[string]interface{})
+	if !ok || details == nil {
+		return
+	}
+	state, _ := details[kDetailsLogoutState].(LogoutState)
+	details[kDetailsLogoutState] = updater(state)
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/idp"
+	"go.uber.org/fx"
+)
+
+var logger = log.New("SEC.OIDC")
+
+const (
+	ParameterIdTokenHint           = "id_token_hint"
+	ParameterPostLogoutRedirectUri = "post_logout_redirect_uri"
+)
+
+var Module = &bootstrap.Module{
+	Name:       "OIDC IDP",
+	Precedence: security.MinSecurityPrecedence + 30,
+	Options: []fx.Option{
+		fx.Provide(BindOidcAuthProperties, provideProviderRegistry),
+		fx.Invoke(register),
+	},
+}
+
+func Use() {
+	bootstrap.Register(Module)
+}
+
+type registryDI struct {
+	fx.In
+	Properties OidcAuthProperties
+	IdpManager idp.IdentityProviderManager `optional:"true"`
+}
+
+func provideProviderRegistry(di registryDI) *ProviderRegistry {
+	return NewProviderRegistry(RegistryWithProperties(&di.Properties), RegistryWithDelegate(di.IdpManager))
+}
+
+type initDI struct {
+	fx.In
+	SecRegistrar security.Registrar `optional:"true"`
+	Registry     *ProviderRegistry
+	AccountStore security.FederatedAccountStore `optional:"true"`
+}
+
+func register(di initDI) {
+	if di.SecRegistrar == nil {
+		return
+	}
+	shared := newOidcConfigurer(di.Registry)
+	if di.AccountStore != nil {
+		loginConfigurer := newOidcLoginConfigurer(shared, di.AccountStore)
+		di.SecRegistrar.(security.FeatureRegistrar).RegisterFeature(FeatureId, loginConfigurer)
+	}
+	logoutConfigurer := newOidcLogoutConfigurer(shared)
+	di.SecRegistrar.(security.FeatureRegistrar).RegisterFeature(LogoutFeatureId, logoutConfigurer)
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	PropertiesPrefix = "security.idp.oidc"
+)
+
+type OidcAuthProperties struct {
+	Enabled bool `json:"enabled"`
+	// MetadataTTL controls how long discovery documents and JWK sets of external OPs are cached
+	MetadataTTL utils.Duration             `json:"metadata-ttl"`
+	Endpoints   OidcAuthEndpointProperties `json:"endpoints"`
+	// Providers are external OpenID providers defined in properties, keyed by a name.
+	// Providers defined this way are available in addition to those given by idp.IdentityProviderManager
+	Providers map[string]OidcProviderProperties `json:"providers"`
+}
+
+type OidcAuthEndpointProperties struct {
+	// Callback is the redirect_uri path of authorization code flow
+	Callback string `json:"callback"`
+	// LogoutCallback is the post_logout_redirect_uri path of RP-initiated logout
+	LogoutCallback string `json:"logout-callback"`
+}
+
+type OidcProviderProperties struct {
+	Domain       string   `json:"domain"`
+	IssuerURI    string   `json:"issuer-uri"`
+	ClientId     string   `json:"client-id"`
+	ClientSecret string   `json:"client-secret"`
+	Scopes       []string `json:"scopes"`
+	// ExternalIdClaim is the ID token claim used as external ID. Default to "sub"
+	ExternalIdClaim string `json:"external-id-claim"`
+	// ExternalIdpName is used when loading federated account. Default to the provider's key in properties
+	ExternalIdpName string                       `json:"external-idp-name"`
+	AutoCreateUser  OidcAutoCreateUserProperties `json:"auto-create-user"`
+}
+
+type OidcAutoCreateUserProperties struct {
+	Enabled               bool              `json:"enabled"`
+	EmailWhiteList        []string          `json:"email-white-list"`
+	AttributeMapping      map[string]string `json:"attribute-mapping"`
+	ElevatedUserRoleNames []string          `json:"elevated-user-role-names"`
+	RegularUserRoleNames  []string          `json:"regular-user-role-names"`
+}
+
+func NewOidcAuthProperties() *OidcAuthProperties {
+	return &OidcAuthProperties{
+		MetadataTTL: utils.Duration(time.Hour),
+		Endpoints: OidcAuthEndpointProperties{
+			Callback:       "/oidc/callback",
+			LogoutCallback: "/oidc/logout",
+		},
+		Providers: map[string]OidcProviderProperties{},
+	}
+}
+
+func BindOidcAuthProperties(ctx *bootstrap.ApplicationContext) OidcAuthProperties {
+	props := NewOidcAuthProperties()
+	if err := ctx.Config().Bind(props, PropertiesPrefix); err != nil {
+		panic(errors.Wrap(err, "failed to bind OidcAuthProperties"))
+	}
+	return *props
+}
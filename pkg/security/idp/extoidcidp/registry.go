@@ -0,0 +1,224 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/idp"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrProviderNotFound = errors.New("OpenID provider not found")
+)
+
+type RegistryOptions func(opt *RegistryOption)
+type RegistryOption struct {
+	// Providers are statically defined providers, typically from properties
+	Providers []OpenIDIdentityProvider
+	// Delegate is the application's idp.IdentityProviderManager. Providers with idp.ExternalIdpOIDC flow are also used
+	Delegate idp.IdentityProviderManager
+	// HttpClient is used to load discovery documents, JWK sets and to exchange authorization code
+	HttpClient *http.Client
+	// TTL controls how long discovery documents and JWK sets are cached
+	TTL time.Duration
+}
+
+// RegistryWithProperties is a RegistryOptions that add providers defined in OidcAuthProperties
+func RegistryWithProperties(props *OidcAuthProperties) RegistryOptions {
+	return func(opt *RegistryOption) {
+		names := make([]string, 0, len(props.Providers))
+		for name := range props.Providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			opt.Providers = append(opt.Providers, NewIdentityProviderWithProperties(name, props.Providers[name]))
+		}
+		if props.MetadataTTL > 0 {
+			opt.TTL = time.Duration(props.MetadataTTL)
+		}
+	}
+}
+
+// RegistryWithDelegate is a RegistryOptions that set the idp.IdentityProviderManager to look up providers from
+func RegistryWithDelegate(delegate idp.IdentityProviderManager) RegistryOptions {
+	return func(opt *RegistryOption) {
+		opt.Delegate = delegate
+	}
+}
+
+// ProviderRegistry implements idp.IdentityProviderManager.
+// It combines providers defined in properties with providers given by application's idp.IdentityProviderManager,
+// and caches their discovery documents and JWK stores.
+// Applications that define providers in properties should use ProviderRegistry as authserver.Configuration's IdpManager,
+// so that other IDP configurers don't claim domains of those providers.
+type ProviderRegistry struct {
+	providers  []OpenIDIdentityProvider
+	delegate   idp.IdentityProviderManager
+	httpClient *http.Client
+	ttl        time.Duration
+	mtx        sync.RWMutex
+	cache      map[string]*providerEntry
+}
+
+type providerEntry struct {
+	metadata *ProviderMetadata
+	jwkStore jwt.JwkStore
+	expireAt time.Time
+}
+
+func NewProviderRegistry(opts ...RegistryOptions) *ProviderRegistry {
+	opt := RegistryOption{
+		HttpClient: http.DefaultClient,
+		TTL:        time.Hour,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &ProviderRegistry{
+		providers:  opt.Providers,
+		delegate:   opt.Delegate,
+		httpClient: opt.HttpClient,
+		ttl:        opt.TTL,
+		cache:      map[string]*providerEntry{},
+	}
+}
+
+// GetIdentityProvidersWithFlow implements idp.IdentityProviderManager
+func (r *ProviderRegistry) GetIdentityProvidersWithFlow(ctx context.Context, flow idp.AuthenticationFlow) []idp.IdentityProvider {
+	var ret []idp.IdentityProvider
+	if flow == idp.ExternalIdpOIDC {
+		for _, p := range r.providers {
+			ret = append(ret, p)
+		}
+	}
+	if r.delegate != nil {
+		ret = append(ret, r.delegate.GetIdentityProvidersWithFlow(ctx, flow)...)
+	}
+	return ret
+}
+
+// GetIdentityProviderByDomain implements idp.IdentityProviderManager
+func (r *ProviderRegistry) GetIdentityProviderByDomain(ctx context.Context, domain string) (idp.IdentityProvider, error) {
+	for _, p := range r.providers {
+		if p.Domain() == domain {
+			return p, nil
+		}
+	}
+	if r.delegate != nil {
+		return r.delegate.GetIdentityProviderByDomain(ctx, domain)
+	}
+	return nil, ErrProviderNotFound
+}
+
+// FindByDomain returns OpenIDIdentityProvider of given domain
+func (r *ProviderRegistry) FindByDomain(ctx context.Context, domain string) (OpenIDIdentityProvider, error) {
+	v, e := r.GetIdentityProviderByDomain(ctx, domain)
+	if e != nil {
+		return nil, ErrProviderNotFound
+	}
+	if fa, ok := v.(idp.AuthenticationFlowAware); !ok || fa.AuthenticationFlow() != idp.ExternalIdpOIDC {
+		return nil, ErrProviderNotFound
+	}
+	if p, ok := v.(OpenIDIdentityProvider); ok {
+		return p, nil
+	}
+	return nil, ErrProviderNotFound
+}
+
+// Metadata returns discovery document of given provider. The result is cached
+func (r *ProviderRegistry) Metadata(ctx context.Context, provider OpenIDIdentityProvider) (*ProviderMetadata, error) {
+	entry, e := r.entry(ctx, provider)
+	if e != nil {
+		return nil, e
+	}
+	return entry.metadata, nil
+}
+
+// JwkStore returns jwt.JwkStore backed by given provider's "jwks_uri"
+func (r *ProviderRegistry) JwkStore(ctx context.Context, provider OpenIDIdentityProvider) (jwt.JwkStore, error) {
+	entry, e := r.entry(ctx, provider)
+	if e != nil {
+		return nil, e
+	}
+	return entry.jwkStore, nil
+}
+
+// HttpClient returns the http.Client used for communicating with providers
+func (r *ProviderRegistry) HttpClient() *http.Client {
+	return r.httpClient
+}
+
+func (r *ProviderRegistry) entry(ctx context.Context, provider OpenIDIdentityProvider) (*providerEntry, error) {
+	issuer := provider.IssuerURI()
+	r.mtx.RLock()
+	entry, ok := r.cache[issuer]
+	r.mtx.RUnlock()
+	if ok && time.Now().Before(entry.expireAt) {
+		return entry, nil
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if entry, ok := r.cache[issuer]; ok && time.Now().Before(entry.expireAt) {
+		return entry, nil
+	}
+	metadata, e := FetchProviderMetadata(ctx, r.httpClient, issuer)
+	if e != nil {
+		return nil, e
+	}
+	entry = &providerEntry{
+		metadata: metadata,
+		jwkStore: singleKeyFallbackJwkStore{
+			JwkStore: jwt.NewRemoteJwkStore(func(cfg *jwt.RemoteJwkConfig) {
+				cfg.HttpClient = r.httpClient
+				cfg.JwkSetURL = metadata.JwksURI
+				cfg.TTL = r.ttl
+			}),
+		},
+		expireAt: time.Now().Add(r.ttl),
+	}
+	r.cache[issuer] = entry
+	return entry, nil
+}
+
+// singleKeyFallbackJwkStore allows ID tokens without "kid" header, as long as the provider publishes only one key.
+// See https://openid.net/specs/openid-connect-core-1_0.html#Signing
+type singleKeyFallbackJwkStore struct {
+	jwt.JwkStore
+}
+
+func (s singleKeyFallbackJwkStore) LoadByName(ctx context.Context, name string) (jwt.Jwk, error) {
+	if len(name) != 0 {
+		return s.JwkStore.LoadByName(ctx, name)
+	}
+	jwks, e := s.JwkStore.LoadAll(ctx)
+	if e != nil {
+		return nil, e
+	}
+	if len(jwks) != 1 {
+		return nil, fmt.Errorf("ID token doesn't specify kid, but provider has %d keys", len(jwks))
+	}
+	return jwks[0], nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	codeChallengeMethodS256 = "S256"
+)
+
+func init() {
+	gob.Register((*AuthRequest)(nil))
+}
+
+// AuthRequest is a pending authentication request of authorization code flow. It's kept in session until callback
+type AuthRequest struct {
+	Issuer       string
+	Domain       string
+	State        string
+	Nonce        string
+	CodeVerifier string
+	RedirectURI  string
+}
+
+// IdToken is a validated ID token
+type IdToken struct {
+	Value  string
+	Claims oauth2.MapClaims
+}
+
+// tokenResponse is the token endpoint response. We only need ID token
+type tokenResponse struct {
+	IdToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// RelyingParty performs OpenID Connect authorization code flow with PKCE against external OpenID providers.
+// See https://openid.net/specs/openid-connect-core-1_0.html#CodeFlowAuth
+type RelyingParty struct {
+	registry *ProviderRegistry
+	leeway   time.Duration
+}
+
+func NewRelyingParty(registry *ProviderRegistry) *RelyingParty {
+	return &RelyingParty{
+		registry: registry,
+		leeway:   time.Minute,
+	}
+}
+
+// AuthenticationRequest creates a new AuthRequest and the URL of provider's authorization endpoint to redirect user agent to
+func (rp *RelyingParty) AuthenticationRequest(ctx context.Context, provider OpenIDIdentityProvider, redirectUri string) (*AuthRequest, *url.URL, error) {
+	metadata, e := rp.registry.Metadata(ctx, provider)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	authReq := &AuthRequest{
+		Issuer:       metadata.Issuer,
+		Domain:       provider.Domain(),
+		State:        randomString(),
+		Nonce:        randomString(),
+		CodeVerifier: randomString(),
+		RedirectURI:  redirectUri,
+	}
+	authUrl, e := url.Parse(metadata.AuthorizationEndpoint)
+	if e != nil {
+		return nil, nil, fmt.Errorf("invalid authorization endpoint [%s]: %v", metadata.AuthorizationEndpoint, e)
+	}
+	query := authUrl.Query()
+	query.Set(oauth2.ParameterResponseType, "code")
+	query.Set(oauth2.ParameterClientId, provider.ClientId())
+	query.Set(oauth2.ParameterRedirectUri, redirectUri)
+	query.Set(oauth2.ParameterScope, strings.Join(provider.Scopes(), " "))
+	query.Set(oauth2.ParameterState, authReq.State)
+	query.Set(oauth2.ParameterNonce, authReq.Nonce)
+	query.Set(oauth2.ParameterCodeChallenge, codeChallenge(authReq.CodeVerifier))
+	query.Set(oauth2.ParameterCodeChallengeMethod, codeChallengeMethodS256)
+	authUrl.RawQuery = query.Encode()
+	return authReq, authUrl, nil
+}
+
+// Exchange exchanges authorization code for tokens and returns the validated ID token
+func (rp *RelyingParty) Exchange(ctx context.Context, provider OpenIDIdentityProvider, authReq *AuthRequest, code string) (*IdToken, error) {
+	metadata, e := rp.registry.Metadata(ctx, provider)
+	if e != nil {
+		return nil, e
+	}
+	if metadata.Issuer != authReq.Issuer {
+		return nil, fmt.Errorf("authentication request was not issued for [%s]", metadata.Issuer)
+	}
+
+	values := url.Values{}
+	values.Set(oauth2.ParameterGrantType, oauth2.GrantTypeAuthCode)
+	values.Set(oauth2.ParameterAuthCode, code)
+	values.Set(oauth2.ParameterRedirectUri, authReq.RedirectURI)
+	values.Set(oauth2.ParameterCodeVerifier, authReq.CodeVerifier)
+	if len(provider.ClientSecret()) == 0 {
+		values.Set(oauth2.ParameterClientId, provider.ClientId())
+	}
+	req, e := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(values.Encode()))
+	if e != nil {
+		return nil, e
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if len(provider.ClientSecret()) != 0 {
+		req.SetBasicAuth(url.QueryEscape(provider.ClientId()), url.QueryEscape(provider.ClientSecret()))
+	}
+
+	resp, e := rp.registry.HttpClient().Do(req)
+	if e != nil {
+		return nil, fmt.Errorf("token request failed: %v", e)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var tokenResp tokenResponse
+	if e := json.NewDecoder(resp.Body).Decode(&tokenResp); e != nil {
+		return nil, fmt.Errorf("invalid token response: %v", e)
+	}
+	switch {
+	case len(tokenResp.Error) != 0:
+		return nil, fmt.Errorf("token request failed: %s %s", tokenResp.Error, tokenResp.ErrorDescription)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("token request failed: status %d", resp.StatusCode)
+	case len(tokenResp.IdToken) == 0:
+		return nil, fmt.Errorf("token response doesn't contain ID token")
+	}
+	return rp.ValidateIdToken(ctx, provider, tokenResp.IdToken, authReq.Nonce)
+}
+
+// ValidateIdToken verifies ID token's signature using provider's JWK set, and validates its claims.
+// See https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+func (rp *RelyingParty) ValidateIdToken(ctx context.Context, provider OpenIDIdentityProvider, value string, nonce string) (*IdToken, error) {
+	metadata, e := rp.registry.Metadata(ctx, provider)
+	if e != nil {
+		return nil, e
+	}
+	jwkStore, e := rp.registry.JwkStore(ctx, provider)
+	if e != nil {
+		return nil, e
+	}
+
+	claims := oauth2.MapClaims{}
+	decoder := jwt.NewSignedJwtDecoder(jwt.VerifyWithJwkStore(jwkStore, ""))
+	if e := decoder.DecodeWithClaims(ctx, value, &claims); e != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", e)
+	}
+
+	now := time.Now()
+	aud := claimStringSet(claims.Get(oauth2.ClaimAudience))
+	azp, _ := claims.Get(oauth2.ClaimAuthorizedParty).(string)
+	switch {
+	case claims.Get(oauth2.ClaimIssuer) != metadata.Issuer:
+		return nil, fmt.Errorf("ID token has invalid issuer")
+	case !aud.Has(provider.ClientId()):
+		return nil, fmt.Errorf("ID token has invalid audience")
+	case len(aud) > 1 && azp != provider.ClientId():
+		return nil, fmt.Errorf("ID token has invalid authorized party")
+	case !claimTime(claims.Get(oauth2.ClaimExpire)).Add(rp.leeway).After(now):
+		return nil, fmt.Errorf("ID token is expired")
+	case claimTime(claims.Get(oauth2.ClaimIssueAt)).Add(-rp.leeway).After(now):
+		return nil, fmt.Errorf("ID token is issued in the future")
+	case claims.Get(oauth2.ClaimNonce) != nonce:
+		return nil, fmt.Errorf("ID token has invalid nonce")
+	}
+	return &IdToken{
+		Value:  value,
+		Claims: claims,
+	}, nil
+}
+
+// EndSessionURL returns URL of provider's end session endpoint for RP-initiated logout.
+// Returns nil if provider doesn't support it.
+// See https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+func (rp *RelyingParty) EndSessionURL(ctx context.Context, provider OpenIDIdentityProvider, idTokenHint, postLogoutRedirectUri, state string) (*url.URL, error) {
+	metadata, e := rp.registry.Metadata(ctx, provider)
+	if e != nil {
+		return nil, e
+	}
+	if len(metadata.EndSessionEndpoint) == 0 {
+		return nil, nil
+	}
+	logoutUrl, e := url.Parse(metadata.EndSessionEndpoint)
+	if e != nil {
+		return nil, fmt.Errorf("invalid end session endpoint [%s]: %v", metadata.EndSessionEndpoint, e)
+	}
+	query := logoutUrl.Query()
+	query.Set(oauth2.ParameterClientId, provider.ClientId())
+	if len(idTokenHint) != 0 {
+		query.Set(ParameterIdTokenHint, idTokenHint)
+	}
+	if len(postLogoutRedirectUri) != 0 {
+		query.Set(ParameterPostLogoutRedirectUri, postLogoutRedirectUri)
+		query.Set(oauth2.ParameterState, state)
+	}
+	logoutUrl.RawQuery = query.Encode()
+	return logoutUrl, nil
+}
+
+/***********************
+	Helpers
+ ***********************/
+
+func randomString() string {
+	data := make([]byte, 32)
+	if _, e := rand.Read(data); e != nil {
+		panic(e)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func codeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func claimStringSet(v interface{}) utils.StringSet {
+	switch s := v.(type) {
+	case string:
+		return utils.NewStringSet(s)
+	case []string:
+		return utils.NewStringSet(s...)
+	case []interface{}:
+		return utils.NewStringSetFrom(s)
+	default:
+		return utils.NewStringSet()
+	}
+}
+
+func claimTime(v interface{}) time.Time {
+	switch n := v.(type) {
+	case time.Time:
+		return n
+	case float64:
+		return time.Unix(int64(n), 0)
+	case json.Number:
+		i, _ := n.Int64()
+		return time.Unix(i, 0)
+	case int64:
+		return time.Unix(n, 0)
+	case int:
+		return time.Unix(int64(n), 0)
+	default:
+		return time.Time{}
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package extoidcidp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	TestKid          = "test-op-key"
+	TestDomain       = "oidc.lanai.com"
+	TestClientId     = "test-rp"
+	TestClientSecret = "test-secret"
+	TestSubject      = "ext-user-1"
+	TestRedirectUri  = "http://" + TestDomain + "/auth/oidc/callback"
+	TestAuthCode     = "test-code"
+)
+
+/*************************
+	Mocked OpenID Provider
+ *************************/
+
+type MockedOP struct {
+	*httptest.Server
+	jwkStore  *jwt.SingleJwkStore
+	encoder   jwt.JwtEncoder
+	challenge string
+	nonce     string
+	// ClaimsFn allows test to alter ID token claims
+	ClaimsFn func(claims oauth2.MapClaims)
+}
+
+func NewMockedOP() *MockedOP {
+	op := &MockedOP{
+		jwkStore: jwt.NewSingleJwkStoreWithOptions(func(s *jwt.SingleJwkStore) {
+			s.Kid = TestKid
+		}),
+	}
+	op.encoder = jwt.NewSignedJwtEncoder(jwt.SignWithJwkStore(op.jwkStore, TestKid))
+	mux := http.NewServeMux()
+	mux.HandleFunc(DiscoveryPath, op.discovery)
+	mux.HandleFunc("/jwks", op.jwks)
+	mux.HandleFunc("/token", op.token)
+	op.Server = httptest.NewServer(mux)
+	return op
+}
+
+func (op *MockedOP) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, &ProviderMetadata{
+		Issuer:                op.URL,
+		AuthorizationEndpoint: op.URL + "/authorize",
+		TokenEndpoint:         op.URL + "/token",
+		JwksURI:               op.URL + "/jwks",
+		EndSessionEndpoint:    op.URL + "/logout",
+	})
+}
+
+func (op *MockedOP) jwks(w http.ResponseWriter, r *http.Request) {
+	keys, _ := op.jwkStore.LoadAll(r.Context())
+	writeJson(w, http.StatusOK, map[string]interface{}{"keys": keys})
+}
+
+func (op *MockedOP) token(w http.ResponseWriter, r *http.Request) {
+	clientId, secret, _ := r.BasicAuth()
+	_ = r.ParseForm()
+	switch {
+	case clientId != TestClientId || secret != TestClientSecret:
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	case r.PostForm.Get(oauth2.ParameterAuthCode) != TestAuthCode,
+		r.PostForm.Get(oauth2.ParameterRedirectUri) != TestRedirectUri,
+		codeChallenge(r.PostForm.Get(oauth2.ParameterCodeVerifier)) != op.challenge:
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	now := time.Now()
+	claims := oauth2.MapClaims{
+		oauth2.ClaimIssuer:   op.URL,
+		oauth2.ClaimSubject:  TestSubject,
+		oauth2.ClaimAudience: TestClientId,
+		oauth2.ClaimIssueAt:  now.Unix(),
+		oauth2.ClaimExpire:   now.Add(time.Minute).Unix(),
+		oauth2.ClaimNonce:    op.nonce,
+		"email":              "ext-user@lanai.com",
+	}
+	if op.ClaimsFn != nil {
+		op.ClaimsFn(claims)
+	}
+	idToken, e := op.encoder.Encode(r.Context(), claims)
+	if e != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]string{
+		"id_token":     idToken,
+		"access_token": "ignored",
+		"token_type":   "Bearer",
+	})
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+/*************************
+	Test Cases
+ *************************/
+
+func TestRelyingParty(t *testing.T) {
+	op := NewMockedOP()
+	defer op.Close()
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestDiscovery(op), "Discovery"),
+		test.GomegaSubTest(SubTestRegistry(op), "Registry"),
+		test.GomegaSubTest(SubTestAuthenticationRequest(op), "AuthenticationRequest"),
+		test.GomegaSubTest(SubTestExchangeSuccess(op), "ExchangeSuccess"),
+		test.GomegaSubTest(SubTestExchangeWithInvalidCode(op), "ExchangeWithInvalidCode"),
+		test.GomegaSubTest(SubTestInvalidIdToken(op, "BadNonce", func(c oauth2.MapClaims) { c[oauth2.ClaimNonce] = "other" }), "InvalidNonce"),
+		test.GomegaSubTest(SubTestInvalidIdToken(op, "BadAudience", func(c oauth2.MapClaims) { c[oauth2.ClaimAudience] = "other" }), "InvalidAudience"),
+		test.GomegaSubTest(SubTestInvalidIdToken(op, "BadIssuer", func(c oauth2.MapClaims) { c[oauth2.ClaimIssuer] = "http://other" }), "InvalidIssuer"),
+		test.GomegaSubTest(SubTestInvalidIdToken(op, "Expired", func(c oauth2.MapClaims) {
+			c[oauth2.ClaimExpire] = time.Now().Add(-time.Hour).Unix()
+		}), "ExpiredIdToken"),
+		test.GomegaSubTest(SubTestEndSessionURL(op), "EndSessionURL"),
+		test.GomegaSubTest(SubTestAuthenticator(op), "Authenticator"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestDiscovery(op *MockedOP) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		metadata, e := FetchProviderMetadata(ctx, http.DefaultClient, op.URL+"/")
+		g.Expect(e).To(Succeed(), "fetching metadata should not fail")
+		g.Expect(metadata.Issuer).To(Equal(op.URL), "metadata should have correct issuer")
+		g.Expect(metadata.TokenEndpoint).To(Equal(op.URL+"/token"), "metadata should have correct token endpoint")
+
+		_, e = FetchProviderMetadata(ctx, http.DefaultClient, op.URL+"/other")
+		g.Expect(e).To(HaveOccurred(), "fetching metadata of unknown issuer should fail")
+	}
+}
+
+func SubTestRegistry(op *MockedOP) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		registry := newTestRegistry(op)
+		p, e := registry.FindByDomain(ctx, TestDomain)
+		g.Expect(e).To(Succeed(), "provider of known domain should be found")
+		g.Expect(p.IssuerURI()).To(Equal(op.URL), "provider should have correct issuer")
+		g.Expect(p.Scopes()).To(ConsistOf("openid", "email"), "provider should have correct scopes")
+
+		_, e = registry.FindByDomain(ctx, "unknown.lanai.com")
+		g.Expect(e).To(MatchError(ErrProviderNotFound), "provider of unknown domain should not be found")
+
+		jwkStore, e := registry.JwkStore(ctx, p)
+		g.Expect(e).To(Succeed(), "JWK store should be available")
+		jwk, e := jwkStore.LoadByKid(ctx, TestKid)
+		g.Expect(e).To(Succeed(), "JWK should be loaded from remote")
+		g.Expect(jwk.Id()).To(Equal(TestKid), "JWK should have correct kid")
+	}
+}
+
+func SubTestAuthenticationRequest(op *MockedOP) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		registry := newTestRegistry(op)
+		rp := NewRelyingParty(registry)
+		p, _ := registry.FindByDomain(ctx, TestDomain)
+		authReq, authUrl, e := rp.AuthenticationRequest(ctx, p, TestRedirectUri)
+		g.Expect(e).To(Succeed(), "creating authentication request should not fail")
+		g.Expect(authReq.State).ToNot(BeEmpty(), "state should be generated")
+		g.Expect(authReq.Nonce).ToNot(BeEmpty(), "nonce should be generated")
+		g.Expect(authReq.CodeVerifier).ToNot(BeEmpty(), "code verifier should be generated")
+
+		g.Expect(authUrl.String()).To(HavePrefix(op.URL+"/authorize?"), "URL should be authorization endpoint")
+		q := authUrl.Query()
+		g.Expect(q.Get(oauth2.ParameterResponseType)).To(Equal("code"), "response_type should be correct")
+		g.Expect(q.Get(oauth2.ParameterClientId)).To(Equal(TestClientId), "client_id should be correct")
+		g.Expect(q.Get(oauth2.ParameterRedirectUri)).To(Equal(TestRedirectUri), "redirect_uri should be correct")
+		g.Expect(q.Get(oauth2.ParameterScope)).To(Equal("openid email"), "scope should be correct")
+		g.Expect(q.Get(oauth2.ParameterState)).To(Equal(authReq.State), "state should be correct")
+		g.Expect(q.Get(oauth2.ParameterNonce)).To(Equal(authReq.Nonce), "nonce should be correct")
+		g.Expect(q.Get(oauth2.ParameterCodeChallengeMethod)).To(Equal("S256"), "code_challenge_method should be correct")
+		g.Expect(q.Get(oauth2.ParameterCodeChallenge)).To(Equal(codeChallenge(authReq.CodeVerifier)), "code_challenge should be correct")
+	}
+}
+
+func SubTestExchangeSuccess(op *MockedOP) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		idToken, e := exchange(ctx, op, nil)
+		g.Expect(e).To(Succeed(), "exchange should not fail")
+		g.Expect(idToken.Value).ToNot(BeEmpty(), "ID token should have value")
+		g.Expect(idToken.Claims.Get(oauth2.ClaimSubject)).To(Equal(TestSubject), "ID token should have correct subject")
+		g.Expect(idToken.Claims.Get("email")).To(Equal("ext-user@lanai.com"), "ID token should have extra claims")
+	}
+}
+
+func SubTestExchangeWithInvalidCode(op *MockedOP) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		registry := newTestRegistry(op)
+		rp := NewRelyingParty(registry)
+		p, _ := registry.FindByDomain(ctx, TestDomain)
+		authReq, _, e := rp.AuthenticationRequest(ctx, p, TestRedirectUri)
+		g.Expect(e).To(Succeed(), "creating authentication request should not fail")
+		op.challenge = codeChallenge(authReq.CodeVerifier)
+		op.nonce = authReq.Nonce
+		_, e = rp.Exchange(ctx, p, authReq, "wrong-code")
+		g.Expect(e).To(HaveOccurred(), "exchange with invalid code should fail")
+		g.Expect(e.Error()).To(ContainSubstring("invalid_grant"), "error should contain OP's error")
+	}
+}
+
+func SubTestInvalidIdToken(op *MockedOP, _ string, claimsFn func(c oauth2.MapClaims)) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		_, e := exchange(ctx, op, claimsFn)
+		g.Expect(e).To(HaveOccurred(), "exchange should fail with invalid ID token")
+	}
+}
+
+func SubTestEndSessionURL(op *MockedOP) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		registry := newTestRegistry(op)
+		rp := NewRelyingParty(registry)
+		p, _ := registry.FindByDomain(ctx, TestDomain)
+		const postLogout = "http://" + TestDomain + "/auth/oidc/logout"
+		logoutUrl, e := rp.EndSessionURL(ctx, p, "id-token-value", postLogout, "logout-state")
+		g.Expect(e).To(Succeed(), "end session URL should not fail")
+		g.Expect(logoutUrl).ToNot(BeNil(), "end session URL should be available")
+		g.Expect(logoutUrl.String()).To(HavePrefix(op.URL+"/logout?"), "URL should be end session endpoint")
+		q := logoutUrl.Query()
+		g.Expect(q.Get(ParameterIdTokenHint)).To(Equal("id-token-value"), "id_token_hint should be correct")
+		g.Expect(q.Get(ParameterPostLogoutRedirectUri)).To(Equal(postLogout), "post_logout_redirect_uri should be correct")
+		g.Expect(q.Get(oauth2.ParameterState)).To(Equal("logout-state"), "state should be correct")
+		g.Expect(q.Get(oauth2.ParameterClientId)).To(Equal(TestClientId), "client_id should be correct")
+	}
+}
+
+func SubTestAuthenticator(op *MockedOP) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		idToken, e := exchange(ctx, op, nil)
+		g.Expect(e).To(Succeed(), "exchange should not fail")
+		p, _ := newTestRegistry(op).FindByDomain(ctx, TestDomain)
+
+		store := sectest.NewMockedFederatedAccountStore(&sectest.MockedFederatedUserProperties{
+			ExtIdpName: "test-op",
+			ExtIdName:  "sub",
+			ExtIdValue: TestSubject,
+			MockedAccountProperties: sectest.MockedAccountProperties{
+				Username: "ext-user",
+				Perms:    []string{"ext-perm"},
+			},
+		})
+		auth, e := NewAuthenticator(store).Authenticate(ctx, &IdTokenCandidate{
+			Provider: p,
+			IdToken:  idToken,
+		})
+		g.Expect(e).To(Succeed(), "authenticate should not fail")
+		g.Expect(auth).To(BeAssignableToTypeOf(&oidcAuthentication{}), "authentication should be correct type")
+		g.Expect(auth.State()).To(Equal(security.StateAuthenticated), "authentication should be authenticated")
+		g.Expect(auth.Permissions()).To(HaveKey("ext-perm"), "authentication should have permissions")
+		oidcAuth := auth.(OidcAuthentication)
+		g.Expect(oidcAuth.IdToken()).To(Equal(idToken.Value), "authentication should have ID token")
+		g.Expect(oidcAuth.Issuer()).To(Equal(op.URL), "authentication should have issuer")
+		details := auth.Details().(map[string]interface{})
+		g.Expect(details).To(HaveKeyWithValue(security.DetailsKeyAuthMethod, security.AuthMethodExternalOpenID), "details should have auth method")
+
+		// unknown user
+		unknown := sectest.NewMockedFederatedAccountStore(&sectest.MockedFederatedUserProperties{
+			ExtIdpName: "test-op",
+			ExtIdName:  "sub",
+			ExtIdValue: "someone-else",
+		})
+		_, e = NewAuthenticator(unknown).Authenticate(ctx, &IdTokenCandidate{
+			Provider: p,
+			IdToken:  idToken,
+		})
+		g.Expect(e).To(HaveOccurred(), "authenticate unknown user should fail")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func newTestRegistry(op *MockedOP) *ProviderRegistry {
+	props := NewOidcAuthProperties()
+	props.Providers = map[string]OidcProviderProperties{
+		"test-op": {
+			Domain:          TestDomain,
+			IssuerURI:       op.URL,
+			ClientId:        TestClientId,
+			ClientSecret:    TestClientSecret,
+			Scopes:          []string{"openid", "email"},
+			ExternalIdpName: "test-op",
+		},
+	}
+	return NewProviderRegistry(RegistryWithProperties(props), func(opt *RegistryOption) {
+		opt.HttpClient = op.Client()
+	})
+}
+
+func exchange(ctx context.Context, op *MockedOP, claimsFn func(c oauth2.MapClaims)) (*IdToken, error) {
+	registry := newTestRegistry(op)
+	rp := NewRelyingParty(registry)
+	p, e := registry.FindByDomain(ctx, TestDomain)
+	if e != nil {
+		return nil, e
+	}
+	authReq, authUrl, e := rp.AuthenticationRequest(ctx, p, TestRedirectUri)
+	if e != nil {
+		return nil, e
+	}
+	q, _ := url.ParseQuery(authUrl.RawQuery)
+	op.challenge = q.Get(oauth2.ParameterCodeChallenge)
+	op.nonce = q.Get(oauth2.ParameterNonce)
+	op.ClaimsFn = claimsFn
+	defer func() { op.ClaimsFn = nil }()
+	return rp.Exchange(ctx, p, authReq, TestAuthCode)
+}
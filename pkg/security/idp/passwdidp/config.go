@@ -28,6 +28,7 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/security/redirect"
 	"github.com/cisco-open/go-lanai/pkg/security/request_cache"
 	"github.com/cisco-open/go-lanai/pkg/security/session"
+	"github.com/cisco-open/go-lanai/pkg/security/webauthn"
 	"github.com/cisco-open/go-lanai/pkg/web/matcher"
 	"time"
 )
@@ -116,4 +117,36 @@ func (c *PasswordIdpSecurityConfigurer) Configure(ws security.WebSecurity, confi
 			IgnoreCsrfProtectionMatcher(matcher.RequestWithPattern(config.Endpoints.Authorize.Location.Path)),
 		).
 		With(request_cache.New())
+
+	if c.props.WebAuthn.Enabled {
+		ws.With(c.webAuthnFeature())
+	}
+}
+
+func (c *PasswordIdpSecurityConfigurer) webAuthnFeature() *webauthn.WebAuthnFeature {
+	props := c.props.WebAuthn
+	return webauthn.New().
+		RelyingParty(func(opt *webauthn.RelyingPartyOption) {
+			opt.ID = props.RPID
+			if opt.ID == "" {
+				opt.ID = c.props.Domain
+			}
+			opt.Name = props.RPName
+			opt.Origins = props.Origins
+			if props.Timeout > 0 {
+				opt.Timeout = time.Duration(props.Timeout)
+			}
+			switch {
+			case props.UserVerification != "":
+				opt.UserVerification = props.UserVerification
+			case props.Passwordless:
+				opt.UserVerification = webauthn.UserVerificationRequired
+			}
+		}).
+		Passwordless(props.Passwordless).
+		SecondFactor(props.SecondFactor).
+		RegistrationOptionsUrl(props.Endpoints.RegistrationOptions).
+		RegistrationUrl(props.Endpoints.Registration).
+		AssertionOptionsUrl(props.Endpoints.AssertionOptions).
+		AssertionUrl(props.Endpoints.Assertion)
 }
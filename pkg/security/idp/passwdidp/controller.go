@@ -17,19 +17,81 @@
 package passwdidp
 
 import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security"
 	"github.com/cisco-open/go-lanai/pkg/security/formlogin"
+	"github.com/cisco-open/go-lanai/pkg/security/webauthn"
 	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/template"
 )
 
-func NewWhiteLabelLoginFormController() web.Controller {
-	return formlogin.NewDefaultLoginFormController(func(opts *formlogin.DefaultFormLoginPageOptions) {
-		opts.LoginTemplate = "login.tmpl"
-		opts.LoginProcessUrl = "/login"
-		opts.UsernameParam = "username"
-		opts.PasswordParam = "password"
-		opts.MfaTemplate = "otp_verify.tmpl"
-		opts.MfaVerifyUrl = "/login/mfa"
-		opts.MfaRefreshUrl = "/login/mfa/refresh"
-		opts.OtpParam = "otp"
-	})
+const (
+	LoginModelKeyWebAuthnAssertionOptionsUrl = "webAuthnAssertionOptionsUrl"
+	LoginModelKeyWebAuthnAssertionUrl        = "webAuthnAssertionUrl"
+	LoginModelKeyWebAuthnCredentialParam     = "webAuthnCredentialParam"
+)
+
+// WhiteLabelLoginFormController renders whitelabel login and MFA pages.
+// When WebAuthn is enabled in given PwdAuthProperties (see WithProperties), it also renders the passkey login button
+// and WebAuthn verification page.
+type WhiteLabelLoginFormController struct {
+	*formlogin.DefaultFormLoginController
+	webAuthnProps WebAuthnProperties
+}
+
+func NewWhiteLabelLoginFormController(opts ...Options) web.Controller {
+	opt := option{
+		Properties: NewPwdAuthProperties(),
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &WhiteLabelLoginFormController{
+		DefaultFormLoginController: formlogin.NewDefaultLoginFormController(func(opts *formlogin.DefaultFormLoginPageOptions) {
+			opts.LoginTemplate = "login.tmpl"
+			opts.LoginProcessUrl = "/login"
+			opts.UsernameParam = "username"
+			opts.PasswordParam = "password"
+			opts.MfaTemplate = "otp_verify.tmpl"
+			opts.MfaVerifyUrl = "/login/mfa"
+			opts.MfaRefreshUrl = "/login/mfa/refresh"
+			opts.OtpParam = "otp"
+		}),
+		webAuthnProps: opt.Properties.WebAuthn,
+	}
+}
+
+func (c *WhiteLabelLoginFormController) Mappings() []web.Mapping {
+	return []web.Mapping{
+		template.New().Get("/login").HandlerFunc(c.LoginForm).Build(),
+		template.New().Get("/login/mfa").HandlerFunc(c.OtpVerificationForm).Build(),
+	}
+}
+
+func (c *WhiteLabelLoginFormController) LoginForm(ctx context.Context, r *formlogin.LoginRequest) (*template.ModelView, error) {
+	mv, e := c.DefaultFormLoginController.LoginForm(ctx, r)
+	if e != nil || !c.webAuthnProps.Enabled || !c.webAuthnProps.Passwordless {
+		return mv, e
+	}
+	c.populateWebAuthnModel(mv.Model)
+	return mv, nil
+}
+
+// OtpVerificationForm renders WebAuthn verification page instead of OTP when WebAuthn is pending as second factor
+func (c *WhiteLabelLoginFormController) OtpVerificationForm(ctx context.Context, r *formlogin.OTPVerificationRequest) (*template.ModelView, error) {
+	mv, e := c.DefaultFormLoginController.OtpVerificationForm(ctx, r)
+	if e != nil || !c.webAuthnProps.Enabled {
+		return mv, e
+	}
+	if security.Get(ctx).Permissions().Has(webauthn.SpecialPermissionWebAuthnPending) {
+		mv.View = "webauthn_verify.tmpl"
+		c.populateWebAuthnModel(mv.Model)
+	}
+	return mv, nil
+}
+
+func (c *WhiteLabelLoginFormController) populateWebAuthnModel(model template.Model) {
+	model[LoginModelKeyWebAuthnAssertionOptionsUrl] = c.webAuthnProps.Endpoints.AssertionOptions
+	model[LoginModelKeyWebAuthnAssertionUrl] = c.webAuthnProps.Endpoints.Assertion
+	model[LoginModelKeyWebAuthnCredentialParam] = "credential"
 }
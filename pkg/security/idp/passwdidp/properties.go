@@ -34,6 +34,7 @@ type PwdAuthProperties struct {
 	Endpoints                 PwdAuthEndpointProperties `json:"endpoints"`
 	MFA                       PwdAuthMfaProperties      `json:"mfa"`
 	RememberMe                RememberMeProperties      `json:"remember-me"`
+	WebAuthn                  WebAuthnProperties        `json:"webauthn"`
 }
 
 type PwdAuthEndpointProperties struct {
@@ -62,6 +63,31 @@ type RememberMeProperties struct {
 	CookieValidity  utils.Duration `json:"cookie-validity"`
 }
 
+type WebAuthnProperties struct {
+	Enabled bool `json:"enabled"`
+	// Passwordless allows users to login with discoverable credentials (passkeys) instead of password
+	Passwordless bool `json:"passwordless"`
+	// SecondFactor requires WebAuthn after password login for users with registered credentials, instead of OTP
+	SecondFactor bool `json:"second-factor"`
+	// RPID is the relying party ID, typically the domain of the login page. Default to Domain
+	RPID   string `json:"rp-id"`
+	RPName string `json:"rp-name"`
+	// Origins are allowed origins of the login page. Default to "https://<rp-id>"
+	Origins []string       `json:"origins"`
+	Timeout utils.Duration `json:"timeout"`
+	// UserVerification is "required", "preferred" or "discouraged". Default to "required" if Passwordless is enabled,
+	// because the credential is the only authentication factor. Otherwise, default to "preferred"
+	UserVerification string                     `json:"user-verification"`
+	Endpoints        WebAuthnEndpointProperties `json:"endpoints"`
+}
+
+type WebAuthnEndpointProperties struct {
+	RegistrationOptions string `json:"registration-options"`
+	Registration        string `json:"registration"`
+	AssertionOptions    string `json:"assertion-options"`
+	Assertion           string `json:"assertion"`
+}
+
 func NewPwdAuthProperties() *PwdAuthProperties {
 	return &PwdAuthProperties{
 		Domain: "localhost",
@@ -86,6 +112,16 @@ func NewPwdAuthProperties() *PwdAuthProperties {
 		RememberMe: RememberMeProperties{
 			CookieValidity: utils.Duration(2 * 7 * 24 * 60 * time.Minute),
 		},
+		WebAuthn: WebAuthnProperties{
+			RPName:  "go-lanai",
+			Timeout: utils.Duration(5 * time.Minute),
+			Endpoints: WebAuthnEndpointProperties{
+				RegistrationOptions: "/webauthn/registration/options",
+				Registration:        "/webauthn/registration",
+				AssertionOptions:    "/webauthn/assertion/options",
+				Assertion:           "/webauthn/assertion",
+			},
+		},
 	}
 }
 
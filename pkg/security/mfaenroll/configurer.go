@@ -0,0 +1,93 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mfaenroll
+
+import (
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/access"
+	"github.com/cisco-open/go-lanai/pkg/security/csrf"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"github.com/cisco-open/go-lanai/pkg/web/mapping"
+	"github.com/cisco-open/go-lanai/pkg/web/matcher"
+	"github.com/cisco-open/go-lanai/pkg/web/rest"
+	"net/http"
+)
+
+type EnrollmentConfigurer struct {
+	accountStore security.AccountStore
+}
+
+func newEnrollmentConfigurer(accountStore security.AccountStore) *EnrollmentConfigurer {
+	return &EnrollmentConfigurer{
+		accountStore: accountStore,
+	}
+}
+
+func (c *EnrollmentConfigurer) Apply(feature security.Feature, ws security.WebSecurity) error {
+	// Verify
+	if err := c.validate(feature.(*EnrollmentFeature), ws); err != nil {
+		return err
+	}
+	f := feature.(*EnrollmentFeature)
+	ep := NewEndpoints(f)
+
+	// let ws know to intercept additional url
+	ws.Route(matcher.RouteWithPattern(f.baseUrl)).
+		Route(matcher.RouteWithPattern(f.baseUrl + "/**"))
+
+	ws.Add(rest.New("mfa enrollment status").Get(f.baseUrl).
+		EndpointFunc(ep.Status).Build())
+	ws.Add(rest.New("mfa enrollment delete").Delete(f.baseUrl).
+		EndpointFunc(ep.Unenroll).Build())
+	ws.Add(rest.New("totp enrollment").Post(f.baseUrl + "/totp").
+		EndpointFunc(ep.BeginTOTP).Build())
+	ws.Add(rest.New("totp enrollment verify").Post(f.baseUrl + "/totp/verify").
+		EndpointFunc(ep.VerifyTOTP).Build())
+	ws.Add(rest.New("mfa recovery codes").Post(f.baseUrl + "/recovery-codes").
+		EndpointFunc(ep.RegenerateRecoveryCodes).Build())
+	ws.Add(mapping.Get(f.baseUrl + "/totp/qr").
+		HandlerFunc(ep.QRCode).
+		Name("totp enrollment qr"))
+
+	// configure access
+	requestMatcher := matcher.RequestWithPattern(f.baseUrl).
+		Or(matcher.RequestWithPattern(f.baseUrl + "/**"))
+	access.Configure(ws).
+		Request(requestMatcher).WithOrder(order.Highest).
+		Authenticated()
+
+	// CSRF protection for all modifying requests
+	csrfMatcher := matcher.RequestWithPattern(f.baseUrl+"/**", http.MethodPost).
+		Or(matcher.RequestWithPattern(f.baseUrl, http.MethodDelete))
+	csrf.Configure(ws).AddCsrfProtectionMatcher(csrfMatcher)
+	return nil
+}
+
+func (c *EnrollmentConfigurer) validate(f *EnrollmentFeature, _ security.WebSecurity) error {
+	if f.accountStore == nil {
+		f.accountStore = c.accountStore
+	}
+	if _, ok := f.accountStore.(passwd.MFAEnrollmentStore); !ok {
+		return fmt.Errorf("unable to configure MFA enrollment: account store [%T] doesn't implement passwd.MFAEnrollmentStore", f.accountStore)
+	}
+	if f.secretSize < 10 {
+		return fmt.Errorf("unable to configure MFA enrollment: TOTP secret size should be at least 10 bytes")
+	}
+	return nil
+}
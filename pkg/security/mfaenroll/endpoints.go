@@ -0,0 +1,261 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mfaenroll
+
+import (
+	"context"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+	"github.com/cisco-open/go-lanai/pkg/security/session"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/gin-gonic/gin"
+	"image/png"
+	"net/http"
+	"time"
+)
+
+const (
+	sessionKeyPendingSecret = "MFAEnrollment.PendingSecret"
+	qrCodeSize              = 256
+)
+
+type TOTPVerificationRequest struct {
+	Passcode string `json:"passcode" form:"passcode" binding:"required"`
+}
+
+// StepUpRequest carries a current TOTP passcode or recovery code of existing enrollment.
+// It's required to change existing enrollment, unless the user logged in recently. See EnrollmentFeature.StepUpMaxAge
+type StepUpRequest struct {
+	Passcode string `json:"passcode" form:"passcode"`
+}
+
+type EnrollmentStatus struct {
+	Enrolled               bool       `json:"enrolled"`
+	Method                 string     `json:"method,omitempty"`
+	EnrolledAt             *time.Time `json:"enrolledAt,omitempty"`
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// Endpoints implements authenticator-app TOTP enrollment of current user.
+// The secret is kept in session until it's confirmed with a valid passcode.
+type Endpoints struct {
+	accountStore      security.AccountStore
+	enrollmentStore   passwd.MFAEnrollmentStore
+	issuer            string
+	secretSize        int
+	recoveryCodeCount int
+	stepUpMaxAge      time.Duration
+}
+
+func NewEndpoints(f *EnrollmentFeature) *Endpoints {
+	return &Endpoints{
+		accountStore:      f.accountStore,
+		enrollmentStore:   f.accountStore.(passwd.MFAEnrollmentStore),
+		issuer:            f.issuer,
+		secretSize:        f.secretSize,
+		recoveryCodeCount: f.recoveryCodeCount,
+		stepUpMaxAge:      f.stepUpMaxAge,
+	}
+}
+
+// Status returns MFA enrollment status of current user
+func (ep *Endpoints) Status(ctx context.Context, _ *http.Request) (*EnrollmentStatus, error) {
+	acct, e := ep.currentAccount(ctx)
+	if e != nil {
+		return nil, e
+	}
+	enrollment, e := ep.enrollmentStore.LoadMFAEnrollment(ctx, acct)
+	if e != nil || enrollment == nil {
+		return &EnrollmentStatus{}, e
+	}
+	return &EnrollmentStatus{
+		Enrolled:               true,
+		Method:                 enrollment.Method,
+		EnrolledAt:             &enrollment.EnrolledAt,
+		RecoveryCodesRemaining: len(enrollment.RecoveryCodes),
+	}, nil
+}
+
+// BeginTOTP generates a new TOTP secret and returns its "otpauth://" URI.
+// Existing enrollment is not changed until the new secret is confirmed via VerifyTOTP.
+// Replacing existing enrollment requires step-up verification
+func (ep *Endpoints) BeginTOTP(ctx context.Context, req *StepUpRequest) (*TOTPEnrollmentResponse, error) {
+	acct, e := ep.currentAccount(ctx)
+	if e != nil {
+		return nil, e
+	}
+	switch enrollment, e := ep.enrollmentStore.LoadMFAEnrollment(ctx, acct); {
+	case e != nil:
+		return nil, e
+	case enrollment != nil:
+		if e := passwd.VerifyStepUp(ctx, ep.accountStore, acct.Username(), req.Passcode, ep.stepUpMaxAge); e != nil {
+			return nil, e
+		}
+	}
+	s := session.Get(ctx)
+	if s == nil {
+		return nil, security.NewInternalError("MFA enrollment requires session")
+	}
+	secret, e := passwd.NewTOTPSecret(ep.secretSize)
+	if e != nil {
+		return nil, e
+	}
+	key, e := passwd.NewTOTPKey(ep.issuer, acct.Username(), secret)
+	if e != nil {
+		return nil, e
+	}
+	s.Set(sessionKeyPendingSecret, secret)
+	return &TOTPEnrollmentResponse{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+	}, nil
+}
+
+// QRCode renders the "otpauth://" URI of pending enrollment as PNG image
+func (ep *Endpoints) QRCode(c *gin.Context) {
+	acct, e := ep.currentAccount(c)
+	if e != nil {
+		_ = c.AbortWithError(http.StatusForbidden, e)
+		return
+	}
+	secret, ok := ep.pendingSecret(c)
+	if !ok {
+		_ = c.AbortWithError(http.StatusNotFound, errors.New("TOTP enrollment is not in progress"))
+		return
+	}
+	key, e := passwd.NewTOTPKey(ep.issuer, acct.Username(), secret)
+	if e != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, e)
+		return
+	}
+	img, e := key.Image(qrCodeSize, qrCodeSize)
+	if e != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, e)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "image/png")
+	c.Status(http.StatusOK)
+	if e := png.Encode(c.Writer, img); e != nil {
+		logger.WithContext(c).Warnf("unable to write QR code: %v", e)
+	}
+}
+
+// VerifyTOTP confirms pending enrollment with a passcode from the authenticator app.
+// On success, the enrollment is saved and new recovery codes are returned. Recovery codes are only shown once.
+func (ep *Endpoints) VerifyTOTP(ctx context.Context, req *TOTPVerificationRequest) (*RecoveryCodesResponse, error) {
+	acct, e := ep.currentAccount(ctx)
+	if e != nil {
+		return nil, e
+	}
+	secret, ok := ep.pendingSecret(ctx)
+	if !ok {
+		return nil, web.NewBadRequestError(errors.New("TOTP enrollment is not in progress"))
+	}
+	step, valid, e := passwd.ValidateTOTPPasscodeAfter(secret, req.Passcode, 0)
+	if e != nil || !valid {
+		return nil, web.NewBadRequestError(errors.New("invalid passcode"))
+	}
+
+	codes, hashed, e := passwd.NewRecoveryCodes(ep.recoveryCodeCount)
+	if e != nil {
+		return nil, e
+	}
+	enrollment := &passwd.MFAEnrollment{
+		Method:        passwd.MFAMethodTOTP,
+		Secret:        secret,
+		RecoveryCodes: hashed,
+		EnrolledAt:    time.Now().UTC(),
+		LastUsedStep:  step,
+	}
+	if e := ep.enrollmentStore.SaveMFAEnrollment(ctx, acct, enrollment); e != nil {
+		return nil, e
+	}
+	session.Get(ctx).Delete(sessionKeyPendingSecret)
+	logger.WithContext(ctx).Infof("TOTP enrolled for user [%s]", acct.Username())
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of current user. It requires step-up verification
+func (ep *Endpoints) RegenerateRecoveryCodes(ctx context.Context, req *StepUpRequest) (*RecoveryCodesResponse, error) {
+	acct, e := ep.currentAccount(ctx)
+	if e != nil {
+		return nil, e
+	}
+	if e := passwd.VerifyStepUp(ctx, ep.accountStore, acct.Username(), req.Passcode, ep.stepUpMaxAge); e != nil {
+		return nil, e
+	}
+	codes, hashed, e := passwd.NewRecoveryCodes(ep.recoveryCodeCount)
+	if e != nil {
+		return nil, e
+	}
+	updated, e := passwd.UpdateMFAEnrollment(ctx, ep.enrollmentStore, acct, func(enrollment *passwd.MFAEnrollment) bool {
+		enrollment.RecoveryCodes = hashed
+		return true
+	})
+	switch {
+	case e != nil:
+		return nil, e
+	case !updated:
+		return nil, web.NewBadRequestError(errors.New("MFA is not enrolled"))
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Unenroll removes MFA enrollment of current user. It requires step-up verification
+func (ep *Endpoints) Unenroll(ctx context.Context, req *StepUpRequest) (*EnrollmentStatus, error) {
+	acct, e := ep.currentAccount(ctx)
+	if e != nil {
+		return nil, e
+	}
+	if e := passwd.VerifyStepUp(ctx, ep.accountStore, acct.Username(), req.Passcode, ep.stepUpMaxAge); e != nil {
+		return nil, e
+	}
+	if e := ep.enrollmentStore.DeleteMFAEnrollment(ctx, acct); e != nil {
+		return nil, e
+	}
+	logger.WithContext(ctx).Infof("MFA enrollment removed for user [%s]", acct.Username())
+	return &EnrollmentStatus{}, nil
+}
+
+func (ep *Endpoints) currentAccount(ctx context.Context) (security.Account, error) {
+	auth := security.Get(ctx)
+	username, e := security.GetUsername(auth)
+	if e != nil || !security.IsFullyAuthenticated(auth) {
+		return nil, security.NewAccessDeniedError("MFA enrollment requires authenticated user")
+	}
+	return ep.accountStore.LoadAccountByUsername(ctx, username)
+}
+
+func (ep *Endpoints) pendingSecret(ctx context.Context) (string, bool) {
+	s := session.Get(ctx)
+	if s == nil {
+		return "", false
+	}
+	secret, ok := s.Get(sessionKeyPendingSecret).(string)
+	return secret, ok && len(secret) != 0
+}
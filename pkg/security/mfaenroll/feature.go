@@ -0,0 +1,99 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mfaenroll
+
+import (
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"time"
+)
+
+var (
+	FeatureId = security.FeatureId("MFAEnrollment", security.FeatureOrderMFAEnrollment)
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type EnrollmentFeature struct {
+	accountStore      security.AccountStore
+	issuer            string
+	secretSize        int
+	recoveryCodeCount int
+	stepUpMaxAge      time.Duration
+	baseUrl           string
+}
+
+// Configure is Standard security.Feature entrypoint
+func Configure(ws security.WebSecurity) *EnrollmentFeature {
+	feature := New()
+	if fm, ok := ws.(security.FeatureModifier); ok {
+		return fm.Enable(feature).(*EnrollmentFeature)
+	}
+	panic(fmt.Errorf("unable to configure MFA enrollment: provided WebSecurity [%T] doesn't support FeatureModifier", ws))
+}
+
+// New is Standard security.Feature entrypoint, DSL style. Used with security.WebSecurity
+func New() *EnrollmentFeature {
+	return &EnrollmentFeature{
+		issuer:            "go-lanai",
+		secretSize:        20,
+		recoveryCodeCount: 10,
+		stepUpMaxAge:      5 * time.Minute,
+		baseUrl:           "/mfa/enrollment",
+	}
+}
+
+func (f *EnrollmentFeature) Identifier() security.FeatureIdentifier {
+	return FeatureId
+}
+
+// AccountStore overrides the security.AccountStore provided via dependency injection.
+// The store must implement passwd.MFAEnrollmentStore
+func (f *EnrollmentFeature) AccountStore(store security.AccountStore) *EnrollmentFeature {
+	f.accountStore = store
+	return f
+}
+
+// Issuer is the name displayed by authenticator apps
+func (f *EnrollmentFeature) Issuer(issuer string) *EnrollmentFeature {
+	f.issuer = issuer
+	return f
+}
+
+// SecretSize is the size of TOTP secret in bytes
+func (f *EnrollmentFeature) SecretSize(size int) *EnrollmentFeature {
+	f.secretSize = size
+	return f
+}
+
+// RecoveryCodeCount is the number of recovery codes generated on enrollment
+func (f *EnrollmentFeature) RecoveryCodeCount(count int) *EnrollmentFeature {
+	f.recoveryCodeCount = count
+	return f
+}
+
+// StepUpMaxAge is how long after login the user can change existing enrollment without providing a current passcode
+// or recovery code. Zero or negative value means passcode or recovery code is always required.
+func (f *EnrollmentFeature) StepUpMaxAge(maxAge time.Duration) *EnrollmentFeature {
+	f.stepUpMaxAge = maxAge
+	return f
+}
+
+// BaseUrl is the path prefix of all enrollment endpoints
+func (f *EnrollmentFeature) BaseUrl(url string) *EnrollmentFeature {
+	f.baseUrl = url
+	return f
+}
@@ -62,6 +62,7 @@ func (c *AuthorizeEndpointConfigurer) Apply(feature security.Feature, ws securit
 		opts.AuthorizeHandler = f.authorizeHandler
 		opts.ApprovalMatcher = approveRequestMatcher
 		opts.ApprovalStore = f.approvalStore
+		opts.PushedRequestStore = f.parStore
 	})
 
 	// install middlewares
@@ -34,6 +34,7 @@ type AuthorizeFeature struct {
 	authorizeHandler auth.AuthorizeHandler
 	errorHandler     *auth.OAuth2ErrorHandler
 	approvalStore    auth.ApprovalStore
+	parStore         auth.PushedAuthorizationRequestStore
 }
 
 func (f *AuthorizeFeature) Identifier() security.FeatureIdentifier {
@@ -95,3 +96,9 @@ func (f *AuthorizeFeature) ApprovalStore(store auth.ApprovalStore) *AuthorizeFea
 	f.approvalStore = store
 	return f
 }
+
+// PushedRequestStore enables "request_uri" issued by pushed authorization request endpoint (RFC 9126)
+func (f *AuthorizeFeature) PushedRequestStore(store auth.PushedAuthorizationRequestStore) *AuthorizeFeature {
+	f.parStore = store
+	return f
+}
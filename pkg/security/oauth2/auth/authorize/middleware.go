@@ -34,6 +34,7 @@ import (
 const (
 	sessionKeyAuthorizeRequest = "kAuthorizeRequest"
 	scopeParamPrefix           = "scope."
+	ctxKeyPushedRequestUri     = "kPushedRequestUri"
 )
 
 /***********************
@@ -47,6 +48,7 @@ type AuthorizeEndpointMiddleware struct {
 	approveMatcher   web.RequestMatcher
 
 	approvalStore auth.ApprovalStore
+	parStore      auth.PushedAuthorizationRequestStore
 }
 
 //goland:noinspection GoNameStartsWithPackageName
@@ -58,6 +60,8 @@ type AuthorizeMWOption struct {
 	AuthorizeHandler auth.AuthorizeHandler
 	ApprovalMatcher  web.RequestMatcher
 	ApprovalStore    auth.ApprovalStore
+	// PushedRequestStore is optional. When set, "request_uri" issued by pushed authorization request endpoint is accepted
+	PushedRequestStore auth.PushedAuthorizationRequestStore
 }
 
 func NewAuthorizeEndpointMiddleware(opts ...AuthorizeMWOptions) *AuthorizeEndpointMiddleware {
@@ -74,6 +78,7 @@ func NewAuthorizeEndpointMiddleware(opts ...AuthorizeMWOptions) *AuthorizeEndpoi
 		authorizeHandler: opt.AuthorizeHandler,
 		approveMatcher:   opt.ApprovalMatcher,
 		approvalStore:    opt.ApprovalStore,
+		parStore:         opt.PushedRequestStore,
 	}
 }
 
@@ -86,8 +91,10 @@ func (mw *AuthorizeEndpointMiddleware) PreAuthenticateHandlerFunc(condition web.
 		// parse or load request
 		var request *auth.AuthorizeRequest
 		var err error
-		switch approve, e := mw.approveMatcher.MatchesWithContext(ctx, ctx.Request); {
-		case e == nil && approve:
+		approve, e := mw.approveMatcher.MatchesWithContext(ctx, ctx.Request)
+		approve = e == nil && approve
+		switch {
+		case approve:
 			// approve or deny request
 			if request, err = mw.loadAuthorizeRequest(ctx); err != nil {
 				err = oauth2.NewInvalidAuthorizeRequestError("error loading authorize request for approval", e)
@@ -95,6 +102,8 @@ func (mw *AuthorizeEndpointMiddleware) PreAuthenticateHandlerFunc(condition web.
 		default:
 			if request, err = auth.ParseAuthorizeRequest(ctx.Request); err != nil {
 				err = oauth2.NewInvalidAuthorizeRequestError("invalid authorize request", e)
+			} else {
+				request, err = mw.resolvePushedRequest(ctx, request)
 			}
 		}
 		if err != nil {
@@ -112,6 +121,15 @@ func (mw *AuthorizeEndpointMiddleware) PreAuthenticateHandlerFunc(condition web.
 			return
 		}
 
+		// clients requiring PAR can only use "request_uri" issued by pushed authorization request endpoint
+		if !approve {
+			if e := mw.validatePushedRequest(ctx, processed); e != nil {
+				mw.transferContextValues(processed.Context(), ctx)
+				mw.handleError(ctx, e)
+				return
+			}
+		}
+
 		// everything is ok, set it to context for later usage
 		mw.transferContextValues(processed.Context(), ctx)
 		ctx.Set(oauth2.CtxKeyValidatedAuthorizeRequest, processed)
@@ -132,6 +150,9 @@ func (mw *AuthorizeEndpointMiddleware) AuthorizeHandlerFunc(condition web.Reques
 		}
 		logger.WithContext(ctx).Debug(fmt.Sprintf("AuthorizeRequest: %s", request))
 
+		// pushed authorization request is one-time use
+		mw.removePushedRequest(ctx)
+
 		// check auto-approval and create response
 		var respFunc auth.ResponseHandlerFunc
 		e = auth.ValidateAllAutoApprovalScopes(ctx, client, request.Scopes)
@@ -325,6 +346,47 @@ func (mw *AuthorizeEndpointMiddleware) parseApproval(ctx *gin.Context) (approval
 	return
 }
 
+// resolvePushedRequest replaces given request with the one stored by pushed authorization request endpoint,
+// if its "request_uri" is issued by that endpoint. Other parameters are ignored in such case, except "client_id".
+// See https://datatracker.ietf.org/doc/html/rfc9126#section-4
+func (mw *AuthorizeEndpointMiddleware) resolvePushedRequest(ctx *gin.Context, request *auth.AuthorizeRequest) (*auth.AuthorizeRequest, error) {
+	requestUri := request.Parameters[oauth2.ParameterRequestUri]
+	if mw.parStore == nil || !auth.IsPushedAuthorizationRequestUri(requestUri) {
+		return request, nil
+	}
+	if request.ClientId == "" {
+		return nil, oauth2.NewInvalidAuthorizeRequestError(fmt.Sprintf("%s is required when using %s", oauth2.ParameterClientId, oauth2.ParameterRequestUri))
+	}
+
+	pushed, e := mw.parStore.LoadAuthorizeRequest(ctx, requestUri, request.ClientId)
+	if e != nil {
+		return nil, e
+	}
+	ctx.Set(ctxKeyPushedRequestUri, requestUri)
+	return pushed.WithContext(ctx.Request.Context()), nil
+}
+
+func (mw *AuthorizeEndpointMiddleware) validatePushedRequest(ctx *gin.Context, request *auth.AuthorizeRequest) error {
+	client, ok := request.Context().Value(oauth2.CtxKeyAuthenticatedClient).(oauth2.PushedAuthorizationAware)
+	if !ok || !client.RequirePushedAuthorizationRequests() {
+		return nil
+	}
+	if _, ok := ctx.Value(ctxKeyPushedRequestUri).(string); !ok {
+		return oauth2.NewInvalidAuthorizeRequestError("client requires pushed authorization request")
+	}
+	return nil
+}
+
+func (mw *AuthorizeEndpointMiddleware) removePushedRequest(ctx *gin.Context) {
+	requestUri, ok := ctx.Value(ctxKeyPushedRequestUri).(string)
+	if !ok || mw.parStore == nil {
+		return
+	}
+	if e := mw.parStore.RemoveAuthorizeRequest(ctx, requestUri); e != nil {
+		logger.WithContext(ctx).Warnf("pushed authorization request was not removed: %v", e)
+	}
+}
+
 func (mw *AuthorizeEndpointMiddleware) transferContextValues(src context.Context, dst context.Context) {
 	mutable := utils.FindMutableContext(dst)
 	listable, ok := src.(utils.ListableContext)
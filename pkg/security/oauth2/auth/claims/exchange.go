@@ -0,0 +1,62 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package claims
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"strings"
+)
+
+// ExchangedAudience returns the narrowed audience requested during token exchange.
+// The value is only available when the source authentication is created by the token exchange grant.
+func ExchangedAudience(_ context.Context, opt *FactoryOption) (v interface{}, err error) {
+	if opt.Source.OAuth2Request() == nil {
+		return nil, errorMissingRequest
+	}
+	aud, ok := opt.Source.OAuth2Request().Extensions()[oauth2.ExtTokenExchangeAudience].(string)
+	if !ok || strings.TrimSpace(aud) == "" {
+		return nil, errorMissingRequestParams
+	}
+	return utils.NewStringSet(strings.Fields(aud)...), nil
+}
+
+// Actor returns the "act" claim as defined in RFC 8693 section 4.1.
+// The value is prepared by token exchange granter and stored in the source authentication's OAuth2 request extensions.
+// It represents the complete delegation chain: the current actor at top level, prior actors as nested "act" claims.
+func Actor(_ context.Context, opt *FactoryOption) (v interface{}, err error) {
+	if opt.Source.OAuth2Request() == nil {
+		return nil, errorMissingRequest
+	}
+	actor, ok := opt.Source.OAuth2Request().Extensions()[oauth2.ExtTokenExchangeActor].(map[string]interface{})
+	if !ok || len(actor) == 0 {
+		return nil, errorMissingRequestParams
+	}
+	return copyActor(actor), nil
+}
+
+func copyActor(actor map[string]interface{}) map[string]interface{} {
+	act := make(map[string]interface{}, len(actor))
+	for k, v := range actor {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = copyActor(nested)
+		}
+		act[k] = v
+	}
+	return act
+}
@@ -0,0 +1,30 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package claims
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+)
+
+var (
+	// TokenExchangeClaimSpecs are applied on top of regular access token claims
+	// when the token is issued via token exchange grant
+	TokenExchangeClaimSpecs = map[string]ClaimSpec{
+		oauth2.ClaimAudience: Optional(ExchangedAudience),
+		oauth2.ClaimActor:    Optional(Actor),
+	}
+)
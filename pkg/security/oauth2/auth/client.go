@@ -38,9 +38,25 @@ type ClientDetails struct {
 	UseSessionTimeout    bool
 	AssignedTenantIds    utils.StringSet
 	ResourceIds          utils.StringSet
-}
-
-// DefaultOAuth2Client implements security.Account & OAuth2Client
+	// TokenEndpointAuthMethod see oauth2.ClientAssertionAware
+	TokenEndpointAuthMethod string
+	// JwksUri see oauth2.ClientAssertionAware
+	JwksUri string
+	// Jwks see oauth2.ClientAssertionAware
+	Jwks string
+	// RequirePushedAuthorizationRequests see oauth2.PushedAuthorizationAware
+	RequirePushedAuthorizationRequests bool
+	// IdTokenEncryptedResponseAlg see oauth2.EncryptionAware
+	IdTokenEncryptedResponseAlg string
+	// IdTokenEncryptedResponseEnc see oauth2.EncryptionAware
+	IdTokenEncryptedResponseEnc string
+	// UserInfoEncryptedResponseAlg see oauth2.EncryptionAware
+	UserInfoEncryptedResponseAlg string
+	// UserInfoEncryptedResponseEnc see oauth2.EncryptionAware
+	UserInfoEncryptedResponseEnc string
+}
+
+// DefaultOAuth2Client implements security.Account, OAuth2Client, ClientAssertionAware, PushedAuthorizationAware & EncryptionAware
 type DefaultOAuth2Client struct {
 	ClientDetails
 }
@@ -105,6 +121,40 @@ func (c *DefaultOAuth2Client) ResourceIDs() utils.StringSet {
 	return c.ClientDetails.ResourceIds
 }
 
+/** ClientAssertionAware **/
+func (c *DefaultOAuth2Client) TokenEndpointAuthMethod() string {
+	return c.ClientDetails.TokenEndpointAuthMethod
+}
+
+func (c *DefaultOAuth2Client) JwksUri() string {
+	return c.ClientDetails.JwksUri
+}
+
+func (c *DefaultOAuth2Client) Jwks() string {
+	return c.ClientDetails.Jwks
+}
+
+func (c *DefaultOAuth2Client) RequirePushedAuthorizationRequests() bool {
+	return c.ClientDetails.RequirePushedAuthorizationRequests
+}
+
+/** EncryptionAware **/
+func (c *DefaultOAuth2Client) IdTokenEncryptedResponseAlg() string {
+	return c.ClientDetails.IdTokenEncryptedResponseAlg
+}
+
+func (c *DefaultOAuth2Client) IdTokenEncryptedResponseEnc() string {
+	return c.ClientDetails.IdTokenEncryptedResponseEnc
+}
+
+func (c *DefaultOAuth2Client) UserInfoEncryptedResponseAlg() string {
+	return c.ClientDetails.UserInfoEncryptedResponseAlg
+}
+
+func (c *DefaultOAuth2Client) UserInfoEncryptedResponseEnc() string {
+	return c.ClientDetails.UserInfoEncryptedResponseEnc
+}
+
 func (c *DefaultOAuth2Client) MaxTokensPerUser() int {
 	return -1
 }
@@ -0,0 +1,71 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/redis"
+	"time"
+)
+
+const (
+	clientAssertionPrefix = "CA"
+)
+
+// ClientAssertionReplayStore keeps track of used client assertions, so that each JWT assertion can only be used once.
+// See https://datatracker.ietf.org/doc/html/rfc7523#section-3
+type ClientAssertionReplayStore interface {
+	// MarkUsed records the "jti" of given client until the assertion expires.
+	// It returns false if the same "jti" was already used by the client
+	MarkUsed(ctx context.Context, clientId, jti string, expireAt time.Time) (bool, error)
+}
+
+// RedisClientAssertionReplayStore implements ClientAssertionReplayStore using Redis
+type RedisClientAssertionReplayStore struct {
+	redisClient redis.Client
+}
+
+func NewRedisClientAssertionReplayStore(ctx context.Context, cf redis.ClientFactory, dbIndex int) *RedisClientAssertionReplayStore {
+	client, e := cf.New(ctx, func(opt *redis.ClientOption) {
+		opt.DbIndex = dbIndex
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	return &RedisClientAssertionReplayStore{
+		redisClient: client,
+	}
+}
+
+func (s *RedisClientAssertionReplayStore) MarkUsed(ctx context.Context, clientId, jti string, expireAt time.Time) (bool, error) {
+	ttl := time.Until(expireAt)
+	if ttl <= 0 {
+		// expired assertion is rejected anyway, nothing to record
+		return true, nil
+	}
+	cmd := s.redisClient.SetNX(ctx, s.redisKey(clientId, jti), expireAt.Unix(), ttl)
+	if cmd.Err() != nil {
+		return false, cmd.Err()
+	}
+	return cmd.Val(), nil
+}
+
+func (s *RedisClientAssertionReplayStore) redisKey(clientId, jti string) string {
+	return fmt.Sprintf("%s:%s:%s", clientAssertionPrefix, clientId, jti)
+}
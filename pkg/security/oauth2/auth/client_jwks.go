@@ -0,0 +1,68 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"sync"
+)
+
+// ErrClientJwksNotAvailable is returned by ClientJwkResolver when client registered neither "jwks" nor "jwks_uri"
+var ErrClientJwksNotAvailable = errors.New("client doesn't have JWK Set")
+
+// ClientJwkResolver resolves public keys of clients registered with "jwks" or "jwks_uri" (oauth2.ClientAssertionAware).
+// jwt.RemoteJwkStore is cached by JWK Set URL, so clients sharing same "jwks_uri" share same store.
+type ClientJwkResolver struct {
+	mtx          sync.Mutex
+	remoteStores map[string]jwt.JwkStore
+}
+
+func NewClientJwkResolver() *ClientJwkResolver {
+	return &ClientJwkResolver{
+		remoteStores: map[string]jwt.JwkStore{},
+	}
+}
+
+// Resolve returns jwt.JwkStore of given client. Inline "jwks" takes precedence over "jwks_uri".
+// ErrClientJwksNotAvailable is returned if client has neither.
+func (r *ClientJwkResolver) Resolve(client oauth2.ClientAssertionAware) (jwt.JwkStore, error) {
+	if jwksJson := client.Jwks(); len(jwksJson) != 0 {
+		jwks, e := jwt.ParseJwkSet([]byte(jwksJson))
+		if e != nil {
+			return nil, fmt.Errorf("invalid JWK Set: %v", e)
+		}
+		return jwt.NewJwkSetStore(jwks...), nil
+	}
+
+	uri := client.JwksUri()
+	if len(uri) == 0 {
+		return nil, ErrClientJwksNotAvailable
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if store, ok := r.remoteStores[uri]; ok {
+		return store, nil
+	}
+	store := jwt.NewRemoteJwkStore(func(cfg *jwt.RemoteJwkConfig) {
+		cfg.JwkSetURL = uri
+	})
+	r.remoteStores[uri] = store
+	return store, nil
+}
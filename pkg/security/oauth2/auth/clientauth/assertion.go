@@ -0,0 +1,307 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package clientauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"time"
+)
+
+const (
+	defaultAssertionLeeway = 30 * time.Second
+)
+
+/******************************
+	Candidate
+ ******************************/
+
+// AssertionCandidate implements security.Candidate for JWT assertion based client authentication
+// See https://datatracker.ietf.org/doc/html/rfc7523#section-2.2
+type AssertionCandidate struct {
+	// ClientId is the "client_id" form parameter. Optional, if present, it has to match the assertion's "sub"
+	ClientId      string
+	Assertion     string
+	AssertionType string
+	DetailsMap    map[string]interface{}
+}
+
+func (c *AssertionCandidate) Principal() interface{} {
+	return c.ClientId
+}
+
+func (c *AssertionCandidate) Credentials() interface{} {
+	return c.Assertion
+}
+
+func (c *AssertionCandidate) Details() interface{} {
+	return c.DetailsMap
+}
+
+/******************************
+	Authentication
+ ******************************/
+
+// assertionAuthentication implements security.Authentication
+type assertionAuthentication struct {
+	Acct       security.Account
+	Perms      map[string]interface{}
+	DetailsMap map[string]interface{}
+}
+
+func (a *assertionAuthentication) Principal() interface{} {
+	return a.Acct
+}
+
+func (a *assertionAuthentication) Permissions() security.Permissions {
+	return a.Perms
+}
+
+func (a *assertionAuthentication) State() security.AuthenticationState {
+	return security.StateAuthenticated
+}
+
+func (a *assertionAuthentication) Details() interface{} {
+	return a.DetailsMap
+}
+
+/******************************
+	Authenticator
+ ******************************/
+
+type AssertionAuthenticatorOptions func(opt *AssertionAuthenticatorOption)
+type AssertionAuthenticatorOption struct {
+	ClientStore oauth2.OAuth2ClientStore
+	// Audiences acceptable "aud" of assertions. Typically, the issuer's identifier and the token endpoint URL
+	Audiences utils.StringSet
+	// ReplayStore is used to reject re-used assertions. Optional, "jti" is not tracked when not set
+	ReplayStore auth.ClientAssertionReplayStore
+	// Leeway is the allowed clock skew when validating "exp", "nbf" and "iat"
+	Leeway time.Duration
+}
+
+// AssertionAuthenticator implements security.Authenticator and authenticates clients using JWT assertion:
+//   - "private_key_jwt": assertion is verified using client's public keys (JWK Set, inline or by URL)
+//   - "client_secret_jwt": assertion is verified using HMAC with client's secret.
+//     Note: this requires client secret to be stored in clear text
+//
+// See https://datatracker.ietf.org/doc/html/rfc7523#section-3 and
+// https://openid.net/specs/openid-connect-core-1_0.html#ClientAuthentication
+type AssertionAuthenticator struct {
+	clientStore oauth2.OAuth2ClientStore
+	audiences   utils.StringSet
+	replayStore auth.ClientAssertionReplayStore
+	leeway      time.Duration
+	parser      *gojwt.Parser
+	jwkResolver *auth.ClientJwkResolver
+}
+
+func NewAssertionAuthenticator(opts ...AssertionAuthenticatorOptions) *AssertionAuthenticator {
+	opt := AssertionAuthenticatorOption{
+		Audiences: utils.NewStringSet(),
+		Leeway:    defaultAssertionLeeway,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &AssertionAuthenticator{
+		clientStore: opt.ClientStore,
+		audiences:   opt.Audiences,
+		replayStore: opt.ReplayStore,
+		leeway:      opt.Leeway,
+		parser:      gojwt.NewParser(),
+		jwkResolver: auth.NewClientJwkResolver(),
+	}
+}
+
+func (a *AssertionAuthenticator) Authenticate(ctx context.Context, candidate security.Candidate) (security.Authentication, error) {
+	c, ok := candidate.(*AssertionCandidate)
+	if !ok {
+		return nil, nil
+	}
+
+	if c.AssertionType != oauth2.ClientAssertionTypeJwtBearer {
+		return nil, security.NewBadCredentialsError(fmt.Sprintf("unsupported client assertion type [%s]", c.AssertionType))
+	}
+
+	// find out who is the client
+	clientId, e := a.resolveClientId(c)
+	if e != nil {
+		return nil, e
+	}
+	client, e := a.clientStore.LoadClientByClientId(ctx, clientId)
+	if e != nil {
+		return nil, security.NewUsernameNotFoundError("invalid clientId", e)
+	}
+	acct, ok := client.(security.Account)
+	if !ok {
+		return nil, security.NewInternalAuthenticationError("loaded client is not an account")
+	}
+
+	// verify signature and claims
+	dec, e := a.decoder(ctx, client)
+	if e != nil {
+		return nil, e
+	}
+	claims := oauth2.BasicClaims{}
+	if e := dec.DecodeWithClaims(ctx, c.Assertion, &claims); e != nil {
+		return nil, security.NewBadCredentialsError("invalid client assertion", e)
+	}
+	if e := a.validateClaims(ctx, clientId, &claims); e != nil {
+		return nil, e
+	}
+
+	// create authentication
+	details := c.DetailsMap
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details[security.DetailsKeyAuthTime] = time.Now().UTC()
+	perms := map[string]interface{}{}
+	for _, p := range acct.Permissions() {
+		perms[p] = true
+	}
+	return &assertionAuthentication{
+		Acct:       acct,
+		Perms:      perms,
+		DetailsMap: details,
+	}, nil
+}
+
+// resolveClientId extract client ID from unverified assertion, and make sure it's consistent with "client_id" parameter
+func (a *AssertionAuthenticator) resolveClientId(c *AssertionCandidate) (string, error) {
+	claims := gojwt.RegisteredClaims{}
+	if _, _, e := a.parser.ParseUnverified(c.Assertion, &claims); e != nil {
+		return "", security.NewBadCredentialsError("malformed client assertion", e)
+	}
+	switch {
+	case len(claims.Subject) == 0:
+		return "", security.NewBadCredentialsError(`client assertion is missing "sub"`)
+	case len(c.ClientId) != 0 && c.ClientId != claims.Subject:
+		return "", security.NewBadCredentialsError(`client_id parameter and client assertion's "sub" doesn't match`)
+	}
+	return claims.Subject, nil
+}
+
+func (a *AssertionAuthenticator) decoder(ctx context.Context, client oauth2.OAuth2Client) (jwt.JwtDecoder, error) {
+	aware, ok := client.(oauth2.ClientAssertionAware)
+	if !ok {
+		return nil, security.NewBadCredentialsError("client is not registered for JWT assertion authentication")
+	}
+
+	var store jwt.JwkStore
+	var methods []gojwt.SigningMethod
+	switch aware.TokenEndpointAuthMethod() {
+	case oauth2.ClientAuthMethodPrivateKeyJwt:
+		s, e := a.clientJwkStore(ctx, aware)
+		if e != nil {
+			return nil, e
+		}
+		store = s
+		methods = jwt.AsymmetricSigningMethods
+	case oauth2.ClientAuthMethodSecretJwt:
+		if len(client.Secret()) == 0 {
+			return nil, security.NewBadCredentialsError("client doesn't have secret for client_secret_jwt authentication")
+		}
+		store = jwt.NewJwkSetStore(jwt.NewJwk(client.ClientId(), client.ClientId(), []byte(client.Secret())))
+		methods = jwt.SymmetricSigningMethods
+	default:
+		return nil, security.NewBadCredentialsError("client is not registered for JWT assertion authentication")
+	}
+	return jwt.NewSignedJwtDecoder(
+		jwt.VerifyWithJwkStore(singleJwkFallbackStore{store}, ""),
+		jwt.VerifyWithMethods(methods...),
+	), nil
+}
+
+func (a *AssertionAuthenticator) clientJwkStore(_ context.Context, client oauth2.ClientAssertionAware) (jwt.JwkStore, error) {
+	store, e := a.jwkResolver.Resolve(client)
+	switch {
+	case errors.Is(e, auth.ErrClientJwksNotAvailable):
+		return nil, security.NewInternalAuthenticationError("client doesn't have JWK Set for private_key_jwt authentication")
+	case e != nil:
+		return nil, security.NewInternalAuthenticationError("invalid client JWK Set", e)
+	}
+	return store, nil
+}
+
+func (a *AssertionAuthenticator) validateClaims(ctx context.Context, clientId string, claims *oauth2.BasicClaims) error {
+	now := time.Now()
+	switch {
+	case claims.Issuer != clientId || claims.Subject != clientId:
+		return security.NewBadCredentialsError(`client assertion's "iss" and "sub" must be the client ID`)
+	case !a.hasValidAudience(claims):
+		return security.NewBadCredentialsError(`client assertion's "aud" doesn't identify this authorization server`)
+	case claims.ExpiresAt.IsZero():
+		return security.NewBadCredentialsError(`client assertion is missing "exp"`)
+	case now.After(claims.ExpiresAt.Add(a.leeway)):
+		return security.NewBadCredentialsError("client assertion is expired")
+	case !claims.NotBefore.IsZero() && now.Add(a.leeway).Before(claims.NotBefore):
+		return security.NewBadCredentialsError("client assertion is not valid yet")
+	case !claims.IssuedAt.IsZero() && now.Add(a.leeway).Before(claims.IssuedAt):
+		return security.NewBadCredentialsError("client assertion is issued in the future")
+	case len(claims.Id) == 0:
+		return security.NewBadCredentialsError(`client assertion is missing "jti"`)
+	}
+
+	if a.replayStore == nil {
+		return nil
+	}
+	switch ok, e := a.replayStore.MarkUsed(ctx, clientId, claims.Id, claims.ExpiresAt.Add(a.leeway)); {
+	case e != nil:
+		return security.NewInternalAuthenticationError("unable to verify client assertion", e)
+	case !ok:
+		return security.NewBadCredentialsError("client assertion is already used")
+	}
+	return nil
+}
+
+func (a *AssertionAuthenticator) hasValidAudience(claims *oauth2.BasicClaims) bool {
+	for aud := range claims.Audience {
+		if a.audiences.Has(aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// singleJwkFallbackStore wraps a jwt.JwkStore. When assertion doesn't have "kid" header, jwt.SignedJwtDecoder would
+// load key by empty name, in such case, we use the only key available in the store.
+type singleJwkFallbackStore struct {
+	jwt.JwkStore
+}
+
+func (s singleJwkFallbackStore) LoadByName(ctx context.Context, name string) (jwt.Jwk, error) {
+	if len(name) != 0 {
+		return s.JwkStore.LoadByName(ctx, name)
+	}
+	switch jwks, e := s.JwkStore.LoadAll(ctx); {
+	case e != nil:
+		return nil, e
+	case len(jwks) != 1:
+		return nil, fmt.Errorf(`"kid" is required when client has %d keys`, len(jwks))
+	default:
+		return jwks[0], nil
+	}
+}
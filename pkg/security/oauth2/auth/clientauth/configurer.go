@@ -17,13 +17,16 @@
 package clientauth
 
 import (
+    "context"
     "fmt"
     "github.com/cisco-open/go-lanai/pkg/security"
     "github.com/cisco-open/go-lanai/pkg/security/access"
     "github.com/cisco-open/go-lanai/pkg/security/basicauth"
     "github.com/cisco-open/go-lanai/pkg/security/errorhandling"
+    "github.com/cisco-open/go-lanai/pkg/security/oauth2"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
     "github.com/cisco-open/go-lanai/pkg/security/passwd"
+    "github.com/cisco-open/go-lanai/pkg/utils"
     "github.com/cisco-open/go-lanai/pkg/web/matcher"
     "github.com/cisco-open/go-lanai/pkg/web/middleware"
 )
@@ -80,6 +83,19 @@ func (c *ClientAuthConfigurer) Apply(feature security.Feature, ws security.WebSe
 		)
 	}
 
+	// add authenticator and middleware to support JWT assertion based client auth
+	if f.allowAssertion {
+		ws.Authenticator().(*security.CompositeAuthenticator).Add(NewAssertionAuthenticator(func(opt *AssertionAuthenticatorOption) {
+			opt.ClientStore = f.clientStore
+			opt.Audiences = utils.NewStringSet(f.assertionAudiences...)
+			opt.ReplayStore = f.assertionReplay
+		}))
+		ws.Add(middleware.NewBuilder("assertion client auth").
+			Order(security.MWOrderFormAuth).
+			Use(mw.ClientAssertionHandlerFunc()),
+		)
+	}
+
 	return nil
 }
 
@@ -95,11 +111,44 @@ func (c *ClientAuthConfigurer) validate(f *ClientAuthFeature, ws security.WebSec
 	if f.errorHandler == nil {
 		f.errorHandler = auth.NewOAuth2ErrorHandler()
 	}
+
+	if f.allowAssertion {
+		if _, ok := ws.Authenticator().(*security.CompositeAuthenticator); !ok {
+			return fmt.Errorf("unable to add client assertion authenticator to %T", ws.Authenticator())
+		}
+		if len(f.assertionAudiences) == 0 {
+			return fmt.Errorf("audiences of client assertion is not set")
+		}
+	}
 	return nil
 }
 
-func (c *ClientAuthConfigurer) clientAccountStore(f *ClientAuthFeature) *auth.OAuth2ClientAccountStore {
-	return auth.WrapOAuth2ClientStore(f.clientStore)
+func (c *ClientAuthConfigurer) clientAccountStore(f *ClientAuthFeature) security.AccountStore {
+	store := auth.WrapOAuth2ClientStore(f.clientStore)
+	if !f.allowAssertion {
+		return store
+	}
+	return &secretClientAccountStore{OAuth2ClientAccountStore: store}
+}
+
+// secretClientAccountStore wraps auth.OAuth2ClientAccountStore and hides clients registered with JWT assertion
+// based authentication methods, so that such clients cannot authenticate using client secret (or as public client).
+type secretClientAccountStore struct {
+	*auth.OAuth2ClientAccountStore
+}
+
+func (s *secretClientAccountStore) LoadAccountByUsername(ctx context.Context, username string) (security.Account, error) {
+	acct, e := s.OAuth2ClientAccountStore.LoadAccountByUsername(ctx, username)
+	if e != nil {
+		return nil, e
+	}
+	if aware, ok := acct.(oauth2.ClientAssertionAware); ok {
+		switch aware.TokenEndpointAuthMethod() {
+		case oauth2.ClientAuthMethodPrivateKeyJwt, oauth2.ClientAuthMethodSecretJwt:
+			return nil, security.NewUsernameNotFoundError("client requires JWT assertion authentication")
+		}
+	}
+	return acct, nil
 }
 
 
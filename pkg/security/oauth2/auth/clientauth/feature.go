@@ -31,6 +31,9 @@ type ClientAuthFeature struct {
 	clientSecretEncoder passwd.PasswordEncoder
 	errorHandler        *auth.OAuth2ErrorHandler
 	allowForm           bool
+	allowAssertion      bool
+	assertionAudiences  []string
+	assertionReplay     auth.ClientAssertionReplayStore
 }
 
 // Standard security.Feature entrypoint
@@ -72,4 +75,25 @@ func (f *ClientAuthFeature) ErrorHandler(errorHandler *auth.OAuth2ErrorHandler)
 func (f *ClientAuthFeature) AllowForm(allowForm bool) *ClientAuthFeature {
 	f.allowForm = allowForm
 	return f
-}
\ No newline at end of file
+}
+
+// AllowAssertion with "true" enables JWT assertion based client authentication, i.e. "private_key_jwt" and
+// "client_secret_jwt". See https://datatracker.ietf.org/doc/html/rfc7523#section-2.2
+// Clients registered with these methods are not allowed to authenticate using client secret.
+func (f *ClientAuthFeature) AllowAssertion(allowAssertion bool) *ClientAuthFeature {
+	f.allowAssertion = allowAssertion
+	return f
+}
+
+// AssertionAudiences set acceptable "aud" of client assertions. Typically, the issuer's identifier and the token endpoint URL.
+// Required when assertion is allowed
+func (f *ClientAuthFeature) AssertionAudiences(audiences ...string) *ClientAuthFeature {
+	f.assertionAudiences = append(f.assertionAudiences, audiences...)
+	return f
+}
+
+// AssertionReplayStore set the store used to prevent client assertions from being re-used.
+func (f *ClientAuthFeature) AssertionReplayStore(store auth.ClientAssertionReplayStore) *ClientAuthFeature {
+	f.assertionReplay = store
+	return f
+}
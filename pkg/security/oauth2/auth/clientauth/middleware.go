@@ -60,7 +60,9 @@ func (mw *Middleware) ClientAuthFormHandlerFunc() http.HandlerFunc {
 		}
 
 		_, hasClientId := r.Form[oauth2.ParameterClientId]
-		if !hasClientId {
+		_, hasAssertion := r.PostForm[oauth2.ParameterClientAssertion]
+		if !hasClientId || hasAssertion {
+			// client assertion is handled by ClientAssertionHandlerFunc
 			return
 		}
 
@@ -94,6 +96,42 @@ func (mw *Middleware) ClientAuthFormHandlerFunc() http.HandlerFunc {
 	}
 }
 
+// ClientAssertionHandlerFunc authenticate client using "client_assertion" and "client_assertion_type" form parameters
+// See https://datatracker.ietf.org/doc/html/rfc7523#section-2.2
+func (mw *Middleware) ClientAssertionHandlerFunc() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if e := r.ParseForm(); e != nil {
+			return
+		}
+
+		_, hasAssertion := r.PostForm[oauth2.ParameterClientAssertion]
+		_, hasAssertionType := r.PostForm[oauth2.ParameterClientAssertionType]
+		if !hasAssertion && !hasAssertionType {
+			return
+		}
+
+		// client should not use more than one authentication method
+		before := security.Get(r.Context())
+		if _, ok := before.(passwd.UsernamePasswordAuthentication); ok {
+			mw.handleError(r.Context(), oauth2.NewInvalidClientError("multiple client authentication methods are used"))
+			return
+		}
+
+		candidate := AssertionCandidate{
+			ClientId:      r.PostForm.Get(oauth2.ParameterClientId),
+			Assertion:     r.PostForm.Get(oauth2.ParameterClientAssertion),
+			AssertionType: r.PostForm.Get(oauth2.ParameterClientAssertionType),
+		}
+		// Authenticate
+		auth, err := mw.authenticator.Authenticate(r.Context(), &candidate)
+		if err != nil {
+			mw.handleError(r.Context(), err)
+			return
+		}
+		mw.handleSuccess(r.Context(), r, rw, before, auth)
+	}
+}
+
 func (mw *Middleware) ErrorTranslationHandlerFunc() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
@@ -22,6 +22,8 @@ const (
 	TokenEnhancerOrderBasicClaims
 	TokenEnhancerOrderDetailsClaims
 	TokenEnhancerOrderResourceIdClaims
+	TokenEnhancerOrderTokenExchangeClaims
+	TokenEnhancerOrderDPoPClaims
 	TokenEnhancerOrderTokenDetails
 	TokenEnhancerOrderRefreshToken
 	//TokenEnhancerOrder
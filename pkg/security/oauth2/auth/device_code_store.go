@@ -0,0 +1,504 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/redis"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	redislib "github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultDeviceCodeLength    = 40
+	defaultUserCodeLength      = 8
+	deviceCodePrefix           = "DC"
+	userCodePrefix             = "UC"
+	userCodeAttemptsPrefix     = "UCA"
+	userCodeMaxRetries         = 5
+	consumeMaxRetries          = 3
+	defaultUserCodeMaxAttempts = 5
+	// userCodeCharset is the base-20 charset recommended by https://datatracker.ietf.org/doc/html/rfc8628#section-6.1
+	userCodeCharset utils.RandomCharset = "BCDFGHJKLMNPQRSTVWXZ"
+)
+
+var (
+	defaultDeviceCodeValidity    = 10 * time.Minute
+	defaultDeviceCodeInterval    = 5 * time.Second
+	defaultUserCodeAttemptWindow = 15 * time.Minute
+	// slowDownIncrement is how much polling interval is increased each time a client polls too fast.
+	// https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+	slowDownIncrement = 5 * time.Second
+	// deviceCodeGracePeriod keeps expired device codes around a little longer, so polling clients would get
+	// "expired_token" instead of "invalid_grant"
+	deviceCodeGracePeriod = time.Minute
+)
+
+/**********************
+	Abstraction
+ **********************/
+
+type DeviceCodeStatus string
+
+const (
+	DeviceCodeStatusPending  DeviceCodeStatus = "pending"
+	DeviceCodeStatusApproved DeviceCodeStatus = "approved"
+	DeviceCodeStatusDenied   DeviceCodeStatus = "denied"
+)
+
+// DeviceAuthorization is the pending authorization created by device authorization endpoint
+// See https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type DeviceAuthorization struct {
+	DeviceCode string
+	UserCode   string
+	Status     DeviceCodeStatus
+	Request    oauth2.OAuth2Request
+	ExpireAt   time.Time
+	Interval   time.Duration
+}
+
+// DeviceCodeStore manages device codes and user codes of OAuth2 Device Authorization Grant
+// See https://datatracker.ietf.org/doc/html/rfc8628
+type DeviceCodeStore interface {
+	// GenerateDeviceCode creates a pending DeviceAuthorization for given request
+	GenerateDeviceCode(ctx context.Context, r oauth2.OAuth2Request) (*DeviceAuthorization, error)
+	// LoadByUserCode loads pending DeviceAuthorization by user code entered by end user.
+	// error is returned if the user code is invalid, expired or already processed
+	LoadByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error)
+	// ApproveUserCode marks the pending DeviceAuthorization as approved by given user
+	ApproveUserCode(ctx context.Context, userCode string, user security.Authentication) error
+	// DenyUserCode marks the pending DeviceAuthorization as denied
+	DenyUserCode(ctx context.Context, userCode string) error
+	// ConsumeDeviceCode is used by token granter when client polls with device code.
+	// It returns the approved oauth2.Authentication and remove the device code.
+	// Otherwise, one of "authorization_pending", "slow_down", "access_denied" and "expired_token" error is returned
+	ConsumeDeviceCode(ctx context.Context, deviceCode string, clientId string) (oauth2.Authentication, error)
+}
+
+// UserCodeAttemptLimiter is an optional interface of DeviceCodeStore. When supported, failed user code attempts are
+// tracked, and further attempts are rejected after too many failures, to prevent end users from brute forcing user codes.
+// See https://datatracker.ietf.org/doc/html/rfc8628#section-5.1
+type UserCodeAttemptLimiter interface {
+	// CheckUserCodeAttempts returns error if given key (e.g. username) reached max failed attempts
+	CheckUserCodeAttempts(ctx context.Context, key string) error
+	// RecordFailedUserCodeAttempt increases failed attempts of given key
+	RecordFailedUserCodeAttempt(ctx context.Context, key string) error
+}
+
+// NormalizeUserCode removes any characters that are not in the user code charset and converts to upper case.
+// This allows end users to enter user code with dashes, spaces or in lower case
+func NormalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToUpper(r)
+		if strings.ContainsRune(string(userCodeCharset), r) {
+			return r
+		}
+		return -1
+	}, userCode)
+}
+
+// FormatUserCode formats normalized user code for display, e.g. "BCDF-GHJK"
+func FormatUserCode(userCode string) string {
+	if len(userCode) <= 4 {
+		return userCode
+	}
+	mid := len(userCode) / 2
+	return userCode[:mid] + "-" + userCode[mid:]
+}
+
+/**********************
+	Redis Impl
+ **********************/
+
+type DeviceCodeStoreOptions func(opt *DeviceCodeStoreOption)
+type DeviceCodeStoreOption struct {
+	// Validity is the lifetime of device code and user code
+	Validity time.Duration
+	// Interval is the minimum amount of time client should wait between polling requests
+	Interval time.Duration
+	// MaxAttempts is the max number of failed user code attempts within AttemptWindow. Zero or negative value disables
+	// the limit. See UserCodeAttemptLimiter
+	MaxAttempts int
+	// AttemptWindow is how long failed user code attempts are tracked, starting from the first failure
+	AttemptWindow time.Duration
+}
+
+// RedisDeviceCodeStore store device codes and user codes in Redis
+type RedisDeviceCodeStore struct {
+	redisClient   redis.Client
+	validity      time.Duration
+	interval      time.Duration
+	maxAttempts   int
+	attemptWindow time.Duration
+}
+
+func NewRedisDeviceCodeStore(ctx context.Context, cf redis.ClientFactory, dbIndex int, opts ...DeviceCodeStoreOptions) *RedisDeviceCodeStore {
+	opt := DeviceCodeStoreOption{
+		Validity:      defaultDeviceCodeValidity,
+		Interval:      defaultDeviceCodeInterval,
+		MaxAttempts:   defaultUserCodeMaxAttempts,
+		AttemptWindow: defaultUserCodeAttemptWindow,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+
+	client, e := cf.New(ctx, func(opt *redis.ClientOption) {
+		opt.DbIndex = dbIndex
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	return &RedisDeviceCodeStore{
+		redisClient:   client,
+		validity:      opt.Validity,
+		interval:      opt.Interval,
+		maxAttempts:   opt.MaxAttempts,
+		attemptWindow: opt.AttemptWindow,
+	}
+}
+
+func (s *RedisDeviceCodeStore) GenerateDeviceCode(ctx context.Context, r oauth2.OAuth2Request) (*DeviceAuthorization, error) {
+	now := time.Now()
+	deviceCode := utils.RandomStringWithCharset(defaultDeviceCodeLength, utils.CharsetAlphanumeric)
+	userCode, e := s.reserveUserCode(ctx, deviceCode)
+	if e != nil {
+		return nil, oauth2.NewInternalError(e)
+	}
+
+	record := deviceCodeRecord{
+		UserCode: userCode,
+		Status:   DeviceCodeStatusPending,
+		ExpireAt: now.Add(s.validity),
+		Interval: s.interval,
+	}
+	if e := record.setAuthentication(oauth2.NewAuthentication(func(opt *oauth2.AuthOption) {
+		opt.Request = r
+	})); e != nil {
+		return nil, oauth2.NewInternalError(e)
+	}
+
+	if e := s.save(ctx, s.redisClient, deviceCode, &record, now); e != nil {
+		return nil, oauth2.NewInternalError(e)
+	}
+	return record.toDeviceAuthorization(deviceCode)
+}
+
+func (s *RedisDeviceCodeStore) LoadByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error) {
+	deviceCode, record, e := s.loadByUserCode(ctx, userCode)
+	if e != nil {
+		return nil, e
+	}
+	return record.toDeviceAuthorization(deviceCode)
+}
+
+func (s *RedisDeviceCodeStore) ApproveUserCode(ctx context.Context, userCode string, user security.Authentication) error {
+	if user == nil || user.State() < security.StateAuthenticated {
+		return oauth2.NewAccessRejectedError("user is not authenticated")
+	}
+
+	deviceCode, record, e := s.loadByUserCode(ctx, userCode)
+	if e != nil {
+		return e
+	}
+
+	stored, e := record.authentication()
+	if e != nil {
+		return oauth2.NewInternalError(e)
+	}
+	approved := oauth2.NewAuthentication(func(opt *oauth2.AuthOption) {
+		opt.Request = stored.OAuth2Request().NewOAuth2Request(func(details *oauth2.RequestDetails) {
+			details.Approved = true
+		})
+		opt.UserAuth = ConvertToOAuthUserAuthentication(user)
+	})
+	if e := record.setAuthentication(approved); e != nil {
+		return oauth2.NewInternalError(e)
+	}
+	record.Status = DeviceCodeStatusApproved
+	return s.finishUserInteraction(ctx, deviceCode, record)
+}
+
+func (s *RedisDeviceCodeStore) DenyUserCode(ctx context.Context, userCode string) error {
+	deviceCode, record, e := s.loadByUserCode(ctx, userCode)
+	if e != nil {
+		return e
+	}
+	record.Status = DeviceCodeStatusDenied
+	return s.finishUserInteraction(ctx, deviceCode, record)
+}
+
+// ConsumeDeviceCode implements DeviceCodeStore.
+// The device code is processed within an optimistic transaction (WATCH/MULTI/EXEC), so concurrent polling requests
+// cannot consume the same approved device code more than once, nor overwrite the end user's decision.
+func (s *RedisDeviceCodeStore) ConsumeDeviceCode(ctx context.Context, deviceCode string, clientId string) (oauth2.Authentication, error) {
+	var auth oauth2.Authentication
+	consume := func(tx *redislib.Tx) (err error) {
+		auth, err = s.consume(ctx, tx, deviceCode, clientId)
+		return
+	}
+	for i := 0; i < consumeMaxRetries; i++ {
+		switch e := s.redisClient.Watch(ctx, consume, s.deviceCodeRedisKey(deviceCode)); {
+		case errors.Is(e, redislib.TxFailedErr):
+			// device code is modified by concurrent request, try again
+			continue
+		case e != nil:
+			return nil, e
+		default:
+			return auth, nil
+		}
+	}
+	return nil, oauth2.NewInternalError(fmt.Sprintf("device code is modified concurrently after %d attempts", consumeMaxRetries))
+}
+
+func (s *RedisDeviceCodeStore) CheckUserCodeAttempts(ctx context.Context, key string) error {
+	if s.maxAttempts <= 0 {
+		return nil
+	}
+	cmd := s.redisClient.Get(ctx, s.userCodeAttemptsRedisKey(key))
+	switch {
+	case errors.Is(cmd.Err(), redislib.Nil):
+		return nil
+	case cmd.Err() != nil:
+		return oauth2.NewInternalError(cmd.Err())
+	}
+	if attempts, e := cmd.Int(); e == nil && attempts >= s.maxAttempts {
+		return oauth2.NewAccessRejectedError("too many failed attempts, please try again later")
+	}
+	return nil
+}
+
+func (s *RedisDeviceCodeStore) RecordFailedUserCodeAttempt(ctx context.Context, key string) error {
+	if s.maxAttempts <= 0 {
+		return nil
+	}
+	redisKey := s.userCodeAttemptsRedisKey(key)
+	cmd := s.redisClient.Incr(ctx, redisKey)
+	if cmd.Err() != nil {
+		return cmd.Err()
+	}
+	// the window starts from the first failure
+	if cmd.Val() == 1 {
+		return s.redisClient.Expire(ctx, redisKey, s.attemptWindow).Err()
+	}
+	return nil
+}
+
+/**********************
+	Helpers
+ **********************/
+
+type deviceCodeRecord struct {
+	UserCode   string           `json:"userCode"`
+	Status     DeviceCodeStatus `json:"status"`
+	ExpireAt   time.Time        `json:"expireAt"`
+	Interval   time.Duration    `json:"interval"`
+	LastPolled time.Time        `json:"lastPolled"`
+	Auth       json.RawMessage  `json:"auth"`
+}
+
+func (r *deviceCodeRecord) setAuthentication(oauth oauth2.Authentication) (err error) {
+	r.Auth, err = json.Marshal(oauth)
+	return
+}
+
+func (r *deviceCodeRecord) authentication() (oauth2.Authentication, error) {
+	toLoad := oauth2.NewAuthentication(func(opt *oauth2.AuthOption) {
+		opt.Request = oauth2.NewOAuth2Request()
+		opt.UserAuth = oauth2.NewUserAuthentication()
+		opt.Details = map[string]interface{}{}
+	})
+	if e := json.Unmarshal(r.Auth, &toLoad); e != nil {
+		return nil, e
+	}
+	return toLoad, nil
+}
+
+func (r *deviceCodeRecord) toDeviceAuthorization(deviceCode string) (*DeviceAuthorization, error) {
+	oauth, e := r.authentication()
+	if e != nil {
+		return nil, oauth2.NewInternalError(e)
+	}
+	return &DeviceAuthorization{
+		DeviceCode: deviceCode,
+		UserCode:   r.UserCode,
+		Status:     r.Status,
+		Request:    oauth.OAuth2Request(),
+		ExpireAt:   r.ExpireAt,
+		Interval:   r.Interval,
+	}, nil
+}
+
+// consume checks the device code record loaded within the watching transaction, and updates or removes it accordingly.
+// All changes are executed as MULTI/EXEC, which fails with redis.TxFailedErr if the record is modified concurrently.
+func (s *RedisDeviceCodeStore) consume(ctx context.Context, tx *redislib.Tx, deviceCode string, clientId string) (oauth2.Authentication, error) {
+	record, e := s.load(ctx, tx, deviceCode)
+	if e != nil {
+		return nil, oauth2.NewInvalidGrantError("device code is not valid", e)
+	}
+
+	toLoad, e := record.authentication()
+	if e != nil {
+		return nil, oauth2.NewInvalidGrantError("device code is not valid", e)
+	}
+
+	if toLoad.OAuth2Request().ClientId() != clientId {
+		return nil, oauth2.NewInvalidGrantError("client ID mismatch")
+	}
+
+	now := time.Now()
+	if now.After(record.ExpireAt) {
+		if e := s.remove(ctx, tx, deviceCode, record.UserCode); e != nil {
+			return nil, e
+		}
+		return nil, oauth2.NewExpiredTokenError("device code is expired")
+	}
+
+	switch record.Status {
+	case DeviceCodeStatusApproved:
+		if e := s.remove(ctx, tx, deviceCode, record.UserCode); e != nil {
+			return nil, e
+		}
+		return toLoad, nil
+	case DeviceCodeStatusDenied:
+		if e := s.remove(ctx, tx, deviceCode, record.UserCode); e != nil {
+			return nil, e
+		}
+		return nil, oauth2.NewDeviceAccessDeniedError("user denied the authorization request")
+	}
+
+	// still pending, check polling interval
+	tooFast := !record.LastPolled.IsZero() && now.Sub(record.LastPolled) < record.Interval
+	if tooFast {
+		record.Interval = record.Interval + slowDownIncrement
+	}
+	record.LastPolled = now
+	_, e = tx.TxPipelined(ctx, func(pipe redislib.Pipeliner) error {
+		return s.save(ctx, pipe, deviceCode, record, now)
+	})
+	switch {
+	case errors.Is(e, redislib.TxFailedErr):
+		return nil, e
+	case e != nil:
+		return nil, oauth2.NewInternalError(e)
+	}
+
+	if tooFast {
+		return nil, oauth2.NewSlowDownError(fmt.Sprintf("polling too frequently, interval is increased to %d seconds", int(record.Interval.Seconds())))
+	}
+	return nil, oauth2.NewAuthorizationPendingError("authorization is pending")
+}
+
+// reserveUserCode generate an unused user code and associate it with given device code
+func (s *RedisDeviceCodeStore) reserveUserCode(ctx context.Context, deviceCode string) (string, error) {
+	for i := 0; i < userCodeMaxRetries; i++ {
+		userCode := utils.RandomStringWithCharset(defaultUserCodeLength, userCodeCharset)
+		cmd := s.redisClient.SetNX(ctx, s.userCodeRedisKey(userCode), deviceCode, s.validity)
+		if cmd.Err() != nil {
+			return "", cmd.Err()
+		} else if cmd.Val() {
+			return userCode, nil
+		}
+	}
+	return "", fmt.Errorf("unable to generate unique user code after %d attempts", userCodeMaxRetries)
+}
+
+func (s *RedisDeviceCodeStore) loadByUserCode(ctx context.Context, userCode string) (string, *deviceCodeRecord, error) {
+	userCode = NormalizeUserCode(userCode)
+	cmd := s.redisClient.Get(ctx, s.userCodeRedisKey(userCode))
+	if cmd.Err() != nil {
+		return "", nil, oauth2.NewInvalidGrantError(fmt.Sprintf("user code [%s] is not valid", FormatUserCode(userCode)))
+	}
+
+	deviceCode := cmd.Val()
+	record, e := s.load(ctx, s.redisClient, deviceCode)
+	switch {
+	case e != nil:
+		return "", nil, oauth2.NewInvalidGrantError(fmt.Sprintf("user code [%s] is not valid", FormatUserCode(userCode)), e)
+	case record.Status != DeviceCodeStatusPending:
+		return "", nil, oauth2.NewInvalidGrantError(fmt.Sprintf("user code [%s] is already used", FormatUserCode(userCode)))
+	case time.Now().After(record.ExpireAt):
+		return "", nil, oauth2.NewExpiredTokenError(fmt.Sprintf("user code [%s] is expired", FormatUserCode(userCode)))
+	}
+	return deviceCode, record, nil
+}
+
+// finishUserInteraction saves the record and invalidate the user code, so it cannot be used again
+func (s *RedisDeviceCodeStore) finishUserInteraction(ctx context.Context, deviceCode string, record *deviceCodeRecord) error {
+	if e := s.save(ctx, s.redisClient, deviceCode, record, time.Now()); e != nil {
+		return oauth2.NewInternalError(e)
+	}
+	if cmd := s.redisClient.Del(ctx, s.userCodeRedisKey(record.UserCode)); cmd.Err() != nil {
+		logger.WithContext(ctx).Warnf("user code was not removed: %v", cmd.Err())
+	}
+	return nil
+}
+
+func (s *RedisDeviceCodeStore) load(ctx context.Context, c redislib.Cmdable, deviceCode string) (*deviceCodeRecord, error) {
+	cmd := c.Get(ctx, s.deviceCodeRedisKey(deviceCode))
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+	var record deviceCodeRecord
+	if e := json.Unmarshal([]byte(cmd.Val()), &record); e != nil {
+		return nil, e
+	}
+	return &record, nil
+}
+
+func (s *RedisDeviceCodeStore) save(ctx context.Context, c redislib.Cmdable, deviceCode string, record *deviceCodeRecord, now time.Time) error {
+	toSave, e := json.Marshal(record)
+	if e != nil {
+		return e
+	}
+	ttl := record.ExpireAt.Add(deviceCodeGracePeriod).Sub(now)
+	cmd := c.Set(ctx, s.deviceCodeRedisKey(deviceCode), toSave, ttl)
+	return cmd.Err()
+}
+
+// remove deletes the device code and its user code within the watching transaction
+func (s *RedisDeviceCodeStore) remove(ctx context.Context, tx *redislib.Tx, deviceCode, userCode string) error {
+	_, e := tx.TxPipelined(ctx, func(pipe redislib.Pipeliner) error {
+		pipe.Del(ctx, s.deviceCodeRedisKey(deviceCode), s.userCodeRedisKey(userCode))
+		return nil
+	})
+	if e != nil && !errors.Is(e, redislib.TxFailedErr) {
+		return oauth2.NewInternalError(e)
+	}
+	return e
+}
+
+func (s *RedisDeviceCodeStore) deviceCodeRedisKey(code string) string {
+	return fmt.Sprintf("%s:%s", deviceCodePrefix, code)
+}
+
+func (s *RedisDeviceCodeStore) userCodeRedisKey(code string) string {
+	return fmt.Sprintf("%s:%s", userCodePrefix, code)
+}
+
+func (s *RedisDeviceCodeStore) userCodeAttemptsRedisKey(key string) string {
+	return fmt.Sprintf("%s:%s", userCodeAttemptsPrefix, key)
+}
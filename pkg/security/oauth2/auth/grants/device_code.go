@@ -0,0 +1,99 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package grants
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+)
+
+var (
+	deviceCodeIgnoreParams = utils.NewStringSet(
+		oauth2.ParameterScope,
+		oauth2.ParameterClientSecret,
+		oauth2.ParameterDeviceCode,
+	)
+)
+
+// DeviceCodeGranter implements auth.TokenGranter
+// See https://datatracker.ietf.org/doc/html/rfc8628#section-3.4
+type DeviceCodeGranter struct {
+	authService     auth.AuthorizationService
+	deviceCodeStore auth.DeviceCodeStore
+}
+
+func NewDeviceCodeGranter(authService auth.AuthorizationService, deviceCodeStore auth.DeviceCodeStore) *DeviceCodeGranter {
+	if authService == nil {
+		panic(fmt.Errorf("cannot create DeviceCodeGranter without auth service"))
+	}
+
+	if deviceCodeStore == nil {
+		panic(fmt.Errorf("cannot create DeviceCodeGranter without device code store"))
+	}
+
+	return &DeviceCodeGranter{
+		authService:     authService,
+		deviceCodeStore: deviceCodeStore,
+	}
+}
+
+func (g *DeviceCodeGranter) Grant(ctx context.Context, request *auth.TokenRequest) (oauth2.AccessToken, error) {
+	if oauth2.GrantTypeDeviceCode != request.GrantType {
+		return nil, nil
+	}
+
+	client := auth.RetrieveAuthenticatedClient(ctx)
+
+	// common check
+	if e := auth.ValidateGrant(ctx, client, request.GrantType); e != nil {
+		return nil, e
+	}
+
+	// load authentication using device code
+	code, ok := request.Extensions[oauth2.ParameterDeviceCode].(string)
+	if !ok || code == "" {
+		return nil, oauth2.NewInvalidTokenRequestError(fmt.Sprintf("missing required parameter %s", oauth2.ParameterDeviceCode))
+	}
+
+	stored, e := g.deviceCodeStore.ConsumeDeviceCode(ctx, code, client.ClientId())
+	if e != nil {
+		return nil, e
+	} else if !stored.OAuth2Request().Approved() || stored.UserAuthentication() == nil {
+		return nil, oauth2.NewInvalidGrantError("original device authorization request is invalid")
+	}
+
+	// create authentication from stored value
+	oauthRequest, e := mergedOAuth2Request(stored.OAuth2Request(), request, deviceCodeIgnoreParams)
+	if e != nil {
+		return nil, e
+	}
+
+	oauth, e := g.authService.CreateAuthentication(ctx, oauthRequest, stored.UserAuthentication())
+	if e != nil {
+		return nil, oauth2.NewInvalidGrantError(e)
+	}
+
+	// create token
+	token, e := g.authService.CreateAccessToken(ctx, oauth)
+	if e != nil {
+		return nil, oauth2.NewInvalidGrantError(e)
+	}
+	return token, nil
+}
@@ -64,6 +64,10 @@ func (g *PermissionBasedGranter) authenticateToken(ctx context.Context, request
 		return nil, oauth2.NewInvalidGrantError("access token is not associated with a valid user")
 	}
 
+	// DPoP-bound token requires proof of the same key
+	if e := validateDPoPBinding(oauth.OAuth2Request(), request, oauth2.ParameterAccessToken); e != nil {
+		return nil, e
+	}
 	return oauth, nil
 }
 
@@ -28,6 +28,8 @@ var (
 	refreshIgnoreParams = utils.NewStringSet(
 		oauth2.ParameterClientSecret,
 		oauth2.ParameterRefreshToken,
+		// DPoP binding of the original grant cannot be changed by refresh request
+		oauth2.ExtDPoPJkt,
 	)
 )
 
@@ -90,6 +92,11 @@ func (g *RefreshGranter) Grant(ctx context.Context, request *auth.TokenRequest)
 		return nil, oauth2.NewInvalidGrantError("client ID mismatch")
 	}
 
+	// DPoP-bound grant requires proof of the same key
+	if e := validateDPoPBinding(stored.OAuth2Request(), request, oauth2.ParameterRefreshToken); e != nil {
+		return nil, e
+	}
+
 	// reduced scope
 	oauthRequest, e := reduceScope(ctx, client, stored.OAuth2Request(), request)
 	if e != nil {
@@ -111,6 +118,23 @@ func (g *RefreshGranter) Grant(ctx context.Context, request *auth.TokenRequest)
 	return token, nil
 }
 
+// validateDPoPBinding make sure token issued with DPoP binding is presented with a proof of the same key.
+// "param" is the name of the token used in error messages.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-5
+func validateDPoPBinding(src oauth2.OAuth2Request, request *auth.TokenRequest, param string) error {
+	jkt, _ := src.Extensions()[oauth2.ExtDPoPJkt].(string)
+	if len(jkt) == 0 {
+		return nil
+	}
+	switch proofJkt := request.DPoPJkt; {
+	case len(proofJkt) == 0:
+		return oauth2.NewInvalidGrantError(fmt.Sprintf("DPoP proof is required to use DPoP-bound %s", param))
+	case proofJkt != jkt:
+		return oauth2.NewInvalidGrantError(fmt.Sprintf("DPoP proof key doesn't match the %s's binding", param))
+	}
+	return nil
+}
+
 func reduceScope(c context.Context, client oauth2.OAuth2Client, src oauth2.OAuth2Request, request *auth.TokenRequest) (oauth2.OAuth2Request, error) {
 	if !src.Approved() {
 		return nil, oauth2.NewInvalidGrantError("original OAuth2 request was not approved")
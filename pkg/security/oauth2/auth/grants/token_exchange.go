@@ -0,0 +1,362 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package grants
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/tokenauth"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"strings"
+)
+
+var (
+	tokenExchangeIgnoreParams = utils.NewStringSet(
+		oauth2.ParameterClientSecret,
+		oauth2.ParameterAccessToken,
+		oauth2.ParameterSubjectToken,
+		oauth2.ParameterActorToken,
+		oauth2.ParameterTenantId,
+		oauth2.ParameterTenantExternalId,
+		oauth2.ExtTokenExchangeActor,
+		oauth2.ExtTokenExchangeAudience,
+	)
+	tokenExchangeSupportedTypes = utils.NewStringSet(
+		oauth2.TokenTypeIdAccessToken,
+		oauth2.TokenTypeIdJwt,
+	)
+)
+
+// TokenExchangeGranter implements auth.TokenGranter
+// It implements OAuth 2.0 Token Exchange (RFC 8693) for service-to-service delegation:
+// the authenticated client presents a user's access token as "subject_token" (and optionally its own token as "actor_token"),
+// and receives a new access token of the same user, with narrowed scopes and audience.
+// The acting party is recorded in "act" claim of the issued token. See claims.Actor
+// https://datatracker.ietf.org/doc/html/rfc8693
+type TokenExchangeGranter struct {
+	authenticator security.Authenticator
+	authService   auth.AuthorizationService
+}
+
+func NewTokenExchangeGranter(authService auth.AuthorizationService, authenticator security.Authenticator) *TokenExchangeGranter {
+	if authenticator == nil {
+		panic(fmt.Errorf("cannot create TokenExchangeGranter without authenticator."))
+	}
+
+	if authService == nil {
+		panic(fmt.Errorf("cannot create TokenExchangeGranter without authorization service."))
+	}
+
+	return &TokenExchangeGranter{
+		authenticator: authenticator,
+		authService:   authService,
+	}
+}
+
+func (g *TokenExchangeGranter) Grant(ctx context.Context, request *auth.TokenRequest) (oauth2.AccessToken, error) {
+	if oauth2.GrantTypeTokenExchange != request.GrantType {
+		return nil, nil
+	}
+
+	client := auth.RetrieveAuthenticatedClient(ctx)
+
+	// common check
+	if e := auth.ValidateGrant(ctx, client, request.GrantType); e != nil {
+		return nil, e
+	}
+
+	// additional request params check
+	if e := g.validateRequest(ctx, request); e != nil {
+		return nil, e
+	}
+
+	// authenticate subject token, it has to be associated with a user
+	subjectToken, _ := request.Extensions[oauth2.ParameterSubjectToken].(string)
+	subject, e := g.authenticateToken(ctx, request, subjectToken, oauth2.ParameterSubjectToken)
+	if e != nil {
+		return nil, e
+	}
+	if subject.UserAuthentication() == nil || subject.UserAuthentication().State() < security.StateAuthenticated {
+		return nil, oauth2.NewInvalidGrantError("subject_token is not associated with a valid user")
+	}
+
+	// determine actor and chain it with prior actors of the subject token
+	actor, e := g.resolveActor(ctx, client, request)
+	if e != nil {
+		return nil, e
+	}
+	if prior, ok := subject.OAuth2Request().Extensions()[oauth2.ExtTokenExchangeActor].(map[string]interface{}); ok && len(prior) != 0 {
+		actor[oauth2.ClaimActor] = prior
+	}
+
+	// narrow down scopes and audience
+	scopes, e := g.reduceScope(ctx, client, subject.OAuth2Request(), request)
+	if e != nil {
+		return nil, e
+	}
+
+	audience, e := g.reduceAudience(ctx, client, request)
+	if e != nil {
+		return nil, e
+	}
+
+	// create new request
+	req := g.exchangedRequest(ctx, client, subject, request, func(opt *oauth2.RequestDetails) {
+		opt.Scopes = scopes
+		opt.Extensions[oauth2.ExtTokenExchangeActor] = actor
+		if len(audience) != 0 {
+			opt.Extensions[oauth2.ExtTokenExchangeAudience] = strings.Join(audience.Values(), " ")
+		}
+	})
+
+	// create authentication
+	oauth, e := g.authService.SwitchAuthentication(ctx, req, subject.UserAuthentication(), exchangeSource(subject))
+	if e != nil {
+		return nil, oauth2.NewInvalidGrantError(e)
+	}
+
+	// create token
+	token, e := g.authService.CreateAccessToken(ctx, oauth)
+	if e != nil {
+		return nil, oauth2.NewInvalidGrantError(e)
+	}
+	return token, nil
+}
+
+func (g *TokenExchangeGranter) validateRequest(_ context.Context, request *auth.TokenRequest) error {
+	subjectToken, _ := request.Extensions[oauth2.ParameterSubjectToken].(string)
+	if strings.TrimSpace(subjectToken) == "" {
+		return oauth2.NewInvalidTokenRequestError(fmt.Sprintf("[%s] is required", oauth2.ParameterSubjectToken))
+	}
+
+	subjectType, _ := request.Extensions[oauth2.ParameterSubjectTokenType].(string)
+	if e := g.validateTokenType(oauth2.ParameterSubjectTokenType, subjectType, true); e != nil {
+		return e
+	}
+
+	actorToken, _ := request.Extensions[oauth2.ParameterActorToken].(string)
+	actorType, _ := request.Extensions[oauth2.ParameterActorTokenType].(string)
+	switch {
+	case strings.TrimSpace(actorToken) != "":
+		if e := g.validateTokenType(oauth2.ParameterActorTokenType, actorType, true); e != nil {
+			return e
+		}
+	case strings.TrimSpace(actorType) != "":
+		return oauth2.NewInvalidTokenRequestError(fmt.Sprintf("[%s] is not allowed without [%s]", oauth2.ParameterActorTokenType, oauth2.ParameterActorToken))
+	}
+
+	requestedType, _ := request.Extensions[oauth2.ParameterRequestedTokenType].(string)
+	return g.validateTokenType(oauth2.ParameterRequestedTokenType, requestedType, false)
+}
+
+func (g *TokenExchangeGranter) validateTokenType(param, value string, required bool) error {
+	switch value = strings.TrimSpace(value); {
+	case value == "" && required:
+		return oauth2.NewInvalidTokenRequestError(fmt.Sprintf("[%s] is required", param))
+	case value != "" && !tokenExchangeSupportedTypes.Has(value):
+		return oauth2.NewInvalidTokenRequestError(fmt.Sprintf("unsupported %s [%s]", param, value))
+	}
+	return nil
+}
+
+func (g *TokenExchangeGranter) authenticateToken(ctx context.Context, request *auth.TokenRequest, tokenValue string, param string) (oauth2.Authentication, error) {
+	candidate := tokenauth.BearerToken{
+		Token:      tokenValue,
+		DetailsMap: map[string]interface{}{},
+	}
+
+	auth, e := g.authenticator.Authenticate(ctx, &candidate)
+	if e != nil {
+		return nil, oauth2.NewInvalidGrantError(fmt.Sprintf("invalid %s", param), e)
+	}
+	oauth, ok := auth.(oauth2.Authentication)
+	if !ok || oauth.State() < security.StateAuthenticated {
+		return nil, oauth2.NewInvalidGrantError(fmt.Sprintf("invalid %s", param))
+	}
+
+	// DPoP-bound token requires proof of the same key
+	if e := validateDPoPBinding(oauth.OAuth2Request(), request, param); e != nil {
+		return nil, e
+	}
+	return oauth, nil
+}
+
+// resolveActor returns the actor descriptor used for "act" claim.
+// When "actor_token" is present, the actor is the party represented by the token. Otherwise, the requesting client is the actor.
+func (g *TokenExchangeGranter) resolveActor(ctx context.Context, client oauth2.OAuth2Client, request *auth.TokenRequest) (map[string]interface{}, error) {
+	actorToken, _ := request.Extensions[oauth2.ParameterActorToken].(string)
+	if strings.TrimSpace(actorToken) == "" {
+		return map[string]interface{}{
+			oauth2.ClaimClientId: client.ClientId(),
+		}, nil
+	}
+
+	actor, e := g.authenticateToken(ctx, request, actorToken, oauth2.ParameterActorToken)
+	if e != nil {
+		return nil, e
+	}
+
+	act := map[string]interface{}{
+		oauth2.ClaimClientId: actor.OAuth2Request().ClientId(),
+	}
+	if actor.UserAuthentication() != nil {
+		if username, e := security.GetUsername(actor.UserAuthentication()); e == nil && username != "" {
+			act[oauth2.ClaimSubject] = username
+		}
+	}
+	return act, nil
+}
+
+// reduceScope calculate scopes of the exchanged token.
+// Requested scopes must be granted to the subject token and allowed by the requesting client.
+// When no scope is requested, the result is all subject token's scopes that are allowed by the requesting client.
+func (g *TokenExchangeGranter) reduceScope(ctx context.Context, client oauth2.OAuth2Client, src oauth2.OAuth2Request, request *auth.TokenRequest) (utils.StringSet, error) {
+	original := src.Scopes()
+	if len(request.Scopes) == 0 {
+		scopes := utils.NewStringSet()
+		for scope := range original {
+			if client.Scopes().Has(scope) {
+				scopes.Add(scope)
+			}
+		}
+		if len(scopes) == 0 {
+			return nil, oauth2.NewInvalidScopeError("subject_token has no scope allowed by this client")
+		}
+		return scopes, nil
+	}
+
+	for scope := range request.Scopes {
+		if !original.Has(scope) {
+			return nil, oauth2.NewInvalidScopeError(fmt.Sprintf("scope [%s] is not granted to subject_token", scope))
+		}
+	}
+	if e := auth.ValidateAllScopes(ctx, client, request.Scopes); e != nil {
+		return nil, e
+	}
+	return request.Scopes.Copy(), nil
+}
+
+// reduceAudience validate requested "audience" and "resource".
+// Requested values must be within the audience the requesting client would normally receive, i.e. its resource IDs
+func (g *TokenExchangeGranter) reduceAudience(_ context.Context, client oauth2.OAuth2Client, request *auth.TokenRequest) (utils.StringSet, error) {
+	requested := utils.NewStringSet()
+	for _, param := range []string{oauth2.ParameterAudience, oauth2.ParameterResource} {
+		if v, ok := request.Extensions[param].(string); ok {
+			requested.Add(strings.Fields(v)...)
+		}
+	}
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	allowed := client.ResourceIDs()
+	if len(allowed) == 0 {
+		allowed = utils.NewStringSet(oauth2.LegacyResourceId)
+	}
+	for aud := range requested {
+		if !allowed.Has(aud) {
+			return nil, oauth2.NewInvalidTargetError(fmt.Sprintf("audience [%s] is not allowed by this client", aud))
+		}
+	}
+	return requested, nil
+}
+
+// exchangedRequest creates OAuth2 request of the exchanged token. Unlike switch user/tenant, the request is not derived
+// from subject token's original request: only the user, tenant and the delegation chain are carried over.
+func (g *TokenExchangeGranter) exchangedRequest(_ context.Context, client oauth2.OAuth2Client, subject oauth2.Authentication,
+	request *auth.TokenRequest, opts ...oauth2.RequestOptionsFunc) oauth2.OAuth2Request {
+	return oauth2.NewOAuth2Request(func(opt *oauth2.RequestDetails) {
+		opt.ClientId = client.ClientId()
+		opt.Approved = true
+		opt.GrantType = request.GrantType
+		for k, v := range request.Parameters {
+			if tokenExchangeIgnoreParams.Has(k) {
+				continue
+			}
+			opt.Parameters[k] = v
+		}
+		for k, v := range request.Extensions {
+			if tokenExchangeIgnoreParams.Has(k) {
+				continue
+			}
+			opt.Extensions[k] = v
+		}
+		// exchanged token stays in subject token's tenant
+		if td, ok := subject.Details().(security.TenantDetails); ok && td.TenantId() != "" {
+			opt.Parameters[oauth2.ParameterTenantId] = td.TenantId()
+		}
+		for _, fn := range opts {
+			fn(opt)
+		}
+	})
+}
+
+// exchangeSource presents the subject token's authentication as source of the exchanged authentication.
+// Subject's key-value details are carried over, except its request extensions: the exchanged token has its own request.
+func exchangeSource(subject oauth2.Authentication) oauth2.Authentication {
+	details := exchangeSourceDetails{kv: map[string]interface{}{}}
+	if kv, ok := subject.Details().(security.KeyValueDetails); ok {
+		for k, v := range kv.Values() {
+			if k == oauth2.DetailsKeyRequestExt {
+				continue
+			}
+			details.kv[k] = v
+		}
+	}
+	if proxy, ok := subject.Details().(security.ProxiedUserDetails); ok && proxy.Proxied() {
+		details.proxied = true
+		details.originalUsername = proxy.OriginalUsername()
+	}
+	return oauth2.NewAuthentication(func(opt *oauth2.AuthOption) {
+		opt.Request = subject.OAuth2Request()
+		opt.UserAuth = subject.UserAuthentication()
+		opt.Token = subject.AccessToken()
+		opt.Details = details
+	})
+}
+
+// exchangeSourceDetails implements security.KeyValueDetails and security.ProxiedUserDetails.
+// The exchanged token is issued to the subject token's user, so proxy information is the only user details carried over.
+type exchangeSourceDetails struct {
+	proxied          bool
+	originalUsername string
+	kv               map[string]interface{}
+}
+
+func (d exchangeSourceDetails) OriginalUsername() string {
+	return d.originalUsername
+}
+
+func (d exchangeSourceDetails) Proxied() bool {
+	return d.proxied
+}
+
+func (d exchangeSourceDetails) Value(key string) (v interface{}, ok bool) {
+	v, ok = d.kv[key]
+	return
+}
+
+func (d exchangeSourceDetails) Values() map[string]interface{} {
+	ret := make(map[string]interface{}, len(d.kv))
+	for k, v := range d.kv {
+		ret[k] = v
+	}
+	return ret
+}
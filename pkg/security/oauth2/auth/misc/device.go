@@ -0,0 +1,274 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package misc
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	netutil "github.com/cisco-open/go-lanai/pkg/utils/net"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DeviceModelKeyUserCodeParam   = "userCodeParam"
+	DeviceModelKeyApprovalParam   = "approvalParam"
+	DeviceModelKeyVerificationUrl = "verificationUrl"
+	DeviceModelKeyUserCode        = "UserCode"
+	DeviceModelKeyDeviceRequest   = "DeviceRequest"
+	DeviceModelKeyDeviceResult    = "DeviceResult"
+)
+
+const (
+	deviceResultApproved = "approved"
+	deviceResultDenied   = "denied"
+)
+
+/*****************************
+	Device Authorization
+ *****************************/
+
+type DeviceAuthorizationRequest struct {
+	Scope string `form:"scope"`
+}
+
+// DeviceAuthorizationResponse https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationUri         string `json:"verification_uri"`
+	VerificationUriComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// DeviceAuthorizationEndpoint is the device authorization endpoint as defined in https://datatracker.ietf.org/doc/html/rfc8628#section-3.1
+// This endpoint requires client authentication. Public clients are allowed.
+type DeviceAuthorizationEndpoint struct {
+	issuer           security.Issuer
+	deviceCodeStore  auth.DeviceCodeStore
+	verificationPath string
+}
+
+func NewDeviceAuthorizationEndpoint(issuer security.Issuer, deviceCodeStore auth.DeviceCodeStore, verificationPath string) *DeviceAuthorizationEndpoint {
+	return &DeviceAuthorizationEndpoint{
+		issuer:           issuer,
+		deviceCodeStore:  deviceCodeStore,
+		verificationPath: verificationPath,
+	}
+}
+
+func (ep *DeviceAuthorizationEndpoint) DeviceAuthorization(c context.Context, request *DeviceAuthorizationRequest) (*DeviceAuthorizationResponse, error) {
+	client := auth.RetrieveAuthenticatedClient(c)
+	if client == nil {
+		return nil, oauth2.NewInvalidClientError("device authorization endpoint requires client authentication")
+	}
+
+	if e := auth.ValidateGrant(c, client, oauth2.GrantTypeDeviceCode); e != nil {
+		return nil, e
+	}
+
+	scopes := utils.NewStringSet(strings.Fields(request.Scope)...)
+	if len(scopes) == 0 {
+		scopes = client.Scopes().Copy()
+	}
+	if e := auth.ValidateAllScopes(c, client, scopes); e != nil {
+		return nil, e
+	}
+
+	req := oauth2.NewOAuth2Request(func(details *oauth2.RequestDetails) {
+		details.ClientId = client.ClientId()
+		details.Scopes = scopes
+		details.GrantType = oauth2.GrantTypeDeviceCode
+		details.Parameters[oauth2.ParameterClientId] = client.ClientId()
+		if request.Scope != "" {
+			details.Parameters[oauth2.ParameterScope] = request.Scope
+		}
+	})
+
+	da, e := ep.deviceCodeStore.GenerateDeviceCode(c, req)
+	if e != nil {
+		return nil, e
+	}
+
+	verificationUrl, e := ep.verificationUrl(c)
+	if e != nil {
+		return nil, oauth2.NewInternalError(e)
+	}
+	userCode := auth.FormatUserCode(da.UserCode)
+	completeUrl := *verificationUrl
+	completeUrl.RawQuery = url.Values{oauth2.ParameterUserCode: []string{userCode}}.Encode()
+
+	return &DeviceAuthorizationResponse{
+		DeviceCode:              da.DeviceCode,
+		UserCode:                userCode,
+		VerificationUri:         verificationUrl.String(),
+		VerificationUriComplete: completeUrl.String(),
+		ExpiresIn:               int(time.Until(da.ExpireAt).Round(time.Second).Seconds()),
+		Interval:                int(da.Interval.Seconds()),
+	}, nil
+}
+
+// verificationUrl prefer the domain of current request, because the verification page is protected by the IDP
+// associated with that domain
+func (ep *DeviceAuthorizationEndpoint) verificationUrl(c context.Context) (*url.URL, error) {
+	if req := web.HttpRequest(c); req != nil {
+		verificationUrl, e := ep.issuer.BuildUrl(func(opt *security.UrlBuilderOption) {
+			opt.FQDN = netutil.GetForwardedHostName(req)
+			opt.Path = ep.verificationPath
+		})
+		if e == nil {
+			return verificationUrl, nil
+		}
+	}
+	return ep.issuer.BuildUrl(func(opt *security.UrlBuilderOption) {
+		opt.Path = ep.verificationPath
+	})
+}
+
+/*****************************
+	Device Verification
+ *****************************/
+
+type DeviceVerificationRequest struct {
+	UserCode string `form:"user_code"`
+	Approval string `form:"user_oauth_approval"`
+}
+
+// DeviceVerificationEndpoint renders the user-facing page where end user enter the user code displayed on device
+// and approve or deny the device's authorization request.
+// See https://datatracker.ietf.org/doc/html/rfc8628#section-3.3
+// This endpoint requires user authentication (e.g. form login) and CSRF protection.
+// If the DeviceCodeStore supports auth.UserCodeAttemptLimiter, failed user code attempts are limited per user.
+type DeviceVerificationEndpoint struct {
+	deviceCodeStore  auth.DeviceCodeStore
+	verificationPath string
+	template         string
+}
+
+func NewDeviceVerificationEndpoint(deviceCodeStore auth.DeviceCodeStore, verificationPath string, template string) *DeviceVerificationEndpoint {
+	return &DeviceVerificationEndpoint{
+		deviceCodeStore:  deviceCodeStore,
+		verificationPath: verificationPath,
+		template:         template,
+	}
+}
+
+// VerificationForm should be mapped to GET. It renders user code entry form, or the confirmation form if
+// user code is provided.
+func (ep *DeviceVerificationEndpoint) VerificationForm(ctx context.Context, r *DeviceVerificationRequest) (*template.ModelView, error) {
+	model := ep.model()
+	if r.UserCode == "" {
+		return ep.modelView(model), nil
+	}
+
+	model[DeviceModelKeyUserCode] = auth.FormatUserCode(auth.NormalizeUserCode(r.UserCode))
+	if e := ep.checkAttempts(ctx); e != nil {
+		model[template.ModelKeyError] = e
+		return ep.modelView(model), nil
+	}
+	da, e := ep.deviceCodeStore.LoadByUserCode(ctx, r.UserCode)
+	if e != nil {
+		ep.recordFailedAttempt(ctx)
+		model[template.ModelKeyError] = e
+	} else {
+		model[DeviceModelKeyDeviceRequest] = da
+	}
+	return ep.modelView(model), nil
+}
+
+// Verify should be mapped to POST. It approves or denies the device authorization request associated with the
+// given user code. If approval is not provided, it behaves like VerificationForm
+func (ep *DeviceVerificationEndpoint) Verify(ctx context.Context, r *DeviceVerificationRequest) (*template.ModelView, error) {
+	if r.Approval == "" {
+		return ep.VerificationForm(ctx, r)
+	}
+
+	model := ep.model()
+	model[DeviceModelKeyUserCode] = auth.FormatUserCode(auth.NormalizeUserCode(r.UserCode))
+	approved, e := strconv.ParseBool(r.Approval)
+	if e != nil {
+		model[template.ModelKeyError] = oauth2.NewInvalidAuthorizeRequestError(fmt.Sprintf("invalid %s", oauth2.ParameterUserApproval))
+		return ep.modelView(model), nil
+	}
+
+	if e := ep.checkAttempts(ctx); e != nil {
+		model[template.ModelKeyError] = e
+		return ep.modelView(model), nil
+	}
+	if approved {
+		e = ep.deviceCodeStore.ApproveUserCode(ctx, r.UserCode, security.Get(ctx))
+		model[DeviceModelKeyDeviceResult] = deviceResultApproved
+	} else {
+		e = ep.deviceCodeStore.DenyUserCode(ctx, r.UserCode)
+		model[DeviceModelKeyDeviceResult] = deviceResultDenied
+	}
+
+	if e != nil {
+		ep.recordFailedAttempt(ctx)
+		delete(model, DeviceModelKeyDeviceResult)
+		model[template.ModelKeyError] = e
+	}
+	return ep.modelView(model), nil
+}
+
+// checkAttempts returns error if current user reached max failed user code attempts
+func (ep *DeviceVerificationEndpoint) checkAttempts(ctx context.Context) error {
+	limiter, ok := ep.deviceCodeStore.(auth.UserCodeAttemptLimiter)
+	if !ok {
+		return nil
+	}
+	username, e := security.GetUsername(security.Get(ctx))
+	if e != nil {
+		return oauth2.NewAccessRejectedError("user is not authenticated")
+	}
+	return limiter.CheckUserCodeAttempts(ctx, username)
+}
+
+// recordFailedAttempt is best-effort, failure of recording doesn't affect the result of current attempt
+func (ep *DeviceVerificationEndpoint) recordFailedAttempt(ctx context.Context) {
+	limiter, ok := ep.deviceCodeStore.(auth.UserCodeAttemptLimiter)
+	if !ok {
+		return
+	}
+	if username, e := security.GetUsername(security.Get(ctx)); e == nil {
+		_ = limiter.RecordFailedUserCodeAttempt(ctx, username)
+	}
+}
+
+func (ep *DeviceVerificationEndpoint) model() template.Model {
+	return template.Model{
+		DeviceModelKeyUserCodeParam:   oauth2.ParameterUserCode,
+		DeviceModelKeyApprovalParam:   oauth2.ParameterUserApproval,
+		DeviceModelKeyVerificationUrl: ep.verificationPath,
+	}
+}
+
+func (ep *DeviceVerificationEndpoint) modelView(model template.Model) *template.ModelView {
+	return &template.ModelView{
+		View:  ep.template,
+		Model: model,
+	}
+}
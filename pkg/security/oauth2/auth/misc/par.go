@@ -0,0 +1,129 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package misc
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// parExcludedParams are client authentication parameters that should not be stored as part of authorize request
+	parExcludedParams = utils.NewStringSet(
+		oauth2.ParameterClientSecret, oauth2.ParameterClientAssertion, oauth2.ParameterClientAssertionType,
+	)
+)
+
+type PushedAuthorizationRequest struct {
+	ClientId   string `form:"client_id"`
+	RequestUri string `form:"request_uri"`
+}
+
+// PushedAuthorizationResponse https://datatracker.ietf.org/doc/html/rfc9126#section-2.2
+type PushedAuthorizationResponse struct {
+	RequestUri string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// StatusCode implements web.StatusCoder
+func (r PushedAuthorizationResponse) StatusCode() int {
+	return http.StatusCreated
+}
+
+// PushedAuthorizationEndpoint is the pushed authorization request endpoint as defined in https://datatracker.ietf.org/doc/html/rfc9126#section-2
+// Clients push the authorize request directly to this endpoint, and use returned "request_uri" at authorize endpoint.
+// This endpoint requires client authentication. The pushed request is validated the same way as authorize endpoint.
+type PushedAuthorizationEndpoint struct {
+	requestProcessor auth.AuthorizeRequestProcessor
+	parStore         auth.PushedAuthorizationRequestStore
+}
+
+func NewPushedAuthorizationEndpoint(processor auth.AuthorizeRequestProcessor, parStore auth.PushedAuthorizationRequestStore) *PushedAuthorizationEndpoint {
+	return &PushedAuthorizationEndpoint{
+		requestProcessor: processor,
+		parStore:         parStore,
+	}
+}
+
+func (ep *PushedAuthorizationEndpoint) PushedAuthorization(c context.Context, request *PushedAuthorizationRequest) (*PushedAuthorizationResponse, error) {
+	client := auth.RetrieveAuthenticatedClient(c)
+	if client == nil {
+		return nil, oauth2.NewInvalidClientError("pushed authorization request endpoint requires client authentication")
+	}
+
+	switch {
+	case request.RequestUri != "":
+		return nil, oauth2.NewInvalidAuthorizeRequestError(fmt.Sprintf("%s is not allowed in pushed authorization request", oauth2.ParameterRequestUri))
+	case request.ClientId != "" && request.ClientId != client.ClientId():
+		return nil, oauth2.NewInvalidAuthorizeRequestError(fmt.Sprintf("%s doesn't match authenticated client", oauth2.ParameterClientId))
+	}
+
+	ar, e := ep.parseAuthorizeRequest(c, client)
+	if e != nil {
+		return nil, e
+	}
+
+	// validate with a separate mutable context, so any security context changes made by processors (e.g. "prompt=login")
+	// don't affect current request
+	//nolint:contextcheck
+	if _, e := ep.requestProcessor.Process(utils.NewMutableContext(c), ar.WithContext(c)); e != nil {
+		return nil, e
+	}
+
+	// processors may modify the request, so we save the request as it was received.
+	// It will be processed again at authorize endpoint
+	ar, e = ep.parseAuthorizeRequest(c, client)
+	if e != nil {
+		return nil, e
+	}
+	par, e := ep.parStore.SaveAuthorizeRequest(c, ar)
+	if e != nil {
+		return nil, e
+	}
+	return &PushedAuthorizationResponse{
+		RequestUri: par.RequestUri,
+		ExpiresIn:  int(time.Until(par.ExpireAt).Round(time.Second).Seconds()),
+	}, nil
+}
+
+func (ep *PushedAuthorizationEndpoint) parseAuthorizeRequest(c context.Context, client oauth2.OAuth2Client) (*auth.AuthorizeRequest, error) {
+	req := web.HttpRequest(c)
+	if req == nil {
+		return nil, oauth2.NewInternalError("pushed authorization request endpoint is invoked without http request")
+	}
+	if e := req.ParseForm(); e != nil {
+		return nil, oauth2.NewInvalidAuthorizeRequestError("invalid pushed authorization request", e)
+	}
+
+	// Note: per RFC 9126, parameters are sent via POST body
+	values := make(map[string]interface{}, len(req.PostForm))
+	for k, v := range req.PostForm {
+		if len(v) == 0 || parExcludedParams.Has(k) {
+			continue
+		}
+		values[k] = strings.Join(v, " ")
+	}
+	values[oauth2.ParameterClientId] = client.ClientId()
+	return auth.ParseAuthorizeRequestWithKVs(c, values)
+}
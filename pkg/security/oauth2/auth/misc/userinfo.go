@@ -25,6 +25,7 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/openid"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
 	"github.com/cisco-open/go-lanai/pkg/web"
+	"net/http"
 )
 
 var (
@@ -59,25 +60,63 @@ func (r UserInfoJwtResponse) MarshalText() (text []byte, err error) {
 	return []byte(r), nil
 }
 
+type UserInfoOptions func(opt *UserInfoOption)
+type UserInfoOption struct {
+	// Encryptor is optional. When set, JWT userinfo response is encrypted for clients that registered encryption.
+	Encryptor *openid.ResponseEncryptor
+}
+
 type UserInfoEndpoint struct {
 	issuer       security.Issuer
 	accountStore security.AccountStore
 	jwtEncoder   jwt.JwtEncoder
+	encryptor    *openid.ResponseEncryptor
 }
 
-func NewUserInfoEndpoint(issuer security.Issuer, accountStore security.AccountStore, jwtEncoder jwt.JwtEncoder) *UserInfoEndpoint {
+func NewUserInfoEndpoint(issuer security.Issuer, accountStore security.AccountStore, jwtEncoder jwt.JwtEncoder, opts ...UserInfoOptions) *UserInfoEndpoint {
+	opt := UserInfoOption{}
+	for _, fn := range opts {
+		fn(&opt)
+	}
 	return &UserInfoEndpoint{
 		issuer:       issuer,
 		accountStore: accountStore,
 		jwtEncoder:   jwtEncoder,
+		encryptor:    opt.Encryptor,
+	}
+}
+
+// UserInfo is the endpoint for requests that don't explicitly accept "application/jwt".
+// It returns UserInfoJwtResponse if the client registered userinfo encryption, otherwise *UserInfoPlainResponse.
+// Use UserInfoResponseEncoder to encode its response.
+func (ep *UserInfoEndpoint) UserInfo(ctx context.Context, req UserInfoRequest) (interface{}, error) {
+	auth, ok := security.Get(ctx).(oauth2.Authentication)
+	if !ok || auth.UserAuthentication() == nil {
+		return nil, oauth2.NewAccessRejectedError("missing user authentication")
+	}
+	encrypted, e := ep.encryptionRequired(ctx, auth)
+	switch {
+	case e != nil:
+		return nil, e
+	case encrypted:
+		return ep.JwtUserInfo(ctx, req)
+	default:
+		return ep.PlainUserInfo(ctx, req)
 	}
 }
 
+// PlainUserInfo returns userinfo claims as plain JSON.
+// Clients registered userinfo encryption are rejected, because their responses should always be encrypted.
 func (ep *UserInfoEndpoint) PlainUserInfo(ctx context.Context, _ UserInfoRequest) (resp *UserInfoPlainResponse, err error) {
 	auth, ok := security.Get(ctx).(oauth2.Authentication)
 	if !ok || auth.UserAuthentication() == nil {
 		return nil, oauth2.NewAccessRejectedError("missing user authentication")
 	}
+	if encrypted, e := ep.encryptionRequired(ctx, auth); e != nil {
+		return nil, e
+	} else if encrypted {
+		return nil, oauth2.NewAccessRejectedError("client requires encrypted userinfo response")
+	}
 
 	specs := ep.determineClaimSpecs(auth.OAuth2Request())
 	requested := ep.determineRequestedClaims(auth.OAuth2Request())
@@ -123,13 +162,31 @@ func (ep *UserInfoEndpoint) JwtUserInfo(ctx context.Context, _ UserInfoRequest)
 		return "", oauth2.NewInternalError(err)
 	}
 
-	token, e := ep.jwtEncoder.Encode(ctx, &c)
+	encoder := ep.jwtEncoder
+	if ep.encryptor != nil && auth.OAuth2Request() != nil {
+		if encoder, e = ep.encryptor.UserInfoEncoder(ctx, auth.OAuth2Request().ClientId(), encoder); e != nil {
+			return "", oauth2.NewInternalError(e)
+		}
+	}
+
+	token, e := encoder.Encode(ctx, &c)
 	if e != nil {
 		return "", oauth2.NewInternalError(e)
 	}
 	return UserInfoJwtResponse(token), nil
 }
 
+func (ep *UserInfoEndpoint) encryptionRequired(ctx context.Context, auth oauth2.Authentication) (bool, error) {
+	if ep.encryptor == nil || auth.OAuth2Request() == nil {
+		return false, nil
+	}
+	encrypted, e := ep.encryptor.UserInfoEncrypted(ctx, auth.OAuth2Request().ClientId())
+	if e != nil {
+		return false, oauth2.NewInternalError(e)
+	}
+	return encrypted, nil
+}
+
 // determineClaimSpecs works slightly different from the id_token version:
 // When openid scope is not in the request, full specs is given
 func (ep *UserInfoEndpoint) determineClaimSpecs(request oauth2.OAuth2Request) []map[string]claims.ClaimSpec {
@@ -174,3 +231,16 @@ func JwtResponseEncoder() web.EncodeResponseFunc {
 		opt.WriteFunc = web.TextWriteFunc
 	})
 }
+
+// UserInfoResponseEncoder encodes response of UserInfoEndpoint.UserInfo,
+// UserInfoJwtResponse is written as "application/jwt" and any other response as JSON.
+func UserInfoResponseEncoder() web.EncodeResponseFunc {
+	jwtEncoder := JwtResponseEncoder()
+	jsonEncoder := web.JsonResponseEncoder()
+	return func(ctx context.Context, rw http.ResponseWriter, response interface{}) error {
+		if _, ok := response.(UserInfoJwtResponse); ok {
+			return jwtEncoder(ctx, rw, response)
+		}
+		return jsonEncoder(ctx, rw, response)
+	}
+}
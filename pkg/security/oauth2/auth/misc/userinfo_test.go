@@ -2,10 +2,14 @@ package misc_test
 
 import (
     "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "encoding/json"
     "fmt"
     "github.com/cisco-open/go-lanai/pkg/security"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/misc"
+    "github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/openid"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
     "github.com/cisco-open/go-lanai/pkg/utils"
     "github.com/cisco-open/go-lanai/test"
@@ -23,6 +27,10 @@ import (
 	Setup Test
  *************************/
 
+const (
+	TestClientEncKid = `client-enc-key`
+)
+
 var MockedSecurityDetailsTmpl = sectest.SecurityDetailsMock{
 	Username:                 "test-user",
 	UserId:                   "test-id",
@@ -55,6 +63,7 @@ type UserInfoDI struct {
 	AuthDI
 	Endpoint     *misc.UserInfoEndpoint
 	AccountStore security.AccountStore
+	JwtEncoder   jwt.JwtEncoder
 	JwtDecoder   jwt.JwtDecoder
 }
 
@@ -76,6 +85,8 @@ func TestUserInfoEndpoint(t *testing.T) {
 		test.GomegaSubTest(SubTestPlainUserInfo(&di), "PlainUserInfo"),
 		test.GomegaSubTest(SubTestUserInfoWithOIDCScope(&di), "UserInfoWithOIDCScope"),
 		test.GomegaSubTest(SubTestUserInfoWithClaimsRequest(&di), "TestUserInfoWithClaimsRequest"),
+		test.GomegaSubTest(SubTestUserInfoWithoutEncryption(&di), "UserInfoWithoutEncryption"),
+		test.GomegaSubTest(SubTestUserInfoWithEncryption(&di), "UserInfoWithEncryption"),
 	)
 }
 
@@ -219,6 +230,77 @@ func SubTestUserInfoWithClaimsRequest(di *UserInfoDI) test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestUserInfoWithoutEncryption(di *UserInfoDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const username = TestUser1
+		var req misc.UserInfoRequest
+		acct, e := di.AccountStore.LoadAccountByUsername(ctx, username)
+		g.Expect(e).To(Succeed(), "load account [%s] should not fail", username)
+		ctx = sectest.ContextWithSecurity(ctx, sectest.MockedAuthentication(SecurityMockWithAccount(acct)))
+		resp, e := di.Endpoint.UserInfo(ctx, req)
+		g.Expect(e).To(Succeed(), "UserInfo should not fail")
+		g.Expect(resp).To(BeAssignableToTypeOf(&misc.UserInfoPlainResponse{}), "UserInfo should return plain response")
+		AssertUserInfoClaims(g, resp.(*misc.UserInfoPlainResponse), acct, ExpectUserInfoProfile, ExpectUserInfoEmail, ExpectUserInfoPhone, ExpectUserInfoAddress)
+
+		respWriter := httptest.NewRecorder()
+		e = misc.UserInfoResponseEncoder()(ctx, respWriter, resp)
+		g.Expect(e).To(Succeed(), "encoding response should not fail")
+		g.Expect(respWriter.Header().Get("Content-Type")).To(HavePrefix("application/json"), "plain response should have correct content-type")
+	}
+}
+
+func SubTestUserInfoWithEncryption(di *UserInfoDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const username = TestUser1
+		var req misc.UserInfoRequest
+		clientKey, e := rsa.GenerateKey(rand.Reader, 2048)
+		g.Expect(e).To(Succeed(), "generating client key should not fail")
+		jwks, e := json.Marshal(map[string]interface{}{
+			"keys": []jwt.Jwk{jwt.NewJwk(TestClientEncKid, TestClientEncKid, clientKey.Public())},
+		})
+		g.Expect(e).To(Succeed(), "marshalling client JWKS should not fail")
+		clientStore := sectest.NewMockedClientStore(&sectest.MockedClientProperties{
+			ClientID:       ClientIDMinor,
+			Jwks:           string(jwks),
+			UserInfoJweAlg: jwt.JweAlgRsaOaep256,
+		})
+		ep := misc.NewUserInfoEndpoint(NewTestIssuer(), di.AccountStore, di.JwtEncoder, func(opt *misc.UserInfoOption) {
+			opt.Encryptor = openid.NewResponseEncryptor(clientStore)
+		})
+		decoder := jwt.NewEncryptedJwtDecoder(
+			jwt.DecryptWithJwkStore(jwt.NewJwkSetStore(jwt.NewPrivateJwk(TestClientEncKid, TestClientEncKid, clientKey)), ""),
+			jwt.DecryptAndVerifyWith(di.JwtDecoder),
+		)
+
+		acct, e := di.AccountStore.LoadAccountByUsername(ctx, username)
+		g.Expect(e).To(Succeed(), "load account [%s] should not fail", username)
+		ctx = sectest.ContextWithSecurity(ctx, sectest.MockedAuthentication(SecurityMockWithAccount(acct)))
+
+		// JSON requested
+		resp, e := ep.UserInfo(ctx, req)
+		g.Expect(e).To(Succeed(), "UserInfo should not fail")
+		g.Expect(resp).To(BeAssignableToTypeOf(misc.UserInfoJwtResponse("")), "UserInfo should return JWT response")
+		jwtResp := resp.(misc.UserInfoJwtResponse)
+		g.Expect(jwt.IsJwe(string(jwtResp))).To(BeTrue(), "userinfo should be encrypted")
+		AssertUserInfoJwt(g, string(jwtResp), decoder, acct, ExpectUserInfoProfile, ExpectUserInfoEmail, ExpectUserInfoPhone, ExpectUserInfoAddress)
+
+		respWriter := httptest.NewRecorder()
+		e = misc.UserInfoResponseEncoder()(ctx, respWriter, resp)
+		g.Expect(e).To(Succeed(), "encoding response should not fail")
+		g.Expect(respWriter.Header().Get("Content-Type")).To(HavePrefix("application/jwt"), "encrypted response should have correct content-type")
+		g.Expect(respWriter.Body.Bytes()).To(Equal([]byte(jwtResp)), "encrypted response should have correct body")
+
+		// plain response is never given
+		_, e = ep.PlainUserInfo(ctx, req)
+		g.Expect(e).To(HaveOccurred(), "PlainUserInfo should fail for client that registered encryption")
+
+		// JWT requested
+		jwtResp, e = ep.JwtUserInfo(ctx, req)
+		g.Expect(e).To(Succeed(), "JwtUserInfo should not fail")
+		AssertUserInfoJwt(g, string(jwtResp), decoder, acct, ExpectUserInfoProfile, ExpectUserInfoEmail, ExpectUserInfoPhone, ExpectUserInfoAddress)
+	}
+}
+
 /*************************
 	Helpers
  *************************/
@@ -80,6 +80,8 @@ func SubTestOpenIDConfigWithExtra(di *WellKnownDI) test.GomegaSubTestFunc {
 			openid.OPMetadataUserInfoEndpoint:   "/userinfo",
 			openid.OPMetadataJwkSetURI:          "/jwks",
 			openid.OPMetadataEndSessionEndpoint: "/logout",
+			openid.OPMetadataDeviceAuthEndpoint: "/device_authorization",
+			openid.OPMetadataParEndpoint:        "/par",
 		})
 
 		resp, e = endpoint.OpenIDConfig(ctx, req)
@@ -89,6 +91,8 @@ func SubTestOpenIDConfigWithExtra(di *WellKnownDI) test.GomegaSubTestFunc {
 			ExpectClaim(openid.OPMetadataTokenEndpoint, FullURL("/token")),
 			ExpectClaim(openid.OPMetadataUserInfoEndpoint, FullURL("/userinfo")),
 			ExpectClaim(openid.OPMetadataJwkSetURI, FullURL("/jwks")),
+			ExpectClaim(openid.OPMetadataDeviceAuthEndpoint, FullURL("/device_authorization")),
+			ExpectClaim(openid.OPMetadataParEndpoint, FullURL("/par")),
 		)
 	}
 }
@@ -108,7 +112,7 @@ func FullURL(path string) string {
 func AssertOpenIDConfigClaims(g *gomega.WithT, claims oauth2.Claims, expectExtra ...ExpectedClaimsOption) {
 	expectOpts := []ExpectedClaimsOption{
 		ExpectClaim(openid.OPMetadataIssuer, "http://"+IssuerDomain+IssuerPath),
-		ExpectClaim(openid.OPMetadataGrantTypes, HaveLen(8)),
+		ExpectClaim(openid.OPMetadataGrantTypes, HaveLen(10)),
 		ExpectClaim(openid.OPMetadataScopes, HaveLen(9)),
 		ExpectClaim(openid.OPMetadataResponseTypes, HaveKey("code")),
 		ExpectClaim(openid.OPMetadataACRValues, HaveKey(Or(Equal(ACRValue(1)), Equal(ACRValue(2)), Equal(ACRValue(3))))),
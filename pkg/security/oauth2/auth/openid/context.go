@@ -99,6 +99,9 @@ const (
 	OPMetadataPolicyUri             = "op_policy_uri"
 	OPMetadataTosUri                = "op_tos_uri"
 	OPMetadataEndSessionEndpoint    = "end_session_endpoint"
+	OPMetadataDeviceAuthEndpoint    = "device_authorization_endpoint"         // https://datatracker.ietf.org/doc/html/rfc8628#section-4
+	OPMetadataParEndpoint           = "pushed_authorization_request_endpoint" // https://datatracker.ietf.org/doc/html/rfc9126#section-5
+	OPMetadataDPoPJwsAlg            = "dpop_signing_alg_values_supported"     // https://datatracker.ietf.org/doc/html/rfc9449#section-5.1
 )
 
 // OPMetadata leverage claims implementations
@@ -0,0 +1,134 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package openid
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+)
+
+const (
+	// DefaultJweEnc is the default "enc" when client registered "alg" only.
+	// See https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata
+	DefaultJweEnc = jwt.JweEncA128CBCHS256
+)
+
+// ResponseEncryptor decides whether ID tokens and userinfo responses should be encrypted for a client,
+// based on client's registered metadata (oauth2.EncryptionAware), and provides jwt.JwtEncoder accordingly.
+// Encrypted responses are nested JWT, i.e. signed first then encrypted with client's public key.
+type ResponseEncryptor struct {
+	clientStore oauth2.OAuth2ClientStore
+	jwkResolver *auth.ClientJwkResolver
+}
+
+func NewResponseEncryptor(clientStore oauth2.OAuth2ClientStore) *ResponseEncryptor {
+	return &ResponseEncryptor{
+		clientStore: clientStore,
+		jwkResolver: auth.NewClientJwkResolver(),
+	}
+}
+
+// IdTokenEncoder returns the jwt.JwtEncoder for ID token of given client.
+// The given signer is returned as-is if client didn't register "id_token_encrypted_response_alg"
+func (r *ResponseEncryptor) IdTokenEncoder(ctx context.Context, clientId string, signer jwt.JwtEncoder) (jwt.JwtEncoder, error) {
+	return r.encoder(ctx, clientId, signer, func(aware oauth2.EncryptionAware) (string, string) {
+		return aware.IdTokenEncryptedResponseAlg(), aware.IdTokenEncryptedResponseEnc()
+	})
+}
+
+// UserInfoEncoder returns the jwt.JwtEncoder for userinfo response of given client.
+// The given signer is returned as-is if client didn't register "userinfo_encrypted_response_alg"
+func (r *ResponseEncryptor) UserInfoEncoder(ctx context.Context, clientId string, signer jwt.JwtEncoder) (jwt.JwtEncoder, error) {
+	return r.encoder(ctx, clientId, signer, func(aware oauth2.EncryptionAware) (string, string) {
+		return aware.UserInfoEncryptedResponseAlg(), aware.UserInfoEncryptedResponseEnc()
+	})
+}
+
+// UserInfoEncrypted returns true if given client registered "userinfo_encrypted_response_alg".
+// In such case, userinfo response should always be encrypted regardless of the requested content type.
+func (r *ResponseEncryptor) UserInfoEncrypted(ctx context.Context, clientId string) (bool, error) {
+	client, e := r.loadClient(ctx, clientId)
+	if e != nil || client == nil {
+		return false, e
+	}
+	aware, ok := client.(oauth2.EncryptionAware)
+	return ok && len(aware.UserInfoEncryptedResponseAlg()) != 0, nil
+}
+
+func (r *ResponseEncryptor) encoder(ctx context.Context, clientId string, signer jwt.JwtEncoder,
+	algFn func(aware oauth2.EncryptionAware) (alg string, enc string)) (jwt.JwtEncoder, error) {
+	client, e := r.loadClient(ctx, clientId)
+	if e != nil {
+		return nil, e
+	}
+	aware, ok := client.(oauth2.EncryptionAware)
+	if !ok {
+		return signer, nil
+	}
+	alg, enc := algFn(aware)
+	switch {
+	case len(alg) == 0:
+		return signer, nil
+	case len(enc) == 0:
+		enc = DefaultJweEnc
+	}
+	if !isSupported(jwt.SupportedJweAlgorithms, alg) || !isSupported(jwt.SupportedJweEncryptions, enc) {
+		return nil, fmt.Errorf("client [%s] registered unsupported encryption alg=%s, enc=%s", clientId, alg, enc)
+	}
+
+	store, e := r.clientJwkStore(client)
+	if e != nil {
+		return nil, e
+	}
+	return jwt.NewEncryptedJwtEncoder(
+		jwt.EncryptWithJwkStore(store, ""),
+		jwt.EncryptWithAlgorithms(alg, enc),
+		jwt.EncryptSignedWith(signer),
+	), nil
+}
+
+// loadClient returns nil client without error if client store is not available or client ID is empty
+func (r *ResponseEncryptor) loadClient(ctx context.Context, clientId string) (oauth2.OAuth2Client, error) {
+	if r.clientStore == nil || len(clientId) == 0 {
+		return nil, nil
+	}
+	return r.clientStore.LoadClientByClientId(ctx, clientId)
+}
+
+func (r *ResponseEncryptor) clientJwkStore(client oauth2.OAuth2Client) (jwt.JwkStore, error) {
+	aware, ok := client.(oauth2.ClientAssertionAware)
+	if !ok {
+		return nil, fmt.Errorf("client [%s] doesn't have JWK Set for encryption", client.ClientId())
+	}
+	store, e := r.jwkResolver.Resolve(aware)
+	if e != nil {
+		return nil, fmt.Errorf("unable to resolve JWK Set of client [%s] for encryption: %v", client.ClientId(), e)
+	}
+	return store, nil
+}
+
+func isSupported(supported []string, value string) bool {
+	for _, v := range supported {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
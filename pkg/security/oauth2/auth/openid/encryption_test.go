@@ -0,0 +1,175 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package openid
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/apptest"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"testing"
+)
+
+const (
+	TestClientEncKid = `client-enc-key`
+)
+
+/*************************
+	Setup Test
+ *************************/
+
+type EncryptionDI struct {
+	fx.In
+	AccountStore security.AccountStore
+	JwtEncoder   jwt.JwtEncoder
+	JwtDecoder   jwt.JwtDecoder
+}
+
+/*************************
+	Test
+ *************************/
+
+func TestIDTokenEncryption(t *testing.T) {
+	var di EncryptionDI
+	clientKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	encryptedStore := newEncryptionTestClientStore(clientKey)
+	plainStore := sectest.NewMockedClientStore(&sectest.MockedClientProperties{ClientID: ClientIDMinor})
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		apptest.WithFxOptions(
+			fx.Provide(
+				sectest.BindMockingProperties, NewTestIssuer, NewTestAccountStore,
+				NewJwkStore, NewJwtEncoder, NewJwtDecoder,
+			),
+		),
+		apptest.WithDI(&di),
+		test.GomegaSubTest(SubTestEncryptedIDToken(&di, encryptedStore, clientKey), "EncryptedIDToken"),
+		test.GomegaSubTest(SubTestUnencryptedIDToken(&di, plainStore), "UnencryptedIDToken"),
+		test.GomegaSubTest(SubTestEncryptionWithInvalidMetadata(&di), "InvalidMetadata"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestEncryptedIDToken(di *EncryptionDI, clientStore oauth2.OAuth2ClientStore, clientKey *rsa.PrivateKey) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		enhancer := NewOpenIDTokenEnhancer(func(opt *EnhancerOption) {
+			opt.Issuer = NewTestIssuer()
+			opt.JwtEncoder = di.JwtEncoder
+			opt.Encryptor = NewResponseEncryptor(clientStore)
+		})
+		acct, auth := encryptionTestAuth(ctx, g, di)
+		token, e := enhancer.Enhance(ctx, oauth2.FromAccessToken(auth.AccessToken()), auth)
+		g.Expect(e).To(Succeed(), "Enhance() should not fail")
+		idToken, ok := token.Details()["id_token"].(string)
+		g.Expect(ok).To(BeTrue(), "enhanced token should contains 'id_token'")
+		g.Expect(jwt.IsJwe(idToken)).To(BeTrue(), "id_token should be encrypted")
+
+		decoder := jwt.NewEncryptedJwtDecoder(
+			jwt.DecryptWithJwkStore(jwt.NewJwkSetStore(jwt.NewPrivateJwk(TestClientEncKid, TestClientEncKid, clientKey)), ""),
+			jwt.DecryptAndVerifyWith(di.JwtDecoder),
+		)
+		AssertIDToken(g, idToken, decoder, acct, auth)
+	}
+}
+
+func SubTestUnencryptedIDToken(di *EncryptionDI, clientStore oauth2.OAuth2ClientStore) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		enhancer := NewOpenIDTokenEnhancer(func(opt *EnhancerOption) {
+			opt.Issuer = NewTestIssuer()
+			opt.JwtEncoder = di.JwtEncoder
+			opt.Encryptor = NewResponseEncryptor(clientStore)
+		})
+		acct, auth := encryptionTestAuth(ctx, g, di)
+		token, e := enhancer.Enhance(ctx, oauth2.FromAccessToken(auth.AccessToken()), auth)
+		g.Expect(e).To(Succeed(), "Enhance() should not fail")
+		idToken, ok := token.Details()["id_token"].(string)
+		g.Expect(ok).To(BeTrue(), "enhanced token should contains 'id_token'")
+		g.Expect(jwt.IsJwe(idToken)).To(BeFalse(), "id_token should not be encrypted")
+		AssertIDToken(g, idToken, di.JwtDecoder, acct, auth)
+	}
+}
+
+func SubTestEncryptionWithInvalidMetadata(di *EncryptionDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// unsupported alg
+		encryptor := NewResponseEncryptor(sectest.NewMockedClientStore(&sectest.MockedClientProperties{
+			ClientID:      ClientIDMinor,
+			Jwks:          `{"keys":[]}`,
+			IdTokenJweAlg: "RSA1_5",
+		}))
+		_, e := encryptor.IdTokenEncoder(ctx, ClientIDMinor, di.JwtEncoder)
+		g.Expect(e).To(HaveOccurred(), "unsupported alg should fail")
+
+		// missing JWKS
+		encryptor = NewResponseEncryptor(sectest.NewMockedClientStore(&sectest.MockedClientProperties{
+			ClientID:       ClientIDMinor,
+			UserInfoJweAlg: jwt.JweAlgRsaOaep,
+		}))
+		_, e = encryptor.UserInfoEncoder(ctx, ClientIDMinor, di.JwtEncoder)
+		g.Expect(e).To(HaveOccurred(), "missing client JWKS should fail")
+
+		// not registered
+		enc, e := encryptor.IdTokenEncoder(ctx, ClientIDMinor, di.JwtEncoder)
+		g.Expect(e).To(Succeed(), "client without encryption should not fail")
+		g.Expect(enc).To(BeIdenticalTo(di.JwtEncoder), "client without encryption should use signer")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func newEncryptionTestClientStore(clientKey *rsa.PrivateKey) oauth2.OAuth2ClientStore {
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []jwt.Jwk{jwt.NewJwk(TestClientEncKid, TestClientEncKid, clientKey.Public())},
+	})
+	return sectest.NewMockedClientStore(
+		&sectest.MockedClientProperties{
+			ClientID:      ClientIDMinor,
+			Jwks:          string(jwks),
+			IdTokenJweAlg: jwt.JweAlgRsaOaep256,
+			IdTokenJweEnc: jwt.JweEncA256GCM,
+		},
+	)
+}
+
+func encryptionTestAuth(ctx context.Context, g *gomega.WithT, di *EncryptionDI) (security.Account, oauth2.Authentication) {
+	acct, e := di.AccountStore.LoadAccountByUsername(ctx, TestUser1)
+	g.Expect(e).To(Succeed(), "load account [%s] should not fail", TestUser1)
+	auth := OAuth2AuthenticationWithAccount(acct,
+		func(d *sectest.SecurityDetailsMock) {
+			d.AccessToken = MockedJWTValue(di.JwtEncoder)
+			d.KVs[security.DetailsKeyAuthMethod] = security.AuthMethodPassword
+			d.OAuth2Parameters[oauth2.ParameterNonce] = TestNonce
+			d.OAuth2ResponseTypes = utils.NewStringSet("code")
+		},
+	)
+	return acct, auth
+}
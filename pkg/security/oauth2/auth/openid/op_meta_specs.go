@@ -19,6 +19,21 @@ package openid
 import (
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
 	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/claims"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+)
+
+var (
+	opMetaClientAuthMethods = []string{
+		oauth2.ClientAuthMethodSecretBasic, oauth2.ClientAuthMethodSecretPost,
+		oauth2.ClientAuthMethodSecretJwt, oauth2.ClientAuthMethodPrivateKeyJwt,
+	}
+	opMetaClientAuthJwsAlgs = []string{
+		"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "PS384", "PS512", "EdDSA",
+		"HS256", "HS384", "HS512",
+	}
+	opMetaDPoPJwsAlgs = []string{
+		"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "PS384", "PS512", "EdDSA",
+	}
 )
 
 var (
@@ -32,6 +47,7 @@ var (
 			oauth2.GrantTypeClientCredentials, oauth2.GrantTypePassword,
 			oauth2.GrantTypeAuthCode, oauth2.GrantTypeImplicit, oauth2.GrantTypeRefresh,
 			oauth2.GrantTypeSwitchUser, oauth2.GrantTypeSwitchTenant, oauth2.GrantTypeSamlSSO,
+			oauth2.GrantTypeDeviceCode, oauth2.GrantTypeTokenExchange,
 		),
 		OPMetadataScopes: opMetaFixedSet(
 			oauth2.ScopeRead, oauth2.ScopeWrite, oauth2.ScopeTokenDetails, oauth2.ScopeTenantHierarchy,
@@ -55,16 +71,16 @@ var (
 	OPMetadataOptionalSpecs = map[string]claims.ClaimSpec{
 		OPMetadataRegEndpoint:           claims.Unsupported(),
 		OPMetadataResponseModes:         claims.Unsupported(),
-		OPMetadataIdTokenJweAlg:         claims.Unsupported(),
-		OPMetadataIdTokenJweEnc:         claims.Unsupported(),
+		OPMetadataIdTokenJweAlg:         opMetaFixedSet(jwt.SupportedJweAlgorithms...),
+		OPMetadataIdTokenJweEnc:         opMetaFixedSet(jwt.SupportedJweEncryptions...),
 		OPMetadataUserInfoJwsAlg:        opMetaFixedSet("RS256"),
-		OPMetadataUserInfoJweAlg:        claims.Unsupported(),
-		OPMetadataUserInfoJweEnc:        claims.Unsupported(),
+		OPMetadataUserInfoJweAlg:        opMetaFixedSet(jwt.SupportedJweAlgorithms...),
+		OPMetadataUserInfoJweEnc:        opMetaFixedSet(jwt.SupportedJweEncryptions...),
 		OPMetadataRequestJwsAlg:         claims.Unsupported(),
 		OPMetadataRequestJweAlg:         claims.Unsupported(),
 		OPMetadataRequestJweEnc:         claims.Unsupported(),
-		OPMetadataClientAuthMethod:      opMetaFixedSet("client_secret_basic", "client_secret_post"),
-		OPMetadataAuthJwsAlg:            claims.Unsupported(),
+		OPMetadataClientAuthMethod:      opMetaFixedSet(opMetaClientAuthMethods...),
+		OPMetadataAuthJwsAlg:            opMetaFixedSet(opMetaClientAuthJwsAlgs...),
 		OPMetadataDisplayValues:         opMetaFixedSet("page", "touch"),
 		OPMetadataClaimTypes:            opMetaFixedSet("normal"),
 		OPMetadataServiceDocs:           claims.Unsupported(),
@@ -77,5 +93,8 @@ var (
 		OPMetadataPolicyUri:             claims.Unsupported(),
 		OPMetadataTosUri:                claims.Unsupported(),
 		OPMetadataEndSessionEndpoint:    opMetaEndpoint(OPMetadataEndSessionEndpoint),
+		OPMetadataDeviceAuthEndpoint:    opMetaEndpoint(OPMetadataDeviceAuthEndpoint),
+		OPMetadataParEndpoint:           opMetaEndpoint(OPMetadataParEndpoint),
+		OPMetadataDPoPJwsAlg:            opMetaFixedSet(opMetaDPoPJwsAlgs...),
 	}
 )
@@ -53,6 +53,8 @@ type EnhancerOptions func(opt *EnhancerOption)
 type EnhancerOption struct {
 	Issuer     security.Issuer
 	JwtEncoder jwt.JwtEncoder
+	// Encryptor is optional. When set, ID token is encrypted for clients that registered encryption.
+	Encryptor *ResponseEncryptor
 }
 
 // OpenIDTokenEnhancer implements order.Ordered and TokenEnhancer
@@ -61,6 +63,7 @@ type EnhancerOption struct {
 type OpenIDTokenEnhancer struct {
 	issuer     security.Issuer
 	jwtEncoder jwt.JwtEncoder
+	encryptor  *ResponseEncryptor
 }
 
 func NewOpenIDTokenEnhancer(opts ...EnhancerOptions) *OpenIDTokenEnhancer {
@@ -71,6 +74,7 @@ func NewOpenIDTokenEnhancer(opts ...EnhancerOptions) *OpenIDTokenEnhancer {
 	return &OpenIDTokenEnhancer{
 		issuer:     opt.Issuer,
 		jwtEncoder: opt.JwtEncoder,
+		encryptor:  opt.Encryptor,
 	}
 }
 
@@ -103,7 +107,14 @@ func (oe *OpenIDTokenEnhancer) Enhance(ctx context.Context, token oauth2.AccessT
 		return nil, oauth2.NewInternalError(e)
 	}
 
-	idToken, e := oe.jwtEncoder.Encode(ctx, &c)
+	encoder := oe.jwtEncoder
+	if oe.encryptor != nil {
+		if encoder, e = oe.encryptor.IdTokenEncoder(ctx, oauth.OAuth2Request().ClientId(), encoder); e != nil {
+			return nil, oauth2.NewInternalError(e)
+		}
+	}
+
+	idToken, e := encoder.Encode(ctx, &c)
 	if e != nil {
 		return nil, oauth2.NewInternalError(e)
 	}
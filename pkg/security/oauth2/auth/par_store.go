@@ -0,0 +1,169 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/redis"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"strings"
+	"time"
+)
+
+const (
+	defaultParRefLength = 32
+	parPrefix           = "PAR"
+)
+
+var (
+	// defaultParValidity is the lifetime of pushed authorization request.
+	// See https://datatracker.ietf.org/doc/html/rfc9126#section-2.2
+	defaultParValidity = 90 * time.Second
+)
+
+/**********************
+	Abstraction
+ **********************/
+
+// PushedAuthorization is the result of a pushed authorization request
+// See https://datatracker.ietf.org/doc/html/rfc9126#section-2.2
+type PushedAuthorization struct {
+	RequestUri string
+	ExpireAt   time.Time
+}
+
+// PushedAuthorizationRequestStore stores validated authorization requests pushed by clients and reference them
+// with "request_uri". See https://datatracker.ietf.org/doc/html/rfc9126
+type PushedAuthorizationRequestStore interface {
+	// SaveAuthorizeRequest stores the given request and returns generated "request_uri"
+	SaveAuthorizeRequest(ctx context.Context, r *AuthorizeRequest) (*PushedAuthorization, error)
+	// LoadAuthorizeRequest loads the stored request by "request_uri". The request should belong to the given client.
+	// "invalid_request_uri" error is returned if the "request_uri" is not valid, expired or doesn't belong to the client
+	LoadAuthorizeRequest(ctx context.Context, requestUri string, clientId string) (*AuthorizeRequest, error)
+	// RemoveAuthorizeRequest invalidate the "request_uri", so it cannot be used again
+	RemoveAuthorizeRequest(ctx context.Context, requestUri string) error
+}
+
+// IsPushedAuthorizationRequestUri returns true if given "request_uri" is issued by PushedAuthorizationRequestStore
+func IsPushedAuthorizationRequestUri(requestUri string) bool {
+	return strings.HasPrefix(requestUri, oauth2.RequestUriPrefixPushedAuthorization)
+}
+
+/**********************
+	Redis Impl
+ **********************/
+
+type ParStoreOptions func(opt *ParStoreOption)
+type ParStoreOption struct {
+	// Validity is the lifetime of "request_uri"
+	Validity time.Duration
+}
+
+// RedisPushedAuthorizationRequestStore store pushed authorization requests in Redis
+type RedisPushedAuthorizationRequestStore struct {
+	redisClient redis.Client
+	validity    time.Duration
+}
+
+func NewRedisPushedAuthorizationRequestStore(ctx context.Context, cf redis.ClientFactory, dbIndex int, opts ...ParStoreOptions) *RedisPushedAuthorizationRequestStore {
+	opt := ParStoreOption{
+		Validity: defaultParValidity,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+
+	client, e := cf.New(ctx, func(opt *redis.ClientOption) {
+		opt.DbIndex = dbIndex
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	return &RedisPushedAuthorizationRequestStore{
+		redisClient: client,
+		validity:    opt.Validity,
+	}
+}
+
+func (s *RedisPushedAuthorizationRequestStore) SaveAuthorizeRequest(ctx context.Context, r *AuthorizeRequest) (*PushedAuthorization, error) {
+	record := parRecord{
+		ClientId:   r.ClientId,
+		Parameters: r.Parameters,
+	}
+	toSave, e := json.Marshal(&record)
+	if e != nil {
+		return nil, oauth2.NewInternalError(e)
+	}
+
+	ref := utils.RandomStringWithCharset(defaultParRefLength, utils.CharsetAlphanumeric)
+	if cmd := s.redisClient.Set(ctx, s.parRedisKey(ref), toSave, s.validity); cmd.Err() != nil {
+		return nil, oauth2.NewInternalError(cmd.Err())
+	}
+	return &PushedAuthorization{
+		RequestUri: oauth2.RequestUriPrefixPushedAuthorization + ref,
+		ExpireAt:   time.Now().Add(s.validity),
+	}, nil
+}
+
+func (s *RedisPushedAuthorizationRequestStore) LoadAuthorizeRequest(ctx context.Context, requestUri string, clientId string) (*AuthorizeRequest, error) {
+	if !IsPushedAuthorizationRequestUri(requestUri) {
+		return nil, oauth2.NewInvalidRequestUriError(fmt.Sprintf("%s is not issued by this server", oauth2.ParameterRequestUri))
+	}
+	cmd := s.redisClient.Get(ctx, s.parRedisKey(strings.TrimPrefix(requestUri, oauth2.RequestUriPrefixPushedAuthorization)))
+	if cmd.Err() != nil {
+		return nil, oauth2.NewInvalidRequestUriError(fmt.Sprintf("%s is invalid or expired", oauth2.ParameterRequestUri))
+	}
+
+	var record parRecord
+	if e := json.Unmarshal([]byte(cmd.Val()), &record); e != nil {
+		return nil, oauth2.NewInvalidRequestUriError(fmt.Sprintf("%s is invalid or expired", oauth2.ParameterRequestUri), e)
+	}
+	if record.ClientId != clientId {
+		return nil, oauth2.NewInvalidRequestUriError(fmt.Sprintf("%s was not issued to this client", oauth2.ParameterRequestUri))
+	}
+
+	values := make(map[string]interface{}, len(record.Parameters))
+	for k, v := range record.Parameters {
+		values[k] = v
+	}
+	return ParseAuthorizeRequestWithKVs(ctx, values)
+}
+
+func (s *RedisPushedAuthorizationRequestStore) RemoveAuthorizeRequest(ctx context.Context, requestUri string) error {
+	if !IsPushedAuthorizationRequestUri(requestUri) {
+		return nil
+	}
+	cmd := s.redisClient.Del(ctx, s.parRedisKey(strings.TrimPrefix(requestUri, oauth2.RequestUriPrefixPushedAuthorization)))
+	return cmd.Err()
+}
+
+/**********************
+	Helpers
+ **********************/
+
+type parRecord struct {
+	ClientId   string            `json:"clientId"`
+	Parameters map[string]string `json:"params"`
+}
+
+func (s *RedisPushedAuthorizationRequestStore) parRedisKey(ref string) string {
+	return fmt.Sprintf("%s:%s", parPrefix, ref)
+}
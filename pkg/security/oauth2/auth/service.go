@@ -78,6 +78,8 @@ func NewDefaultAuthorizationService(opts ...DASOptions) *DefaultAuthorizationSer
 			&basicEnhancer,
 			&LegacyTokenEnhancer{},
 			&ResourceIdTokenEnhancer{},
+			&TokenExchangeTokenEnhancer{},
+			&DPoPTokenEnhancer{},
 			&DetailsTokenEnhancer{},
 			&refreshTokenEnhancer,
 		},
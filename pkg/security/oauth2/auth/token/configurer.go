@@ -49,6 +49,8 @@ func (c *TokenEndpointConfigurer) Apply(feature security.Feature, ws security.We
 	// prepare middlewares
 	tokenMw := NewTokenEndpointMiddleware(func(opts *TokenEndpointOptions) {
 		opts.Granter = auth.NewCompositeTokenGranter(f.granters...)
+		opts.DPoPVerifier = f.dpopVerifier
+		opts.DPoPUrl = f.dpopUrl
 	})
 
 	// install middlewares
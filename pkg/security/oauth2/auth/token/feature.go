@@ -20,6 +20,7 @@ import (
     "fmt"
     "github.com/cisco-open/go-lanai/pkg/security"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+    "github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
 )
 
 // We currently don't have any stuff to configure
@@ -27,6 +28,8 @@ import (
 type TokenFeature struct {
 	path string
 	granters []auth.TokenGranter
+	dpopVerifier *dpop.ProofVerifier
+	dpopUrl string
 }
 
 // Standard security.Feature entrypoint
@@ -62,4 +65,17 @@ func (f *TokenFeature) AddGranter(granter auth.TokenGranter) *TokenFeature {
 	}
 
 	return f
-}
\ No newline at end of file
+}
+
+// DPoPVerifier enables DPoP-bound access tokens (RFC 9449). Tokens requested with valid DPoP proof are bound to the proof's key
+func (f *TokenFeature) DPoPVerifier(verifier *dpop.ProofVerifier) *TokenFeature {
+	f.dpopVerifier = verifier
+	return f
+}
+
+// DPoPUrl set the expected "htu" of DPoP proofs, typically the token endpoint URL built from issuer.
+// If not set, the request URL is used
+func (f *TokenFeature) DPoPUrl(url string) *TokenFeature {
+	f.dpopUrl = url
+	return f
+}
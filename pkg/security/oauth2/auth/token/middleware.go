@@ -20,16 +20,36 @@ import (
     "errors"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2/auth"
+    "github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
     "github.com/gin-gonic/gin"
+    "net/http"
 )
 
 /***********************
 	Token Endpoint
  ***********************/
 
+var (
+	// errors defined by device authorization grant are returned as-is, so polling clients know how to proceed.
+	// See https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+	passthroughErrors = []error{
+		oauth2.NewAuthorizationPendingError(""),
+		oauth2.NewSlowDownError(""),
+		oauth2.NewExpiredTokenError(""),
+		oauth2.NewDeviceAccessDeniedError(""),
+		oauth2.NewInvalidTargetError(""),
+		// DPoP errors are returned as-is, so clients know to retry with proper proof or nonce.
+		// See https://datatracker.ietf.org/doc/html/rfc9449#section-5
+		oauth2.NewInvalidDPoPProofError(""),
+		oauth2.NewUseDPoPNonceError(""),
+	}
+)
+
 //goland:noinspection GoNameStartsWithPackageName
 type TokenEndpointMiddleware struct {
-	granter     auth.TokenGranter
+	granter      auth.TokenGranter
+	dpopVerifier *dpop.ProofVerifier
+	dpopUrl      string
 }
 
 //goland:noinspection GoNameStartsWithPackageName
@@ -38,6 +58,10 @@ type TokenEndpointOptionsFunc func(*TokenEndpointOptions)
 //goland:noinspection GoNameStartsWithPackageName
 type TokenEndpointOptions struct {
 	Granter     *auth.CompositeTokenGranter
+	// DPoPVerifier is used to verify DPoP proofs. Optional, DPoP header is ignored when not set
+	DPoPVerifier *dpop.ProofVerifier
+	// DPoPUrl is the expected "htu" of DPoP proofs. Optional, the request URL is used when not set
+	DPoPUrl string
 }
 
 func NewTokenEndpointMiddleware(optionFuncs...TokenEndpointOptionsFunc) *TokenEndpointMiddleware {
@@ -50,7 +74,9 @@ func NewTokenEndpointMiddleware(optionFuncs...TokenEndpointOptionsFunc) *TokenEn
 		}
 	}
 	return &TokenEndpointMiddleware{
-		granter: opts.Granter,
+		granter:      opts.Granter,
+		dpopVerifier: opts.DPoPVerifier,
+		dpopUrl:      opts.DPoPUrl,
 	}
 }
 
@@ -89,6 +115,12 @@ func (mw *TokenEndpointMiddleware) TokenHandlerFunc() gin.HandlerFunc {
 			return
 		}
 
+		// check DPoP proof and bind issued token to the proof's key
+		if e := mw.bindDPoP(ctx, tokenRequest); e != nil {
+			mw.handleError(ctx, e)
+			return
+		}
+
 		token, e := mw.granter.Grant(ctx, tokenRequest)
 		if e != nil {
 			mw.handleError(ctx, e)
@@ -99,6 +131,45 @@ func (mw *TokenEndpointMiddleware) TokenHandlerFunc() gin.HandlerFunc {
 	}
 }
 
+// bindDPoP verifies DPoP proof if present, and record the JWK thumbprint of the proof's key in token request.
+// Any client supplied "dpop_jkt" parameter is discarded, the thumbprint is only recorded from a verified proof.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-5
+func (mw *TokenEndpointMiddleware) bindDPoP(ctx *gin.Context, tokenRequest *auth.TokenRequest) error {
+	tokenRequest.DPoPJkt = ""
+	delete(tokenRequest.Extensions, oauth2.ExtDPoPJkt)
+	proofs := ctx.Request.Header.Values(dpop.HeaderDPoP)
+	switch {
+	case mw.dpopVerifier == nil || len(proofs) == 0:
+		return nil
+	case len(proofs) > 1:
+		return oauth2.NewInvalidDPoPProofError("multiple DPoP proofs are not allowed")
+	}
+
+	htu := mw.dpopUrl
+	if len(htu) == 0 {
+		htu = mw.dpopVerifier.RequestURL(ctx.Request)
+	}
+	proof, e := mw.dpopVerifier.Verify(ctx, proofs[0], dpop.ProofExpectation{
+		Method: http.MethodPost,
+		URL:    htu,
+	})
+	switch {
+	case e == nil:
+		tokenRequest.DPoPJkt = proof.Thumbprint
+		tokenRequest.Extensions[oauth2.ExtDPoPJkt] = proof.Thumbprint
+		return nil
+	case errors.Is(e, dpop.ErrNonceRequired):
+		if nonce, err := mw.dpopVerifier.Nonce(ctx); err == nil {
+			ctx.Header(dpop.HeaderDPoPNonce, nonce)
+		}
+		return oauth2.NewUseDPoPNonceError(e.Error())
+	case errors.Is(e, dpop.ErrInvalidProof):
+		return oauth2.NewInvalidDPoPProofError(e.Error())
+	default:
+		return oauth2.NewInternalError(e)
+	}
+}
+
 func (mw *TokenEndpointMiddleware) handleSuccess(c *gin.Context, v interface{}) {
 	c.Header("Cache-Control", "no-store")
 	c.Header("Pragma", "no-cache")
@@ -107,10 +178,19 @@ func (mw *TokenEndpointMiddleware) handleSuccess(c *gin.Context, v interface{})
 }
 
 func (mw *TokenEndpointMiddleware) handleError(c *gin.Context, err error) {
-	if errors.Is(err, oauth2.ErrorTypeOAuth2) {
+	if errors.Is(err, oauth2.ErrorTypeOAuth2) && !isPassthroughError(err) {
 		err = oauth2.NewInvalidGrantError(err)
 	}
 
 	_ = c.Error(err)
 	c.Abort()
-}
\ No newline at end of file
+}
+
+func isPassthroughError(err error) bool {
+	for _, target := range passthroughErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+)
+
+/*****************************
+	DPoP Enhancer
+ *****************************/
+
+// dpopClaims implements Claims and wraps claims created by other enhancers
+type dpopClaims struct {
+	oauth2.FieldClaimsMapper
+	oauth2.Claims
+	Confirmation map[string]interface{} `claim:"cnf"`
+}
+
+func (c *dpopClaims) MarshalJSON() ([]byte, error) {
+	return c.FieldClaimsMapper.DoMarshalJSON(c)
+}
+
+func (c *dpopClaims) UnmarshalJSON(bytes []byte) error {
+	return c.FieldClaimsMapper.DoUnmarshalJSON(c, bytes)
+}
+
+func (c *dpopClaims) Get(claim string) interface{} {
+	return c.FieldClaimsMapper.Get(c, claim)
+}
+
+func (c *dpopClaims) Has(claim string) bool {
+	return c.FieldClaimsMapper.Has(c, claim)
+}
+
+func (c *dpopClaims) Set(claim string, value interface{}) {
+	c.FieldClaimsMapper.Set(c, claim, value)
+}
+
+func (c *dpopClaims) Values() map[string]interface{} {
+	return c.FieldClaimsMapper.Values(c)
+}
+
+// DPoPTokenEnhancer implements order.Ordered and TokenEnhancer
+// DPoPTokenEnhancer binds access tokens to the key of DPoP proof presented at token endpoint, by adding "cnf.jkt" claim
+// and changing token type to "DPoP".
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-6
+type DPoPTokenEnhancer struct{}
+
+func (te *DPoPTokenEnhancer) Order() int {
+	return TokenEnhancerOrderDPoPClaims
+}
+
+func (te *DPoPTokenEnhancer) Enhance(_ context.Context, token oauth2.AccessToken, oauth oauth2.Authentication) (oauth2.AccessToken, error) {
+	t, ok := token.(*oauth2.DefaultAccessToken)
+	if !ok {
+		return nil, oauth2.NewInternalError(errTmplUnsupportedToken, t)
+	}
+
+	jkt, _ := oauth.OAuth2Request().Extensions()[oauth2.ExtDPoPJkt].(string)
+	if len(jkt) == 0 {
+		return t, nil
+	}
+
+	if t.Claims() == nil {
+		return nil, oauth2.NewInternalError("DPoPTokenEnhancer need to be placed after BasicClaimsEnhancer")
+	}
+
+	t.SetClaims(&dpopClaims{
+		Claims: t.Claims(),
+		Confirmation: map[string]interface{}{
+			oauth2.ClaimJwkThumbprint: jkt,
+		},
+	})
+	t.SetType(oauth2.TokenTypeDPoP)
+	return t, nil
+}
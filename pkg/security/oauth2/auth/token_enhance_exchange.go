@@ -0,0 +1,95 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/auth/claims"
+)
+
+/*****************************
+	Token Exchange Enhancer
+ *****************************/
+
+// exchangeClaims implements Claims and wraps claims created by other enhancers
+type exchangeClaims struct {
+	oauth2.FieldClaimsMapper
+	oauth2.Claims
+	Actor map[string]interface{} `claim:"act"`
+}
+
+func (c *exchangeClaims) MarshalJSON() ([]byte, error) {
+	return c.FieldClaimsMapper.DoMarshalJSON(c)
+}
+
+func (c *exchangeClaims) UnmarshalJSON(bytes []byte) error {
+	return c.FieldClaimsMapper.DoUnmarshalJSON(c, bytes)
+}
+
+func (c *exchangeClaims) Get(claim string) interface{} {
+	return c.FieldClaimsMapper.Get(c, claim)
+}
+
+func (c *exchangeClaims) Has(claim string) bool {
+	return c.FieldClaimsMapper.Has(c, claim)
+}
+
+func (c *exchangeClaims) Set(claim string, value interface{}) {
+	c.FieldClaimsMapper.Set(c, claim, value)
+}
+
+func (c *exchangeClaims) Values() map[string]interface{} {
+	return c.FieldClaimsMapper.Values(c)
+}
+
+// TokenExchangeTokenEnhancer implements order.Ordered and TokenEnhancer
+// TokenExchangeTokenEnhancer narrows "aud" claim and adds "act" claim to tokens issued via token exchange grant.
+// See claims.TokenExchangeClaimSpecs
+type TokenExchangeTokenEnhancer struct{}
+
+func (te *TokenExchangeTokenEnhancer) Order() int {
+	return TokenEnhancerOrderTokenExchangeClaims
+}
+
+func (te *TokenExchangeTokenEnhancer) Enhance(ctx context.Context, token oauth2.AccessToken, oauth oauth2.Authentication) (oauth2.AccessToken, error) {
+	t, ok := token.(*oauth2.DefaultAccessToken)
+	if !ok {
+		return nil, oauth2.NewInternalError(errTmplUnsupportedToken, t)
+	}
+
+	if _, ok := oauth.OAuth2Request().Extensions()[oauth2.ExtTokenExchangeActor]; !ok {
+		return t, nil
+	}
+
+	if t.Claims() == nil {
+		return nil, oauth2.NewInternalError("TokenExchangeTokenEnhancer need to be placed after BasicClaimsEnhancer")
+	}
+
+	exchanged := &exchangeClaims{Claims: t.Claims()}
+	e := claims.Populate(ctx, exchanged,
+		claims.WithSpecs(claims.TokenExchangeClaimSpecs),
+		claims.WithSource(oauth),
+	)
+	if e != nil {
+		return nil, oauth2.NewInternalError(e)
+	}
+
+	t.PutDetails(oauth2.JsonFieldIssuedTokenType, oauth2.TokenTypeIdAccessToken)
+	t.SetClaims(exchanged)
+	return t, nil
+}
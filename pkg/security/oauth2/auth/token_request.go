@@ -30,6 +30,8 @@ type TokenRequest struct {
 	Scopes     utils.StringSet
 	GrantType  string
 	Extensions map[string]interface{}
+	// DPoPJkt is the JWK thumbprint of a verified DPoP proof. It's set by token endpoint and never parsed from request.
+	DPoPJkt    string
 	context    utils.MutableContext
 }
 
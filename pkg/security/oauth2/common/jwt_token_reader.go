@@ -85,14 +85,14 @@ func (r *jwtTokenStoreReader) ReadRefreshToken(c context.Context, value string)
 }
 
 func (r *jwtTokenStoreReader) parseAccessToken(c context.Context, value string) (*internal.DecodedAccessToken, error) {
-	claims := internal.ExtendedClaims{}
-	if e := r.jwtDecoder.DecodeWithClaims(c, value, &claims); e != nil {
+	claims := internal.NewExtendedClaims()
+	if e := r.jwtDecoder.DecodeWithClaims(c, value, claims); e != nil {
 		return nil, e
 	}
 
 	token := internal.DecodedAccessToken{}
 	token.TokenValue = value
-	token.DecodedClaims = &claims
+	token.DecodedClaims = claims
 	token.ExpireAt = claims.ExpiresAt
 	token.IssuedAt = claims.IssuedAt
 	token.ScopesSet = claims.Scopes.Copy()
@@ -100,14 +100,14 @@ func (r *jwtTokenStoreReader) parseAccessToken(c context.Context, value string)
 }
 
 func (r *jwtTokenStoreReader) parseRefreshToken(c context.Context, value string) (*internal.DecodedRefreshToken, error) {
-	claims := internal.ExtendedClaims{}
-	if e := r.jwtDecoder.DecodeWithClaims(c, value, &claims); e != nil {
+	claims := internal.NewExtendedClaims()
+	if e := r.jwtDecoder.DecodeWithClaims(c, value, claims); e != nil {
 		return nil, e
 	}
 
 	token := internal.DecodedRefreshToken{}
 	token.TokenValue = value
-	token.DecodedClaims = &claims
+	token.DecodedClaims = claims
 	token.ExpireAt = claims.ExpiresAt
 	token.IssuedAt = claims.IssuedAt
 	token.ScopesSet = claims.Scopes.Copy()
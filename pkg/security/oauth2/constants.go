@@ -25,6 +25,7 @@ const (
 	JsonFieldScope             = "scope"
 	JsonFieldRefreshTokenValue = "refresh_token"
 	JsonFieldIDTokenValue      = "id_token"
+	JsonFieldIssuedTokenType   = "issued_token_type"
 )
 
 const (
@@ -58,11 +59,25 @@ const (
 	ParameterACR                 = "acr_values"
 	ParameterPrompt              = "prompt"
 	ParameterClaims              = "claims"
+	ParameterDeviceCode          = "device_code"
+	ParameterUserCode            = "user_code"
+	ParameterAudience            = "audience"
+	ParameterResource            = "resource"
+	ParameterSubjectToken        = "subject_token"
+	ParameterSubjectTokenType    = "subject_token_type"
+	ParameterActorToken          = "actor_token"
+	ParameterActorTokenType      = "actor_token_type"
+	ParameterRequestedTokenType  = "requested_token_type"
+	ParameterClientAssertion     = "client_assertion"
+	ParameterClientAssertionType = "client_assertion_type"
 	//Parameter = ""
 )
 
 const (
-	ExtUseSessionTimeout = "use_session_timeout"
+	ExtUseSessionTimeout     = "use_session_timeout"
+	ExtTokenExchangeActor    = "token_exchange_actor"
+	ExtTokenExchangeAudience = "token_exchange_audience"
+	ExtDPoPJkt               = "dpop_jkt"
 	//Ext     = ""
 )
 
@@ -75,8 +90,36 @@ const (
 	GrantTypeSwitchUser        = "urn:cisco:nfv:oauth:grant-type:switch-user"
 	GrantTypeSwitchTenant      = "urn:cisco:nfv:oauth:grant-type:switch-tenant"
 	GrantTypeSamlSSO           = "urn:ietf:params:oauth:grant-type:saml2-bearer"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
 )
 
+// Token type identifiers used by token exchange
+// https://datatracker.ietf.org/doc/html/rfc8693#section-3
+const (
+	TokenTypeIdAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeIdRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIdJwt          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// Client authentication methods used at token endpoint
+// https://openid.net/specs/openid-connect-core-1_0.html#ClientAuthentication
+const (
+	ClientAuthMethodSecretBasic   = "client_secret_basic"
+	ClientAuthMethodSecretPost    = "client_secret_post"
+	ClientAuthMethodSecretJwt     = "client_secret_jwt"
+	ClientAuthMethodPrivateKeyJwt = "private_key_jwt"
+	ClientAuthMethodNone          = "none"
+)
+
+// ClientAssertionTypeJwtBearer is the only supported "client_assertion_type"
+// https://datatracker.ietf.org/doc/html/rfc7523#section-2.2
+const ClientAssertionTypeJwtBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// RequestUriPrefixPushedAuthorization is the prefix of "request_uri" issued by pushed authorization request endpoint
+// https://datatracker.ietf.org/doc/html/rfc9126#section-2.2
+const RequestUriPrefixPushedAuthorization = "urn:ietf:params:oauth:request_uri:"
+
 const (
 	ScopeRead            = "read"
 	ScopeWrite           = "write"
@@ -173,6 +216,22 @@ const (
 	ClaimTokenType = "token_type"
 	//Claim = ""
 
+	/**
+	 * Token Exchange
+	 * https://datatracker.ietf.org/doc/html/rfc8693#section-4
+	 */
+	ClaimActor = "act"
+	//Claim = ""
+
+	/**
+	 * Proof-of-Possession
+	 * https://datatracker.ietf.org/doc/html/rfc7800#section-3.1
+	 * https://datatracker.ietf.org/doc/html/rfc9449#section-6.1
+	 */
+	ClaimConfirmation  = "cnf"
+	ClaimJwkThumbprint = "jkt"
+	//Claim = ""
+
 	/**
 	 * NFV Additions - custom
 	 */
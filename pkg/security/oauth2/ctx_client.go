@@ -42,6 +42,42 @@ type OAuth2Client interface {
 	ResourceIDs() utils.StringSet
 }
 
+// ClientAssertionAware is an optional interface that OAuth2Client could implement to support JWT assertion based
+// client authentication, i.e. "private_key_jwt" and "client_secret_jwt".
+// See https://datatracker.ietf.org/doc/html/rfc7523#section-2.2
+type ClientAssertionAware interface {
+	// TokenEndpointAuthMethod returns registered client authentication method, e.g. ClientAuthMethodPrivateKeyJwt.
+	// Empty string means secret based authentication (basic auth or form)
+	TokenEndpointAuthMethod() string
+	// JwksUri returns the URL of the client's JWK Set. Used by "private_key_jwt" when Jwks is not available
+	JwksUri() string
+	// Jwks returns the client's JWK Set in JSON format. Used by "private_key_jwt" and takes precedence over JwksUri
+	Jwks() string
+}
+
+// PushedAuthorizationAware is an optional interface that OAuth2Client could implement to require
+// Pushed Authorization Requests. See https://datatracker.ietf.org/doc/html/rfc9126#section-6
+type PushedAuthorizationAware interface {
+	// RequirePushedAuthorizationRequests returns true if the client can only use "request_uri" issued by
+	// pushed authorization request endpoint at authorize endpoint
+	RequirePushedAuthorizationRequests() bool
+}
+
+// EncryptionAware is an optional interface that OAuth2Client could implement to receive encrypted ID tokens and
+// userinfo responses. Encryption keys are taken from the client's JWK Set (see ClientAssertionAware).
+// Empty "alg" means encryption is not required.
+// See https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata
+type EncryptionAware interface {
+	// IdTokenEncryptedResponseAlg returns "id_token_encrypted_response_alg", e.g. "RSA-OAEP"
+	IdTokenEncryptedResponseAlg() string
+	// IdTokenEncryptedResponseEnc returns "id_token_encrypted_response_enc", e.g. "A256GCM"
+	IdTokenEncryptedResponseEnc() string
+	// UserInfoEncryptedResponseAlg returns "userinfo_encrypted_response_alg"
+	UserInfoEncryptedResponseAlg() string
+	// UserInfoEncryptedResponseEnc returns "userinfo_encrypted_response_enc"
+	UserInfoEncryptedResponseEnc() string
+}
+
 /***********************************
 	Store
  ***********************************/
@@ -31,6 +31,7 @@ const(
 	TokenTypeBearer = "bearer"
 	TokenTypeMac    = "mac"
 	TokenTypeBasic  = "basic"
+	TokenTypeDPoP   = "dpop"
 )
 
 func (t TokenType) HttpHeader() string {
@@ -39,6 +40,8 @@ func (t TokenType) HttpHeader() string {
 		return "MAC"
 	case TokenTypeBasic:
 		return "Basic"
+	case TokenTypeDPoP:
+		return "DPoP"
 	default:
 		return "Bearer"
 	}
@@ -182,6 +185,11 @@ func (t *DefaultAccessToken) SetValue(v string) *DefaultAccessToken {
 	return t
 }
 
+func (t *DefaultAccessToken) SetType(v TokenType) *DefaultAccessToken {
+	t.tokenType = v
+	return t
+}
+
 func (t *DefaultAccessToken) SetIssueTime(v time.Time) *DefaultAccessToken {
 	t.issueTime = v.UTC()
 	return t
@@ -0,0 +1,114 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dpop implements OAuth 2.0 Demonstrating Proof of Possession (DPoP) as specified in RFC 9449.
+//
+// Authorization server binds issued access tokens to the public key of a DPoP proof presented at token endpoint,
+// using JWK SHA-256 thumbprint as "cnf.jkt" claim. Resource servers require a valid DPoP proof signed by the
+// same key whenever a bound token is presented.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9449
+package dpop
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	netutil "github.com/cisco-open/go-lanai/pkg/utils/net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// HeaderDPoP is the HTTP header carrying DPoP proof JWT
+	HeaderDPoP = "DPoP"
+	// HeaderDPoPNonce is the HTTP header carrying server provided nonce
+	HeaderDPoPNonce = "DPoP-Nonce"
+	// AuthScheme is the "Authorization" header scheme used for DPoP-bound access tokens
+	AuthScheme = "DPoP"
+	// ProofType is the required "typ" header of DPoP proof JWT
+	ProofType = "dpop+jwt"
+)
+
+var (
+	// ErrInvalidProof is returned when DPoP proof is missing, malformed or doesn't match the request
+	ErrInvalidProof = errors.New("invalid DPoP proof")
+	// ErrNonceRequired is returned when DPoP proof doesn't carry a valid server provided nonce
+	ErrNonceRequired = errors.New("DPoP nonce required")
+)
+
+// AccessTokenHash calculates the "ath" claim of given access token:
+// base64url encoded SHA-256 hash of the ASCII encoding of the token value
+func AccessTokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// RequestURL returns the "htu" to expect for given request, i.e. the request URL without query and fragment.
+// Forwarded headers are ignored because any client can set them. Use TrustedRequestURL for services behind reverse proxies.
+func RequestURL(r *http.Request) string {
+	return TrustedRequestURL(r, nil)
+}
+
+// TrustedRequestURL is similar to RequestURL, but honors "X-Forwarded-Proto" and "X-Forwarded-Host" headers
+// when the immediate peer of the request is one of the trusted proxies.
+func TrustedRequestURL(r *http.Request, proxies netutil.TrustedProxies) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+	if len(proxies) != 0 && proxies.IsTrustedPeer(r) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); len(proto) != 0 {
+			scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+		}
+		if fwdHost := r.Header.Get("X-Forwarded-Host"); len(fwdHost) != 0 {
+			host = strings.TrimSpace(strings.Split(fwdHost, ",")[0])
+		}
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   r.URL.Path,
+	}
+	return u.String()
+}
+
+// normalizeHtu returns scheme, host and path of given URL for comparison. Query and fragment are ignored.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-4.3
+func normalizeHtu(v string) (string, error) {
+	u, e := url.Parse(v)
+	if e != nil {
+		return "", e
+	}
+	if len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return "", errors.New("URL is not absolute")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+	switch {
+	case scheme == "http" && strings.HasSuffix(host, ":80"):
+		host = strings.TrimSuffix(host, ":80")
+	case scheme == "https" && strings.HasSuffix(host, ":443"):
+		host = strings.TrimSuffix(host, ":443")
+	}
+	path := u.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+	return scheme + "://" + host + path, nil
+}
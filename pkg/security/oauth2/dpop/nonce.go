@@ -0,0 +1,89 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dpop
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+)
+
+const (
+	nonceMacLength = 16
+)
+
+// NonceSource issues and validates server provided nonce values.
+// When a NonceSource is configured, DPoP proofs are required to carry a valid "nonce" claim.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-8
+type NonceSource interface {
+	// Nonce returns a fresh nonce to be sent to client via HeaderDPoPNonce
+	Nonce(ctx context.Context) (string, error)
+	// Validate returns true if the given nonce was issued by this source and is still valid
+	Validate(ctx context.Context, nonce string) bool
+}
+
+// HmacNonceSource implements NonceSource. Issued nonce is the issue time authenticated by HMAC-SHA256.
+// It doesn't require any shared storage, but all instances of the same service need to share the same secret
+type HmacNonceSource struct {
+	secret   []byte
+	validity time.Duration
+}
+
+// NewHmacNonceSource create a HmacNonceSource with given secret and validity.
+// When the secret is empty, a random secret is generated, which is only suitable for single instance services
+func NewHmacNonceSource(secret []byte, validity time.Duration) *HmacNonceSource {
+	if len(secret) == 0 {
+		secret = make([]byte, sha256.Size)
+		if _, e := rand.Read(secret); e != nil {
+			panic(e)
+		}
+	}
+	return &HmacNonceSource{
+		secret:   secret,
+		validity: validity,
+	}
+}
+
+func (s *HmacNonceSource) Nonce(_ context.Context) (string, error) {
+	ts := make([]byte, 8)
+	binary.BigEndian.PutUint64(ts, uint64(time.Now().Unix()))
+	return base64.RawURLEncoding.EncodeToString(append(ts, s.mac(ts)...)), nil
+}
+
+func (s *HmacNonceSource) Validate(_ context.Context, nonce string) bool {
+	data, e := base64.RawURLEncoding.DecodeString(nonce)
+	if e != nil || len(data) != 8+nonceMacLength {
+		return false
+	}
+	ts, mac := data[:8], data[8:]
+	if !hmac.Equal(mac, s.mac(ts)) {
+		return false
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(ts)), 0)
+	now := time.Now()
+	return !issued.After(now) && now.Sub(issued) <= s.validity
+}
+
+func (s *HmacNonceSource) mac(ts []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(ts)
+	return h.Sum(nil)[:nonceMacLength]
+}
@@ -0,0 +1,268 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dpop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	netutil "github.com/cisco-open/go-lanai/pkg/utils/net"
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultProofValidity = 5 * time.Minute
+	defaultProofLeeway   = 30 * time.Second
+)
+
+/*********************
+	Proof
+ *********************/
+
+// ProofClaims is the payload of DPoP proof JWT.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-4.2
+type ProofClaims struct {
+	Id              string             `json:"jti"`
+	Method          string             `json:"htm"`
+	URI             string             `json:"htu"`
+	IssuedAt        *gojwt.NumericDate `json:"iat"`
+	AccessTokenHash string             `json:"ath,omitempty"`
+	Nonce           string             `json:"nonce,omitempty"`
+}
+
+// Valid implements gojwt.Claims. Claims are validated by ProofVerifier instead.
+func (c *ProofClaims) Valid() error {
+	return nil
+}
+
+// Proof is a verified DPoP proof
+type Proof struct {
+	Claims ProofClaims
+	// Jwk is the public key embedded in the proof's "jwk" header
+	Jwk jwt.Jwk
+	// Thumbprint is the JWK SHA-256 thumbprint of Jwk, used as "cnf.jkt" of bound access tokens
+	Thumbprint string
+}
+
+// ProofExpectation describes the request the DPoP proof is expected to be created for
+type ProofExpectation struct {
+	// Method is the expected "htm", e.g. http.MethodPost
+	Method string
+	// URL is the expected "htu". Query and fragment are ignored
+	URL string
+	// AccessToken is the access token presented with the proof. When set, the proof's "ath" is required to match
+	AccessToken string
+	// Thumbprint is the "cnf.jkt" of the presented access token. When set, the proof is required to be signed by that key
+	Thumbprint string
+}
+
+/*********************
+	Verifier
+ *********************/
+
+type VerifierOptions func(opt *VerifierOption)
+type VerifierOption struct {
+	// ReplayStore is used to reject re-used proofs. Optional, "jti" is not tracked when not set
+	ReplayStore ReplayStore
+	// NonceSource is used to require server provided nonce. Optional, "nonce" is not required when not set
+	NonceSource NonceSource
+	// Validity is the max allowed age of proofs, based on "iat"
+	Validity time.Duration
+	// Leeway is the allowed clock skew when validating "iat"
+	Leeway time.Duration
+	// Methods are acceptable signing methods. Only asymmetric methods are allowed by RFC 9449
+	Methods []gojwt.SigningMethod
+	// TrustedProxies are used by ProofVerifier.RequestURL. Forwarded headers are ignored when not set
+	TrustedProxies netutil.TrustedProxies
+}
+
+// WithProperties is a VerifierOptions that apply DPoPProperties.
+func WithProperties(props *DPoPProperties) VerifierOptions {
+	return func(opt *VerifierOption) {
+		opt.Validity = time.Duration(props.Validity)
+		opt.Leeway = time.Duration(props.Leeway)
+		// invalid values are rejected by BindDPoPProperties
+		opt.TrustedProxies, _ = netutil.ParseTrustedProxies(props.TrustedProxies...)
+		if props.Nonce.Enabled {
+			opt.NonceSource = NewHmacNonceSource([]byte(props.Nonce.Secret), time.Duration(props.Nonce.Validity))
+		}
+	}
+}
+
+// ProofVerifier verifies DPoP proofs: signature with embedded public key, "typ", "htm", "htu", "iat", "ath",
+// server provided nonce and "jti" replay.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-4.3
+type ProofVerifier struct {
+	replayStore    ReplayStore
+	nonceSource    NonceSource
+	validity       time.Duration
+	leeway         time.Duration
+	trustedProxies netutil.TrustedProxies
+	parser         *gojwt.Parser
+}
+
+func NewProofVerifier(opts ...VerifierOptions) *ProofVerifier {
+	opt := VerifierOption{
+		Validity: defaultProofValidity,
+		Leeway:   defaultProofLeeway,
+		Methods:  jwt.AsymmetricSigningMethods,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	methods := make([]string, len(opt.Methods))
+	for i := range opt.Methods {
+		methods[i] = opt.Methods[i].Alg()
+	}
+	return &ProofVerifier{
+		replayStore:    opt.ReplayStore,
+		nonceSource:    opt.NonceSource,
+		validity:       opt.Validity,
+		leeway:         opt.Leeway,
+		trustedProxies: opt.TrustedProxies,
+		parser:         gojwt.NewParser(gojwt.WithValidMethods(methods), gojwt.WithoutClaimsValidation()),
+	}
+}
+
+// RequestURL returns the "htu" to expect for given request, honoring forwarded headers of configured trusted proxies.
+// See TrustedRequestURL
+func (v *ProofVerifier) RequestURL(r *http.Request) string {
+	return TrustedRequestURL(r, v.trustedProxies)
+}
+
+// Verify verifies given DPoP proof against the expectation.
+// Returned error wraps ErrNonceRequired if a fresh nonce is required, or ErrInvalidProof otherwise
+func (v *ProofVerifier) Verify(ctx context.Context, proof string, expect ProofExpectation) (*Proof, error) {
+	if len(proof) == 0 {
+		return nil, fmt.Errorf("%w: proof is missing", ErrInvalidProof)
+	}
+
+	var jwk jwt.Jwk
+	claims := ProofClaims{}
+	_, e := v.parser.ParseWithClaims(proof, &claims, func(token *gojwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != ProofType {
+			return nil, fmt.Errorf(`"typ" header must be "%s"`, ProofType)
+		}
+		parsed, e := parseEmbeddedJwk(token.Header["jwk"])
+		if e != nil {
+			return nil, e
+		}
+		jwk = parsed
+		return jwk.Public(), nil
+	})
+	if e != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, e)
+	}
+
+	thumbprint, e := jwt.JwkThumbprint(jwk)
+	if e != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, e)
+	}
+	if e := v.validateClaims(ctx, &claims, &expect); e != nil {
+		return nil, e
+	}
+	if len(expect.Thumbprint) != 0 && expect.Thumbprint != thumbprint {
+		return nil, fmt.Errorf("%w: proof key doesn't match the access token's confirmation", ErrInvalidProof)
+	}
+	if e := v.markUsed(ctx, thumbprint, &claims); e != nil {
+		return nil, e
+	}
+	return &Proof{
+		Claims:     claims,
+		Jwk:        jwk,
+		Thumbprint: thumbprint,
+	}, nil
+}
+
+// Nonce returns a fresh nonce for HeaderDPoPNonce. Empty string is returned if nonce is not required
+func (v *ProofVerifier) Nonce(ctx context.Context) (string, error) {
+	if v.nonceSource == nil {
+		return "", nil
+	}
+	return v.nonceSource.Nonce(ctx)
+}
+
+func (v *ProofVerifier) validateClaims(ctx context.Context, claims *ProofClaims, expect *ProofExpectation) error {
+	now := time.Now()
+	switch {
+	case len(claims.Id) == 0:
+		return fmt.Errorf(`%w: "jti" is missing`, ErrInvalidProof)
+	case claims.Method != expect.Method:
+		return fmt.Errorf(`%w: "htm" doesn't match the request method`, ErrInvalidProof)
+	case !matchHtu(claims.URI, expect.URL):
+		return fmt.Errorf(`%w: "htu" doesn't match the request URL`, ErrInvalidProof)
+	case claims.IssuedAt == nil:
+		return fmt.Errorf(`%w: "iat" is missing`, ErrInvalidProof)
+	case claims.IssuedAt.After(now.Add(v.leeway)):
+		return fmt.Errorf(`%w: proof is issued in the future`, ErrInvalidProof)
+	case claims.IssuedAt.Add(v.validity + v.leeway).Before(now):
+		return fmt.Errorf(`%w: proof is expired`, ErrInvalidProof)
+	}
+
+	if len(expect.AccessToken) != 0 && claims.AccessTokenHash != AccessTokenHash(expect.AccessToken) {
+		return fmt.Errorf(`%w: "ath" doesn't match the access token`, ErrInvalidProof)
+	}
+
+	if v.nonceSource != nil && (len(claims.Nonce) == 0 || !v.nonceSource.Validate(ctx, claims.Nonce)) {
+		return fmt.Errorf(`%w: "nonce" is missing or expired`, ErrNonceRequired)
+	}
+	return nil
+}
+
+func (v *ProofVerifier) markUsed(ctx context.Context, thumbprint string, claims *ProofClaims) error {
+	if v.replayStore == nil {
+		return nil
+	}
+	switch ok, e := v.replayStore.MarkUsed(ctx, thumbprint, claims.Id, claims.IssuedAt.Add(v.validity+v.leeway)); {
+	case e != nil:
+		return fmt.Errorf("unable to verify DPoP proof: %v", e)
+	case !ok:
+		return fmt.Errorf("%w: proof is already used", ErrInvalidProof)
+	}
+	return nil
+}
+
+// parseEmbeddedJwk parses "jwk" header. The header is required to be a public key.
+func parseEmbeddedJwk(header interface{}) (jwt.Jwk, error) {
+	m, ok := header.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`"jwk" header is missing`)
+	}
+	if _, ok := m["d"]; ok {
+		return nil, fmt.Errorf(`"jwk" header must not contain private key`)
+	}
+	if kty, _ := m["kty"].(string); kty == jwt.JwkTypeOctet {
+		return nil, fmt.Errorf(`"jwk" header must be an asymmetric public key`)
+	}
+	data, e := json.Marshal(m)
+	if e != nil {
+		return nil, e
+	}
+	return jwt.ParseJwk(data)
+}
+
+func matchHtu(actual, expected string) bool {
+	a, e := normalizeHtu(actual)
+	if e != nil {
+		return false
+	}
+	b, e := normalizeHtu(expected)
+	return e == nil && a == b
+}
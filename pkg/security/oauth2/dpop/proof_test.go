@@ -0,0 +1,270 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dpop_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"github.com/cisco-open/go-lanai/test"
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"testing"
+	"time"
+)
+
+/*************************
+	Setup
+ *************************/
+
+const (
+	TestUrl         = `https://api.example.com/resource`
+	TestAccessToken = `dummy-access-token`
+)
+
+/*************************
+	Test
+ *************************/
+
+func TestProofVerifier(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestValidProof(NewTestSigner(jwtES256)), "ValidProof-ES256"),
+		test.GomegaSubTest(SubTestValidProof(NewTestSigner(jwtRS256)), "ValidProof-RS256"),
+		test.GomegaSubTest(SubTestMismatchedRequest(), "MismatchedRequest"),
+		test.GomegaSubTest(SubTestMismatchedToken(), "MismatchedToken"),
+		test.GomegaSubTest(SubTestExpiredProof(), "ExpiredProof"),
+		test.GomegaSubTest(SubTestReplayedProof(), "ReplayedProof"),
+		test.GomegaSubTest(SubTestNonce(), "Nonce"),
+		test.GomegaSubTest(SubTestMalformedProof(), "MalformedProof"),
+		test.GomegaSubTest(SubTestRequestURL(), "RequestURL"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestRequestURL() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		newRequest := func(remoteAddr string) *http.Request {
+			req, e := http.NewRequest(http.MethodGet, "http://internal.svc:8080/resource?q=ignored", nil)
+			g.Expect(e).To(Succeed(), "creating request should not fail")
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-Host", "api.example.com")
+			return req
+		}
+
+		// forwarded headers are ignored by default
+		g.Expect(dpop.RequestURL(newRequest("10.0.0.1:1234"))).To(Equal("http://internal.svc:8080/resource"),
+			"forwarded headers should be ignored without trusted proxies")
+		g.Expect(dpop.NewProofVerifier().RequestURL(newRequest("10.0.0.1:1234"))).To(Equal("http://internal.svc:8080/resource"),
+			"forwarded headers should be ignored without trusted proxies")
+
+		// forwarded headers are only honored from trusted proxies
+		verifier := dpop.NewProofVerifier(dpop.WithProperties(&dpop.DPoPProperties{
+			TrustedProxies: []string{"10.0.0.0/8"},
+		}))
+		g.Expect(verifier.RequestURL(newRequest("10.0.0.1:1234"))).To(Equal(TestUrl),
+			"forwarded headers should be honored from trusted proxy")
+		g.Expect(verifier.RequestURL(newRequest("192.168.0.1:1234"))).To(Equal("http://internal.svc:8080/resource"),
+			"forwarded headers should be ignored from untrusted peer")
+	}
+}
+
+func SubTestValidProof(signer *dpop.ProofSigner) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		verifier := dpop.NewProofVerifier()
+		proof := MustSign(g, signer, http.MethodGet, TestUrl+"?q=ignored#fragment", dpop.WithAccessToken(TestAccessToken))
+		verified, e := verifier.Verify(ctx, proof, dpop.ProofExpectation{
+			Method:      http.MethodGet,
+			URL:         TestUrl,
+			AccessToken: TestAccessToken,
+			Thumbprint:  signer.Thumbprint(),
+		})
+		g.Expect(e).To(Succeed(), "valid proof should be accepted")
+		g.Expect(verified.Thumbprint).To(Equal(signer.Thumbprint()), "verified proof should have correct thumbprint")
+		g.Expect(verified.Claims.Id).ToNot(BeEmpty(), "verified proof should have jti")
+	}
+}
+
+func SubTestMismatchedRequest() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		signer := NewTestSigner(jwtES256)
+		verifier := dpop.NewProofVerifier()
+		proof := MustSign(g, signer, http.MethodGet, TestUrl)
+		_, e := verifier.Verify(ctx, proof, dpop.ProofExpectation{Method: http.MethodPost, URL: TestUrl})
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "proof with wrong htm should be rejected")
+
+		proof = MustSign(g, signer, http.MethodGet, TestUrl)
+		_, e = verifier.Verify(ctx, proof, dpop.ProofExpectation{Method: http.MethodGet, URL: TestUrl + "/other"})
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "proof with wrong htu should be rejected")
+	}
+}
+
+func SubTestMismatchedToken() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		signer := NewTestSigner(jwtES256)
+		verifier := dpop.NewProofVerifier()
+		expect := dpop.ProofExpectation{
+			Method:      http.MethodGet,
+			URL:         TestUrl,
+			AccessToken: TestAccessToken,
+			Thumbprint:  signer.Thumbprint(),
+		}
+		proof := MustSign(g, signer, http.MethodGet, TestUrl)
+		_, e := verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "proof without ath should be rejected")
+
+		proof = MustSign(g, signer, http.MethodGet, TestUrl, dpop.WithAccessToken("another-token"))
+		_, e = verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "proof with wrong ath should be rejected")
+
+		another := NewTestSigner(jwtES256)
+		proof = MustSign(g, another, http.MethodGet, TestUrl, dpop.WithAccessToken(TestAccessToken))
+		_, e = verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "proof signed by another key should be rejected")
+	}
+}
+
+func SubTestExpiredProof() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		signer := NewTestSigner(jwtES256)
+		verifier := dpop.NewProofVerifier(func(opt *dpop.VerifierOption) {
+			opt.Validity = time.Minute
+			opt.Leeway = 0
+		})
+		proof := MustSign(g, signer, http.MethodGet, TestUrl, func(claims *dpop.ProofClaims) {
+			claims.IssuedAt = gojwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+		})
+		_, e := verifier.Verify(ctx, proof, dpop.ProofExpectation{Method: http.MethodGet, URL: TestUrl})
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "expired proof should be rejected")
+
+		proof = MustSign(g, signer, http.MethodGet, TestUrl, func(claims *dpop.ProofClaims) {
+			claims.IssuedAt = gojwt.NewNumericDate(time.Now().Add(time.Minute))
+		})
+		_, e = verifier.Verify(ctx, proof, dpop.ProofExpectation{Method: http.MethodGet, URL: TestUrl})
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "proof issued in the future should be rejected")
+	}
+}
+
+func SubTestReplayedProof() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		signer := NewTestSigner(jwtES256)
+		verifier := dpop.NewProofVerifier(func(opt *dpop.VerifierOption) {
+			opt.ReplayStore = dpop.NewInMemoryReplayStore()
+		})
+		expect := dpop.ProofExpectation{Method: http.MethodGet, URL: TestUrl}
+		proof := MustSign(g, signer, http.MethodGet, TestUrl)
+		_, e := verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(Succeed(), "proof should be accepted the first time")
+		_, e = verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "replayed proof should be rejected")
+	}
+}
+
+func SubTestNonce() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		signer := NewTestSigner(jwtES256)
+		verifier := dpop.NewProofVerifier(func(opt *dpop.VerifierOption) {
+			opt.NonceSource = dpop.NewHmacNonceSource(nil, time.Minute)
+		})
+		expect := dpop.ProofExpectation{Method: http.MethodGet, URL: TestUrl}
+		proof := MustSign(g, signer, http.MethodGet, TestUrl)
+		_, e := verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(MatchError(dpop.ErrNonceRequired), "proof without nonce should be rejected")
+
+		proof = MustSign(g, signer, http.MethodGet, TestUrl, dpop.WithNonce("invalid-nonce"))
+		_, e = verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(MatchError(dpop.ErrNonceRequired), "proof with invalid nonce should be rejected")
+
+		nonce, e := verifier.Nonce(ctx)
+		g.Expect(e).To(Succeed(), "issuing nonce should not fail")
+		proof = MustSign(g, signer, http.MethodGet, TestUrl, dpop.WithNonce(nonce))
+		_, e = verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(Succeed(), "proof with valid nonce should be accepted")
+	}
+}
+
+func SubTestMalformedProof() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		verifier := dpop.NewProofVerifier()
+		expect := dpop.ProofExpectation{Method: http.MethodGet, URL: TestUrl}
+		_, e := verifier.Verify(ctx, "", expect)
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "missing proof should be rejected")
+
+		_, e = verifier.Verify(ctx, "not-a-jwt", expect)
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "malformed proof should be rejected")
+
+		// regular JWT without "typ" and "jwk" headers
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		token := gojwt.NewWithClaims(gojwt.SigningMethodES256, &dpop.ProofClaims{
+			Id:       "dummy-jti",
+			Method:   http.MethodGet,
+			URI:      TestUrl,
+			IssuedAt: gojwt.NewNumericDate(time.Now()),
+		})
+		proof, e := token.SignedString(key)
+		g.Expect(e).To(Succeed(), "signing JWT should not fail")
+		_, e = verifier.Verify(ctx, proof, expect)
+		g.Expect(e).To(MatchError(dpop.ErrInvalidProof), "JWT without DPoP headers should be rejected")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+const (
+	jwtES256 = "ES256"
+	jwtRS256 = "RS256"
+)
+
+func NewTestSigner(alg string) *dpop.ProofSigner {
+	var jwk jwt.PrivateJwk
+	switch alg {
+	case jwtRS256:
+		key, e := rsa.GenerateKey(rand.Reader, 2048)
+		if e != nil {
+			panic(e)
+		}
+		jwk = jwt.NewPrivateJwk("", "", key)
+	default:
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if e != nil {
+			panic(e)
+		}
+		jwk = jwt.NewPrivateJwk("", "", key)
+	}
+	signer, e := dpop.NewProofSigner(jwk)
+	if e != nil {
+		panic(e)
+	}
+	return signer
+}
+
+func MustSign(g *gomega.WithT, signer *dpop.ProofSigner, method, url string, opts ...dpop.ProofClaimsOptions) string {
+	proof, e := signer.Sign(method, url, opts...)
+	g.Expect(e).To(Succeed(), "signing DPoP proof should not fail")
+	return proof
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dpop
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	netutil "github.com/cisco-open/go-lanai/pkg/utils/net"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const PropertiesPrefix = "security.dpop"
+
+type DPoPProperties struct {
+	// Validity is the max allowed age of DPoP proofs
+	Validity utils.Duration `json:"validity"`
+	// Leeway is the allowed clock skew when validating DPoP proofs
+	Leeway utils.Duration `json:"leeway"`
+	// DbIndex is the Redis DB used to track used DPoP proofs
+	DbIndex int `json:"db-index"`
+	// Nonce configures server provided nonce
+	Nonce NonceProperties `json:"nonce"`
+	// TrustedProxies are IP addresses or CIDRs of reverse proxies, e.g. "10.0.0.0/8".
+	// "X-Forwarded-Proto" and "X-Forwarded-Host" are honored when building expected "htu" only if the request is sent
+	// by a trusted proxy. See TrustedRequestURL
+	TrustedProxies []string `json:"trusted-proxies"`
+}
+
+type NonceProperties struct {
+	Enabled bool `json:"enabled"`
+	// Secret is used to authenticate issued nonce. All instances of the same service should share the same secret.
+	// A random secret is used when not set
+	Secret   string         `json:"secret"`
+	Validity utils.Duration `json:"validity"`
+}
+
+// NewDPoPProperties create a DPoPProperties with default values
+func NewDPoPProperties() *DPoPProperties {
+	return &DPoPProperties{
+		Validity: utils.Duration(defaultProofValidity),
+		Leeway:   utils.Duration(defaultProofLeeway),
+		Nonce: NonceProperties{
+			Validity: utils.Duration(5 * time.Minute),
+		},
+	}
+}
+
+// BindDPoPProperties create and bind DPoPProperties, with a optional prefix
+func BindDPoPProperties(ctx *bootstrap.ApplicationContext) DPoPProperties {
+	props := NewDPoPProperties()
+	if err := ctx.Config().Bind(props, PropertiesPrefix); err != nil {
+		panic(errors.Wrap(err, "failed to bind DPoPProperties"))
+	}
+	if _, err := netutil.ParseTrustedProxies(props.TrustedProxies...); err != nil {
+		panic(errors.Wrap(err, "invalid DPoPProperties"))
+	}
+	return *props
+}
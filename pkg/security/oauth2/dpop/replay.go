@@ -0,0 +1,101 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dpop
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/redis"
+	"sync"
+	"time"
+)
+
+const (
+	redisKeyPrefix = "DPOP"
+)
+
+// ReplayStore keeps track of used DPoP proofs, so that each proof can only be used once.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-11.1
+type ReplayStore interface {
+	// MarkUsed records the "jti" of proofs signed by the key of given thumbprint until expireAt.
+	// It returns false if the same "jti" was already used with the same key
+	MarkUsed(ctx context.Context, jkt, jti string, expireAt time.Time) (bool, error)
+}
+
+// InMemoryReplayStore implements ReplayStore. It's suitable for single instance services and tests
+type InMemoryReplayStore struct {
+	mtx  sync.Mutex
+	used map[string]time.Time
+}
+
+func NewInMemoryReplayStore() *InMemoryReplayStore {
+	return &InMemoryReplayStore{
+		used: map[string]time.Time{},
+	}
+}
+
+func (s *InMemoryReplayStore) MarkUsed(_ context.Context, jkt, jti string, expireAt time.Time) (bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := time.Now()
+	for k, exp := range s.used {
+		if now.After(exp) {
+			delete(s.used, k)
+		}
+	}
+	key := jkt + ":" + jti
+	if _, ok := s.used[key]; ok {
+		return false, nil
+	}
+	s.used[key] = expireAt
+	return true, nil
+}
+
+// RedisReplayStore implements ReplayStore using Redis
+type RedisReplayStore struct {
+	redisClient redis.Client
+}
+
+func NewRedisReplayStore(ctx context.Context, cf redis.ClientFactory, dbIndex int) *RedisReplayStore {
+	client, e := cf.New(ctx, func(opt *redis.ClientOption) {
+		opt.DbIndex = dbIndex
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	return &RedisReplayStore{
+		redisClient: client,
+	}
+}
+
+func (s *RedisReplayStore) MarkUsed(ctx context.Context, jkt, jti string, expireAt time.Time) (bool, error) {
+	ttl := time.Until(expireAt)
+	if ttl <= 0 {
+		// expired proof is rejected anyway, nothing to record
+		return true, nil
+	}
+	cmd := s.redisClient.SetNX(ctx, s.redisKey(jkt, jti), expireAt.Unix(), ttl)
+	if cmd.Err() != nil {
+		return false, cmd.Err()
+	}
+	return cmd.Val(), nil
+}
+
+func (s *RedisReplayStore) redisKey(jkt, jti string) string {
+	return fmt.Sprintf("%s:%s:%s", redisKeyPrefix, jkt, jti)
+}
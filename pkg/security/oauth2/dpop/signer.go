@@ -0,0 +1,133 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dpop
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"time"
+)
+
+type ProofClaimsOptions func(claims *ProofClaims)
+
+// WithAccessToken is a ProofClaimsOptions that set "ath" for given access token
+func WithAccessToken(token string) ProofClaimsOptions {
+	return func(claims *ProofClaims) {
+		if len(token) != 0 {
+			claims.AccessTokenHash = AccessTokenHash(token)
+		}
+	}
+}
+
+// WithNonce is a ProofClaimsOptions that set server provided "nonce"
+func WithNonce(nonce string) ProofClaimsOptions {
+	return func(claims *ProofClaims) {
+		claims.Nonce = nonce
+	}
+}
+
+// ProofSigner creates DPoP proofs with a client's private key.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-4
+type ProofSigner struct {
+	key        jwt.PrivateJwk
+	method     gojwt.SigningMethod
+	jwkHeader  map[string]interface{}
+	thumbprint string
+}
+
+// NewProofSigner create a ProofSigner using given asymmetric private key.
+// Signing method is resolved from the key type, e.g. ES256 for P-256 EC keys and RS256 for RSA keys
+func NewProofSigner(key jwt.PrivateJwk) (*ProofSigner, error) {
+	method, e := resolveSigningMethod(key)
+	if e != nil {
+		return nil, e
+	}
+	thumbprint, e := jwt.JwkThumbprint(key)
+	if e != nil {
+		return nil, e
+	}
+	// public key only, without "kid"
+	data, e := json.Marshal(jwt.NewJwk("", "", key.Public()))
+	if e != nil {
+		return nil, e
+	}
+	header := map[string]interface{}{}
+	if e := json.Unmarshal(data, &header); e != nil {
+		return nil, e
+	}
+	delete(header, "kid")
+	return &ProofSigner{
+		key:        key,
+		method:     method,
+		jwkHeader:  header,
+		thumbprint: thumbprint,
+	}, nil
+}
+
+// Thumbprint returns JWK SHA-256 thumbprint of the signer's key, i.e. expected "cnf.jkt" of bound tokens
+func (s *ProofSigner) Thumbprint() string {
+	return s.thumbprint
+}
+
+// Sign creates a DPoP proof for given HTTP method and URL.
+// Query and fragment of the URL are not included in "htu"
+func (s *ProofSigner) Sign(method, url string, opts ...ProofClaimsOptions) (string, error) {
+	htu, e := normalizeHtu(url)
+	if e != nil {
+		return "", e
+	}
+	claims := ProofClaims{
+		Id:       uuid.New().String(),
+		Method:   method,
+		URI:      htu,
+		IssuedAt: gojwt.NewNumericDate(time.Now()),
+	}
+	for _, fn := range opts {
+		fn(&claims)
+	}
+	token := gojwt.NewWithClaims(s.method, &claims)
+	token.Header["typ"] = ProofType
+	token.Header["jwk"] = s.jwkHeader
+	return token.SignedString(s.key.Private())
+}
+
+func resolveSigningMethod(key jwt.PrivateJwk) (gojwt.SigningMethod, error) {
+	switch v := key.Private().(type) {
+	case *rsa.PrivateKey:
+		return gojwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch v.Curve.Params().BitSize {
+		case 256:
+			return gojwt.SigningMethodES256, nil
+		case 384:
+			return gojwt.SigningMethodES384, nil
+		case 521:
+			return gojwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf(`unsupported EC curve for DPoP proof: %s`, v.Curve.Params().Name)
+	case ed25519.PrivateKey:
+		return gojwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf(`DPoP proof requires asymmetric private key, but got %T`, v)
+	}
+}
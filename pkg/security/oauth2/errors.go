@@ -58,6 +58,7 @@ const (
 	ErrorCodeInvalidRedirectUri
 	ErrorCodeAccessRejected
 	ErrorCodeOpenIDExt
+	ErrorCodeInvalidRequestUri
 )
 
 // ErrorSubTypeCodeOAuth2Grant
@@ -70,6 +71,13 @@ const (
 	ErrorCodeInvalidScope
 	ErrorCodeUnsupportedTokenType
 	ErrorCodeGeneric
+	ErrorCodeAuthorizationPending
+	ErrorCodeSlowDown
+	ErrorCodeExpiredToken
+	ErrorCodeDeviceAccessDenied
+	ErrorCodeInvalidTarget
+	ErrorCodeInvalidDPoPProof
+	ErrorCodeUseDPoPNonce
 )
 
 // ErrorSubTypeCodeOAuth2Res
@@ -78,6 +86,8 @@ const (
 	ErrorCodeInvalidAccessToken
 	ErrorCodeInsufficientScope
 	ErrorCodeResourceServerGeneral // this should only be used for error deserialization
+	ErrorCodeDPoPProofRejected
+	ErrorCodeDPoPNonceRequired
 )
 
 // ErrorTypes, can be used in errors.Is
@@ -120,6 +130,18 @@ const (
 	// https://tools.ietf.org/html/rfc7009#section-4.1.1
 	ErrorTranslationUnsupportedTokenType = "unsupported_token_type"
 
+	// https://tools.ietf.org/html/rfc8628#section-3.5
+	ErrorTranslationAuthorizationPending = "authorization_pending"
+	ErrorTranslationSlowDown             = "slow_down"
+	ErrorTranslationExpiredToken         = "expired_token"
+
+	// https://datatracker.ietf.org/doc/html/rfc8693#section-2.2.2
+	ErrorTranslationInvalidTarget = "invalid_target"
+
+	// https://datatracker.ietf.org/doc/html/rfc9449#section-12.2
+	ErrorTranslationInvalidDPoPProof = "invalid_dpop_proof"
+	ErrorTranslationUseDPoPNonce     = "use_dpop_nonce"
+
 	// https://openid.net/specs/openid-connect-core-1_0.html#AuthError
 	ErrorTranslationInteractionRequired     = "interaction_required"
 	ErrorTranslationLoginRequired           = "login_required"
@@ -323,6 +345,48 @@ func NewGenericError(value interface{}, causes ...interface{}) error {
 		causes...)
 }
 
+func NewAuthorizationPendingError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeAuthorizationPending, value,
+		ErrorTranslationAuthorizationPending, http.StatusBadRequest,
+		causes...)
+}
+
+func NewSlowDownError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeSlowDown, value,
+		ErrorTranslationSlowDown, http.StatusBadRequest,
+		causes...)
+}
+
+func NewExpiredTokenError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeExpiredToken, value,
+		ErrorTranslationExpiredToken, http.StatusBadRequest,
+		causes...)
+}
+
+func NewDeviceAccessDeniedError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeDeviceAccessDenied, value,
+		ErrorTranslationAccessDenied, http.StatusBadRequest,
+		causes...)
+}
+
+func NewInvalidTargetError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeInvalidTarget, value,
+		ErrorTranslationInvalidTarget, http.StatusBadRequest,
+		causes...)
+}
+
+func NewInvalidDPoPProofError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeInvalidDPoPProof, value,
+		ErrorTranslationInvalidDPoPProof, http.StatusBadRequest,
+		causes...)
+}
+
+func NewUseDPoPNonceError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeUseDPoPNonce, value,
+		ErrorTranslationUseDPoPNonce, http.StatusBadRequest,
+		causes...)
+}
+
 func NewInvalidAuthorizeRequestError(value interface{}, causes ...interface{}) error {
 	return NewOAuth2Error(ErrorCodeInvalidAuthorizeRequest, value,
 		ErrorTranslationInvalidRequest, http.StatusBadRequest,
@@ -347,6 +411,12 @@ func NewAccessRejectedError(value interface{}, causes ...interface{}) error {
 		causes...)
 }
 
+func NewInvalidRequestUriError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeInvalidRequestUri, value,
+		ErrorTranslationInvalidRequestURI, http.StatusBadRequest,
+		causes...)
+}
+
 /* OAuth2Res family */
 
 func NewInvalidAccessTokenError(value interface{}, causes ...interface{}) error {
@@ -360,3 +430,15 @@ func NewInsufficientScopeError(value interface{}, causes ...interface{}) error {
 		ErrorTranslationInsufficientScope, http.StatusForbidden,
 		causes...)
 }
+
+func NewDPoPProofRejectedError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeDPoPProofRejected, value,
+		ErrorTranslationInvalidDPoPProof, http.StatusUnauthorized,
+		causes...)
+}
+
+func NewDPoPNonceRequiredError(value interface{}, causes ...interface{}) error {
+	return NewOAuth2Error(ErrorCodeDPoPNonceRequired, value,
+		ErrorTranslationUseDPoPNonce, http.StatusUnauthorized,
+		causes...)
+}
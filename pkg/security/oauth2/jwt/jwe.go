@@ -0,0 +1,377 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/go-jose/go-jose/v3"
+	"strings"
+)
+
+const (
+	// JweAlgRsaOaep is key management algorithm "RSA-OAEP". See RFC7518 Section 4.3
+	JweAlgRsaOaep = string(jose.RSA_OAEP)
+	// JweAlgRsaOaep256 is key management algorithm "RSA-OAEP-256". See RFC7518 Section 4.3
+	JweAlgRsaOaep256 = string(jose.RSA_OAEP_256)
+	// JweAlgEcdhEs is key management algorithm "ECDH-ES" (direct key agreement). See RFC7518 Section 4.6
+	JweAlgEcdhEs = string(jose.ECDH_ES)
+	// JweAlgEcdhEsA256KW is key management algorithm "ECDH-ES+A256KW". See RFC7518 Section 4.6
+	JweAlgEcdhEsA256KW = string(jose.ECDH_ES_A256KW)
+
+	// JweEncA128CBCHS256 is content encryption algorithm "A128CBC-HS256". See RFC7518 Section 5.2
+	JweEncA128CBCHS256 = string(jose.A128CBC_HS256)
+	// JweEncA256CBCHS512 is content encryption algorithm "A256CBC-HS512". See RFC7518 Section 5.2
+	JweEncA256CBCHS512 = string(jose.A256CBC_HS512)
+	// JweEncA128GCM is content encryption algorithm "A128GCM". See RFC7518 Section 5.3
+	JweEncA128GCM = string(jose.A128GCM)
+	// JweEncA256GCM is content encryption algorithm "A256GCM". See RFC7518 Section 5.3
+	JweEncA256GCM = string(jose.A256GCM)
+)
+
+var (
+	SupportedJweAlgorithms  = []string{JweAlgRsaOaep, JweAlgRsaOaep256, JweAlgEcdhEs, JweAlgEcdhEsA256KW}
+	SupportedJweEncryptions = []string{JweEncA128CBCHS256, JweEncA256CBCHS512, JweEncA128GCM, JweEncA256GCM}
+)
+
+/*********************
+	Encoder
+ *********************/
+
+type EncryptionOptions func(opt *EncryptionOption)
+type EncryptionOption struct {
+	// JwkStore provides the recipient's public keys
+	JwkStore JwkStore
+	// JwkName is the name of key to use. When empty, the first key compatible with Algorithm is used.
+	JwkName string
+	// Algorithm is the key management algorithm ("alg" header)
+	Algorithm string
+	// Encryption is the content encryption algorithm ("enc" header)
+	Encryption string
+	// Signer is used for nested JWT. When set, claims are signed first, then encrypted ("cty" is "JWT").
+	// When nil, claims are encrypted as JSON directly.
+	Signer JwtEncoder
+}
+
+// EncryptWithJwkStore is an EncryptionOptions that set JwkStore and key name of the recipient.
+func EncryptWithJwkStore(store JwkStore, jwkName string) EncryptionOptions {
+	return func(opt *EncryptionOption) {
+		opt.JwkStore = store
+		opt.JwkName = jwkName
+	}
+}
+
+// EncryptWithAlgorithms is an EncryptionOptions that set key management algorithm ("alg") and
+// content encryption algorithm ("enc"). Empty values are ignored.
+func EncryptWithAlgorithms(alg, enc string) EncryptionOptions {
+	return func(opt *EncryptionOption) {
+		if len(alg) != 0 {
+			opt.Algorithm = alg
+		}
+		if len(enc) != 0 {
+			opt.Encryption = enc
+		}
+	}
+}
+
+// EncryptSignedWith is an EncryptionOptions that enables nested JWT (sign-then-encrypt) with given signing JwtEncoder.
+// See RFC7519 Section 5.2
+func EncryptSignedWith(signer JwtEncoder) EncryptionOptions {
+	return func(opt *EncryptionOption) {
+		opt.Signer = signer
+	}
+}
+
+// NewEncryptedJwtEncoder create a JwtEncoder that encrypt JWT as JWE in compact serialization.
+// By default, "RSA-OAEP" and "A256GCM" are used.
+func NewEncryptedJwtEncoder(opts ...EncryptionOptions) *EncryptedJwtEncoder {
+	opt := EncryptionOption{
+		Algorithm:  JweAlgRsaOaep,
+		Encryption: JweEncA256GCM,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &EncryptedJwtEncoder{
+		jwkName:  opt.JwkName,
+		jwkStore: opt.JwkStore,
+		alg:      opt.Algorithm,
+		enc:      opt.Encryption,
+		signer:   opt.Signer,
+	}
+}
+
+// EncryptedJwtEncoder implements JwtEncoder. It encrypts claims (or signed JWT) using recipient's public key
+type EncryptedJwtEncoder struct {
+	jwkName  string
+	jwkStore JwkStore
+	alg      string
+	enc      string
+	signer   JwtEncoder
+}
+
+func (enc *EncryptedJwtEncoder) Encode(ctx context.Context, claims interface{}) (string, error) {
+	jwk, e := enc.findJwk(ctx)
+	if e != nil {
+		return "", e
+	}
+
+	var payload []byte
+	encOpts := &jose.EncrypterOptions{}
+	if enc.signer != nil {
+		signed, e := enc.signer.Encode(ctx, claims)
+		if e != nil {
+			return "", e
+		}
+		payload = []byte(signed)
+		encOpts = encOpts.WithContentType("JWT")
+	} else {
+		if payload, e = json.Marshal(claims); e != nil {
+			return "", e
+		}
+		encOpts = encOpts.WithType("JWT")
+	}
+
+	rcpt := jose.Recipient{
+		Algorithm: jose.KeyAlgorithm(enc.alg),
+		Key:       jwk.Public(),
+	}
+	// same convention as SignedJwtEncoder: set "kid" unless it's agreed out of band via name
+	if jwk.Id() != enc.jwkName {
+		rcpt.KeyID = jwk.Id()
+	}
+	encrypter, e := jose.NewEncrypter(jose.ContentEncryption(enc.enc), rcpt, encOpts)
+	if e != nil {
+		return "", fmt.Errorf("unable to encrypt JWT with alg=%s, enc=%s: %v", enc.alg, enc.enc, e)
+	}
+	obj, e := encrypter.Encrypt(payload)
+	if e != nil {
+		return "", e
+	}
+	return obj.CompactSerialize()
+}
+
+func (enc *EncryptedJwtEncoder) findJwk(ctx context.Context) (Jwk, error) {
+	if enc.jwkStore == nil {
+		return nil, fmt.Errorf("JWK store is not available for JWT encryption")
+	}
+	if len(enc.jwkName) != 0 {
+		return enc.jwkStore.LoadByName(ctx, enc.jwkName)
+	}
+	jwks, e := enc.jwkStore.LoadAll(ctx)
+	if e != nil {
+		return nil, e
+	}
+	// keys intended for encryption are preferred, keys without "use" are the fallback. Other keys are never used
+	var fallback Jwk
+	for _, jwk := range jwks {
+		if !isJweCompatibleKey(enc.alg, jwk.Public()) {
+			continue
+		}
+		switch jwkUse(jwk) {
+		case JwkUseEncryption:
+			return jwk, nil
+		case "":
+			if fallback == nil {
+				fallback = jwk
+			}
+		}
+	}
+	if fallback != nil {
+		return fallback, nil
+	}
+	return nil, fmt.Errorf("unable to find JWK compatible with JWE algorithm [%s]", enc.alg)
+}
+
+/*********************
+	Decoder
+ *********************/
+
+type DecryptionOptions func(opt *DecryptionOption)
+type DecryptionOption struct {
+	// JwkStore provides private keys for decryption
+	JwkStore JwkStore
+	// JwkName is the fallback key name when JWE doesn't have "kid" header
+	JwkName string
+	// Algorithms are allowed key management algorithms ("alg" header)
+	Algorithms []string
+	// Encryptions are allowed content encryption algorithms ("enc" header)
+	Encryptions []string
+	// Verifier is used to verify nested JWT. Nested JWT is rejected if not set.
+	Verifier JwtDecoder
+}
+
+// DecryptWithJwkStore is a DecryptionOptions that set JwkStore and default key name to use when decrypting.
+// the provided key name is used as fallback if the to-be-decrypted JWE doesn't have "kid" in header
+func DecryptWithJwkStore(store JwkStore, jwkName string) DecryptionOptions {
+	return func(opt *DecryptionOption) {
+		opt.JwkStore = store
+		opt.JwkName = jwkName
+	}
+}
+
+// DecryptWithAlgorithms is a DecryptionOptions that specify allowed "alg" and "enc". Nil slices are ignored.
+func DecryptWithAlgorithms(algs []string, encs []string) DecryptionOptions {
+	return func(opt *DecryptionOption) {
+		if algs != nil {
+			opt.Algorithms = algs
+		}
+		if encs != nil {
+			opt.Encryptions = encs
+		}
+	}
+}
+
+// DecryptAndVerifyWith is a DecryptionOptions that enables nested JWT (sign-then-encrypt) with given JwtDecoder
+func DecryptAndVerifyWith(verifier JwtDecoder) DecryptionOptions {
+	return func(opt *DecryptionOption) {
+		opt.Verifier = verifier
+	}
+}
+
+func NewEncryptedJwtDecoder(opts ...DecryptionOptions) *EncryptedJwtDecoder {
+	opt := DecryptionOption{
+		Algorithms:  SupportedJweAlgorithms,
+		Encryptions: SupportedJweEncryptions,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &EncryptedJwtDecoder{
+		jwkName:  opt.JwkName,
+		jwkStore: opt.JwkStore,
+		algs:     opt.Algorithms,
+		encs:     opt.Encryptions,
+		verifier: opt.Verifier,
+	}
+}
+
+// EncryptedJwtDecoder implements JwtDecoder. It decrypts JWE in compact serialization,
+// and verifies the nested JWT if applicable.
+type EncryptedJwtDecoder struct {
+	jwkName  string
+	jwkStore JwkStore
+	algs     []string
+	encs     []string
+	verifier JwtDecoder
+}
+
+func (dec *EncryptedJwtDecoder) Decode(ctx context.Context, tokenString string) (oauth2.Claims, error) {
+	claims := oauth2.MapClaims{}
+	if e := dec.DecodeWithClaims(ctx, tokenString, &claims); e != nil {
+		return nil, e
+	}
+	return claims, nil
+}
+
+func (dec *EncryptedJwtDecoder) DecodeWithClaims(ctx context.Context, tokenString string, claims interface{}) error {
+	if !IsJwe(tokenString) {
+		return fmt.Errorf("token is not a JWE in compact serialization")
+	}
+	obj, e := jose.ParseEncrypted(tokenString)
+	if e != nil {
+		return fmt.Errorf("malformed JWE: %v", e)
+	}
+
+	alg := obj.Header.Algorithm
+	enc, _ := obj.Header.ExtraHeaders["enc"].(string)
+	switch {
+	case !containsString(dec.algs, alg):
+		return fmt.Errorf(`JWE "alg" [%s] is not allowed`, alg)
+	case !containsString(dec.encs, enc):
+		return fmt.Errorf(`JWE "enc" [%s] is not allowed`, enc)
+	}
+
+	jwk, e := dec.findJwk(ctx, obj.Header.KeyID)
+	if e != nil {
+		return e
+	}
+	payload, e := obj.Decrypt(jwk.Private())
+	if e != nil {
+		return fmt.Errorf("unable to decrypt JWE: %v", e)
+	}
+
+	cty, _ := obj.Header.ExtraHeaders[jose.HeaderContentType].(string)
+	if strings.EqualFold(cty, "JWT") {
+		if dec.verifier == nil {
+			return fmt.Errorf("nested JWT is not supported")
+		}
+		return dec.verifier.DecodeWithClaims(ctx, string(payload), claims)
+	}
+	return json.Unmarshal(payload, claims)
+}
+
+func (dec *EncryptedJwtDecoder) findJwk(ctx context.Context, kid string) (PrivateJwk, error) {
+	if dec.jwkStore == nil {
+		return nil, fmt.Errorf("JWK store is not available for JWT decryption")
+	}
+	var jwk Jwk
+	var e error
+	if len(kid) != 0 {
+		jwk, e = dec.jwkStore.LoadByKid(ctx, kid)
+	} else {
+		jwk, e = dec.jwkStore.LoadByName(ctx, dec.jwkName)
+	}
+	if e != nil {
+		return nil, fmt.Errorf("failed to fetch JWK with kid [%s]: %v", kid, e)
+	}
+	private, ok := jwk.(PrivateJwk)
+	if !ok {
+		return nil, fmt.Errorf("JWK with kid [%s] doesn't have private key", jwk.Id())
+	}
+	return private, nil
+}
+
+/*********************
+	Helpers
+ *********************/
+
+// IsJwe returns true if given value looks like a JWE in compact serialization (5 segments)
+func IsJwe(value string) bool {
+	return strings.Count(value, ".") == 4
+}
+
+func isJweCompatibleKey(alg string, key interface{}) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return alg == JweAlgRsaOaep || alg == JweAlgRsaOaep256
+	case *ecdsa.PublicKey:
+		return alg == JweAlgEcdhEs || alg == JweAlgEcdhEsA256KW
+	default:
+		return false
+	}
+}
+
+func jwkUse(jwk Jwk) string {
+	if usage, ok := jwk.(JwkUsage); ok {
+		return usage.Use()
+	}
+	return ""
+}
+
+func containsString(values []string, v string) bool {
+	for i := range values {
+		if values[i] == v {
+			return true
+		}
+	}
+	return false
+}
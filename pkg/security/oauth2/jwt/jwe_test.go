@@ -0,0 +1,200 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/golang-jwt/jwt/v4"
+	. "github.com/onsi/gomega"
+	"strings"
+	"testing"
+)
+
+const (
+	testJweRsaKid = "enc-rsa"
+	testJweEcKid  = "enc-ec"
+)
+
+/*************************
+	Test Cases
+ *************************/
+
+func TestJwe(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	privateStore := NewJwkSetStore(
+		NewPrivateJwk(testJweRsaKid, testJweRsaKid, rsaKey),
+		NewPrivateJwk(testJweEcKid, testJweEcKid, ecKey),
+	)
+	publicStore := NewJwkSetStore(
+		NewJwk(testJweRsaKid, testJweRsaKid, rsaKey.Public()),
+		NewJwk(testJweEcKid, testJweEcKid, ecKey.Public()),
+	)
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestJweRoundTrip(publicStore, privateStore, JweAlgRsaOaep, JweEncA256GCM, testJweRsaKid), "RSA-OAEP"),
+		test.GomegaSubTest(SubTestJweRoundTrip(publicStore, privateStore, JweAlgRsaOaep256, JweEncA128GCM, testJweRsaKid), "RSA-OAEP-256"),
+		test.GomegaSubTest(SubTestJweRoundTrip(publicStore, privateStore, JweAlgEcdhEs, JweEncA256GCM, testJweEcKid), "ECDH-ES"),
+		test.GomegaSubTest(SubTestJweRoundTrip(publicStore, privateStore, JweAlgEcdhEsA256KW, JweEncA256GCM, testJweEcKid), "ECDH-ES+A256KW"),
+		test.GomegaSubTest(SubTestNestedJwe(publicStore, privateStore), "NestedJwt"),
+		test.GomegaSubTest(SubTestJweWithDisallowedAlg(publicStore, privateStore), "DisallowedAlg"),
+		test.GomegaSubTest(SubTestJweWithWrongKey(publicStore), "WrongKey"),
+		test.GomegaSubTest(SubTestJweKeyUsage(), "KeyUsage"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestJweRoundTrip(pub, priv JwkStore, alg, enc, expectedKid string) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		encoder := NewEncryptedJwtEncoder(EncryptWithJwkStore(pub, ""), EncryptWithAlgorithms(alg, enc))
+		value, e := encoder.Encode(ctx, claims)
+		g.Expect(e).To(Succeed(), "encrypting should not fail")
+		g.Expect(IsJwe(value)).To(BeTrue(), "encrypted value should be JWE")
+
+		decoder := NewEncryptedJwtDecoder(DecryptWithJwkStore(priv, ""))
+		decoded, e := decoder.Decode(ctx, value)
+		g.Expect(e).To(Succeed(), "decrypting should not fail")
+		g.Expect(decoded.Get("sub")).To(Equal(claims["sub"]), "decrypted claims should be correct")
+		g.Expect(decoded.Get("iss")).To(Equal(claims["iss"]), "decrypted claims should be correct")
+
+		// selected key should be compatible with alg
+		obj := mustParseJweHeader(g, value)
+		g.Expect(obj).To(HaveKeyWithValue("kid", expectedKid), "JWE should have correct kid")
+		g.Expect(obj).To(HaveKeyWithValue("alg", alg), "JWE should have correct alg")
+		g.Expect(obj).To(HaveKeyWithValue("enc", enc), "JWE should have correct enc")
+	}
+}
+
+func SubTestNestedJwe(pub, priv JwkStore) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		signingStore := NewSingleJwkStoreWithOptions(func(s *SingleJwkStore) {
+			s.Kid = testDefaultKid
+		})
+		signer := NewSignedJwtEncoder(SignWithJwkStore(signingStore, testDefaultKid), SignWithMethod(jwt.SigningMethodRS256))
+		encoder := NewEncryptedJwtEncoder(
+			EncryptWithJwkStore(pub, testJweRsaKid),
+			EncryptWithAlgorithms(JweAlgRsaOaep256, JweEncA256GCM),
+			EncryptSignedWith(signer),
+		)
+		value, e := encoder.Encode(ctx, claims)
+		g.Expect(e).To(Succeed(), "encrypting nested JWT should not fail")
+		g.Expect(mustParseJweHeader(g, value)).To(HaveKeyWithValue("cty", "JWT"), "nested JWE should have cty header")
+		g.Expect(mustParseJweHeader(g, value)).ToNot(HaveKey("kid"), "JWE should not have kid when key is selected by name")
+
+		// without verifier
+		decoder := NewEncryptedJwtDecoder(DecryptWithJwkStore(priv, testJweRsaKid))
+		_, e = decoder.Decode(ctx, value)
+		g.Expect(e).To(HaveOccurred(), "decrypting nested JWT without verifier should fail")
+
+		// with verifier
+		verifier := NewSignedJwtDecoder(VerifyWithJwkStore(signingStore, testDefaultKid))
+		decoder = NewEncryptedJwtDecoder(DecryptWithJwkStore(priv, testJweRsaKid), DecryptAndVerifyWith(verifier))
+		decoded := oauth2.MapClaims{}
+		e = decoder.DecodeWithClaims(ctx, value, &decoded)
+		g.Expect(e).To(Succeed(), "decrypting nested JWT should not fail")
+		g.Expect(decoded.Get("sub")).To(Equal(claims["sub"]), "decrypted claims should be correct")
+	}
+}
+
+func SubTestJweWithDisallowedAlg(pub, priv JwkStore) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		encoder := NewEncryptedJwtEncoder(EncryptWithJwkStore(pub, ""), EncryptWithAlgorithms(JweAlgRsaOaep, JweEncA128GCM))
+		value, e := encoder.Encode(ctx, claims)
+		g.Expect(e).To(Succeed(), "encrypting should not fail")
+
+		decoder := NewEncryptedJwtDecoder(DecryptWithJwkStore(priv, ""), DecryptWithAlgorithms([]string{JweAlgRsaOaep256}, nil))
+		_, e = decoder.Decode(ctx, value)
+		g.Expect(e).To(HaveOccurred(), "decrypting with disallowed alg should fail")
+
+		decoder = NewEncryptedJwtDecoder(DecryptWithJwkStore(priv, ""), DecryptWithAlgorithms(nil, []string{JweEncA256GCM}))
+		_, e = decoder.Decode(ctx, value)
+		g.Expect(e).To(HaveOccurred(), "decrypting with disallowed enc should fail")
+	}
+}
+
+func SubTestJweWithWrongKey(pub JwkStore) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		encoder := NewEncryptedJwtEncoder(EncryptWithJwkStore(pub, ""))
+		value, e := encoder.Encode(ctx, claims)
+		g.Expect(e).To(Succeed(), "encrypting should not fail")
+
+		otherKey, _ := rsa.GenerateKey(rand.Reader, rsaKeySize)
+		other := NewJwkSetStore(NewPrivateJwk(testJweRsaKid, testJweRsaKid, otherKey))
+		decoder := NewEncryptedJwtDecoder(DecryptWithJwkStore(other, ""))
+		_, e = decoder.Decode(ctx, value)
+		g.Expect(e).To(HaveOccurred(), "decrypting with wrong key should fail")
+
+		// public key only
+		decoder = NewEncryptedJwtDecoder(DecryptWithJwkStore(pub, ""))
+		_, e = decoder.Decode(ctx, value)
+		g.Expect(e).To(HaveOccurred(), "decrypting without private key should fail")
+	}
+}
+
+func SubTestJweKeyUsage() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		sigKey, _ := rsa.GenerateKey(rand.Reader, rsaKeySize)
+		encKey, _ := rsa.GenerateKey(rand.Reader, rsaKeySize)
+		jwksJson := fmt.Sprintf(`{"keys":[%s,%s]}`,
+			mustMarshalJwkWithUse(g, "sig-rsa", sigKey.Public(), JwkUseSignature),
+			mustMarshalJwkWithUse(g, testJweRsaKid, encKey.Public(), JwkUseEncryption),
+		)
+		jwks, e := ParseJwkSet([]byte(jwksJson))
+		g.Expect(e).To(Succeed(), "parsing JWK Set should not fail")
+		g.Expect(jwks[0]).To(BeAssignableToTypeOf(&GenericJwk{}))
+		g.Expect(jwks[0].(JwkUsage).Use()).To(Equal(JwkUseSignature), "parsed JWK should have correct use")
+
+		// key for encryption is preferred
+		encoder := NewEncryptedJwtEncoder(EncryptWithJwkStore(NewJwkSetStore(jwks...), ""), EncryptWithAlgorithms(JweAlgRsaOaep256, JweEncA128GCM))
+		value, e := encoder.Encode(ctx, claims)
+		g.Expect(e).To(Succeed(), "encrypting should not fail")
+		g.Expect(mustParseJweHeader(g, value)).To(HaveKeyWithValue("kid", testJweRsaKid), "key for encryption should be used")
+
+		// key for signature is never used
+		encoder = NewEncryptedJwtEncoder(EncryptWithJwkStore(NewJwkSetStore(jwks[0]), ""), EncryptWithAlgorithms(JweAlgRsaOaep256, JweEncA128GCM))
+		_, e = encoder.Encode(ctx, claims)
+		g.Expect(e).To(HaveOccurred(), "encrypting with key for signature should fail")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func mustMarshalJwkWithUse(g *WithT, kid string, key crypto.PublicKey, use string) string {
+	data, e := json.Marshal(&GenericJwk{kid: kid, name: kid, public: key, use: use})
+	g.Expect(e).To(Succeed(), "marshalling JWK should not fail")
+	g.Expect(string(data)).To(ContainSubstring(`"use":"%s"`, use), "marshalled JWK should have use")
+	return string(data)
+}
+
+func mustParseJweHeader(g *WithT, value string) map[string]interface{} {
+	headers, e := ParseJwtHeaders(strings.Join(strings.Split(value, ".")[:3], "."))
+	g.Expect(e).To(Succeed(), "parsing JWE header should not fail")
+	return headers
+}
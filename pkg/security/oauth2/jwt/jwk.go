@@ -33,6 +33,13 @@ type Jwk interface {
 	Public() crypto.PublicKey
 }
 
+// JwkUsage is an optional interface of Jwk. Use returns intended use of the public key ("use" parameter),
+// e.g. JwkUseSignature or JwkUseEncryption. Empty string is returned if not specified.
+// See RFC 7517 Section 4.2 https://datatracker.ietf.org/doc/html/rfc7517#section-4.2
+type JwkUsage interface {
+	Use() string
+}
+
 type PrivateJwk interface {
 	Jwk
 	Private() crypto.PrivateKey
@@ -67,11 +74,12 @@ type JwkRotator interface {
 	Implements Base
  *********************/
 
-// GenericJwk implements Jwk
+// GenericJwk implements Jwk and JwkUsage
 type GenericJwk struct {
 	kid    string
 	name   string
 	public crypto.PublicKey
+	use    string
 }
 
 func (k *GenericJwk) Id() string {
@@ -86,6 +94,10 @@ func (k *GenericJwk) Public() crypto.PublicKey {
 	return k.public
 }
 
+func (k *GenericJwk) Use() string {
+	return k.use
+}
+
 func (k *GenericJwk) MarshalJSON() ([]byte, error) {
 	return marshalJwk(k)
 }
@@ -20,8 +20,17 @@ const (
 	JwkTypeEdDSA = `OKP`
 )
 
+// Values of JWK "use" parameter. See JwkUsage
+const (
+	JwkUseSignature  = `sig`
+	JwkUseEncryption = `enc`
+)
+
 func marshalJwk(jwk Jwk) ([]byte, error) {
 	params := generalJwk{Id: jwk.Id()}
+	if usage, ok := jwk.(JwkUsage); ok {
+		params.Use = usage.Use()
+	}
 	key := jwk.Public()
 	var val interface{}
 	switch v := key.(type) {
@@ -60,7 +69,11 @@ func unmarshalJwk(data []byte) (Jwk, error) {
 	if e := json.Unmarshal(data, jwk); e != nil {
 		return nil, e
 	}
-	return jwk.toJwk()
+	ret, e := jwk.toJwk()
+	if generic, ok := ret.(*GenericJwk); ok {
+		generic.use = meta.Use
+	}
+	return ret, e
 }
 
 type jwkBytes []byte
@@ -94,6 +107,7 @@ func (b *jwkBytes) UnmarshalText(data []byte) error {
 type generalJwk struct {
 	Id   string `json:"kid"`
 	Type string `json:"kty"`
+	Use  string `json:"use,omitempty"`
 }
 
 type publicJwk interface {
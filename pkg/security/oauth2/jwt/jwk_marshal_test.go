@@ -104,6 +104,16 @@ func TestJwkUnmarshalling(t *testing.T) {
 	)
 }
 
+func TestJwkThumbprint(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestJwkThumbprintRFC7638(), "RFC7638-Example"),
+		test.GomegaSubTest(SubTestJwkThumbprintRoundTrip(jwt.SigningMethodRS256), "RSA"),
+		test.GomegaSubTest(SubTestJwkThumbprintRoundTrip(jwt.SigningMethodES256), "EC256"),
+		test.GomegaSubTest(SubTestJwkThumbprintRoundTrip(jwt.SigningMethodES512), "EC521"),
+		test.GomegaSubTest(SubTestJwkThumbprintRoundTrip(jwt.SigningMethodEdDSA), "ED25519"),
+	)
+}
+
 /*************************
 	Sub-Test Cases
  *************************/
@@ -143,6 +153,36 @@ func SubTestJwkUnmarshal(method jwt.SigningMethod, jwtVal string, expect Marshal
 	}
 }
 
+func SubTestJwkThumbprintRFC7638() test.GomegaSubTestFunc {
+	// https://datatracker.ietf.org/doc/html/rfc7638#section-3.1
+	const jwkJson = `{"kty":"RSA","e":"AQAB","alg":"RS256","kid":"2011-04-29",` +
+		`"n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}`
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		jwk, e := ParseJwk([]byte(jwkJson))
+		g.Expect(e).To(Succeed(), "parsing JWK should not fail")
+		thumbprint, e := JwkThumbprint(jwk)
+		g.Expect(e).To(Succeed(), "calculating thumbprint should not fail")
+		g.Expect(thumbprint).To(Equal("NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"), "thumbprint should be correct")
+	}
+}
+
+func SubTestJwkThumbprintRoundTrip(method jwt.SigningMethod) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		jwk := NewTestJwk(g, method, false)
+		expected, e := JwkThumbprint(jwk)
+		g.Expect(e).To(Succeed(), "calculating thumbprint should not fail")
+		g.Expect(expected).ToNot(BeEmpty(), "thumbprint should not be empty")
+
+		data, e := json.Marshal(jwk)
+		g.Expect(e).To(Succeed(), "marshaling JWK should not fail")
+		parsed, e := ParseJwk(data)
+		g.Expect(e).To(Succeed(), "parsing JWK should not fail")
+		thumbprint, e := JwkThumbprint(parsed)
+		g.Expect(e).To(Succeed(), "calculating thumbprint of parsed JWK should not fail")
+		g.Expect(thumbprint).To(Equal(expected), "thumbprint should be same after marshalling round trip")
+	}
+}
+
 /*************************
 	Helpers
  *************************/
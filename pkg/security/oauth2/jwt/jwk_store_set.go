@@ -0,0 +1,89 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ParseJwkSet parse JWK Set from JSON as specified in RFC 7517 Section 5.
+// Note: Private key information is ignored in the parsed JWKs. See ParseJwk for supported key types
+func ParseJwkSet(jsonData []byte) ([]Jwk, error) {
+	var set jwkSet
+	if e := json.Unmarshal(jsonData, &set); e != nil {
+		return nil, e
+	}
+	jwks := make([]Jwk, len(set.Keys))
+	for i := range set.Keys {
+		jwks[i] = set.Keys[i]
+	}
+	return jwks, nil
+}
+
+// JwkSetStore implements JwkStore. It's an immutable store backed by a fixed set of JWKs, e.g. JWKs parsed from
+// JSON via ParseJwkSet.
+// Note: LoadByName would match JWK by its name first, then by its "kid".
+type JwkSetStore struct {
+	jwks []Jwk
+}
+
+func NewJwkSetStore(jwks ...Jwk) *JwkSetStore {
+	return &JwkSetStore{
+		jwks: jwks,
+	}
+}
+
+func (s *JwkSetStore) LoadByKid(_ context.Context, kid string) (Jwk, error) {
+	for _, jwk := range s.jwks {
+		if jwk.Id() == kid {
+			return jwk, nil
+		}
+	}
+	return nil, fmt.Errorf("JWK with kid '%s' is not available", kid)
+}
+
+func (s *JwkSetStore) LoadByName(_ context.Context, name string) (Jwk, error) {
+	for _, jwk := range s.jwks {
+		if jwk.Name() == name {
+			return jwk, nil
+		}
+	}
+	for _, jwk := range s.jwks {
+		if jwk.Id() == name {
+			return jwk, nil
+		}
+	}
+	return nil, fmt.Errorf("JWK with name '%s' is not available", name)
+}
+
+func (s *JwkSetStore) LoadAll(_ context.Context, names ...string) ([]Jwk, error) {
+	if len(names) == 0 {
+		return s.jwks, nil
+	}
+	filtered := make([]Jwk, 0, len(s.jwks))
+	for _, jwk := range s.jwks {
+		for _, name := range names {
+			if jwk.Name() == name {
+				filtered = append(filtered, jwk)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2/jwt"
+	"github.com/cisco-open/go-lanai/test"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+const (
+	TestSetRsaKid = `rsa-kid`
+	TestSetEcKid  = `ec-kid`
+)
+
+/*************************
+	Test Cases
+ *************************/
+
+func TestJwkSetStore(t *testing.T) {
+	privStore := NewTestPrivateJwkSetStore(t)
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestParseJwkSet(privStore), "ParseJwkSet"),
+		test.GomegaSubTest(SubTestParseInvalidJwkSet(), "ParseInvalidJwkSet"),
+		test.GomegaSubTest(SubTestJwkSetStoreLoad(privStore), "LoadJwks"),
+		test.GomegaSubTest(SubTestJwkSetStoreVerify(privStore), "VerifyWithParsedJwkSet"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestParseJwkSet(privStore *jwt.JwkSetStore) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		jwks, e := jwt.ParseJwkSet(MarshalTestJwkSet(ctx, g, privStore))
+		g.Expect(e).To(Succeed(), "parsing JWK Set should not fail")
+		g.Expect(jwks).To(HaveLen(2), "parsed JWK Set should have correct size")
+		for _, jwk := range jwks {
+			_, isPrivate := jwk.(jwt.PrivateJwk)
+			g.Expect(isPrivate).To(BeFalse(), "parsed JWK should not have private key")
+			expected, e := privStore.LoadByKid(ctx, jwk.Id())
+			g.Expect(e).To(Succeed(), "parsed JWK should have correct kid")
+			g.Expect(jwk.Public()).To(BeEquivalentTo(expected.Public()), "parsed JWK should have correct public key")
+		}
+	}
+}
+
+func SubTestParseInvalidJwkSet() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		_, e := jwt.ParseJwkSet([]byte(`{"keys":[{"kty":"unknown"}]}`))
+		g.Expect(e).To(HaveOccurred(), "parsing JWK Set with unsupported key type should fail")
+		_, e = jwt.ParseJwkSet([]byte(`not a json`))
+		g.Expect(e).To(HaveOccurred(), "parsing invalid JSON should fail")
+	}
+}
+
+func SubTestJwkSetStoreLoad(store *jwt.JwkSetStore) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		jwk, e := store.LoadByKid(ctx, TestSetRsaKid)
+		g.Expect(e).To(Succeed(), "LoadByKid should not fail")
+		g.Expect(jwk.Id()).To(Equal(TestSetRsaKid), "LoadByKid should return correct JWK")
+		_, e = store.LoadByKid(ctx, "non-exist")
+		g.Expect(e).To(HaveOccurred(), "LoadByKid with non-exist kid should fail")
+
+		jwk, e = store.LoadByName(ctx, TestSetEcKid+"-name")
+		g.Expect(e).To(Succeed(), "LoadByName should not fail")
+		g.Expect(jwk.Id()).To(Equal(TestSetEcKid), "LoadByName should return correct JWK")
+		jwk, e = store.LoadByName(ctx, TestSetEcKid)
+		g.Expect(e).To(Succeed(), "LoadByName with kid should not fail")
+		g.Expect(jwk.Id()).To(Equal(TestSetEcKid), "LoadByName with kid should return correct JWK")
+		_, e = store.LoadByName(ctx, "non-exist")
+		g.Expect(e).To(HaveOccurred(), "LoadByName with non-exist name should fail")
+
+		jwks, e := store.LoadAll(ctx)
+		g.Expect(e).To(Succeed(), "LoadAll should not fail")
+		g.Expect(jwks).To(HaveLen(2), "LoadAll should return all JWKs")
+		jwks, e = store.LoadAll(ctx, TestSetRsaKid+"-name")
+		g.Expect(e).To(Succeed(), "LoadAll with names should not fail")
+		g.Expect(jwks).To(HaveLen(1), "LoadAll with names should return filtered JWKs")
+	}
+}
+
+func SubTestJwkSetStoreVerify(privStore *jwt.JwkSetStore) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		jwks, e := jwt.ParseJwkSet(MarshalTestJwkSet(ctx, g, privStore))
+		g.Expect(e).To(Succeed(), "parsing JWK Set should not fail")
+		pubStore := jwt.NewJwkSetStore(jwks...)
+
+		enc := jwt.NewSignedJwtEncoder(jwt.SignWithJwkStore(privStore, TestSetEcKid+"-name"), jwt.SignWithMethod(nil))
+		token, e := enc.Encode(ctx, oauth2.MapClaims{oauth2.ClaimSubject: "test"})
+		g.Expect(e).To(Succeed(), "encoding JWT should not fail")
+
+		dec := jwt.NewSignedJwtDecoder(jwt.VerifyWithJwkStore(pubStore, ""))
+		claims, e := dec.Decode(ctx, token)
+		g.Expect(e).To(Succeed(), "decoding JWT with parsed JWK Set should not fail")
+		g.Expect(claims.Get(oauth2.ClaimSubject)).To(Equal("test"), "decoded JWT should have correct claims")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func NewTestPrivateJwkSetStore(t *testing.T) *jwt.JwkSetStore {
+	rsaKey, e := rsa.GenerateKey(rand.Reader, 2048)
+	if e != nil {
+		t.Fatalf("unable to generate RSA key: %v", e)
+	}
+	ecKey, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if e != nil {
+		t.Fatalf("unable to generate EC key: %v", e)
+	}
+	return jwt.NewJwkSetStore(
+		jwt.NewPrivateJwk(TestSetRsaKid, TestSetRsaKid+"-name", rsaKey),
+		jwt.NewPrivateJwk(TestSetEcKid, TestSetEcKid+"-name", ecKey),
+	)
+}
+
+func MarshalTestJwkSet(ctx context.Context, g *WithT, store *jwt.JwkSetStore) []byte {
+	jwks, e := store.LoadAll(ctx)
+	g.Expect(e).To(Succeed(), "loading all JWKs should not fail")
+	keys := make([]jwt.Jwk, len(jwks))
+	for i := range jwks {
+		keys[i] = jwt.NewJwk(jwks[i].Id(), jwks[i].Name(), jwks[i].Public())
+	}
+	data, e := json.Marshal(map[string]interface{}{"keys": keys})
+	g.Expect(e).To(Succeed(), "marshalling JWK Set should not fail")
+	return data
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// JwkThumbprint calculates the SHA-256 JWK Thumbprint of given Jwk's public key as specified in RFC 7638.
+// The result is base64url encoded without padding, which is the format used by "jkt" confirmation (RFC 9449).
+// See https://datatracker.ietf.org/doc/html/rfc7638#section-3
+func JwkThumbprint(jwk Jwk) (string, error) {
+	// Note: encoding/json sorts map keys lexicographically and produces no whitespace,
+	// which is exactly what RFC 7638 requires for the required members
+	var members map[string]string
+	switch v := jwk.Public().(type) {
+	case *rsa.PublicKey:
+		members = map[string]string{
+			"kty": JwkTypeRSA,
+			"e":   jwkBytes(bigEndian(v.E)).String(),
+			"n":   jwkBytes(v.N.Bytes()).String(),
+		}
+	case *ecdsa.PublicKey:
+		if v.Curve == nil || v.Curve.Params() == nil || v.X == nil || v.Y == nil {
+			return "", fmt.Errorf(`unable to calculate JWK thumbprint: invalid EC public key`)
+		}
+		// coordinates are padded to the full length of the curve's field size
+		size := (v.Curve.Params().BitSize + 7) / 8
+		members = map[string]string{
+			"kty": JwkTypeEC,
+			"crv": v.Curve.Params().Name,
+			"x":   jwkBytes(v.X.FillBytes(make([]byte, size))).String(),
+			"y":   jwkBytes(v.Y.FillBytes(make([]byte, size))).String(),
+		}
+	case ed25519.PublicKey:
+		members = map[string]string{
+			"kty": JwkTypeEdDSA,
+			"crv": "Ed25519",
+			"x":   jwkBytes(v).String(),
+		}
+	case []byte:
+		members = map[string]string{
+			"kty": JwkTypeOctet,
+			"k":   jwkBytes(v).String(),
+		}
+	default:
+		return "", fmt.Errorf(`unable to calculate JWK thumbprint: unrecognized public key type: %T`, v)
+	}
+	data, e := json.Marshal(members)
+	if e != nil {
+		return "", e
+	}
+	hash := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
@@ -21,6 +21,7 @@ import (
     "github.com/cisco-open/go-lanai/pkg/security"
     "github.com/cisco-open/go-lanai/pkg/security/errorhandling"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2"
+    "github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
     "github.com/cisco-open/go-lanai/pkg/web/middleware"
 )
 
@@ -31,6 +32,7 @@ var (
 //goland:noinspection GoNameStartsWithPackageName
 type TokenAuthConfigurer struct {
 	tokenStoreReader oauth2.TokenStoreReader
+	dpopVerifier     *dpop.ProofVerifier
 }
 
 //goland:noinspection GoNameStartsWithPackageName
@@ -39,6 +41,9 @@ type TokenAuthOptions func(opt *TokenAuthOption)
 //goland:noinspection GoNameStartsWithPackageName
 type TokenAuthOption struct {
 	TokenStoreReader oauth2.TokenStoreReader
+	// DPoPVerifier verifies DPoP proofs of DPoP-bound access tokens.
+	// Optional, a verifier with in-memory replay cache is used when not set
+	DPoPVerifier *dpop.ProofVerifier
 }
 
 func NewTokenAuthConfigurer(opts ...TokenAuthOptions) *TokenAuthConfigurer {
@@ -46,8 +51,14 @@ func NewTokenAuthConfigurer(opts ...TokenAuthOptions) *TokenAuthConfigurer {
 	for _, f := range opts {
 		f(&opt)
 	}
+	if opt.DPoPVerifier == nil {
+		opt.DPoPVerifier = dpop.NewProofVerifier(func(opt *dpop.VerifierOption) {
+			opt.ReplayStore = dpop.NewInMemoryReplayStore()
+		})
+	}
 	return &TokenAuthConfigurer{
 		tokenStoreReader: opt.TokenStoreReader,
+		dpopVerifier:     opt.DPoPVerifier,
 	}
 }
 
@@ -78,6 +89,7 @@ func (c *TokenAuthConfigurer) Apply(feature security.Feature, ws security.WebSec
 		opt.Authenticator = ws.Authenticator()
 		opt.SuccessHandler = successHandler
 		opt.PostBodyEnabled = f.postBodyEnabled
+		opt.DPoPVerifier = c.dpopVerifier
 	})
 
 	// install middlewares
@@ -22,6 +22,7 @@ import (
     "fmt"
     "github.com/cisco-open/go-lanai/pkg/security"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2"
+    "github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
     "net/http"
 )
 
@@ -50,7 +51,7 @@ func writeOAuth2Error(c context.Context, r *http.Request, rw http.ResponseWriter
 	challenge := ""
 	sc := err.TranslateStatusCode()
 	if sc == http.StatusUnauthorized || sc == http.StatusForbidden {
-		challenge = fmt.Sprintf("%s %s", "Bearer", err.Error())
+		challenge = fmt.Sprintf("%s %s", challengeScheme(err), err.Error())
 	}
 	writeAdditionalHeader(c, r, rw, challenge)
 	security.WriteError(c, r, rw, sc, err)
@@ -69,3 +70,13 @@ func writeAdditionalHeader(_ context.Context, _ *http.Request, rw http.ResponseW
 	}
 }
 
+// challengeScheme returns "DPoP" for DPoP proof errors and "Bearer" for others.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-7.1
+func challengeScheme(err error) string {
+	switch {
+	case errors.Is(err, oauth2.NewDPoPProofRejectedError("")), errors.Is(err, oauth2.NewDPoPNonceRequiredError("")):
+		return dpop.AuthScheme
+	default:
+		return "Bearer"
+	}
+}
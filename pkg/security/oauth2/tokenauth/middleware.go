@@ -20,12 +20,14 @@ import (
     "errors"
     "github.com/cisco-open/go-lanai/pkg/security"
     "github.com/cisco-open/go-lanai/pkg/security/oauth2"
+    "github.com/cisco-open/go-lanai/pkg/security/oauth2/dpop"
     "github.com/gin-gonic/gin"
     "strings"
 )
 
 const (
 	bearerTokenPrefix = "Bearer "
+	dpopTokenPrefix   = dpop.AuthScheme + " "
 )
 
 /****************************
@@ -37,6 +39,7 @@ type TokenAuthMiddleware struct {
 	authenticator   security.Authenticator
 	successHandler  security.AuthenticationSuccessHandler
 	postBodyEnabled bool
+	dpopVerifier    *dpop.ProofVerifier
 }
 
 //goland:noinspection GoNameStartsWithPackageName
@@ -47,6 +50,9 @@ type TokenAuthMWOption struct {
 	Authenticator   security.Authenticator
 	SuccessHandler  security.AuthenticationSuccessHandler
 	PostBodyEnabled bool
+	// DPoPVerifier is used to verify DPoP proofs presented with DPoP-bound access tokens.
+	// When not set, DPoP-bound access tokens are rejected
+	DPoPVerifier *dpop.ProofVerifier
 }
 
 func NewTokenAuthMiddleware(opts ...TokenAuthMWOptions) *TokenAuthMiddleware {
@@ -60,6 +66,7 @@ func NewTokenAuthMiddleware(opts ...TokenAuthMWOptions) *TokenAuthMiddleware {
 		authenticator:   opt.Authenticator,
 		successHandler:  opt.SuccessHandler,
 		postBodyEnabled: opt.PostBodyEnabled,
+		dpopVerifier:    opt.DPoPVerifier,
 	}
 }
 
@@ -71,7 +78,7 @@ func (mw *TokenAuthMiddleware) AuthenticateHandlerFunc() gin.HandlerFunc {
 		security.MustClear(ctx)
 
 		// grab bearer token and create candidate
-		tokenValue, e := mw.extractAccessToken(ctx)
+		tokenValue, isDPoP, e := mw.extractAccessToken(ctx)
 		if e != nil {
 			mw.handleError(ctx, e)
 			return
@@ -90,6 +97,12 @@ func (mw *TokenAuthMiddleware) AuthenticateHandlerFunc() gin.HandlerFunc {
 			mw.handleError(ctx, err)
 			return
 		}
+
+		// sender-constrained token requires proof of possession
+		if err := mw.verifyDPoP(ctx, tokenValue, isDPoP, auth); err != nil {
+			mw.handleError(ctx, err)
+			return
+		}
 		mw.handleSuccess(ctx, before, auth)
 	}
 }
@@ -103,7 +116,7 @@ func (mw *TokenAuthMiddleware) handleSuccess(c *gin.Context, before, new securit
 	// we don't explicitly write any thing on success
 }
 
-func (mw *TokenAuthMiddleware) extractAccessToken(ctx *gin.Context) (ret string, err error) {
+func (mw *TokenAuthMiddleware) extractAccessToken(ctx *gin.Context) (ret string, isDPoP bool, err error) {
 	header := ctx.GetHeader("Authorization")
 	if header == "" {
 		if mw.postBodyEnabled {
@@ -111,11 +124,54 @@ func (mw *TokenAuthMiddleware) extractAccessToken(ctx *gin.Context) (ret string,
 		}
 		return
 	}
-	if !strings.HasPrefix(strings.ToUpper(header), strings.ToUpper(bearerTokenPrefix)) {
-		return "", oauth2.NewInvalidAccessTokenError("missing bearer token")
+	switch {
+	case strings.HasPrefix(strings.ToUpper(header), strings.ToUpper(bearerTokenPrefix)):
+		return header[len(bearerTokenPrefix):], false, nil
+	case strings.HasPrefix(strings.ToUpper(header), strings.ToUpper(dpopTokenPrefix)):
+		return header[len(dpopTokenPrefix):], true, nil
+	default:
+		return "", false, oauth2.NewInvalidAccessTokenError("missing bearer token")
+	}
+}
+
+// verifyDPoP make sure DPoP-bound access token is presented with valid DPoP proof.
+// See https://datatracker.ietf.org/doc/html/rfc9449#section-7
+func (mw *TokenAuthMiddleware) verifyDPoP(ctx *gin.Context, tokenValue string, isDPoP bool, auth security.Authentication) error {
+	jkt := boundJwkThumbprint(auth)
+	switch {
+	case len(jkt) == 0 && isDPoP:
+		return oauth2.NewInvalidAccessTokenError("access token is not DPoP-bound")
+	case len(jkt) == 0:
+		return nil
+	case !isDPoP:
+		return oauth2.NewInvalidAccessTokenError("DPoP-bound access token requires DPoP authorization scheme")
+	case mw.dpopVerifier == nil:
+		return oauth2.NewInvalidAccessTokenError("DPoP-bound access token is not supported")
 	}
 
-	return header[len(bearerTokenPrefix):], nil
+	proofs := ctx.Request.Header.Values(dpop.HeaderDPoP)
+	if len(proofs) != 1 {
+		return oauth2.NewDPoPProofRejectedError("exactly one DPoP proof is required")
+	}
+	_, e := mw.dpopVerifier.Verify(ctx, proofs[0], dpop.ProofExpectation{
+		Method:      ctx.Request.Method,
+		URL:         mw.dpopVerifier.RequestURL(ctx.Request),
+		AccessToken: tokenValue,
+		Thumbprint:  jkt,
+	})
+	switch {
+	case e == nil:
+		return nil
+	case errors.Is(e, dpop.ErrNonceRequired):
+		if nonce, err := mw.dpopVerifier.Nonce(ctx); err == nil {
+			ctx.Header(dpop.HeaderDPoPNonce, nonce)
+		}
+		return oauth2.NewDPoPNonceRequiredError(e.Error())
+	case errors.Is(e, dpop.ErrInvalidProof):
+		return oauth2.NewDPoPProofRejectedError(e.Error())
+	default:
+		return oauth2.NewInternalError(e)
+	}
 }
 
 func (mw *TokenAuthMiddleware) handleError(c *gin.Context, err error) {
@@ -127,3 +183,18 @@ func (mw *TokenAuthMiddleware) handleError(c *gin.Context, err error) {
 	_ = c.Error(err)
 	c.Abort()
 }
+
+// boundJwkThumbprint returns "cnf.jkt" claim of the authentication's access token, if available
+func boundJwkThumbprint(auth security.Authentication) string {
+	oauth, ok := auth.(oauth2.Authentication)
+	if !ok || oauth.AccessToken() == nil {
+		return ""
+	}
+	container, ok := oauth.AccessToken().(oauth2.ClaimsContainer)
+	if !ok || container.Claims() == nil {
+		return ""
+	}
+	cnf, _ := container.Claims().Get(oauth2.ClaimConfirmation).(map[string]interface{})
+	jkt, _ := cnf[oauth2.ClaimJwkThumbprint].(string)
+	return jkt
+}
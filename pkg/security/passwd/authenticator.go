@@ -111,7 +111,7 @@ func (a *Authenticator) Authenticate(ctx context.Context, candidate security.Can
 	}
 
 	// create authentication
-	newAuth, e := a.CreateSuccessAuthentication(upp, user)
+	newAuth, e := a.createSuccessAuthentication(ctx, upp, user)
 	if e != nil {
 		err = a.translate(e)
 		return
@@ -123,10 +123,33 @@ func (a *Authenticator) Authenticate(ctx context.Context, candidate security.Can
 		return
 	}
 
+	// password is verified, re-encode it if necessary. The account is persisted by post processors
+	a.upgradeEncoding(ctx, upp.Password, user)
+
 	auth = newAuth
 	return
 }
 
+// upgradeEncoding re-encodes password with current PasswordEncoder if the encoder supports UpgradablePasswordEncoder
+// and the account supports security.CredentialsUpdater
+func (a *Authenticator) upgradeEncoding(ctx context.Context, raw string, account security.Account) {
+	upgradable, ok := a.passwdEncoder.(UpgradablePasswordEncoder)
+	if !ok {
+		return
+	}
+	updater, ok := account.(security.CredentialsUpdater)
+	if !ok {
+		return
+	}
+	if encoded, ok := account.Credentials().(string); !ok || !upgradable.UpgradeEncoding(encoded) {
+		return
+	}
+	if encoded := upgradable.Encode(raw); encoded != "" {
+		updater.UpdateCredentials(encoded)
+		logger.WithContext(ctx).Debugf("Password encoding of account [%s] is upgraded", account.Username())
+	}
+}
+
 // CreateSuccessAuthentication exported for override posibility
 func (a *Authenticator) CreateSuccessAuthentication(candidate *UsernamePasswordPair, account security.Account) (security.Authentication, error) {
 
@@ -170,6 +193,41 @@ func (a *Authenticator) CreateSuccessAuthentication(candidate *UsernamePasswordP
 	return &auth, nil
 }
 
+// createSuccessAuthentication uses MFAEnrollment of the account if available, otherwise CreateSuccessAuthentication
+func (a *Authenticator) createSuccessAuthentication(ctx context.Context, candidate *UsernamePasswordPair, account security.Account) (security.Authentication, error) {
+	if candidate.EnforceMFA == MFAModeSkip {
+		return a.CreateSuccessAuthentication(candidate, account)
+	}
+
+	otpManager, _ := a.otpManager.(EnrolledOTPManager)
+	switch enrollment, e := loadMFAEnrollment(ctx, a.accountStore, account); {
+	case e != nil:
+		return nil, security.NewInternalAuthenticationError(MessageOtpNotAvailable, e)
+	case enrollment == nil || enrollment.Method != MFAMethodTOTP:
+		return a.CreateSuccessAuthentication(candidate, account)
+	case otpManager == nil:
+		return nil, security.NewInternalAuthenticationError(MessageOtpNotAvailable)
+	default:
+		// passcode is from authenticator app, no need to broadcast MFAEventOtpCreate
+		otp, e := otpManager.NewEnrolled(account.Username())
+		if e != nil {
+			return nil, security.NewInternalAuthenticationError(MessageOtpNotAvailable)
+		}
+		details := candidate.DetailsMap
+		if details == nil {
+			details = map[string]interface{}{}
+		}
+		return &usernamePasswordAuthentication{
+			Acct: account.CacheableCopy(),
+			Perms: map[string]interface{}{
+				SpecialPermissionMFAPending: true,
+				SpecialPermissionOtpId:      otp.ID(),
+			},
+			DetailsMap: details,
+		}, nil
+	}
+}
+
 func (a *Authenticator) translate(err error) error {
 
 	switch {
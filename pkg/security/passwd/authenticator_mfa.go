@@ -77,9 +77,26 @@ func (a *MfaVerifyAuthenticator) Authenticate(ctx context.Context, candidate sec
 		return
 	}
 
-	// Check OTP
+	// Check OTP, recovery code is accepted if the account is enrolled
 	id := verify.CurrentAuth.OTPIdentifier()
-	switch otp, more, e := a.otpStore.Verify(id, verify.OTP); {
+	otp, more, e := a.otpStore.Verify(id, verify.OTP)
+	switch {
+	case otp != nil && otp.enrolled():
+		// OTP of authenticator app only counts attempts, passcode is verified against MFAEnrollment and is single-use.
+		// Recovery code is tried on every counted attempt, including the last one
+		if otp.username() != user.Username() {
+			break
+		}
+		if used, ue := useMFAPasscode(ctx, a.accountStore, user, verify.OTP); ue == nil && used {
+			e = nil
+		}
+	case e != nil && otp != nil:
+		// recovery code is tried on every counted attempt, including the last one
+		if used, re := useRecoveryCode(ctx, a.accountStore, user, verify.OTP); re == nil && used {
+			e = nil
+		}
+	}
+	switch {
 	case e != nil:
 		broadcastMFAEvent(MFAEventVerificationFailure, otp, user, a.mfaEventListeners...)
 		err = a.translate(e, more)
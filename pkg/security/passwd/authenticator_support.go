@@ -0,0 +1,93 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package passwd
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"sort"
+	"time"
+)
+
+// AuthenticatorSupport provides common account checks and post-processing to security.Authenticator implementations
+// that authenticate accounts of security.AccountStore with credentials other than password (e.g. WebAuthn, X509)
+type AuthenticatorSupport struct {
+	Checkers       []AuthenticationDecisionMaker
+	PostProcessors []PostAuthenticationProcessor
+}
+
+// NewAuthenticatorSupport sorts given checkers and post processors.
+// When nil, account status checker and default post processors (persist account and account status) are used
+func NewAuthenticatorSupport(accountStore security.AccountStore,
+	checkers []AuthenticationDecisionMaker, postProcessors []PostAuthenticationProcessor) AuthenticatorSupport {
+	if checkers == nil {
+		checkers = []AuthenticationDecisionMaker{
+			PreCredentialsCheck(NewAccountStatusChecker(accountStore)),
+		}
+	}
+	if postProcessors == nil {
+		postProcessors = []PostAuthenticationProcessor{
+			NewPersistAccountPostProcessor(accountStore),
+			NewAccountStatusPostProcessor(accountStore),
+		}
+	}
+	sort.SliceStable(checkers, func(i, j int) bool {
+		return order.OrderedFirstCompare(checkers[i], checkers[j])
+	})
+	sort.SliceStable(postProcessors, func(i, j int) bool {
+		return order.OrderedFirstCompareReverse(postProcessors[i], postProcessors[j])
+	})
+	return AuthenticatorSupport{
+		Checkers:       checkers,
+		PostProcessors: postProcessors,
+	}
+}
+
+// Decide executes all checkers in order, and returns the first error
+func (s AuthenticatorSupport) Decide(ctx context.Context, can security.Candidate, acct security.Account, auth security.Authentication) error {
+	return makeDecision(s.Checkers, ctx, can, acct, auth)
+}
+
+// PostProcess applies all post processors in order to the authentication result
+func (s AuthenticatorSupport) PostProcess(ctx context.Context, acct security.Account, can security.Candidate,
+	auth security.Authentication, err error) (security.Authentication, error) {
+	return applyPostAuthenticationProcessors(s.PostProcessors, ctx, acct, can, auth, err)
+}
+
+// AccountPermissions returns all permissions of given account, in the form used by security.Authentication
+func AccountPermissions(acct security.Account) map[string]interface{} {
+	permissions := map[string]interface{}{}
+	for _, p := range acct.Permissions() {
+		permissions[p] = true
+	}
+	return permissions
+}
+
+// NewAuthenticationDetails copies given details and sets security.DetailsKeyAuthTime to now.
+// security.DetailsKeyAuthMethod is also set if authMethod is not empty
+func NewAuthenticationDetails(src map[string]interface{}, authMethod string) map[string]interface{} {
+	details := map[string]interface{}{}
+	for k, v := range src {
+		details[k] = v
+	}
+	if len(authMethod) != 0 {
+		details[security.DetailsKeyAuthMethod] = authMethod
+	}
+	details[security.DetailsKeyAuthTime] = time.Now().UTC()
+	return details
+}
@@ -39,6 +39,7 @@ func TestAuthenticator(t *testing.T) {
 		test.GomegaSubTest(SubTestAutoLockoutException(), "AutoLockoutException"),
 		test.GomegaSubTest(SubTestExpiredCredentials(), "ExpiredCredentials"),
 		test.GomegaSubTest(SubTestExpiringCredentialsWarning(), "ExpiringCredentialsWarning"),
+		test.GomegaSubTest(SubTestPasswordEncodingUpgrade(), "PasswordEncodingUpgrade"),
 	)
 }
 
@@ -208,11 +209,64 @@ func SubTestExpiringCredentialsWarning() test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestPasswordEncodingUpgrade() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		encoder := passwd.NewDefaultDelegatingPasswordEncoder(func(opt *passwd.DelegatingEncoderOption) {
+			opt.IdForEncode = passwd.EncoderIdPbkdf2
+			opt.Encoders[passwd.EncoderIdPbkdf2] = passwd.NewPbkdf2PasswordEncoder(func(opt *passwd.Pbkdf2EncoderOption) {
+				opt.Iterations = 1000
+			})
+		})
+		legacy := passwd.NewBcryptPasswordEncoder().Encode(TestUserPassword)
+		pwdChangedTime := time.Now().Add(-time.Minute)
+		acct := NewAccount(TestUser, legacy, func(acct *security.DefaultAccount) {
+			acct.AcctDetails.PwdChangedTime = pwdChangedTime
+		})
+		authenticator := NewTestAuthenticatorWithEncoder(ctx, g, encoder, acct)
+
+		// wrong password
+		_, e := authenticator.Authenticate(ctx, NewCredentialsCandidate(TestUser, "whatever"))
+		g.Expect(e).To(HaveOccurred(), "authentication should fail")
+		g.Expect(acct.Credentials()).To(Equal(legacy), "password should not be upgraded after failed login")
+
+		// correct password
+		auth, e := authenticator.Authenticate(ctx, NewCredentialsCandidate(TestUser, TestUserPassword))
+		g.Expect(e).To(Succeed(), "authentication should not fail")
+		g.Expect(auth.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+		upgraded, _ := acct.Credentials().(string)
+		g.Expect(upgraded).To(HavePrefix("{pbkdf2}"), "password should be upgraded after successful login")
+		g.Expect(encoder.Matches(TestUserPassword, upgraded)).To(BeTrue(), "upgraded password should match raw password")
+		g.Expect(acct.PwdChangedTime()).To(Equal(pwdChangedTime), "password changed time should not be affected")
+
+		// login again with upgraded password
+		_, e = authenticator.Authenticate(ctx, NewCredentialsCandidate(TestUser, TestUserPassword))
+		g.Expect(e).To(Succeed(), "authentication with upgraded password should not fail")
+		g.Expect(acct.Credentials()).To(Equal(upgraded), "password should not be upgraded again")
+	}
+}
+
 /*************************
 	Helpers
  *************************/
 
 func NewTestAuthenticator(ctx context.Context, g *gomega.WithT, accts ...security.Account) security.Authenticator {
+	authn, e := passwd.NewAuthenticatorBuilder(passwd.New().
+		AccountStore(NewTestAccountStore(accts...)).MFA(true),
+	).Build(ctx)
+	g.Expect(e).To(Succeed(), "building authenticator should not fail")
+	return authn
+}
+
+func NewTestAuthenticatorWithEncoder(ctx context.Context, g *gomega.WithT, encoder passwd.PasswordEncoder, accts ...security.Account) security.Authenticator {
+	authn, e := passwd.NewAuthenticatorBuilder(passwd.New().
+		AccountStore(NewTestAccountStore(accts...)).MFA(true).
+		PasswordEncoder(encoder),
+	).Build(ctx)
+	g.Expect(e).To(Succeed(), "building authenticator should not fail")
+	return authn
+}
+
+func NewTestAccountStore(accts ...security.Account) security.AccountStore {
 	props := make([]*sectest.MockedAccountProperties, len(accts))
 	overrideLookup := map[string]security.Account{}
 	for i := range accts {
@@ -222,17 +276,12 @@ func NewTestAuthenticator(ctx context.Context, g *gomega.WithT, accts ...securit
 		}
 		overrideLookup[accts[i].Username()] = accts[i]
 	}
-	store := sectest.NewMockedAccountStore(props, func(acct security.Account) security.Account {
+	return sectest.NewMockedAccountStore(props, func(acct security.Account) security.Account {
 		if override, ok := overrideLookup[acct.Username()]; ok && override != nil {
 			return override
 		}
 		return acct
 	})
-	authn, e := passwd.NewAuthenticatorBuilder(passwd.New().
-		AccountStore(store).MFA(true),
-	).Build(ctx)
-	g.Expect(e).To(Succeed(), "building authenticator should not fail")
-	return authn
 }
 
 func NewCredentialsCandidate(username, password string) security.Candidate {
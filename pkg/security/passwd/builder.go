@@ -161,10 +161,10 @@ func (b *AuthenticatorBuilder) prepareDecisionMakers(f *PasswordAuthFeature) []A
 	acctStatusChecker := NewAccountStatusChecker(f.accountStore)
 	passwordChecker := NewPasswordPolicyChecker(f.accountStore)
 
-	return []AuthenticationDecisionMaker{
+	return append([]AuthenticationDecisionMaker{
 		PreCredentialsCheck(acctStatusChecker),
 		FinalCheck(passwordChecker),
-	}
+	}, f.decisionMakers...)
 }
 
 func (b *AuthenticatorBuilder) preparePostProcessors(f *PasswordAuthFeature) []PostAuthenticationProcessor {
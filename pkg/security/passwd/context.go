@@ -190,8 +190,12 @@ func (auth *usernamePasswordAuthentication) Username() string {
 }
 
 func (auth *usernamePasswordAuthentication) IsMFAPending() bool {
-	_, ok := auth.Permissions()[SpecialPermissionOtpId].(string)
-	return ok
+	if _, ok := auth.Permissions()[SpecialPermissionOtpId].(string); ok {
+		return true
+	}
+	// second factor other than OTP, e.g. WebAuthn
+	pending, _ := auth.Permissions()[SpecialPermissionMFAPending].(bool)
+	return pending
 }
 
 func (auth *usernamePasswordAuthentication) OTPIdentifier() string {
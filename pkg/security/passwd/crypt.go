@@ -23,6 +23,15 @@ type PasswordEncoder interface {
 	Matches(raw, encoded string) bool
 }
 
+// UpgradablePasswordEncoder is an optional interface of PasswordEncoder.
+// UpgradeEncoding returns true if the encoded password should be encoded again for better security,
+// e.g. it's encoded with an outdated algorithm or weaker parameters.
+// Authenticator would re-encode the password after successful login if it returns true.
+type UpgradablePasswordEncoder interface {
+	PasswordEncoder
+	UpgradeEncoding(encoded string) bool
+}
+
 
 type noopPasswordEncoder string
 
@@ -59,4 +68,10 @@ func (enc *bcryptPasswordEncoder) Encode(raw string) string {
 func (enc *bcryptPasswordEncoder) Matches(raw, encoded string) bool {
 	e := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(raw))
 	return e == nil
-}
\ No newline at end of file
+}
+
+// UpgradeEncoding returns true if the encoded password's cost is lower than the current one
+func (enc *bcryptPasswordEncoder) UpgradeEncoding(encoded string) bool {
+	cost, e := bcrypt.Cost([]byte(encoded))
+	return e == nil && cost < enc.cost
+}
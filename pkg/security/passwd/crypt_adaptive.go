@@ -0,0 +1,332 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package passwd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"strings"
+)
+
+// Encoded passwords of adaptive encoders in this file follow PHC string format:
+// 	$<id>[$v=<version>]$<param>=<value>(,<param>=<value>)*$<salt>$<hash>
+// where salt and hash are base64 encoded without padding.
+// See https://github.com/P-H-C/phc-string-format/blob/master/phc-sf-spec.md
+
+const (
+	phcIdArgon2id     = "argon2id"
+	phcIdScrypt       = "scrypt"
+	phcIdPbkdf2Sha256 = "pbkdf2-sha256"
+)
+
+var phcEncoding = base64.RawStdEncoding
+
+/*************************
+	Argon2id
+ *************************/
+
+type Argon2idEncoderOptions func(opt *Argon2idEncoderOption)
+
+// Argon2idEncoderOption parameters of argon2id. Default values follow OWASP recommendation
+// See https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#argon2id
+type Argon2idEncoderOption struct {
+	// Memory in KiB. Default 19456 (19 MiB)
+	Memory uint32
+	// Iterations number of passes over the memory. Default 2
+	Iterations uint32
+	// Parallelism number of threads. Default 1
+	Parallelism uint8
+	// SaltLength in bytes. Default 16
+	SaltLength int
+	// KeyLength in bytes. Default 32
+	KeyLength uint32
+}
+
+// argon2idPasswordEncoder implements UpgradablePasswordEncoder
+type argon2idPasswordEncoder struct {
+	Argon2idEncoderOption
+}
+
+func NewArgon2idPasswordEncoder(opts ...Argon2idEncoderOptions) PasswordEncoder {
+	enc := argon2idPasswordEncoder{
+		Argon2idEncoderOption: Argon2idEncoderOption{
+			Memory:      19 * 1024,
+			Iterations:  2,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}
+	for _, fn := range opts {
+		fn(&enc.Argon2idEncoderOption)
+	}
+	return &enc
+}
+
+func (enc *argon2idPasswordEncoder) Encode(raw string) string {
+	salt, e := randomSalt(enc.SaltLength)
+	if e != nil {
+		return ""
+	}
+	hash := argon2.IDKey([]byte(raw), salt, enc.Iterations, enc.Memory, enc.Parallelism, enc.KeyLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", phcIdArgon2id, argon2.Version,
+		enc.Memory, enc.Iterations, enc.Parallelism, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(hash))
+}
+
+func (enc *argon2idPasswordEncoder) Matches(raw, encoded string) bool {
+	params, salt, hash, e := enc.decode(encoded)
+	if e != nil {
+		return false
+	}
+	actual := argon2.IDKey([]byte(raw), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(hash)))
+	return subtle.ConstantTimeCompare(actual, hash) == 1
+}
+
+// UpgradeEncoding returns true if any parameter of encoded password is weaker than the current one
+func (enc *argon2idPasswordEncoder) UpgradeEncoding(encoded string) bool {
+	params, _, hash, e := enc.decode(encoded)
+	if e != nil {
+		return false
+	}
+	return params.Memory < enc.Memory || params.Iterations < enc.Iterations ||
+		params.Parallelism < enc.Parallelism || uint32(len(hash)) < enc.KeyLength
+}
+
+func (enc *argon2idPasswordEncoder) decode(encoded string) (params Argon2idEncoderOption, salt, hash []byte, err error) {
+	var version int
+	var paramsStr string
+	if paramsStr, salt, hash, err = parsePHC(encoded, phcIdArgon2id, &version); err != nil {
+		return
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	if _, err = fmt.Sscanf(paramsStr, "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %v", err)
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters")
+	}
+	return
+}
+
+/*************************
+	Scrypt
+ *************************/
+
+type ScryptEncoderOptions func(opt *ScryptEncoderOption)
+
+// ScryptEncoderOption parameters of scrypt.
+// See https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#scrypt
+type ScryptEncoderOption struct {
+	// CostLog2 log2 of CPU/memory cost parameter N. Default 15 (N = 32768)
+	CostLog2 uint8
+	// BlockSize parameter r. Default 8
+	BlockSize int
+	// Parallelism parameter p. Default 1
+	Parallelism int
+	// SaltLength in bytes. Default 16
+	SaltLength int
+	// KeyLength in bytes. Default 32
+	KeyLength int
+}
+
+// scryptPasswordEncoder implements UpgradablePasswordEncoder
+type scryptPasswordEncoder struct {
+	ScryptEncoderOption
+}
+
+func NewScryptPasswordEncoder(opts ...ScryptEncoderOptions) PasswordEncoder {
+	enc := scryptPasswordEncoder{
+		ScryptEncoderOption: ScryptEncoderOption{
+			CostLog2:    15,
+			BlockSize:   8,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}
+	for _, fn := range opts {
+		fn(&enc.ScryptEncoderOption)
+	}
+	return &enc
+}
+
+func (enc *scryptPasswordEncoder) Encode(raw string) string {
+	salt, e := randomSalt(enc.SaltLength)
+	if e != nil {
+		return ""
+	}
+	hash, e := scrypt.Key([]byte(raw), salt, 1<<enc.CostLog2, enc.BlockSize, enc.Parallelism, enc.KeyLength)
+	if e != nil {
+		return ""
+	}
+	return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s$%s", phcIdScrypt,
+		enc.CostLog2, enc.BlockSize, enc.Parallelism, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(hash))
+}
+
+func (enc *scryptPasswordEncoder) Matches(raw, encoded string) bool {
+	params, salt, hash, e := enc.decode(encoded)
+	if e != nil {
+		return false
+	}
+	actual, e := scrypt.Key([]byte(raw), salt, 1<<params.CostLog2, params.BlockSize, params.Parallelism, len(hash))
+	return e == nil && subtle.ConstantTimeCompare(actual, hash) == 1
+}
+
+// UpgradeEncoding returns true if any parameter of encoded password is weaker than the current one
+func (enc *scryptPasswordEncoder) UpgradeEncoding(encoded string) bool {
+	params, _, hash, e := enc.decode(encoded)
+	if e != nil {
+		return false
+	}
+	return params.CostLog2 < enc.CostLog2 || params.BlockSize < enc.BlockSize ||
+		params.Parallelism < enc.Parallelism || len(hash) < enc.KeyLength
+}
+
+func (enc *scryptPasswordEncoder) decode(encoded string) (params ScryptEncoderOption, salt, hash []byte, err error) {
+	var paramsStr string
+	if paramsStr, salt, hash, err = parsePHC(encoded, phcIdScrypt, nil); err != nil {
+		return
+	}
+	if _, err = fmt.Sscanf(paramsStr, "ln=%d,r=%d,p=%d", &params.CostLog2, &params.BlockSize, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid scrypt parameters: %v", err)
+	}
+	if params.CostLog2 == 0 || params.CostLog2 >= 32 || params.BlockSize <= 0 || params.Parallelism <= 0 {
+		return params, nil, nil, fmt.Errorf("invalid scrypt parameters")
+	}
+	return
+}
+
+/*************************
+	PBKDF2
+ *************************/
+
+type Pbkdf2EncoderOptions func(opt *Pbkdf2EncoderOption)
+
+// Pbkdf2EncoderOption parameters of PBKDF2 with HMAC-SHA256.
+// See https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#pbkdf2
+type Pbkdf2EncoderOption struct {
+	// Iterations default 600000
+	Iterations int
+	// SaltLength in bytes. Default 16
+	SaltLength int
+	// KeyLength in bytes. Default 32
+	KeyLength int
+}
+
+// pbkdf2PasswordEncoder implements UpgradablePasswordEncoder
+type pbkdf2PasswordEncoder struct {
+	Pbkdf2EncoderOption
+}
+
+func NewPbkdf2PasswordEncoder(opts ...Pbkdf2EncoderOptions) PasswordEncoder {
+	enc := pbkdf2PasswordEncoder{
+		Pbkdf2EncoderOption: Pbkdf2EncoderOption{
+			Iterations: 600000,
+			SaltLength: 16,
+			KeyLength:  32,
+		},
+	}
+	for _, fn := range opts {
+		fn(&enc.Pbkdf2EncoderOption)
+	}
+	return &enc
+}
+
+func (enc *pbkdf2PasswordEncoder) Encode(raw string) string {
+	salt, e := randomSalt(enc.SaltLength)
+	if e != nil {
+		return ""
+	}
+	hash := pbkdf2.Key([]byte(raw), salt, enc.Iterations, enc.KeyLength, sha256.New)
+	return fmt.Sprintf("$%s$i=%d$%s$%s", phcIdPbkdf2Sha256,
+		enc.Iterations, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(hash))
+}
+
+func (enc *pbkdf2PasswordEncoder) Matches(raw, encoded string) bool {
+	params, salt, hash, e := enc.decode(encoded)
+	if e != nil {
+		return false
+	}
+	actual := pbkdf2.Key([]byte(raw), salt, params.Iterations, len(hash), sha256.New)
+	return subtle.ConstantTimeCompare(actual, hash) == 1
+}
+
+// UpgradeEncoding returns true if any parameter of encoded password is weaker than the current one
+func (enc *pbkdf2PasswordEncoder) UpgradeEncoding(encoded string) bool {
+	params, _, hash, e := enc.decode(encoded)
+	if e != nil {
+		return false
+	}
+	return params.Iterations < enc.Iterations || len(hash) < enc.KeyLength
+}
+
+func (enc *pbkdf2PasswordEncoder) decode(encoded string) (params Pbkdf2EncoderOption, salt, hash []byte, err error) {
+	var paramsStr string
+	if paramsStr, salt, hash, err = parsePHC(encoded, phcIdPbkdf2Sha256, nil); err != nil {
+		return
+	}
+	if _, err = fmt.Sscanf(paramsStr, "i=%d", &params.Iterations); err != nil || params.Iterations <= 0 {
+		return params, nil, nil, fmt.Errorf("invalid pbkdf2 parameters")
+	}
+	return
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func randomSalt(size int) ([]byte, error) {
+	salt := make([]byte, size)
+	if _, e := rand.Read(salt); e != nil {
+		return nil, e
+	}
+	return salt, nil
+}
+
+// parsePHC split PHC formatted string into parameters, salt and hash. Version is parsed only when given pointer is not nil
+func parsePHC(encoded, expectedId string, version *int) (params string, salt, hash []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	expectedLen := 5
+	if version != nil {
+		expectedLen = 6
+	}
+	if len(parts) != expectedLen || parts[0] != "" || parts[1] != expectedId {
+		return "", nil, nil, fmt.Errorf("not a %s encoded password", expectedId)
+	}
+	parts = parts[2:]
+	if version != nil {
+		if _, err = fmt.Sscanf(parts[0], "v=%d", version); err != nil {
+			return "", nil, nil, fmt.Errorf("invalid %s version: %v", expectedId, err)
+		}
+		parts = parts[1:]
+	}
+	if salt, err = phcEncoding.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, fmt.Errorf("invalid %s salt: %v", expectedId, err)
+	}
+	if hash, err = phcEncoding.DecodeString(parts[2]); err != nil || len(hash) == 0 {
+		return "", nil, nil, fmt.Errorf("invalid %s hash", expectedId)
+	}
+	return parts[0], salt, hash, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package passwd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Encoder IDs used by NewDefaultDelegatingPasswordEncoder
+const (
+	EncoderIdArgon2id = "argon2id"
+	EncoderIdBcrypt   = "bcrypt"
+	EncoderIdScrypt   = "scrypt"
+	EncoderIdPbkdf2   = "pbkdf2"
+	EncoderIdNoop     = "noop"
+)
+
+const (
+	encoderIdPrefix = "{"
+	encoderIdSuffix = "}"
+)
+
+type DelegatingEncoderOptions func(opt *DelegatingEncoderOption)
+
+type DelegatingEncoderOption struct {
+	// IdForEncode the ID of encoder used for encoding new passwords. Required
+	IdForEncode string
+	// Encoders all supported encoders by ID
+	Encoders map[string]PasswordEncoder
+	// DefaultForMatches encoder used for encoded passwords without "{id}" prefix.
+	// If nil, such passwords never match
+	DefaultForMatches PasswordEncoder
+}
+
+// DelegatingPasswordEncoder implements UpgradablePasswordEncoder.
+// It encodes passwords with the preferred encoder and prefixes the result with "{id}",
+// e.g. "{bcrypt}$2a$10$...". When matching, the encoder is selected by the prefix of the encoded password.
+// This allows mixed password hashes during migration from one algorithm to another.
+//
+// Any encoded password not produced by the preferred encoder, or produced with weaker parameters,
+// is reported by UpgradeEncoding
+type DelegatingPasswordEncoder struct {
+	idForEncode       string
+	encoderForEncode  PasswordEncoder
+	encoders          map[string]PasswordEncoder
+	defaultForMatches PasswordEncoder
+}
+
+// NewDelegatingPasswordEncoder create DelegatingPasswordEncoder with given options.
+// This function panics if the encoder of DelegatingEncoderOption.IdForEncode is not available
+func NewDelegatingPasswordEncoder(opts ...DelegatingEncoderOptions) *DelegatingPasswordEncoder {
+	opt := DelegatingEncoderOption{
+		Encoders: map[string]PasswordEncoder{},
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	encoder, ok := opt.Encoders[opt.IdForEncode]
+	if !ok || encoder == nil {
+		panic(fmt.Errorf("password encoder with id [%s] is not available", opt.IdForEncode))
+	}
+	return &DelegatingPasswordEncoder{
+		idForEncode:       opt.IdForEncode,
+		encoderForEncode:  encoder,
+		encoders:          opt.Encoders,
+		defaultForMatches: opt.DefaultForMatches,
+	}
+}
+
+// NewDefaultDelegatingPasswordEncoder create DelegatingPasswordEncoder that encodes with argon2id and supports
+// all encoders in this package. Passwords without "{id}" prefix are matched as bcrypt
+func NewDefaultDelegatingPasswordEncoder(opts ...DelegatingEncoderOptions) *DelegatingPasswordEncoder {
+	bcryptEncoder := NewBcryptPasswordEncoder()
+	defaults := func(opt *DelegatingEncoderOption) {
+		opt.IdForEncode = EncoderIdArgon2id
+		opt.Encoders = map[string]PasswordEncoder{
+			EncoderIdArgon2id: NewArgon2idPasswordEncoder(),
+			EncoderIdBcrypt:   bcryptEncoder,
+			EncoderIdScrypt:   NewScryptPasswordEncoder(),
+			EncoderIdPbkdf2:   NewPbkdf2PasswordEncoder(),
+			EncoderIdNoop:     NewNoopPasswordEncoder(),
+		}
+		opt.DefaultForMatches = bcryptEncoder
+	}
+	return NewDelegatingPasswordEncoder(append([]DelegatingEncoderOptions{defaults}, opts...)...)
+}
+
+func (enc *DelegatingPasswordEncoder) Encode(raw string) string {
+	encoded := enc.encoderForEncode.Encode(raw)
+	if encoded == "" {
+		return ""
+	}
+	return encoderIdPrefix + enc.idForEncode + encoderIdSuffix + encoded
+}
+
+func (enc *DelegatingPasswordEncoder) Matches(raw, encoded string) bool {
+	delegate, encoded := enc.delegate(encoded)
+	if delegate == nil {
+		return false
+	}
+	return delegate.Matches(raw, encoded)
+}
+
+// UpgradeEncoding returns true if the encoded password is not encoded by the preferred encoder,
+// or the preferred encoder reports it should be upgraded
+func (enc *DelegatingPasswordEncoder) UpgradeEncoding(encoded string) bool {
+	id, encoded, ok := extractEncoderId(encoded)
+	if !ok || id != enc.idForEncode {
+		return true
+	}
+	if upgradable, ok := enc.encoderForEncode.(UpgradablePasswordEncoder); ok {
+		return upgradable.UpgradeEncoding(encoded)
+	}
+	return false
+}
+
+func (enc *DelegatingPasswordEncoder) delegate(encoded string) (PasswordEncoder, string) {
+	id, stripped, ok := extractEncoderId(encoded)
+	if !ok {
+		return enc.defaultForMatches, encoded
+	}
+	return enc.encoders[id], stripped
+}
+
+// extractEncoderId split "{id}encoded" into "id" and "encoded". returns false if the prefix is not found
+func extractEncoderId(encoded string) (id string, stripped string, ok bool) {
+	if !strings.HasPrefix(encoded, encoderIdPrefix) {
+		return "", encoded, false
+	}
+	end := strings.Index(encoded, encoderIdSuffix)
+	if end < 0 {
+		return "", encoded, false
+	}
+	return encoded[len(encoderIdPrefix):end], encoded[end+len(encoderIdSuffix):], true
+}
@@ -17,6 +17,10 @@ func TestPasswordEncoder(t *testing.T) {
 	test.RunTest(context.Background(), t,
 		test.GomegaSubTest(SubTestBCryptPasswordEncoder(), "BCryptPasswordEncoder"),
 		test.GomegaSubTest(SubTestNoopPasswordEncoder(), "NoopPasswordEncoder"),
+		test.GomegaSubTest(SubTestArgon2idPasswordEncoder(), "Argon2idPasswordEncoder"),
+		test.GomegaSubTest(SubTestScryptPasswordEncoder(), "ScryptPasswordEncoder"),
+		test.GomegaSubTest(SubTestPbkdf2PasswordEncoder(), "Pbkdf2PasswordEncoder"),
+		test.GomegaSubTest(SubTestDelegatingPasswordEncoder(), "DelegatingPasswordEncoder"),
 	)
 }
 
@@ -66,3 +70,115 @@ func SubTestNoopPasswordEncoder() test.GomegaSubTestFunc {
         g.Expect(ok).To(BeFalse(), "wrong encoded password from other tools with different cost should not match raw password")
     }
 }
+
+func SubTestArgon2idPasswordEncoder() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		// reference vector of argon2: password "password", salt "somesalt"
+		const reference = `$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc`
+		const wrongVersion = `$argon2id$v=16$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc`
+		encoder := passwd.NewArgon2idPasswordEncoder(func(opt *passwd.Argon2idEncoderOption) {
+			opt.Memory = 8 * 1024
+			opt.Iterations = 1
+		})
+		AssertAdaptivePasswordEncoder(g, encoder, `$argon2id$v=19$m=8192,t=1,p=1$`)
+
+		g.Expect(encoder.Matches("password", reference)).To(BeTrue(), "encoded password from other tools should match raw password")
+		g.Expect(encoder.Matches("wrong", reference)).To(BeFalse(), "encoded password from other tools should not match wrong password")
+		g.Expect(encoder.Matches("password", wrongVersion)).To(BeFalse(), "encoded password of unsupported version should not match")
+		upgradable := encoder.(passwd.UpgradablePasswordEncoder)
+		g.Expect(upgradable.UpgradeEncoding(reference)).To(BeFalse(), "stronger parameters should not require upgrade")
+
+		stronger := passwd.NewArgon2idPasswordEncoder().(passwd.UpgradablePasswordEncoder)
+		g.Expect(stronger.UpgradeEncoding(encoder.Encode("password"))).To(BeTrue(), "weaker parameters should require upgrade")
+	}
+}
+
+func SubTestScryptPasswordEncoder() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const password = `test-password`
+		const reference = `$scrypt$ln=10,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$P1wOlsSuBJUoqqbUKCpjd2wYlM4d2xdzH6DnpSaHYoc`
+		encoder := passwd.NewScryptPasswordEncoder(func(opt *passwd.ScryptEncoderOption) {
+			opt.CostLog2 = 10
+		})
+		AssertAdaptivePasswordEncoder(g, encoder, `$scrypt$ln=10,r=8,p=1$`)
+
+		g.Expect(encoder.Matches(password, reference)).To(BeTrue(), "encoded password from other tools should match raw password")
+		g.Expect(encoder.Matches("wrong", reference)).To(BeFalse(), "encoded password from other tools should not match wrong password")
+
+		stronger := passwd.NewScryptPasswordEncoder().(passwd.UpgradablePasswordEncoder)
+		g.Expect(stronger.UpgradeEncoding(reference)).To(BeTrue(), "weaker parameters should require upgrade")
+	}
+}
+
+func SubTestPbkdf2PasswordEncoder() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const password = `test-password`
+		const reference = `$pbkdf2-sha256$i=1000$MDEyMzQ1Njc4OWFiY2RlZg$oKSMaLhlscPu7/MZztimoPk+MWkSqwoTU5lW8/eqKkw`
+		encoder := passwd.NewPbkdf2PasswordEncoder(func(opt *passwd.Pbkdf2EncoderOption) {
+			opt.Iterations = 1000
+		})
+		AssertAdaptivePasswordEncoder(g, encoder, `$pbkdf2-sha256$i=1000$`)
+
+		g.Expect(encoder.Matches(password, reference)).To(BeTrue(), "encoded password from other tools should match raw password")
+		g.Expect(encoder.Matches("wrong", reference)).To(BeFalse(), "encoded password from other tools should not match wrong password")
+
+		stronger := passwd.NewPbkdf2PasswordEncoder().(passwd.UpgradablePasswordEncoder)
+		g.Expect(stronger.UpgradeEncoding(reference)).To(BeTrue(), "weaker parameters should require upgrade")
+	}
+}
+
+func SubTestDelegatingPasswordEncoder() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const password = `test-password`
+		const bcrypt10 = `$2y$12$/UE0PRYdLLzqsNU3Y6aNWeDUyveQAvWAdfJPJ9PdNO3Oh23yPlXKC`
+		const pbkdf2 = `$pbkdf2-sha256$i=1000$MDEyMzQ1Njc4OWFiY2RlZg$oKSMaLhlscPu7/MZztimoPk+MWkSqwoTU5lW8/eqKkw`
+		encoder := passwd.NewDefaultDelegatingPasswordEncoder(func(opt *passwd.DelegatingEncoderOption) {
+			opt.Encoders[passwd.EncoderIdArgon2id] = passwd.NewArgon2idPasswordEncoder(func(opt *passwd.Argon2idEncoderOption) {
+				opt.Memory = 8 * 1024
+				opt.Iterations = 1
+			})
+		})
+
+		encoded := encoder.Encode(password)
+		g.Expect(encoded).To(HavePrefix(`{argon2id}$argon2id$`), "encoded password should have correct prefix")
+		g.Expect(encoder.Matches(password, encoded)).To(BeTrue(), "encoded password should match raw password")
+		g.Expect(encoder.UpgradeEncoding(encoded)).To(BeFalse(), "password encoded by preferred encoder should not require upgrade")
+
+		// mixed hashes
+		for _, v := range []string{"{bcrypt}" + bcrypt10, "{pbkdf2}" + pbkdf2, "{noop}" + password, bcrypt10} {
+			g.Expect(encoder.Matches(password, v)).To(BeTrue(), "[%s] should match raw password", v)
+			g.Expect(encoder.Matches("wrong", v)).To(BeFalse(), "[%s] should not match wrong password", v)
+			g.Expect(encoder.UpgradeEncoding(v)).To(BeTrue(), "[%s] should require upgrade", v)
+		}
+
+		g.Expect(encoder.Matches(password, "{unknown}"+password)).To(BeFalse(), "unknown encoder ID should not match")
+		g.Expect(encoder.Matches(password, "{noop"+password)).To(BeFalse(), "malformed prefix should not match")
+		g.Expect(func() {
+			passwd.NewDelegatingPasswordEncoder(func(opt *passwd.DelegatingEncoderOption) {
+				opt.IdForEncode = "unknown"
+			})
+		}).To(Panic(), "unknown encoder ID for encoding should panic")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func AssertAdaptivePasswordEncoder(g *gomega.WithT, encoder passwd.PasswordEncoder, expectedPrefix string) {
+	const password = `test-password`
+	encoded := encoder.Encode(password)
+	g.Expect(encoded).To(HavePrefix(expectedPrefix), "encoded password should have correct prefix")
+
+	another := encoder.Encode(password)
+	g.Expect(another).ToNot(Equal(encoded), "encoded password should be salted")
+
+	g.Expect(encoder.Matches(password, encoded)).To(BeTrue(), "encoded password should match raw password")
+	g.Expect(encoder.Matches("wrong", encoded)).To(BeFalse(), "encoded password should not match wrong password")
+	g.Expect(encoder.Matches(password, "malformed")).To(BeFalse(), "malformed password should not match raw password")
+	g.Expect(encoder.Matches(password, password)).To(BeFalse(), "raw password should not match")
+
+	upgradable, ok := encoder.(passwd.UpgradablePasswordEncoder)
+	g.Expect(ok).To(BeTrue(), "encoder should be upgradable")
+	g.Expect(upgradable.UpgradeEncoding(encoded)).To(BeFalse(), "password encoded with same parameters should not require upgrade")
+}
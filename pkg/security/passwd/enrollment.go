@@ -0,0 +1,312 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package passwd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"strings"
+	"time"
+)
+
+const (
+	MFAMethodTOTP = "totp"
+)
+
+// Authenticator-app TOTP settings. Most authenticator apps only support these values.
+const (
+	TOTPAppPeriod    = 30 * time.Second
+	TOTPAppDigits    = otp.DigitsSix
+	TOTPAppAlgorithm = otp.AlgorithmSHA1
+	// TOTPAppSkew allows passcode of one period before/after current time, to tolerate clock drift
+	TOTPAppSkew = 1
+)
+
+const (
+	defaultRecoveryCodeCount    = 10
+	recoveryCodeLength          = 10
+	maxEnrollmentUpdateAttempts = 3
+)
+
+// MFAEnrollment is the per-account MFA enrollment state, e.g. authenticator-app TOTP secret.
+// Accounts with MFAEnrollment verify passcode generated by their authenticator app against the stored secret,
+// instead of receiving OTP via MFAEventListenerFunc.
+type MFAEnrollment struct {
+	// Method is the enrolled MFA method, e.g. MFAMethodTOTP
+	Method string
+	// Secret is the base32 encoded TOTP secret, shared with user's authenticator app.
+	// It's recommended to encrypt it at rest.
+	Secret string
+	// RecoveryCodes are SHA-256 hashes of single-use recovery codes. See HashRecoveryCode
+	RecoveryCodes []string
+	EnrolledAt    time.Time
+	// LastUsedStep is the TOTP time step (Unix time / TOTPAppPeriod) of the last accepted passcode.
+	// Passcodes of the same or earlier time step are rejected, so a captured passcode cannot be replayed.
+	LastUsedStep uint64
+}
+
+// UseTOTPPasscode checks given passcode against the Secret and records its time step in LastUsedStep.
+// Returns true if the passcode is valid and not used before. Caller is responsible to persist the change.
+func (e *MFAEnrollment) UseTOTPPasscode(passcode string) bool {
+	step, valid, err := ValidateTOTPPasscodeAfter(e.Secret, passcode, e.LastUsedStep)
+	if err != nil || !valid {
+		return false
+	}
+	e.LastUsedStep = step
+	return true
+}
+
+// UseRecoveryCode consumes the given recovery code if it matches any remaining one.
+// Returns true if the code is valid and removed from RecoveryCodes. Caller is responsible to persist the change,
+// see UpdateMFAEnrollment.
+func (e *MFAEnrollment) UseRecoveryCode(code string) bool {
+	hashed := []byte(HashRecoveryCode(code))
+	found := -1
+	for i := range e.RecoveryCodes {
+		if subtle.ConstantTimeCompare(hashed, []byte(e.RecoveryCodes[i])) == 1 {
+			found = i
+		}
+	}
+	if found < 0 {
+		return false
+	}
+	e.RecoveryCodes = append(e.RecoveryCodes[:found], e.RecoveryCodes[found+1:]...)
+	return true
+}
+
+func (e *MFAEnrollment) clone() *MFAEnrollment {
+	cloned := *e
+	cloned.RecoveryCodes = append([]string(nil), e.RecoveryCodes...)
+	return &cloned
+}
+
+// MFAEnrollmentStore is an optional extension of security.AccountStore to persist MFAEnrollment.
+// When the account store implements this interface, password login of enrolled accounts requires passcode from
+// authenticator app or a recovery code.
+type MFAEnrollmentStore interface {
+	// LoadMFAEnrollment returns nil without error if given account is not enrolled
+	LoadMFAEnrollment(ctx context.Context, acct security.Account) (*MFAEnrollment, error)
+	// SaveMFAEnrollment creates or replaces the MFAEnrollment of given account
+	SaveMFAEnrollment(ctx context.Context, acct security.Account, enrollment *MFAEnrollment) error
+	// DeleteMFAEnrollment removes the MFAEnrollment of given account, if any
+	DeleteMFAEnrollment(ctx context.Context, acct security.Account) error
+}
+
+// AtomicMFAEnrollmentStore is an optional extension of MFAEnrollmentStore.
+// Single-use credentials (TOTP passcodes and recovery codes) are consumed by load-then-save. Without this interface,
+// two concurrent logins may both accept the same passcode or recovery code.
+// Implement this interface to make the consumption atomic, e.g. using optimistic locking.
+type AtomicMFAEnrollmentStore interface {
+	MFAEnrollmentStore
+	// CompareAndSwapMFAEnrollment replaces the MFAEnrollment of given account only if the stored one still equals to "old".
+	// Returns false without error if the stored enrollment was changed concurrently.
+	CompareAndSwapMFAEnrollment(ctx context.Context, acct security.Account, old, new *MFAEnrollment) (swapped bool, err error)
+}
+
+/*****************************
+	Helpers
+ *****************************/
+
+// NewTOTPSecret generates a random base32 encoded TOTP secret of given size in bytes
+func NewTOTPSecret(size int) (string, error) {
+	secret := make([]byte, size)
+	if _, e := rand.Read(secret); e != nil {
+		return "", e
+	}
+	return b32NoPadding.EncodeToString(secret), nil
+}
+
+// NewTOTPKey creates the "otpauth://" key of given secret, to be used by authenticator apps.
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func NewTOTPKey(issuer, accountName, secret string) (*otp.Key, error) {
+	raw, e := b32NoPadding.DecodeString(strings.ToUpper(secret))
+	if e != nil {
+		return nil, fmt.Errorf("invalid TOTP secret: %v", e)
+	}
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      uint(TOTPAppPeriod.Seconds()),
+		Secret:      raw,
+		Digits:      TOTPAppDigits,
+		Algorithm:   TOTPAppAlgorithm,
+	})
+}
+
+// ValidateTOTPPasscode checks given passcode generated by authenticator app against the secret
+func ValidateTOTPPasscode(secret, passcode string) (bool, error) {
+	return totp.ValidateCustom(passcode, secret, time.Now(), totp.ValidateOpts{
+		Period:    uint(TOTPAppPeriod.Seconds()),
+		Skew:      TOTPAppSkew,
+		Digits:    TOTPAppDigits,
+		Algorithm: TOTPAppAlgorithm,
+	})
+}
+
+// ValidateTOTPPasscodeAfter is similar to ValidateTOTPPasscode, but only accepts passcode of time step later than
+// the given one. It returns the time step of the valid passcode, to be recorded as MFAEnrollment.LastUsedStep.
+func ValidateTOTPPasscodeAfter(secret, passcode string, lastUsedStep uint64) (step uint64, valid bool, err error) {
+	period := uint64(TOTPAppPeriod.Seconds())
+	current := uint64(time.Now().Unix()) / period
+	for i := current - TOTPAppSkew; i <= current+TOTPAppSkew; i++ {
+		if i <= lastUsedStep {
+			continue
+		}
+		valid, err = totp.ValidateCustom(passcode, secret, time.Unix(int64(i*period), 0).UTC(), totp.ValidateOpts{
+			Period:    uint(period),
+			Digits:    TOTPAppDigits,
+			Algorithm: TOTPAppAlgorithm,
+		})
+		if err != nil || valid {
+			return i, valid, err
+		}
+	}
+	return 0, false, nil
+}
+
+// NewRecoveryCodes generates given number of recovery codes. It returns the plain codes to be shown to the user once,
+// and their hashes to be stored in MFAEnrollment.RecoveryCodes
+func NewRecoveryCodes(count int) (codes []string, hashed []string, err error) {
+	if count <= 0 {
+		count = defaultRecoveryCodeCount
+	}
+	codes = make([]string, count)
+	hashed = make([]string, count)
+	for i := range codes {
+		raw := make([]byte, recoveryCodeLength)
+		if _, e := rand.Read(raw); e != nil {
+			return nil, nil, e
+		}
+		code := strings.ToLower(b32NoPadding.EncodeToString(raw))[:recoveryCodeLength]
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashed[i] = HashRecoveryCode(codes[i])
+	}
+	return
+}
+
+// HashRecoveryCode returns hex encoded SHA-256 of normalized recovery code.
+// Recovery codes are case-insensitive and dashes/spaces are ignored.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func loadMFAEnrollment(ctx context.Context, store security.AccountStore, acct security.Account) (*MFAEnrollment, error) {
+	enrollmentStore, ok := store.(MFAEnrollmentStore)
+	if !ok {
+		return nil, nil
+	}
+	return enrollmentStore.LoadMFAEnrollment(ctx, acct)
+}
+
+// UseMFAPasscode consumes given TOTP passcode or recovery code of the enrolled account.
+// Returns false without error if the account is not enrolled or the passcode is invalid or already used.
+func UseMFAPasscode(ctx context.Context, store MFAEnrollmentStore, acct security.Account, passcode string) (bool, error) {
+	return UpdateMFAEnrollment(ctx, store, acct, func(enrollment *MFAEnrollment) bool {
+		return enrollment.UseTOTPPasscode(passcode) || enrollment.UseRecoveryCode(passcode)
+	})
+}
+
+// VerifyStepUp verifies that current user may perform a sensitive operation (e.g. changing MFA enrollment or credentials).
+// If passcode is provided, it has to be a current TOTP passcode or recovery code of the user's MFAEnrollment, and is consumed.
+// Otherwise, the current authentication has to be no older than maxAge.
+func VerifyStepUp(ctx context.Context, accountStore security.AccountStore, username string, passcode string, maxAge time.Duration) error {
+	if len(passcode) != 0 {
+		store, ok := accountStore.(MFAEnrollmentStore)
+		if !ok {
+			return security.NewAccessDeniedError("passcode is not supported")
+		}
+		acct, e := accountStore.LoadAccountByUsername(ctx, username)
+		if e != nil {
+			return security.NewAccessDeniedError("unable to load account", e)
+		}
+		switch used, e := UseMFAPasscode(ctx, store, acct, passcode); {
+		case e != nil:
+			return e
+		case !used:
+			return security.NewAccessDeniedError("invalid or already used passcode")
+		}
+		return nil
+	}
+	authTime := security.DetermineAuthenticationTime(ctx, security.Get(ctx))
+	if maxAge > 0 && !authTime.IsZero() && time.Since(authTime) <= maxAge {
+		return nil
+	}
+	return security.NewAccessDeniedError("current passcode, recovery code or recent login is required")
+}
+
+// UpdateMFAEnrollment loads the MFAEnrollment of given account, applies "mutate" on a copy and saves it if "mutate" returns true.
+// When the store implements AtomicMFAEnrollmentStore, the copy is saved only if the enrollment is not changed concurrently,
+// otherwise "mutate" is retried on freshly loaded enrollment.
+// Returns false without error if the account is not enrolled, "mutate" returns false or the conflict persists.
+func UpdateMFAEnrollment(ctx context.Context, store MFAEnrollmentStore, acct security.Account, mutate func(enrollment *MFAEnrollment) bool) (bool, error) {
+	atomicStore, atomic := store.(AtomicMFAEnrollmentStore)
+	for i := 0; i < maxEnrollmentUpdateAttempts; i++ {
+		current, e := store.LoadMFAEnrollment(ctx, acct)
+		if e != nil || current == nil {
+			return false, e
+		}
+		updated := current.clone()
+		if !mutate(updated) {
+			return false, nil
+		}
+		if !atomic {
+			if e := store.SaveMFAEnrollment(ctx, acct, updated); e != nil {
+				return false, e
+			}
+			return true, nil
+		}
+		switch swapped, e := atomicStore.CompareAndSwapMFAEnrollment(ctx, acct, current, updated); {
+		case e != nil:
+			return false, e
+		case swapped:
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func useMFAPasscode(ctx context.Context, store security.AccountStore, acct security.Account, passcode string) (bool, error) {
+	enrollmentStore, ok := store.(MFAEnrollmentStore)
+	if !ok {
+		return false, nil
+	}
+	return UseMFAPasscode(ctx, enrollmentStore, acct, passcode)
+}
+
+func useRecoveryCode(ctx context.Context, store security.AccountStore, acct security.Account, code string) (bool, error) {
+	enrollmentStore, ok := store.(MFAEnrollmentStore)
+	if !ok {
+		return false, nil
+	}
+	return UpdateMFAEnrollment(ctx, enrollmentStore, acct, func(enrollment *MFAEnrollment) bool {
+		return enrollment.UseRecoveryCode(code)
+	})
+}
@@ -0,0 +1,388 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package passwd_test
+
+import (
+	"context"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	. "github.com/cisco-open/go-lanai/test/utils/gomega"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"github.com/pquerna/otp/totp"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*************************
+	Test
+ *************************/
+
+func TestMFAEnrollment(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestTOTPKey(), "TOTPKey"),
+		test.GomegaSubTest(SubTestEnrolledTOTPLogin(), "EnrolledTOTPLogin"),
+		test.GomegaSubTest(SubTestRecoveryCodeLogin(), "RecoveryCodeLogin"),
+		test.GomegaSubTest(SubTestRecoveryCodeLastAttempt(), "RecoveryCodeLastAttempt"),
+		test.GomegaSubTest(SubTestEnrolledTOTPRefresh(), "EnrolledTOTPRefresh"),
+		test.GomegaSubTest(SubTestEnrolledTOTPReplay(), "EnrolledTOTPReplay"),
+		test.GomegaSubTest(SubTestRecoveryCodeConcurrentUse(), "RecoveryCodeConcurrentUse"),
+		test.GomegaSubTest(SubTestEnrolledWithBasicOTPManager(), "EnrolledWithBasicOTPManager"),
+		test.GomegaSubTest(SubTestVerifyStepUp(), "VerifyStepUp"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestTOTPKey() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		secret, e := passwd.NewTOTPSecret(20)
+		g.Expect(e).To(Succeed(), "generating secret should not fail")
+		key, e := passwd.NewTOTPKey("test-issuer", TestUser, secret)
+		g.Expect(e).To(Succeed(), "creating key should not fail")
+		g.Expect(key.URL()).To(HavePrefix("otpauth://totp/test-issuer:"+TestUser), "URI should be correct")
+		g.Expect(key.Secret()).To(Equal(secret), "secret should be correct")
+		g.Expect(key.Period()).To(BeEquivalentTo(30), "period should be correct")
+
+		passcode, e := totp.GenerateCode(secret, time.Now())
+		g.Expect(e).To(Succeed(), "generating passcode should not fail")
+		valid, e := passwd.ValidateTOTPPasscode(secret, passcode)
+		g.Expect(e).To(Succeed(), "validating passcode should not fail")
+		g.Expect(valid).To(BeTrue(), "passcode should be valid")
+	}
+}
+
+func SubTestEnrolledTOTPLogin() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword))
+		secret, _ := store.MustEnroll(ctx, g, TestUser)
+		authenticator := NewTestMFAAuthenticator(ctx, g, store)
+
+		auth := MustLoginWithEnrolledMFA(ctx, g, authenticator)
+
+		// wrong passcode
+		_, e := authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         "000000x",
+		})
+		g.Expect(e).To(HaveOccurred(), "verification should fail")
+		g.Expect(e).To(IsError(security.NewBadCredentialsError("")), "error should be correct")
+
+		// passcode from authenticator app
+		passcode, e := totp.GenerateCode(secret, time.Now())
+		g.Expect(e).To(Succeed(), "generating passcode should not fail")
+		verified, e := authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         passcode,
+		})
+		g.Expect(e).To(Succeed(), "verification should not fail")
+		g.Expect(verified.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+	}
+}
+
+func SubTestRecoveryCodeLogin() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword))
+		_, codes := store.MustEnroll(ctx, g, TestUser)
+		authenticator := NewTestMFAAuthenticator(ctx, g, store)
+
+		// recovery code is case-insensitive and dashes are ignored
+		auth := MustLoginWithEnrolledMFA(ctx, g, authenticator)
+		verified, e := authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         strings.ToUpper(strings.ReplaceAll(codes[0], "-", "")),
+		})
+		g.Expect(e).To(Succeed(), "verification with recovery code should not fail")
+		g.Expect(verified.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+		g.Expect(store.enrollments[TestUser].RecoveryCodes).To(HaveLen(len(codes)-1), "recovery code should be consumed")
+
+		// recovery code is single-use
+		auth = MustLoginWithEnrolledMFA(ctx, g, authenticator)
+		_, e = authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         codes[0],
+		})
+		g.Expect(e).To(HaveOccurred(), "verification with used recovery code should fail")
+	}
+}
+
+func SubTestRecoveryCodeLastAttempt() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword))
+		_, codes := store.MustEnroll(ctx, g, TestUser)
+		authenticator := NewTestMFAAuthenticator(ctx, g, store)
+
+		// use up all but the last attempt
+		auth := MustLoginWithEnrolledMFA(ctx, g, authenticator)
+		for i := 0; i < 2; i++ {
+			_, e := authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+				CurrentAuth: auth,
+				OTP:         "000000",
+			})
+			g.Expect(e).To(HaveOccurred(), "verification with wrong passcode should fail")
+			g.Expect(e).To(IsError(security.NewBadCredentialsError("")), "error should be correct")
+		}
+
+		// recovery code is accepted on the last attempt
+		verified, e := authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         codes[0],
+		})
+		g.Expect(e).To(Succeed(), "verification with recovery code on last attempt should not fail")
+		g.Expect(verified.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+		g.Expect(store.enrollments[TestUser].RecoveryCodes).To(HaveLen(len(codes)-1), "recovery code should be consumed")
+
+		// attempts are exhausted afterwards
+		_, e = authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         codes[1],
+		})
+		g.Expect(e).To(HaveOccurred(), "verification after max attempts should fail")
+		g.Expect(store.enrollments[TestUser].RecoveryCodes).To(HaveLen(len(codes)-1), "recovery code should not be consumed")
+	}
+}
+
+func SubTestEnrolledTOTPRefresh() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword))
+		store.MustEnroll(ctx, g, TestUser)
+		authenticator := NewTestMFAAuthenticator(ctx, g, store)
+
+		auth := MustLoginWithEnrolledMFA(ctx, g, authenticator)
+		_, e := authenticator.Authenticate(ctx, &passwd.MFAOtpRefresh{CurrentAuth: auth})
+		g.Expect(e).To(HaveOccurred(), "refresh should fail")
+		g.Expect(e).To(IsError(security.NewBadCredentialsError("")), "error should be correct")
+	}
+}
+
+func SubTestEnrolledTOTPReplay() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword))
+		secret, _ := store.MustEnroll(ctx, g, TestUser)
+		authenticator := NewTestMFAAuthenticator(ctx, g, store)
+
+		passcode, e := totp.GenerateCode(secret, time.Now())
+		g.Expect(e).To(Succeed(), "generating passcode should not fail")
+		auth := MustLoginWithEnrolledMFA(ctx, g, authenticator)
+		_, e = authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         passcode,
+		})
+		g.Expect(e).To(Succeed(), "verification should not fail")
+		g.Expect(store.enrollments[TestUser].LastUsedStep).ToNot(BeZero(), "time step should be recorded")
+
+		// same passcode cannot be used again
+		auth = MustLoginWithEnrolledMFA(ctx, g, authenticator)
+		_, e = authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: auth,
+			OTP:         passcode,
+		})
+		g.Expect(e).To(HaveOccurred(), "verification with used passcode should fail")
+		g.Expect(e).To(IsError(security.NewBadCredentialsError("")), "error should be correct")
+
+		// passcode of earlier time step is also rejected
+		earlier, e := totp.GenerateCode(secret, time.Now().Add(-passwd.TOTPAppPeriod))
+		g.Expect(e).To(Succeed(), "generating passcode should not fail")
+		_, valid, e := passwd.ValidateTOTPPasscodeAfter(secret, earlier, store.enrollments[TestUser].LastUsedStep)
+		g.Expect(e).To(Succeed(), "validating passcode should not fail")
+		g.Expect(valid).To(BeFalse(), "passcode of earlier time step should be invalid")
+	}
+}
+
+func SubTestRecoveryCodeConcurrentUse() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := &AtomicEnrollmentStore{
+			EnrollmentAwareAccountStore: NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword)),
+		}
+		_, codes := store.MustEnroll(ctx, g, TestUser)
+		acct, e := store.LoadAccountByUsername(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "loading account should not fail")
+
+		// another login consumes the same code between load and save
+		var concurrentUsed bool
+		store.beforeSwap = func() {
+			store.beforeSwap = nil
+			concurrentUsed, e = passwd.UseMFAPasscode(ctx, store, acct, codes[0])
+			g.Expect(e).To(Succeed(), "concurrent use should not fail")
+		}
+		used, e := passwd.UseMFAPasscode(ctx, store, acct, codes[0])
+		g.Expect(e).To(Succeed(), "using recovery code should not fail")
+		g.Expect(concurrentUsed).To(BeTrue(), "concurrent use should succeed")
+		g.Expect(used).To(BeFalse(), "recovery code should not be used twice")
+		g.Expect(store.enrollments[TestUser].RecoveryCodes).To(HaveLen(len(codes)-1), "recovery code should be consumed once")
+	}
+}
+
+func SubTestEnrolledWithBasicOTPManager() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword))
+		store.MustEnroll(ctx, g, TestUser)
+		authenticator := passwd.NewAuthenticator(func(opts *passwd.AuthenticatorOptions) {
+			opts.AccountStore = store
+			opts.OTPManager = BasicOTPManager{}
+		})
+
+		_, e := authenticator.Authenticate(ctx, NewCredentialsCandidate(TestUser, TestUserPassword))
+		g.Expect(e).To(HaveOccurred(), "authentication should fail")
+		g.Expect(e).To(IsError(security.NewInternalAuthenticationError("")), "error should be correct")
+	}
+}
+
+func SubTestVerifyStepUp() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewEnrollmentAwareAccountStore(NewAccount(TestUser, TestUserPassword))
+		_, codes := store.MustEnroll(ctx, g, TestUser)
+
+		// passcode or recovery code
+		e := passwd.VerifyStepUp(ctx, store, TestUser, codes[0], 0)
+		g.Expect(e).To(Succeed(), "step-up with recovery code should not fail")
+		e = passwd.VerifyStepUp(ctx, store, TestUser, codes[0], 0)
+		g.Expect(e).To(HaveOccurred(), "step-up with used recovery code should fail")
+		e = passwd.VerifyStepUp(ctx, store, TestUser, "", time.Minute)
+		g.Expect(e).To(HaveOccurred(), "step-up without passcode and authentication should fail")
+
+		// recent login
+		authenticator := NewTestMFAAuthenticator(ctx, g, store)
+		auth, e := authenticator.Authenticate(ctx, &passwd.MFAOtpVerification{
+			CurrentAuth: MustLoginWithEnrolledMFA(ctx, g, authenticator),
+			OTP:         codes[1],
+		})
+		g.Expect(e).To(Succeed(), "verification with recovery code should not fail")
+		secCtx := sectest.ContextWithSecurity(ctx, sectest.Authentication(auth))
+		e = passwd.VerifyStepUp(secCtx, store, TestUser, "", time.Minute)
+		g.Expect(e).To(Succeed(), "step-up with recent login should not fail")
+		e = passwd.VerifyStepUp(secCtx, store, TestUser, "", 0)
+		g.Expect(e).To(HaveOccurred(), "step-up without max age should fail")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+// EnrollmentAwareAccountStore implements passwd.MFAEnrollmentStore
+type EnrollmentAwareAccountStore struct {
+	*sectest.MockAccountStore
+	enrollments map[string]*passwd.MFAEnrollment
+}
+
+func NewEnrollmentAwareAccountStore(acct security.Account) *EnrollmentAwareAccountStore {
+	store := sectest.NewMockedAccountStore([]*sectest.MockedAccountProperties{
+		{UserId: acct.ID().(string), Username: acct.Username()},
+	}, func(security.Account) security.Account {
+		return acct
+	})
+	return &EnrollmentAwareAccountStore{
+		MockAccountStore: store,
+		enrollments:      map[string]*passwd.MFAEnrollment{},
+	}
+}
+
+func (s *EnrollmentAwareAccountStore) LoadMFAEnrollment(_ context.Context, acct security.Account) (*passwd.MFAEnrollment, error) {
+	return s.enrollments[acct.Username()], nil
+}
+
+func (s *EnrollmentAwareAccountStore) SaveMFAEnrollment(_ context.Context, acct security.Account, enrollment *passwd.MFAEnrollment) error {
+	s.enrollments[acct.Username()] = enrollment
+	return nil
+}
+
+func (s *EnrollmentAwareAccountStore) DeleteMFAEnrollment(_ context.Context, acct security.Account) error {
+	delete(s.enrollments, acct.Username())
+	return nil
+}
+
+// AtomicEnrollmentStore implements passwd.AtomicMFAEnrollmentStore
+type AtomicEnrollmentStore struct {
+	*EnrollmentAwareAccountStore
+	beforeSwap func()
+}
+
+func (s *AtomicEnrollmentStore) CompareAndSwapMFAEnrollment(_ context.Context, acct security.Account, old, new *passwd.MFAEnrollment) (bool, error) {
+	if s.beforeSwap != nil {
+		s.beforeSwap()
+	}
+	if !reflect.DeepEqual(s.enrollments[acct.Username()], old) {
+		return false, nil
+	}
+	s.enrollments[acct.Username()] = new
+	return true, nil
+}
+
+func (s *EnrollmentAwareAccountStore) MustEnroll(ctx context.Context, g *gomega.WithT, username string) (secret string, codes []string) {
+	secret, e := passwd.NewTOTPSecret(20)
+	g.Expect(e).To(Succeed(), "generating secret should not fail")
+	codes, hashed, e := passwd.NewRecoveryCodes(3)
+	g.Expect(e).To(Succeed(), "generating recovery codes should not fail")
+	g.Expect(codes).To(HaveLen(3), "recovery codes should be generated")
+	acct, e := s.LoadAccountByUsername(ctx, username)
+	g.Expect(e).To(Succeed(), "loading account should not fail")
+	e = s.SaveMFAEnrollment(ctx, acct, &passwd.MFAEnrollment{
+		Method:        passwd.MFAMethodTOTP,
+		Secret:        secret,
+		RecoveryCodes: hashed,
+		EnrolledAt:    time.Now(),
+	})
+	g.Expect(e).To(Succeed(), "saving enrollment should not fail")
+	return
+}
+
+func NewTestMFAAuthenticator(ctx context.Context, g *gomega.WithT, store security.AccountStore) security.Authenticator {
+	authn, e := passwd.NewAuthenticatorBuilder(passwd.New().
+		AccountStore(store).MFA(true),
+	).Build(ctx)
+	g.Expect(e).To(Succeed(), "building authenticator should not fail")
+	return authn
+}
+
+func MustLoginWithEnrolledMFA(ctx context.Context, g *gomega.WithT, authenticator security.Authenticator) passwd.UsernamePasswordAuthentication {
+	auth, e := authenticator.Authenticate(ctx, NewCredentialsCandidate(TestUser, TestUserPassword))
+	g.Expect(e).To(Succeed(), "authentication should not fail")
+	g.Expect(auth.State()).To(Equal(security.StatePrincipalKnown), "MFA should be pending")
+	g.Expect(auth.Permissions()).To(HaveKey(passwd.SpecialPermissionOtpId), "OTP should be created")
+	return auth.(passwd.UsernamePasswordAuthentication)
+}
+
+// BasicOTPManager implements passwd.OTPManager but not passwd.EnrolledOTPManager
+type BasicOTPManager struct{}
+
+func (m BasicOTPManager) New() (passwd.OTP, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m BasicOTPManager) Get(_ string) (passwd.OTP, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m BasicOTPManager) Verify(_, _ string) (passwd.OTP, bool, error) {
+	return nil, false, errors.New("not supported")
+}
+
+func (m BasicOTPManager) Refresh(_ string) (passwd.OTP, bool, error) {
+	return nil, false, errors.New("not supported")
+}
+
+func (m BasicOTPManager) Delete(_ string) error {
+	return errors.New("not supported")
+}
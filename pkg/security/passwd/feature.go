@@ -25,6 +25,7 @@ import (
 type PasswordAuthFeature struct {
 	accountStore    security.AccountStore
 	passwordEncoder PasswordEncoder
+	decisionMakers  []AuthenticationDecisionMaker
 
 	// MFA support
 	mfaEnabled        bool
@@ -64,6 +65,12 @@ func (f *PasswordAuthFeature) PasswordEncoder(pe PasswordEncoder) *PasswordAuthF
 	return f
 }
 
+// DecisionMakers adds additional AuthenticationDecisionMaker to the authenticators, in addition to the default ones
+func (f *PasswordAuthFeature) DecisionMakers(decisionMakers ...AuthenticationDecisionMaker) *PasswordAuthFeature {
+	f.decisionMakers = append(f.decisionMakers, decisionMakers...)
+	return f
+}
+
 func (f *PasswordAuthFeature) MFA(enabled bool) *PasswordAuthFeature {
 	f.mfaEnabled = enabled
 	return f
@@ -45,6 +45,8 @@ type OTP interface {
 	IncrementRefreshes()
 
 	secret() string
+	enrolled() bool
+	username() string
 }
 
 type OTPManager interface {
@@ -70,6 +72,16 @@ type OTPManager interface {
 	Delete(id string) error
 }
 
+// EnrolledOTPManager is an optional interface of OTPManager that supports MFAEnrollment with authenticator app.
+// Accounts with MFAEnrollment cannot login when the OTPManager doesn't implement this interface
+type EnrolledOTPManager interface {
+	// NewEnrolled create new OTP for the given enrolled username and save it.
+	// Neither passcode nor secret is kept in the OTP, the user is expected to get the passcode from the enrolled
+	// authenticator app, and the passcode is verified against the MFAEnrollment loaded from MFAEnrollmentStore.
+	// OTPManager.Verify on such OTP only counts the attempt and always reports mismatch.
+	NewEnrolled(username string) (OTP, error)
+}
+
 type OTPStore interface {
 	Save(OTP) error
 	Load(id string) (OTP, error)
@@ -86,12 +98,24 @@ type timeBasedOtp struct {
 	Value        TOTP
 	AttemptCount uint
 	RefreshCount uint
+	// Enrolled indicates the passcode is generated by user's authenticator app using enrolled secret.
+	// The secret is not stored in such OTP, only the Username to look up the MFAEnrollment
+	Enrolled bool
+	Username string
 }
 
 func (v *timeBasedOtp) secret() string {
 	return v.Value.Secret
 }
 
+func (v *timeBasedOtp) enrolled() bool {
+	return v.Enrolled
+}
+
+func (v *timeBasedOtp) username() string {
+	return v.Username
+}
+
 func (v *timeBasedOtp) ID() string {
 	return v.Identifier
 }
@@ -172,6 +196,30 @@ func (m *totpManager) New() (OTP, error) {
 	return otp, nil
 }
 
+func (m *totpManager) NewEnrolled(username string) (OTP, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to create TOTP")
+	}
+
+	// Note: TTL is the period used by authenticator apps, Expire is how long the user can attempt to verify
+	otp := &timeBasedOtp{
+		Identifier: id.String(),
+		Value: TOTP{
+			TTL:    TOTPAppPeriod,
+			Expire: time.Now().Add(m.ttl),
+		},
+		Enrolled: true,
+		Username: username,
+	}
+
+	// save
+	if err := m.store.Save(otp); err != nil {
+		return nil, err
+	}
+	return otp, nil
+}
+
 func (m *totpManager) Get(id string) (OTP, error) {
 	otp, err := m.store.Load(id)
 	if err != nil {
@@ -204,7 +252,14 @@ func (m *totpManager) Verify(id, passcode string) (loaded OTP, hasMoreChances bo
 
 	loaded = otp
 	hasMoreChances = otp.Attempts() < m.maxVerifyLimit
-	if valid, e := m.factory.Validate(toValidate); e != nil || !valid {
+	validate := m.factory.Validate
+	if otp.enrolled() {
+		// secret is not available, the caller verifies the passcode against MFAEnrollment
+		validate = func(TOTP) (bool, error) {
+			return false, nil
+		}
+	}
+	if valid, e := validate(toValidate); e != nil || !valid {
 		if hasMoreChances {
 			err = security.NewBadCredentialsError("Passcode doesn't match", e)
 		} else {
@@ -226,6 +281,11 @@ func (m *totpManager) Refresh(id string) (loaded OTP, hasMoreChances bool, err e
 		return nil, false, security.NewCredentialsExpiredError("Passcode expired", e)
 	}
 
+	// passcode of authenticator app cannot be resent
+	if otp.enrolled() {
+		return loaded, true, security.NewBadCredentialsError("Passcode is generated by authenticator app")
+	}
+
 	// schedule for post refresh
 	defer m.cleanup(otp)
 
@@ -46,6 +46,7 @@ func TestOTPManagerWithInMemoryOTPStore(t *testing.T) {
 		test.GomegaSubTest(SubTestVerifyWrongPasscode(&di), "VerifyWrongPasscode"),
 		test.GomegaSubTest(SubTestRefreshPasscode(&di), "RefreshPasscode"),
 		test.GomegaSubTest(SubTestDeleteOTP(&di), "DeleteOTP"),
+		test.GomegaSubTest(SubTestEnrolledOTP(&di), "EnrolledOTP"),
 	)
 }
 
@@ -64,6 +65,7 @@ func TestOTPManagerWithRedisOTPStore(t *testing.T) {
         test.GomegaSubTest(SubTestVerifyWrongPasscode(&di), "VerifyWrongPasscode"),
         test.GomegaSubTest(SubTestRefreshPasscode(&di), "RefreshPasscode"),
         test.GomegaSubTest(SubTestDeleteOTP(&di), "DeleteOTP"),
+        test.GomegaSubTest(SubTestEnrolledOTP(&di), "EnrolledOTP"),
     )
 }
 
@@ -205,3 +207,42 @@ func SubTestDeleteOTP(di *OtpDI) test.GomegaSubTestFunc {
         g.Expect(otp).To(BeNil(), "get deleted OTP should return nil")
     }
 }
+
+func SubTestEnrolledOTP(di *OtpDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const username = "test-user"
+		manager := newTotpManager(func(manager *totpManager) {
+			manager.store = di.Store
+			manager.factory = newTotpFactory()
+			manager.maxVerifyLimit = 2
+		})
+		otp, e := manager.NewEnrolled(username)
+		g.Expect(e).To(Succeed(), "NewEnrolled() should not fail")
+		g.Expect(otp.ID()).ToNot(BeEmpty(), "OTP id should be correct")
+
+		stored, e := di.Store.Load(otp.ID())
+		g.Expect(e).To(Succeed(), "load enrolled OTP should not fail")
+		g.Expect(stored.enrolled()).To(BeTrue(), "stored OTP should be enrolled")
+		g.Expect(stored.username()).To(Equal(username), "stored OTP should reference the username")
+		g.Expect(stored.secret()).To(BeEmpty(), "stored OTP should not contain the enrolled secret")
+		g.Expect(stored.Passcode()).To(BeEmpty(), "stored OTP should not contain passcode")
+
+		// 1
+		loaded, moreChance, e := manager.Verify(otp.ID(), "123456")
+		g.Expect(e).To(HaveOccurred(), "Verify() of enrolled OTP should report mismatch")
+		g.Expect(loaded).ToNot(BeNil(), "Verify() should return loaded OTP")
+		g.Expect(loaded.Attempts()).To(BeEquivalentTo(1), "Verify() should count the attempt")
+		g.Expect(moreChance).To(BeTrue(), "Verify() return correct 'more chances'")
+
+		// 2
+		loaded, moreChance, e = manager.Verify(otp.ID(), "123456")
+		g.Expect(e).To(HaveOccurred(), "Verify() of enrolled OTP should report mismatch")
+		g.Expect(loaded).ToNot(BeNil(), "Verify() should return loaded OTP")
+		g.Expect(moreChance).To(BeFalse(), "Verify() return correct 'more chances'")
+
+		// 3
+		loaded, _, e = manager.Verify(otp.ID(), "123456")
+		g.Expect(e).To(HaveOccurred(), "Verify() should fail after max attempts")
+		g.Expect(loaded).To(BeNil(), "Verify() should not return OTP after max attempts")
+	}
+}
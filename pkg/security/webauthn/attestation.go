@@ -0,0 +1,194 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	AttestationFormatNone   = "none"
+	AttestationFormatPacked = "packed"
+)
+
+// Attestation types. See https://www.w3.org/TR/webauthn-2/#sctn-attestation-types
+const (
+	AttestationTypeNone  = "none"
+	AttestationTypeSelf  = "self"
+	AttestationTypeBasic = "basic"
+)
+
+// oidFidoGenCeAAGUID is the certificate extension "id-fido-gen-ce-aaguid"
+var oidFidoGenCeAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// AttestationObject is the CBOR decoded "attestationObject".
+// See https://www.w3.org/TR/webauthn-2/#sctn-attestation
+type AttestationObject struct {
+	Format      string                 `cbor:"fmt"`
+	Statement   map[string]interface{} `cbor:"attStmt"`
+	RawAuthData []byte                 `cbor:"authData"`
+	AuthData    *AuthenticatorData     `cbor:"-"`
+}
+
+// ParseAttestationObject decodes CBOR encoded attestation object and its authenticator data
+func ParseAttestationObject(raw []byte) (*AttestationObject, error) {
+	var obj AttestationObject
+	if e := cbor.Unmarshal(raw, &obj); e != nil {
+		return nil, fmt.Errorf("invalid attestation object: %v", e)
+	}
+	authData, e := ParseAuthenticatorData(obj.RawAuthData)
+	if e != nil {
+		return nil, e
+	}
+	obj.AuthData = authData
+	return &obj, nil
+}
+
+// AttestationVerifier verifies attestation statement of given format and returns the attestation type.
+// roots is optional. When provided, attestation certificates should chain up to one of them.
+type AttestationVerifier func(obj *AttestationObject, clientDataHash []byte, roots *x509.CertPool) (string, error)
+
+var attestationVerifiers = map[string]AttestationVerifier{
+	AttestationFormatNone:   verifyNoneAttestation,
+	AttestationFormatPacked: verifyPackedAttestation,
+}
+
+// VerifyAttestation verifies attestation statement according to its format.
+// When roots is nil, the trust path of attestation certificates is not validated.
+func VerifyAttestation(obj *AttestationObject, clientDataHash []byte, roots *x509.CertPool) (string, error) {
+	verifier, ok := attestationVerifiers[obj.Format]
+	if !ok {
+		return "", fmt.Errorf("unsupported attestation format [%s]", obj.Format)
+	}
+	return verifier(obj, clientDataHash, roots)
+}
+
+// verifyNoneAttestation See https://www.w3.org/TR/webauthn-2/#sctn-none-attestation
+func verifyNoneAttestation(obj *AttestationObject, _ []byte, _ *x509.CertPool) (string, error) {
+	if len(obj.Statement) != 0 {
+		return "", errors.New(`attestation statement of format "none" should be empty`)
+	}
+	return AttestationTypeNone, nil
+}
+
+// verifyPackedAttestation See https://www.w3.org/TR/webauthn-2/#sctn-packed-attestation
+func verifyPackedAttestation(obj *AttestationObject, clientDataHash []byte, roots *x509.CertPool) (string, error) {
+	alg, ok := coseInt(obj.Statement["alg"])
+	if !ok {
+		return "", errors.New(`packed attestation statement is missing "alg"`)
+	}
+	sig, ok := obj.Statement["sig"].([]byte)
+	if !ok {
+		return "", errors.New(`packed attestation statement is missing "sig"`)
+	}
+	if _, ok := obj.Statement["ecdaaKeyId"]; ok {
+		return "", errors.New("ECDAA attestation is not supported")
+	}
+	signed := append(append([]byte{}, obj.RawAuthData...), clientDataHash...)
+
+	x5c, ok := obj.Statement["x5c"].([]interface{})
+	if !ok {
+		// self attestation
+		pub, credAlg, e := ParsePublicKey(obj.AuthData.AttestedCredential.PublicKey)
+		if e != nil {
+			return "", e
+		}
+		if COSEAlgorithm(alg) != credAlg {
+			return "", errors.New("self attestation algorithm doesn't match credential public key")
+		}
+		if e := VerifySignature(pub, credAlg, signed, sig); e != nil {
+			return "", fmt.Errorf("invalid self attestation signature: %v", e)
+		}
+		return AttestationTypeSelf, nil
+	}
+
+	certs, e := parseCertificateChain(x5c)
+	if e != nil {
+		return "", e
+	}
+	leaf := certs[0]
+	if e := VerifySignature(leaf.PublicKey, COSEAlgorithm(alg), signed, sig); e != nil {
+		return "", fmt.Errorf("invalid packed attestation signature: %v", e)
+	}
+	if e := verifyPackedCertificate(leaf, obj.AuthData.AttestedCredential.AAGUID); e != nil {
+		return "", e
+	}
+	if roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, e := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if e != nil {
+			return "", fmt.Errorf("untrusted attestation certificate: %v", e)
+		}
+	}
+	return AttestationTypeBasic, nil
+}
+
+// verifyPackedCertificate See https://www.w3.org/TR/webauthn-2/#sctn-packed-attestation-cert-requirements
+func verifyPackedCertificate(cert *x509.Certificate, aaguid []byte) error {
+	switch {
+	case cert.Version != 3:
+		return errors.New("attestation certificate should be version 3")
+	case !cert.BasicConstraintsValid || cert.IsCA:
+		return errors.New("attestation certificate should not be a CA")
+	case len(cert.Subject.OrganizationalUnit) == 0 || cert.Subject.OrganizationalUnit[0] != "Authenticator Attestation":
+		return errors.New(`attestation certificate's subject OU should be "Authenticator Attestation"`)
+	}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidFidoGenCeAAGUID) {
+			continue
+		}
+		if ext.Critical {
+			return errors.New("AAGUID extension of attestation certificate should not be critical")
+		}
+		var value []byte
+		if _, e := asn1.Unmarshal(ext.Value, &value); e != nil || !bytes.Equal(value, aaguid) {
+			return errors.New("AAGUID of attestation certificate doesn't match authenticator data")
+		}
+	}
+	return nil
+}
+
+func parseCertificateChain(x5c []interface{}) ([]*x509.Certificate, error) {
+	if len(x5c) == 0 {
+		return nil, errors.New(`attestation statement's "x5c" is empty`)
+	}
+	certs := make([]*x509.Certificate, len(x5c))
+	for i, v := range x5c {
+		der, ok := v.([]byte)
+		if !ok {
+			return nil, errors.New(`invalid attestation statement "x5c"`)
+		}
+		cert, e := x509.ParseCertificate(der)
+		if e != nil {
+			return nil, fmt.Errorf("invalid attestation certificate: %v", e)
+		}
+		certs[i] = cert
+	}
+	return certs, nil
+}
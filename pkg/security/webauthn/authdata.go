@@ -0,0 +1,107 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// AuthenticatorFlags is the flags byte of authenticator data.
+// See https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+type AuthenticatorFlags byte
+
+const (
+	FlagUserPresent            AuthenticatorFlags = 0x01
+	FlagUserVerified           AuthenticatorFlags = 0x04
+	FlagBackupEligible         AuthenticatorFlags = 0x08
+	FlagBackupState            AuthenticatorFlags = 0x10
+	FlagAttestedCredentialData AuthenticatorFlags = 0x40
+	FlagExtensionData          AuthenticatorFlags = 0x80
+)
+
+func (f AuthenticatorFlags) Has(flag AuthenticatorFlags) bool {
+	return f&flag == flag
+}
+
+const (
+	authDataMinLength = 37
+	aaguidLength      = 16
+)
+
+// AuthenticatorData is the parsed authenticator data
+type AuthenticatorData struct {
+	Raw                []byte
+	RPIDHash           []byte
+	Flags              AuthenticatorFlags
+	SignCount          uint32
+	AttestedCredential *AttestedCredentialData
+}
+
+// AttestedCredentialData is present in authenticator data during registration
+type AttestedCredentialData struct {
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// ParseAuthenticatorData parses raw authenticator data
+func ParseAuthenticatorData(raw []byte) (*AuthenticatorData, error) {
+	if len(raw) < authDataMinLength {
+		return nil, fmt.Errorf("authenticator data is too short: %d bytes", len(raw))
+	}
+	data := &AuthenticatorData{
+		Raw:       raw,
+		RPIDHash:  raw[:32],
+		Flags:     AuthenticatorFlags(raw[32]),
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if !data.Flags.Has(FlagAttestedCredentialData) {
+		return data, nil
+	}
+
+	rest := raw[authDataMinLength:]
+	if len(rest) < aaguidLength+2 {
+		return nil, errors.New("attested credential data is too short")
+	}
+	aaguid := rest[:aaguidLength]
+	idLen := int(binary.BigEndian.Uint16(rest[aaguidLength : aaguidLength+2]))
+	rest = rest[aaguidLength+2:]
+	if len(rest) < idLen {
+		return nil, errors.New("attested credential ID is too short")
+	}
+	credId := rest[:idLen]
+	rest = rest[idLen:]
+
+	// public key is CBOR encoded, its length is only known after decoding
+	var key cbor.RawMessage
+	remaining, e := cbor.UnmarshalFirst(rest, &key)
+	if e != nil {
+		return nil, fmt.Errorf("invalid credential public key: %v", e)
+	}
+	if len(remaining) != 0 && !data.Flags.Has(FlagExtensionData) {
+		return nil, errors.New("unexpected trailing bytes in authenticator data")
+	}
+	data.AttestedCredential = &AttestedCredentialData{
+		AAGUID:       aaguid,
+		CredentialID: credId,
+		PublicKey:    []byte(key),
+	}
+	return data, nil
+}
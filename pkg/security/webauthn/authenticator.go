@@ -0,0 +1,232 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+)
+
+const (
+	// SpecialPermissionWebAuthnPending is granted together with passwd.SpecialPermissionMFAPending when WebAuthn is
+	// required as second factor
+	SpecialPermissionWebAuthnPending = "WebAuthnPending"
+)
+
+const (
+	DetailsKeyCredentialID = "WebAuthnCredentialId"
+)
+
+/************************
+	security.Candidate
+************************/
+
+// AssertionCandidate is the security.Candidate of passwordless login
+type AssertionCandidate struct {
+	Ceremony   *Ceremony
+	Response   *AssertionResponse
+	DetailsMap map[string]interface{}
+}
+
+func (c *AssertionCandidate) Principal() interface{} {
+	if c.Ceremony == nil {
+		return ""
+	}
+	return c.Ceremony.Username
+}
+
+func (c *AssertionCandidate) Credentials() interface{} {
+	return c.Response
+}
+
+func (c *AssertionCandidate) Details() interface{} {
+	return c.DetailsMap
+}
+
+// MFAAssertionVerification is the security.Candidate of WebAuthn as second factor of password login
+type MFAAssertionVerification struct {
+	CurrentAuth passwd.UsernamePasswordAuthentication
+	Ceremony    *Ceremony
+	Response    *AssertionResponse
+	DetailsMap  map[string]interface{}
+}
+
+func (c *MFAAssertionVerification) Principal() interface{} {
+	return c.CurrentAuth.Principal()
+}
+
+func (c *MFAAssertionVerification) Credentials() interface{} {
+	return c.Response
+}
+
+func (c *MFAAssertionVerification) Details() interface{} {
+	return c.DetailsMap
+}
+
+/******************************
+	security.Authentication
+******************************/
+
+// Authentication is the security.Authentication of users authenticated with WebAuthn credential,
+// either passwordless or as second factor
+type Authentication interface {
+	security.Authentication
+	Username() string
+	CredentialID() []byte
+}
+
+// webAuthnAuthentication
+// Note: all fields should not be used directly. It's exported only because gob only deal with exported field
+type webAuthnAuthentication struct {
+	Acct       security.Account
+	Perms      map[string]interface{}
+	DetailsMap map[string]interface{}
+	CredID     []byte
+}
+
+func (a *webAuthnAuthentication) Principal() interface{} {
+	return a.Acct
+}
+
+func (a *webAuthnAuthentication) Permissions() security.Permissions {
+	return a.Perms
+}
+
+func (a *webAuthnAuthentication) State() security.AuthenticationState {
+	return security.StateAuthenticated
+}
+
+func (a *webAuthnAuthentication) Details() interface{} {
+	return a.DetailsMap
+}
+
+func (a *webAuthnAuthentication) Username() string {
+	return a.Acct.Username()
+}
+
+func (a *webAuthnAuthentication) CredentialID() []byte {
+	return a.CredID
+}
+
+/******************************
+	security.Authenticator
+******************************/
+
+type AuthenticatorOptions func(opt *AuthenticatorOption)
+type AuthenticatorOption struct {
+	RelyingParty   *RelyingParty
+	AccountStore   security.AccountStore
+	Checkers       []passwd.AuthenticationDecisionMaker
+	PostProcessors []passwd.PostAuthenticationProcessor
+}
+
+// Authenticator implements security.Authenticator. It supports AssertionCandidate and MFAAssertionVerification
+type Authenticator struct {
+	rp           *RelyingParty
+	accountStore security.AccountStore
+	support      passwd.AuthenticatorSupport
+}
+
+func NewAuthenticator(opts ...AuthenticatorOptions) *Authenticator {
+	opt := AuthenticatorOption{}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &Authenticator{
+		rp:           opt.RelyingParty,
+		accountStore: opt.AccountStore,
+		support:      passwd.NewAuthenticatorSupport(opt.AccountStore, opt.Checkers, opt.PostProcessors),
+	}
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, candidate security.Candidate) (auth security.Authentication, err error) {
+	var ceremony *Ceremony
+	var resp *AssertionResponse
+	var currentAuth passwd.UsernamePasswordAuthentication
+	switch can := candidate.(type) {
+	case *AssertionCandidate:
+		// the credential is the only factor, user verification is mandatory
+		if can.Ceremony != nil && can.Ceremony.UserVerification != UserVerificationRequired {
+			return nil, newVerificationError("passwordless login requires user verification")
+		}
+		ceremony, resp = can.Ceremony, can.Response
+	case *MFAAssertionVerification:
+		if can.CurrentAuth == nil || !can.CurrentAuth.Permissions().Has(SpecialPermissionWebAuthnPending) {
+			return nil, security.NewAccessDeniedError("WebAuthn verification is not in progress")
+		}
+		if can.Ceremony == nil || can.Ceremony.Username != can.CurrentAuth.Username() {
+			return nil, newVerificationError("WebAuthn ceremony is not in progress")
+		}
+		ceremony, resp, currentAuth = can.Ceremony, can.Response, can.CurrentAuth
+	default:
+		return nil, nil
+	}
+
+	// schedule post processing
+	var user security.Account
+	defer func() {
+		auth, err = a.support.PostProcess(ctx, user, candidate, auth, err)
+	}()
+
+	if resp == nil {
+		return nil, newVerificationError("credential is missing")
+	}
+	cred, e := a.rp.FinishAssertion(ctx, ceremony, resp)
+	if e != nil {
+		return nil, e
+	}
+
+	if user, e = a.accountStore.LoadAccountByUsername(ctx, cred.Username); e != nil {
+		user = nil
+		return nil, security.NewUsernameNotFoundError("account not found", e)
+	}
+	if e := a.support.Decide(ctx, candidate, user, nil); e != nil {
+		return nil, e
+	}
+
+	newAuth := a.createSuccessAuthentication(candidate, currentAuth, user, cred)
+	if e := a.support.Decide(ctx, candidate, user, newAuth); e != nil {
+		return nil, e
+	}
+	return newAuth, nil
+}
+
+func (a *Authenticator) createSuccessAuthentication(candidate security.Candidate, currentAuth passwd.UsernamePasswordAuthentication,
+	account security.Account, cred *Credential) security.Authentication {
+	var details map[string]interface{}
+	if currentAuth != nil {
+		// second factor, keep details of password authentication
+		existing, _ := currentAuth.Details().(map[string]interface{})
+		details = passwd.NewAuthenticationDetails(existing, "")
+		details[security.DetailsKeyMFAApplied] = true
+	} else {
+		var src map[string]interface{}
+		if can, ok := candidate.(*AssertionCandidate); ok {
+			src = can.DetailsMap
+		}
+		details = passwd.NewAuthenticationDetails(src, security.AuthMethodWebAuthn)
+	}
+	details[DetailsKeyCredentialID] = Base64URL(cred.ID).String()
+
+	return &webAuthnAuthentication{
+		Acct:       account.CacheableCopy(),
+		Perms:      passwd.AccountPermissions(account),
+		DetailsMap: details,
+		CredID:     cred.ID,
+	}
+}
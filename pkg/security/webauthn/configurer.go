@@ -0,0 +1,198 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/access"
+	"github.com/cisco-open/go-lanai/pkg/security/csrf"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+	"github.com/cisco-open/go-lanai/pkg/security/redirect"
+	"github.com/cisco-open/go-lanai/pkg/security/request_cache"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"github.com/cisco-open/go-lanai/pkg/web/mapping"
+	"github.com/cisco-open/go-lanai/pkg/web/matcher"
+	"github.com/cisco-open/go-lanai/pkg/web/middleware"
+	"github.com/cisco-open/go-lanai/pkg/web/rest"
+	"net/http"
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type WebAuthnConfigurer struct {
+	credentialStore CredentialStore
+	accountStore    security.AccountStore
+}
+
+func newWebAuthnConfigurer(credentialStore CredentialStore, accountStore security.AccountStore) *WebAuthnConfigurer {
+	return &WebAuthnConfigurer{
+		credentialStore: credentialStore,
+		accountStore:    accountStore,
+	}
+}
+
+func (c *WebAuthnConfigurer) Apply(feature security.Feature, ws security.WebSecurity) error {
+	// Verify
+	if err := c.validate(feature.(*WebAuthnFeature), ws); err != nil {
+		return err
+	}
+	f := feature.(*WebAuthnFeature)
+
+	// Build relying party and authenticator
+	rp := NewRelyingParty(append(f.rpOptions, func(opt *RelyingPartyOption) {
+		opt.CredentialStore = f.credentialStore
+	})...)
+	if rp.ID() == "" {
+		return fmt.Errorf("unable to configure WebAuthn: relying party ID is not set")
+	}
+
+	authenticator := NewAuthenticator(func(opt *AuthenticatorOption) {
+		opt.RelyingParty = rp
+		opt.AccountStore = f.accountStore
+	})
+	ws.Authenticator().(*security.CompositeAuthenticator).Add(authenticator)
+
+	// Require WebAuthn after password login. passwd feature is applied after this one
+	if f.secondFactor {
+		passwd.Configure(ws).DecisionMakers(NewSecondFactorDecisionMaker(f.credentialStore))
+	}
+
+	c.configureRegistration(f, ws, rp)
+	c.configureAssertion(f, ws, rp)
+	c.configureCSRF(f, ws)
+	return nil
+}
+
+func (c *WebAuthnConfigurer) validate(f *WebAuthnFeature, ws security.WebSecurity) error {
+	if _, ok := ws.Authenticator().(*security.CompositeAuthenticator); !ok {
+		return fmt.Errorf("unable to add WebAuthn authenticator to %T", ws.Authenticator())
+	}
+
+	if f.credentialStore == nil {
+		f.credentialStore = c.credentialStore
+	}
+	if f.credentialStore == nil {
+		return fmt.Errorf("unable to configure WebAuthn: credential store is not set")
+	}
+
+	if f.accountStore == nil {
+		f.accountStore = c.accountStore
+	}
+	if f.accountStore == nil {
+		return fmt.Errorf("unable to configure WebAuthn: account store is not set")
+	}
+
+	if f.successHandler == nil {
+		f.successHandler = c.defaultSavedRequestSuccessHandler()
+	}
+	return nil
+}
+
+func (c *WebAuthnConfigurer) configureRegistration(f *WebAuthnFeature, ws security.WebSecurity, rp *RelyingParty) {
+	ep := NewEndpoints(func(opt *EndpointsOption) {
+		opt.RelyingParty = rp
+		opt.AccountStore = f.accountStore
+		opt.Passwordless = f.passwordless
+		opt.StepUpMaxAge = f.stepUpMaxAge
+	})
+
+	// let ws know to intercept additional url
+	ws.Route(matcher.RouteWithURL(f.registrationOptionsUrl, http.MethodPost)).
+		Route(matcher.RouteWithURL(f.registrationUrl, http.MethodPost))
+
+	ws.Add(rest.New("webauthn registration options").Post(f.registrationOptionsUrl).
+		EndpointFunc(ep.RegistrationOptions).Build())
+	ws.Add(rest.New("webauthn registration").Post(f.registrationUrl).
+		EndpointFunc(ep.Registration).Build())
+
+	// configure access
+	requestMatcher := matcher.RequestWithURL(f.registrationOptionsUrl, http.MethodPost).
+		Or(matcher.RequestWithURL(f.registrationUrl, http.MethodPost))
+	access.Configure(ws).
+		Request(requestMatcher).WithOrder(order.Highest).
+		Authenticated()
+}
+
+func (c *WebAuthnConfigurer) configureAssertion(f *WebAuthnFeature, ws security.WebSecurity, rp *RelyingParty) {
+	ep := NewEndpoints(func(opt *EndpointsOption) {
+		opt.RelyingParty = rp
+		opt.Passwordless = f.passwordless
+	})
+
+	// let ws know to intercept additional url
+	routeOptions := matcher.RouteWithURL(f.assertionOptionsUrl, http.MethodPost)
+	routeAssertion := matcher.RouteWithURL(f.assertionUrl, http.MethodPost)
+	ws.Route(routeOptions).Route(routeAssertion)
+
+	ws.Add(rest.New("webauthn assertion options").Post(f.assertionOptionsUrl).
+		EndpointFunc(ep.AssertionOptions).Build())
+
+	// configure middlewares
+	// Note: since this MW handles a new path, we add middleware as-is instead of a security.MiddlewareTemplate
+	assertion := NewAssertionMiddleware(func(opt *AssertionMWOption) {
+		opt.Authenticator = ws.Authenticator()
+		opt.SuccessHandler = c.effectiveSuccessHandler(f, ws)
+		opt.CredentialParam = f.credentialParam
+		opt.Passwordless = f.passwordless
+	})
+	mw := middleware.NewBuilder("webauthn assertion").
+		ApplyTo(routeAssertion).
+		Order(security.MWOrderFormAuth).
+		Use(assertion.AssertionHandlerFunc())
+	ws.Add(mw)
+
+	// configure additional endpoint mappings to trigger middleware
+	ws.Add(mapping.Post(f.assertionUrl).
+		HandlerFunc(security.NoopHandlerFunc()).
+		Name("webauthn assertion dummy"))
+
+	// configure access
+	requestMatcher := matcher.RequestWithURL(f.assertionOptionsUrl, http.MethodPost).
+		Or(matcher.RequestWithURL(f.assertionUrl, http.MethodPost))
+	if f.passwordless {
+		access.Configure(ws).
+			Request(requestMatcher).WithOrder(order.Highest).
+			PermitAll()
+	} else {
+		access.Configure(ws).
+			Request(requestMatcher).WithOrder(order.Highest).
+			HasPermissions(passwd.SpecialPermissionMFAPending, SpecialPermissionWebAuthnPending)
+	}
+}
+
+func (c *WebAuthnConfigurer) configureCSRF(f *WebAuthnFeature, ws security.WebSecurity) {
+	csrfMatcher := matcher.RequestWithURL(f.registrationOptionsUrl, http.MethodPost).
+		Or(matcher.RequestWithURL(f.registrationUrl, http.MethodPost)).
+		Or(matcher.RequestWithURL(f.assertionOptionsUrl, http.MethodPost)).
+		Or(matcher.RequestWithURL(f.assertionUrl, http.MethodPost))
+	csrf.Configure(ws).AddCsrfProtectionMatcher(csrfMatcher)
+}
+
+func (c *WebAuthnConfigurer) effectiveSuccessHandler(f *WebAuthnFeature, ws security.WebSecurity) security.AuthenticationSuccessHandler {
+	if globalHandler, ok := ws.Shared(security.WSSharedKeyCompositeAuthSuccessHandler).(security.AuthenticationSuccessHandler); ok {
+		return security.NewAuthenticationSuccessHandler(globalHandler, f.successHandler)
+	}
+	return security.NewAuthenticationSuccessHandler(f.successHandler)
+}
+
+func (c *WebAuthnConfigurer) defaultSavedRequestSuccessHandler() security.AuthenticationSuccessHandler {
+	return request_cache.NewSavedRequestAuthenticationSuccessHandler(
+		redirect.NewRedirectWithRelativePath("/", true),
+		func(_, to security.Authentication) bool {
+			return security.IsFullyAuthenticated(to)
+		})
+}
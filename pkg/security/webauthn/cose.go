@@ -0,0 +1,151 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"math/big"
+)
+
+// COSEAlgorithm is COSE algorithm identifier.
+// See https://www.iana.org/assignments/cose/cose.xhtml#algorithms
+type COSEAlgorithm int
+
+const (
+	AlgES256 COSEAlgorithm = -7
+	AlgEdDSA COSEAlgorithm = -8
+	AlgRS256 COSEAlgorithm = -257
+)
+
+// SupportedAlgorithms are public key algorithms accepted for new credentials, in order of preference
+var SupportedAlgorithms = []COSEAlgorithm{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters. See https://www.rfc-editor.org/rfc/rfc9053
+const (
+	coseKeyKty = 1
+	coseKeyAlg = 3
+	coseKeyCrv = -1
+	coseKeyX   = -2
+	coseKeyY   = -3
+	coseKeyN   = -1
+	coseKeyE   = -2
+)
+
+const (
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+)
+
+const (
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// ParsePublicKey parses CBOR encoded COSE_Key and returns the public key and its algorithm
+func ParsePublicKey(coseKey []byte) (crypto.PublicKey, COSEAlgorithm, error) {
+	var params map[int]interface{}
+	if e := cbor.Unmarshal(coseKey, &params); e != nil {
+		return nil, 0, fmt.Errorf("invalid COSE key: %v", e)
+	}
+	kty, _ := coseInt(params[coseKeyKty])
+	alg, _ := coseInt(params[coseKeyAlg])
+	switch kty {
+	case coseKtyEC2:
+		crv, _ := coseInt(params[coseKeyCrv])
+		x, xOk := params[coseKeyX].([]byte)
+		y, yOk := params[coseKeyY].([]byte)
+		if COSEAlgorithm(alg) != AlgES256 || crv != coseCrvP256 || !xOk || !yOk {
+			return nil, 0, fmt.Errorf("unsupported EC2 COSE key: alg=%d, crv=%d", alg, crv)
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, errors.New("invalid EC2 COSE key: point is not on curve")
+		}
+		return pub, AlgES256, nil
+	case coseKtyOKP:
+		crv, _ := coseInt(params[coseKeyCrv])
+		x, xOk := params[coseKeyX].([]byte)
+		if COSEAlgorithm(alg) != AlgEdDSA || crv != coseCrvEd25519 || !xOk || len(x) != ed25519.PublicKeySize {
+			return nil, 0, fmt.Errorf("unsupported OKP COSE key: alg=%d, crv=%d", alg, crv)
+		}
+		return ed25519.PublicKey(x), AlgEdDSA, nil
+	case coseKtyRSA:
+		n, nOk := params[coseKeyN].([]byte)
+		e, eOk := params[coseKeyE].([]byte)
+		if COSEAlgorithm(alg) != AlgRS256 || !nOk || !eOk {
+			return nil, 0, fmt.Errorf("unsupported RSA COSE key: alg=%d", alg)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, AlgRS256, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported COSE key type [%d]", kty)
+	}
+}
+
+// VerifySignature verifies signature of given data using public key and COSE algorithm
+func VerifySignature(pub crypto.PublicKey, alg COSEAlgorithm, data, sig []byte) error {
+	switch alg {
+	case AlgES256:
+		key, ok := pub.(*ecdsa.PublicKey)
+		hash := sha256.Sum256(data)
+		if !ok || !ecdsa.VerifyASN1(key, hash[:], sig) {
+			return errors.New("invalid ES256 signature")
+		}
+	case AlgEdDSA:
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(key, data, sig) {
+			return errors.New("invalid EdDSA signature")
+		}
+	case AlgRS256:
+		key, ok := pub.(*rsa.PublicKey)
+		hash := sha256.Sum256(data)
+		if !ok || rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig) != nil {
+			return errors.New("invalid RS256 signature")
+		}
+	default:
+		return fmt.Errorf("unsupported signature algorithm [%d]", alg)
+	}
+	return nil
+}
+
+func coseInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"context"
+	"time"
+)
+
+// Credential is a registered WebAuthn public key credential of a user
+type Credential struct {
+	// ID is the credential ID generated by authenticator
+	ID []byte
+	// Username is the owner of the credential
+	Username string
+	// UserHandle is the opaque user ID given to authenticator during registration.
+	// All credentials of same user share the same user handle
+	UserHandle []byte
+	// PublicKey is the COSE encoded credential public key
+	PublicKey []byte
+	// AttestationType is the verified attestation type, e.g. AttestationTypeNone, AttestationTypeSelf
+	AttestationType string
+	// AAGUID identifies the authenticator model
+	AAGUID []byte
+	// SignCount is the last known signature counter. Used to detect cloned authenticators
+	SignCount uint32
+	// Transports are hints of how the client could communicate with the authenticator, e.g. "usb", "internal"
+	Transports []string
+	// BackupEligible indicates the credential could be synced across devices (passkey)
+	BackupEligible bool
+	CreatedAt      time.Time
+	LastUsedAt     time.Time
+}
+
+// CredentialStore loads and persists WebAuthn credentials.
+// LoadCredentialByID should return nil credential without error if the credential doesn't exist.
+type CredentialStore interface {
+	LoadCredentialsByUsername(ctx context.Context, username string) ([]*Credential, error)
+	LoadCredentialByID(ctx context.Context, id []byte) (*Credential, error)
+	// SaveCredential creates new credential or updates existing one
+	SaveCredential(ctx context.Context, cred *Credential) error
+	DeleteCredential(ctx context.Context, id []byte) error
+}
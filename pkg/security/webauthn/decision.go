@@ -0,0 +1,82 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+)
+
+type ctxKeySecondFactor struct{}
+
+// SecondFactorDecisionMaker implements passwd.AuthenticationDecisionMaker.
+// When a user with registered WebAuthn credentials logs in with password, it skips OTP based MFA and turns the
+// proposed authentication into MFA pending state with SpecialPermissionWebAuthnPending.
+// The authentication is completed with MFAAssertionVerification.
+//
+// Password login requested with passwd.MFAModeSkip is not affected.
+type SecondFactorDecisionMaker struct {
+	store CredentialStore
+}
+
+func NewSecondFactorDecisionMaker(store CredentialStore) *SecondFactorDecisionMaker {
+	return &SecondFactorDecisionMaker{store: store}
+}
+
+func (dm *SecondFactorDecisionMaker) Decide(ctx context.Context, can security.Candidate, acct security.Account, auth security.Authentication) error {
+	upp, ok := can.(*passwd.UsernamePasswordPair)
+	if !ok || acct == nil {
+		return nil
+	}
+	if auth == nil {
+		return dm.decidePreCredentialsCheck(ctx, upp, acct)
+	}
+
+	if required, _ := ctx.Value(ctxKeySecondFactor{}).(bool); !required || auth.State() < security.StateAuthenticated {
+		return nil
+	}
+	perms := auth.Permissions()
+	for k := range perms {
+		delete(perms, k)
+	}
+	perms[passwd.SpecialPermissionMFAPending] = true
+	perms[SpecialPermissionWebAuthnPending] = true
+	if details, ok := auth.Details().(map[string]interface{}); ok {
+		delete(details, security.DetailsKeyAuthTime)
+	}
+	return nil
+}
+
+func (dm *SecondFactorDecisionMaker) decidePreCredentialsCheck(ctx context.Context, upp *passwd.UsernamePasswordPair, acct security.Account) error {
+	mc := utils.FindMutableContext(ctx)
+	if upp.EnforceMFA == passwd.MFAModeSkip || mc == nil {
+		return nil
+	}
+	creds, e := dm.store.LoadCredentialsByUsername(ctx, acct.Username())
+	if e != nil {
+		return security.NewInternalAuthenticationError(e)
+	}
+	if len(creds) == 0 {
+		return nil
+	}
+	// WebAuthn takes over OTP
+	upp.EnforceMFA = passwd.MFAModeSkip
+	mc.Set(ctxKeySecondFactor{}, true)
+	return nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+	"github.com/cisco-open/go-lanai/pkg/security/session"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+const (
+	sessionKeyRegistration = "WebAuthn.Registration"
+	sessionKeyAssertion    = "WebAuthn.Assertion"
+)
+
+// RegistrationOptionsRequest optionally carries a current TOTP passcode or recovery code of the user's MFA enrollment.
+// It's required to register new credential, unless the user logged in recently. See WebAuthnFeature.StepUpMaxAge
+type RegistrationOptionsRequest struct {
+	DisplayName string `json:"displayName" form:"displayName"`
+	Passcode    string `json:"passcode" form:"passcode"`
+}
+
+type AssertionOptionsRequest struct {
+	Username string `json:"username" form:"username"`
+}
+
+type RegistrationResult struct {
+	ID              Base64URL `json:"id"`
+	AttestationType string    `json:"attestationType"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// Endpoints implements REST endpoints generating options and verifying registration response.
+// Ceremonies are kept in session between the two steps.
+type Endpoints struct {
+	rp           *RelyingParty
+	accountStore security.AccountStore
+	passwordless bool
+	stepUpMaxAge time.Duration
+}
+
+type EndpointsOptions func(opt *EndpointsOption)
+type EndpointsOption struct {
+	RelyingParty *RelyingParty
+	// AccountStore is used to verify passcode during step-up. Passcode is not accepted if it doesn't implement passwd.MFAEnrollmentStore
+	AccountStore security.AccountStore
+	Passwordless bool
+	// StepUpMaxAge is how long after login the user can register new credential without providing a passcode
+	StepUpMaxAge time.Duration
+}
+
+func NewEndpoints(opts ...EndpointsOptions) *Endpoints {
+	opt := EndpointsOption{}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &Endpoints{
+		rp:           opt.RelyingParty,
+		accountStore: opt.AccountStore,
+		passwordless: opt.Passwordless,
+		stepUpMaxAge: opt.StepUpMaxAge,
+	}
+}
+
+// RegistrationOptions generates credential creation options for current user.
+// Registering new credential requires step-up: either the user logged in recently, or a current passcode is provided.
+func (ep *Endpoints) RegistrationOptions(ctx context.Context, req *RegistrationOptionsRequest) (*CredentialCreationOptions, error) {
+	username, e := security.GetUsername(security.Get(ctx))
+	if e != nil || !security.IsFullyAuthenticated(security.Get(ctx)) {
+		return nil, security.NewAccessDeniedError("WebAuthn registration requires authenticated user")
+	}
+	s := session.Get(ctx)
+	if s == nil {
+		return nil, security.NewInternalError("WebAuthn registration requires session")
+	}
+	if e := passwd.VerifyStepUp(ctx, ep.accountStore, username, req.Passcode, ep.stepUpMaxAge); e != nil {
+		return nil, e
+	}
+	opts, ceremony, e := ep.rp.BeginRegistration(ctx, username, req.DisplayName)
+	if e != nil {
+		return nil, e
+	}
+	s.Set(sessionKeyRegistration, ceremony)
+	return opts, nil
+}
+
+// Registration verifies registration response and saves new credential of current user.
+// The ceremony is only issued by RegistrationOptions after a successful step-up, and expires per RelyingPartyOption.Timeout.
+// Verification errors are reported as bad request, so current authentication is not affected.
+func (ep *Endpoints) Registration(ctx context.Context, req *RegistrationResponse) (*RegistrationResult, error) {
+	username, e := security.GetUsername(security.Get(ctx))
+	if e != nil || !security.IsFullyAuthenticated(security.Get(ctx)) {
+		return nil, security.NewAccessDeniedError("WebAuthn registration requires authenticated user")
+	}
+	var ceremony *Ceremony
+	if s := session.Get(ctx); s != nil {
+		ceremony, _ = s.Get(sessionKeyRegistration).(*Ceremony)
+		s.Delete(sessionKeyRegistration)
+	}
+	if ceremony == nil || ceremony.Username != username {
+		return nil, web.NewBadRequestError(errors.New("WebAuthn registration is not in progress"))
+	}
+	cred, e := ep.rp.FinishRegistration(ctx, ceremony, req)
+	switch {
+	case errors.Is(e, security.ErrorSubTypeInternalError):
+		return nil, e
+	case e != nil:
+		return nil, web.NewBadRequestError(errors.New(e.Error()))
+	}
+	logger.WithContext(ctx).Infof("WebAuthn credential registered for user [%s]", username)
+	return &RegistrationResult{
+		ID:              cred.ID,
+		AttestationType: cred.AttestationType,
+		CreatedAt:       cred.CreatedAt,
+	}, nil
+}
+
+// AssertionOptions generates credential request options.
+// If WebAuthn is pending as second factor, options are generated for current user.
+// Otherwise, options are generated for passwordless login, with optional username. User verification is always
+// required for passwordless login.
+func (ep *Endpoints) AssertionOptions(ctx context.Context, req *AssertionOptionsRequest) (*CredentialRequestOptions, error) {
+	s := session.Get(ctx)
+	if s == nil {
+		return nil, security.NewInternalError("WebAuthn login requires session")
+	}
+	var opts *CredentialRequestOptions
+	var ceremony *Ceremony
+	var e error
+	switch currentAuth, ok := security.Get(ctx).(passwd.UsernamePasswordAuthentication); {
+	case ok && currentAuth.Permissions().Has(SpecialPermissionWebAuthnPending):
+		opts, ceremony, e = ep.rp.BeginAssertion(ctx, currentAuth.Username())
+	case ep.passwordless:
+		opts, ceremony, e = ep.rp.BeginPasswordlessAssertion(ctx, req.Username)
+	default:
+		return nil, security.NewAccessDeniedError("WebAuthn verification is not in progress")
+	}
+	if e != nil {
+		return nil, e
+	}
+	s.Set(sessionKeyAssertion, ceremony)
+	return opts, nil
+}
+
+/*********************************
+	Assertion Middleware
+ *********************************/
+
+// AssertionMiddleware processes AssertionResponse posted as form parameter, and authenticate the user
+// either as second factor or as passwordless login
+type AssertionMiddleware struct {
+	authenticator   security.Authenticator
+	successHandler  security.AuthenticationSuccessHandler
+	credentialParam string
+	passwordless    bool
+}
+
+type AssertionMWOptions func(opt *AssertionMWOption)
+type AssertionMWOption struct {
+	Authenticator   security.Authenticator
+	SuccessHandler  security.AuthenticationSuccessHandler
+	CredentialParam string
+	Passwordless    bool
+}
+
+func NewAssertionMiddleware(opts ...AssertionMWOptions) *AssertionMiddleware {
+	opt := AssertionMWOption{}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &AssertionMiddleware{
+		authenticator:   opt.Authenticator,
+		successHandler:  opt.SuccessHandler,
+		credentialParam: opt.CredentialParam,
+		passwordless:    opt.Passwordless,
+	}
+}
+
+func (mw *AssertionMiddleware) AssertionHandlerFunc() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var ceremony *Ceremony
+		if s := session.Get(ctx); s != nil {
+			ceremony, _ = s.Get(sessionKeyAssertion).(*Ceremony)
+			s.Delete(sessionKeyAssertion)
+		}
+		var resp AssertionResponse
+		if e := json.Unmarshal([]byte(ctx.PostForm(mw.credentialParam)), &resp); e != nil {
+			mw.handleError(ctx, newVerificationError("invalid credential: %v", e))
+			return
+		}
+
+		before := security.Get(ctx)
+		var candidate security.Candidate
+		switch currentAuth, ok := before.(passwd.UsernamePasswordAuthentication); {
+		case ok && currentAuth.Permissions().Has(SpecialPermissionWebAuthnPending):
+			candidate = &MFAAssertionVerification{
+				CurrentAuth: currentAuth,
+				Ceremony:    ceremony,
+				Response:    &resp,
+				DetailsMap:  map[string]interface{}{},
+			}
+		case mw.passwordless:
+			candidate = &AssertionCandidate{
+				Ceremony:   ceremony,
+				Response:   &resp,
+				DetailsMap: map[string]interface{}{},
+			}
+		default:
+			mw.handleError(ctx, security.NewAccessDeniedError("WebAuthn verification is not in progress"))
+			return
+		}
+
+		auth, e := mw.authenticator.Authenticate(ctx, candidate)
+		if e != nil {
+			mw.handleError(ctx, e)
+			return
+		}
+		mw.handleSuccess(ctx, before, auth)
+	}
+}
+
+func (mw *AssertionMiddleware) handleSuccess(c *gin.Context, before, new security.Authentication) {
+	if new != nil {
+		security.MustSet(c, new)
+	}
+	mw.successHandler.HandleAuthenticationSuccess(c, c.Request, c.Writer, before, new)
+	if c.Writer.Written() {
+		c.Abort()
+	}
+}
+
+func (mw *AssertionMiddleware) handleError(c *gin.Context, err error) {
+	security.MustClear(c)
+	_ = c.Error(err)
+	c.Abort()
+}
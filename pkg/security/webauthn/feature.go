@@ -0,0 +1,143 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"time"
+)
+
+var (
+	FeatureId = security.FeatureId("WebAuthn", security.FeatureOrderWebAuthn)
+)
+
+/*********************************
+	Feature Impl
+ *********************************/
+
+//goland:noinspection GoNameStartsWithPackageName
+type WebAuthnFeature struct {
+	rpOptions              []RelyingPartyOptions
+	credentialStore        CredentialStore
+	accountStore           security.AccountStore
+	passwordless           bool
+	secondFactor           bool
+	registrationOptionsUrl string
+	registrationUrl        string
+	assertionOptionsUrl    string
+	assertionUrl           string
+	credentialParam        string
+	stepUpMaxAge           time.Duration
+	successHandler         security.AuthenticationSuccessHandler
+}
+
+// Configure is Standard security.Feature entrypoint
+func Configure(ws security.WebSecurity) *WebAuthnFeature {
+	feature := New()
+	if fm, ok := ws.(security.FeatureModifier); ok {
+		return fm.Enable(feature).(*WebAuthnFeature)
+	}
+	panic(fmt.Errorf("unable to configure WebAuthn: provided WebSecurity [%T] doesn't support FeatureModifier", ws))
+}
+
+// New is Standard security.Feature entrypoint, DSL style. Used with security.WebSecurity
+func New() *WebAuthnFeature {
+	return &WebAuthnFeature{
+		registrationOptionsUrl: "/webauthn/registration/options",
+		registrationUrl:        "/webauthn/registration",
+		assertionOptionsUrl:    "/webauthn/assertion/options",
+		assertionUrl:           "/webauthn/assertion",
+		credentialParam:        "credential",
+		stepUpMaxAge:           5 * time.Minute,
+	}
+}
+
+func (f *WebAuthnFeature) Identifier() security.FeatureIdentifier {
+	return FeatureId
+}
+
+// RelyingParty configures RP ID, origins, etc. See RelyingPartyOption
+func (f *WebAuthnFeature) RelyingParty(opts ...RelyingPartyOptions) *WebAuthnFeature {
+	f.rpOptions = append(f.rpOptions, opts...)
+	return f
+}
+
+// CredentialStore overrides the CredentialStore provided via dependency injection
+func (f *WebAuthnFeature) CredentialStore(store CredentialStore) *WebAuthnFeature {
+	f.credentialStore = store
+	return f
+}
+
+// AccountStore overrides the security.AccountStore provided via dependency injection
+func (f *WebAuthnFeature) AccountStore(store security.AccountStore) *WebAuthnFeature {
+	f.accountStore = store
+	return f
+}
+
+// Passwordless enables login with discoverable WebAuthn credentials (passkeys) without password
+func (f *WebAuthnFeature) Passwordless(enabled bool) *WebAuthnFeature {
+	f.passwordless = enabled
+	return f
+}
+
+// SecondFactor requires WebAuthn after password login for users with registered credentials.
+// It replaces OTP for those users. Requires passwd feature.
+func (f *WebAuthnFeature) SecondFactor(enabled bool) *WebAuthnFeature {
+	f.secondFactor = enabled
+	return f
+}
+
+func (f *WebAuthnFeature) RegistrationOptionsUrl(url string) *WebAuthnFeature {
+	f.registrationOptionsUrl = url
+	return f
+}
+
+func (f *WebAuthnFeature) RegistrationUrl(url string) *WebAuthnFeature {
+	f.registrationUrl = url
+	return f
+}
+
+func (f *WebAuthnFeature) AssertionOptionsUrl(url string) *WebAuthnFeature {
+	f.assertionOptionsUrl = url
+	return f
+}
+
+func (f *WebAuthnFeature) AssertionUrl(url string) *WebAuthnFeature {
+	f.assertionUrl = url
+	return f
+}
+
+// CredentialParameter is the form parameter carrying AssertionResponse JSON when posting to AssertionUrl
+func (f *WebAuthnFeature) CredentialParameter(param string) *WebAuthnFeature {
+	f.credentialParam = param
+	return f
+}
+
+// StepUpMaxAge is how long after login the user can register new credential without providing a current passcode
+// or recovery code. Zero or negative value means passcode or recovery code is always required.
+func (f *WebAuthnFeature) StepUpMaxAge(maxAge time.Duration) *WebAuthnFeature {
+	f.stepUpMaxAge = maxAge
+	return f
+}
+
+// SuccessHandler is invoked after successful WebAuthn login.
+// By default, user is redirected to the saved request or "/"
+func (f *WebAuthnFeature) SuccessHandler(successHandler security.AuthenticationSuccessHandler) *WebAuthnFeature {
+	f.successHandler = successHandler
+	return f
+}
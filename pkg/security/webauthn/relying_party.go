@@ -0,0 +1,406 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"time"
+)
+
+const (
+	challengeLength  = 32
+	userHandleLength = 32
+)
+
+// Ceremony is the server side state of a registration or authentication ceremony.
+// It's created by RelyingParty when options are generated, and should be kept (e.g. in session) until the response
+// is received from the client.
+type Ceremony struct {
+	Type             string
+	Challenge        []byte
+	Username         string
+	UserHandle       []byte
+	UserVerification string
+	Expires          time.Time
+}
+
+type RelyingPartyOptions func(opt *RelyingPartyOption)
+type RelyingPartyOption struct {
+	// ID is the RP ID, typically the domain of the login page. e.g. "example.com"
+	ID string
+	// Name is the human-palatable name of the RP
+	Name string
+	// Origins are allowed origins of clientDataJSON. Default to "https://<ID>"
+	Origins []string
+	// Timeout of ceremonies
+	Timeout time.Duration
+	// UserVerification is the UserVerificationRequirement of both registration and authentication
+	UserVerification string
+	// ResidentKey is the ResidentKeyRequirement of registration. Passwordless login requires discoverable credentials.
+	ResidentKey string
+	// Attestation is the AttestationConveyancePreference of registration
+	Attestation string
+	// AttestationRoots are trusted roots of attestation certificates. Trust path is not validated if not set.
+	AttestationRoots *x509.CertPool
+	CredentialStore  CredentialStore
+}
+
+// RelyingParty performs WebAuthn registration and authentication ceremonies.
+// See https://www.w3.org/TR/webauthn-2/#sctn-rp-operations
+type RelyingParty struct {
+	id               string
+	name             string
+	rpIdHash         []byte
+	origins          utils.StringSet
+	timeout          time.Duration
+	userVerification string
+	residentKey      string
+	attestation      string
+	attestationRoots *x509.CertPool
+	store            CredentialStore
+}
+
+func NewRelyingParty(opts ...RelyingPartyOptions) *RelyingParty {
+	opt := RelyingPartyOption{
+		Timeout:          5 * time.Minute,
+		UserVerification: UserVerificationPreferred,
+		ResidentKey:      ResidentKeyPreferred,
+		Attestation:      AttestationConveyanceNone,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	if len(opt.Name) == 0 {
+		opt.Name = opt.ID
+	}
+	if len(opt.Origins) == 0 {
+		opt.Origins = []string{"https://" + opt.ID}
+	}
+	hash := sha256.Sum256([]byte(opt.ID))
+	return &RelyingParty{
+		id:               opt.ID,
+		name:             opt.Name,
+		rpIdHash:         hash[:],
+		origins:          utils.NewStringSet(opt.Origins...),
+		timeout:          opt.Timeout,
+		userVerification: opt.UserVerification,
+		residentKey:      opt.ResidentKey,
+		attestation:      opt.Attestation,
+		attestationRoots: opt.AttestationRoots,
+		store:            opt.CredentialStore,
+	}
+}
+
+func (rp *RelyingParty) ID() string {
+	return rp.id
+}
+
+func (rp *RelyingParty) CredentialStore() CredentialStore {
+	return rp.store
+}
+
+// BeginRegistration creates credential creation options for given user. The returned Ceremony is required to finish
+// the registration.
+func (rp *RelyingParty) BeginRegistration(ctx context.Context, username, displayName string) (*CredentialCreationOptions, *Ceremony, error) {
+	existing, e := rp.store.LoadCredentialsByUsername(ctx, username)
+	if e != nil {
+		return nil, nil, security.NewInternalAuthenticationError(e)
+	}
+
+	var userHandle []byte
+	if len(existing) != 0 {
+		userHandle = existing[0].UserHandle
+	} else if userHandle, e = randomBytes(userHandleLength); e != nil {
+		return nil, nil, security.NewInternalAuthenticationError(e)
+	}
+	challenge, e := randomBytes(challengeLength)
+	if e != nil {
+		return nil, nil, security.NewInternalAuthenticationError(e)
+	}
+
+	params := make([]CredentialParameter, len(SupportedAlgorithms))
+	for i, alg := range SupportedAlgorithms {
+		params[i] = CredentialParameter{Type: CredentialTypePublicKey, Algorithm: alg}
+	}
+	if len(displayName) == 0 {
+		displayName = username
+	}
+	opts := &CredentialCreationOptions{
+		PublicKey: PublicKeyCredentialCreationOptions{
+			RelyingParty:       RelyingPartyEntity{ID: rp.id, Name: rp.name},
+			User:               UserEntity{ID: userHandle, Name: username, DisplayName: displayName},
+			Challenge:          challenge,
+			Parameters:         params,
+			Timeout:            rp.timeout.Milliseconds(),
+			ExcludeCredentials: descriptors(existing),
+			AuthenticatorSelection: AuthenticatorSelection{
+				ResidentKey:        rp.residentKey,
+				RequireResidentKey: rp.residentKey == ResidentKeyRequired,
+				UserVerification:   rp.userVerification,
+			},
+			Attestation: rp.attestation,
+		},
+	}
+	return opts, rp.newCeremony(ClientDataTypeCreate, challenge, username, userHandle), nil
+}
+
+// FinishRegistration verifies registration response and saves the new credential.
+// See https://www.w3.org/TR/webauthn-2/#sctn-registering-a-new-credential
+func (rp *RelyingParty) FinishRegistration(ctx context.Context, ceremony *Ceremony, resp *RegistrationResponse) (*Credential, error) {
+	if e := rp.verifyCeremony(ceremony, ClientDataTypeCreate, resp.Type); e != nil {
+		return nil, e
+	}
+	if e := rp.verifyClientData(ceremony, resp.Response.ClientDataJSON); e != nil {
+		return nil, e
+	}
+
+	att, e := ParseAttestationObject(resp.Response.AttestationObject)
+	if e != nil {
+		return nil, newVerificationError("%v", e)
+	}
+	if e := rp.verifyAuthData(ceremony, att.AuthData); e != nil {
+		return nil, e
+	}
+	attested := att.AuthData.AttestedCredential
+	if attested == nil {
+		return nil, newVerificationError("attested credential data is missing")
+	}
+	if len(resp.RawID) != 0 && !bytes.Equal(resp.RawID, attested.CredentialID) {
+		return nil, newVerificationError("credential ID mismatch")
+	}
+	if _, _, e := ParsePublicKey(attested.PublicKey); e != nil {
+		return nil, newVerificationError("%v", e)
+	}
+
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	attType, e := VerifyAttestation(att, clientDataHash[:], rp.attestationRoots)
+	if e != nil {
+		return nil, newVerificationError("%v", e)
+	}
+
+	switch existing, e := rp.store.LoadCredentialByID(ctx, attested.CredentialID); {
+	case e != nil:
+		return nil, security.NewInternalAuthenticationError(e)
+	case existing != nil:
+		return nil, newVerificationError("credential is already registered")
+	}
+
+	now := time.Now().UTC()
+	cred := &Credential{
+		ID:              attested.CredentialID,
+		Username:        ceremony.Username,
+		UserHandle:      ceremony.UserHandle,
+		PublicKey:       attested.PublicKey,
+		AttestationType: attType,
+		AAGUID:          attested.AAGUID,
+		SignCount:       att.AuthData.SignCount,
+		Transports:      resp.Response.Transports,
+		BackupEligible:  att.AuthData.Flags.Has(FlagBackupEligible),
+		CreatedAt:       now,
+		LastUsedAt:      now,
+	}
+	if e := rp.store.SaveCredential(ctx, cred); e != nil {
+		return nil, security.NewInternalAuthenticationError(e)
+	}
+	return cred, nil
+}
+
+// BeginAssertion creates credential request options. When username is empty, discoverable credentials are expected.
+// The returned Ceremony is required to finish the authentication.
+func (rp *RelyingParty) BeginAssertion(ctx context.Context, username string) (*CredentialRequestOptions, *Ceremony, error) {
+	return rp.beginAssertion(ctx, username, rp.userVerification)
+}
+
+// BeginPasswordlessAssertion is same as BeginAssertion, except that user verification is always required,
+// because the credential is the only authentication factor.
+func (rp *RelyingParty) BeginPasswordlessAssertion(ctx context.Context, username string) (*CredentialRequestOptions, *Ceremony, error) {
+	return rp.beginAssertion(ctx, username, UserVerificationRequired)
+}
+
+func (rp *RelyingParty) beginAssertion(ctx context.Context, username string, userVerification string) (*CredentialRequestOptions, *Ceremony, error) {
+	var allowed []*Credential
+	if len(username) != 0 {
+		var e error
+		if allowed, e = rp.store.LoadCredentialsByUsername(ctx, username); e != nil {
+			return nil, nil, security.NewInternalAuthenticationError(e)
+		}
+	}
+	challenge, e := randomBytes(challengeLength)
+	if e != nil {
+		return nil, nil, security.NewInternalAuthenticationError(e)
+	}
+	opts := &CredentialRequestOptions{
+		PublicKey: PublicKeyCredentialRequestOptions{
+			Challenge:        challenge,
+			Timeout:          rp.timeout.Milliseconds(),
+			RelyingPartyID:   rp.id,
+			AllowCredentials: descriptors(allowed),
+			UserVerification: userVerification,
+		},
+	}
+	ceremony := rp.newCeremony(ClientDataTypeGet, challenge, username, nil)
+	ceremony.UserVerification = userVerification
+	return opts, ceremony, nil
+}
+
+// FinishAssertion verifies assertion response and returns the updated credential.
+// If the Ceremony was created for a specific user, the credential must belong to that user.
+// See https://www.w3.org/TR/webauthn-2/#sctn-verifying-assertion
+func (rp *RelyingParty) FinishAssertion(ctx context.Context, ceremony *Ceremony, resp *AssertionResponse) (*Credential, error) {
+	if e := rp.verifyCeremony(ceremony, ClientDataTypeGet, resp.Type); e != nil {
+		return nil, e
+	}
+
+	cred, e := rp.store.LoadCredentialByID(ctx, resp.RawID)
+	switch {
+	case e != nil:
+		return nil, security.NewInternalAuthenticationError(e)
+	case cred == nil:
+		return nil, newVerificationError("credential is not registered")
+	case len(ceremony.Username) != 0 && cred.Username != ceremony.Username:
+		return nil, newVerificationError("credential is not registered for current user")
+	case len(resp.Response.UserHandle) != 0 && !bytes.Equal(resp.Response.UserHandle, cred.UserHandle):
+		return nil, newVerificationError("user handle mismatch")
+	}
+
+	if e := rp.verifyClientData(ceremony, resp.Response.ClientDataJSON); e != nil {
+		return nil, e
+	}
+	authData, e := ParseAuthenticatorData(resp.Response.AuthenticatorData)
+	if e != nil {
+		return nil, newVerificationError("%v", e)
+	}
+	if e := rp.verifyAuthData(ceremony, authData); e != nil {
+		return nil, e
+	}
+
+	pub, alg, e := ParsePublicKey(cred.PublicKey)
+	if e != nil {
+		return nil, security.NewInternalAuthenticationError(e)
+	}
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte{}, resp.Response.AuthenticatorData...), clientDataHash[:]...)
+	if e := VerifySignature(pub, alg, signed, resp.Response.Signature); e != nil {
+		return nil, newVerificationError("%v", e)
+	}
+
+	// signature counter, zero means the authenticator doesn't support it
+	if (authData.SignCount != 0 || cred.SignCount != 0) && authData.SignCount <= cred.SignCount {
+		return nil, newVerificationError("signature counter is not increased, the authenticator might be cloned")
+	}
+
+	cred.SignCount = authData.SignCount
+	cred.BackupEligible = authData.Flags.Has(FlagBackupEligible)
+	cred.LastUsedAt = time.Now().UTC()
+	if e := rp.store.SaveCredential(ctx, cred); e != nil {
+		return nil, security.NewInternalAuthenticationError(e)
+	}
+	return cred, nil
+}
+
+/***************************
+	Helpers
+ ***************************/
+
+func (rp *RelyingParty) newCeremony(typ string, challenge []byte, username string, userHandle []byte) *Ceremony {
+	return &Ceremony{
+		Type:             typ,
+		Challenge:        challenge,
+		Username:         username,
+		UserHandle:       userHandle,
+		UserVerification: rp.userVerification,
+		Expires:          time.Now().Add(rp.timeout),
+	}
+}
+
+func (rp *RelyingParty) verifyCeremony(ceremony *Ceremony, expectedType, credType string) error {
+	switch {
+	case ceremony == nil || ceremony.Type != expectedType:
+		return newVerificationError("WebAuthn ceremony is not in progress")
+	case time.Now().After(ceremony.Expires):
+		return newVerificationError("WebAuthn ceremony expired")
+	case credType != CredentialTypePublicKey:
+		return newVerificationError("unsupported credential type [%s]", credType)
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyClientData(ceremony *Ceremony, raw []byte) error {
+	var clientData CollectedClientData
+	if e := json.Unmarshal(raw, &clientData); e != nil {
+		return newVerificationError("invalid client data: %v", e)
+	}
+	challenge, e := base64.RawURLEncoding.DecodeString(clientData.Challenge)
+	switch {
+	case clientData.Type != ceremony.Type:
+		return newVerificationError("unexpected client data type [%s]", clientData.Type)
+	case e != nil || subtle.ConstantTimeCompare(challenge, ceremony.Challenge) != 1:
+		return newVerificationError("challenge mismatch")
+	case !rp.origins.Has(clientData.Origin):
+		return newVerificationError("origin [%s] is not allowed", clientData.Origin)
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthData(ceremony *Ceremony, authData *AuthenticatorData) error {
+	switch {
+	case !bytes.Equal(authData.RPIDHash, rp.rpIdHash):
+		return newVerificationError("RP ID hash mismatch")
+	case !authData.Flags.Has(FlagUserPresent):
+		return newVerificationError("user is not present")
+	case ceremony.UserVerification == UserVerificationRequired && !authData.Flags.Has(FlagUserVerified):
+		return newVerificationError("user is not verified")
+	}
+	return nil
+}
+
+func descriptors(creds []*Credential) []CredentialDescriptor {
+	if len(creds) == 0 {
+		return nil
+	}
+	ret := make([]CredentialDescriptor, len(creds))
+	for i, cred := range creds {
+		ret[i] = CredentialDescriptor{
+			Type:       CredentialTypePublicKey,
+			ID:         cred.ID,
+			Transports: cred.Transports,
+		}
+	}
+	return ret
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, e := rand.Read(b); e != nil {
+		return nil, e
+	}
+	return b, nil
+}
+
+func newVerificationError(format string, args ...interface{}) error {
+	return security.NewBadCredentialsError(fmt.Sprintf("WebAuthn verification failed: "+format, args...))
+}
@@ -0,0 +1,690 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+	"github.com/cisco-open/go-lanai/pkg/security/session"
+	"github.com/cisco-open/go-lanai/pkg/security/webauthn"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	. "github.com/cisco-open/go-lanai/test/utils/gomega"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	TestRPID     = "auth.example.com"
+	TestOrigin   = "https://auth.example.com"
+	TestUser     = "test-user"
+	TestPassword = "TheCakeIsALie"
+)
+
+/*************************
+	Test
+ *************************/
+
+func TestRelyingParty(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestRegistrationWithNoneAttestation(), "RegistrationWithNoneAttestation"),
+		test.GomegaSubTest(SubTestRegistrationWithSelfAttestation(), "RegistrationWithSelfAttestation"),
+		test.GomegaSubTest(SubTestRegistrationWithBasicAttestation(), "RegistrationWithBasicAttestation"),
+		test.GomegaSubTest(SubTestRegistrationWithWrongOrigin(), "RegistrationWithWrongOrigin"),
+		test.GomegaSubTest(SubTestRegistrationWithWrongChallenge(), "RegistrationWithWrongChallenge"),
+		test.GomegaSubTest(SubTestAssertionSuccess(), "AssertionSuccess"),
+		test.GomegaSubTest(SubTestAssertionWithClonedAuthenticator(), "AssertionWithClonedAuthenticator"),
+		test.GomegaSubTest(SubTestAssertionWithOtherUserCredential(), "AssertionWithOtherUserCredential"),
+	)
+}
+
+func TestAuthenticator(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestPasswordlessLogin(), "PasswordlessLogin"),
+		test.GomegaSubTest(SubTestPasswordlessLoginWithoutUserVerification(), "PasswordlessLoginWithoutUserVerification"),
+		test.GomegaSubTest(SubTestSecondFactorLogin(), "SecondFactorLogin"),
+		test.GomegaSubTest(SubTestPasswordLoginWithoutCredentials(), "PasswordLoginWithoutCredentials"),
+	)
+}
+
+func TestEndpoints(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestRegistrationWithRecentLogin(), "RegistrationWithRecentLogin"),
+		test.GomegaSubTest(SubTestRegistrationWithoutStepUp(), "RegistrationWithoutStepUp"),
+		test.GomegaSubTest(SubTestRegistrationWithRecoveryCode(), "RegistrationWithRecoveryCode"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestRegistrationWithNoneAttestation() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewInMemoryCredentialStore()
+		rp := NewTestRelyingParty(store)
+		key := NewSoftwareAuthenticator(g)
+		cred := key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatNone)
+		g.Expect(cred.AttestationType).To(Equal(webauthn.AttestationTypeNone), "attestation type should be correct")
+		g.Expect(cred.Username).To(Equal(TestUser), "credential's username should be correct")
+		g.Expect(cred.ID).To(Equal(key.credID), "credential ID should be correct")
+
+		creds, e := store.LoadCredentialsByUsername(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "loading credentials should not fail")
+		g.Expect(creds).To(HaveLen(1), "credential should be saved")
+	}
+}
+
+func SubTestRegistrationWithSelfAttestation() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore())
+		key := NewSoftwareAuthenticator(g)
+		cred := key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatPacked)
+		g.Expect(cred.AttestationType).To(Equal(webauthn.AttestationTypeSelf), "attestation type should be correct")
+	}
+}
+
+func SubTestRegistrationWithBasicAttestation() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		key := NewSoftwareAuthenticator(g)
+		key.WithAttestationCertificate(g)
+		roots := x509.NewCertPool()
+		roots.AddCert(key.rootCert)
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore(), func(opt *webauthn.RelyingPartyOption) {
+			opt.AttestationRoots = roots
+		})
+		cred := key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatPacked)
+		g.Expect(cred.AttestationType).To(Equal(webauthn.AttestationTypeBasic), "attestation type should be correct")
+
+		// untrusted root
+		rp = NewTestRelyingParty(NewInMemoryCredentialStore(), func(opt *webauthn.RelyingPartyOption) {
+			opt.AttestationRoots = x509.NewCertPool()
+		})
+		_, ceremony, e := rp.BeginRegistration(ctx, TestUser, "")
+		g.Expect(e).To(Succeed(), "begin registration should not fail")
+		_, e = rp.FinishRegistration(ctx, ceremony, key.Register(g, ceremony.Challenge, ceremony.UserHandle, TestOrigin, webauthn.AttestationFormatPacked))
+		g.Expect(e).To(HaveOccurred(), "registration with untrusted attestation should fail")
+	}
+}
+
+func SubTestRegistrationWithWrongOrigin() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore())
+		key := NewSoftwareAuthenticator(g)
+		_, ceremony, e := rp.BeginRegistration(ctx, TestUser, "")
+		g.Expect(e).To(Succeed(), "begin registration should not fail")
+		resp := key.Register(g, ceremony.Challenge, ceremony.UserHandle, "https://evil.example.com", webauthn.AttestationFormatNone)
+		_, e = rp.FinishRegistration(ctx, ceremony, resp)
+		g.Expect(e).To(HaveOccurred(), "registration should fail")
+		g.Expect(e).To(BeAssignableToTypeOf(&security.CodedError{}), "error should be security error")
+		g.Expect(e.Error()).To(ContainSubstring("origin"), "error should be correct")
+	}
+}
+
+func SubTestRegistrationWithWrongChallenge() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore())
+		key := NewSoftwareAuthenticator(g)
+		_, ceremony, e := rp.BeginRegistration(ctx, TestUser, "")
+		g.Expect(e).To(Succeed(), "begin registration should not fail")
+		resp := key.Register(g, []byte("not-the-challenge"), ceremony.UserHandle, TestOrigin, webauthn.AttestationFormatNone)
+		_, e = rp.FinishRegistration(ctx, ceremony, resp)
+		g.Expect(e).To(HaveOccurred(), "registration should fail")
+		g.Expect(e.Error()).To(ContainSubstring("challenge"), "error should be correct")
+	}
+}
+
+func SubTestAssertionSuccess() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore())
+		key := NewSoftwareAuthenticator(g)
+		key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatNone)
+
+		opts, ceremony, e := rp.BeginAssertion(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		g.Expect(opts.PublicKey.AllowCredentials).To(HaveLen(1), "allowed credentials should be correct")
+		g.Expect(opts.PublicKey.RelyingPartyID).To(Equal(TestRPID), "RP ID should be correct")
+
+		cred, e := rp.FinishAssertion(ctx, ceremony, key.Assert(g, ceremony.Challenge, TestOrigin))
+		g.Expect(e).To(Succeed(), "assertion should not fail")
+		g.Expect(cred.SignCount).To(Equal(key.signCount), "sign count should be updated")
+		g.Expect(cred.LastUsedAt).ToNot(BeZero(), "last used time should be updated")
+	}
+}
+
+func SubTestAssertionWithClonedAuthenticator() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore())
+		key := NewSoftwareAuthenticator(g)
+		key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatNone)
+
+		_, ceremony, e := rp.BeginAssertion(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		_, e = rp.FinishAssertion(ctx, ceremony, key.Assert(g, ceremony.Challenge, TestOrigin))
+		g.Expect(e).To(Succeed(), "assertion should not fail")
+
+		// replay with same counter
+		key.signCount--
+		_, ceremony, e = rp.BeginAssertion(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		_, e = rp.FinishAssertion(ctx, ceremony, key.Assert(g, ceremony.Challenge, TestOrigin))
+		g.Expect(e).To(HaveOccurred(), "assertion should fail")
+		g.Expect(e.Error()).To(ContainSubstring("counter"), "error should be correct")
+	}
+}
+
+func SubTestAssertionWithOtherUserCredential() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore())
+		key := NewSoftwareAuthenticator(g)
+		key.MustRegister(ctx, g, rp, "another-user", webauthn.AttestationFormatNone)
+
+		_, ceremony, e := rp.BeginAssertion(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		_, e = rp.FinishAssertion(ctx, ceremony, key.Assert(g, ceremony.Challenge, TestOrigin))
+		g.Expect(e).To(HaveOccurred(), "assertion should fail")
+		g.Expect(e).To(IsError(security.NewBadCredentialsError("")), "error should be correct")
+	}
+}
+
+func SubTestPasswordlessLogin() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewInMemoryCredentialStore()
+		rp := NewTestRelyingParty(store)
+		key := NewSoftwareAuthenticator(g)
+		key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatNone)
+		authenticator := webauthn.NewAuthenticator(func(opt *webauthn.AuthenticatorOption) {
+			opt.RelyingParty = rp
+			opt.AccountStore = NewTestAccountStore()
+		})
+
+		// discoverable credential, username is not known
+		opts, ceremony, e := rp.BeginPasswordlessAssertion(ctx, "")
+		g.Expect(opts.PublicKey.UserVerification).To(Equal(webauthn.UserVerificationRequired), "user verification should be required")
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		auth, e := authenticator.Authenticate(ctx, &webauthn.AssertionCandidate{
+			Ceremony:   ceremony,
+			Response:   key.Assert(g, ceremony.Challenge, TestOrigin),
+			DetailsMap: map[string]interface{}{},
+		})
+		g.Expect(e).To(Succeed(), "authentication should not fail")
+		g.Expect(auth.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+		webAuthnAuth, ok := auth.(webauthn.Authentication)
+		g.Expect(ok).To(BeTrue(), "auth should be WebAuthn authentication")
+		g.Expect(webAuthnAuth.Username()).To(Equal(TestUser), "username should be correct")
+		details := auth.Details().(map[string]interface{})
+		g.Expect(details).To(HaveKeyWithValue(security.DetailsKeyAuthMethod, security.AuthMethodWebAuthn), "auth method should be correct")
+		g.Expect(details).To(HaveKey(security.DetailsKeyAuthTime), "auth time should be set")
+	}
+}
+
+func SubTestPasswordlessLoginWithoutUserVerification() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		rp := NewTestRelyingParty(NewInMemoryCredentialStore(), func(opt *webauthn.RelyingPartyOption) {
+			opt.UserVerification = webauthn.UserVerificationDiscouraged
+		})
+		key := NewSoftwareAuthenticator(g)
+		key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatNone)
+		key.skipUserVerification = true
+		authenticator := webauthn.NewAuthenticator(func(opt *webauthn.AuthenticatorOption) {
+			opt.RelyingParty = rp
+			opt.AccountStore = NewTestAccountStore()
+		})
+
+		// user is not verified by the authenticator
+		_, ceremony, e := rp.BeginPasswordlessAssertion(ctx, "")
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		_, e = authenticator.Authenticate(ctx, &webauthn.AssertionCandidate{
+			Ceremony:   ceremony,
+			Response:   key.Assert(g, ceremony.Challenge, TestOrigin),
+			DetailsMap: map[string]interface{}{},
+		})
+		g.Expect(e).To(HaveOccurred(), "authentication without user verification should fail")
+		g.Expect(e.Error()).To(ContainSubstring("not verified"), "error should be correct")
+
+		// ceremony not meant for passwordless login
+		_, ceremony, e = rp.BeginAssertion(ctx, "")
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		_, e = authenticator.Authenticate(ctx, &webauthn.AssertionCandidate{
+			Ceremony:   ceremony,
+			Response:   key.Assert(g, ceremony.Challenge, TestOrigin),
+			DetailsMap: map[string]interface{}{},
+		})
+		g.Expect(e).To(HaveOccurred(), "authentication with ceremony not requiring user verification should fail")
+		g.Expect(e.Error()).To(ContainSubstring("requires user verification"), "error should be correct")
+	}
+}
+
+func SubTestSecondFactorLogin() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewInMemoryCredentialStore()
+		rp := NewTestRelyingParty(store)
+		key := NewSoftwareAuthenticator(g)
+		key.MustRegister(ctx, g, rp, TestUser, webauthn.AttestationFormatNone)
+		acctStore := NewTestAccountStore()
+
+		// password login
+		pwdAuth := NewTestPasswordAuthenticator(ctx, g, acctStore, store)
+		auth, e := pwdAuth.Authenticate(ctx, &passwd.UsernamePasswordPair{
+			Username: TestUser, Password: TestPassword, EnforceMFA: passwd.MFAModeOptional,
+		})
+		g.Expect(e).To(Succeed(), "password authentication should not fail")
+		g.Expect(auth.State()).To(Equal(security.StatePrincipalKnown), "auth state should be MFA pending")
+		g.Expect(auth.Permissions()).To(HaveKey(passwd.SpecialPermissionMFAPending), "MFA should be pending")
+		g.Expect(auth.Permissions()).To(HaveKey(webauthn.SpecialPermissionWebAuthnPending), "WebAuthn should be pending")
+		g.Expect(auth.Permissions()).ToNot(HaveKey(passwd.SpecialPermissionOtpId), "OTP should not be generated")
+
+		// WebAuthn verification
+		authenticator := webauthn.NewAuthenticator(func(opt *webauthn.AuthenticatorOption) {
+			opt.RelyingParty = rp
+			opt.AccountStore = acctStore
+		})
+		_, ceremony, e := rp.BeginAssertion(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "begin assertion should not fail")
+		auth, e = authenticator.Authenticate(ctx, &webauthn.MFAAssertionVerification{
+			CurrentAuth: auth.(passwd.UsernamePasswordAuthentication),
+			Ceremony:    ceremony,
+			Response:    key.Assert(g, ceremony.Challenge, TestOrigin),
+			DetailsMap:  map[string]interface{}{},
+		})
+		g.Expect(e).To(Succeed(), "WebAuthn verification should not fail")
+		g.Expect(auth.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+		g.Expect(auth.Permissions()).ToNot(HaveKey(passwd.SpecialPermissionMFAPending), "MFA should not be pending")
+		g.Expect(auth.Details()).To(HaveKeyWithValue(security.DetailsKeyMFAApplied, true), "MFA should be applied")
+	}
+}
+
+func SubTestPasswordLoginWithoutCredentials() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		pwdAuth := NewTestPasswordAuthenticator(ctx, g, NewTestAccountStore(), NewInMemoryCredentialStore())
+		auth, e := pwdAuth.Authenticate(ctx, &passwd.UsernamePasswordPair{
+			Username: TestUser, Password: TestPassword, EnforceMFA: passwd.MFAModeOptional,
+		})
+		g.Expect(e).To(Succeed(), "password authentication should not fail")
+		g.Expect(auth.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+		g.Expect(auth.Permissions()).ToNot(HaveKey(webauthn.SpecialPermissionWebAuthnPending), "WebAuthn should not be pending")
+	}
+}
+
+func SubTestRegistrationWithRecentLogin() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewInMemoryCredentialStore()
+		acctStore := NewTestAccountStore()
+		ep := NewTestEndpoints(store, acctStore, 5*time.Minute)
+		ctx = ContextWithPasswordLogin(ctx, g, acctStore)
+
+		key := NewSoftwareAuthenticator(g)
+		opts, e := ep.RegistrationOptions(ctx, &webauthn.RegistrationOptionsRequest{})
+		g.Expect(e).To(Succeed(), "registration options should be generated after recent login")
+		_, e = ep.Registration(ctx, key.Register(g, opts.PublicKey.Challenge, opts.PublicKey.User.ID, TestOrigin, webauthn.AttestationFormatNone))
+		g.Expect(e).To(Succeed(), "registration should not fail")
+		creds, e := store.LoadCredentialsByUsername(ctx, TestUser)
+		g.Expect(e).To(Succeed(), "loading credentials should not fail")
+		g.Expect(creds).To(HaveLen(1), "credential should be saved")
+	}
+}
+
+func SubTestRegistrationWithoutStepUp() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		store := NewInMemoryCredentialStore()
+		acctStore := NewTestAccountStore()
+		ep := NewTestEndpoints(store, acctStore, 0)
+		ctx = ContextWithPasswordLogin(ctx, g, acctStore)
+
+		_, e := ep.RegistrationOptions(ctx, &webauthn.RegistrationOptionsRequest{})
+		g.Expect(e).To(HaveOccurred(), "registration options should require step-up")
+		g.Expect(e).To(IsError(security.NewAccessDeniedError("")), "error should be correct")
+
+		_, e = ep.RegistrationOptions(ctx, &webauthn.RegistrationOptionsRequest{Passcode: "123456"})
+		g.Expect(e).To(IsError(security.NewAccessDeniedError("")), "passcode should not be accepted without enrollment store")
+
+		// registration without ceremony issued after step-up
+		key := NewSoftwareAuthenticator(g)
+		_, e = ep.Registration(ctx, key.Register(g, []byte("challenge"), []byte("user"), TestOrigin, webauthn.AttestationFormatNone))
+		g.Expect(e).To(HaveOccurred(), "registration should fail without step-up")
+		creds, _ := store.LoadCredentialsByUsername(ctx, TestUser)
+		g.Expect(creds).To(BeEmpty(), "credential should not be saved")
+	}
+}
+
+func SubTestRegistrationWithRecoveryCode() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		codes, hashed, e := passwd.NewRecoveryCodes(2)
+		g.Expect(e).To(Succeed(), "generating recovery codes should not fail")
+		acctStore := &EnrolledAccountStore{
+			AccountStore: NewTestAccountStore(),
+			enrollment:   &passwd.MFAEnrollment{Method: passwd.MFAMethodTOTP, RecoveryCodes: hashed},
+		}
+		ep := NewTestEndpoints(NewInMemoryCredentialStore(), acctStore, 0)
+		ctx = ContextWithPasswordLogin(ctx, g, acctStore)
+
+		_, e = ep.RegistrationOptions(ctx, &webauthn.RegistrationOptionsRequest{Passcode: "invalid-code"})
+		g.Expect(e).To(IsError(security.NewAccessDeniedError("")), "invalid passcode should be rejected")
+		_, e = ep.RegistrationOptions(ctx, &webauthn.RegistrationOptionsRequest{Passcode: codes[0]})
+		g.Expect(e).To(Succeed(), "registration options should be generated with recovery code")
+		_, e = ep.RegistrationOptions(ctx, &webauthn.RegistrationOptionsRequest{Passcode: codes[0]})
+		g.Expect(e).To(IsError(security.NewAccessDeniedError("")), "used recovery code should be rejected")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func NewTestEndpoints(store webauthn.CredentialStore, acctStore security.AccountStore, stepUpMaxAge time.Duration) *webauthn.Endpoints {
+	return webauthn.NewEndpoints(func(opt *webauthn.EndpointsOption) {
+		opt.RelyingParty = NewTestRelyingParty(store)
+		opt.AccountStore = acctStore
+		opt.StepUpMaxAge = stepUpMaxAge
+	})
+}
+
+// ContextWithPasswordLogin returns a context with session and authentication of a fresh password login
+func ContextWithPasswordLogin(ctx context.Context, g *gomega.WithT, acctStore security.AccountStore) context.Context {
+	auth, e := NewTestPasswordAuthenticator(ctx, g, acctStore, NewInMemoryCredentialStore()).
+		Authenticate(ctx, &passwd.UsernamePasswordPair{Username: TestUser, Password: TestPassword})
+	g.Expect(e).To(Succeed(), "password authentication should not fail")
+	mc := utils.MakeMutableContext(ctx)
+	security.MustSet(mc, auth)
+	session.MustSet(mc, session.CreateSession(sectest.NewMockedSessionStore(), "test-session"))
+	return mc
+}
+
+// EnrolledAccountStore implements passwd.MFAEnrollmentStore with single enrollment shared by all accounts
+type EnrolledAccountStore struct {
+	security.AccountStore
+	mtx        sync.Mutex
+	enrollment *passwd.MFAEnrollment
+}
+
+func (s *EnrolledAccountStore) LoadMFAEnrollment(_ context.Context, _ security.Account) (*passwd.MFAEnrollment, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.enrollment == nil {
+		return nil, nil
+	}
+	cp := *s.enrollment
+	cp.RecoveryCodes = append([]string(nil), s.enrollment.RecoveryCodes...)
+	return &cp, nil
+}
+
+func (s *EnrolledAccountStore) SaveMFAEnrollment(_ context.Context, _ security.Account, enrollment *passwd.MFAEnrollment) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.enrollment = enrollment
+	return nil
+}
+
+func (s *EnrolledAccountStore) DeleteMFAEnrollment(_ context.Context, _ security.Account) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.enrollment = nil
+	return nil
+}
+
+func NewTestRelyingParty(store webauthn.CredentialStore, opts ...webauthn.RelyingPartyOptions) *webauthn.RelyingParty {
+	return webauthn.NewRelyingParty(append([]webauthn.RelyingPartyOptions{
+		func(opt *webauthn.RelyingPartyOption) {
+			opt.ID = TestRPID
+			opt.CredentialStore = store
+		},
+	}, opts...)...)
+}
+
+func NewTestAccountStore() security.AccountStore {
+	return sectest.NewMockedAccountStore([]*sectest.MockedAccountProperties{
+		{UserId: "test-user-id", Username: TestUser, Password: TestPassword, Perms: []string{"TEST_PERMISSION"}},
+		{UserId: "another-user-id", Username: "another-user", Password: TestPassword},
+	})
+}
+
+func NewTestPasswordAuthenticator(ctx context.Context, g *gomega.WithT, acctStore security.AccountStore, credStore webauthn.CredentialStore) security.Authenticator {
+	authn, e := passwd.NewAuthenticatorBuilder(passwd.New().
+		AccountStore(acctStore).
+		PasswordEncoder(passwd.NewNoopPasswordEncoder()).
+		MFA(true).
+		DecisionMakers(webauthn.NewSecondFactorDecisionMaker(credStore)),
+	).Build(ctx)
+	g.Expect(e).To(Succeed(), "building password authenticator should not fail")
+	return authn
+}
+
+// SoftwareAuthenticator emulates an ES256 authenticator
+type SoftwareAuthenticator struct {
+	key        *ecdsa.PrivateKey
+	credID     []byte
+	aaguid     []byte
+	signCount  uint32
+	userHandle []byte
+	// skipUserVerification omits UV flag in assertions
+	skipUserVerification bool
+	// optional attestation certificate
+	attKey   *ecdsa.PrivateKey
+	attCert  *x509.Certificate
+	rootCert *x509.Certificate
+}
+
+func NewSoftwareAuthenticator(g *gomega.WithT) *SoftwareAuthenticator {
+	key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(e).To(Succeed(), "generating key should not fail")
+	return &SoftwareAuthenticator{
+		key:    key,
+		credID: []byte(utils.RandomString(32)),
+		aaguid: bytes.Repeat([]byte{0xab}, 16),
+	}
+}
+
+func (a *SoftwareAuthenticator) WithAttestationCertificate(g *gomega.WithT) {
+	rootKey, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(e).To(Succeed(), "generating key should not fail")
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Attestation Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDer, e := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	g.Expect(e).To(Succeed(), "creating root certificate should not fail")
+	a.rootCert, _ = x509.ParseCertificate(rootDer)
+
+	a.attKey, e = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(e).To(Succeed(), "generating key should not fail")
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName:         "Test Authenticator",
+			Organization:       []string{"Test Vendor"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			Country:            []string{"US"},
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+	}
+	leafDer, e := x509.CreateCertificate(rand.Reader, leafTmpl, a.rootCert, &a.attKey.PublicKey, rootKey)
+	g.Expect(e).To(Succeed(), "creating attestation certificate should not fail")
+	a.attCert, _ = x509.ParseCertificate(leafDer)
+}
+
+func (a *SoftwareAuthenticator) MustRegister(ctx context.Context, g *gomega.WithT, rp *webauthn.RelyingParty, username, format string) *webauthn.Credential {
+	opts, ceremony, e := rp.BeginRegistration(ctx, username, "")
+	g.Expect(e).To(Succeed(), "begin registration should not fail")
+	resp := a.Register(g, opts.PublicKey.Challenge, opts.PublicKey.User.ID, TestOrigin, format)
+	cred, e := rp.FinishRegistration(ctx, ceremony, resp)
+	g.Expect(e).To(Succeed(), "registration should not fail")
+	return cred
+}
+
+func (a *SoftwareAuthenticator) Register(g *gomega.WithT, challenge, userHandle []byte, origin, format string) *webauthn.RegistrationResponse {
+	a.userHandle = userHandle
+	clientData := a.clientData(g, webauthn.ClientDataTypeCreate, challenge, origin)
+	coseKey, e := cbor.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	g.Expect(e).To(Succeed(), "encoding COSE key should not fail")
+
+	attested := append([]byte{}, a.aaguid...)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credID)))
+	attested = append(append(attested, a.credID...), coseKey...)
+	authData := a.authData(webauthn.FlagUserPresent|webauthn.FlagUserVerified|webauthn.FlagAttestedCredentialData, attested)
+
+	stmt := map[string]interface{}{}
+	if format == webauthn.AttestationFormatPacked {
+		signingKey := a.key
+		if a.attKey != nil {
+			signingKey = a.attKey
+			stmt["x5c"] = []interface{}{a.attCert.Raw}
+		}
+		stmt["alg"] = -7
+		stmt["sig"] = a.sign(g, signingKey, authData, clientData)
+	}
+	attObj, e := cbor.Marshal(map[string]interface{}{
+		"fmt":      format,
+		"attStmt":  stmt,
+		"authData": authData,
+	})
+	g.Expect(e).To(Succeed(), "encoding attestation object should not fail")
+
+	return &webauthn.RegistrationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credID),
+		RawID: a.credID,
+		Type:  webauthn.CredentialTypePublicKey,
+		Response: webauthn.AuthenticatorAttestationResponse{
+			ClientDataJSON:    clientData,
+			AttestationObject: attObj,
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+func (a *SoftwareAuthenticator) Assert(g *gomega.WithT, challenge []byte, origin string) *webauthn.AssertionResponse {
+	a.signCount++
+	clientData := a.clientData(g, webauthn.ClientDataTypeGet, challenge, origin)
+	flags := webauthn.FlagUserPresent | webauthn.FlagUserVerified
+	if a.skipUserVerification {
+		flags = webauthn.FlagUserPresent
+	}
+	authData := a.authData(flags, nil)
+	return &webauthn.AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credID),
+		RawID: a.credID,
+		Type:  webauthn.CredentialTypePublicKey,
+		Response: webauthn.AuthenticatorAssertionResponse{
+			ClientDataJSON:    clientData,
+			AuthenticatorData: authData,
+			Signature:         a.sign(g, a.key, authData, clientData),
+			UserHandle:        a.userHandle,
+		},
+	}
+}
+
+func (a *SoftwareAuthenticator) clientData(g *gomega.WithT, typ string, challenge []byte, origin string) []byte {
+	data, e := json.Marshal(webauthn.CollectedClientData{
+		Type:      typ,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    origin,
+	})
+	g.Expect(e).To(Succeed(), "encoding client data should not fail")
+	return data
+}
+
+func (a *SoftwareAuthenticator) authData(flags webauthn.AuthenticatorFlags, attested []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(TestRPID))
+	data := append(rpIdHash[:], byte(flags))
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func (a *SoftwareAuthenticator) sign(g *gomega.WithT, key *ecdsa.PrivateKey, authData, clientData []byte) []byte {
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	sig, e := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	g.Expect(e).To(Succeed(), "signing should not fail")
+	return sig
+}
+
+// InMemoryCredentialStore implements webauthn.CredentialStore
+type InMemoryCredentialStore struct {
+	mtx   sync.Mutex
+	creds map[string]*webauthn.Credential
+}
+
+func NewInMemoryCredentialStore() *InMemoryCredentialStore {
+	return &InMemoryCredentialStore{creds: map[string]*webauthn.Credential{}}
+}
+
+func (s *InMemoryCredentialStore) LoadCredentialsByUsername(_ context.Context, username string) ([]*webauthn.Credential, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var ret []*webauthn.Credential
+	for _, cred := range s.creds {
+		if cred.Username == username {
+			cp := *cred
+			ret = append(ret, &cp)
+		}
+	}
+	return ret, nil
+}
+
+func (s *InMemoryCredentialStore) LoadCredentialByID(_ context.Context, id []byte) (*webauthn.Credential, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if cred, ok := s.creds[string(id)]; ok {
+		cp := *cred
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (s *InMemoryCredentialStore) SaveCredential(_ context.Context, cred *webauthn.Credential) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	cp := *cred
+	s.creds[string(cred.ID)] = &cp
+	return nil
+}
+
+func (s *InMemoryCredentialStore) DeleteCredential(_ context.Context, id []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.creds, string(id))
+	return nil
+}
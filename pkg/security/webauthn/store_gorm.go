@@ -0,0 +1,147 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webauthn
+
+import (
+	"context"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/data/tx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const TableName = "webauthn_credentials"
+
+// CredentialModel is the gorm model of Credential used by GormCredentialStore.
+// Application is responsible for creating the table, e.g. via AutoMigrate or a migration step:
+//
+//	CREATE TABLE IF NOT EXISTS "webauthn_credentials" (
+//		"id" BYTES PRIMARY KEY,
+//		"username" STRING NOT NULL,
+//		"user_handle" BYTES NOT NULL,
+//		"public_key" BYTES NOT NULL,
+//		"attestation_type" STRING,
+//		"aaguid" BYTES,
+//		"sign_count" INT8 NOT NULL DEFAULT 0,
+//		"transports" JSONB,
+//		"backup_eligible" BOOL NOT NULL DEFAULT false,
+//		"created_at" TIMESTAMPTZ,
+//		"last_used_at" TIMESTAMPTZ,
+//		INDEX "idx_webauthn_credentials_username" ("username")
+//	);
+type CredentialModel struct {
+	ID              []byte    `gorm:"primaryKey;"`
+	Username        string    `gorm:"not null;index:idx_webauthn_credentials_username;"`
+	UserHandle      []byte    `gorm:"not null;"`
+	PublicKey       []byte    `gorm:"not null;"`
+	AttestationType string    `gorm:""`
+	AAGUID          []byte    `gorm:"column:aaguid;"`
+	SignCount       int64     `gorm:"not null;default:0;"`
+	Transports      []string  `gorm:"type:jsonb;serializer:json;"`
+	BackupEligible  bool      `gorm:"not null;default:false;"`
+	CreatedAt       time.Time `gorm:""`
+	LastUsedAt      time.Time `gorm:""`
+}
+
+func (CredentialModel) TableName() string {
+	return TableName
+}
+
+// GormCredentialStore is a reference implementation of CredentialStore backed by gorm.
+// Current gorm transaction is used if available (see tx.GormTxWithContext)
+type GormCredentialStore struct {
+	db *gorm.DB
+}
+
+func NewGormCredentialStore(db *gorm.DB) *GormCredentialStore {
+	return &GormCredentialStore{db: db}
+}
+
+func (s *GormCredentialStore) LoadCredentialsByUsername(ctx context.Context, username string) ([]*Credential, error) {
+	var models []*CredentialModel
+	if e := s.gorm(ctx).Where(&CredentialModel{Username: username}).Order("created_at").Find(&models).Error; e != nil {
+		return nil, e
+	}
+	creds := make([]*Credential, len(models))
+	for i := range models {
+		creds[i] = models[i].toCredential()
+	}
+	return creds, nil
+}
+
+func (s *GormCredentialStore) LoadCredentialByID(ctx context.Context, id []byte) (*Credential, error) {
+	var model CredentialModel
+	switch e := s.gorm(ctx).Where("id = ?", id).Take(&model).Error; {
+	case errors.Is(e, gorm.ErrRecordNotFound):
+		return nil, nil
+	case e != nil:
+		return nil, e
+	}
+	return model.toCredential(), nil
+}
+
+func (s *GormCredentialStore) SaveCredential(ctx context.Context, cred *Credential) error {
+	model := newCredentialModel(cred)
+	return s.gorm(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sign_count", "backup_eligible", "last_used_at"}),
+	}).Create(model).Error
+}
+
+func (s *GormCredentialStore) DeleteCredential(ctx context.Context, id []byte) error {
+	return s.gorm(ctx).Where("id = ?", id).Delete(&CredentialModel{}).Error
+}
+
+func (s *GormCredentialStore) gorm(ctx context.Context) *gorm.DB {
+	if db := tx.GormTxWithContext(ctx); db != nil {
+		return db
+	}
+	return s.db.WithContext(ctx)
+}
+
+func newCredentialModel(cred *Credential) *CredentialModel {
+	return &CredentialModel{
+		ID:              cred.ID,
+		Username:        cred.Username,
+		UserHandle:      cred.UserHandle,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.AAGUID,
+		SignCount:       int64(cred.SignCount),
+		Transports:      cred.Transports,
+		BackupEligible:  cred.BackupEligible,
+		CreatedAt:       cred.CreatedAt,
+		LastUsedAt:      cred.LastUsedAt,
+	}
+}
+
+func (m *CredentialModel) toCredential() *Credential {
+	return &Credential{
+		ID:              m.ID,
+		Username:        m.Username,
+		UserHandle:      m.UserHandle,
+		PublicKey:       m.PublicKey,
+		AttestationType: m.AttestationType,
+		AAGUID:          m.AAGUID,
+		SignCount:       uint32(m.SignCount),
+		Transports:      m.Transports,
+		BackupEligible:  m.BackupEligible,
+		CreatedAt:       m.CreatedAt,
+		LastUsedAt:      m.LastUsedAt,
+	}
+}
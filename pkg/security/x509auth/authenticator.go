@@ -0,0 +1,208 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package x509auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/passwd"
+)
+
+const (
+	DetailsKeyCertificateSubject     = "X509Subject"
+	DetailsKeyCertificateFingerprint = "X509Fingerprint"
+)
+
+// PrincipalExtractor extracts username from verified client certificate
+type PrincipalExtractor func(cert *x509.Certificate) (string, error)
+
+// SubjectCommonNameExtractor is a PrincipalExtractor that uses subject's CN as username
+func SubjectCommonNameExtractor(cert *x509.Certificate) (string, error) {
+	if cert.Subject.CommonName == "" {
+		return "", errors.New("client certificate doesn't have subject common name")
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// EmailSANExtractor is a PrincipalExtractor that uses the first email address of SANs as username
+func EmailSANExtractor(cert *x509.Certificate) (string, error) {
+	if len(cert.EmailAddresses) == 0 {
+		return "", errors.New("client certificate doesn't have email address")
+	}
+	return cert.EmailAddresses[0], nil
+}
+
+// Fingerprint returns hex encoded SHA-256 of DER encoded certificate
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+/************************
+	security.Candidate
+************************/
+
+// Candidate is the security.Candidate of client certificate verified by the web server
+type Candidate struct {
+	Certificate *x509.Certificate
+	DetailsMap  map[string]interface{}
+}
+
+func (c *Candidate) Principal() interface{} {
+	if c.Certificate == nil {
+		return ""
+	}
+	return c.Certificate.Subject.String()
+}
+
+func (c *Candidate) Credentials() interface{} {
+	return c.Certificate
+}
+
+func (c *Candidate) Details() interface{} {
+	return c.DetailsMap
+}
+
+/******************************
+	security.Authentication
+******************************/
+
+// Authentication is the security.Authentication of users authenticated with client certificate
+type Authentication interface {
+	security.Authentication
+	Username() string
+	Fingerprint() string
+}
+
+// x509Authentication
+// Note: all fields should not be used directly. It's exported only because gob only deal with exported field
+type x509Authentication struct {
+	Acct       security.Account
+	Perms      map[string]interface{}
+	DetailsMap map[string]interface{}
+	CertFP     string
+}
+
+func (a *x509Authentication) Principal() interface{} {
+	return a.Acct
+}
+
+func (a *x509Authentication) Permissions() security.Permissions {
+	return a.Perms
+}
+
+func (a *x509Authentication) State() security.AuthenticationState {
+	return security.StateAuthenticated
+}
+
+func (a *x509Authentication) Details() interface{} {
+	return a.DetailsMap
+}
+
+func (a *x509Authentication) Username() string {
+	return a.Acct.Username()
+}
+
+func (a *x509Authentication) Fingerprint() string {
+	return a.CertFP
+}
+
+/******************************
+	security.Authenticator
+******************************/
+
+type AuthenticatorOptions func(opt *AuthenticatorOption)
+type AuthenticatorOption struct {
+	AccountStore       security.AccountStore
+	PrincipalExtractor PrincipalExtractor
+	Checkers           []passwd.AuthenticationDecisionMaker
+	PostProcessors     []passwd.PostAuthenticationProcessor
+}
+
+// Authenticator implements security.Authenticator. It supports Candidate
+type Authenticator struct {
+	accountStore       security.AccountStore
+	principalExtractor PrincipalExtractor
+	support            passwd.AuthenticatorSupport
+}
+
+func NewAuthenticator(opts ...AuthenticatorOptions) *Authenticator {
+	opt := AuthenticatorOption{
+		PrincipalExtractor: SubjectCommonNameExtractor,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &Authenticator{
+		accountStore:       opt.AccountStore,
+		principalExtractor: opt.PrincipalExtractor,
+		support:            passwd.NewAuthenticatorSupport(opt.AccountStore, opt.Checkers, opt.PostProcessors),
+	}
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, candidate security.Candidate) (auth security.Authentication, err error) {
+	can, ok := candidate.(*Candidate)
+	if !ok {
+		return nil, nil
+	}
+	if can.Certificate == nil {
+		return nil, security.NewBadCredentialsError("client certificate is missing")
+	}
+
+	// schedule post processing
+	var user security.Account
+	defer func() {
+		auth, err = a.support.PostProcess(ctx, user, candidate, auth, err)
+	}()
+
+	username, e := a.principalExtractor(can.Certificate)
+	if e != nil {
+		return nil, security.NewBadCredentialsError("unable to extract principal from client certificate", e)
+	}
+
+	if user, e = a.accountStore.LoadAccountByUsername(ctx, username); e != nil {
+		user = nil
+		return nil, security.NewUsernameNotFoundError("account not found", e)
+	}
+	if e := a.support.Decide(ctx, candidate, user, nil); e != nil {
+		return nil, e
+	}
+
+	newAuth := a.createSuccessAuthentication(can, user)
+	if e := a.support.Decide(ctx, candidate, user, newAuth); e != nil {
+		return nil, e
+	}
+	return newAuth, nil
+}
+
+func (a *Authenticator) createSuccessAuthentication(can *Candidate, account security.Account) security.Authentication {
+	fingerprint := Fingerprint(can.Certificate)
+	details := passwd.NewAuthenticationDetails(can.DetailsMap, security.AuthMethodX509)
+	details[DetailsKeyCertificateSubject] = can.Certificate.Subject.String()
+	details[DetailsKeyCertificateFingerprint] = fingerprint
+
+	return &x509Authentication{
+		Acct:       account.CacheableCopy(),
+		Perms:      passwd.AccountPermissions(account),
+		DetailsMap: details,
+		CertFP:     fingerprint,
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package x509auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/x509auth"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	. "github.com/cisco-open/go-lanai/test/utils/gomega"
+	"github.com/gin-gonic/gin"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	TestUser         = "test-user"
+	TestDisabledUser = "disabled-user"
+	TestEmail        = "test-user@example.com"
+	TestPermission   = "ALLOW_TEST"
+)
+
+/*************************
+	Test
+ *************************/
+
+func TestX509Authenticator(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestAuthenticateWithCommonName(), "AuthenticateWithCommonName"),
+		test.GomegaSubTest(SubTestAuthenticateWithEmailSAN(), "AuthenticateWithEmailSAN"),
+		test.GomegaSubTest(SubTestUnknownUser(), "UnknownUser"),
+		test.GomegaSubTest(SubTestDisabledAccount(), "DisabledAccount"),
+		test.GomegaSubTest(SubTestUnsupportedCandidate(), "UnsupportedCandidate"),
+		test.GomegaSubTest(SubTestMiddleware(), "Middleware"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestAuthenticateWithCommonName() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		authenticator := NewTestAuthenticator()
+		cert := NewTestCertificate(g, TestUser, TestEmail)
+		auth, e := authenticator.Authenticate(ctx, &x509auth.Candidate{Certificate: cert})
+		g.Expect(e).To(Succeed(), "authentication should not fail")
+		AssertAuthentication(g, auth, cert)
+	}
+}
+
+func SubTestAuthenticateWithEmailSAN() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		authenticator := NewTestAuthenticator(func(opt *x509auth.AuthenticatorOption) {
+			opt.PrincipalExtractor = x509auth.EmailSANExtractor
+		})
+		cert := NewTestCertificate(g, "whatever", TestUser)
+		auth, e := authenticator.Authenticate(ctx, &x509auth.Candidate{Certificate: cert})
+		g.Expect(e).To(Succeed(), "authentication should not fail")
+		AssertAuthentication(g, auth, cert)
+
+		cert = NewTestCertificate(g, TestUser, "")
+		_, e = authenticator.Authenticate(ctx, &x509auth.Candidate{Certificate: cert})
+		g.Expect(e).To(IsError(security.NewBadCredentialsError("")), "certificate without email should fail")
+	}
+}
+
+func SubTestUnknownUser() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		authenticator := NewTestAuthenticator()
+		cert := NewTestCertificate(g, "another-user", "")
+		_, e := authenticator.Authenticate(ctx, &x509auth.Candidate{Certificate: cert})
+		g.Expect(e).To(IsError(security.NewUsernameNotFoundError("")), "error should be correct")
+
+		cert = NewTestCertificate(g, "", "")
+		_, e = authenticator.Authenticate(ctx, &x509auth.Candidate{Certificate: cert})
+		g.Expect(e).To(IsError(security.NewBadCredentialsError("")), "certificate without CN should fail")
+	}
+}
+
+func SubTestDisabledAccount() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		authenticator := NewTestAuthenticator()
+		cert := NewTestCertificate(g, TestDisabledUser, "")
+		_, e := authenticator.Authenticate(ctx, &x509auth.Candidate{Certificate: cert})
+		g.Expect(e).To(IsError(security.NewAccountStatusError("")), "error should be correct")
+	}
+}
+
+func SubTestUnsupportedCandidate() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		authenticator := NewTestAuthenticator()
+		auth, e := authenticator.Authenticate(ctx, &security.AnonymousCandidate{})
+		g.Expect(e).To(Succeed(), "unsupported candidate should not fail")
+		g.Expect(auth).To(BeNil(), "unsupported candidate should be ignored")
+	}
+}
+
+func SubTestMiddleware() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		successHandler := &countingSuccessHandler{}
+		mw := x509auth.NewX509AuthMiddleware(NewTestAuthenticator(), successHandler)
+		cert := NewTestCertificate(g, TestUser, "")
+
+		// without TLS
+		gc := NewTestGinContext(nil)
+		mw.HandlerFunc()(gc)
+		g.Expect(security.Get(gc).State()).To(Equal(security.StateAnonymous), "request without certificate should not be authenticated")
+
+		// with verified certificate
+		gc = NewTestGinContext(cert)
+		mw.HandlerFunc()(gc)
+		AssertAuthentication(g, security.Get(gc), cert)
+		g.Expect(successHandler.count).To(Equal(1), "success handler should be invoked")
+
+		// same certificate again
+		mw.HandlerFunc()(gc)
+		g.Expect(successHandler.count).To(Equal(1), "success handler should not be invoked again")
+
+		// unknown user
+		gc = NewTestGinContext(NewTestCertificate(g, "another-user", ""))
+		mw.HandlerFunc()(gc)
+		g.Expect(gc.IsAborted()).To(BeTrue(), "request should be aborted")
+		g.Expect(gc.Errors).To(HaveLen(1), "error should be recorded")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func NewTestAuthenticator(opts ...x509auth.AuthenticatorOptions) *x509auth.Authenticator {
+	store := sectest.NewMockedAccountStore([]*sectest.MockedAccountProperties{
+		{UserId: "test-user-id", Username: TestUser, Perms: []string{TestPermission}},
+		{UserId: "disabled-user-id", Username: TestDisabledUser, Perms: []string{TestPermission}},
+	}, func(acct security.Account) security.Account {
+		if acct.Username() != TestDisabledUser {
+			return acct
+		}
+		return &security.DefaultAccount{
+			AcctDetails: security.AcctDetails{
+				ID:       acct.ID().(string),
+				Type:     security.AccountTypeDefault,
+				Username: acct.Username(),
+				Disabled: true,
+			},
+		}
+	})
+	return x509auth.NewAuthenticator(append([]x509auth.AuthenticatorOptions{
+		func(opt *x509auth.AuthenticatorOption) {
+			opt.AccountStore = store
+		},
+	}, opts...)...)
+}
+
+func NewTestCertificate(g *gomega.WithT, cn, email string) *x509.Certificate {
+	key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(e).To(Succeed(), "generating key should not fail")
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if email != "" {
+		tmpl.EmailAddresses = []string{email}
+	}
+	der, e := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	g.Expect(e).To(Succeed(), "creating certificate should not fail")
+	cert, e := x509.ParseCertificate(der)
+	g.Expect(e).To(Succeed(), "parsing certificate should not fail")
+	return cert
+}
+
+func NewTestGinContext(cert *x509.Certificate) *gin.Context {
+	gc, engine := gin.CreateTestContext(httptest.NewRecorder())
+	engine.ContextWithFallback = true
+	gc.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if cert != nil {
+		gc.Request.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+	}
+	gc.Request = gc.Request.WithContext(utils.MakeMutableContext(gc.Request.Context()))
+	return gc
+}
+
+func AssertAuthentication(g *gomega.WithT, auth security.Authentication, cert *x509.Certificate) {
+	g.Expect(auth).ToNot(BeNil(), "authentication should not be nil")
+	x509Auth, ok := auth.(x509auth.Authentication)
+	g.Expect(ok).To(BeTrue(), "authentication should be x509auth.Authentication")
+	g.Expect(x509Auth.State()).To(Equal(security.StateAuthenticated), "auth state should be correct")
+	g.Expect(x509Auth.Username()).To(Equal(TestUser), "username should be correct")
+	g.Expect(x509Auth.Fingerprint()).To(Equal(x509auth.Fingerprint(cert)), "fingerprint should be correct")
+	g.Expect(x509Auth.Permissions().Has(TestPermission)).To(BeTrue(), "permissions should be correct")
+	g.Expect(x509Auth.Details()).To(HaveKeyWithValue(security.DetailsKeyAuthMethod, security.AuthMethodX509), "auth method should be correct")
+}
+
+type countingSuccessHandler struct {
+	count int
+}
+
+func (h *countingSuccessHandler) HandleAuthenticationSuccess(_ context.Context, _ *http.Request, _ http.ResponseWriter, _, _ security.Authentication) {
+	h.count++
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package x509auth
+
+import (
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/web/middleware"
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type X509AuthConfigurer struct {
+	accountStore security.AccountStore
+}
+
+func newX509AuthConfigurer(accountStore security.AccountStore) *X509AuthConfigurer {
+	return &X509AuthConfigurer{
+		accountStore: accountStore,
+	}
+}
+
+func (c *X509AuthConfigurer) Apply(feature security.Feature, ws security.WebSecurity) error {
+	f := feature.(*X509AuthFeature)
+	if err := c.validate(f, ws); err != nil {
+		return err
+	}
+
+	authenticator := NewAuthenticator(func(opt *AuthenticatorOption) {
+		opt.AccountStore = f.accountStore
+		opt.PrincipalExtractor = f.principalExtractor
+	})
+	ws.Authenticator().(*security.CompositeAuthenticator).Add(authenticator)
+
+	mw := NewX509AuthMiddleware(
+		ws.Authenticator(),
+		ws.Shared(security.WSSharedKeyCompositeAuthSuccessHandler).(security.AuthenticationSuccessHandler),
+	)
+	ws.Add(middleware.NewBuilder("x509 auth").
+		Order(security.MWOrderX509Auth).
+		Use(mw.HandlerFunc()),
+	)
+	return nil
+}
+
+func (c *X509AuthConfigurer) validate(f *X509AuthFeature, ws security.WebSecurity) error {
+	if _, ok := ws.Authenticator().(*security.CompositeAuthenticator); !ok {
+		return fmt.Errorf("unable to add X509 authenticator to %T", ws.Authenticator())
+	}
+	if f.accountStore == nil {
+		f.accountStore = c.accountStore
+	}
+	if f.accountStore == nil {
+		return fmt.Errorf("unable to configure X509 auth: account store is not set")
+	}
+	if f.principalExtractor == nil {
+		f.principalExtractor = SubjectCommonNameExtractor
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package x509auth
+
+import (
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+)
+
+var (
+	FeatureId = security.FeatureId("X509Auth", security.FeatureOrderX509Auth)
+)
+
+// X509AuthFeature authenticates requests using verified client certificates (mutual TLS).
+// Client certificates are only available when the web server terminates TLS and verifies client certificates,
+// i.e. "server.tls.client-auth" is "verify-if-given" or "require-and-verify"
+//
+//goland:noinspection GoNameStartsWithPackageName
+type X509AuthFeature struct {
+	accountStore       security.AccountStore
+	principalExtractor PrincipalExtractor
+}
+
+// Configure is Standard security.Feature entrypoint
+func Configure(ws security.WebSecurity) *X509AuthFeature {
+	feature := New()
+	if fm, ok := ws.(security.FeatureModifier); ok {
+		return fm.Enable(feature).(*X509AuthFeature)
+	}
+	panic(fmt.Errorf("unable to configure X509 auth: provided WebSecurity [%T] doesn't support FeatureModifier", ws))
+}
+
+// New is Standard security.Feature entrypoint, DSL style. Used with security.WebSecurity
+func New() *X509AuthFeature {
+	return &X509AuthFeature{
+		principalExtractor: SubjectCommonNameExtractor,
+	}
+}
+
+func (f *X509AuthFeature) Identifier() security.FeatureIdentifier {
+	return FeatureId
+}
+
+// AccountStore overrides the security.AccountStore provided via dependency injection
+func (f *X509AuthFeature) AccountStore(store security.AccountStore) *X509AuthFeature {
+	f.accountStore = store
+	return f
+}
+
+// PrincipalExtractor overrides how username is extracted from client certificate. Default is SubjectCommonNameExtractor
+func (f *X509AuthFeature) PrincipalExtractor(extractor PrincipalExtractor) *X509AuthFeature {
+	f.principalExtractor = extractor
+	return f
+}
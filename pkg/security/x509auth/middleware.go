@@ -0,0 +1,82 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package x509auth
+
+import (
+	"crypto/x509"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type X509AuthMiddleware struct {
+	authenticator  security.Authenticator
+	successHandler security.AuthenticationSuccessHandler
+}
+
+func NewX509AuthMiddleware(authenticator security.Authenticator, successHandler security.AuthenticationSuccessHandler) *X509AuthMiddleware {
+	return &X509AuthMiddleware{
+		authenticator:  authenticator,
+		successHandler: successHandler,
+	}
+}
+
+func (m *X509AuthMiddleware) HandlerFunc() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cert := PeerCertificate(ctx.Request)
+		if cert == nil {
+			// verified client certificate not available, bail
+			return
+		}
+
+		before := security.Get(ctx)
+		if current, ok := before.(Authentication); ok && current.Fingerprint() == Fingerprint(cert) {
+			// already authenticated with same certificate
+			return
+		}
+
+		candidate := Candidate{
+			Certificate: cert,
+			DetailsMap:  map[string]interface{}{},
+		}
+		auth, err := m.authenticator.Authenticate(ctx, &candidate)
+		switch {
+		case err != nil:
+			m.handleError(ctx, err)
+		case auth != nil:
+			security.MustSet(ctx, auth)
+			m.successHandler.HandleAuthenticationSuccess(ctx, ctx.Request, ctx.Writer, before, auth)
+		}
+	}
+}
+
+func (m *X509AuthMiddleware) handleError(c *gin.Context, err error) {
+	logger.WithContext(c).Debugf("X509 authentication failed: %v", err)
+	security.MustClear(c)
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// PeerCertificate returns the client certificate verified by the web server during TLS handshake.
+// Returns nil if the connection is not TLS or client certificate is not given or not verified
+func PeerCertificate(r *http.Request) *x509.Certificate {
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/cisco-open/go-lanai/pkg/tracing"
 	"github.com/cisco-open/go-lanai/pkg/tracing/instrument"
 	jaegertracing "github.com/cisco-open/go-lanai/pkg/tracing/jaeger"
+	oteltracing "github.com/cisco-open/go-lanai/pkg/tracing/otel"
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/fx"
 )
@@ -41,6 +42,8 @@ var Module = &bootstrap.Module{
 }
 
 func init() {
+	// OpenTelemetry valuers are chained in front of Jaeger's, so log fields work with either tracer
+	tracing.DefaultLogValuers = oteltracing.ChainLogValuers(tracing.DefaultLogValuers)
 	log.RegisterContextLogFields(tracing.DefaultLogValuers.ContextValuers())
 }
 
@@ -96,6 +99,12 @@ func provideTracer(ctx *bootstrap.ApplicationContext, props tracing.TracingPrope
 		return
 	}
 
+	// Jaeger is enabled by default. OpenTelemetry replaces it when enabled
+	if props.OTel.Enabled && props.Jaeger.Enabled {
+		logger.WithContext(ctx).Infof("OpenTelemetry tracer is enabled, Jaeger tracer is disabled")
+		props.Jaeger.Enabled = false
+	}
+
 	tracers := make([]opentracing.Tracer, 0, 2)
 	if props.Jaeger.Enabled {
 		tracer, closer := jaegertracing.NewTracer(ctx, &props.Jaeger, &props.Sampler)
@@ -113,6 +122,22 @@ func provideTracer(ctx *bootstrap.ApplicationContext, props tracing.TracingPrope
 		}
 	}
 
+	if props.OTel.Enabled {
+		tracer, closer := oteltracing.NewTracer(ctx, &props.OTel, &props.Sampler)
+		tracers = append(tracers, tracer)
+		ret.FxHook = &fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				logger.WithContext(ctx).Infof("closing OpenTelemetry Tracer...")
+				e := closer.Close()
+				if e != nil {
+					logger.WithContext(ctx).Errorf("failed to close OpenTelemetry Tracer: %v", e)
+				}
+				logger.WithContext(ctx).Infof("OpenTelemetry Tracer closed")
+				return e
+			},
+		}
+	}
+
 	if props.Zipkin.Enabled {
 		panic("zipkin is currently unsupported")
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/onsi/gomega"
 	. "github.com/onsi/gomega"
 	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
 	"go.uber.org/fx"
 	"testing"
 )
@@ -128,6 +129,44 @@ func TestTracerWithInvalidSampler(t *testing.T) {
 	)
 }
 
+func TestTracerWithOpenTelemetry(t *testing.T) {
+	di := TestTracerDI{}
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		apptest.WithModules(tracinginit.Module),
+		apptest.WithProperties(
+			"tracing.jaeger.enabled: false",
+			"tracing.otel.enabled: true",
+			"tracing.otel.exporter.protocol: http",
+			"tracing.otel.exporter.endpoint: localhost:4318",
+			"tracing.otel.exporter.insecure: true",
+			"tracing.otel.exporter.timeout: 1s",
+			"tracing.sampler.limit-per-second: 50",
+		),
+		apptest.WithDI(&di),
+		test.Setup(SetupBootstrapTracing()),
+		test.GomegaSubTest(SubTestApplicationSpan(&di), "TestApplicationSpan"),
+	)
+}
+
+func TestTracerWithOpenTelemetryAndDefaultJaeger(t *testing.T) {
+	di := TestTracerDI{}
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		apptest.WithModules(tracinginit.Module),
+		apptest.WithProperties(
+			"tracing.otel.enabled: true",
+			"tracing.otel.exporter.protocol: http",
+			"tracing.otel.exporter.endpoint: localhost:4318",
+			"tracing.otel.exporter.insecure: true",
+			"tracing.otel.exporter.timeout: 1s",
+		),
+		apptest.WithDI(&di),
+		test.Setup(SetupBootstrapTracing()),
+		test.GomegaSubTest(SubTestOpenTelemetryTracer(&di), "TestOpenTelemetryTracer"),
+	)
+}
+
 /*************************
 	Sub-Test Cases
  *************************/
@@ -142,6 +181,14 @@ func SubTestApplicationSpan(di *TestTracerDI) test.GomegaSubTestFunc {
 	}
 }
 
+func SubTestOpenTelemetryTracer(di *TestTracerDI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		g.Expect(di.Tracer).ToNot(BeNil(), "tracer should be available")
+		g.Expect(di.Tracer).ToNot(BeAssignableToTypeOf(&jaeger.Tracer{}), "Jaeger tracer should be replaced by OpenTelemetry")
+		g.Expect(di.Tracer).ToNot(BeAssignableToTypeOf(opentracing.NoopTracer{}), "tracer should not be noop")
+	}
+}
+
 /*************************
 	Helper
  *************************/
@@ -0,0 +1,74 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/tracing"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"strings"
+	"time"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// newExporter create OTLP exporter. Note: the exporter doesn't connect to the collector until first export
+func newExporter(ctx context.Context, props *tracing.OTLPExporterProperties) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(props.Protocol) {
+	case ProtocolGRPC, "":
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithTimeout(time.Duration(props.Timeout)),
+		}
+		if props.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(props.Endpoint))
+		}
+		if props.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(props.Headers) != 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(props.Headers))
+		}
+		logger.WithContext(ctx).Infof("Use OTLP gRPC exporter with endpoint [%s]", props.Endpoint)
+		return otlptracegrpc.New(ctx, opts...)
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithTimeout(time.Duration(props.Timeout)),
+		}
+		if props.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(props.Endpoint))
+		}
+		if props.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(props.URLPath))
+		}
+		if props.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(props.Headers) != 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(props.Headers))
+		}
+		logger.WithContext(ctx).Infof("Use OTLP HTTP exporter with endpoint [%s]", props.Endpoint)
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol [%s]", props.Protocol)
+	}
+}
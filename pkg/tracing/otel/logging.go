@@ -0,0 +1,70 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oteltracing
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelSpanContext is implemented by OpenTracing bridge's span context
+type otelSpanContext interface {
+	TraceID() trace.TraceID
+	SpanID() trace.SpanID
+}
+
+// ChainLogValuers returns log valuers that extract tracing information from OpenTelemetry spans,
+// and fall back to given valuers for other tracer implementations.
+func ChainLogValuers(fallback tracing.LogValuers) tracing.LogValuers {
+	return tracing.LogValuers{
+		TraceIDValuer:  chainValuer(traceIdContextValuer, fallback.TraceIDValuer),
+		SpanIDValuer:   chainValuer(spanIdContextValuer, fallback.SpanIDValuer),
+		ParentIDValuer: fallback.ParentIDValuer,
+	}
+}
+
+func chainValuer(valuer, fallback func(ctx context.Context) interface{}) func(ctx context.Context) interface{} {
+	return func(ctx context.Context) interface{} {
+		if v := valuer(ctx); v != nil {
+			return v
+		}
+		return fallback(ctx)
+	}
+}
+
+func traceIdContextValuer(ctx context.Context) (ret interface{}) {
+	span := tracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	if sc, ok := span.Context().(otelSpanContext); ok && sc.TraceID().IsValid() {
+		ret = sc.TraceID().String()
+	}
+	return
+}
+
+func spanIdContextValuer(ctx context.Context) (ret interface{}) {
+	span := tracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	if sc, ok := span.Context().(otelSpanContext); ok && sc.SpanID().IsValid() {
+		ret = sc.SpanID().String()
+	}
+	return
+}
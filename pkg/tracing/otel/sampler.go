@@ -0,0 +1,80 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// newSampler follows same rules as Jaeger tracer. All samplers respect parent's sampling decision.
+func newSampler(ctx context.Context, sp *tracing.SamplerProperties) sdktrace.Sampler {
+	if !sp.Enabled {
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	}
+
+	if sp.Probability > 0 && sp.Probability <= 1.0 {
+		logger.WithContext(ctx).
+			Infof("Use TraceIDRatioBased sampler with probability %%%2.1f", sp.Probability*100)
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sp.Probability))
+	}
+
+	if sp.RateLimit > 0 {
+		logger.WithContext(ctx).
+			Infof("Use RateLimitingSampler with rate limit %.3f/s", sp.RateLimit)
+		return sdktrace.ParentBased(NewRateLimitingSampler(sp.RateLimit))
+	}
+
+	logger.WithContext(ctx).Warnf("both rate limit and probability are not valid, tracing sampling is disabled")
+	return sdktrace.ParentBased(sdktrace.NeverSample())
+}
+
+// rateLimitingSampler implements sdktrace.Sampler. It samples at most given number of traces per second
+type rateLimitingSampler struct {
+	limiter *rate.Limiter
+	desc    string
+}
+
+func NewRateLimitingSampler(perSecond float64) sdktrace.Sampler {
+	burst := int(perSecond)
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimitingSampler{
+		limiter: rate.NewLimiter(rate.Limit(perSecond), burst),
+		desc:    fmt.Sprintf("RateLimitingSampler{%.3f}", perSecond),
+	}
+}
+
+func (s *rateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	decision := sdktrace.Drop
+	if s.limiter.Allow() {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (s *rateLimitingSampler) Description() string {
+	return s.desc
+}
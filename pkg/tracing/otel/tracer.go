@@ -0,0 +1,120 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/tracing"
+	"github.com/opentracing/opentracing-go"
+	otbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"io"
+	"time"
+)
+
+var logger = log.New("Tracing")
+
+const (
+	instrumentationName = "github.com/cisco-open/go-lanai/pkg/tracing"
+	shutdownTimeout     = 10 * time.Second
+)
+
+type TracerOptions func(opt *TracerOption)
+type TracerOption struct {
+	// ServiceName is used as "service.name" resource attribute
+	ServiceName string
+	// Exporter overrides the exporter configured via properties. e.g. an in-memory exporter for testing
+	Exporter sdktrace.SpanExporter
+	// SyncExport export spans synchronously when ended. Should only be used for testing
+	SyncExport bool
+	// Sampler overrides the sampler configured via properties
+	Sampler sdktrace.Sampler
+	// Propagator overrides the propagator configured via properties
+	Propagator propagation.TextMapPropagator
+}
+
+// WithExporter is a TracerOptions that override the span exporter, typically used for testing
+func WithExporter(exporter sdktrace.SpanExporter, sync bool) TracerOptions {
+	return func(opt *TracerOption) {
+		opt.Exporter = exporter
+		opt.SyncExport = sync
+	}
+}
+
+// NewTracer create an OpenTelemetry backed opentracing.Tracer.
+// Returned tracer is an OpenTracing bridge, so existing instrumentation based on tracing.SpanOperator keep working.
+// The returned io.Closer flush and shutdown underlying TracerProvider
+func NewTracer(ctx *bootstrap.ApplicationContext, op *tracing.OTelProperties, sp *tracing.SamplerProperties, opts ...TracerOptions) (opentracing.Tracer, io.Closer) {
+	opt := TracerOption{
+		ServiceName: ctx.Name(),
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	if opt.Sampler == nil {
+		opt.Sampler = newSampler(ctx, sp)
+	}
+	if opt.Propagator == nil {
+		opt.Propagator = newPropagator(ctx, op.Propagators)
+	}
+	if opt.Exporter == nil {
+		exporter, e := newExporter(ctx, &op.Exporter)
+		if e != nil {
+			panic(fmt.Errorf("unable to create OpenTelemetry exporter: %v", e))
+		}
+		opt.Exporter = exporter
+	}
+	return newTracer(&opt)
+}
+
+func newTracer(opt *TracerOption) (opentracing.Tracer, io.Closer) {
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(opt.ServiceName))
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(opt.Sampler),
+	}
+	if opt.SyncExport {
+		providerOpts = append(providerOpts, sdktrace.WithSyncer(opt.Exporter))
+	} else {
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(opt.Exporter))
+	}
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+
+	bridge, _ := otbridge.NewTracerPair(provider.Tracer(instrumentationName))
+	bridge.SetTextMapPropagator(opt.Propagator)
+	bridge.SetWarningHandler(func(msg string) {
+		logger.Debugf("OpenTracing bridge: %s", msg)
+	})
+	return bridge, providerCloser{provider: provider}
+}
+
+// providerCloser implements io.Closer
+type providerCloser struct {
+	provider *sdktrace.TracerProvider
+}
+
+func (c providerCloser) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return c.provider.Shutdown(ctx)
+}
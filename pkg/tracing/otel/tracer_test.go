@@ -0,0 +1,133 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oteltracing
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/tracing"
+	"github.com/cisco-open/go-lanai/test"
+	. "github.com/onsi/gomega"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"testing"
+)
+
+/*************************
+	Setup Test
+ *************************/
+
+type testTracer struct {
+	tracer   opentracing.Tracer
+	exporter *tracetest.InMemoryExporter
+}
+
+func SetupTestTracer(tt *testTracer) test.SetupFunc {
+	return func(ctx context.Context, t *testing.T) (context.Context, error) {
+		tt.exporter = tracetest.NewInMemoryExporter()
+		tt.tracer, _ = newTracer(&TracerOption{
+			ServiceName: "test",
+			Exporter:    tt.exporter,
+			SyncExport:  true,
+			Sampler:     sdktrace.AlwaysSample(),
+			Propagator:  newPropagator(ctx, []string{PropagatorTraceContext, PropagatorBaggage}),
+		})
+		return ctx, nil
+	}
+}
+
+/*************************
+	Tests
+ *************************/
+
+func TestOTelTracer(t *testing.T) {
+	tt := testTracer{}
+	test.RunTest(context.Background(), t,
+		test.SubTestSetup(SetupTestTracer(&tt)),
+		test.GomegaSubTest(SubTestSpanOperator(&tt), "TestSpanOperator"),
+		test.GomegaSubTest(SubTestPropagation(&tt), "TestPropagation"),
+		test.GomegaSubTest(SubTestLogValuers(&tt), "TestLogValuers"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestSpanOperator(tt *testTracer) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		rootCtx := tracing.WithTracer(tt.tracer).
+			WithOpName("root").
+			NewSpanOrDescendant(ctx)
+		childCtx := tracing.WithTracer(tt.tracer).
+			WithOpName("child").
+			WithOptions(tracing.SpanTag("key", "value")).
+			DescendantOrNoSpan(rootCtx)
+		rewound := tracing.WithTracer(tt.tracer).FinishAndRewind(childCtx)
+		g.Expect(rewound).To(Equal(rootCtx), "FinishAndRewind should restore parent context")
+		tracing.WithTracer(tt.tracer).Finish(rootCtx)
+
+		spans := tt.exporter.GetSpans()
+		g.Expect(spans).To(HaveLen(2), "both spans should be exported")
+		child, root := spans[0], spans[1]
+		g.Expect(root.Name).To(Equal("root"))
+		g.Expect(child.Name).To(Equal("child"))
+		g.Expect(child.Parent.SpanID()).To(Equal(root.SpanContext.SpanID()), "child span should have correct parent")
+		g.Expect(child.SpanContext.TraceID()).To(Equal(root.SpanContext.TraceID()), "child span should have same trace ID")
+		g.Expect(child.Attributes).To(ContainElement(attribute.String("key", "value")), "child span should have tags")
+	}
+}
+
+func SubTestPropagation(tt *testTracer) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		span := tt.tracer.StartSpan("client")
+		span.SetBaggageItem("tenant", "my-tenant")
+		header := http.Header{}
+		e := tt.tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+		g.Expect(e).To(Succeed(), "inject should not fail")
+		g.Expect(header.Get("traceparent")).ToNot(BeEmpty(), "W3C traceparent header should be injected")
+		g.Expect(header.Get("baggage")).To(ContainSubstring("tenant=my-tenant"), "baggage header should be injected")
+
+		spanCtx, e := tt.tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+		g.Expect(e).To(Succeed(), "extract should not fail")
+		server := tt.tracer.StartSpan("server", ext.RPCServerOption(spanCtx))
+		g.Expect(server.BaggageItem("tenant")).To(Equal("my-tenant"), "baggage should be propagated")
+		server.Finish()
+		span.Finish()
+
+		spans := tt.exporter.GetSpans()
+		g.Expect(spans).To(HaveLen(2), "both spans should be exported")
+		g.Expect(spans[0].SpanContext.TraceID()).To(Equal(spans[1].SpanContext.TraceID()), "trace ID should be propagated")
+		g.Expect(spans[0].Parent.SpanID()).To(Equal(spans[1].SpanContext.SpanID()), "server span should be child of client span")
+	}
+}
+
+func SubTestLogValuers(tt *testTracer) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *WithT) {
+		spanCtx := tracing.WithTracer(tt.tracer).WithOpName("log").NewSpanOrDescendant(ctx)
+		defer tracing.WithTracer(tt.tracer).Finish(spanCtx)
+		span := tracing.SpanFromContext(spanCtx)
+		sc := span.Context().(otelSpanContext)
+		valuers := ChainLogValuers(tracing.DefaultLogValuers)
+		g.Expect(valuers.TraceIDValuer(spanCtx)).To(Equal(sc.TraceID().String()), "trace ID should be available for logging")
+		g.Expect(valuers.SpanIDValuer(spanCtx)).To(Equal(sc.SpanID().String()), "span ID should be available for logging")
+		g.Expect(valuers.TraceIDValuer(ctx)).To(BeNil(), "trace ID should be nil without span")
+	}
+}
@@ -18,7 +18,9 @@ package tracing
 
 import (
 	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/utils"
 	"github.com/pkg/errors"
+	"time"
 )
 
 const (
@@ -29,6 +31,7 @@ type TracingProperties struct {
 	Enabled bool              `json:"enabled"`
 	Jaeger  JaegerProperties  `json:"jaeger"`
 	Zipkin  ZipkinProperties  `json:"zipkin"`
+	OTel    OTelProperties    `json:"otel"`
 	Sampler SamplerProperties `json:"sampler"`
 }
 
@@ -42,6 +45,27 @@ type ZipkinProperties struct {
 	Enabled bool `json:"enabled"`
 }
 
+// OTelProperties configures OpenTelemetry tracer backend.
+// When enabled, it replaces Jaeger tracer regardless of JaegerProperties.Enabled
+type OTelProperties struct {
+	Enabled bool `json:"enabled"`
+	// Propagators are names of context propagation formats. Supported values are "tracecontext" and "baggage"
+	Propagators utils.CommaSeparatedSlice `json:"propagators"`
+	Exporter    OTLPExporterProperties    `json:"exporter"`
+}
+
+type OTLPExporterProperties struct {
+	// Protocol is either "grpc" or "http"
+	Protocol string `json:"protocol"`
+	// Endpoint is the host and port of OTLP collector, without scheme and path. e.g. "localhost:4317"
+	Endpoint string `json:"endpoint"`
+	// URLPath is the URL path of HTTP protocol. Ignored if Protocol is "grpc"
+	URLPath  string            `json:"url-path"`
+	Insecure bool              `json:"insecure"`
+	Headers  map[string]string `json:"headers"`
+	Timeout  utils.Duration    `json:"timeout"`
+}
+
 type SamplerProperties struct {
 	Enabled     bool    `json:"enabled"`
 	RateLimit   float64 `json:"limit-per-second"`
@@ -57,6 +81,14 @@ func NewTracingProperties() *TracingProperties {
 			Enabled: true,
 		},
 		Zipkin: ZipkinProperties{},
+		OTel: OTelProperties{
+			Enabled:     false,
+			Propagators: utils.CommaSeparatedSlice{"tracecontext", "baggage"},
+			Exporter: OTLPExporterProperties{
+				Protocol: "grpc",
+				Timeout:  utils.Duration(10 * time.Second),
+			},
+		},
 		Sampler: SamplerProperties{
 			Enabled:   false,
 			RateLimit: 10.0,
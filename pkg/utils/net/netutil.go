@@ -110,3 +110,69 @@ func AppendRedirectUrl(redirectUrl string, params map[string]string) (string, er
 
 	return loc.String(), nil
 }
+
+// TrustedProxies are IP networks of reverse proxies, whose forwarded headers (e.g. "X-Forwarded-For") can be trusted.
+// Forwarded headers of requests not sent by trusted proxies should be ignored, because any client can set them.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses given IP addresses or CIDRs, e.g. "10.0.0.0/8" or "192.168.1.1"
+func ParseTrustedProxies(proxies ...string) (TrustedProxies, error) {
+	nets := make(TrustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		ipNet, e := parseIPNet(proxy)
+		if e != nil {
+			return nil, e
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// Contains returns true if given IP address belongs to any trusted proxy
+func (p TrustedProxies) Contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range p {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTrustedPeer returns true if the immediate peer of given request, i.e. http.Request.RemoteAddr, is a trusted proxy
+func (p TrustedProxies) IsTrustedPeer(request *http.Request) bool {
+	return p.Contains(GetRemoteIP(request))
+}
+
+// GetRemoteIP returns IP address of the immediate peer of given request, i.e. http.Request.RemoteAddr without port.
+// Forwarded headers are ignored.
+func GetRemoteIP(request *http.Request) string {
+	host, _, e := net.SplitHostPort(strings.TrimSpace(request.RemoteAddr))
+	if e != nil {
+		return strings.TrimSpace(request.RemoteAddr)
+	}
+	return host
+}
+
+func parseIPNet(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		_, ipNet, e := net.ParseCIDR(value)
+		if e != nil {
+			return nil, fmt.Errorf(`invalid trusted proxy "%s": %v`, value, e)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf(`invalid trusted proxy "%s"`, value)
+	}
+	bits := 8 * net.IPv4len
+	if ip.To4() == nil {
+		bits = 8 * net.IPv6len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
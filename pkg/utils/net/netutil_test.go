@@ -59,4 +59,26 @@ func TestGetIP(t *testing.T) {
 		g.Expect(ip).To(MatchRegexp(`[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}`), "GetIp(%s) should be correct", "lo")
 	}
 
-}
\ No newline at end of file
+}
+func TestTrustedProxies(t *testing.T) {
+	g := gomega.NewWithT(t)
+	proxies, e := ParseTrustedProxies("10.0.0.0/8", "192.168.1.1", "::1")
+	g.Expect(e).To(Succeed(), "parsing trusted proxies should not fail")
+	g.Expect(proxies.Contains("10.1.2.3")).To(BeTrue(), "IP in CIDR should be trusted")
+	g.Expect(proxies.Contains("192.168.1.1")).To(BeTrue(), "single IP should be trusted")
+	g.Expect(proxies.Contains("::1")).To(BeTrue(), "IPv6 should be trusted")
+	g.Expect(proxies.Contains("192.168.1.2")).To(BeFalse(), "other IP should not be trusted")
+	g.Expect(proxies.Contains("not-an-ip")).To(BeFalse(), "invalid IP should not be trusted")
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	g.Expect(GetRemoteIP(req)).To(Equal("10.0.0.1"), "remote IP should be correct")
+	g.Expect(proxies.IsTrustedPeer(req)).To(BeTrue(), "request from trusted proxy should be trusted")
+	req.RemoteAddr = "172.16.0.1:12345"
+	g.Expect(proxies.IsTrustedPeer(req)).To(BeFalse(), "request from other peer should not be trusted")
+
+	_, e = ParseTrustedProxies("not-an-ip")
+	g.Expect(e).To(HaveOccurred(), "invalid IP should fail")
+	_, e = ParseTrustedProxies("10.0.0.0/99")
+	g.Expect(e).To(HaveOccurred(), "invalid CIDR should fail")
+}
@@ -57,6 +57,9 @@ type EngineOptions func(*Engine)
 // Example of common implementation includes JSON decoder or form data extractor
 type DecodeRequestFunc func(ctx context.Context, httpReq *http.Request) (req interface{}, err error)
 
+// DecodeMessageFunc extracts a payload from a message that is not a http.Request. e.g. WebSocket messages
+type DecodeMessageFunc func(ctx context.Context, data []byte) (req interface{}, err error)
+
 // RequestRewriter handles request rewrite. e.g. rewrite http.Request.URL.Path
 type RequestRewriter interface {
 	// HandleRewrite take the rewritten request and put it through the entire handling cycle.
@@ -197,6 +200,22 @@ type EndpointMapping MvcMapping
 // See template.MappingBuilder
 type TemplateMapping MvcMapping
 
+// WebSocketMapping defines WebSocket endpoint. The HTTP upgrade request is a GET request that goes through the same
+// middlewares (e.g. security) as other RoutedMapping. After upgrade, the connection is served by UpgradeHandlerFunc
+// until it's closed.
+// See websocket.MappingBuilder
+type WebSocketMapping interface {
+	RoutedMapping
+	UpgradeHandlerFunc() http.HandlerFunc
+}
+
+// WebSocketShutdownHook is an optional interface of WebSocketMapping.
+// Upgraded connections are hijacked from the http server and are not closed by its graceful shutdown.
+// When implemented, OnShutdown is invoked when the server is stopping, so those connections can be closed properly.
+type WebSocketShutdownHook interface {
+	OnShutdown()
+}
+
 // MiddlewareMapping defines middlewares that applies to all or selected set (via Matcher and Condition) of requests.
 // Middlewares are often used for task like security, pre/post processing request or response, metrics measurements, etc.
 // See middleware.MappingBuilder
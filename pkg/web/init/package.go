@@ -21,12 +21,18 @@ import (
 	"embed"
 	appconfig "github.com/cisco-open/go-lanai/pkg/appconfig/init"
 	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/certs"
+	"github.com/cisco-open/go-lanai/pkg/log"
 	"github.com/cisco-open/go-lanai/pkg/web"
 	"github.com/cisco-open/go-lanai/pkg/web/cors"
+	webmetrics "github.com/cisco-open/go-lanai/pkg/web/metrics"
 	webtracing "github.com/cisco-open/go-lanai/pkg/web/tracing"
 	"go.uber.org/fx"
+	"time"
 )
 
+var logger = log.New("Web.Init")
+
 //go:embed defaults-web.yml
 var defaultConfigFS embed.FS
 
@@ -39,10 +45,14 @@ var Module = &bootstrap.Module{
 			web.BindServerProperties,
 			web.NewEngine,
 			web.NewRegistrar),
+		fx.Provide(
+			provideServerShutdownHook,
+			fx.Annotated{Group: bootstrap.FxShutdownHookGroup, Target: providePreStopShutdownHook},
+		),
 		fx.Invoke(setup),
 	},
 	Modules: []*bootstrap.Module{
-		cors.Module, webtracing.Module,
+		cors.Module, webtracing.Module, webmetrics.Module,
 	},
 }
 
@@ -66,9 +76,11 @@ type initDI struct {
 	fx.In
 	Registrar        *web.Registrar
 	Properties       web.ServerProperties
-	Controllers      []web.Controller      `group:"controllers"`
-	Customizers      []web.Customizer      `group:"customizers"`
-	ErrorTranslators []web.ErrorTranslator `group:"error_translators"`
+	Controllers      []web.Controller           `group:"controllers"`
+	Customizers      []web.Customizer           `group:"customizers"`
+	ErrorTranslators []web.ErrorTranslator      `group:"error_translators"`
+	CertsManager     certs.Manager              `optional:"true"`
+	ServerStop       bootstrap.ShutdownHookFunc `name:"web/server-stop"`
 }
 
 func setup(lc fx.Lifecycle, di initDI) {
@@ -79,13 +91,47 @@ func setup(lc fx.Lifecycle, di initDI) {
 	di.Registrar.MustRegister(di.Controllers)
 	di.Registrar.MustRegister(di.Customizers)
 	di.Registrar.MustRegister(di.ErrorTranslators)
+	di.Registrar.UseCertsManager(di.CertsManager)
 
+	// Note: server is stopped by bootstrap.ShutdownHook, see provideServerShutdownHook.
+	//       OnStop is a fallback in case graceful shutdown is not executed (e.g. other components failed to start)
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) (err error) {
 			return di.Registrar.Run(ctx)
 		},
-		OnStop: func(ctx context.Context) error {
-			return di.Registrar.Stop(ctx)
-		},
+		OnStop: di.ServerStop,
 	})
 }
+
+type serverShutdownOut struct {
+	fx.Out
+	Hook bootstrap.ShutdownHook     `group:"bootstrap_shutdown_hook"`
+	Stop bootstrap.ShutdownHookFunc `name:"web/server-stop"`
+}
+
+func provideServerShutdownHook(reg *web.Registrar, props web.ServerProperties) serverShutdownOut {
+	hook := bootstrap.NewShutdownHook("web server", bootstrap.ShutdownPhaseWebServer, reg.Stop)
+	if props.Shutdown.Graceful {
+		hook = hook.WithTimeout(time.Duration(props.Shutdown.DrainTimeout))
+	}
+	return serverShutdownOut{
+		Hook: hook,
+		Stop: hook.Func,
+	}
+}
+
+func providePreStopShutdownHook(props web.ServerProperties) bootstrap.ShutdownHook {
+	delay := time.Duration(props.Shutdown.PreStopDelay)
+	if delay <= 0 {
+		return bootstrap.ShutdownHook{}
+	}
+	return bootstrap.NewShutdownHook("pre-stop delay", bootstrap.ShutdownPhasePreStop, func(ctx context.Context) error {
+		logger.WithContext(ctx).Infof("Waiting %v before stopping http server...", delay)
+		select {
+		case <-time.After(delay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}).WithTimeout(delay)
+}
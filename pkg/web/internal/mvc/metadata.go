@@ -266,6 +266,10 @@ func isSupportedResponseType(t reflect.Type) bool {
 		fallthrough
 	case reflect.Array:
 		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Chan:
+		fallthrough
+	case reflect.Func:
+		return web.IsStreamType(t)
 	default:
 		return false
 	}
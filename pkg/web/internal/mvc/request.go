@@ -26,6 +26,25 @@ func GinBindingRequestDecoder(s *Metadata) web.DecodeRequestFunc {
 	})
 }
 
+// JsonMessageDecoder is a web.DecodeMessageFunc that unmarshal JSON data into the object instantiated based on Metadata.request.
+// If the function doesn't take request, nil is returned. If the function takes *http.Request, the request associated
+// with the context is returned.
+func JsonMessageDecoder(s *Metadata) web.DecodeMessageFunc {
+	switch {
+	case s.request == nil:
+		return func(_ context.Context, _ []byte) (request interface{}, err error) {
+			return nil, nil
+		}
+	case isHttpRequestPtr(s.request):
+		return func(c context.Context, _ []byte) (request interface{}, err error) {
+			return web.HttpRequest(c), nil
+		}
+	}
+	return web.JsonMessageDecoder(func() interface{} {
+		return instantiateByType(s.request)
+	})
+}
+
 // allocate memory space of given type.
 // If the given type is a pointer, the returned value is non-nil.
 // Otherwise, a zero value is returned
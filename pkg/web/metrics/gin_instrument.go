@@ -0,0 +1,116 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webmetrics
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/metrics"
+	"github.com/cisco-open/go-lanai/pkg/utils/order"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	MeterNameServerRequests = "http.server.requests"
+	TagMethod               = "method"
+	TagUri                  = "uri"
+	TagStatus               = "status"
+	TagOutcome              = "outcome"
+)
+
+const (
+	uriNotFound = "NOT_FOUND"
+	uriUnknown  = "UNKNOWN"
+)
+
+type metricsWebCustomizer struct {
+	registry metrics.Registry
+}
+
+func newMetricsWebCustomizer(registry metrics.Registry) *metricsWebCustomizer {
+	return &metricsWebCustomizer{
+		registry: registry,
+	}
+}
+
+// Order we want metricsWebCustomizer right after tracing, so the measured duration is as close to actual as possible
+func (c metricsWebCustomizer) Order() int {
+	return order.Highest + 1
+}
+
+func (c metricsWebCustomizer) Customize(_ context.Context, r *web.Registrar) error {
+	//nolint:contextcheck // false positive
+	if e := r.AddGlobalMiddlewares(GinMetrics(c.registry)); e != nil {
+		return e
+	}
+	return nil
+}
+
+// GinMetrics returns a gin.HandlerFunc that records each request's duration as histogram "http.server.requests".
+// The "uri" tag uses the route template instead of actual path, to avoid high cardinality
+func GinMetrics(registry metrics.Registry) gin.HandlerFunc {
+	histogram := registry.Histogram(MeterNameServerRequests,
+		metrics.WithDescription("Duration of HTTP server requests"),
+		metrics.WithUnit("seconds"),
+		metrics.WithTags(TagMethod, TagUri, TagStatus, TagOutcome),
+	)
+	return func(gc *gin.Context) {
+		start := time.Now()
+		gc.Next()
+
+		status := gc.Writer.Status()
+		histogram.Observe(time.Since(start).Seconds(), metrics.Tags{
+			TagMethod:  gc.Request.Method,
+			TagUri:     routeTemplate(gc, status),
+			TagStatus:  strconv.Itoa(status),
+			TagOutcome: Outcome(status),
+		})
+	}
+}
+
+/*********************
+	common funcs
+ *********************/
+
+func routeTemplate(gc *gin.Context, status int) string {
+	if path := gc.FullPath(); path != "" {
+		return path
+	}
+	if status == http.StatusNotFound {
+		return uriNotFound
+	}
+	return uriUnknown
+}
+
+// Outcome convert HTTP status code to outcome tag value, e.g. SUCCESS, CLIENT_ERROR, etc.
+func Outcome(status int) string {
+	switch {
+	case status < 200:
+		return "INFORMATIONAL"
+	case status < 300:
+		return "SUCCESS"
+	case status < 400:
+		return "REDIRECTION"
+	case status < 500:
+		return "CLIENT_ERROR"
+	default:
+		return "SERVER_ERROR"
+	}
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -44,6 +45,21 @@ func GinBindingRequestDecoder(instantiateFunc func() interface{}) DecodeRequestF
 	}
 }
 
+// JsonMessageDecoder is a DecodeMessageFunc that unmarshal JSON data into the object created by instantiateFunc.
+// The decoded object is validated in the same way as GinBindingRequestDecoder.
+// If the instantiateFunc returns a non-pointer value, the decoder uses reflect to find its pointer
+func JsonMessageDecoder(instantiateFunc func() interface{}) DecodeMessageFunc {
+	return func(c context.Context, data []byte) (request interface{}, err error) {
+		toBind, toRet := resolveBindable(instantiateFunc())
+		if len(data) != 0 {
+			if err = json.Unmarshal(data, toBind); err != nil {
+				return nil, translateBindingError(err)
+			}
+		}
+		return toRet.Interface(), validateBinding(c, toBind)
+	}
+}
+
 type bindingFunc func(interface{}) error
 
 func bind(obj interface{}, bindings ...bindingFunc) (err error) {
@@ -0,0 +1,442 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ContentTypeEventStream = "text/event-stream"
+	ContentTypeNDJson      = "application/x-ndjson"
+)
+
+const (
+	defaultSSEHeartbeat = 15 * time.Second
+)
+
+var errInvalidStreamResponse = errors.New("invalid stream response type, expecting channel or iterator function")
+
+/**********************************
+	Stream Response
+***********************************/
+
+// StreamEvent can be used as element of streamed response to control Server-Sent Event fields.
+// Any other element type is treated as StreamEvent.Data.
+// Note: ID, Event and Retry are ignored by NDJsonResponseEncoder, only Data is written
+type StreamEvent struct {
+	// ID "id" field of the event, optional. Line breaks are removed
+	ID string
+	// Event "event" field of the event, optional. Line breaks are removed
+	Event string
+	// Retry "retry" field of the event, optional
+	Retry time.Duration
+	// Data "data" field of the event. string and []byte are written as-is, other types are JSON encoded
+	Data interface{}
+}
+
+type StreamOptions func(opt *StreamOption)
+
+type StreamOption struct {
+	// Heartbeat interval of keep-alive messages sent when no data is available. Zero value disables heartbeat.
+	// Default to 15s for SSE and disabled for NDJSON.
+	// SSE heartbeat is a comment line, NDJSON heartbeat is an empty line.
+	Heartbeat time.Duration
+	// MarshalFunc encodes each element's data. Default to JSON, except that SSE writes string and []byte as-is
+	MarshalFunc func(v interface{}) ([]byte, error)
+}
+
+// SSEResponseEncoder encodes streamed response as Server-Sent Events (text/event-stream).
+// The response (or its body if the response is a BodyContainer) should be one of following:
+//   - a receive-able channel, e.g. <-chan *MyEvent. The stream ends when the channel is closed
+//   - an iterator function, e.g. func(yield func(*MyEvent) bool), compatible with iter.Seq
+//   - an iterator function with error, e.g. func(yield func(*MyEvent, error) bool), compatible with iter.Seq2
+//
+// Each element is flushed to the client as soon as it's available. When the client disconnects, the request context
+// is cancelled and the iterator's "yield" returns false. Endpoints producing data through a channel should watch
+// the request context and stop producing.
+// Errors produced by iterators are sent as an event named "error" and the stream ends.
+func SSEResponseEncoder(opts ...StreamOptions) EncodeResponseFunc {
+	opt := StreamOption{
+		Heartbeat:   defaultSSEHeartbeat,
+		MarshalFunc: sseMarshalFunc,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return makeStreamEncodeResponseFunc(sseStreamWriter{opt: opt})
+}
+
+// NDJsonResponseEncoder encodes streamed response as newline delimited JSON (application/x-ndjson).
+// See SSEResponseEncoder for supported response types.
+// Errors produced by iterators are sent as a JSON line in the same format of JsonErrorEncoder and the stream ends.
+func NDJsonResponseEncoder(opts ...StreamOptions) EncodeResponseFunc {
+	opt := StreamOption{
+		MarshalFunc: json.Marshal,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return makeStreamEncodeResponseFunc(ndjsonStreamWriter{opt: opt})
+}
+
+/**********************************
+	Stream Writers
+***********************************/
+
+type streamWriter interface {
+	contentType() string
+	heartbeat() time.Duration
+	writeItem(w http.ResponseWriter, v interface{}) error
+	writeError(w http.ResponseWriter, err error) error
+	writeHeartbeat(w http.ResponseWriter) error
+}
+
+var sseFieldSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+type sseStreamWriter struct {
+	opt StreamOption
+}
+
+func (s sseStreamWriter) contentType() string {
+	return ContentTypeEventStream
+}
+
+func (s sseStreamWriter) heartbeat() time.Duration {
+	return s.opt.Heartbeat
+}
+
+func (s sseStreamWriter) writeItem(w http.ResponseWriter, v interface{}) error {
+	event, ok := v.(StreamEvent)
+	if !ok {
+		if ptr, isPtr := v.(*StreamEvent); isPtr && ptr != nil {
+			event = *ptr
+		} else {
+			event = StreamEvent{Data: v}
+		}
+	}
+	data, e := s.opt.MarshalFunc(event.Data)
+	if e != nil {
+		return e
+	}
+	return s.writeEvent(w, &event, data)
+}
+
+func (s sseStreamWriter) writeError(w http.ResponseWriter, err error) error {
+	return s.writeEvent(w, &StreamEvent{Event: "error"}, marshalStreamError(err))
+}
+
+func (s sseStreamWriter) writeHeartbeat(w http.ResponseWriter) error {
+	_, e := w.Write([]byte(":\n\n"))
+	return e
+}
+
+func (s sseStreamWriter) writeEvent(w http.ResponseWriter, event *StreamEvent, data []byte) error {
+	var buf bytes.Buffer
+	// line breaks in "id" and "event" would inject additional fields or events, so they are removed
+	if id := sseFieldSanitizer.Replace(event.ID); len(id) != 0 {
+		buf.WriteString("id: " + id + "\n")
+	}
+	if name := sseFieldSanitizer.Replace(event.Event); len(name) != 0 {
+		buf.WriteString("event: " + name + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	// multi-line data is sent as multiple "data" fields. CRLF, CR and LF are all line breaks in event stream
+	data = bytes.ReplaceAll(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), []byte("\r"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	_, e := w.Write(buf.Bytes())
+	return e
+}
+
+type ndjsonStreamWriter struct {
+	opt StreamOption
+}
+
+func (s ndjsonStreamWriter) contentType() string {
+	return ContentTypeNDJson
+}
+
+func (s ndjsonStreamWriter) heartbeat() time.Duration {
+	return s.opt.Heartbeat
+}
+
+func (s ndjsonStreamWriter) writeItem(w http.ResponseWriter, v interface{}) error {
+	switch event := v.(type) {
+	case StreamEvent:
+		v = event.Data
+	case *StreamEvent:
+		if event != nil {
+			v = event.Data
+		}
+	}
+	data, e := s.opt.MarshalFunc(v)
+	if e != nil {
+		return e
+	}
+	return s.writeLine(w, data)
+}
+
+func (s ndjsonStreamWriter) writeError(w http.ResponseWriter, err error) error {
+	return s.writeLine(w, marshalStreamError(err))
+}
+
+func (s ndjsonStreamWriter) writeHeartbeat(w http.ResponseWriter) error {
+	_, e := w.Write([]byte("\n"))
+	return e
+}
+
+func (s ndjsonStreamWriter) writeLine(w http.ResponseWriter, data []byte) error {
+	_, e := w.Write(append(bytes.TrimRight(data, "\r\n"), '\n'))
+	return e
+}
+
+/**********************************
+	Stream Encoding Helpers
+***********************************/
+
+type streamItem struct {
+	v   interface{}
+	err error
+}
+
+func makeStreamEncodeResponseFunc(sw streamWriter) EncodeResponseFunc {
+	return func(ctx context.Context, rw http.ResponseWriter, response interface{}) error {
+		body := response
+		if entity, ok := response.(BodyContainer); ok {
+			body = entity.Body()
+		}
+		// validate response before anything is written, so the error can be properly encoded
+		producer, e := newStreamProducer(body)
+		if e != nil {
+			return NewHttpError(http.StatusInternalServerError, e)
+		}
+
+		// headers and status code
+		if headerer, ok := response.(Headerer); ok {
+			overwriteHeaders(rw, headerer)
+		}
+		rw.Header().Set("Content-Type", sw.contentType())
+		rw.Header().Set("Cache-Control", "no-cache")
+		// disable response buffering of reverse proxies (e.g. nginx)
+		rw.Header().Set("X-Accel-Buffering", "no")
+		sc := http.StatusOK
+		if coder, ok := response.(StatusCoder); ok && coder.StatusCode() != 0 {
+			sc = coder.StatusCode()
+		}
+		rw.WriteHeader(sc)
+
+		// streams are long living, server's write timeout should not apply
+		rc := http.NewResponseController(rw)
+		if e := rc.SetWriteDeadline(time.Time{}); e != nil && !errors.Is(e, http.ErrNotSupported) {
+			logger.WithContext(ctx).Debugf("unable to reset write deadline of streaming response: %v", e)
+		}
+		if e := rc.Flush(); e != nil {
+			return recordStreamError(ctx, fmt.Errorf("streaming response is not supported: %w", e))
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		items := producer(ctx)
+
+		var heartbeat <-chan time.Time
+		if interval := sw.heartbeat(); interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+		for {
+			var e error
+			select {
+			case <-ctx.Done():
+				// client disconnected
+				return nil
+			case <-heartbeat:
+				e = sw.writeHeartbeat(rw)
+			case item, ok := <-items:
+				switch {
+				case !ok:
+					return nil
+				case item.err != nil:
+					_ = sw.writeError(rw, item.err)
+					_ = rc.Flush()
+					return recordStreamError(ctx, item.err)
+				default:
+					e = sw.writeItem(rw, item.v)
+				}
+			}
+			if e == nil {
+				e = rc.Flush()
+			}
+			if e != nil {
+				return recordStreamError(ctx, e)
+			}
+		}
+	}
+}
+
+// recordStreamError records the error in gin context for logging and tracing purpose.
+// Once the stream is started, status code and headers are already sent, so error encoders should not be involved
+func recordStreamError(ctx context.Context, err error) error {
+	if gc := GinContext(ctx); gc != nil {
+		_ = gc.Error(err)
+	}
+	return nil
+}
+
+func marshalStreamError(err error) []byte {
+	//nolint:errorlint
+	if _, ok := err.(json.Marshaler); !ok {
+		err = NewHttpError(0, err)
+	}
+	data, e := json.Marshal(err)
+	if e != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return data
+}
+
+func sseMarshalFunc(v interface{}) ([]byte, error) {
+	switch data := v.(type) {
+	case nil:
+		return []byte{}, nil
+	case string:
+		return []byte(data), nil
+	case []byte:
+		return data, nil
+	case encoding.TextMarshaler:
+		return data.MarshalText()
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// newStreamProducer returns a function that starts producing stream items asynchronously.
+// Supported types are receive-able channel and iterator functions compatible with iter.Seq and iter.Seq2.
+// The returned channel is closed when the source is exhausted or the context is cancelled.
+func newStreamProducer(v interface{}) (func(ctx context.Context) <-chan streamItem, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !IsStreamType(rv.Type()) || rv.IsNil() {
+		return nil, errInvalidStreamResponse
+	}
+	switch rv.Kind() {
+	case reflect.Chan:
+		return func(ctx context.Context) <-chan streamItem {
+			return produceFromChan(ctx, rv)
+		}, nil
+	default:
+		return func(ctx context.Context) <-chan streamItem {
+			return produceFromIterator(ctx, rv)
+		}, nil
+	}
+}
+
+func produceFromChan(ctx context.Context, ch reflect.Value) <-chan streamItem {
+	out := make(chan streamItem)
+	go func() {
+		defer close(out)
+		defer recoverStreamPanic(ctx, out)
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: ch},
+		}
+		for {
+			chosen, recv, ok := reflect.Select(cases)
+			if chosen == 0 || !ok {
+				return
+			}
+			select {
+			case out <- streamItem{v: recv.Interface()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func produceFromIterator(ctx context.Context, fn reflect.Value) <-chan streamItem {
+	out := make(chan streamItem)
+	yieldType := fn.Type().In(0)
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		item := streamItem{v: args[0].Interface()}
+		if len(args) > 1 {
+			item.err, _ = args[1].Interface().(error)
+		}
+		select {
+		case out <- item:
+			return []reflect.Value{reflect.ValueOf(item.err == nil && ctx.Err() == nil)}
+		case <-ctx.Done():
+			return []reflect.Value{reflect.ValueOf(false)}
+		}
+	})
+	go func() {
+		defer close(out)
+		defer recoverStreamPanic(ctx, out)
+		fn.Call([]reflect.Value{yield})
+	}()
+	return out
+}
+
+// recoverStreamPanic recovers from panic of the stream source and ends the stream with an error item,
+// because the producer goroutine is not covered by gin's recovery middleware
+func recoverStreamPanic(ctx context.Context, out chan<- streamItem) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	select {
+	case out <- streamItem{err: fmt.Errorf("stream source panicked: %v", r)}:
+	case <-ctx.Done():
+	}
+}
+
+// IsStreamType returns true if given type is supported by SSEResponseEncoder and NDJsonResponseEncoder, i.e.
+// receive-able channel, or iterator function compatible with iter.Seq or iter.Seq2 (with error as 2nd element)
+func IsStreamType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan:
+		return t.ChanDir()&reflect.RecvDir != 0
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			return false
+		}
+		yield := t.In(0)
+		if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+			return false
+		}
+		return yield.NumIn() == 1 || yield.NumIn() == 2 && yield.In(1) == typeError
+	default:
+		return false
+	}
+}
@@ -18,8 +18,11 @@ package web
 
 import (
 	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/certs"
 	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/utils"
 	"github.com/pkg/errors"
+	"time"
 )
 
 /***********************
@@ -31,9 +34,55 @@ const (
 )
 
 type ServerProperties struct {
-	Port        int               `json:"port"`
-	ContextPath string            `json:"context-path"`
-	Logging     LoggingProperties `json:"logging"`
+	Port              int                `json:"port"`
+	ContextPath       string             `json:"context-path"`
+	Logging           LoggingProperties  `json:"logging"`
+	TLS               TLSProperties      `json:"tls"`
+	HTTP2             HTTP2Properties    `json:"http2"`
+	ReadTimeout       utils.Duration     `json:"read-timeout"`
+	ReadHeaderTimeout utils.Duration     `json:"read-header-timeout"`
+	WriteTimeout      utils.Duration     `json:"write-timeout"`
+	IdleTimeout       utils.Duration     `json:"idle-timeout"`
+	MaxHeaderBytes    int                `json:"max-header-bytes"`
+	Shutdown          ShutdownProperties `json:"shutdown"`
+}
+
+// ShutdownProperties configures how the embedded web server is stopped when application shuts down.
+type ShutdownProperties struct {
+	// Graceful when enabled, the server stops accepting new connections and waits for in-flight requests to finish.
+	// Otherwise, all connections are closed immediately
+	Graceful bool `json:"graceful"`
+	// DrainTimeout max time to wait for in-flight requests during graceful shutdown.
+	// Remaining connections are forcibly closed after the timeout
+	DrainTimeout utils.Duration `json:"drain-timeout"`
+	// PreStopDelay time to wait after the application is marked as not ready and deregistered from service discovery,
+	// before the server stops accepting new connections. This gives load-balancers time to stop routing traffic
+	PreStopDelay utils.Duration `json:"pre-stop-delay"`
+}
+
+// TLSProperties configures TLS of the embedded web server.
+// Server certificate and the CAs used to verify client certificates are obtained from certs.Manager.
+type TLSProperties struct {
+	Enabled bool `json:"enabled"`
+	// Certs certificate source of the server's certificate. e.g. {"preset": "my-preset"} or {"type": "file", ...}
+	// The source's root CAs are used to verify client certificates when ClientAuth requires verification.
+	// Note: the root CAs are loaded once at startup, rotated CAs are not applied until the server is restarted
+	Certs certs.SourceProperties `json:"certs"`
+	// MinVersion minimum TLS version, supported values are "tls10", "tls11", "tls12" and "tls13"
+	MinVersion string `json:"min-version"`
+	// ClientAuth client certificate policy, supported values are
+	// "none", "request", "require", "verify-if-given" and "require-and-verify"
+	ClientAuth string `json:"client-auth"`
+}
+
+// HTTP2Properties configures HTTP/2 support of the embedded web server.
+type HTTP2Properties struct {
+	// Enabled enables HTTP/2 over TLS (h2). Only effective when TLS is enabled
+	Enabled bool `json:"enabled"`
+	// H2C enables HTTP/2 over cleartext (h2c). Only effective when TLS is disabled
+	H2C bool `json:"h2c"`
+	// MaxConcurrentStreams max number of concurrent streams per connection. 0 means default of the http2 package
+	MaxConcurrentStreams uint32 `json:"max-concurrent-streams"`
 }
 
 type LoggingProperties struct {
@@ -61,6 +110,20 @@ func NewServerProperties() *ServerProperties {
 			DefaultLevel: log.LevelDebug,
 			Levels:       map[string]LoggingLevelProperties{},
 		},
+		TLS: TLSProperties{
+			MinVersion: "tls12",
+			ClientAuth: ClientAuthNone,
+		},
+		HTTP2: HTTP2Properties{
+			Enabled: true,
+		},
+		ReadTimeout:    utils.Duration(60 * time.Second),
+		WriteTimeout:   utils.Duration(60 * time.Second),
+		MaxHeaderBytes: 1 << 20,
+		Shutdown: ShutdownProperties{
+			Graceful:     true,
+			DrainTimeout: utils.Duration(10 * time.Second),
+		},
 	}
 }
 
@@ -0,0 +1,82 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/matcher"
+	"sort"
+)
+
+// Customizer implements web.Customizer. It installs rate limit middlewares of routes configured via properties
+type Customizer struct {
+	properties RateLimitProperties
+	store      Store
+}
+
+func newCustomizer(properties RateLimitProperties, store Store) web.Customizer {
+	return &Customizer{
+		properties: properties,
+		store:      store,
+	}
+}
+
+func (c *Customizer) Customize(_ context.Context, r *web.Registrar) error {
+	if !c.properties.Enabled {
+		return nil
+	}
+
+	names := make([]string, 0, len(c.properties.Routes))
+	for name := range c.properties.Routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ipKeyFunc, e := ClientIPKeyFunc(c.properties.TrustedProxies...)
+	if e != nil {
+		return fmt.Errorf(`invalid rate limit properties: %v`, e)
+	}
+
+	for _, name := range names {
+		route := c.properties.Routes[name]
+		if len(route.Pattern) == 0 {
+			return fmt.Errorf(`invalid rate limit [%s]: pattern is required`, name)
+		}
+		key := route.Key
+		if len(key) == 0 {
+			key = KeyClientIP
+		}
+		if _, ok := KeyFuncs[key]; !ok {
+			return fmt.Errorf(`invalid rate limit [%s]: unsupported key "%s"`, name, key)
+		}
+		limit := route.ToLimit()
+		if e := limit.Validate(); e != nil {
+			return fmt.Errorf(`invalid rate limit [%s]: %v`, name, e)
+		}
+		opts := []MiddlewareOptions{WithName(name), WithStore(c.store), WithKey(key), WithLimit(limit)}
+		if key == KeyClientIP {
+			opts = append(opts, WithKeyFunc(ipKeyFunc))
+		}
+		mw := NewMiddlewareMapping(matcher.RouteWithPattern(route.Pattern, route.Methods()...), opts...)
+		if e := r.Register(mw); e != nil {
+			return e
+		}
+	}
+	return nil
+}
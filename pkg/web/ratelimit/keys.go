@@ -0,0 +1,135 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/oauth2"
+	netutil "github.com/cisco-open/go-lanai/pkg/utils/net"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Supported key names, used in properties and WithKey
+const (
+	KeyClientIP = "ip"
+	KeyClientID = "client"
+	KeyUsername = "username"
+	KeyTenant   = "tenant"
+)
+
+const headerForwardedFor = "X-Forwarded-For"
+
+// KeyFunc extracts the rate limit key from the request. Returning empty string means the request is not rate limited.
+type KeyFunc func(ctx context.Context, r *http.Request) string
+
+// KeyFuncs maps supported key names to KeyFunc
+var KeyFuncs = map[string]KeyFunc{
+	KeyClientIP: KeyByClientIP,
+	KeyClientID: KeyByClientID,
+	KeyUsername: KeyByUsername,
+	KeyTenant:   KeyByTenant,
+}
+
+// KeyByClientIP returns IP address of the immediate peer of the request, i.e. http.Request.RemoteAddr.
+// Headers such as "X-Forwarded-For" are ignored, because any client can set them.
+// For services behind reverse proxies, use ClientIPKeyFunc with the proxies' addresses.
+func KeyByClientIP(_ context.Context, r *http.Request) string {
+	return netutil.GetRemoteIP(r)
+}
+
+// ClientIPKeyFunc returns a KeyFunc that honors "X-Forwarded-For" of requests sent by trusted proxies.
+// trustedProxies are IP addresses or CIDRs, e.g. "10.0.0.0/8".
+// When the immediate peer is a trusted proxy, the key is the right-most address in "X-Forwarded-For" that is not a trusted proxy.
+// Otherwise, the key is the immediate peer's IP, same as KeyByClientIP.
+func ClientIPKeyFunc(trustedProxies ...string) (KeyFunc, error) {
+	proxies, e := netutil.ParseTrustedProxies(trustedProxies...)
+	if e != nil {
+		return nil, e
+	}
+	return func(_ context.Context, r *http.Request) string {
+		ip := netutil.GetRemoteIP(r)
+		if !proxies.Contains(ip) {
+			return ip
+		}
+		forwarded := strings.Split(strings.Join(r.Header.Values(headerForwardedFor), ","), ",")
+		for i := len(forwarded) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(forwarded[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if ip = hop; !proxies.Contains(hop) {
+				break
+			}
+		}
+		return ip
+	}, nil
+}
+
+func KeyByClientID(ctx context.Context, _ *http.Request) string {
+	auth := security.Get(ctx)
+	switch v := auth.(type) {
+	case oauth2.Authentication:
+		if v.OAuth2Request() != nil {
+			return v.OAuth2Request().ClientId()
+		}
+	}
+	if auth.State() < security.StatePrincipalKnown {
+		return ""
+	}
+	if client, ok := auth.Principal().(oauth2.OAuth2Client); ok {
+		return client.ClientId()
+	}
+	return ""
+}
+
+// KeyByUsername uses username of current security.Authentication
+func KeyByUsername(ctx context.Context, _ *http.Request) string {
+	auth := security.Get(ctx)
+	if auth.State() < security.StatePrincipalKnown {
+		return ""
+	}
+	switch v := auth.(type) {
+	case oauth2.Authentication:
+		// client only token doesn't have username
+		if v.UserAuthentication() == nil {
+			return ""
+		}
+	}
+	if _, ok := auth.Principal().(oauth2.OAuth2Client); ok {
+		return ""
+	}
+	username, e := security.GetUsername(auth)
+	if e != nil {
+		return ""
+	}
+	return username
+}
+
+// KeyByTenant uses tenant ID of current security.Authentication
+func KeyByTenant(ctx context.Context, _ *http.Request) string {
+	auth := security.Get(ctx)
+	if auth.State() < security.StatePrincipalKnown {
+		return ""
+	}
+	if details, ok := auth.Details().(security.TenantDetails); ok {
+		return details.TenantId()
+	}
+	return ""
+}
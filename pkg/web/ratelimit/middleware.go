@@ -0,0 +1,212 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/matcher"
+	"github.com/cisco-open/go-lanai/pkg/web/middleware"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	pathutils "path"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// Middleware Orders
+const (
+	// MWOrderRateLimitPreAuth is used by rate limits that don't require authentication, e.g. KeyClientIP.
+	// It's applied before any security middleware, so login attempts are also limited.
+	MWOrderRateLimitPreAuth = security.HighestMiddlewareOrder - 100
+	// MWOrderRateLimit is used by rate limits that require authentication, e.g. KeyClientID, KeyUsername and KeyTenant.
+	// It's applied after authentication and before access control.
+	MWOrderRateLimit = security.MWOrderAccessControl - 50
+)
+
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+type MiddlewareOptions func(opt *MiddlewareOption)
+type MiddlewareOption struct {
+	// Name of the rate limit rule. Requests limited by different rules are tracked separately
+	Name string
+	// Store where rate limit state is kept. Default to a new InMemoryStore
+	Store Store
+	// Limit is required
+	Limit Limit
+	// KeyFunc extracts rate limit key from request. Default to KeyByClientIP
+	KeyFunc KeyFunc
+	// Order of web.MiddlewareMapping. Only used by NewMiddlewareMapping and ForMapping
+	Order int
+}
+
+// WithName set name of the rule
+func WithName(name string) MiddlewareOptions {
+	return func(opt *MiddlewareOption) {
+		opt.Name = name
+	}
+}
+
+// WithStore set Store
+func WithStore(store Store) MiddlewareOptions {
+	return func(opt *MiddlewareOption) {
+		opt.Store = store
+	}
+}
+
+// WithLimit set Limit
+func WithLimit(limit Limit) MiddlewareOptions {
+	return func(opt *MiddlewareOption) {
+		opt.Limit = limit
+	}
+}
+
+// WithKey set KeyFunc by one of supported key names (KeyClientIP, KeyClientID, KeyUsername, KeyTenant),
+// and choose middleware order accordingly. Unknown key names cause panic when building the middleware
+func WithKey(key string) MiddlewareOptions {
+	return func(opt *MiddlewareOption) {
+		opt.KeyFunc = KeyFuncs[key]
+		opt.Order = orderOfKey(key)
+	}
+}
+
+// WithKeyFunc set custom KeyFunc. Use WithOrder to choose when the middleware is executed
+func WithKeyFunc(fn KeyFunc) MiddlewareOptions {
+	return func(opt *MiddlewareOption) {
+		opt.KeyFunc = fn
+	}
+}
+
+// WithOrder set order of web.MiddlewareMapping
+func WithOrder(order int) MiddlewareOptions {
+	return func(opt *MiddlewareOption) {
+		opt.Order = order
+	}
+}
+
+// NewMiddleware returns a gin.HandlerFunc that performs rate limiting.
+// Each allowed request gets "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset" and "RateLimit-Policy" headers.
+// Requests exceeding the limit are rejected with 429 Too Many Requests and "Retry-After" header.
+// When the Store is not available, requests are allowed.
+func NewMiddleware(opts ...MiddlewareOptions) gin.HandlerFunc {
+	opt := newMiddlewareOption(opts)
+	if opt.KeyFunc == nil {
+		panic(fmt.Errorf("unable to build rate limit middleware [%s]: unknown key", opt.Name))
+	}
+	if e := opt.Limit.Validate(); e != nil {
+		panic(fmt.Errorf("unable to build rate limit middleware [%s]: %v", opt.Name, e))
+	}
+	return func(gc *gin.Context) {
+		key := opt.KeyFunc(gc, gc.Request)
+		if len(key) == 0 {
+			return
+		}
+		result, e := opt.Store.Allow(gc, opt.Name+":"+key, opt.Limit)
+		if e != nil {
+			logger.WithContext(gc).Warnf("rate limit [%s] is not applied: %v", opt.Name, e)
+			return
+		}
+		header := gc.Writer.Header()
+		header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+		header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+		header.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		header.Set(HeaderRateLimitPolicy, opt.Limit.String())
+		if result.Allowed {
+			return
+		}
+		retryAfter := http.Header{}
+		retryAfter.Set(HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		_ = gc.Error(web.NewHttpError(http.StatusTooManyRequests, ErrRateLimitExceeded, retryAfter))
+		gc.Abort()
+	}
+}
+
+// NewMiddlewareMapping returns a web.MiddlewareMapping that performs rate limiting on routes matching given matcher.
+// See NewMiddleware
+func NewMiddlewareMapping(routeMatcher web.RouteMatcher, opts ...MiddlewareOptions) web.MiddlewareMapping {
+	opt := newMiddlewareOption(opts)
+	return middleware.NewBuilder("rate-limit " + opt.Name).
+		Order(opt.Order).
+		ApplyTo(routeMatcher).
+		Use(NewMiddleware(opts...)).
+		Build()
+}
+
+// ForMapping returns a web.MiddlewareMapping that performs rate limiting on the route of given mapping,
+// e.g. a web.EndpointMapping built by rest.MappingBuilder:
+//
+//	m := rest.Post("/api/v1/orders").EndpointFunc(c.CreateOrder).Build()
+//	return []web.Mapping{m, ratelimit.ForMapping(m, ratelimit.WithLimit(ratelimit.PerMinute(10)), ratelimit.WithKey(ratelimit.KeyUsername))}
+//
+// The rule name defaults to the mapping's name. See NewMiddleware
+func ForMapping(m web.RoutedMapping, opts ...MiddlewareOptions) web.MiddlewareMapping {
+	var methods []string
+	if m.Method() != "" && m.Method() != web.MethodAny {
+		methods = []string{m.Method()}
+	}
+	opts = append([]MiddlewareOptions{WithName(m.Name())}, opts...)
+	opt := newMiddlewareOption(opts)
+	builder := middleware.NewBuilder("rate-limit " + opt.Name).
+		Order(opt.Order).
+		ApplyTo(matcher.RouteWithPattern(pathutils.Join(m.Group(), m.Path()), methods...)).
+		Use(NewMiddleware(opts...))
+	if m.Condition() != nil {
+		builder = builder.WithCondition(m.Condition())
+	}
+	return builder.Build()
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func newMiddlewareOption(opts []MiddlewareOptions) *MiddlewareOption {
+	opt := MiddlewareOption{
+		Name:    "default",
+		KeyFunc: KeyByClientIP,
+		Order:   MWOrderRateLimitPreAuth,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	if opt.Store == nil {
+		opt.Store = NewInMemoryStore()
+	}
+	return &opt
+}
+
+func orderOfKey(key string) int {
+	if key == KeyClientIP {
+		return MWOrderRateLimitPreAuth
+	}
+	return MWOrderRateLimit
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
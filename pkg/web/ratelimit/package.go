@@ -0,0 +1,76 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/redis"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"go.uber.org/fx"
+)
+
+var logger = log.New("Web.RateLimit")
+
+var Module = &bootstrap.Module{
+	Name:       "rate-limit",
+	Precedence: web.MinWebPrecedence + 1,
+	PriorityOptions: []fx.Option{
+		fx.Provide(BindRateLimitProperties, provideStore),
+		web.FxCustomizerProviders(newCustomizer),
+	},
+}
+
+func Use() {
+	bootstrap.Register(Module)
+}
+
+/**************************
+	Provider
+***************************/
+
+type storeDI struct {
+	fx.In
+	AppCtx       *bootstrap.ApplicationContext
+	Properties   RateLimitProperties
+	RedisFactory redis.ClientFactory `optional:"true"`
+}
+
+func provideStore(di storeDI) (Store, error) {
+	switch di.Properties.Store {
+	case StoreTypeMemory, "":
+		return NewInMemoryStore(), nil
+	case StoreTypeRedis:
+		if di.RedisFactory == nil {
+			return nil, fmt.Errorf(`redis.ClientFactory is required for rate limit store "%s"`, StoreTypeRedis)
+		}
+		client, e := di.RedisFactory.New(di.AppCtx, func(opt *redis.ClientOption) {
+			opt.DbIndex = di.Properties.Redis.DbIndex
+		})
+		if e != nil {
+			return nil, fmt.Errorf(`unable to initialize rate limit Redis store: %v`, e)
+		}
+		return NewRedisStore(client, func(opt *RedisStoreOption) {
+			if len(di.Properties.Redis.KeyPrefix) != 0 {
+				opt.KeyPrefix = di.Properties.Redis.KeyPrefix
+			}
+		}), nil
+	default:
+		return nil, fmt.Errorf(`unsupported rate limit store "%s"`, di.Properties.Store)
+	}
+}
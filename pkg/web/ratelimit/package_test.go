@@ -0,0 +1,205 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit_test
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/ratelimit"
+	"github.com/cisco-open/go-lanai/pkg/web/rest"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/apptest"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	"github.com/cisco-open/go-lanai/test/webtest"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+/*************************
+	Setup Test
+ *************************/
+
+func RegisterTestController(reg *web.Registrar) error {
+	return reg.Register(TestController{})
+}
+
+/*************************
+	Tests
+ *************************/
+
+func TestRateLimitMiddleware(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		webtest.WithMockedServer(),
+		apptest.WithModules(ratelimit.Module),
+		apptest.WithFxOptions(
+			fx.Invoke(RegisterTestController),
+		),
+		test.GomegaSubTest(SubTestRouteFromProperties(), "TestRouteFromProperties"),
+		test.GomegaSubTest(SubTestMethodNotLimited(), "TestMethodNotLimited"),
+		test.GomegaSubTest(SubTestForMapping(), "TestForMapping"),
+	)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestKeysWithUser(), "TestKeysWithUser"),
+		test.GomegaSubTest(SubTestKeysWithoutAuth(), "TestKeysWithoutAuth"),
+		test.GomegaSubTest(SubTestClientIPWithSpoofedHeader(), "TestClientIPWithSpoofedHeader"),
+		test.GomegaSubTest(SubTestClientIPWithTrustedProxies(), "TestClientIPWithTrustedProxies"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestRouteFromProperties() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		for i := 0; i < 2; i++ {
+			resp := webtest.MustExec(ctx, webtest.NewRequest(ctx, http.MethodGet, "/hello/limited", nil)).Response
+			g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "request within limit should succeed")
+			AssertRateLimitHeaders(g, resp, "2", 1-i, "2;w=60")
+			g.Expect(resp.Header.Get(ratelimit.HeaderRetryAfter)).To(BeEmpty(), "response should not have Retry-After")
+		}
+		resp := webtest.MustExec(ctx, webtest.NewRequest(ctx, http.MethodGet, "/hello/limited", nil)).Response
+		g.Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests), "request exceeding limit should be rejected")
+		AssertRateLimitHeaders(g, resp, "2", 0, "2;w=60")
+		g.Expect(resp.Header.Get(ratelimit.HeaderRetryAfter)).To(Equal("30"), "response should have correct Retry-After")
+	}
+}
+
+func SubTestMethodNotLimited() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		for i := 0; i < 5; i++ {
+			resp := webtest.MustExec(ctx, webtest.NewRequest(ctx, http.MethodPost, "/hello/limited", nil)).Response
+			g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "request with other methods should not be limited")
+			g.Expect(resp.Header.Get(ratelimit.HeaderRateLimitLimit)).To(BeEmpty(), "response should not have rate limit headers")
+		}
+	}
+}
+
+func SubTestForMapping() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		resp := webtest.MustExec(ctx, webtest.NewRequest(ctx, http.MethodPost, "/mapped", nil)).Response
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "request within limit should succeed")
+		AssertRateLimitHeaders(g, resp, "1", 0, "1;w=3600")
+
+		resp = webtest.MustExec(ctx, webtest.NewRequest(ctx, http.MethodPost, "/mapped", nil)).Response
+		g.Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests), "request exceeding limit should be rejected")
+		g.Expect(resp.Header.Get(ratelimit.HeaderRetryAfter)).To(Equal("3600"), "response should have correct Retry-After")
+
+		resp = webtest.MustExec(ctx, webtest.NewRequest(ctx, http.MethodGet, "/mapped", nil)).Response
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "request of unmapped method should not be limited")
+	}
+}
+
+func SubTestKeysWithUser() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		ctx = sectest.ContextWithSecurity(ctx, sectest.MockedAuthentication(func(d *sectest.SecurityDetailsMock) {
+			d.Username = "test-user"
+			d.TenantId = "test-tenant"
+			d.ClientID = "test-client"
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		g.Expect(ratelimit.KeyByClientID(ctx, req)).To(Equal("test-client"), "client key should be correct")
+		g.Expect(ratelimit.KeyByUsername(ctx, req)).To(Equal("test-user"), "username key should be correct")
+		g.Expect(ratelimit.KeyByTenant(ctx, req)).To(Equal("test-tenant"), "tenant key should be correct")
+		g.Expect(ratelimit.KeyByClientIP(ctx, req)).ToNot(BeEmpty(), "ip key should not be empty")
+	}
+}
+
+func SubTestKeysWithoutAuth() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		g.Expect(ratelimit.KeyByClientID(ctx, req)).To(BeEmpty(), "client key should be empty")
+		g.Expect(ratelimit.KeyByUsername(ctx, req)).To(BeEmpty(), "username key should be empty")
+		g.Expect(ratelimit.KeyByTenant(ctx, req)).To(BeEmpty(), "tenant key should be empty")
+	}
+}
+
+func SubTestClientIPWithSpoofedHeader() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		req.RemoteAddr = "203.0.113.10:5678"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		g.Expect(ratelimit.KeyByClientIP(ctx, req)).To(Equal("203.0.113.10"), "ip key should ignore X-Forwarded-For")
+
+		// header from untrusted peer is ignored
+		fn, e := ratelimit.ClientIPKeyFunc("10.0.0.0/8")
+		g.Expect(e).To(Succeed(), "creating key func should not fail")
+		g.Expect(fn(ctx, req)).To(Equal("203.0.113.10"), "ip key should ignore X-Forwarded-For from untrusted peer")
+	}
+}
+
+func SubTestClientIPWithTrustedProxies() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		fn, e := ratelimit.ClientIPKeyFunc("10.0.0.0/8", "192.168.1.1")
+		g.Expect(e).To(Succeed(), "creating key func should not fail")
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		req.RemoteAddr = "10.0.0.1:5678"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.10, 192.168.1.1")
+		g.Expect(fn(ctx, req)).To(Equal("203.0.113.10"), "ip key should be the right-most untrusted address")
+
+		req.Header.Set("X-Forwarded-For", "10.0.0.2")
+		g.Expect(fn(ctx, req)).To(Equal("10.0.0.2"), "ip key should be the left-most address when all are trusted")
+
+		req.Header.Del("X-Forwarded-For")
+		g.Expect(fn(ctx, req)).To(Equal("10.0.0.1"), "ip key should be the peer without X-Forwarded-For")
+
+		_, e = ratelimit.ClientIPKeyFunc("not-an-ip")
+		g.Expect(e).To(HaveOccurred(), "invalid trusted proxy should fail")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func AssertRateLimitHeaders(g *gomega.WithT, resp *http.Response, limit string, remaining int, policy string) {
+	g.Expect(resp.Header.Get(ratelimit.HeaderRateLimitLimit)).To(Equal(limit), "response should have correct RateLimit-Limit")
+	g.Expect(resp.Header.Get(ratelimit.HeaderRateLimitRemaining)).To(Equal(strconv.Itoa(remaining)), "response should have correct RateLimit-Remaining")
+	g.Expect(resp.Header.Get(ratelimit.HeaderRateLimitReset)).ToNot(BeEmpty(), "response should have RateLimit-Reset")
+	g.Expect(resp.Header.Get(ratelimit.HeaderRateLimitPolicy)).To(Equal(policy), "response should have correct RateLimit-Policy")
+}
+
+/*************************
+	Dummy Controller
+ *************************/
+
+type TestController struct{}
+
+func (c TestController) Mappings() []web.Mapping {
+	mapped := rest.Post("/mapped").EndpointFunc(c.Hello).Build()
+	return []web.Mapping{
+		rest.Get("/hello/limited").EndpointFunc(c.Hello).Build(),
+		rest.Post("/hello/limited").EndpointFunc(c.Hello).Build(),
+		rest.Get("/mapped").EndpointFunc(c.Hello).Build(),
+		mapped,
+		ratelimit.ForMapping(mapped, ratelimit.WithLimit(ratelimit.PerHour(1))),
+	}
+}
+
+func (TestController) Hello(_ context.Context, _ *http.Request) (interface{}, error) {
+	return map[string]string{"message": "hello"}, nil
+}
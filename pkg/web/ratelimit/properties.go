@@ -0,0 +1,109 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const (
+	RateLimitPropertiesPrefix = "security.rate-limit"
+	StoreTypeMemory           = "memory"
+	StoreTypeRedis            = "redis"
+)
+
+type RateLimitProperties struct {
+	Enabled bool `json:"enabled"`
+	// Store type, "memory" or "redis". Default to "memory"
+	Store string `json:"store"`
+	// Redis store settings, only used when Store is "redis"
+	Redis RedisStoreProperties `json:"redis"`
+	// Routes per-route rate limits, keyed by rule name
+	Routes map[string]RouteProperties `json:"routes"`
+	// TrustedProxies IPs or CIDRs of reverse proxies. "X-Forwarded-For" is only used for "ip" key when the request is
+	// sent by one of them. Default to none, i.e. "X-Forwarded-For" is ignored
+	TrustedProxies []string `json:"trusted-proxies"`
+}
+
+type RedisStoreProperties struct {
+	DbIndex   int    `json:"db-index"`
+	KeyPrefix string `json:"key-prefix"`
+}
+
+type RouteProperties struct {
+	// Pattern of route path, e.g. "/api/v1/orders/**". Context path should not be included
+	Pattern string `json:"pattern"`
+	// Space-separated HTTP methods. Empty or "*" matches all methods
+	Method string `json:"method"`
+	// Key to limit by, one of "ip", "client", "username" or "tenant". Default to "ip"
+	Key string `json:"key"`
+	// Limit max number of requests per Period
+	Limit int `json:"limit"`
+	// Period of the limit. If a duration suffix is not specified, seconds will be used. Default to 1s
+	Period utils.Duration `json:"period"`
+	// Burst max number of requests allowed at once. Default to Limit
+	Burst int `json:"burst"`
+}
+
+func (p RouteProperties) Methods() []string {
+	methods := strings.Fields(p.Method)
+	for i, m := range methods {
+		if m == "*" {
+			return nil
+		}
+		methods[i] = strings.ToUpper(m)
+	}
+	return methods
+}
+
+func (p RouteProperties) ToLimit() Limit {
+	period := time.Duration(p.Period)
+	if period == 0 {
+		period = time.Second
+	}
+	return Limit{
+		Rate:   p.Limit,
+		Period: period,
+		Burst:  p.Burst,
+	}
+}
+
+// NewRateLimitProperties create a RateLimitProperties with default values
+func NewRateLimitProperties() *RateLimitProperties {
+	return &RateLimitProperties{
+		Enabled: true,
+		Store:   StoreTypeMemory,
+		Redis: RedisStoreProperties{
+			DbIndex:   0,
+			KeyPrefix: defaultRedisKeyPrefix,
+		},
+		Routes: map[string]RouteProperties{},
+	}
+}
+
+// BindRateLimitProperties create and bind a RateLimitProperties using default prefix
+func BindRateLimitProperties(ctx *bootstrap.ApplicationContext) RateLimitProperties {
+	props := NewRateLimitProperties()
+	if err := ctx.Config().Bind(props, RateLimitPropertiesPrefix); err != nil {
+		panic(errors.Wrap(err, "failed to bind RateLimitProperties"))
+	}
+	return *props
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limit defines a token bucket: Burst requests are allowed at once, and the bucket is refilled at Rate per Period.
+type Limit struct {
+	// Rate number of requests allowed per Period
+	Rate int
+	// Period the time window of Rate
+	Period time.Duration
+	// Burst max number of requests allowed at once. Default to Rate when not positive
+	Burst int
+}
+
+// PerSecond returns a Limit that allows "rate" requests per second
+func PerSecond(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute returns a Limit that allows "rate" requests per minute
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// PerHour returns a Limit that allows "rate" requests per hour
+func PerHour(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Hour}
+}
+
+func (l Limit) Validate() error {
+	if l.Rate <= 0 || l.Period <= 0 {
+		return fmt.Errorf("invalid rate limit: rate [%d] and period [%v] should be positive", l.Rate, l.Period)
+	}
+	return nil
+}
+
+// String returns limit policy in the format of "RateLimit-Policy" header, e.g. "100;w=60"
+func (l Limit) String() string {
+	return fmt.Sprintf("%d;w=%d", l.Rate, int64(l.Period.Seconds()))
+}
+
+// interval returns emission interval, i.e. the time to refill one token
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+func (l Limit) burst() int {
+	if l.Burst <= 0 {
+		return l.Rate
+	}
+	return l.Burst
+}
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	// Allowed whether the request is allowed
+	Allowed bool
+	// Limit the max number of requests allowed at once (bucket capacity)
+	Limit int
+	// Remaining number of requests allowed before the limit is reached
+	Remaining int
+	// RetryAfter time to wait before the next request would be allowed. Only set when not Allowed
+	RetryAfter time.Duration
+	// ResetAfter time until the limit is fully reset (bucket is full)
+	ResetAfter time.Duration
+}
+
+// Store keeps track of rate limit state of each key
+type Store interface {
+	// Allow consumes one token of the given key and reports whether the request is allowed
+	Allow(ctx context.Context, key string, limit Limit) (*Result, error)
+}
+
+// gcra implements Generic Cell Rate Algorithm, which is equivalent to a token bucket but only requires to keep
+// one timestamp per key, i.e. theoretical arrival time (TAT).
+// Given current time and previous TAT, it returns the new TAT and the result.
+// When the request is not allowed, TAT is unchanged.
+func gcra(now, tat time.Time, limit Limit) (time.Time, *Result) {
+	interval := limit.interval()
+	burst := limit.burst()
+	tolerance := interval * time.Duration(burst)
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-tolerance)
+	if now.Before(allowAt) {
+		return tat, &Result{
+			Allowed:    false,
+			Limit:      burst,
+			Remaining:  0,
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}
+	}
+	return newTat, &Result{
+		Allowed:    true,
+		Limit:      burst,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTat.Sub(now),
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultSweepInterval = time.Minute
+
+type InMemoryStoreOptions func(opt *InMemoryStoreOption)
+type InMemoryStoreOption struct {
+	// SweepInterval how often expired keys are removed
+	SweepInterval time.Duration
+	// Clock returns current time. Default to time.Now
+	Clock func() time.Time
+}
+
+// InMemoryStore implements Store. The state is local to the application instance.
+// Expired keys are removed periodically during Allow.
+type InMemoryStore struct {
+	mtx           sync.Mutex
+	tats          map[string]time.Time
+	sweepInterval time.Duration
+	lastSweep     time.Time
+	clock         func() time.Time
+}
+
+func NewInMemoryStore(opts ...InMemoryStoreOptions) *InMemoryStore {
+	opt := InMemoryStoreOption{
+		SweepInterval: defaultSweepInterval,
+		Clock:         time.Now,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &InMemoryStore{
+		tats:          map[string]time.Time{},
+		sweepInterval: opt.SweepInterval,
+		lastSweep:     opt.Clock(),
+		clock:         opt.Clock,
+	}
+}
+
+func (s *InMemoryStore) Allow(_ context.Context, key string, limit Limit) (*Result, error) {
+	if e := limit.Validate(); e != nil {
+		return nil, e
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.clock()
+	s.trySweep(now)
+	tat, result := gcra(now, s.tats[key], limit)
+	s.tats[key] = tat
+	return result, nil
+}
+
+// trySweep removes keys whose buckets are full, i.e. TAT is in the past
+func (s *InMemoryStore) trySweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.sweepInterval {
+		return
+	}
+	for k, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, k)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	redislib "github.com/go-redis/redis/v8"
+	"time"
+)
+
+const defaultRedisKeyPrefix = "LANAI:RATELIMIT:"
+
+// gcraScript performs GCRA atomically. All time values are in microseconds.
+// KEYS[1]: key of the TAT
+// ARGV[1]: emission interval, ARGV[2]: burst tolerance, ARGV[3]: current time
+// Returns {allowed, remaining, retry_after, reset_after}
+var gcraScript = redislib.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+type RedisStoreOptions func(opt *RedisStoreOption)
+type RedisStoreOption struct {
+	// KeyPrefix prefix of all keys stored in Redis
+	KeyPrefix string
+	// Clock returns current time. Default to time.Now
+	Clock func() time.Time
+}
+
+// RedisStore implements Store. The state is shared among all application instances using the same Redis.
+// Rate limit is calculated using GCRA via Lua script. Note that current time is provided by the application,
+// so the clocks of application instances should be synchronized.
+type RedisStore struct {
+	client redislib.UniversalClient
+	prefix string
+	clock  func() time.Time
+}
+
+func NewRedisStore(client redislib.UniversalClient, opts ...RedisStoreOptions) *RedisStore {
+	opt := RedisStoreOption{
+		KeyPrefix: defaultRedisKeyPrefix,
+		Clock:     time.Now,
+	}
+	for _, fn := range opts {
+		fn(&opt)
+	}
+	return &RedisStore{
+		client: client,
+		prefix: opt.KeyPrefix,
+		clock:  opt.Clock,
+	}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	if e := limit.Validate(); e != nil {
+		return nil, e
+	}
+	burst := limit.burst()
+	interval := limit.interval().Microseconds()
+	tolerance := interval * int64(burst)
+	now := s.clock().UnixMicro()
+	values, e := gcraScript.Run(ctx, s.client, []string{s.prefix + key}, interval, tolerance, now).Int64Slice()
+	if e != nil {
+		return nil, fmt.Errorf("unable to check rate limit: %v", e)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("unable to check rate limit: unexpected script result %v", values)
+	}
+	return &Result{
+		Allowed:    values[0] == 1,
+		Limit:      burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
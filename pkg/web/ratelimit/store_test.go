@@ -0,0 +1,167 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit_test
+
+import (
+	"context"
+	"github.com/cisco-open/go-lanai/pkg/bootstrap"
+	"github.com/cisco-open/go-lanai/pkg/redis"
+	"github.com/cisco-open/go-lanai/pkg/web/ratelimit"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/apptest"
+	"github.com/cisco-open/go-lanai/test/embedded"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"testing"
+	"time"
+)
+
+/*************************
+	Tests
+ *************************/
+
+type TestRedisStoreDI struct {
+	fx.In
+	AppCtx *bootstrap.ApplicationContext
+	Redis  redis.ClientFactory
+}
+
+func TestInMemoryStore(t *testing.T) {
+	clock := NewMockedClock()
+	var store ratelimit.Store = ratelimit.NewInMemoryStore(func(opt *ratelimit.InMemoryStoreOption) {
+		opt.Clock = clock.Now
+	})
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestBurstAndRefill(&store, clock), "TestBurstAndRefill"),
+		test.GomegaSubTest(SubTestIndependentKeys(&store), "TestIndependentKeys"),
+		test.GomegaSubTest(SubTestInvalidLimit(&store), "TestInvalidLimit"),
+	)
+}
+
+func TestRedisStore(t *testing.T) {
+	di := TestRedisStoreDI{}
+	clock := NewMockedClock()
+	var store ratelimit.Store
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		embedded.WithRedis(),
+		apptest.WithModules(redis.Module),
+		apptest.WithDI(&di),
+		test.SubTestSetup(func(ctx context.Context, t *testing.T) (context.Context, error) {
+			client, e := di.Redis.New(ctx)
+			if e != nil {
+				return ctx, e
+			}
+			store = ratelimit.NewRedisStore(client, func(opt *ratelimit.RedisStoreOption) {
+				opt.Clock = clock.Now
+			})
+			return ctx, nil
+		}),
+		test.GomegaSubTest(SubTestBurstAndRefill(&store, clock), "TestBurstAndRefill"),
+		test.GomegaSubTest(SubTestIndependentKeys(&store), "TestIndependentKeys"),
+		test.GomegaSubTest(SubTestInvalidLimit(&store), "TestInvalidLimit"),
+	)
+}
+
+/*************************
+	Sub-Test Cases
+ *************************/
+
+func SubTestBurstAndRefill(store *ratelimit.Store, clock *MockedClock) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		const key = "burst-test"
+		limit := ratelimit.Limit{Rate: 2, Period: time.Second, Burst: 3}
+		s := *store
+
+		// burst
+		for i := 0; i < 3; i++ {
+			rs, e := s.Allow(ctx, key, limit)
+			g.Expect(e).To(Succeed(), "Allow should not fail")
+			g.Expect(rs.Allowed).To(BeTrue(), "request %d within burst should be allowed", i+1)
+			g.Expect(rs.Limit).To(Equal(3), "limit should be burst size")
+			g.Expect(rs.Remaining).To(Equal(2-i), "remaining should be correct")
+		}
+		rs, e := s.Allow(ctx, key, limit)
+		g.Expect(e).To(Succeed(), "Allow should not fail")
+		g.Expect(rs.Allowed).To(BeFalse(), "request exceeding burst should not be allowed")
+		g.Expect(rs.Remaining).To(Equal(0), "remaining should be correct")
+		g.Expect(rs.RetryAfter).To(Equal(500*time.Millisecond), "retry-after should be correct")
+		g.Expect(rs.ResetAfter).To(Equal(1500*time.Millisecond), "reset-after should be correct")
+
+		// refill one token
+		clock.Advance(500 * time.Millisecond)
+		rs, e = s.Allow(ctx, key, limit)
+		g.Expect(e).To(Succeed(), "Allow should not fail")
+		g.Expect(rs.Allowed).To(BeTrue(), "request should be allowed after refill")
+		g.Expect(rs.Remaining).To(Equal(0), "remaining should be correct")
+		rs, e = s.Allow(ctx, key, limit)
+		g.Expect(e).To(Succeed(), "Allow should not fail")
+		g.Expect(rs.Allowed).To(BeFalse(), "request should not be allowed before next refill")
+
+		// fully reset
+		clock.Advance(rs.ResetAfter)
+		rs, e = s.Allow(ctx, key, limit)
+		g.Expect(e).To(Succeed(), "Allow should not fail")
+		g.Expect(rs.Allowed).To(BeTrue(), "request should be allowed after reset")
+		g.Expect(rs.Remaining).To(Equal(2), "remaining should be correct after reset")
+	}
+}
+
+func SubTestIndependentKeys(store *ratelimit.Store) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		limit := ratelimit.PerMinute(1)
+		s := *store
+		rs, e := s.Allow(ctx, "key-1", limit)
+		g.Expect(e).To(Succeed(), "Allow should not fail")
+		g.Expect(rs.Allowed).To(BeTrue(), "first request of key-1 should be allowed")
+		rs, e = s.Allow(ctx, "key-1", limit)
+		g.Expect(e).To(Succeed(), "Allow should not fail")
+		g.Expect(rs.Allowed).To(BeFalse(), "second request of key-1 should not be allowed")
+		rs, e = s.Allow(ctx, "key-2", limit)
+		g.Expect(e).To(Succeed(), "Allow should not fail")
+		g.Expect(rs.Allowed).To(BeTrue(), "first request of key-2 should be allowed")
+	}
+}
+
+func SubTestInvalidLimit(store *ratelimit.Store) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		s := *store
+		_, e := s.Allow(ctx, "invalid", ratelimit.Limit{Rate: 0, Period: time.Second})
+		g.Expect(e).To(HaveOccurred(), "Allow should fail with invalid limit")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+type MockedClock struct {
+	now time.Time
+}
+
+func NewMockedClock() *MockedClock {
+	return &MockedClock{now: time.Now().Truncate(time.Second)}
+}
+
+func (c *MockedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *MockedClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/certs"
 	"github.com/cisco-open/go-lanai/pkg/utils"
 	"github.com/cisco-open/go-lanai/pkg/utils/order"
 	"github.com/cisco-open/go-lanai/pkg/utils/reflectutils"
@@ -47,6 +48,7 @@ type Registrar struct {
 	router           gin.IRouter
 	server           *http.Server
 	port             int
+	certsManager     certs.Manager
 	properties       ServerProperties
 	validator        *Validate
 	requestRewriter  RequestRewriter
@@ -57,6 +59,7 @@ type Registrar struct {
 	errMappings      []ErrorTranslateMapping
 	errTranslators   []ErrorTranslator
 	embedFs          []fs.FS
+	shutdownHooks    []func()
 	initialized      bool
 	warnDuplicateMWs bool
 	warnExclusion    utils.StringSet
@@ -143,6 +146,11 @@ func (r *Registrar) AddEngineOptions(opts ...EngineOptions) error {
 	return nil
 }
 
+// UseCertsManager set certs.Manager for server TLS. Required when TLS is enabled in ServerProperties
+func (r *Registrar) UseCertsManager(certsMgr certs.Manager) {
+	r.certsManager = certsMgr
+}
+
 func (r *Registrar) WarnDuplicateMiddlewares(ifWarn bool, excludedPath ...string) {
 	r.warnDuplicateMWs = ifWarn
 	r.warnExclusion.Add(excludedPath...)
@@ -163,12 +171,15 @@ func (r *Registrar) Run(ctx context.Context) (err error) {
 		addr = ":0"
 	}
 
-	r.server = &http.Server{
-		Addr:           addr,
-		Handler:        r.engine,
-		ReadTimeout:    60 * time.Second,
-		WriteTimeout:   60 * time.Second,
-		MaxHeaderBytes: 1 << 20,
+	tlsConfig, e := newServerTLSConfig(ctx, r.certsManager, &r.properties.TLS)
+	if e != nil {
+		return e
+	}
+	if r.server, e = newHttpServer(addr, r.engine, &r.properties, tlsConfig); e != nil {
+		return e
+	}
+	for _, fn := range r.shutdownHooks {
+		r.server.RegisterOnShutdown(fn)
 	}
 
 	// start the server
@@ -179,12 +190,22 @@ func (r *Registrar) Run(ctx context.Context) (err error) {
 	return e
 }
 
-// Stop closes http server
+// Stop stops http server.
+// When graceful shutdown is enabled, the server stops accepting new connections and waits for in-flight requests
+// to finish, up to the configured drain timeout. Remaining connections are closed afterwards.
 func (r *Registrar) Stop(ctx context.Context) (err error) {
 	if r.server == nil {
 		return fmt.Errorf("attempt to stop server before initialization")
 	}
-	err = r.server.Close()
+	if r.properties.Shutdown.Graceful {
+		err = r.shutdown(ctx)
+	} else {
+		// http.Server.Close doesn't invoke functions registered via RegisterOnShutdown
+		for _, fn := range r.shutdownHooks {
+			fn()
+		}
+		err = r.server.Close()
+	}
 	if err != nil {
 		logger.WithContext(ctx).Warnf("error when stop http server: %v", err)
 	} else {
@@ -225,6 +246,20 @@ func (r *Registrar) MustRegister(items ...interface{}) {
 	}
 }
 
+func (r *Registrar) shutdown(ctx context.Context) error {
+	if timeout := time.Duration(r.properties.Shutdown.DrainTimeout); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	logger.WithContext(ctx).Infof("http server draining in-flight requests...")
+	if e := r.server.Shutdown(ctx); e != nil {
+		logger.WithContext(ctx).Warnf("http server is not drained gracefully: %v", e)
+		return r.server.Close()
+	}
+	return nil
+}
+
 func (r *Registrar) listenAndServe() (*net.TCPAddr, error) {
 	ln, err := net.Listen("tcp", r.server.Addr)
 	if err != nil {
@@ -232,7 +267,11 @@ func (r *Registrar) listenAndServe() (*net.TCPAddr, error) {
 	}
 
 	go func() {
-		_ = r.server.Serve(ln)
+		if r.server.TLSConfig != nil {
+			_ = r.server.ServeTLS(ln, "", "")
+		} else {
+			_ = r.server.Serve(ln)
+		}
 	}()
 	return ln.Addr().(*net.TCPAddr), nil
 }
@@ -249,6 +288,8 @@ func (r *Registrar) register(i interface{}) (err error) {
 		err = r.registerMvcMapping(v)
 	case StaticMapping:
 		err = r.registerStaticMapping(v)
+	case WebSocketMapping:
+		err = r.registerWebSocketMapping(v)
 	case MiddlewareMapping:
 		err = r.registerMiddlewareMapping(v)
 	case SimpleMapping:
@@ -345,6 +386,16 @@ func (r *Registrar) registerMvcMapping(m MvcMapping) error {
 	return r.registerRoutedMapping(m)
 }
 
+func (r *Registrar) registerWebSocketMapping(m WebSocketMapping) error {
+	if method := strings.ToUpper(m.Method()); method != http.MethodGet {
+		return fmt.Errorf("WebSocket mapping [%s] should use %s method, but got %s", m.Name(), http.MethodGet, method)
+	}
+	if hook, ok := m.(WebSocketShutdownHook); ok {
+		r.shutdownHooks = append(r.shutdownHooks, hook.OnShutdown)
+	}
+	return r.registerRoutedMapping(m)
+}
+
 func (r *Registrar) registerStaticMapping(m StaticMapping) error {
 	r.staticMappings = append(r.staticMappings, m)
 	return nil
@@ -497,6 +548,9 @@ func (r *Registrar) installRoutedMappings(ctx context.Context, method, group str
 		switch m.(type) {
 		case MvcMapping:
 			handlerFuncs[i] = r.makeHandlerFuncFromMvcMapping(m.(MvcMapping), errTranslators)
+		case WebSocketMapping:
+			f := NewHttpGinHandlerFunc(m.(WebSocketMapping).UpgradeHandlerFunc())
+			handlerFuncs[i] = r.makeGinConditionalHandlerFunc(f, m.Condition())
 		case SimpleGinMapping:
 			handlerFuncs[i] = r.makeGinConditionalHandlerFunc(m.(SimpleGinMapping).GinHandlerFunc(), m.Condition())
 		case SimpleMapping:
@@ -36,6 +36,7 @@ import (
 //   - map[string]interface{}
 //   - string
 //   - []byte
+//   - a receive-able channel or an iterator function, when used with MappingBuilder.EventStream or MappingBuilder.NDJsonStream
 //
 // e.g.: func(context.Context, request *AnyStructWithTag) (response *AnyStructWithTag, error) {...}
 type EndpointFunc interface{}
@@ -168,6 +169,20 @@ func (b *MappingBuilder) Head(path string) *MappingBuilder {
 	return b.Path(path).Method(http.MethodHead)
 }
 
+// Streaming
+
+// EventStream streams the response as Server-Sent Events using web.SSEResponseEncoder.
+// The EndpointFunc should return a receive-able channel or an iterator function. See web.SSEResponseEncoder
+func (b *MappingBuilder) EventStream(opts ...web.StreamOptions) *MappingBuilder {
+	return b.EncodeResponseFunc(web.SSEResponseEncoder(opts...))
+}
+
+// NDJsonStream streams the response as newline delimited JSON using web.NDJsonResponseEncoder.
+// The EndpointFunc should return a receive-able channel or an iterator function. See web.SSEResponseEncoder
+func (b *MappingBuilder) NDJsonStream(opts ...web.StreamOptions) *MappingBuilder {
+	return b.EncodeResponseFunc(web.NDJsonResponseEncoder(opts...))
+}
+
 // Overrides
 
 func (b *MappingBuilder) DecodeRequestFunc(f web.DecodeRequestFunc) *MappingBuilder {
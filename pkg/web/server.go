@@ -0,0 +1,131 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/certs"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported values of TLSProperties.ClientAuth
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequire          = "require"
+	ClientAuthVerifyIfGiven    = "verify-if-given"
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                         tls.NoClientCert,
+	ClientAuthNone:             tls.NoClientCert,
+	ClientAuthRequest:          tls.RequestClientCert,
+	ClientAuthRequire:          tls.RequireAnyClientCert,
+	ClientAuthVerifyIfGiven:    tls.VerifyClientCertIfGiven,
+	ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
+}
+
+var tlsVersions = map[string]uint16{
+	"":      tls.VersionTLS12,
+	"tls10": tls.VersionTLS10,
+	"tls11": tls.VersionTLS11,
+	"tls12": tls.VersionTLS12,
+	"tls13": tls.VersionTLS13,
+}
+
+// newHttpServer create http.Server based on ServerProperties. tlsConfig is optional
+func newHttpServer(addr string, handler http.Handler, props *ServerProperties, tlsConfig *tls.Config) (*http.Server, error) {
+	h2s := &http2.Server{
+		MaxConcurrentStreams: props.HTTP2.MaxConcurrentStreams,
+		IdleTimeout:          time.Duration(props.IdleTimeout),
+	}
+	if tlsConfig == nil && props.HTTP2.H2C {
+		handler = h2c.NewHandler(handler, h2s)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       time.Duration(props.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(props.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(props.WriteTimeout),
+		IdleTimeout:       time.Duration(props.IdleTimeout),
+		MaxHeaderBytes:    props.MaxHeaderBytes,
+	}
+
+	switch {
+	case tlsConfig == nil:
+	case props.HTTP2.Enabled:
+		if e := http2.ConfigureServer(server, h2s); e != nil {
+			return nil, fmt.Errorf("unable to configure HTTP/2: %v", e)
+		}
+	default:
+		// non-nil empty map disables HTTP/2
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	return server, nil
+}
+
+// newServerTLSConfig create tls.Config for the web server using certificate source from certs.Manager.
+// Returns nil if TLS is not enabled
+func newServerTLSConfig(ctx context.Context, certsMgr certs.Manager, props *TLSProperties) (*tls.Config, error) {
+	if !props.Enabled {
+		return nil, nil
+	}
+	if certsMgr == nil {
+		return nil, errors.New("server TLS is enabled but certificate manager is not available")
+	}
+
+	minVer, ok := tlsVersions[strings.ToLower(props.MinVersion)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS version [%s]", props.MinVersion)
+	}
+	clientAuth, ok := clientAuthTypes[strings.ToLower(props.ClientAuth)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported client-auth [%s]", props.ClientAuth)
+	}
+
+	src, e := certsMgr.Source(ctx, certs.WithSourceProperties(&props.Certs))
+	if e != nil {
+		return nil, fmt.Errorf("unable to load server certificate source: %v", e)
+	}
+	srcConfig, e := src.TLSConfig(ctx)
+	if e != nil {
+		return nil, fmt.Errorf("unable to load server certificate: %v", e)
+	}
+	if srcConfig.GetCertificate == nil && len(srcConfig.Certificates) == 0 {
+		return nil, fmt.Errorf("certificate source [%T] doesn't support server certificate", src)
+	}
+
+	// Note: unlike server certificate, client CAs are not reloaded. See TLSProperties.Certs
+	return &tls.Config{
+		Certificates:   srcConfig.Certificates,
+		GetCertificate: srcConfig.GetCertificate,
+		ClientAuth:     clientAuth,
+		ClientCAs:      srcConfig.RootCAs,
+		MinVersion:     minVer,
+	}, nil
+}
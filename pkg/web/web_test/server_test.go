@@ -0,0 +1,368 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package web_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/certs"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/rest"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/http2"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*************************
+	Test
+ *************************/
+
+func TestServerTLS(t *testing.T) {
+	pki := NewTestPKI(t)
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestServerWithTLS(pki), "ServerWithTLS"),
+		test.GomegaSubTest(SubTestServerWithTLSWithoutHTTP2(pki), "ServerWithTLSWithoutHTTP2"),
+		test.GomegaSubTest(SubTestServerWithMutualTLS(pki), "ServerWithMutualTLS"),
+		test.GomegaSubTest(SubTestServerWithH2C(), "ServerWithH2C"),
+		test.GomegaSubTest(SubTestServerWithoutCertsManager(), "ServerWithoutCertsManager"),
+	)
+}
+
+func TestServerShutdown(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestGracefulShutdown(), "GracefulShutdown"),
+		test.GomegaSubTest(SubTestGracefulShutdownWithTimeout(), "GracefulShutdownWithTimeout"),
+		test.GomegaSubTest(SubTestNonGracefulShutdown(), "NonGracefulShutdown"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestServerWithTLS(pki *TestPKI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := NewTLSServerProperties(web.ClientAuthNone)
+		reg := StartTestServer(ctx, g, props, pki)
+		defer func() { _ = reg.Stop(ctx) }()
+
+		client := NewTLSClient(pki, nil, true)
+		resp := MustGetPeerInfo(g, client, fmt.Sprintf("https://127.0.0.1:%d/test/peer", reg.ServerPort()))
+		g.Expect(resp.ProtoMajor).To(Equal(2), "HTTP/2 should be used")
+		g.Expect(ReadBody(g, resp)).To(Equal(`"no peer certificate"`), "peer certificate should not be available")
+
+		// plain HTTP should not work
+		resp, e := http.Get(fmt.Sprintf("http://127.0.0.1:%d/test/peer", reg.ServerPort()))
+		g.Expect(e).To(Succeed(), "plain HTTP request should not fail")
+		defer func() { _ = resp.Body.Close() }()
+		g.Expect(resp.StatusCode).To(Equal(http.StatusBadRequest), "plain HTTP should be rejected")
+	}
+}
+
+func SubTestServerWithTLSWithoutHTTP2(pki *TestPKI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := NewTLSServerProperties(web.ClientAuthNone)
+		props.HTTP2.Enabled = false
+		reg := StartTestServer(ctx, g, props, pki)
+		defer func() { _ = reg.Stop(ctx) }()
+
+		client := NewTLSClient(pki, nil, true)
+		resp := MustGetPeerInfo(g, client, fmt.Sprintf("https://127.0.0.1:%d/test/peer", reg.ServerPort()))
+		g.Expect(resp.ProtoMajor).To(Equal(1), "HTTP/1.1 should be used")
+	}
+}
+
+func SubTestServerWithMutualTLS(pki *TestPKI) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := NewTLSServerProperties(web.ClientAuthRequireAndVerify)
+		reg := StartTestServer(ctx, g, props, pki)
+		defer func() { _ = reg.Stop(ctx) }()
+		url := fmt.Sprintf("https://127.0.0.1:%d/test/peer", reg.ServerPort())
+
+		// with client cert
+		client := NewTLSClient(pki, &pki.ClientCert, true)
+		resp := MustGetPeerInfo(g, client, url)
+		g.Expect(ReadBody(g, resp)).To(Equal(`"test-client"`), "peer certificate should be available")
+
+		// without client cert
+		client = NewTLSClient(pki, nil, false)
+		_, e := client.Get(url)
+		g.Expect(e).To(HaveOccurred(), "request without client certificate should fail")
+	}
+}
+
+func SubTestServerWithH2C() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := web.NewServerProperties()
+		props.ContextPath = "/test"
+		props.HTTP2.H2C = true
+		reg := StartTestServer(ctx, g, props, nil)
+		defer func() { _ = reg.Stop(ctx) }()
+
+		client := &http.Client{
+			Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, network, addr)
+				},
+			},
+		}
+		resp := MustGetPeerInfo(g, client, fmt.Sprintf("http://127.0.0.1:%d/test/peer", reg.ServerPort()))
+		g.Expect(resp.ProtoMajor).To(Equal(2), "HTTP/2 should be used")
+
+		// HTTP/1.1 should still work
+		resp = MustGetPeerInfo(g, http.DefaultClient, fmt.Sprintf("http://127.0.0.1:%d/test/peer", reg.ServerPort()))
+		g.Expect(resp.ProtoMajor).To(Equal(1), "HTTP/1.1 should be used")
+	}
+}
+
+func SubTestServerWithoutCertsManager() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := NewTLSServerProperties(web.ClientAuthNone)
+		reg := web.NewRegistrar(web.NewEngine(), *props)
+		e := reg.Run(ctx)
+		g.Expect(e).To(HaveOccurred(), "server should not start without certificate manager")
+	}
+}
+
+func SubTestGracefulShutdown() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := web.NewServerProperties()
+		props.ContextPath = "/test"
+		reg := StartSlowServer(ctx, g, props, 500*time.Millisecond)
+		url := fmt.Sprintf("http://127.0.0.1:%d/test/slow", reg.ServerPort())
+
+		respCh := SendAsync(url)
+		time.Sleep(100 * time.Millisecond)
+		e := reg.Stop(ctx)
+		g.Expect(e).To(Succeed(), "stop should not fail")
+
+		result := <-respCh
+		g.Expect(result.err).To(Succeed(), "in-flight request should not fail")
+		g.Expect(result.resp.StatusCode).To(Equal(http.StatusOK), "in-flight request should finish")
+		g.Expect(ReadBody(g, result.resp)).To(Equal(`"done"`), "in-flight request should have correct response")
+
+		// new requests are refused
+		_, e = http.Get(url)
+		g.Expect(e).To(HaveOccurred(), "new request should fail after shutdown")
+	}
+}
+
+func SubTestGracefulShutdownWithTimeout() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := web.NewServerProperties()
+		props.ContextPath = "/test"
+		props.Shutdown.DrainTimeout = utils.Duration(100 * time.Millisecond)
+		reg := StartSlowServer(ctx, g, props, 2*time.Second)
+		url := fmt.Sprintf("http://127.0.0.1:%d/test/slow", reg.ServerPort())
+
+		respCh := SendAsync(url)
+		time.Sleep(100 * time.Millisecond)
+		start := time.Now()
+		_ = reg.Stop(ctx)
+		g.Expect(time.Since(start)).To(BeNumerically("<", time.Second), "stop should not wait longer than drain timeout")
+
+		result := <-respCh
+		g.Expect(result.err).To(HaveOccurred(), "in-flight request should be interrupted after drain timeout")
+	}
+}
+
+func SubTestNonGracefulShutdown() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		props := web.NewServerProperties()
+		props.ContextPath = "/test"
+		props.Shutdown.Graceful = false
+		reg := StartSlowServer(ctx, g, props, 2*time.Second)
+		url := fmt.Sprintf("http://127.0.0.1:%d/test/slow", reg.ServerPort())
+
+		respCh := SendAsync(url)
+		time.Sleep(100 * time.Millisecond)
+		e := reg.Stop(ctx)
+		g.Expect(e).To(Succeed(), "stop should not fail")
+
+		result := <-respCh
+		g.Expect(result.err).To(HaveOccurred(), "in-flight request should be interrupted")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+type asyncResult struct {
+	resp *http.Response
+	err  error
+}
+
+func SendAsync(url string) <-chan asyncResult {
+	ch := make(chan asyncResult, 1)
+	go func() {
+		resp, e := http.Get(url)
+		ch <- asyncResult{resp: resp, err: e}
+	}()
+	return ch
+}
+
+func StartSlowServer(ctx context.Context, g *gomega.WithT, props *web.ServerProperties, delay time.Duration) *web.Registrar {
+	reg := web.NewRegistrar(web.NewEngine(), *props)
+	reg.MustRegister(rest.Get("/slow").EndpointFunc(func(ctx context.Context, _ *http.Request) (interface{}, error) {
+		time.Sleep(delay)
+		return "done", nil
+	}).Build())
+	e := reg.Run(ctx)
+	g.Expect(e).To(Succeed(), "server should start")
+	return reg
+}
+
+func NewTLSServerProperties(clientAuth string) *web.ServerProperties {
+	props := web.NewServerProperties()
+	props.ContextPath = "/test"
+	props.TLS.Enabled = true
+	props.TLS.ClientAuth = clientAuth
+	props.TLS.Certs = certs.SourceProperties{Type: "test"}
+	return props
+}
+
+func StartTestServer(ctx context.Context, g *gomega.WithT, props *web.ServerProperties, pki *TestPKI) *web.Registrar {
+	reg := web.NewRegistrar(web.NewEngine(), *props)
+	if pki != nil {
+		reg.UseCertsManager(pki)
+	}
+	reg.MustRegister(rest.Get("/peer").EndpointFunc(PeerInfoEndpoint).Build())
+	e := reg.Run(ctx)
+	g.Expect(e).To(Succeed(), "server should start")
+	return reg
+}
+
+func PeerInfoEndpoint(ctx context.Context, _ *http.Request) (interface{}, error) {
+	req := web.HttpRequest(ctx)
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+		return "no peer certificate", nil
+	}
+	return req.TLS.VerifiedChains[0][0].Subject.CommonName, nil
+}
+
+func NewTLSClient(pki *TestPKI, clientCert *tls.Certificate, h2 bool) *http.Client {
+	tlsConfig := &tls.Config{
+		RootCAs:    pki.CAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+	transport := &http.Transport{TLSClientConfig: tlsConfig, ForceAttemptHTTP2: h2}
+	return &http.Client{Transport: transport}
+}
+
+func MustGetPeerInfo(g *gomega.WithT, client *http.Client, url string) *http.Response {
+	resp, e := client.Get(url)
+	g.Expect(e).To(Succeed(), "request should not fail")
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "response should have correct status code")
+	return resp
+}
+
+func ReadBody(g *gomega.WithT, resp *http.Response) string {
+	defer func() { _ = resp.Body.Close() }()
+	body, e := io.ReadAll(resp.Body)
+	g.Expect(e).To(Succeed(), "reading response body should not fail")
+	return strings.TrimSpace(string(body))
+}
+
+// TestPKI implements certs.Manager and certs.Source with generated CA, server certificate and client certificate
+type TestPKI struct {
+	CAs        *x509.CertPool
+	ServerCert tls.Certificate
+	ClientCert tls.Certificate
+}
+
+func NewTestPKI(t *testing.T) *TestPKI {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, e := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if e != nil {
+		t.Fatalf("unable to create CA: %v", e)
+	}
+	ca, _ := x509.ParseCertificate(caDer)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage) tls.Certificate {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			DNSNames:     []string{"localhost"},
+		}
+		der, e := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if e != nil {
+			t.Fatalf("unable to create certificate: %v", e)
+		}
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+	return &TestPKI{
+		CAs:        pool,
+		ServerCert: issue(2, "test-server", x509.ExtKeyUsageServerAuth),
+		ClientCert: issue(3, "test-client", x509.ExtKeyUsageClientAuth),
+	}
+}
+
+func (p *TestPKI) Source(_ context.Context, _ ...certs.Options) (certs.Source, error) {
+	return p, nil
+}
+
+func (p *TestPKI) TLSConfig(_ context.Context, _ ...certs.TLSOptions) (*tls.Config, error) {
+	return &tls.Config{
+		GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &p.ServerCert, nil
+		},
+		RootCAs:    p.CAs,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+func (p *TestPKI) Files(_ context.Context) (*certs.CertificateFiles, error) {
+	return nil, fmt.Errorf("not supported")
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package web_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/rest"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*************************
+	Test
+ *************************/
+
+func TestStreamResponse(t *testing.T) {
+	test.RunTest(context.Background(), t,
+		test.GomegaSubTest(SubTestSSEWithChannel(), "SSEWithChannel"),
+		test.GomegaSubTest(SubTestSSEWithIterator(), "SSEWithIterator"),
+		test.GomegaSubTest(SubTestSSEHeartbeat(), "SSEHeartbeat"),
+		test.GomegaSubTest(SubTestSSELineBreaks(), "SSELineBreaks"),
+		test.GomegaSubTest(SubTestNDJsonWithError(), "NDJsonWithError"),
+		test.GomegaSubTest(SubTestStreamClientDisconnect(), "StreamClientDisconnect"),
+		test.GomegaSubTest(SubTestStreamPanic(), "StreamPanic"),
+		test.GomegaSubTest(SubTestStreamInvalidResponse(), "StreamInvalidResponse"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestSSEWithChannel() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").EventStream().
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (<-chan web.StreamEvent, error) {
+				ch := make(chan web.StreamEvent)
+				go func() {
+					defer close(ch)
+					for i := 1; i <= 2; i++ {
+						select {
+						case ch <- web.StreamEvent{ID: fmt.Sprint(i), Event: "count", Data: StreamMessage{Count: i}}:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+				return ch, nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		resp := MustGetStream(g, reg, "/stream")
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "status code should be correct")
+		g.Expect(resp.Header.Get("Content-Type")).To(Equal(web.ContentTypeEventStream), "content type should be correct")
+		g.Expect(resp.Header.Get("Cache-Control")).To(Equal("no-cache"), "cache control should be correct")
+		g.Expect(ReadBody(g, resp)).To(Equal(
+			"id: 1\nevent: count\ndata: {\"count\":1}\n\n"+
+				"id: 2\nevent: count\ndata: {\"count\":2}",
+		), "body should be correct")
+	}
+}
+
+func SubTestSSEWithIterator() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").EventStream().
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (func(yield func(string) bool), error) {
+				return func(yield func(string) bool) {
+					for _, v := range []string{"hello", "multi\nline"} {
+						if !yield(v) {
+							return
+						}
+					}
+				}, nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		resp := MustGetStream(g, reg, "/stream")
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "status code should be correct")
+		g.Expect(ReadBody(g, resp)).To(Equal("data: hello\n\ndata: multi\ndata: line"), "body should be correct")
+	}
+}
+
+func SubTestSSEHeartbeat() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		heartbeat := func(opt *web.StreamOption) {
+			opt.Heartbeat = 20 * time.Millisecond
+		}
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").EventStream(heartbeat).
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (<-chan string, error) {
+				ch := make(chan string, 1)
+				go func() {
+					defer close(ch)
+					time.Sleep(100 * time.Millisecond)
+					ch <- "done"
+				}()
+				return ch, nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		resp := MustGetStream(g, reg, "/stream")
+		body := ReadBody(g, resp)
+		g.Expect(body).To(HavePrefix(":\n\n"), "heartbeat should be sent")
+		g.Expect(body).To(HaveSuffix("data: done"), "data should be sent")
+	}
+}
+
+func SubTestSSELineBreaks() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").EventStream().
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (func(yield func(web.StreamEvent) bool), error) {
+				return func(yield func(web.StreamEvent) bool) {
+					_ = yield(web.StreamEvent{
+						ID:    "1\r\nevent: injected",
+						Event: "count\n\ndata: injected",
+						Data:  "crlf\r\ncr\rlf\nend",
+					})
+				}, nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		resp := MustGetStream(g, reg, "/stream")
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "status code should be correct")
+		g.Expect(ReadBody(g, resp)).To(Equal(
+			"id: 1event: injected\nevent: countdata: injected\n"+
+				"data: crlf\ndata: cr\ndata: lf\ndata: end",
+		), "line breaks should not inject fields and data should be split on all line breaks")
+	}
+}
+
+func SubTestNDJsonWithError() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").NDJsonStream().
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (func(yield func(*StreamMessage, error) bool), error) {
+				return func(yield func(*StreamMessage, error) bool) {
+					if !yield(&StreamMessage{Count: 1}, nil) {
+						return
+					}
+					if !yield(nil, errors.New("oops")) {
+						return
+					}
+					yield(&StreamMessage{Count: 2}, nil)
+				}, nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		resp := MustGetStream(g, reg, "/stream")
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "status code should be correct")
+		g.Expect(resp.Header.Get("Content-Type")).To(Equal(web.ContentTypeNDJson), "content type should be correct")
+		lines := strings.Split(ReadBody(g, resp), "\n")
+		g.Expect(lines).To(HaveLen(2), "stream should end after error")
+		g.Expect(lines[0]).To(Equal(`{"count":1}`), "data line should be correct")
+		g.Expect(lines[1]).To(ContainSubstring(`"message":"oops"`), "error line should be correct")
+	}
+}
+
+func SubTestStreamClientDisconnect() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		stopped := make(chan struct{})
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").EventStream().
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (func(yield func(int) bool), error) {
+				return func(yield func(int) bool) {
+					defer close(stopped)
+					for i := 0; yield(i); i++ {
+						time.Sleep(10 * time.Millisecond)
+					}
+				}, nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		req, e := http.NewRequestWithContext(reqCtx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/test/stream", reg.ServerPort()), nil)
+		g.Expect(e).To(Succeed(), "create request should not fail")
+		resp, e := http.DefaultClient.Do(req)
+		g.Expect(e).To(Succeed(), "request should not fail")
+		defer func() { _ = resp.Body.Close() }()
+
+		// first event should be flushed before the stream ends
+		line, e := bufio.NewReader(resp.Body).ReadString('\n')
+		g.Expect(e).To(Succeed(), "read stream should not fail")
+		g.Expect(line).To(Equal("data: 0\n"), "first event should be received")
+
+		cancel()
+		g.Eventually(stopped).WithTimeout(time.Second).Should(BeClosed(), "iterator should stop when client disconnects")
+	}
+}
+
+func SubTestStreamPanic() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").NDJsonStream().
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (func(yield func(*StreamMessage) bool), error) {
+				return func(yield func(*StreamMessage) bool) {
+					if !yield(&StreamMessage{Count: 1}) {
+						return
+					}
+					panic("oops")
+				}, nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		resp := MustGetStream(g, reg, "/stream")
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK), "status code should be correct")
+		lines := strings.Split(ReadBody(g, resp), "\n")
+		g.Expect(lines).To(HaveLen(2), "stream should end after panic")
+		g.Expect(lines[0]).To(Equal(`{"count":1}`), "data line should be correct")
+		g.Expect(lines[1]).To(ContainSubstring(`oops`), "error line should be correct")
+	}
+}
+
+func SubTestStreamInvalidResponse() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		reg := StartStreamServer(ctx, g, rest.Get("/stream").EventStream().
+			EndpointFunc(func(ctx context.Context, _ *http.Request) (interface{}, error) {
+				return "not a stream", nil
+			}))
+		defer func() { _ = reg.Stop(ctx) }()
+
+		resp := MustGetStream(g, reg, "/stream")
+		g.Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError), "status code should be correct")
+		g.Expect(resp.Header.Get("Content-Type")).To(HavePrefix("application/json"), "error should be encoded as JSON")
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+type StreamMessage struct {
+	Count int `json:"count"`
+}
+
+func StartStreamServer(ctx context.Context, g *gomega.WithT, builder *rest.MappingBuilder) *web.Registrar {
+	props := web.NewServerProperties()
+	props.ContextPath = "/test"
+	reg := web.NewRegistrar(web.NewEngine(), *props)
+	reg.MustRegister(builder.Build())
+	e := reg.Run(ctx)
+	g.Expect(e).To(Succeed(), "server should start")
+	return reg
+}
+
+func MustGetStream(g *gomega.WithT, reg *web.Registrar, path string) *http.Response {
+	resp, e := http.Get(fmt.Sprintf("http://127.0.0.1:%d/test%s", reg.ServerPort(), path))
+	g.Expect(e).To(Succeed(), "request should not fail")
+	return resp
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/internal/mvc"
+	"net/http"
+	"time"
+)
+
+// MessageHandlerFunc is a function with following signature, similar to rest.EndpointFunc
+//   - one or two input parameters with the 1st as context.Context and the 2nd as <request>
+//   - at least two output parameters with the 2nd last as <response> and the last as error
+//
+// where
+// <request>:   a struct or a pointer to a struct, decoded from the JSON payload of the inbound Message.
+// <response>:  any type that can be JSON encoded. It's sent back as payload of a Message with same type and ID. nil means no reply.
+//
+// The context.Context is the connection's context. See ConnFromContext.
+// e.g.: func(ctx context.Context, request *ChatMessage) (response *ChatReceipt, error) {...}
+type MessageHandlerFunc interface{}
+
+// ConnectHookFunc is invoked after the connection is upgraded and before any message is dispatched.
+// Returning error closes the connection
+type ConnectHookFunc func(ctx context.Context, conn *Conn) error
+
+// CloseHookFunc is invoked after the connection is closed
+type CloseHookFunc func(ctx context.Context, conn *Conn)
+
+type Options func(opt *Option)
+type Option struct {
+	// PingInterval interval of "ping" control messages. Zero value disables ping. Default to 30s
+	PingInterval time.Duration
+	// PongTimeout max time to wait for any message (including "pong") from client before the connection is closed.
+	// Zero value disables read timeout. Default to 60s. It should be greater than PingInterval
+	PongTimeout time.Duration
+	// WriteTimeout max time to write a single message. Default to 10s
+	WriteTimeout time.Duration
+	// ReadLimit max size in bytes of inbound messages. Default to 64KiB
+	ReadLimit int64
+	// CheckOrigin returns true if the request Origin header is acceptable.
+	// When nil, only same origin requests or requests without Origin header are accepted.
+	CheckOrigin func(r *http.Request) bool
+	// Subprotocols server's supported protocols in order of preference
+	Subprotocols []string
+	// EnableCompression whether the server should attempt to negotiate per message compression (RFC 7692)
+	EnableCompression bool
+}
+
+// MappingBuilder builds web.WebSocketMapping. The upgrade request is a GET request that goes through the same
+// middlewares as any other mappings (e.g. session or bearer token authentication and access control).
+// After upgrade, each inbound Message is dispatched to the MessageHandlerFunc registered for the Message.Type.
+// Messages of a connection are processed in the order they are received.
+// Example:
+// <code>
+// websocket.Path("/ws/chat").Handle("chat.send", c.Send).OnConnect(c.Join).OnClose(c.Leave).Build()
+// </code>
+type MappingBuilder struct {
+	name      string
+	group     string
+	path      string
+	condition web.RequestMatcher
+	handlers  map[string]MessageHandlerFunc
+	onConnect ConnectHookFunc
+	onClose   CloseHookFunc
+	options   []Options
+}
+
+func New(names ...string) *MappingBuilder {
+	var name string
+	if len(names) > 0 {
+		name = names[0]
+	}
+	return &MappingBuilder{
+		name:     name,
+		handlers: map[string]MessageHandlerFunc{},
+	}
+}
+
+// Path is a convenient constructor
+func Path(path string) *MappingBuilder {
+	return New().Path(path)
+}
+
+/*****************************
+	Public
+******************************/
+
+func (b *MappingBuilder) Name(name string) *MappingBuilder {
+	b.name = name
+	return b
+}
+
+func (b *MappingBuilder) Group(group string) *MappingBuilder {
+	b.group = group
+	return b
+}
+
+func (b *MappingBuilder) Path(path string) *MappingBuilder {
+	b.path = path
+	return b
+}
+
+func (b *MappingBuilder) Condition(condition web.RequestMatcher) *MappingBuilder {
+	b.condition = condition
+	return b
+}
+
+// Handle registers MessageHandlerFunc for given message type. See MessageHandlerFunc for supported signatures
+func (b *MappingBuilder) Handle(msgType string, handlerFunc MessageHandlerFunc) *MappingBuilder {
+	b.handlers[msgType] = handlerFunc
+	return b
+}
+
+func (b *MappingBuilder) OnConnect(fn ConnectHookFunc) *MappingBuilder {
+	b.onConnect = fn
+	return b
+}
+
+func (b *MappingBuilder) OnClose(fn CloseHookFunc) *MappingBuilder {
+	b.onClose = fn
+	return b
+}
+
+func (b *MappingBuilder) WithOptions(opts ...Options) *MappingBuilder {
+	b.options = append(b.options, opts...)
+	return b
+}
+
+func (b *MappingBuilder) Build() web.WebSocketMapping {
+	if err := b.validate(); err != nil {
+		panic(err)
+	}
+	return b.buildMapping()
+}
+
+/*****************************
+	Private
+******************************/
+
+func (b *MappingBuilder) validate() error {
+	if b.path == "" && (b.group == "" || b.group == "/") {
+		return errors.New("empty path")
+	}
+	if len(b.handlers) == 0 && b.onConnect == nil {
+		return errors.New("missing message handlers")
+	}
+	for k := range b.handlers {
+		if k == MessageTypeError {
+			return fmt.Errorf(`message type "%s" is reserved`, MessageTypeError)
+		}
+	}
+	return nil
+}
+
+func (b *MappingBuilder) buildMapping() web.WebSocketMapping {
+	if b.name == "" {
+		b.name = fmt.Sprintf("WS %s%s", b.group, b.path)
+	}
+
+	opt := Option{
+		PingInterval: 30 * time.Second,
+		PongTimeout:  60 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		ReadLimit:    64 * 1024,
+	}
+	for _, fn := range b.options {
+		fn(&opt)
+	}
+
+	handlers := make(map[string]*messageHandler, len(b.handlers))
+	for k, fn := range b.handlers {
+		metadata := mvc.NewFuncMetadata(fn, nil)
+		handlers[k] = &messageHandler{
+			decodeFunc:  mvc.JsonMessageDecoder(metadata),
+			handlerFunc: metadata.HandlerFunc(),
+		}
+	}
+
+	return &wsMapping{
+		name:      b.name,
+		group:     b.group,
+		path:      b.path,
+		condition: b.condition,
+		server: &server{
+			option:    opt,
+			upgrader:  newUpgrader(&opt),
+			handlers:  handlers,
+			onConnect: b.onConnect,
+			onClose:   b.onClose,
+		},
+	}
+}
+
+/*****************************
+	Mapping
+******************************/
+
+// wsMapping implements web.WebSocketMapping
+type wsMapping struct {
+	name      string
+	group     string
+	path      string
+	condition web.RequestMatcher
+	server    *server
+}
+
+func (m *wsMapping) Name() string {
+	return m.name
+}
+
+func (m *wsMapping) Group() string {
+	return m.group
+}
+
+func (m *wsMapping) Path() string {
+	return m.path
+}
+
+func (m *wsMapping) Method() string {
+	return http.MethodGet
+}
+
+func (m *wsMapping) Condition() web.RequestMatcher {
+	return m.condition
+}
+
+func (m *wsMapping) UpgradeHandlerFunc() http.HandlerFunc {
+	return m.server.ServeHTTP
+}
+
+func (m *wsMapping) OnShutdown() {
+	m.server.OnShutdown()
+}
@@ -0,0 +1,145 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MessageTypeError is the type of messages sent when inbound messages cannot be processed
+const MessageTypeError = "error"
+
+var errInternal = web.NewHttpError(http.StatusInternalServerError, errors.New(http.StatusText(http.StatusInternalServerError)))
+
+// Message is the envelope of JSON messages exchanged over the connection, e.g.
+// <code>
+// {"type": "chat.send", "id": "1", "payload": {"text": "hello"}}
+// </code>
+type Message struct {
+	// Type determines which MessageHandlerFunc the inbound message is dispatched to.
+	// Replies have the same type as the inbound message, and errors have MessageTypeError
+	Type string `json:"type"`
+	// ID is optional correlation ID. Replies and errors have the same ID as the inbound message
+	ID string `json:"id,omitempty"`
+	// Payload is decoded into the request of MessageHandlerFunc
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type outboundMessage struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+type connCtxKey struct{}
+
+// Conn is an established WebSocket connection. Conn.Send is safe for concurrent use,
+// so messages can be pushed to the client from any goroutine during the connection's lifetime.
+type Conn struct {
+	ws       *websocket.Conn
+	ctx      utils.MutableContext
+	cancel   context.CancelFunc
+	request  *http.Request
+	writeMtx sync.Mutex
+	timeout  time.Duration
+}
+
+// ConnFromContext returns the Conn associated with the given context.
+// The context passed to MessageHandlerFunc, ConnectHookFunc and CloseHookFunc always carries the Conn
+func ConnFromContext(ctx context.Context) *Conn {
+	conn, _ := ctx.Value(connCtxKey{}).(*Conn)
+	return conn
+}
+
+// Context returns the connection's context. It carries all values of the upgrade request's context,
+// including security.Authentication established by security middlewares, and is cancelled when the connection is closed.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Request returns the upgrade request
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// Subprotocol returns the negotiated subprotocol
+func (c *Conn) Subprotocol() string {
+	return c.ws.Subprotocol()
+}
+
+// Send sends a message of given type to the client. The payload is JSON encoded
+func (c *Conn) Send(msgType string, payload interface{}) error {
+	return c.write(&outboundMessage{Type: msgType, Payload: payload})
+}
+
+// Close sends "close" control message to the client, which terminates the connection
+func (c *Conn) Close() error {
+	return c.close(websocket.CloseNormalClosure, "")
+}
+
+func (c *Conn) write(msg *outboundMessage) error {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	if c.timeout > 0 {
+		_ = c.ws.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	return c.ws.WriteJSON(msg)
+}
+
+func (c *Conn) writeError(id string, err error) error {
+	if !isClientError(err) {
+		logger.WithContext(c.ctx).Debugf("WebSocket message failed with internal error: %v", err)
+		err = errInternal
+	}
+	//nolint:errorlint
+	switch e := err.(type) {
+	case validator.ValidationErrors:
+		err = web.ValidationErrors{ValidationErrors: e}
+	case json.Marshaler:
+	default:
+		err = web.NewHttpError(0, err)
+	}
+	return c.write(&outboundMessage{Type: MessageTypeError, ID: id, Payload: err})
+}
+
+func (c *Conn) close(code int, reason string) error {
+	msg := websocket.FormatCloseMessage(code, reason)
+	return c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+}
+
+// isClientError returns true if the error is caused by the inbound message (status code 4XX) and can be sent as-is.
+// Any other error is replaced by a generic error, so internal details are not leaked to clients.
+func isClientError(err error) bool {
+	//nolint:errorlint
+	switch e := err.(type) {
+	case validator.ValidationErrors:
+		return true
+	case web.StatusCoder:
+		return e.StatusCode() >= http.StatusBadRequest && e.StatusCode() < http.StatusInternalServerError
+	default:
+		return false
+	}
+}
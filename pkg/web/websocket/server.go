@@ -0,0 +1,273 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/log"
+	"github.com/cisco-open/go-lanai/pkg/utils"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var logger = log.New("Web.WebSocket")
+
+// closeGracePeriod is the max time to wait for client's "close" reply during shutdown
+const closeGracePeriod = time.Second
+
+type messageHandler struct {
+	decodeFunc  web.DecodeMessageFunc
+	handlerFunc web.MvcHandlerFunc
+}
+
+// server upgrades HTTP requests and serves WebSocket connections
+type server struct {
+	option    Option
+	upgrader  *websocket.Upgrader
+	handlers  map[string]*messageHandler
+	onConnect ConnectHookFunc
+	onClose   CloseHookFunc
+	connsMtx  sync.Mutex
+	conns     map[*Conn]struct{}
+	stopping  bool
+}
+
+func newUpgrader(opt *Option) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		CheckOrigin:       opt.CheckOrigin,
+		Subprotocols:      opt.Subprotocols,
+		EnableCompression: opt.EnableCompression,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			if gc := web.GinContext(r.Context()); gc != nil {
+				_ = gc.Error(reason)
+			}
+			http.Error(w, http.StatusText(status), status)
+		},
+	}
+}
+
+func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	ws, e := s.upgrader.Upgrade(rw, r, nil)
+	if e != nil {
+		logger.WithContext(r.Context()).Debugf("WebSocket upgrade failed: %v", e)
+		return
+	}
+	conn := s.newConn(r, ws)
+	if !s.track(conn) {
+		_ = conn.close(websocket.CloseGoingAway, "server shutting down")
+		conn.cancel()
+		_ = ws.Close()
+		return
+	}
+	defer func() {
+		s.untrack(conn)
+		conn.cancel()
+		if s.onClose != nil {
+			s.onClose(conn.ctx, conn)
+		}
+		_ = ws.Close()
+	}()
+
+	if s.onConnect != nil {
+		if e := s.onConnect(conn.ctx, conn); e != nil {
+			logger.WithContext(conn.ctx).Debugf("WebSocket connection rejected: %v", e)
+			_ = conn.writeError("", e)
+			_ = conn.close(websocket.ClosePolicyViolation, "connection rejected")
+			return
+		}
+	}
+
+	go s.keepalive(conn)
+	s.readLoop(conn)
+}
+
+// OnShutdown sends "close" control message with code 1001 (Going Away) to all active connections.
+// Upgraded connections are hijacked from http.Server, so they are not closed by the server's graceful shutdown.
+// Connections are given a short period to respond before the underlying network connections are closed.
+func (s *server) OnShutdown() {
+	// connections are closed outside the lock, so writing "close" to slow clients doesn't block track/untrack
+	for _, conn := range s.stop() {
+		if e := conn.close(websocket.CloseGoingAway, "server shutting down"); e != nil {
+			_ = conn.ws.Close()
+			continue
+		}
+		// readLoop returns when the client acknowledges "close" or the deadline is reached
+		_ = conn.ws.SetReadDeadline(time.Now().Add(closeGracePeriod))
+	}
+}
+
+// stop marks the server as shutting down and returns all active connections
+func (s *server) stop() []*Conn {
+	s.connsMtx.Lock()
+	defer s.connsMtx.Unlock()
+	s.stopping = true
+	conns := make([]*Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// track registers the connection as active. Returns false if the server is shutting down
+func (s *server) track(conn *Conn) bool {
+	s.connsMtx.Lock()
+	defer s.connsMtx.Unlock()
+	if s.stopping {
+		return false
+	}
+	if s.conns == nil {
+		s.conns = map[*Conn]struct{}{}
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *server) isStopping() bool {
+	s.connsMtx.Lock()
+	defer s.connsMtx.Unlock()
+	return s.stopping
+}
+
+func (s *server) untrack(conn *Conn) {
+	s.connsMtx.Lock()
+	defer s.connsMtx.Unlock()
+	delete(s.conns, conn)
+}
+
+func (s *server) newConn(r *http.Request, ws *websocket.Conn) *Conn {
+	ws.SetReadLimit(s.option.ReadLimit)
+	cancelCtx, cancel := context.WithCancel(r.Context())
+	conn := &Conn{
+		ws:      ws,
+		cancel:  cancel,
+		request: r,
+		timeout: s.option.WriteTimeout,
+	}
+	conn.ctx = utils.NewMutableContext(cancelCtx)
+	conn.ctx.Set(connCtxKey{}, conn)
+
+	if s.option.PongTimeout > 0 {
+		ws.SetPongHandler(func(string) error {
+			// during shutdown, the read deadline is set by OnShutdown and should not be extended
+			if s.isStopping() {
+				return nil
+			}
+			return ws.SetReadDeadline(time.Now().Add(s.option.PongTimeout))
+		})
+	}
+	return conn
+}
+
+// keepalive sends "ping" periodically until the connection is closed.
+// Note: "pong" is processed by readLoop, which extends read deadline
+func (s *server) keepalive(conn *Conn) {
+	if s.option.PingInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.option.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-conn.ctx.Done():
+			return
+		case <-ticker.C:
+			if e := conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.option.WriteTimeout)); e != nil {
+				logger.WithContext(conn.ctx).Debugf("WebSocket ping failed: %v", e)
+				return
+			}
+		}
+	}
+}
+
+func (s *server) readLoop(conn *Conn) {
+	for {
+		// during shutdown, the read deadline is set by OnShutdown and should not be extended
+		if s.option.PongTimeout > 0 && !s.isStopping() {
+			_ = conn.ws.SetReadDeadline(time.Now().Add(s.option.PongTimeout))
+		}
+		_, data, e := conn.ws.ReadMessage()
+		if e != nil {
+			if websocket.IsUnexpectedCloseError(e, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				logger.WithContext(conn.ctx).Debugf("WebSocket connection closed unexpectedly: %v", e)
+			}
+			return
+		}
+		s.dispatch(conn, data)
+	}
+}
+
+func (s *server) dispatch(conn *Conn, data []byte) {
+	var msg Message
+	if e := json.Unmarshal(data, &msg); e != nil {
+		_ = conn.writeError("", web.NewHttpError(http.StatusBadRequest, fmt.Errorf("invalid message: %v", e)))
+		return
+	}
+	handler, ok := s.handlers[msg.Type]
+	if !ok {
+		_ = conn.writeError(msg.ID, web.NewHttpError(http.StatusBadRequest, fmt.Errorf("unsupported message type [%s]", msg.Type)))
+		return
+	}
+
+	resp, e := s.handle(conn.ctx, handler, msg.Payload)
+	switch {
+	case e != nil:
+		e = conn.writeError(msg.ID, e)
+	case resp != nil:
+		e = conn.write(&outboundMessage{Type: msg.Type, ID: msg.ID, Payload: resp})
+	}
+	if e != nil {
+		logger.WithContext(conn.ctx).Debugf("unable to write WebSocket message: %v", e)
+	}
+}
+
+func (s *server) handle(ctx context.Context, handler *messageHandler, payload []byte) (interface{}, error) {
+	req, e := handler.decodeFunc(ctx, payload)
+	if e != nil {
+		return nil, e
+	}
+	resp, e := handler.handlerFunc(ctx, req)
+	if e != nil {
+		return nil, e
+	}
+	if entity, ok := resp.(web.BodyContainer); ok {
+		resp = entity.Body()
+	}
+	if isNil(resp) {
+		return nil, nil
+	}
+	return resp, nil
+}
+
+// isNil returns true if given value is nil or a typed nil
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
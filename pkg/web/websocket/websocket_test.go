@@ -0,0 +1,352 @@
+// Copyright 2023 Cisco Systems, Inc. and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package websocket_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/cisco-open/go-lanai/pkg/security"
+	"github.com/cisco-open/go-lanai/pkg/security/access"
+	"github.com/cisco-open/go-lanai/pkg/security/errorhandling"
+	"github.com/cisco-open/go-lanai/pkg/web"
+	"github.com/cisco-open/go-lanai/pkg/web/matcher"
+	"github.com/cisco-open/go-lanai/pkg/web/websocket"
+	"github.com/cisco-open/go-lanai/test"
+	"github.com/cisco-open/go-lanai/test/apptest"
+	"github.com/cisco-open/go-lanai/test/sectest"
+	"github.com/cisco-open/go-lanai/test/webtest"
+	ws "github.com/gorilla/websocket"
+	"github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx"
+	"net/http"
+	"testing"
+	"time"
+)
+
+/*************************
+	Setup
+ *************************/
+
+const (
+	TestHeaderUser = "X-Test-User"
+	TestUsername   = "test-user"
+)
+
+type TestController struct {
+	closed   chan string
+	shutdown web.WebSocketMapping
+}
+
+func NewTestController() *TestController {
+	return &TestController{closed: make(chan string, 10)}
+}
+
+func (c *TestController) Mappings() []web.Mapping {
+	c.shutdown = websocket.New("shutdown").Path("/ws/shutdown").Handle("echo", c.Echo).Build()
+	return []web.Mapping{
+		websocket.Path("/ws/test").
+			Handle("echo", c.Echo).
+			Handle("subscribe", c.Subscribe).
+			Handle("fail", c.Fail).
+			OnConnect(c.Connect).
+			OnClose(c.Close).
+			Build(),
+		websocket.New("ping").Path("/ws/ping").
+			Handle("echo", c.Echo).
+			WithOptions(func(opt *websocket.Option) {
+				opt.PingInterval = 20 * time.Millisecond
+			}).
+			Build(),
+		c.shutdown,
+	}
+}
+
+type EchoRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+type EchoResponse struct {
+	Text     string `json:"text"`
+	Username string `json:"username"`
+}
+
+type SubscribeRequest struct {
+	Count int `json:"count"`
+}
+
+func (c *TestController) Echo(ctx context.Context, req *EchoRequest) (*EchoResponse, error) {
+	username, _ := security.GetUsername(security.Get(ctx))
+	return &EchoResponse{Text: req.Text, Username: username}, nil
+}
+
+func (c *TestController) Subscribe(ctx context.Context, req SubscribeRequest) (interface{}, error) {
+	conn := websocket.ConnFromContext(ctx)
+	go func() {
+		for i := 1; i <= req.Count; i++ {
+			if e := conn.Send("tick", map[string]int{"seq": i}); e != nil {
+				return
+			}
+		}
+	}()
+	return nil, nil
+}
+
+func (c *TestController) Fail(_ context.Context, req *EchoRequest) (interface{}, error) {
+	return nil, fmt.Errorf("internal failure: %s", req.Text)
+}
+
+func (c *TestController) Connect(ctx context.Context, conn *websocket.Conn) error {
+	if conn.Request().URL.Query().Get("reject") != "" {
+		return fmt.Errorf("rejected")
+	}
+	return nil
+}
+
+func (c *TestController) Close(ctx context.Context, _ *websocket.Conn) {
+	username, _ := security.GetUsername(security.Get(ctx))
+	c.closed <- username
+}
+
+func RegisterTestSecurity(registrar security.Registrar) {
+	registrar.Register(security.ConfigurerFunc(func(ws security.WebSecurity) {
+		ws.Route(matcher.RouteWithPattern("/ws/**")).
+			With(access.New().Request(matcher.AnyRequest()).Authenticated()).
+			With(errorhandling.New())
+	}))
+}
+
+func MockAuthentication(mc sectest.MWMockContext) security.Authentication {
+	username := mc.Request.Header.Get(TestHeaderUser)
+	if len(username) == 0 {
+		return nil
+	}
+	return TestAuthentication(username)
+}
+
+type TestAuthentication string
+
+func (a TestAuthentication) Principal() interface{} {
+	return string(a)
+}
+
+func (a TestAuthentication) Permissions() security.Permissions {
+	return security.Permissions{}
+}
+
+func (a TestAuthentication) State() security.AuthenticationState {
+	return security.StateAuthenticated
+}
+
+func (a TestAuthentication) Details() interface{} {
+	return map[string]interface{}{}
+}
+
+/*************************
+	Test
+ *************************/
+
+func TestWebSocket(t *testing.T) {
+	controller := NewTestController()
+	test.RunTest(context.Background(), t,
+		apptest.Bootstrap(),
+		webtest.WithRealServer(),
+		sectest.WithMockedMiddleware(sectest.MWCustomMocker(sectest.MWMockFunc(MockAuthentication))),
+		apptest.WithModules(access.Module, errorhandling.Module),
+		apptest.WithFxOptions(
+			fx.Invoke(RegisterTestSecurity),
+			web.FxControllerProviders(func() web.Controller { return controller }),
+		),
+		test.GomegaSubTest(SubTestMessageDispatch(controller), "TestMessageDispatch"),
+		test.GomegaSubTest(SubTestServerPush(), "TestServerPush"),
+		test.GomegaSubTest(SubTestMessageErrors(), "TestMessageErrors"),
+		test.GomegaSubTest(SubTestUnauthenticated(), "TestUnauthenticated"),
+		test.GomegaSubTest(SubTestConnectRejected(), "TestConnectRejected"),
+		test.GomegaSubTest(SubTestPing(), "TestPing"),
+		test.GomegaSubTest(SubTestShutdown(controller), "TestShutdown"),
+	)
+}
+
+/*************************
+	Sub Tests
+ *************************/
+
+func SubTestMessageDispatch(controller *TestController) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		conn := MustDial(ctx, g, "/ws/test")
+
+		WriteMessage(g, conn, `{"type":"echo","id":"1","payload":{"text":"hello"}}`)
+		msg := ReadMessage(g, conn)
+		g.Expect(msg).To(HaveKeyWithValue("type", "echo"), "reply should have correct type")
+		g.Expect(msg).To(HaveKeyWithValue("id", "1"), "reply should have correct ID")
+		g.Expect(msg).To(HaveKeyWithValue("payload", map[string]interface{}{
+			"text": "hello", "username": TestUsername,
+		}), "reply should have correct payload with authentication")
+
+		_ = conn.WriteMessage(ws.CloseMessage, ws.FormatCloseMessage(ws.CloseNormalClosure, ""))
+		_ = conn.Close()
+		g.Eventually(controller.closed).WithTimeout(time.Second).Should(Receive(Equal(TestUsername)), "close hook should be invoked")
+	}
+}
+
+func SubTestServerPush() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		conn := MustDial(ctx, g, "/ws/test")
+		defer func() { _ = conn.Close() }()
+
+		WriteMessage(g, conn, `{"type":"subscribe","payload":{"count":2}}`)
+		for i := 1; i <= 2; i++ {
+			msg := ReadMessage(g, conn)
+			g.Expect(msg).To(HaveKeyWithValue("type", "tick"), "pushed message should have correct type")
+			g.Expect(msg).To(HaveKeyWithValue("payload", HaveKeyWithValue("seq", BeNumerically("==", i))), "pushed message should have correct payload")
+		}
+	}
+}
+
+func SubTestMessageErrors() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		conn := MustDial(ctx, g, "/ws/test")
+		defer func() { _ = conn.Close() }()
+
+		// unsupported type
+		WriteMessage(g, conn, `{"type":"unknown","id":"1"}`)
+		msg := ReadMessage(g, conn)
+		g.Expect(msg).To(HaveKeyWithValue("type", websocket.MessageTypeError), "error should have correct type")
+		g.Expect(msg).To(HaveKeyWithValue("id", "1"), "error should have correct ID")
+		g.Expect(msg).To(HaveKeyWithValue("payload", HaveKeyWithValue("message", ContainSubstring("unknown"))), "client error should have message")
+
+		// validation error
+		WriteMessage(g, conn, `{"type":"echo","id":"2","payload":{}}`)
+		msg = ReadMessage(g, conn)
+		g.Expect(msg).To(HaveKeyWithValue("type", websocket.MessageTypeError), "error should have correct type")
+		g.Expect(msg).To(HaveKeyWithValue("id", "2"), "error should have correct ID")
+		g.Expect(msg).To(HaveKeyWithValue("payload", HaveKeyWithValue("error", http.StatusText(http.StatusBadRequest))), "validation error should be a client error")
+		g.Expect(msg).To(HaveKeyWithValue("payload", HaveKeyWithValue("details", HaveLen(1))), "validation error should have details")
+
+		// internal error
+		WriteMessage(g, conn, `{"type":"fail","id":"4","payload":{"text":"secret"}}`)
+		msg = ReadMessage(g, conn)
+		g.Expect(msg).To(HaveKeyWithValue("type", websocket.MessageTypeError), "error should have correct type")
+		g.Expect(msg).To(HaveKeyWithValue("id", "4"), "error should have correct ID")
+		g.Expect(msg).To(HaveKeyWithValue("payload", HaveKeyWithValue("error", http.StatusText(http.StatusInternalServerError))), "internal error should have correct status")
+		g.Expect(msg).To(HaveKeyWithValue("payload", HaveKeyWithValue("message", Not(ContainSubstring("secret")))), "internal error should not be sent to client")
+
+		// invalid JSON
+		WriteMessage(g, conn, `not json`)
+		msg = ReadMessage(g, conn)
+		g.Expect(msg).To(HaveKeyWithValue("type", websocket.MessageTypeError), "error should have correct type")
+
+		// connection is still usable
+		WriteMessage(g, conn, `{"type":"echo","id":"3","payload":{"text":"still alive"}}`)
+		msg = ReadMessage(g, conn)
+		g.Expect(msg).To(HaveKeyWithValue("id", "3"), "reply should have correct ID")
+	}
+}
+
+func SubTestUnauthenticated() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		header := http.Header{}
+		header.Set("Accept", "application/json")
+		_, resp, e := ws.DefaultDialer.DialContext(ctx, WebSocketURL(ctx, "/ws/test"), header)
+		g.Expect(e).To(HaveOccurred(), "upgrade should fail without authentication")
+		g.Expect(resp).ToNot(BeNil(), "response should be available")
+		g.Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized), "upgrade should be rejected by access control")
+	}
+}
+
+func SubTestConnectRejected() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		conn := MustDial(ctx, g, "/ws/test?reject=true")
+		defer func() { _ = conn.Close() }()
+
+		msg := ReadMessage(g, conn)
+		g.Expect(msg).To(HaveKeyWithValue("type", websocket.MessageTypeError), "error should be sent")
+		_, _, e := conn.ReadMessage()
+		g.Expect(ws.IsCloseError(e, ws.ClosePolicyViolation)).To(BeTrue(), "connection should be closed, but got %v", e)
+	}
+}
+
+func SubTestPing() test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		conn := MustDial(ctx, g, "/ws/ping")
+		defer func() { _ = conn.Close() }()
+
+		pings := make(chan string, 10)
+		conn.SetPingHandler(func(data string) error {
+			pings <- data
+			return conn.WriteControl(ws.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		// control messages are processed while reading
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		_, _, _ = conn.ReadMessage()
+		g.Expect(len(pings)).To(BeNumerically(">", 0), "ping should be received")
+	}
+}
+
+func SubTestShutdown(controller *TestController) test.GomegaSubTestFunc {
+	return func(ctx context.Context, t *testing.T, g *gomega.WithT) {
+		conn := MustDial(ctx, g, "/ws/shutdown")
+		defer func() { _ = conn.Close() }()
+		WriteMessage(g, conn, `{"type":"echo","id":"1","payload":{"text":"hello"}}`)
+		_ = ReadMessage(g, conn)
+
+		hook, ok := controller.shutdown.(web.WebSocketShutdownHook)
+		g.Expect(ok).To(BeTrue(), "mapping should implement WebSocketShutdownHook")
+		hook.OnShutdown()
+
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, e := conn.ReadMessage()
+		g.Expect(ws.IsCloseError(e, ws.CloseGoingAway)).To(BeTrue(), "connection should be closed with 1001, but got %v", e)
+
+		// new connections are closed right away
+		conn2 := MustDial(ctx, g, "/ws/shutdown")
+		defer func() { _ = conn2.Close() }()
+		_ = conn2.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, e = conn2.ReadMessage()
+		g.Expect(ws.IsCloseError(e, ws.CloseGoingAway)).To(BeTrue(), "connection should be closed with 1001, but got %v", e)
+	}
+}
+
+/*************************
+	Helpers
+ *************************/
+
+func WebSocketURL(ctx context.Context, path string) string {
+	return fmt.Sprintf("ws://127.0.0.1:%d%s%s", webtest.CurrentPort(ctx), webtest.CurrentContextPath(ctx), path)
+}
+
+func MustDial(ctx context.Context, g *gomega.WithT, path string) *ws.Conn {
+	header := http.Header{}
+	header.Set(TestHeaderUser, TestUsername)
+	conn, resp, e := ws.DefaultDialer.DialContext(ctx, WebSocketURL(ctx, path), header)
+	g.Expect(e).To(Succeed(), "upgrade should not fail")
+	g.Expect(resp.StatusCode).To(Equal(http.StatusSwitchingProtocols), "upgrade should succeed")
+	return conn
+}
+
+func WriteMessage(g *gomega.WithT, conn *ws.Conn, msg string) {
+	e := conn.WriteMessage(ws.TextMessage, []byte(msg))
+	g.Expect(e).To(Succeed(), "write message should not fail")
+}
+
+func ReadMessage(g *gomega.WithT, conn *ws.Conn) map[string]interface{} {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg map[string]interface{}
+	e := conn.ReadJSON(&msg)
+	g.Expect(e).To(Succeed(), "read message should not fail")
+	return msg
+}
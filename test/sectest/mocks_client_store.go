@@ -143,7 +143,39 @@ func (m MockedClient) AssignedTenantIds() utils.StringSet {
 }
 
 func (m MockedClient) ResourceIDs() utils.StringSet {
-	return utils.NewStringSet()
+	return utils.NewStringSet(m.MockedClientProperties.ResourceIDs...)
+}
+
+func (m MockedClient) TokenEndpointAuthMethod() string {
+	return m.MockedClientProperties.AuthMethod
+}
+
+func (m MockedClient) JwksUri() string {
+	return m.MockedClientProperties.JwksUri
+}
+
+func (m MockedClient) Jwks() string {
+	return m.MockedClientProperties.Jwks
+}
+
+func (m MockedClient) RequirePushedAuthorizationRequests() bool {
+	return m.MockedClientProperties.RequirePar
+}
+
+func (m MockedClient) IdTokenEncryptedResponseAlg() string {
+	return m.MockedClientProperties.IdTokenJweAlg
+}
+
+func (m MockedClient) IdTokenEncryptedResponseEnc() string {
+	return m.MockedClientProperties.IdTokenJweEnc
+}
+
+func (m MockedClient) UserInfoEncryptedResponseAlg() string {
+	return m.MockedClientProperties.UserInfoJweAlg
+}
+
+func (m MockedClient) UserInfoEncryptedResponseEnc() string {
+	return m.MockedClientProperties.UserInfoJweEnc
 }
 
 type MockedClientStore struct {
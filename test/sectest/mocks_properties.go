@@ -93,6 +93,15 @@ type MockedClientProperties struct {
 	ATValidity        utils.Duration            `json:"access-token-validity"`
 	RTValidity        utils.Duration            `json:"refresh-token-validity"`
 	AssignedTenantIds utils.CommaSeparatedSlice `json:"tenants"`
+	ResourceIDs       utils.CommaSeparatedSlice `json:"resource-ids"`
+	AuthMethod        string                    `json:"token-endpoint-auth-method"`
+	JwksUri           string                    `json:"jwks-uri"`
+	Jwks              string                    `json:"jwks"`
+	RequirePar        bool                      `json:"require-pushed-authorization-requests"`
+	IdTokenJweAlg     string                    `json:"id-token-encrypted-response-alg"`
+	IdTokenJweEnc     string                    `json:"id-token-encrypted-response-enc"`
+	UserInfoJweAlg    string                    `json:"userinfo-encrypted-response-alg"`
+	UserInfoJweEnc    string                    `json:"userinfo-encrypted-response-enc"`
 }
 
 type MockedPropertiesAccounts struct {